@@ -0,0 +1,43 @@
+package main
+
+import (
+	"backend_pandhi/pkg/config"
+	"fmt"
+	"os"
+)
+
+const configUsage = `Usage: backend config <command>
+
+Commands:
+  print [--redacted]  show the effective settings as ENV=value lines, with
+                      secrets hidden when --redacted is given; exits 1 after
+                      listing the problems when the configuration is invalid
+`
+
+// runConfig implements `config` and returns the process exit code. It runs
+// before the configuration is validated so it can show what is wrong.
+func runConfig(cfg *config.Config, cfgErr error, args []string) int {
+	if len(args) == 0 || args[0] != "print" {
+		fmt.Fprint(os.Stderr, configUsage)
+		return exitUsage
+	}
+
+	redact := false
+	for _, arg := range args[1:] {
+		if arg != "--redacted" {
+			fmt.Fprint(os.Stderr, configUsage)
+			return exitUsage
+		}
+		redact = true
+	}
+
+	for _, s := range cfg.Settings(redact) {
+		fmt.Printf("%s=%s\n", s.Env, s.Value)
+	}
+
+	if cfgErr != nil {
+		fmt.Fprintln(os.Stderr, cfgErr)
+		return exitFailure
+	}
+	return exitOK
+}
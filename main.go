@@ -1,16 +1,25 @@
 package main
 
 import (
+	"backend_pandhi/pkg/app"
 	"backend_pandhi/pkg/config"
 	"backend_pandhi/pkg/database"
+	"backend_pandhi/pkg/jobs"
+	"backend_pandhi/pkg/logging"
+	"backend_pandhi/pkg/metrics"
+	"backend_pandhi/pkg/middleware"
+	"backend_pandhi/pkg/notifications"
+	"backend_pandhi/pkg/ratings"
 	"backend_pandhi/pkg/routes"
 	"backend_pandhi/pkg/services"
+	"backend_pandhi/pkg/tracing"
 	"context"
-	"log"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
-	"strings"
+	"strconv"
 	"syscall"
 	"time"
 
@@ -18,89 +27,152 @@ import (
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-contrib/sessions/cookie"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 func main() {
 	// Load configuration
-	config.LoadConfig()
+	cfg, cfgErr := config.LoadConfig()
 
-	// Initialize database
-	log.Println("🔌 Initializing database connection...")
-	if err := database.InitDatabase(); err != nil {
-		log.Fatal("Failed to initialize database:", err)
+	// The server runs by default; other commands share its configuration
+	command, args := "serve", os.Args[1:]
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
+	}
+
+	// config reports problems itself; everything else needs a valid one
+	if command == "config" {
+		os.Exit(runConfig(cfg, cfgErr, args))
+	}
+	if cfgErr != nil {
+		fmt.Fprintln(os.Stderr, cfgErr)
+		os.Exit(exitFailure)
 	}
-	defer database.CloseDatabase()
 
-	// Run migrations (optional - comment out in production if using manual migrations)
-	if config.IsDevelopment() {
-		if err := database.AutoMigrate(); err != nil {
-			log.Printf("⚠️ Failed to run migrations: %v", err)
+	// Structured logging (LOG_LEVEL, LOG_FORMAT)
+	if err := logging.Init(cfg); err != nil {
+		logging.Fatal("Failed to initialize logging", "error", err)
+	}
+	slog.Info("Configuration loaded", "environment", cfg.Environment)
+
+	switch command {
+	case "serve":
+		serve(cfg)
+	case "migrate":
+		os.Exit(runMigrate(cfg, args))
+	default:
+		if _, ok := tasks[command]; ok {
+			os.Exit(runTask(cfg, command, args))
 		}
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\nUsage: backend [serve | migrate <command> | config <command> | <maintenance command>]\n\n%s", command, tasksUsage)
+		os.Exit(exitUsage)
 	}
+}
 
-	// Initialize GCP Storage service
-	if err := services.InitGCPStorage(); err != nil {
-		log.Printf("⚠️  Warning: GCP Storage initialization failed: %v", err)
-	} else {
-		log.Println("✅ GCP Storage initialized successfully")
+// serve runs the HTTP server until SIGINT or SIGTERM
+func serve(cfg *config.Config) {
+	// Tracing (OTEL_EXPORTER_OTLP_ENDPOINT); a no-op when no endpoint is set
+	shutdownTracing, err := tracing.Init(context.Background(), cfg)
+	if err != nil {
+		logging.Fatal("Failed to initialize tracing", "error", err)
 	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			slog.Warn("Failed to flush traces", "error", err)
+		}
+	}()
 
-	// Initialize FCM service
-	if err := services.InitFCM(); err != nil {
-		log.Printf("⚠️  Warning: FCM initialization failed: %v", err)
-	} else {
-		log.Println("✅ FCM initialized successfully")
+	// Initialize database
+	slog.Info("Initializing database connection")
+	db, err := database.Open(cfg)
+	if err != nil {
+		logging.Fatal("Failed to initialize database", "error", err)
 	}
+	defer database.Close(db)
 
-	// Initialize Razorpay service
-	if err := services.InitRazorpay(); err != nil {
-		log.Printf("⚠️  Warning: Razorpay initialization failed: %v", err)
-	} else {
-		log.Println("✅ Razorpay initialized successfully")
+	// Apply pending migrations in development, warn about them elsewhere
+	migrateOnStart(context.Background(), cfg, db)
+
+	a := newApp(context.Background(), cfg, db)
+
+	// Initialize rate limiter
+	limiter, err := middleware.NewRateLimiter(a)
+	if err != nil {
+		logging.Fatal("Failed to initialize rate limiter", "error", err)
+	}
+
+	// Send scheduled notifications as they fall due
+	dispatchCtx, stopDispatcher := context.WithCancel(context.Background())
+	defer stopDispatcher()
+	notifications.Start(dispatchCtx, a)
+
+	// Run background jobs. Cancelling stops claiming new ones; the running
+	// ones are drained on shutdown.
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	jobs.Start(jobsCtx, a)
+	if err := ratings.ScheduleRoll(db, a.Clock.Now()); err != nil {
+		slog.Error("Failed to schedule the nightly ratings roll", "error", err)
 	}
 
 	// Set Gin mode based on environment
-	if config.IsProduction() {
+	if cfg.IsProduction() {
 		gin.SetMode(gin.ReleaseMode)
 	} else {
 		gin.SetMode(gin.DebugMode)
 	}
 
-	// Initialize Gin router
-	router := gin.Default()
+	// Initialize Gin router. gin's own access log is replaced by the
+	// structured one below.
+	router := gin.New()
+
+	// Every request gets a trace span and an ID that is echoed back and
+	// attached to its logs
+	router.Use(tracing.Middleware())
+	router.Use(middleware.RequestID())
+	router.Use(middleware.RequestLogger())
+	router.Use(metrics.Middleware())
 
-	// Recovery middleware for panic handling
-	router.Use(gin.Recovery())
+	// Recovery and error middleware render panics and handler errors in the
+	// standard error envelope
+	router.Use(middleware.RecoveryMiddleware())
+	router.Use(middleware.ErrorMiddleware())
 
 	// Session middleware (matching Express.js session config)
-	store := cookie.NewStore([]byte(config.AppConfig.SessionSecret))
+	store := cookie.NewStore([]byte(cfg.SessionSecret))
 	router.Use(sessions.Sessions("session", store))
 
 	// CORS middleware (matching Express.js CORS config)
-	setupCORS(router)
+	setupCORS(router, cfg)
+
+	// Rate limiting (route groups add stricter policies)
+	router.Use(limiter.Limit("default"))
 
 	// JSON body size limit (matching Express.js 10mb limit)
 	router.MaxMultipartMemory = 10 << 20 // 10 MB
 
 	// Routes
-	setupRoutes(router)
+	routes.Setup(router, a, limiter)
 
 	// Start server
 	srv := &http.Server{
-		Addr:    ":" + config.AppConfig.Port,
+		Addr:    ":" + strconv.Itoa(cfg.Port),
 		Handler: router,
 	}
 
 	// Server startup in goroutine
 	go func() {
-		log.Printf("🚀 Server running in %s mode\n", config.AppConfig.Environment)
-		log.Printf("📡 Server listening on http://localhost:%s\n", config.AppConfig.Port)
-		if config.IsProduction() && config.AppConfig.EC2PublicIP != "" {
-			log.Printf("🌐 External access: http://%s:%s\n", config.AppConfig.EC2PublicIP, config.AppConfig.Port)
+		slog.Info("Server listening",
+			"environment", cfg.Environment,
+			"url", fmt.Sprintf("http://localhost:%d", cfg.Port))
+		if cfg.IsProduction() && cfg.EC2PublicIP != "" {
+			slog.Info("External access", "url", fmt.Sprintf("http://%s:%d", cfg.EC2PublicIP, cfg.Port))
 		}
 
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Failed to start server: %v\n", err)
+			logging.Fatal("Failed to start server", "error", err)
 		}
 	}()
 
@@ -109,21 +181,94 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
-	log.Println("🛑 Shutting down server...")
+	slog.Info("Shutting down server")
+	stopDispatcher()
+	stopJobs()
+
+	// Running jobs finish while in-flight requests do
+	drained := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.JobDrainTimeout)
+		defer cancel()
+		drained <- jobs.Default.Drain(ctx)
+	}()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatal("Server forced to shutdown:", err)
+		logging.Fatal("Server forced to shutdown", "error", err)
+	}
+	if err := <-drained; err != nil {
+		slog.Warn("Running jobs were interrupted and requeued", "error", err)
+	}
+
+	slog.Info("Server exited gracefully")
+}
+
+// newApp connects the external services. One that fails to initialize is
+// left unconfigured: its calls return errors and readiness reports it down.
+func newApp(ctx context.Context, cfg *config.Config, db *gorm.DB) *app.App {
+	a := &app.App{
+		Config: cfg,
+		DB:     db,
+		Clock:  app.SystemClock{},
+	}
+
+	// Initialize GCP Storage service
+	if storage, err := services.NewGCPStorage(ctx, cfg); err != nil {
+		slog.Warn("GCP Storage initialization failed", "error", err)
+		a.Storage = &services.GCPStorage{}
+	} else {
+		slog.Info("GCP Storage initialized")
+		a.Storage = storage
+	}
+
+	// Initialize FCM service
+	if push, err := services.NewFCM(ctx, cfg); err != nil {
+		slog.Warn("FCM initialization failed", "error", err)
+		a.Push = &services.FCM{}
+	} else {
+		slog.Info("FCM initialized")
+		a.Push = push
+	}
+
+	// Initialize Razorpay service
+	a.Payments = services.NewRazorpay(cfg)
+	if a.Payments.Ready() {
+		slog.Info("Razorpay initialized")
+	}
+
+	// Initialize mailer
+	if mailer, err := services.NewMailer(cfg); err != nil {
+		slog.Warn("Mailer initialization failed", "error", err)
+	} else {
+		slog.Info("Mailer initialized", "driver", cfg.MailDriver)
+		a.Mailer = mailer
+	}
+
+	// Initialize SMS sender
+	if sms, err := services.NewSMSSender(cfg); err != nil {
+		slog.Warn("SMS initialization failed", "error", err)
+	} else {
+		slog.Info("SMS sender initialized")
+		a.SMS = sms
+	}
+
+	// Initialize Google sign-in
+	if google, err := services.NewGoogleTokenVerifier(cfg); err != nil {
+		slog.Warn("Google sign-in initialization failed", "error", err)
+	} else {
+		slog.Info("Google sign-in initialized")
+		a.Google = google
 	}
 
-	log.Println("✅ Server exited gracefully")
+	return a
 }
 
 // setupCORS configures CORS middleware matching Express.js configuration
-func setupCORS(router *gin.Engine) {
-	isProduction := config.IsProduction()
+func setupCORS(router *gin.Engine, cfg *config.Config) {
+	isProduction := cfg.IsProduction()
 
 	// Default production origins (matching Express.js)
 	defaultProductionOrigins := []string{
@@ -142,9 +287,8 @@ func setupCORS(router *gin.Engine) {
 	var allowOrigins []string
 	if isProduction {
 		// Use environment variable or default list
-		if config.AppConfig.AllowedOrigins != "" {
-			// Parse comma-separated origins
-			allowOrigins = parseOrigins(config.AppConfig.AllowedOrigins)
+		if len(cfg.AllowedOrigins) > 0 {
+			allowOrigins = cfg.AllowedOrigins
 		} else {
 			allowOrigins = defaultProductionOrigins
 		}
@@ -155,8 +299,8 @@ func setupCORS(router *gin.Engine) {
 
 	corsConfig := cors.Config{
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS", "PATCH"},
-		AllowHeaders:     []string{"Content-Type", "Authorization", "X-Requested-With"},
-		ExposeHeaders:    []string{"Content-Range", "X-Content-Range"},
+		AllowHeaders:     []string{"Content-Type", "Authorization", "X-Requested-With", "Idempotency-Key", "X-Request-ID"},
+		ExposeHeaders:    []string{"Content-Range", "X-Content-Range", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy", "Retry-After", "Idempotent-Replayed", "X-Request-ID"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}
@@ -173,55 +317,8 @@ func setupCORS(router *gin.Engine) {
 	router.Use(cors.New(corsConfig))
 
 	if isProduction {
-		log.Printf("🔒 CORS enabled for origins: %v\n", allowOrigins)
+		slog.Info("CORS enabled", "origins", allowOrigins)
 	} else {
-		log.Println("🔓 CORS enabled for all origins (development mode)")
-	}
-}
-
-// parseOrigins splits comma-separated origin string
-func parseOrigins(origins string) []string {
-	// Split by comma and trim spaces
-	parts := strings.Split(origins, ",")
-	result := make([]string, 0, len(parts))
-	for _, part := range parts {
-		trimmed := strings.TrimSpace(part)
-		if trimmed != "" {
-			result = append(result, trimmed)
-		}
-	}
-	return result
-}
-
-// setupRoutes sets up all application routes
-func setupRoutes(router *gin.Engine) {
-	// Root route
-	router.GET("/", func(c *gin.Context) {
-		c.String(http.StatusOK, "UPS Backend Server is running...")
-	})
-
-	// API routes group
-	api := router.Group("/api")
-	{
-		// Register auth routes
-		routes.RegisterAuthRoutes(api)
-
-		// Register customer routes
-		routes.RegisterCustomerRoutes(api)
-
-		// Register staff routes
-		routes.RegisterStaffRoutes(api)
-
-		// Register SuperAdmin routes
-		routes.RegisterSuperAdminRoutes(router)
-
-		// Health check route
-		api.GET("/health", func(c *gin.Context) {
-			c.JSON(http.StatusOK, gin.H{
-				"status":      "ok",
-				"environment": config.AppConfig.Environment,
-				"database":    "connected",
-			})
-		})
+		slog.Info("CORS enabled for all origins (development mode)")
 	}
 }
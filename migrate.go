@@ -0,0 +1,214 @@
+package main
+
+import (
+	"backend_pandhi/pkg/config"
+	"backend_pandhi/pkg/database"
+	"backend_pandhi/pkg/migrations"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Exit codes of the CLI commands
+const (
+	exitOK       = 0
+	exitFailure  = 1
+	exitUsage    = 2
+	exitPending  = 3 // work is waiting: pending migrations, unbalanced wallets
+	exitNotFound = 4 // the named account or record does not exist
+	exitConflict = 5 // already done: account exists, already verified
+)
+
+const migrateUsage = `Usage: backend migrate <command>
+
+Commands:
+  status            list migrations; exits 3 when some are pending
+  up                apply every pending migration
+  down [steps]      roll back the newest applied migrations (default 1)
+  baseline [ver]    record migrations up to ver as applied without running
+                    them, for a database created by the Prisma migrations
+                    (default 1)
+`
+
+// runMigrate implements `migrate` and returns the process exit code
+func runMigrate(cfg *config.Config, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, migrateUsage)
+		return exitUsage
+	}
+	command, args := args[0], args[1:]
+
+	// Optional numeric argument for down and baseline
+	number := func(def int) (int, bool) {
+		if len(args) == 0 {
+			return def, true
+		}
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 1 || len(args) > 1 {
+			return 0, false
+		}
+		return n, true
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	db, err := database.Open(cfg)
+	if err != nil {
+		slog.Error("Failed to initialize database", "error", err)
+		return exitFailure
+	}
+	defer database.Close(db)
+
+	m, err := newMigrator(db)
+	if err != nil {
+		slog.Error("Failed to load migrations", "error", err)
+		return exitFailure
+	}
+
+	switch command {
+	case "status":
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			slog.Error("Failed to read migration status", "error", err)
+			return exitFailure
+		}
+		return printStatus(statuses)
+
+	case "up":
+		if len(args) > 0 {
+			break
+		}
+		applied, err := m.Up(ctx)
+		if err != nil {
+			slog.Error("Migration failed", "error", err)
+			return exitFailure
+		}
+		fmt.Printf("Applied %d migration(s)\n", len(applied))
+		return exitOK
+
+	case "down":
+		steps, ok := number(1)
+		if !ok {
+			break
+		}
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			slog.Error("Failed to read migration status", "error", err)
+			return exitFailure
+		}
+		// Rolling back the baseline drops every table; never in production
+		if cfg.IsProduction() && appliedCount(statuses) <= steps {
+			slog.Error("Refusing to roll back the baseline migration in production")
+			return exitFailure
+		}
+		rolledBack, err := m.Down(ctx, steps)
+		if err != nil {
+			slog.Error("Rollback failed", "error", err)
+			return exitFailure
+		}
+		fmt.Printf("Rolled back %d migration(s)\n", len(rolledBack))
+		return exitOK
+
+	case "baseline":
+		version, ok := number(migrations.BaselineVersion)
+		if !ok {
+			break
+		}
+		recorded, err := m.Baseline(ctx, version)
+		if err != nil {
+			slog.Error("Baseline failed", "error", err)
+			return exitFailure
+		}
+		fmt.Printf("Recorded %d migration(s) as applied\n", len(recorded))
+		return exitOK
+	}
+
+	fmt.Fprint(os.Stderr, migrateUsage)
+	return exitUsage
+}
+
+func newMigrator(db *gorm.DB) (*migrations.Migrator, error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	return migrations.New(sqlDB)
+}
+
+func printStatus(statuses []migrations.Status) int {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATE\tAPPLIED AT")
+
+	code := exitOK
+	for _, s := range statuses {
+		state, appliedAt := "pending", "-"
+		switch {
+		case s.Missing:
+			state = "missing from binary"
+		case s.Modified:
+			state = "modified since applied"
+		case s.Baselined:
+			state = "baselined"
+		case s.Applied:
+			state = "applied"
+		}
+		if s.Applied {
+			appliedAt = s.AppliedAt.Local().Format(time.RFC3339)
+		} else {
+			code = exitPending
+		}
+		fmt.Fprintf(w, "%04d\t%s\t%s\t%s\n", s.Version, s.Name, state, appliedAt)
+	}
+	w.Flush()
+	return code
+}
+
+func appliedCount(statuses []migrations.Status) int {
+	n := 0
+	for _, s := range statuses {
+		if s.Applied {
+			n++
+		}
+	}
+	return n
+}
+
+// migrateOnStart brings a development database up to date when the server
+// starts. Elsewhere `migrate up` is a deploy step, so the server only warns
+// about pending migrations.
+func migrateOnStart(ctx context.Context, cfg *config.Config, db *gorm.DB) {
+	m, err := newMigrator(db)
+	if err != nil {
+		slog.Warn("Failed to load migrations", "error", err)
+		return
+	}
+
+	if cfg.IsDevelopment() {
+		if _, err := m.Up(ctx); err != nil {
+			if errors.Is(err, migrations.ErrNotBaselined) {
+				slog.Warn("Database was created without migrations; run `migrate baseline` (or `migrate baseline 7` for a schema built by the old AutoMigrate)")
+			}
+			slog.Warn("Failed to run migrations", "error", err)
+		}
+		return
+	}
+
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		slog.Warn("Failed to read migration status", "error", err)
+		return
+	}
+	if pending := len(statuses) - appliedCount(statuses); pending > 0 {
+		slog.Warn("Database has pending migrations; run `migrate up`", "pending", pending)
+	}
+}
@@ -0,0 +1,197 @@
+// Package alerts turns negative feedback into internal follow-up tickets.
+//
+// Each outlet has rules on the overall rating, the product category and
+// comment keywords; an outlet without rules uses DefaultRule. New feedback
+// matching a rule opens a ticket linked to the feedback and its order in the
+// transaction that inserts the feedback, and a job pushes the ticket to the
+// outlet's staff on duty. The staff home screen shows the day's tickets as a
+// digest.
+package alerts
+
+import (
+	"backend_pandhi/pkg/models"
+	"backend_pandhi/pkg/moderation"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DefaultRule applies to outlets that have no rules: ratings of 2 or less
+var DefaultRule = models.FeedbackAlertRule{
+	Name:      "Low rating",
+	MaxRating: 2,
+	Priority:  models.PriorityHigh,
+	IsActive:  true,
+}
+
+// Matches reports whether feedback on a product of the given category
+// triggers the rule
+func Matches(rule models.FeedbackAlertRule, f models.Feedback, category models.Category) bool {
+	if !rule.IsActive || f.RatingOverall > rule.MaxRating {
+		return false
+	}
+	if len(rule.Categories) > 0 && !contains(rule.Categories, string(category)) {
+		return false
+	}
+	if len(rule.Keywords) > 0 {
+		if f.Comment == nil {
+			return false
+		}
+		if _, ok := moderation.NewFilter(rule.Keywords).Match(*f.Comment); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// Rules returns the rules of an outlet, or DefaultRule when it has none
+func Rules(db *gorm.DB, outletID int) ([]models.FeedbackAlertRule, error) {
+	var rules []models.FeedbackAlertRule
+	if err := db.Where(`"outletId" = ?`, outletID).Order("id").Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	if len(rules) == 0 {
+		rule := DefaultRule
+		rule.OutletID = outletID
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// Check opens an internal ticket for new feedback when one of the rules of
+// the product's outlet matches, and queues the push to staff. Call it with
+// the transaction that inserts the feedback. It returns the ticket, or nil
+// when no rule matched.
+func Check(tx *gorm.DB, f models.Feedback, product models.Product) (*models.Ticket, error) {
+	rules, err := Rules(tx, product.OutletID)
+	if err != nil {
+		return nil, err
+	}
+	var rule *models.FeedbackAlertRule
+	for i := range rules {
+		if Matches(rules[i], f, product.Category) {
+			rule = &rules[i]
+			break
+		}
+	}
+	if rule == nil {
+		return nil, nil
+	}
+
+	var customer models.CustomerDetails
+	if err := tx.Where(`"userId" = ?`, f.UserID).First(&customer).Error; err != nil {
+		return nil, fmt.Errorf("customer of feedback %d: %w", f.ID, err)
+	}
+
+	ticket := models.Ticket{
+		CustomerID:  customer.ID,
+		Title:       rule.Name + ": " + product.Name,
+		Description: describe(*rule, f, product),
+		Priority:    rule.Priority,
+		Status:      models.TicketStatusOpen,
+		Internal:    true,
+		FeedbackID:  &f.ID,
+		OrderID:     &f.OrderID,
+	}
+	// The unique feedback index makes a repeated check a no-op
+	result := tx.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "feedbackId"}}, DoNothing: true}).Create(&ticket)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+
+	if err := NotifyJob.Enqueue(tx, NotifyRequest{TicketID: ticket.ID, OutletID: product.OutletID}, jobOptions(ticket.ID)); err != nil {
+		return nil, err
+	}
+	return &ticket, nil
+}
+
+func describe(rule models.FeedbackAlertRule, f models.Feedback, product models.Product) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s rated %g/5 (taste %g, quality %g, quantity %g) in order #%d. Rule: %s.",
+		product.Name, f.RatingOverall, f.RatingTaste, f.RatingQuality, f.RatingQuantity, f.OrderID, rule.Name)
+	if f.Comment != nil && *f.Comment != "" {
+		fmt.Fprintf(&b, " Comment: %q", *f.Comment)
+	}
+	return b.String()
+}
+
+// DigestItem is one negative feedback in the daily digest
+type DigestItem struct {
+	TicketID      int                 `json:"ticketId"`
+	TicketStatus  models.TicketStatus `json:"ticketStatus"`
+	Priority      models.Priority     `json:"priority"`
+	FeedbackID    int                 `json:"feedbackId"`
+	OrderID       int                 `json:"orderId"`
+	ProductID     int                 `json:"productId"`
+	ProductName   string              `json:"productName"`
+	RatingOverall float64             `json:"ratingOverall"`
+	Comment       *string             `json:"comment"`
+	CreatedAt     time.Time           `json:"createdAt"`
+}
+
+// Digest summarises the negative feedback an outlet received on one day
+type Digest struct {
+	Date          string       `json:"date"`
+	Count         int          `json:"count"`
+	Open          int          `json:"open"`
+	AverageRating float64      `json:"averageRating"`
+	Items         []DigestItem `json:"items"`
+}
+
+// DailyDigest returns the feedback that opened tickets at an outlet since
+// the start of the day of now, newest first
+func DailyDigest(db *gorm.DB, outletID int, now time.Time) (Digest, error) {
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	digest := Digest{Date: start.Format("2006-01-02"), Items: []DigestItem{}}
+
+	var tickets []models.Ticket
+	if err := db.Joins(`JOIN "Feedback" ON "Feedback".id = "Ticket"."feedbackId"`).
+		Joins(`JOIN "Product" ON "Product".id = "Feedback"."productId"`).
+		Where(`"Ticket".internal AND "Product"."outletId" = ? AND "Ticket"."createdAt" >= ?`, outletID, start).
+		Order(`"Ticket"."createdAt" DESC`).
+		Preload("Feedback.Product").
+		Find(&tickets).Error; err != nil {
+		return digest, err
+	}
+
+	total := 0.0
+	for _, t := range tickets {
+		f := t.Feedback
+		digest.Items = append(digest.Items, DigestItem{
+			TicketID:      t.ID,
+			TicketStatus:  t.Status,
+			Priority:      t.Priority,
+			FeedbackID:    f.ID,
+			OrderID:       f.OrderID,
+			ProductID:     f.ProductID,
+			ProductName:   f.Product.Name,
+			RatingOverall: f.RatingOverall,
+			Comment:       f.Comment,
+			CreatedAt:     t.CreatedAt,
+		})
+		total += f.RatingOverall
+		if t.Status != models.TicketStatusClosed {
+			digest.Open++
+		}
+	}
+	digest.Count = len(digest.Items)
+	if digest.Count > 0 {
+		digest.AverageRating = total / float64(digest.Count)
+	}
+	return digest, nil
+}
@@ -0,0 +1,99 @@
+package alerts_test
+
+import (
+	"backend_pandhi/pkg/alerts"
+	"backend_pandhi/pkg/models"
+	"backend_pandhi/pkg/testenv"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(testenv.Main(m))
+}
+
+func TestMatches(t *testing.T) {
+	comment := func(s string) *string { return &s }
+	rule := models.FeedbackAlertRule{
+		Name:       "Cold desserts",
+		MaxRating:  3,
+		Categories: models.StringList{"Desserts"},
+		Keywords:   models.StringList{"cold", "stale"},
+		IsActive:   true,
+	}
+	inactive := rule
+	inactive.IsActive = false
+
+	for _, tc := range []struct {
+		name     string
+		rule     models.FeedbackAlertRule
+		feedback models.Feedback
+		category models.Category
+		want     bool
+	}{
+		{"default rule on low rating", alerts.DefaultRule, models.Feedback{RatingOverall: 2}, models.CategoryMeals, true},
+		{"default rule on fair rating", alerts.DefaultRule, models.Feedback{RatingOverall: 3}, models.CategoryMeals, false},
+		{"all conditions", rule, models.Feedback{RatingOverall: 3, Comment: comment("Arrived COLD")}, models.CategoryDesserts, true},
+		{"other category", rule, models.Feedback{RatingOverall: 1, Comment: comment("cold")}, models.CategoryMeals, false},
+		{"no keyword", rule, models.Feedback{RatingOverall: 1, Comment: comment("too sweet")}, models.CategoryDesserts, false},
+		{"no comment", rule, models.Feedback{RatingOverall: 1}, models.CategoryDesserts, false},
+		{"inactive", inactive, models.Feedback{RatingOverall: 1, Comment: comment("stale")}, models.CategoryDesserts, false},
+	} {
+		if got := alerts.Matches(tc.rule, tc.feedback, tc.category); got != tc.want {
+			t.Errorf("%s: Matches = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestCheckOpensOneTicketAndDigestsIt(t *testing.T) {
+	env := testenv.New(t)
+	outlet := env.Outlet(t)
+	customer := env.Customer(t, outlet, 0)
+	product := env.Product(t, outlet, 50, 10)
+
+	order := models.Order{
+		CustomerID:    &customer.ID,
+		OutletID:      outlet.ID,
+		TotalAmount:   50,
+		PaymentMethod: models.PaymentMethodWallet,
+		Status:        "DELIVERED",
+		Type:          models.OrderTypeApp,
+	}
+	if err := env.DB.Create(&order).Error; err != nil {
+		t.Fatal(err)
+	}
+	feedback := []models.Feedback{
+		{RatingOverall: 5, RatingTaste: 5, RatingQuality: 5, RatingQuantity: 5},
+		{RatingOverall: 1, RatingTaste: 1, RatingQuality: 2, RatingQuantity: 2},
+	}
+	for i := range feedback {
+		f := &feedback[i]
+		f.UserID, f.OrderID, f.ProductID = customer.ID, order.ID, product.ID
+		if err := env.DB.Create(f).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if ticket, err := alerts.Check(env.DB, feedback[0], product); err != nil || ticket != nil {
+		t.Errorf("Check of a 5-star feedback = %v, %v, want no ticket", ticket, err)
+	}
+	ticket, err := alerts.Check(env.DB, feedback[1], product)
+	if err != nil || ticket == nil {
+		t.Fatalf("Check of a 1-star feedback = %v, %v, want a ticket", ticket, err)
+	}
+	if !ticket.Internal || *ticket.FeedbackID != feedback[1].ID || *ticket.OrderID != order.ID {
+		t.Errorf("ticket = %+v, want an internal ticket linked to feedback %d and order %d", ticket, feedback[1].ID, order.ID)
+	}
+	if again, err := alerts.Check(env.DB, feedback[1], product); err != nil || again != nil {
+		t.Errorf("second Check = %v, %v, want no new ticket", again, err)
+	}
+
+	digest, err := alerts.DailyDigest(env.DB, outlet.ID, time.Now())
+	if err != nil {
+		t.Fatalf("DailyDigest: %v", err)
+	}
+	if digest.Count != 1 || digest.Open != 1 || digest.AverageRating != 1 || digest.Items[0].TicketID != ticket.ID {
+		t.Errorf("digest = %+v, want the one ticket", digest)
+	}
+}
@@ -0,0 +1,75 @@
+package alerts
+
+import (
+	"backend_pandhi/pkg/app"
+	"backend_pandhi/pkg/jobs"
+	"backend_pandhi/pkg/models"
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"gorm.io/gorm"
+)
+
+// NotifyRequest is the payload of a notify job
+type NotifyRequest struct {
+	TicketID int `json:"ticketId"`
+	OutletID int `json:"outletId"`
+}
+
+// NotifyJob pushes a new alert ticket to the outlet's staff on duty, or to
+// all of its staff when nobody is on duty
+var NotifyJob = jobs.Define("alerts.notify", func(ctx context.Context, a *app.App, req NotifyRequest) error {
+	if !a.Push.Ready() {
+		slog.WarnContext(ctx, "Push notifications are not configured; feedback alert not sent", "ticket_id", req.TicketID)
+		return nil
+	}
+	db := a.DB.WithContext(ctx)
+
+	var ticket models.Ticket
+	if err := db.First(&ticket, req.TicketID).Error; err != nil {
+		return err
+	}
+
+	tokens, err := staffTokens(db, req.OutletID, true)
+	if err == nil && len(tokens) == 0 {
+		tokens, err = staffTokens(db, req.OutletID, false)
+	}
+	if err != nil || len(tokens) == 0 {
+		return err
+	}
+
+	data := map[string]string{
+		"type":     "feedback_alert",
+		"ticketId": strconv.Itoa(ticket.ID),
+		"outletId": strconv.Itoa(req.OutletID),
+	}
+	if ticket.FeedbackID != nil {
+		data["feedbackId"] = strconv.Itoa(*ticket.FeedbackID)
+	}
+	if ticket.OrderID != nil {
+		data["orderId"] = strconv.Itoa(*ticket.OrderID)
+	}
+	_, err = a.Push.SendBulk(ctx, tokens, ticket.Title, ticket.Description, data)
+	return err
+}, jobs.Policy{})
+
+func jobOptions(ticketID int) jobs.Options {
+	return jobs.Options{UniqueKey: fmt.Sprintf("%s:%d", NotifyJob.Name, ticketID)}
+}
+
+// staffTokens returns the active device tokens of an outlet's staff,
+// narrowed to those on duty when onDuty is set
+func staffTokens(db *gorm.DB, outletID int, onDuty bool) ([]string, error) {
+	query := db.Model(&models.UserDeviceToken{}).
+		Joins(`JOIN "User" ON "User".id = "UserDeviceToken"."userId"`).
+		Joins(`JOIN "StaffDetails" ON "StaffDetails"."userId" = "User".id`).
+		Where(`"User"."outletId" = ? AND "User".role = ? AND "UserDeviceToken"."isActive"`, outletID, models.RoleStaff)
+	if onDuty {
+		query = query.Where(`"StaffDetails"."isOnDuty"`)
+	}
+	var tokens []string
+	err := query.Pluck(`"UserDeviceToken"."deviceToken"`, &tokens).Error
+	return tokens, err
+}
@@ -0,0 +1,42 @@
+// Package app holds the dependencies shared by the HTTP handlers, the
+// background workers and the command line tools.
+package app
+
+import (
+	"backend_pandhi/pkg/config"
+	"backend_pandhi/pkg/services"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Clock tells the time. Handlers read it instead of time.Now so tests can
+// pin the date.
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock is the wall clock
+type SystemClock struct{}
+
+// Now returns the current local time
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// App is the dependency container built once at startup. Nothing in it is
+// replaced while the server runs.
+type App struct {
+	Config *config.Config
+	DB     *gorm.DB
+	Clock  Clock
+
+	// External services
+	Payments services.Payments
+	Push     services.Push
+	Storage  services.Storage
+	Mailer   services.Mailer
+	SMS      services.SMSSender
+	// Google is nil when Google sign-in is not configured
+	Google *services.GoogleTokenVerifier
+}
@@ -0,0 +1,188 @@
+// Package apperror defines the application's typed errors and renders them in
+// the single JSON error envelope used by every endpoint:
+//
+//	{"success": false, "code": "NOT_FOUND", "message": "Product not found", "fields": [...]}
+//
+// "fields" is present only for validation errors. Extra details attached with
+// With are merged into the top level of the envelope.
+package apperror
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Error codes
+const (
+	CodeBadRequest       = "BAD_REQUEST"
+	CodeValidation       = "VALIDATION_FAILED"
+	CodeUnauthorized     = "UNAUTHORIZED"
+	CodeForbidden        = "FORBIDDEN"
+	CodeNotFound         = "NOT_FOUND"
+	CodeConflict         = "CONFLICT"
+	CodeUnprocessable    = "UNPROCESSABLE"
+	CodeTooManyRequests  = "TOO_MANY_REQUESTS"
+	CodeInternal         = "INTERNAL"
+	CodeBadGateway       = "UPSTREAM_FAILED"
+	CodeUnavailable      = "UNAVAILABLE"
+	defaultInternalError = "Internal server error"
+)
+
+// FieldError describes one invalid input field
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error is an application error with everything needed to render a response.
+// Cause is logged but never sent to the client.
+type Error struct {
+	Status  int
+	Code    string
+	Message string
+	Fields  []FieldError
+	Details map[string]interface{}
+	Cause   error
+}
+
+func (e *Error) Error() string {
+	if e.Cause != nil {
+		return e.Message + ": " + e.Cause.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Cause
+}
+
+// With attaches an extra top-level field to the rendered envelope
+func (e *Error) With(key string, value interface{}) *Error {
+	if e.Details == nil {
+		e.Details = make(map[string]interface{})
+	}
+	e.Details[key] = value
+	return e
+}
+
+// WithCode overrides the error code
+func (e *Error) WithCode(code string) *Error {
+	e.Code = code
+	return e
+}
+
+// Wrap records the underlying error for logging
+func (e *Error) Wrap(cause error) *Error {
+	e.Cause = cause
+	return e
+}
+
+// New creates an error with an explicit status and code
+func New(status int, code, message string) *Error {
+	return &Error{Status: status, Code: code, Message: message}
+}
+
+// BadRequest is a 400 for malformed or unacceptable input
+func BadRequest(message string) *Error {
+	return New(http.StatusBadRequest, CodeBadRequest, message)
+}
+
+// Validation is a 400 listing the offending fields
+func Validation(message string, fields ...FieldError) *Error {
+	e := New(http.StatusBadRequest, CodeValidation, message)
+	e.Fields = fields
+	return e
+}
+
+// Unauthorized is a 401
+func Unauthorized(message string) *Error {
+	return New(http.StatusUnauthorized, CodeUnauthorized, message)
+}
+
+// Forbidden is a 403
+func Forbidden(message string) *Error {
+	return New(http.StatusForbidden, CodeForbidden, message)
+}
+
+// NotFound is a 404
+func NotFound(message string) *Error {
+	return New(http.StatusNotFound, CodeNotFound, message)
+}
+
+// Conflict is a 409
+func Conflict(message string) *Error {
+	return New(http.StatusConflict, CodeConflict, message)
+}
+
+// Unprocessable is a 422
+func Unprocessable(message string) *Error {
+	return New(http.StatusUnprocessableEntity, CodeUnprocessable, message)
+}
+
+// TooManyRequests is a 429
+func TooManyRequests(message string) *Error {
+	return New(http.StatusTooManyRequests, CodeTooManyRequests, message)
+}
+
+// Internal is a 500. The cause is logged; clients only see message, which
+// defaults to "Internal server error".
+func Internal(message string, cause error) *Error {
+	if message == "" {
+		message = defaultInternalError
+	}
+	return New(http.StatusInternalServerError, CodeInternal, message).Wrap(cause)
+}
+
+// BadGateway is a 502 for failures of an upstream service
+func BadGateway(message string) *Error {
+	return New(http.StatusBadGateway, CodeBadGateway, message)
+}
+
+// Unavailable is a 503
+func Unavailable(message string) *Error {
+	return New(http.StatusServiceUnavailable, CodeUnavailable, message)
+}
+
+// From converts any error to an *Error. Unknown errors become an opaque 500 so
+// database and library messages never reach clients.
+func From(err error) *Error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	return Internal("", err)
+}
+
+// Abort records err on the context, writes the error envelope and stops the
+// handler chain
+func Abort(c *gin.Context, err error) {
+	_ = c.Error(err)
+	Render(c, err)
+	c.Abort()
+}
+
+// Render writes the error envelope for err
+func Render(c *gin.Context, err error) {
+	appErr := From(err)
+
+	if appErr.Status >= http.StatusInternalServerError && appErr.Cause != nil {
+		slog.ErrorContext(c.Request.Context(), "Request failed", "method", c.Request.Method, "path", c.Request.URL.Path, "error", appErr)
+	}
+
+	body := gin.H{
+		"success": false,
+		"code":    appErr.Code,
+		"message": appErr.Message,
+	}
+	if len(appErr.Fields) > 0 {
+		body["fields"] = appErr.Fields
+	}
+	for k, v := range appErr.Details {
+		body[k] = v
+	}
+
+	c.JSON(appErr.Status, body)
+}
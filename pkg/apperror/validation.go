@@ -0,0 +1,281 @@
+package apperror
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+func init() {
+	// Report validation failures by the JSON/form name clients actually send
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(f reflect.StructField) string {
+			for _, tag := range []string{"json", "form", "uri"} {
+				name := strings.Split(f.Tag.Get(tag), ",")[0]
+				if name == "-" {
+					return ""
+				}
+				if name != "" {
+					return name
+				}
+			}
+			return f.Name
+		})
+	}
+}
+
+// Binding converts an error from c.ShouldBind* into a 400. Validation failures
+// are reported per field; malformed bodies get message as-is.
+func Binding(err error, message string) *Error {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		fields := make([]FieldError, 0, len(validationErrs))
+		for _, fe := range validationErrs {
+			fields = append(fields, FieldError{Field: fieldPath(fe), Message: fieldMessage(fe)})
+		}
+		return Validation(message, fields...).Wrap(err)
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		field := typeErr.Field
+		if field == "" {
+			field = "body"
+		}
+		return Validation(message, FieldError{
+			Field:   field,
+			Message: fmt.Sprintf("must be a %s", jsonTypeName(typeErr.Type)),
+		}).Wrap(err)
+	}
+
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return Validation(message, FieldError{Field: "body", Message: "must be valid JSON"}).Wrap(err)
+	}
+
+	return BadRequest(message).Wrap(err)
+}
+
+// fieldPath drops the top-level struct name from the validator namespace,
+// e.g. "CreateOrderRequest.items[0].productId" -> "items[0].productId".
+// Anonymous request structs have no type name segment to drop.
+func fieldPath(fe validator.FieldError) string {
+	ns := fe.Namespace()
+	typeName, _, _ := strings.Cut(fe.StructNamespace(), ".")
+	if rest, ok := strings.CutPrefix(ns, typeName+"."); ok {
+		return rest
+	}
+	return ns
+}
+
+func fieldMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "min":
+		if isLengthKind(fe.Kind()) {
+			return fmt.Sprintf("must contain at least %s items", fe.Param())
+		}
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be at least %s characters", fe.Param())
+		}
+		return fmt.Sprintf("must be at least %s", fe.Param())
+	case "max":
+		if isLengthKind(fe.Kind()) {
+			return fmt.Sprintf("must contain at most %s items", fe.Param())
+		}
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be at most %s characters", fe.Param())
+		}
+		return fmt.Sprintf("must be at most %s", fe.Param())
+	case "len":
+		return fmt.Sprintf("must have length %s", fe.Param())
+	case "gt":
+		return fmt.Sprintf("must be greater than %s", fe.Param())
+	case "gte":
+		return fmt.Sprintf("must be at least %s", fe.Param())
+	case "lt":
+		return fmt.Sprintf("must be less than %s", fe.Param())
+	case "lte":
+		return fmt.Sprintf("must be at most %s", fe.Param())
+	case "oneof":
+		return fmt.Sprintf("must be one of: %s", strings.ReplaceAll(fe.Param(), " ", ", "))
+	case "dive":
+		return "is invalid"
+	default:
+		return fmt.Sprintf("failed %q validation", fe.Tag())
+	}
+}
+
+func isLengthKind(k reflect.Kind) bool {
+	return k == reflect.Slice || k == reflect.Array || k == reflect.Map
+}
+
+func jsonTypeName(t reflect.Type) string {
+	if t == nil {
+		return "valid value"
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "whole number"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.String:
+		return "string"
+	case reflect.Slice, reflect.Array:
+		return "list"
+	default:
+		return "object"
+	}
+}
+
+// Parser collects failures while reading path and query parameters so one
+// response can report every bad field. Methods return the zero value for
+// invalid input; check Err once all values are read.
+//
+//	p := apperror.NewParser()
+//	outletID := p.Int("outletId", c.Param("outletId"))
+//	from := p.OptionalDate("from", c.Query("from"))
+//	if err := p.Err("Invalid query parameters"); err != nil { ... }
+type Parser struct {
+	fields []FieldError
+}
+
+// NewParser creates an empty Parser
+func NewParser() *Parser {
+	return &Parser{}
+}
+
+// Fail records a field problem found by the caller's own checks
+func (p *Parser) Fail(field, message string) {
+	p.fields = append(p.fields, FieldError{Field: field, Message: message})
+}
+
+// Int parses a required integer
+func (p *Parser) Int(field, value string) int {
+	if value == "" {
+		p.Fail(field, "is required")
+		return 0
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		p.Fail(field, "must be a whole number")
+		return 0
+	}
+	return n
+}
+
+// OptionalInt parses an integer, returning nil when value is empty
+func (p *Parser) OptionalInt(field, value string) *int {
+	if value == "" {
+		return nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		p.Fail(field, "must be a whole number")
+		return nil
+	}
+	return &n
+}
+
+// Float parses a required number
+func (p *Parser) Float(field, value string) float64 {
+	if value == "" {
+		p.Fail(field, "is required")
+		return 0
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		p.Fail(field, "must be a number")
+		return 0
+	}
+	return f
+}
+
+// Bool parses an optional boolean, returning def when value is empty
+func (p *Parser) Bool(field, value string, def bool) bool {
+	if value == "" {
+		return def
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		p.Fail(field, "must be true or false")
+		return def
+	}
+	return b
+}
+
+// Date parses a required YYYY-MM-DD date
+func (p *Parser) Date(field, value string) time.Time {
+	if value == "" {
+		p.Fail(field, "is required")
+		return time.Time{}
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		p.Fail(field, "must be a date in YYYY-MM-DD format")
+		return time.Time{}
+	}
+	return t
+}
+
+// OptionalDate parses a YYYY-MM-DD date, returning nil when value is empty
+func (p *Parser) OptionalDate(field, value string) *time.Time {
+	if value == "" {
+		return nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		p.Fail(field, "must be a date in YYYY-MM-DD format")
+		return nil
+	}
+	return &t
+}
+
+// Time parses a required value in the given layout
+func (p *Parser) Time(field, layout, value string) time.Time {
+	if value == "" {
+		p.Fail(field, "is required")
+		return time.Time{}
+	}
+	t, err := time.Parse(layout, value)
+	if err != nil {
+		p.Fail(field, fmt.Sprintf("must match format %s", layout))
+		return time.Time{}
+	}
+	return t
+}
+
+// Err returns a validation error listing every recorded problem, or nil
+func (p *Parser) Err(message string) *Error {
+	if len(p.fields) == 0 {
+		return nil
+	}
+	return Validation(message, p.fields...)
+}
+
+// ParamInt reads a required integer path parameter, aborting with a 400 and
+// returning false when it is missing or malformed
+func ParamInt(c *gin.Context, name string) (int, bool) {
+	p := NewParser()
+	n := p.Int(name, c.Param(name))
+	if err := p.Err("Invalid " + name); err != nil {
+		Abort(c, err)
+		return 0, false
+	}
+	return n, true
+}
@@ -0,0 +1,172 @@
+// Package audit writes AuditEvent rows for admin and staff actions.
+package audit
+
+import (
+	"backend_pandhi/pkg/models"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Actions recorded in the audit log
+const (
+	ActionStockAdd               = "stock.add"
+	ActionStockDeduct            = "stock.deduct"
+	ActionWalletRecharge         = "wallet.recharge"
+	ActionWalletReconcile        = "wallet.reconcile"
+	ActionProductUpdate          = "product.update"
+	ActionStaffPermissionsUpdate = "staff.permissions.update"
+	ActionAdminPermissionsUpdate = "admin.permissions.update"
+	ActionCouponDelete           = "coupon.delete"
+	ActionAdminVerify            = "admin.verify"
+	ActionStaffVerify            = "staff.verify"
+	ActionJobRetry               = "job.retry"
+	ActionOutletRatingWeights    = "outlet.ratingWeights.update"
+	ActionFeedbackHide           = "feedback.hide"
+	ActionFeedbackRestore        = "feedback.restore"
+	ActionFeedbackReply          = "feedback.reply"
+	ActionAlertRuleUpdate        = "alertRule.update"
+	ActionAlertRuleDelete        = "alertRule.delete"
+)
+
+// Entity types recorded in the audit log
+const (
+	EntityInventory = "Inventory"
+	EntityWallet    = "Wallet"
+	EntityProduct   = "Product"
+	EntityStaff     = "StaffDetails"
+	EntityAdmin     = "Admin"
+	EntityCoupon    = "Coupon"
+	EntityUser      = "User"
+	EntityJob       = "Job"
+	EntityOutlet    = "Outlet"
+	EntityFeedback  = "Feedback"
+	EntityAlertRule = "FeedbackAlertRule"
+)
+
+// Entry describes one audited change. Before and After may be structs or maps;
+// only fields whose values differ are stored.
+type Entry struct {
+	Action     string
+	EntityType string
+	EntityID   interface{}
+	OutletID   *int
+	Before     interface{}
+	After      interface{}
+}
+
+// Record writes an audit event for the request's authenticated actor. Pass the
+// transaction that made the change so the event commits or rolls back with it,
+// or the plain connection when the change was not made in one.
+func Record(c *gin.Context, tx *gorm.DB, e Entry) error {
+	event := models.AuditEvent{
+		OutletID:   e.OutletID,
+		Action:     e.Action,
+		EntityType: e.EntityType,
+		ActorRole:  "SYSTEM",
+	}
+	if e.EntityID != nil {
+		event.EntityID = fmt.Sprint(e.EntityID)
+	}
+
+	if c != nil {
+		setActor(c, &event)
+		event.IPAddress = c.ClientIP()
+		event.UserAgent = c.Request.UserAgent()
+	}
+
+	before, after, err := Diff(e.Before, e.After)
+	if err != nil {
+		return err
+	}
+	event.Before = before
+	event.After = after
+
+	return tx.Create(&event).Error
+}
+
+func setActor(c *gin.Context, event *models.AuditEvent) {
+	userInterface, exists := c.Get("user")
+	if !exists {
+		return
+	}
+
+	switch u := userInterface.(type) {
+	case models.User:
+		id := u.ID
+		event.ActorID = &id
+		event.ActorRole = string(u.Role)
+		event.ActorEmail = u.Email
+	case gin.H:
+		if id, ok := u["id"].(int); ok {
+			event.ActorID = &id
+		}
+		event.ActorRole = fmt.Sprint(u["role"])
+		if email, ok := u["email"].(string); ok {
+			event.ActorEmail = email
+		}
+	}
+}
+
+// Diff returns JSON objects holding only the fields that differ between before
+// and after. For creations (nil before) or deletions (nil after) the whole
+// object is kept on the side that exists.
+func Diff(before, after interface{}) (*string, *string, error) {
+	beforeMap, err := toMap(before)
+	if err != nil {
+		return nil, nil, err
+	}
+	afterMap, err := toMap(after)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if beforeMap != nil && afterMap != nil {
+		for key, b := range beforeMap {
+			if a, ok := afterMap[key]; ok && reflect.DeepEqual(a, b) {
+				delete(beforeMap, key)
+				delete(afterMap, key)
+			}
+		}
+	}
+
+	beforeJSON, err := marshalOrNil(beforeMap)
+	if err != nil {
+		return nil, nil, err
+	}
+	afterJSON, err := marshalOrNil(afterMap)
+	if err != nil {
+		return nil, nil, err
+	}
+	return beforeJSON, afterJSON, nil
+}
+
+func toMap(v interface{}) (map[string]interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func marshalOrNil(m map[string]interface{}) (*string, error) {
+	if m == nil {
+		return nil, nil
+	}
+	raw, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	s := string(raw)
+	return &s, nil
+}
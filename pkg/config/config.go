@@ -1,116 +1,159 @@
 package config
 
 import (
-	"log"
+	"log/slog"
+	"net/url"
 	"os"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
-// Config holds all configuration for the application
+// Config holds all configuration for the application. Each field is read
+// from the environment variable in its env tag, or from the file named by
+// the same variable with a _FILE suffix (for Docker secrets). Unset and
+// empty variables take the default tag.
 type Config struct {
 	// Server
-	Port        string
-	Environment string
+	Port        int    `env:"PORT" default:"5500"`
+	Environment string `env:"NODE_ENV" default:"development"`
 
 	// Database
-	DatabaseURL string
+	DatabaseURL string `env:"DATABASE_URL" required:"true" secret:"true"`
 
-	// JWT
-	JWTSecret    string
-	JWTExpiresIn string
+	// JWT. The lifetime accepts Go durations and days, e.g. "12h" or "7d".
+	JWTSecret    string        `env:"JWT_SECRET" required:"true" secret:"true"`
+	JWTExpiresIn time.Duration `env:"JWT_EXPIRES_IN" default:"7d"`
 
 	// Session
-	SessionSecret string
+	SessionSecret string `env:"SESSION_SECRET" secret:"true"`
 
-	// Google OAuth
-	GoogleClientID     string
-	GoogleClientSecret string
+	// Google OAuth. Android, iOS and web clients have different IDs.
+	GoogleClientIDs    []string `env:"GOOGLE_CLIENT_ID"`
+	GoogleClientSecret string   `env:"GOOGLE_CLIENT_SECRET" secret:"true"`
 
 	// Razorpay
-	RazorpayKeyID     string
-	RazorpayKeySecret string
+	RazorpayKeyID     string `env:"RAZORPAY_KEY_ID"`
+	RazorpayKeySecret string `env:"RAZORPAY_KEY_SECRET" secret:"true"`
 
 	// Twilio
-	TwilioAccountSID  string
-	TwilioAuthToken   string
-	TwilioPhoneNumber string
+	TwilioAccountSID  string `env:"TWILIO_ACCOUNT_SID"`
+	TwilioAuthToken   string `env:"TWILIO_AUTH_TOKEN" secret:"true"`
+	TwilioPhoneNumber string `env:"TWILIO_PHONE_NUMBER"`
 
 	// Security
-	CookieSecure string
+	CookieSecure bool `env:"COOKIE_SECURE" default:"false"`
 
 	// GCP Storage
-	GCPProjectID                 string
-	GCPBucketName                string
-	GoogleApplicationCredentials string
+	GCPProjectID                 string `env:"GCP_PROJECT_ID"`
+	GCPBucketName                string `env:"GCP_BUCKET_NAME"`
+	GoogleApplicationCredentials string `env:"GOOGLE_APPLICATION_CREDENTIALS"`
 
 	// Mobile Auth
-	EnableMobileTokenReturn string
+	EnableMobileTokenReturn bool `env:"ENABLE_MOBILE_TOKEN_RETURN" default:"false"`
 
 	// EC2
-	EC2PublicIP string
+	EC2PublicIP string `env:"EC2_PUBLIC_IP"`
 
 	// Allowed Origins
-	AllowedOrigins string
+	AllowedOrigins []string `env:"ALLOWED_ORIGINS"`
+
+	// Mail
+	MailDriver   string `env:"MAIL_DRIVER" default:"log"`
+	MailFrom     string `env:"MAIL_FROM" default:"no-reply@mrkadalai.com"`
+	MailLogFile  string `env:"MAIL_LOG_FILE"`
+	SMTPHost     string `env:"SMTP_HOST"`
+	SMTPPort     int    `env:"SMTP_PORT" default:"587"`
+	SMTPUsername string `env:"SMTP_USERNAME"`
+	SMTPPassword string `env:"SMTP_PASSWORD" secret:"true"`
+
+	// Links used in outgoing emails
+	AppBaseURL *url.URL `env:"APP_BASE_URL" default:"http://localhost:3000"`
+
+	// Email verification
+	RequireEmailVerification bool `env:"REQUIRE_EMAIL_VERIFICATION" default:"false"`
+
+	// Phone numbers without an international prefix use this country code
+	DefaultPhoneCountryCode string `env:"DEFAULT_PHONE_COUNTRY_CODE" default:"91"`
+
+	// Login brute-force protection. Bare numbers are minutes.
+	LoginMaxFailures   int           `env:"LOGIN_MAX_FAILURES" default:"5"`
+	LoginIPMaxFailures int           `env:"LOGIN_IP_MAX_FAILURES" default:"50"`
+	LoginLockout       time.Duration `env:"LOGIN_LOCKOUT_MINUTES" unit:"m" default:"15"`
+	LoginFailureWindow time.Duration `env:"LOGIN_FAILURE_WINDOW_MINUTES" unit:"m" default:"15"`
+
+	// Rate limiting. Policies are "name=limit/window" pairs separated by commas,
+	// e.g. "default=300/1m,coupon=10/1m"
+	RateLimitEnabled  bool   `env:"RATE_LIMIT_ENABLED" default:"true"`
+	RateLimitStore    string `env:"RATE_LIMIT_STORE" default:"memory"`
+	RateLimitPolicies string `env:"RATE_LIMIT_POLICIES" default:"default=300/1m,auth=20/1m,signup=5/10m,coupon=10/1m,payment=10/1m"`
+
+	// How long Idempotency-Key responses are kept for replay. Bare numbers
+	// are hours.
+	IdempotencyTTL time.Duration `env:"IDEMPOTENCY_TTL_HOURS" unit:"h" default:"24"`
+
+	// Logging. Format is json or text and defaults to json in production.
+	LogLevel  slog.Level `env:"LOG_LEVEL" default:"info"`
+	LogFormat string     `env:"LOG_FORMAT"`
+
+	// Bearer token required to scrape /metrics; the endpoint is off when empty
+	MetricsToken string `env:"METRICS_TOKEN" secret:"true"`
+
+	// Tracing. Spans are exported over OTLP/HTTP only when the endpoint is
+	// set, e.g. "http://localhost:4318"; the ratio samples new root traces
+	OTLPEndpoint     *url.URL `env:"OTEL_EXPORTER_OTLP_ENDPOINT"`
+	OTELServiceName  string   `env:"OTEL_SERVICE_NAME" default:"ups-backend"`
+	TraceSampleRatio float64  `env:"TRACE_SAMPLE_RATIO" default:"1"`
+
+	// How often due scheduled notifications are sent. Bare numbers are
+	// seconds.
+	NotificationInterval time.Duration `env:"NOTIFICATION_DISPATCH_INTERVAL_SECONDS" unit:"s" default:"30"`
+
+	// Background jobs: how many run at once per server, how often the queue
+	// is polled, how long shutdown waits for running jobs and how long
+	// finished jobs are kept. Bare numbers are seconds, except the
+	// retention in hours.
+	JobConcurrency  int           `env:"JOB_CONCURRENCY" default:"4"`
+	JobPollInterval time.Duration `env:"JOB_POLL_INTERVAL_SECONDS" unit:"s" default:"2"`
+	JobDrainTimeout time.Duration `env:"JOB_DRAIN_TIMEOUT_SECONDS" unit:"s" default:"20"`
+	JobRetention    time.Duration `env:"JOB_RETENTION_HOURS" unit:"h" default:"168"`
+
+	// Feedback comments containing one of these words or phrases are held
+	// for review instead of being published. Matching ignores case and a
+	// word also matches longer words it starts.
+	FeedbackBlockedWords []string `env:"FEEDBACK_BLOCKED_WORDS" default:"fuck,shit,bitch,bastard,asshole,cunt,motherfucker"`
 }
 
-var AppConfig *Config
-
-// LoadConfig loads environment variables into Config struct
-func LoadConfig() {
+// LoadConfig reads the configuration from the environment and .env. The
+// returned error is an Errors listing every problem found; the Config is
+// returned either way so the settings that did load can be shown.
+func LoadConfig() (*Config, error) {
 	// Load .env file if it exists (optional in production)
 	if err := godotenv.Load(); err != nil {
-		log.Println("No .env file found, using environment variables")
-	}
-
-	AppConfig = &Config{
-		Port:                         getEnv("PORT", "5500"),
-		Environment:                  getEnv("NODE_ENV", "development"),
-		DatabaseURL:                  getEnv("DATABASE_URL", ""),
-		JWTSecret:                    getEnv("JWT_SECRET", ""),
-		JWTExpiresIn:                 getEnv("JWT_EXPIRES_IN", "7d"),
-		SessionSecret:                getEnv("SESSION_SECRET", ""),
-		GoogleClientID:               getEnv("GOOGLE_CLIENT_ID", ""),
-		GoogleClientSecret:           getEnv("GOOGLE_CLIENT_SECRET", ""),
-		RazorpayKeyID:                getEnv("RAZORPAY_KEY_ID", ""),
-		RazorpayKeySecret:            getEnv("RAZORPAY_KEY_SECRET", ""),
-		TwilioAccountSID:             getEnv("TWILIO_ACCOUNT_SID", ""),
-		TwilioAuthToken:              getEnv("TWILIO_AUTH_TOKEN", ""),
-		TwilioPhoneNumber:            getEnv("TWILIO_PHONE_NUMBER", ""),
-		CookieSecure:                 getEnv("COOKIE_SECURE", "false"),
-		GCPProjectID:                 getEnv("GCP_PROJECT_ID", ""),
-		GCPBucketName:                getEnv("GCP_BUCKET_NAME", ""),
-		GoogleApplicationCredentials: getEnv("GOOGLE_APPLICATION_CREDENTIALS", ""),
-		EnableMobileTokenReturn:      getEnv("ENABLE_MOBILE_TOKEN_RETURN", "false"),
-		EC2PublicIP:                  getEnv("EC2_PUBLIC_IP", ""),
-		AllowedOrigins:               getEnv("ALLOWED_ORIGINS", ""),
-	}
-
-	// Validate required config
-	if AppConfig.DatabaseURL == "" {
-		log.Fatal("DATABASE_URL is required")
-	}
-	if AppConfig.JWTSecret == "" {
-		log.Fatal("JWT_SECRET is required")
+		slog.Info("No .env file found, using environment variables")
 	}
 
-	log.Println("✅ Configuration loaded successfully")
+	return Load(os.LookupEnv)
 }
 
-// getEnv gets an environment variable or returns a default value
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// Load builds the configuration from lookup, which has the signature of
+// os.LookupEnv
+func Load(lookup func(string) (string, bool)) (*Config, error) {
+	cfg := &Config{}
+	problems := load(cfg, lookup)
+	problems = append(problems, cfg.validate()...)
+	if len(problems) > 0 {
+		return cfg, problems
 	}
-	return defaultValue
+	return cfg, nil
 }
 
 // IsProduction returns true if running in production mode
-func IsProduction() bool {
-	return AppConfig.Environment == "production"
+func (c *Config) IsProduction() bool {
+	return c.Environment == "production"
 }
 
 // IsDevelopment returns true if running in development mode
-func IsDevelopment() bool {
-	return AppConfig.Environment == "development" || AppConfig.Environment == ""
+func (c *Config) IsDevelopment() bool {
+	return c.Environment == "development" || c.Environment == ""
 }
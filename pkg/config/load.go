@@ -0,0 +1,233 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Errors lists every problem found while loading the configuration
+type Errors []string
+
+func (e Errors) Error() string {
+	return "invalid configuration:\n  " + strings.Join(e, "\n  ")
+}
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	levelType    = reflect.TypeOf(slog.Level(0))
+	urlType      = reflect.TypeOf(&url.URL{})
+)
+
+// load sets every tagged field of cfg and returns the values that could not
+// be read or parsed
+func load(cfg *Config, lookup func(string) (string, bool)) Errors {
+	var problems Errors
+
+	v := reflect.ValueOf(cfg).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key := field.Tag.Get("env")
+		if key == "" {
+			continue
+		}
+
+		raw, err := readValue(lookup, key)
+		if err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+		if raw == "" {
+			if field.Tag.Get("required") == "true" {
+				problems = append(problems, key+" is required")
+				continue
+			}
+			raw = field.Tag.Get("default")
+		}
+		if raw == "" {
+			continue
+		}
+
+		if err := setField(v.Field(i), raw, field.Tag.Get("unit")); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", key, err))
+		}
+	}
+	return problems
+}
+
+// readValue returns the variable key, or the contents of the file named by
+// key_FILE. Setting both is an error so a forgotten variable cannot shadow a
+// mounted secret.
+func readValue(lookup func(string) (string, bool), key string) (string, error) {
+	value, _ := lookup(key)
+	path, _ := lookup(key + "_FILE")
+	if path == "" {
+		return value, nil
+	}
+	if value != "" {
+		return "", fmt.Errorf("%s and %s_FILE are both set", key, key)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("%s_FILE: %v", key, err)
+	}
+	// Secret files usually end with a newline that is not part of the value
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+func setField(field reflect.Value, raw, unit string) error {
+	switch field.Type() {
+	case durationType:
+		d, err := parseDuration(raw, unit)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+
+	case levelType:
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(raw)); err != nil {
+			return fmt.Errorf("want debug, info, warn or error, got %q", raw)
+		}
+		field.SetInt(int64(level))
+		return nil
+
+	case urlType:
+		u, err := url.Parse(strings.TrimSpace(raw))
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("want an absolute URL, got %q", raw)
+		}
+		field.Set(reflect.ValueOf(u))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+
+	case reflect.Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(raw))
+		if err != nil {
+			return fmt.Errorf("want true or false, got %q", raw)
+		}
+		field.SetBool(b)
+
+	case reflect.Int:
+		n, err := strconv.Atoi(strings.TrimSpace(raw))
+		if err != nil || n <= 0 {
+			return fmt.Errorf("want a positive whole number, got %q", raw)
+		}
+		field.SetInt(int64(n))
+
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+		if err != nil {
+			return fmt.Errorf("want a number, got %q", raw)
+		}
+		field.SetFloat(f)
+
+	case reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		field.Set(reflect.ValueOf(items))
+
+	default:
+		return fmt.Errorf("unsupported setting type %s", field.Type())
+	}
+	return nil
+}
+
+var daysPattern = regexp.MustCompile(`^(\d+)d$`)
+
+// parseDuration accepts Go durations ("90m", "1h30m"), whole days ("7d")
+// and, for settings whose variable names a unit, bare numbers in that unit
+func parseDuration(raw, unit string) (time.Duration, error) {
+	raw = strings.TrimSpace(raw)
+
+	var d time.Duration
+	if n, err := strconv.Atoi(raw); err == nil && unit != "" {
+		base, _ := time.ParseDuration("1" + unit)
+		d = time.Duration(n) * base
+	} else if m := daysPattern.FindStringSubmatch(raw); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		d = time.Duration(n) * 24 * time.Hour
+	} else if d, err = time.ParseDuration(raw); err != nil {
+		return 0, fmt.Errorf("want a duration such as 30m, 12h or 7d, got %q", raw)
+	}
+
+	if d <= 0 {
+		return 0, fmt.Errorf("want a positive duration, got %q", raw)
+	}
+	return d, nil
+}
+
+var countryCodePattern = regexp.MustCompile(`^[1-9]\d{0,2}$`)
+
+// validate checks settings that parsed but do not make sense, alone or
+// together
+func (c *Config) validate() Errors {
+	var problems Errors
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	switch c.Environment {
+	case "development", "test", "production":
+	default:
+		add("NODE_ENV: want development, test or production, got %q", c.Environment)
+	}
+	if c.Port > 65535 {
+		add("PORT: %d is not a valid port", c.Port)
+	}
+	if c.IsProduction() && c.SessionSecret == "" {
+		add("SESSION_SECRET is required in production")
+	}
+
+	switch c.MailDriver {
+	case "log":
+	case "smtp":
+		if c.SMTPHost == "" {
+			add("SMTP_HOST is required when MAIL_DRIVER is smtp")
+		}
+	default:
+		add("MAIL_DRIVER: want smtp or log, got %q", c.MailDriver)
+	}
+	if c.SMTPPort > 65535 {
+		add("SMTP_PORT: %d is not a valid port", c.SMTPPort)
+	}
+
+	if !countryCodePattern.MatchString(c.DefaultPhoneCountryCode) {
+		add("DEFAULT_PHONE_COUNTRY_CODE: want one to three digits without a +, got %q", c.DefaultPhoneCountryCode)
+	}
+
+	switch c.RateLimitStore {
+	case "memory", "postgres":
+	default:
+		add("RATE_LIMIT_STORE: want memory or postgres, got %q", c.RateLimitStore)
+	}
+
+	switch strings.ToLower(c.LogFormat) {
+	case "", "json", "text":
+	default:
+		add("LOG_FORMAT: want json or text, got %q", c.LogFormat)
+	}
+
+	if c.TraceSampleRatio < 0 || c.TraceSampleRatio > 1 {
+		add("TRACE_SAMPLE_RATIO: want a number between 0 and 1, got %v", c.TraceSampleRatio)
+	}
+
+	return problems
+}
@@ -0,0 +1,131 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func lookupFrom(env map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}
+}
+
+func TestLoadDefaults(t *testing.T) {
+	cfg, err := Load(lookupFrom(map[string]string{
+		"DATABASE_URL": "postgres://localhost/app",
+		"JWT_SECRET":   "secret",
+	}))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Port != 5500 || cfg.JWTExpiresIn != 7*24*time.Hour || cfg.LoginLockout != 15*time.Minute {
+		t.Errorf("defaults not applied: port %d, jwt %v, lockout %v", cfg.Port, cfg.JWTExpiresIn, cfg.LoginLockout)
+	}
+	if !cfg.RateLimitEnabled || cfg.CookieSecure {
+		t.Errorf("boolean defaults not applied")
+	}
+	if cfg.AppBaseURL.String() != "http://localhost:3000" {
+		t.Errorf("AppBaseURL = %v", cfg.AppBaseURL)
+	}
+}
+
+func TestLoadReportsEveryProblem(t *testing.T) {
+	_, err := Load(lookupFrom(map[string]string{
+		"NODE_ENV":           "production",
+		"PORT":               "http",
+		"JWT_EXPIRES_IN":     "soon",
+		"COOKIE_SECURE":      "yes please",
+		"ALLOWED_ORIGINS":    "https://a.example, https://b.example",
+		"TRACE_SAMPLE_RATIO": "2",
+	}))
+	var problems Errors
+	if !errors.As(err, &problems) {
+		t.Fatalf("want Errors, got %v", err)
+	}
+	for _, key := range []string{"DATABASE_URL", "JWT_SECRET", "PORT", "JWT_EXPIRES_IN", "COOKIE_SECURE", "SESSION_SECRET", "TRACE_SAMPLE_RATIO"} {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("no problem reported for %s in:\n%v", key, err)
+		}
+	}
+	if len(problems) != 7 {
+		t.Errorf("want 7 problems, got %d:\n%v", len(problems), err)
+	}
+}
+
+func TestLoadDurations(t *testing.T) {
+	for raw, want := range map[string]time.Duration{"30m": 30 * time.Minute, "1d": 24 * time.Hour, "12h": 12 * time.Hour} {
+		cfg, err := Load(lookupFrom(map[string]string{
+			"DATABASE_URL":   "postgres://localhost/app",
+			"JWT_SECRET":     "secret",
+			"JWT_EXPIRES_IN": raw,
+			// Bare numbers are in the unit the variable names
+			"LOGIN_LOCKOUT_MINUTES": "5",
+		}))
+		if err != nil {
+			t.Fatalf("Load(%q): %v", raw, err)
+		}
+		if cfg.JWTExpiresIn != want {
+			t.Errorf("JWT_EXPIRES_IN=%s gave %v, want %v", raw, cfg.JWTExpiresIn, want)
+		}
+		if cfg.LoginLockout != 5*time.Minute {
+			t.Errorf("LOGIN_LOCKOUT_MINUTES=5 gave %v", cfg.LoginLockout)
+		}
+	}
+}
+
+func TestLoadFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jwt_secret")
+	if err := os.WriteFile(path, []byte("from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(lookupFrom(map[string]string{
+		"DATABASE_URL":    "postgres://localhost/app",
+		"JWT_SECRET_FILE": path,
+	}))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.JWTSecret != "from-file" {
+		t.Errorf("JWTSecret = %q", cfg.JWTSecret)
+	}
+
+	_, err = Load(lookupFrom(map[string]string{
+		"DATABASE_URL":    "postgres://localhost/app",
+		"JWT_SECRET":      "inline",
+		"JWT_SECRET_FILE": path,
+	}))
+	if err == nil || !strings.Contains(err.Error(), "both set") {
+		t.Errorf("want a conflict error, got %v", err)
+	}
+}
+
+func TestSettingsRedactsSecrets(t *testing.T) {
+	cfg, err := Load(lookupFrom(map[string]string{
+		"DATABASE_URL": "postgres://user:pw@localhost/app",
+		"JWT_SECRET":   "secret",
+	}))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	got := make(map[string]string)
+	for _, s := range cfg.Settings(true) {
+		got[s.Env] = s.Value
+	}
+	if got["DATABASE_URL"] != Redacted || got["JWT_SECRET"] != Redacted {
+		t.Errorf("secrets not redacted: %q, %q", got["DATABASE_URL"], got["JWT_SECRET"])
+	}
+	if got["METRICS_TOKEN"] != "" {
+		t.Errorf("unset secret should print empty, got %q", got["METRICS_TOKEN"])
+	}
+	if got["JWT_EXPIRES_IN"] != "7d" || got["PORT"] != "5500" {
+		t.Errorf("JWT_EXPIRES_IN = %q, PORT = %q", got["JWT_EXPIRES_IN"], got["PORT"])
+	}
+}
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Redacted replaces secret values in printed settings
+const Redacted = "[redacted]"
+
+// Setting is one effective configuration value
+type Setting struct {
+	// Env is the environment variable the value is read from
+	Env    string
+	Value  string
+	Secret bool
+}
+
+// Settings returns every setting in declaration order. With redact, secrets
+// that are set read as Redacted.
+func (c *Config) Settings(redact bool) []Setting {
+	var settings []Setting
+
+	v := reflect.ValueOf(c).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key := field.Tag.Get("env")
+		if key == "" {
+			continue
+		}
+
+		s := Setting{
+			Env:    key,
+			Value:  formatValue(v.Field(i).Interface()),
+			Secret: field.Tag.Get("secret") == "true",
+		}
+		if s.Secret && redact && s.Value != "" {
+			s.Value = Redacted
+		}
+		settings = append(settings, s)
+	}
+	return settings
+}
+
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case time.Duration:
+		if v > 0 && v%(24*time.Hour) == 0 {
+			return fmt.Sprintf("%dd", v/(24*time.Hour))
+		}
+		return v.String()
+	case slog.Level:
+		return strings.ToLower(v.String())
+	case *url.URL:
+		if v == nil {
+			return ""
+		}
+		return v.String()
+	case []string:
+		return strings.Join(v, ",")
+	default:
+		return fmt.Sprint(v)
+	}
+}
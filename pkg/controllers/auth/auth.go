@@ -1,10 +1,10 @@
 package auth
 
 import (
-	"backend_pandhi/pkg/config"
-	"backend_pandhi/pkg/database"
+	"backend_pandhi/pkg/apperror"
+	"backend_pandhi/pkg/dto"
 	"backend_pandhi/pkg/models"
-	"backend_pandhi/pkg/services"
+	"backend_pandhi/pkg/security"
 	"backend_pandhi/pkg/utils"
 	"net/http"
 	"strconv"
@@ -15,38 +15,40 @@ import (
 )
 
 // CustomerSignup handles customer registration
-func CustomerSignup(c *gin.Context) {
-	var req struct {
-		Name           string `json:"name" binding:"required"`
-		Email          string `json:"email" binding:"required,email"`
-		Password       string `json:"password" binding:"required"`
-		RetypePassword string `json:"retypePassword" binding:"required"`
-		OutletID       int    `json:"outletId" binding:"required"`
-		Phone          string `json:"phone" binding:"required"`
-		YearOfStudy    *int   `json:"yearOfStudy"`
-	}
+func (ctrl *Controller) CustomerSignup(c *gin.Context) {
+	var req dto.CustomerSignupRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Name, email, password, retype password, outlet ID, and phone are required"})
+		apperror.Abort(c, apperror.Binding(err, "Name, email, password, retype password, outlet ID, and phone are required"))
 		return
 	}
 
 	if req.Password != req.RetypePassword {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Passwords do not match"})
+		apperror.Abort(c, apperror.BadRequest("Passwords do not match"))
+		return
+	}
+
+	phone, err := utils.NormalizePhone(ctrl.Config, req.Phone)
+	if err != nil {
+		apperror.Abort(c, apperror.BadRequest("Invalid phone number"))
 		return
 	}
 
 	// Check if user exists
 	var existingUser models.User
-	if err := database.DB.Where("email = ?", req.Email).First(&existingUser).Error; err == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "User already exists"})
+	if err := ctrl.DB.WithContext(c.Request.Context()).Where("email = ?", req.Email).First(&existingUser).Error; err == nil {
+		apperror.Abort(c, apperror.BadRequest("User already exists"))
+		return
+	}
+	if err := ctrl.DB.WithContext(c.Request.Context()).Where("phone = ?", phone).First(&existingUser).Error; err == nil {
+		apperror.Abort(c, apperror.BadRequest("Phone number is already registered"))
 		return
 	}
 
 	// Hash password
 	hashedPassword, err := utils.HashPassword(req.Password)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal server error"})
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
 		return
 	}
 
@@ -56,63 +58,35 @@ func CustomerSignup(c *gin.Context) {
 		Email:    req.Email,
 		Password: &hashedPassword,
 		Role:     models.RoleCustomer,
-		Phone:    &req.Phone,
+		Phone:    &phone,
 		OutletID: &req.OutletID,
 	}
 
-	err = database.DB.Transaction(func(tx *gorm.DB) error {
-		// Create user
-		if err := tx.Create(&user).Error; err != nil {
+	// The verification email is queued with the account so it is sent once
+	// the signup commits, and retried if the mail server is down
+	err = ctrl.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		if err := createCustomerAccount(tx, &user, req.YearOfStudy); err != nil {
 			return err
 		}
-
-		// Create customer details
-		customerDetails := models.CustomerDetails{
-			UserID:      user.ID,
-			YearOfStudy: req.YearOfStudy,
-		}
-		if err := tx.Create(&customerDetails).Error; err != nil {
-			return err
-		}
-
-		// Create wallet
-		wallet := models.Wallet{
-			CustomerID:     customerDetails.ID,
-			Balance:        0,
-			TotalRecharged: 0,
-			TotalUsed:      0,
-		}
-		if err := tx.Create(&wallet).Error; err != nil {
-			return err
-		}
-
-		// Create cart
-		cart := models.Cart{
-			CustomerID: customerDetails.ID,
-		}
-		if err := tx.Create(&cart).Error; err != nil {
-			return err
-		}
-
-		return nil
+		return queueVerificationEmail(tx, user.ID)
 	})
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal server error"})
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
 		return
 	}
 
 	// Load relationships
-	database.DB.
+	ctrl.DB.WithContext(c.Request.Context()).
 		Preload("CustomerInfo.Wallet").
 		Preload("CustomerInfo.Cart").
 		Preload("Outlet").
 		First(&user, user.ID)
 
 	// Generate JWT token
-	token, err := utils.GenerateToken(user.ID, user.Email, user.Role)
+	token, err := utils.GenerateToken(ctrl.Config, user.ID, user.Email, user.Role)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal server error"})
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
 		return
 	}
 
@@ -123,20 +97,21 @@ func CustomerSignup(c *gin.Context) {
 		7*24*60*60, // 7 days
 		"/",
 		"",
-		config.AppConfig.CookieSecure == "true",
+		ctrl.Config.CookieSecure,
 		true, // httpOnly
 	)
 
 	// Prepare response
 	response := gin.H{
-		"id":       user.ID,
-		"name":     user.Name,
-		"email":    user.Email,
-		"phone":    user.Phone,
-		"role":     user.Role,
-		"outletId": user.OutletID,
-		"outlet":   user.Outlet,
-		"imageUrl": nil,
+		"id":         user.ID,
+		"name":       user.Name,
+		"email":      user.Email,
+		"phone":      user.Phone,
+		"role":       user.Role,
+		"outletId":   user.OutletID,
+		"outlet":     user.Outlet,
+		"imageUrl":   nil,
+		"isVerified": user.IsVerified,
 	}
 
 	if user.CustomerInfo != nil {
@@ -154,52 +129,101 @@ func CustomerSignup(c *gin.Context) {
 	}
 
 	// Add token to response if mobile mode enabled
-	if strings.TrimSpace(config.AppConfig.EnableMobileTokenReturn) == "true" {
+	if ctrl.Config.EnableMobileTokenReturn {
 		jsonResponse["token"] = token
 	}
 
 	c.JSON(http.StatusCreated, jsonResponse)
 }
 
-// CustomerSignIn handles customer login
-func CustomerSignIn(c *gin.Context) {
-	var req struct {
-		Email    string `json:"email" binding:"required"`
-		Password string `json:"password" binding:"required"`
+// createCustomerAccount creates a customer user together with its customer
+// details, wallet and cart. Must run inside a transaction.
+func createCustomerAccount(tx *gorm.DB, user *models.User, yearOfStudy *int) error {
+	// Create user
+	if err := tx.Create(user).Error; err != nil {
+		return err
+	}
+
+	// Create customer details
+	customerDetails := models.CustomerDetails{
+		UserID:      user.ID,
+		YearOfStudy: yearOfStudy,
+	}
+	if err := tx.Create(&customerDetails).Error; err != nil {
+		return err
+	}
+
+	// Create wallet
+	wallet := models.Wallet{
+		CustomerID:     customerDetails.ID,
+		Balance:        0,
+		TotalRecharged: 0,
+		TotalUsed:      0,
+	}
+	if err := tx.Create(&wallet).Error; err != nil {
+		return err
+	}
+
+	// Create cart
+	cart := models.Cart{
+		CustomerID: customerDetails.ID,
 	}
+	return tx.Create(&cart).Error
+}
+
+// CustomerSignIn handles customer login
+func (ctrl *Controller) CustomerSignIn(c *gin.Context) {
+	var req dto.SignInRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Email and password are required"})
+		apperror.Abort(c, apperror.Binding(err, "Email and password are required"))
+		return
+	}
+
+	if ctrl.loginBlocked(c, models.AuthAccountTypeUser, req.Email) {
 		return
 	}
 
 	// Find user
 	var user models.User
-	if err := database.DB.
+	found := ctrl.DB.WithContext(c.Request.Context()).
 		Preload("CustomerInfo.Wallet").
 		Preload("CustomerInfo.Cart").
 		Preload("Outlet").
 		Where("email = ?", req.Email).
-		First(&user).Error; err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid customer credentials"})
+		First(&user).Error == nil
+
+	// Verify password (runs even for unknown emails to keep timing uniform)
+	var hash *string
+	if found {
+		hash = user.Password
+	}
+	if !passwordMatches(hash, req.Password) {
+		security.RecordLoginFailure(ctrl.App, models.AuthAccountTypeUser, req.Email, c.ClientIP())
+		apperror.Abort(c, apperror.Unauthorized("Invalid email or password"))
 		return
 	}
 
 	if user.Role != models.RoleCustomer {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid customer credentials"})
+		apperror.Abort(c, apperror.Unauthorized("Invalid customer credentials"))
 		return
 	}
 
-	// Verify password
-	if user.Password == nil || utils.ComparePassword(*user.Password, req.Password) != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid email or password"})
-		return
-	}
+	// A correct password for another kind of account does not clear the
+	// failures recorded against this email
+	security.RecordLoginSuccess(ctrl.App, models.AuthAccountTypeUser, req.Email)
 
+	ctrl.respondCustomerLogin(c, user, "Customer login successful", http.StatusOK)
+}
+
+// respondCustomerLogin issues a session for a customer whose credentials were
+// already checked and writes the standard customer login response. The user
+// must have CustomerInfo.Wallet, CustomerInfo.Cart and Outlet preloaded.
+func (ctrl *Controller) respondCustomerLogin(c *gin.Context, user models.User, message string, status int) {
 	// Generate JWT token
-	token, err := utils.GenerateToken(user.ID, user.Email, user.Role)
+	token, err := utils.GenerateToken(ctrl.Config, user.ID, user.Email, user.Role)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal server error"})
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
 		return
 	}
 
@@ -210,23 +234,24 @@ func CustomerSignIn(c *gin.Context) {
 		7*24*60*60,
 		"/",
 		"",
-		config.AppConfig.CookieSecure == "true",
+		ctrl.Config.CookieSecure,
 		true,
 	)
 
 	// Prepare response
 	response := gin.H{
-		"id":       user.ID,
-		"name":     user.Name,
-		"email":    user.Email,
-		"phone":    user.Phone,
-		"role":     user.Role,
-		"outletId": user.OutletID,
-		"outlet":   user.Outlet,
+		"id":         user.ID,
+		"name":       user.Name,
+		"email":      user.Email,
+		"phone":      user.Phone,
+		"role":       user.Role,
+		"outletId":   user.OutletID,
+		"outlet":     user.Outlet,
+		"isVerified": user.IsVerified,
 	}
 
 	if user.ImageURL != nil {
-		signedURL, _ := services.GetSignedURL(*user.ImageURL)
+		signedURL, _ := ctrl.Storage.GetSignedURL(*user.ImageURL)
 		response["imageUrl"] = signedURL
 	} else {
 		response["imageUrl"] = nil
@@ -242,49 +267,60 @@ func CustomerSignIn(c *gin.Context) {
 	}
 
 	jsonResponse := gin.H{
-		"message": "Customer login successful",
+		"message": message,
 		"user":    response,
 	}
 
 	// Add token to response if mobile mode enabled
-	if strings.TrimSpace(config.AppConfig.EnableMobileTokenReturn) == "true" {
+	if ctrl.Config.EnableMobileTokenReturn {
 		jsonResponse["token"] = token
 	}
 
-	c.JSON(http.StatusOK, jsonResponse)
+	c.JSON(status, jsonResponse)
 }
 
 // StaffSignup handles staff registration
-func StaffSignup(c *gin.Context) {
-	var req struct {
-		Name           string `json:"name" binding:"required"`
-		Email          string `json:"email" binding:"required,email"`
-		Password       string `json:"password" binding:"required"`
-		RetypePassword string `json:"retypePassword" binding:"required"`
-		Phone          string `json:"phone"`
-	}
+func (ctrl *Controller) StaffSignup(c *gin.Context) {
+	var req dto.SignupRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Name, email, password, and retype password are required"})
+		apperror.Abort(c, apperror.Binding(err, "Name, email, password, and retype password are required"))
 		return
 	}
 
 	if req.Password != req.RetypePassword {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Passwords do not match"})
+		apperror.Abort(c, apperror.BadRequest("Passwords do not match"))
 		return
 	}
 
+	// Phone is optional for staff; store NULL rather than "" so the unique index holds
+	var phone *string
+	if strings.TrimSpace(req.Phone) != "" {
+		normalized, err := utils.NormalizePhone(ctrl.Config, req.Phone)
+		if err != nil {
+			apperror.Abort(c, apperror.BadRequest("Invalid phone number"))
+			return
+		}
+		phone = &normalized
+	}
+
 	// Check if user exists
 	var existingUser models.User
-	if err := database.DB.Where("email = ?", req.Email).First(&existingUser).Error; err == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "User already exists"})
+	if err := ctrl.DB.WithContext(c.Request.Context()).Where("email = ?", req.Email).First(&existingUser).Error; err == nil {
+		apperror.Abort(c, apperror.BadRequest("User already exists"))
 		return
 	}
+	if phone != nil {
+		if err := ctrl.DB.WithContext(c.Request.Context()).Where("phone = ?", *phone).First(&existingUser).Error; err == nil {
+			apperror.Abort(c, apperror.BadRequest("Phone number is already registered"))
+			return
+		}
+	}
 
 	// Hash password
 	hashedPassword, err := utils.HashPassword(req.Password)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal server error"})
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
 		return
 	}
 
@@ -297,7 +333,7 @@ func StaffSignup(c *gin.Context) {
 		f, err := file.Open()
 		if err == nil {
 			defer f.Close()
-			url, err := services.UploadImageFromReader(f, file.Filename)
+			url, err := ctrl.Storage.UploadImageFromReader(c.Request.Context(), f, file.Filename)
 			if err == nil {
 				aadharUrl = url
 			}
@@ -310,7 +346,7 @@ func StaffSignup(c *gin.Context) {
 		f, err := file.Open()
 		if err == nil {
 			defer f.Close()
-			url, err := services.UploadImageFromReader(f, file.Filename)
+			url, err := ctrl.Storage.UploadImageFromReader(c.Request.Context(), f, file.Filename)
 			if err == nil {
 				panUrl = url
 			}
@@ -323,11 +359,11 @@ func StaffSignup(c *gin.Context) {
 		Email:      req.Email,
 		Password:   &hashedPassword,
 		Role:       models.RoleStaff,
-		Phone:      &req.Phone,
+		Phone:      phone,
 		IsVerified: false,
 	}
 
-	err = database.DB.Transaction(func(tx *gorm.DB) error {
+	err = ctrl.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
 		// Create user
 		if err := tx.Create(&user).Error; err != nil {
 			return err
@@ -346,12 +382,12 @@ func StaffSignup(c *gin.Context) {
 	})
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal server error"})
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
 		return
 	}
 
 	// Load relationships
-	database.DB.
+	ctrl.DB.WithContext(c.Request.Context()).
 		Preload("StaffInfo.Permissions").
 		Preload("Outlet").
 		First(&user, user.ID)
@@ -389,48 +425,53 @@ func StaffSignup(c *gin.Context) {
 }
 
 // StaffSignIn handles staff login
-func StaffSignIn(c *gin.Context) {
-	var req struct {
-		Email    string `json:"email" binding:"required"`
-		Password string `json:"password" binding:"required"`
-	}
+func (ctrl *Controller) StaffSignIn(c *gin.Context) {
+	var req dto.SignInRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Email and password are required"})
+		apperror.Abort(c, apperror.Binding(err, "Email and password are required"))
+		return
+	}
+
+	if ctrl.loginBlocked(c, models.AuthAccountTypeUser, req.Email) {
 		return
 	}
 
 	// Find user
 	var user models.User
-	if err := database.DB.
+	found := ctrl.DB.WithContext(c.Request.Context()).
 		Preload("StaffInfo.Permissions").
 		Preload("Outlet").
 		Where("email = ?", req.Email).
-		First(&user).Error; err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid staff credentials"})
+		First(&user).Error == nil
+
+	// Verify password before revealing anything about the account
+	var hash *string
+	if found {
+		hash = user.Password
+	}
+	if !passwordMatches(hash, req.Password) {
+		security.RecordLoginFailure(ctrl.App, models.AuthAccountTypeUser, req.Email, c.ClientIP())
+		apperror.Abort(c, apperror.Unauthorized("Invalid staff credentials"))
 		return
 	}
 
 	if user.Role != models.RoleStaff {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid staff credentials"})
+		apperror.Abort(c, apperror.Unauthorized("Invalid staff credentials"))
 		return
 	}
 
-	if !user.IsVerified {
-		c.JSON(http.StatusForbidden, gin.H{"message": "Staff not verified. Contact SuperAdmin."})
-		return
-	}
+	security.RecordLoginSuccess(ctrl.App, models.AuthAccountTypeUser, req.Email)
 
-	// Verify password
-	if user.Password == nil || utils.ComparePassword(*user.Password, req.Password) != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid staff credentials"})
+	if !user.IsVerified {
+		apperror.Abort(c, apperror.Forbidden("Staff not verified. Contact SuperAdmin."))
 		return
 	}
 
 	// Generate JWT token
-	token, err := utils.GenerateToken(user.ID, user.Email, user.Role)
+	token, err := utils.GenerateToken(ctrl.Config, user.ID, user.Email, user.Role)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal server error"})
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
 		return
 	}
 
@@ -441,7 +482,7 @@ func StaffSignIn(c *gin.Context) {
 		7*24*60*60,
 		"/",
 		"",
-		config.AppConfig.CookieSecure == "true",
+		ctrl.Config.CookieSecure,
 		true,
 	)
 
@@ -457,7 +498,7 @@ func StaffSignIn(c *gin.Context) {
 	}
 
 	if user.ImageURL != nil {
-		signedURL, _ := services.GetSignedURL(*user.ImageURL)
+		signedURL, _ := ctrl.Storage.GetSignedURL(*user.ImageURL)
 		response["imageUrl"] = signedURL
 	} else {
 		response["imageUrl"] = nil
@@ -479,36 +520,30 @@ func StaffSignIn(c *gin.Context) {
 }
 
 // AdminSignup handles admin registration
-func AdminSignup(c *gin.Context) {
-	var req struct {
-		Name           string `json:"name" binding:"required"`
-		Email          string `json:"email" binding:"required,email"`
-		Password       string `json:"password" binding:"required"`
-		RetypePassword string `json:"retypePassword" binding:"required"`
-		Phone          string `json:"phone"`
-	}
+func (ctrl *Controller) AdminSignup(c *gin.Context) {
+	var req dto.SignupRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Name, email, password, and retype password are required"})
+		apperror.Abort(c, apperror.Binding(err, "Name, email, password, and retype password are required"))
 		return
 	}
 
 	if req.Password != req.RetypePassword {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Passwords do not match"})
+		apperror.Abort(c, apperror.BadRequest("Passwords do not match"))
 		return
 	}
 
 	// Check if admin exists
 	var existingAdmin models.Admin
-	if err := database.DB.Where("email = ?", req.Email).First(&existingAdmin).Error; err == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Admin already exists"})
+	if err := ctrl.DB.WithContext(c.Request.Context()).Where("email = ?", req.Email).First(&existingAdmin).Error; err == nil {
+		apperror.Abort(c, apperror.BadRequest("Admin already exists"))
 		return
 	}
 
 	// Hash password
 	hashedPassword, err := utils.HashPassword(req.Password)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal server error"})
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
 		return
 	}
 
@@ -521,7 +556,7 @@ func AdminSignup(c *gin.Context) {
 		f, err := file.Open()
 		if err == nil {
 			defer f.Close()
-			url, err := services.UploadImageFromReader(f, file.Filename)
+			url, err := ctrl.Storage.UploadImageFromReader(c.Request.Context(), f, file.Filename)
 			if err == nil {
 				aadharUrl = url // Using same UploadImageFromReader since it's generic
 			}
@@ -534,7 +569,7 @@ func AdminSignup(c *gin.Context) {
 		f, err := file.Open()
 		if err == nil {
 			defer f.Close()
-			url, err := services.UploadImageFromReader(f, file.Filename)
+			url, err := ctrl.Storage.UploadImageFromReader(c.Request.Context(), f, file.Filename)
 			if err == nil {
 				panUrl = url
 			}
@@ -550,8 +585,8 @@ func AdminSignup(c *gin.Context) {
 		Phone:      &req.Phone,
 	}
 
-	if err := database.DB.Create(&admin).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal server error"})
+	if err := ctrl.DB.WithContext(c.Request.Context()).Create(&admin).Error; err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
 		return
 	}
 
@@ -566,43 +601,48 @@ func AdminSignup(c *gin.Context) {
 }
 
 // AdminSignIn handles admin login
-func AdminSignIn(c *gin.Context) {
-	var req struct {
-		Email    string `json:"email" binding:"required"`
-		Password string `json:"password" binding:"required"`
-	}
+func (ctrl *Controller) AdminSignIn(c *gin.Context) {
+	var req dto.SignInRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Email and password are required"})
+		apperror.Abort(c, apperror.Binding(err, "Email and password are required"))
+		return
+	}
+
+	if ctrl.loginBlocked(c, models.AuthAccountTypeAdmin, req.Email) {
 		return
 	}
 
 	// Find admin
 	var admin models.Admin
-	if err := database.DB.
+	found := ctrl.DB.WithContext(c.Request.Context()).
 		Preload("Outlets.Outlet").
 		Preload("Outlets.Permissions").
 		Where("email = ?", req.Email).
-		First(&admin).Error; err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid email or password"})
-		return
-	}
+		First(&admin).Error == nil
 
-	if !admin.IsVerified {
-		c.JSON(http.StatusForbidden, gin.H{"message": "Admin not verified. Contact SuperAdmin."})
+	// Verify password before revealing anything about the account
+	var hash *string
+	if found {
+		hash = &admin.Password
+	}
+	if !passwordMatches(hash, req.Password) {
+		security.RecordLoginFailure(ctrl.App, models.AuthAccountTypeAdmin, req.Email, c.ClientIP())
+		apperror.Abort(c, apperror.Unauthorized("Invalid email or password"))
 		return
 	}
 
-	// Verify password
-	if utils.ComparePassword(admin.Password, req.Password) != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid email or password"})
+	security.RecordLoginSuccess(ctrl.App, models.AuthAccountTypeAdmin, req.Email)
+
+	if !admin.IsVerified {
+		apperror.Abort(c, apperror.Forbidden("Admin not verified. Contact SuperAdmin."))
 		return
 	}
 
 	// Generate JWT token
-	token, err := utils.GenerateToken(admin.ID, admin.Email, "ADMIN")
+	token, err := utils.GenerateToken(ctrl.Config, admin.ID, admin.Email, "ADMIN")
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal server error"})
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
 		return
 	}
 
@@ -613,7 +653,7 @@ func AdminSignIn(c *gin.Context) {
 		7*24*60*60,
 		"/",
 		"",
-		config.AppConfig.CookieSecure == "true",
+		ctrl.Config.CookieSecure,
 		true,
 	)
 
@@ -644,42 +684,47 @@ func AdminSignIn(c *gin.Context) {
 }
 
 // SuperAdminSignIn handles superadmin login
-func SuperAdminSignIn(c *gin.Context) {
-	var req struct {
-		Email    string `json:"email" binding:"required"`
-		Password string `json:"password" binding:"required"`
-	}
+func (ctrl *Controller) SuperAdminSignIn(c *gin.Context) {
+	var req dto.SignInRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Email and password are required"})
+		apperror.Abort(c, apperror.Binding(err, "Email and password are required"))
+		return
+	}
+
+	if ctrl.loginBlocked(c, models.AuthAccountTypeUser, req.Email) {
 		return
 	}
 
 	// Find user
 	var user models.User
-	if err := database.DB.
+	found := ctrl.DB.WithContext(c.Request.Context()).
 		Preload("Outlet").
 		Where("email = ?", req.Email).
-		First(&user).Error; err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid email or password"})
+		First(&user).Error == nil
+
+	// Verify password before revealing anything about the account
+	var hash *string
+	if found {
+		hash = user.Password
+	}
+	if !passwordMatches(hash, req.Password) {
+		security.RecordLoginFailure(ctrl.App, models.AuthAccountTypeUser, req.Email, c.ClientIP())
+		apperror.Abort(c, apperror.Unauthorized("Invalid email or password"))
 		return
 	}
 
 	if user.Role != models.RoleSuperAdmin {
-		c.JSON(http.StatusForbidden, gin.H{"message": "Access denied. Only SuperAdmin can log in here."})
+		apperror.Abort(c, apperror.Forbidden("Access denied. Only SuperAdmin can log in here."))
 		return
 	}
 
-	// Verify password
-	if user.Password == nil || utils.ComparePassword(*user.Password, req.Password) != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid email or password"})
-		return
-	}
+	security.RecordLoginSuccess(ctrl.App, models.AuthAccountTypeUser, req.Email)
 
 	// Generate JWT token
-	token, err := utils.GenerateToken(user.ID, user.Email, user.Role)
+	token, err := utils.GenerateToken(ctrl.Config, user.ID, user.Email, user.Role)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal server error"})
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
 		return
 	}
 
@@ -690,7 +735,7 @@ func SuperAdminSignIn(c *gin.Context) {
 		7*24*60*60,
 		"/",
 		"",
-		config.AppConfig.CookieSecure == "true",
+		ctrl.Config.CookieSecure,
 		true,
 	)
 
@@ -712,21 +757,21 @@ func SuperAdminSignIn(c *gin.Context) {
 }
 
 // SignOut handles user logout
-func SignOut(c *gin.Context) {
+func (ctrl *Controller) SignOut(c *gin.Context) {
 	c.SetCookie(
 		"token",
 		"",
 		-1,
 		"/",
 		"",
-		config.IsProduction(),
+		ctrl.Config.IsProduction(),
 		true,
 	)
 	c.JSON(http.StatusOK, gin.H{"message": "Signed out successfully"})
 }
 
 // CheckAuth verifies if user is authenticated and returns user details
-func CheckAuth(c *gin.Context) {
+func (ctrl *Controller) CheckAuth(c *gin.Context) {
 	// Get token from cookie or header
 	token := ""
 	if cookieToken, err := c.Cookie("token"); err == nil {
@@ -741,30 +786,30 @@ func CheckAuth(c *gin.Context) {
 	}
 
 	if token == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Not authenticated"})
+		apperror.Abort(c, apperror.Unauthorized("Not authenticated"))
 		return
 	}
 
 	// Verify token
-	claims, err := utils.VerifyToken(token)
+	claims, err := utils.VerifyToken(ctrl.Config, token)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid or expired token"})
+		apperror.Abort(c, apperror.Unauthorized("Invalid or expired token"))
 		return
 	}
 
 	// Check role and fetch user data
 	if claims.Role == "ADMIN" {
 		var admin models.Admin
-		if err := database.DB.
+		if err := ctrl.DB.WithContext(c.Request.Context()).
 			Preload("Outlets.Outlet").
 			Preload("Outlets.Permissions").
 			First(&admin, claims.ID).Error; err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"message": "Admin not found"})
+			apperror.Abort(c, apperror.NotFound("Admin not found"))
 			return
 		}
 
 		if !admin.IsVerified {
-			c.JSON(http.StatusForbidden, gin.H{"message": "Admin not verified"})
+			apperror.Abort(c, apperror.Forbidden("Admin not verified"))
 			return
 		}
 
@@ -782,18 +827,18 @@ func CheckAuth(c *gin.Context) {
 		// Regular user (CUSTOMER, STAFF, SUPERADMIN)
 		userID, err := strconv.Atoi(strconv.Itoa(claims.ID))
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid token payload"})
+			apperror.Abort(c, apperror.BadRequest("Invalid token payload"))
 			return
 		}
 
 		var user models.User
-		if err := database.DB.
+		if err := ctrl.DB.WithContext(c.Request.Context()).
 			Preload("CustomerInfo.Wallet").
 			Preload("CustomerInfo.Cart").
 			Preload("StaffInfo.Permissions").
 			Preload("Outlet").
 			First(&user, userID).Error; err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"message": "User not found"})
+			apperror.Abort(c, apperror.NotFound("User not found"))
 			return
 		}
 
@@ -808,7 +853,7 @@ func CheckAuth(c *gin.Context) {
 		}
 
 		if user.ImageURL != nil {
-			signedURL, _ := services.GetSignedURL(*user.ImageURL)
+			signedURL, _ := ctrl.Storage.GetSignedURL(*user.ImageURL)
 			response["imageUrl"] = signedURL
 		} else {
 			response["imageUrl"] = nil
@@ -0,0 +1,13 @@
+package auth
+
+import "backend_pandhi/pkg/app"
+
+// Controller serves the authentication endpoints
+type Controller struct {
+	*app.App
+}
+
+// New returns a controller backed by a
+func New(a *app.App) *Controller {
+	return &Controller{App: a}
+}
@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"backend_pandhi/pkg/apperror"
+	"backend_pandhi/pkg/dto"
+	"backend_pandhi/pkg/models"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// verificationResentMessage is returned whether or not the account exists
+const verificationResentMessage = "If an unverified account exists for that email, a verification link has been sent"
+
+// ConfirmEmailVerification marks a customer's email as verified
+func (ctrl *Controller) ConfirmEmailVerification(c *gin.Context) {
+	var req dto.EmailVerificationRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.Abort(c, apperror.Binding(err, "Token is required"))
+		return
+	}
+
+	err := ctrl.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		token, err := ctrl.consumeAuthToken(tx, req.Token, models.AuthTokenPurposeEmailVerification)
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&models.User{}).
+			Where("id = ? AND role = ?", token.AccountID, models.RoleCustomer).
+			Update("isVerified", true).Error
+	})
+
+	if err == errInvalidAuthToken {
+		apperror.Abort(c, apperror.BadRequest("Verification link is invalid or has expired"))
+		return
+	}
+	if err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Email verified successfully"})
+}
+
+// ResendEmailVerification issues a fresh verification link for a customer
+func (ctrl *Controller) ResendEmailVerification(c *gin.Context) {
+	var req dto.ResendEmailVerificationRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.Abort(c, apperror.Binding(err, "A valid email is required"))
+		return
+	}
+
+	var user models.User
+	if err := ctrl.DB.WithContext(c.Request.Context()).
+		Where("email = ? AND role = ?", strings.TrimSpace(req.Email), models.RoleCustomer).
+		First(&user).Error; err != nil || user.IsVerified {
+		c.JSON(http.StatusOK, gin.H{"message": verificationResentMessage})
+		return
+	}
+
+	// Throttle resends per account. The response is identical either way so
+	// the endpoint does not reveal which emails are registered.
+	var lastToken models.AuthToken
+	if err := ctrl.DB.WithContext(c.Request.Context()).
+		Where(`"accountType" = ? AND "accountId" = ? AND purpose = ?`,
+			models.AuthAccountTypeUser, user.ID, models.AuthTokenPurposeEmailVerification).
+		Order(`"createdAt" DESC`).
+		First(&lastToken).Error; err == nil {
+		if ctrl.Clock.Now().Sub(lastToken.CreatedAt) < verificationResendCooldown {
+			c.JSON(http.StatusOK, gin.H{"message": verificationResentMessage})
+			return
+		}
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
+		return
+	}
+
+	if err := ctrl.sendVerificationEmail(c.Request.Context(), user); err != nil {
+		slog.WarnContext(c.Request.Context(), "Failed to send verification email", "user_id", user.ID, "error", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": verificationResentMessage})
+}
@@ -0,0 +1,138 @@
+package auth
+
+import (
+	"backend_pandhi/pkg/apperror"
+	"backend_pandhi/pkg/dto"
+	"backend_pandhi/pkg/models"
+	"backend_pandhi/pkg/services"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var (
+	errGoogleAccountConflict = errors.New("email is linked to a different Google account")
+	errGoogleNotCustomer     = errors.New("email belongs to a non-customer account")
+	errGoogleUnverified      = errors.New("email belongs to an unverified account")
+	errGoogleOutletRequired  = errors.New("outlet required for new account")
+)
+
+// CustomerGoogleSignIn signs a customer in with a Google ID token from the app.
+// The token's Google account is matched by GoogleID first, then linked to an
+// existing customer with the same email once that customer has verified it;
+// otherwise a new customer is created. An unverified account is never linked:
+// whoever registered it may not own the address and would keep its password
+// and any token already issued to it.
+func (ctrl *Controller) CustomerGoogleSignIn(c *gin.Context) {
+	var req dto.GoogleSignInRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.Abort(c, apperror.Binding(err, "Google ID token is required"))
+		return
+	}
+
+	identity, err := ctrl.Google.Verify(c.Request.Context(), req.IDToken)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidGoogleToken) {
+			apperror.Abort(c, apperror.Unauthorized("Invalid Google token"))
+			return
+		}
+		slog.WarnContext(c.Request.Context(), "Google token verification failed", "error", err)
+		apperror.Abort(c, apperror.Unavailable("Google sign-in is currently unavailable"))
+		return
+	}
+
+	if identity.Email == "" || !identity.EmailVerified {
+		apperror.Abort(c, apperror.Unauthorized("Google account email is not verified"))
+		return
+	}
+
+	var user models.User
+	created := false
+
+	err = ctrl.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		// Returning user
+		if err := tx.Where(`"googleId" = ?`, identity.Subject).First(&user).Error; err == nil {
+			if user.Role != models.RoleCustomer {
+				return errGoogleNotCustomer
+			}
+			return nil
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		// Link an existing account with the same email
+		if err := tx.Where("LOWER(email) = ?", identity.Email).First(&user).Error; err == nil {
+			if user.Role != models.RoleCustomer {
+				return errGoogleNotCustomer
+			}
+			if user.GoogleID != nil && *user.GoogleID != identity.Subject {
+				return errGoogleAccountConflict
+			}
+			if !user.IsVerified {
+				return errGoogleUnverified
+			}
+			return tx.Model(&user).Update("googleId", identity.Subject).Error
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		// New customer
+		if req.OutletID == nil {
+			return errGoogleOutletRequired
+		}
+
+		name := identity.Name
+		if name == "" {
+			name = identity.Email
+		}
+		googleID := identity.Subject
+		user = models.User{
+			Name:       name,
+			Email:      identity.Email,
+			Role:       models.RoleCustomer,
+			GoogleID:   &googleID,
+			IsVerified: true,
+			OutletID:   req.OutletID,
+		}
+		created = true
+		return createCustomerAccount(tx, &user, req.YearOfStudy)
+	})
+
+	switch {
+	case errors.Is(err, errGoogleNotCustomer):
+		apperror.Abort(c, apperror.Forbidden("This email is registered to a non-customer account"))
+		return
+	case errors.Is(err, errGoogleAccountConflict):
+		apperror.Abort(c, apperror.Conflict("This email is already linked to a different Google account"))
+		return
+	case errors.Is(err, errGoogleUnverified):
+		apperror.Abort(c, apperror.Conflict("An unverified account already uses this email. Verify the email before signing in with Google.").WithCode("EMAIL_NOT_VERIFIED"))
+		return
+	case errors.Is(err, errGoogleOutletRequired):
+		apperror.Abort(c, apperror.BadRequest("Outlet ID is required to create an account").WithCode("OUTLET_REQUIRED"))
+		return
+	case err != nil:
+		apperror.Abort(c, apperror.Internal("Internal server error", nil))
+		return
+	}
+
+	// Load relationships
+	if err := ctrl.DB.WithContext(c.Request.Context()).
+		Preload("CustomerInfo.Wallet").
+		Preload("CustomerInfo.Cart").
+		Preload("Outlet").
+		First(&user, user.ID).Error; err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
+		return
+	}
+
+	if created {
+		ctrl.respondCustomerLogin(c, user, "Customer created successfully", http.StatusCreated)
+		return
+	}
+	ctrl.respondCustomerLogin(c, user, "Customer login successful", http.StatusOK)
+}
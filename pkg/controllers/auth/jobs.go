@@ -0,0 +1,41 @@
+package auth
+
+import (
+	"backend_pandhi/pkg/app"
+	"backend_pandhi/pkg/jobs"
+	"backend_pandhi/pkg/models"
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// VerificationEmailRequest is the payload of a verification email job
+type VerificationEmailRequest struct {
+	UserID int `json:"userId"`
+}
+
+// VerificationEmailJob emails a new customer the link that confirms their
+// address. Customers who are verified by the time it runs get nothing.
+var VerificationEmailJob = jobs.Define("auth.verification_email", func(ctx context.Context, a *app.App, req VerificationEmailRequest) error {
+	var user models.User
+	if err := a.DB.WithContext(ctx).First(&user, req.UserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if user.IsVerified {
+		return nil
+	}
+	return New(a).sendVerificationEmail(ctx, user)
+}, jobs.Policy{})
+
+// queueVerificationEmail queues the verification email of a new customer in
+// the transaction that creates them
+func queueVerificationEmail(tx *gorm.DB, userID int) error {
+	return VerificationEmailJob.Enqueue(tx, VerificationEmailRequest{UserID: userID}, jobs.Options{
+		UniqueKey: fmt.Sprintf("%s:%d", VerificationEmailJob.Name, userID),
+	})
+}
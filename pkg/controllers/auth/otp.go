@@ -0,0 +1,203 @@
+package auth
+
+import (
+	"backend_pandhi/pkg/apperror"
+	"backend_pandhi/pkg/dto"
+	"backend_pandhi/pkg/models"
+	"backend_pandhi/pkg/services"
+	"backend_pandhi/pkg/utils"
+	"context"
+	"crypto/hmac"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	otpLength         = 6
+	otpTTL            = 5 * time.Minute
+	otpMaxAttempts    = 5
+	otpResendCooldown = 30 * time.Second
+	otpMaxPerHour     = 5
+)
+
+// otpRequestedMessage is returned whether or not the phone is registered
+const otpRequestedMessage = "If this number is registered, a login code has been sent"
+
+// RequestLoginOTP sends a one-time login code to a customer's phone
+func (ctrl *Controller) RequestLoginOTP(c *gin.Context) {
+	var req dto.LoginOTPRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.Abort(c, apperror.Binding(err, "Phone number is required"))
+		return
+	}
+
+	phone, err := utils.NormalizePhone(ctrl.Config, req.Phone)
+	if err != nil {
+		apperror.Abort(c, apperror.BadRequest("Invalid phone number"))
+		return
+	}
+
+	// Cooldown and hourly cap apply per phone number, registered or not, so
+	// the endpoint cannot be used to pump SMS or probe registrations
+	now := ctrl.Clock.Now()
+	var recent []models.PhoneOTP
+	if err := ctrl.DB.WithContext(c.Request.Context()).
+		Where(`phone = ? AND "createdAt" >= ?`, phone, now.Add(-time.Hour)).
+		Order(`"createdAt" DESC`).
+		Find(&recent).Error; err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
+		return
+	}
+
+	if len(recent) > 0 {
+		if wait := otpResendCooldown - now.Sub(recent[0].CreatedAt); wait > 0 {
+			apperror.Abort(c, apperror.TooManyRequests("Please wait before requesting another code").
+				With("retryAfterSeconds", int(wait.Seconds())+1))
+			return
+		}
+	}
+	if len(recent) >= otpMaxPerHour {
+		retryAfter := recent[len(recent)-1].CreatedAt.Add(time.Hour).Sub(now)
+		apperror.Abort(c, apperror.TooManyRequests("Too many codes requested. Please try again later").
+			With("retryAfterSeconds", int(retryAfter.Seconds())+1))
+		return
+	}
+
+	code, err := utils.GenerateNumericCode(otpLength)
+	if err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
+		return
+	}
+
+	// Issuing a new code invalidates any earlier unused ones
+	err = ctrl.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.PhoneOTP{}).
+			Where(`phone = ? AND "consumedAt" IS NULL`, phone).
+			Update("consumedAt", now).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.PhoneOTP{
+			Phone:     phone,
+			CodeHash:  utils.HashOTP(ctrl.Config, phone, code),
+			ExpiresAt: now.Add(otpTTL),
+			CreatedAt: now,
+		}).Error
+	})
+	if err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
+		return
+	}
+
+	ctrl.sendLoginOTPAsync(c.Request.Context(), phone, code)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":          otpRequestedMessage,
+		"expiresInSeconds": int(otpTTL.Seconds()),
+	})
+}
+
+// sendLoginOTPAsync texts a login code to the phone if it belongs to a
+// customer. The lookup and the send happen after the response so neither its
+// status nor its timing tells whether the number is registered. It runs in a
+// goroutine rather than as a job so the code is never stored in plain text.
+func (ctrl *Controller) sendLoginOTPAsync(ctx context.Context, phone, code string) {
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		var user models.User
+		if err := ctrl.DB.WithContext(ctx).Where("phone = ? AND role = ?", phone, models.RoleCustomer).First(&user).Error; err != nil {
+			return
+		}
+		body := fmt.Sprintf("%s is your login code. It expires in %d minutes. Do not share it with anyone.", code, int(otpTTL.Minutes()))
+		if err := services.SendSMS(ctx, ctrl.SMS, phone, body); err != nil {
+			slog.WarnContext(ctx, "Failed to send login OTP", "user_id", user.ID, "error", err)
+		}
+	}()
+}
+
+// VerifyLoginOTP checks a login code and signs the customer in
+func (ctrl *Controller) VerifyLoginOTP(c *gin.Context) {
+	var req dto.VerifyLoginOTPRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.Abort(c, apperror.Binding(err, "Phone number and code are required"))
+		return
+	}
+
+	phone, err := utils.NormalizePhone(ctrl.Config, req.Phone)
+	if err != nil {
+		apperror.Abort(c, apperror.BadRequest("Invalid phone number"))
+		return
+	}
+
+	var (
+		matched           bool
+		tooManyAttempts   bool
+		remainingAttempts int
+	)
+
+	err = ctrl.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		// Lock the live code so concurrent guesses are counted correctly
+		var otp models.PhoneOTP
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where(`phone = ? AND "consumedAt" IS NULL AND "expiresAt" > ?`, phone, ctrl.Clock.Now()).
+			Order(`"createdAt" DESC`).
+			First(&otp).Error; err != nil {
+			return nil
+		}
+
+		if otp.Attempts >= otpMaxAttempts {
+			tooManyAttempts = true
+			return nil
+		}
+
+		if hmac.Equal([]byte(otp.CodeHash), []byte(utils.HashOTP(ctrl.Config, phone, req.Code))) {
+			matched = true
+			return tx.Model(&otp).Update("consumedAt", ctrl.Clock.Now()).Error
+		}
+
+		otp.Attempts++
+		remainingAttempts = otpMaxAttempts - otp.Attempts
+		updates := map[string]interface{}{"attempts": otp.Attempts}
+		if remainingAttempts == 0 {
+			// Burn the code once the attempt budget is spent
+			updates["consumedAt"] = ctrl.Clock.Now()
+			tooManyAttempts = true
+		}
+		return tx.Model(&otp).Updates(updates).Error
+	})
+
+	if err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
+		return
+	}
+
+	if tooManyAttempts {
+		apperror.Abort(c, apperror.TooManyRequests("Too many incorrect attempts. Please request a new code"))
+		return
+	}
+
+	if !matched {
+		apperror.Abort(c, apperror.Unauthorized("Invalid or expired code").With("remainingAttempts", remainingAttempts))
+		return
+	}
+
+	var user models.User
+	if err := ctrl.DB.WithContext(c.Request.Context()).
+		Preload("CustomerInfo.Wallet").
+		Preload("CustomerInfo.Cart").
+		Preload("Outlet").
+		Where("phone = ? AND role = ?", phone, models.RoleCustomer).
+		First(&user).Error; err != nil {
+		apperror.Abort(c, apperror.Unauthorized("Invalid or expired code"))
+		return
+	}
+
+	ctrl.respondCustomerLogin(c, user, "Customer login successful", http.StatusOK)
+}
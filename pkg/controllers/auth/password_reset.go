@@ -0,0 +1,142 @@
+package auth
+
+import (
+	"backend_pandhi/pkg/apperror"
+	"backend_pandhi/pkg/dto"
+	"backend_pandhi/pkg/models"
+	"backend_pandhi/pkg/services"
+	"backend_pandhi/pkg/utils"
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// passwordResetRequestedMessage is returned whether or not the account exists
+// so the endpoint cannot be used to discover registered emails
+const passwordResetRequestedMessage = "If an account exists for that email, a password reset link has been sent"
+
+// RequestPasswordReset emails a single-use password reset link
+func (ctrl *Controller) RequestPasswordReset(c *gin.Context) {
+	var req dto.PasswordResetRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.Abort(c, apperror.Binding(err, "A valid email is required"))
+		return
+	}
+
+	if req.AccountType == "" {
+		req.AccountType = models.AuthAccountTypeUser
+	}
+	if req.AccountType != models.AuthAccountTypeUser && req.AccountType != models.AuthAccountTypeAdmin {
+		apperror.Abort(c, apperror.BadRequest("accountType must be USER or ADMIN"))
+		return
+	}
+
+	ctrl.sendPasswordResetEmailAsync(c.Request.Context(), req.AccountType, strings.TrimSpace(req.Email))
+
+	c.JSON(http.StatusOK, gin.H{"message": passwordResetRequestedMessage})
+}
+
+// sendPasswordResetEmail issues a reset token for the account with the email
+// and mails it. Unknown emails are not an error; nothing is sent.
+func (ctrl *Controller) sendPasswordResetEmail(ctx context.Context, accountType models.AuthAccountType, email string) error {
+	var accountID int
+	var name string
+	if accountType == models.AuthAccountTypeAdmin {
+		var admin models.Admin
+		if err := ctrl.DB.WithContext(ctx).Where("email = ?", email).First(&admin).Error; err != nil {
+			return nil
+		}
+		accountID, name = admin.ID, admin.Name
+	} else {
+		var user models.User
+		if err := ctrl.DB.WithContext(ctx).Where("email = ?", email).First(&user).Error; err != nil {
+			return nil
+		}
+		accountID, name = user.ID, user.Name
+	}
+
+	var rawToken string
+	err := ctrl.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		rawToken, err = ctrl.issueAuthToken(tx, accountType, accountID, models.AuthTokenPurposePasswordReset, passwordResetTokenTTL)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf(
+		"Hi %s,\n\nWe received a request to reset your password. Open the link below to choose a new one:\n\n%s\n\nIf the link does not open, use this code in the app: %s\n\nThis link expires in 1 hour. If you did not request a reset, you can ignore this email.\n",
+		name, ctrl.buildAppLink("/reset-password", rawToken), rawToken,
+	)
+	return services.SendEmail(ctx, ctrl.Mailer, email, "Reset your password", body)
+}
+
+// sendPasswordResetEmailAsync looks up the account and sends the reset email
+// after the response, so its timing does not tell whether the email is
+// registered. It runs in a goroutine rather than as a job so the email address
+// is not stored in the queue. The request's values (its ID) are kept but not
+// its cancellation.
+func (ctrl *Controller) sendPasswordResetEmailAsync(ctx context.Context, accountType models.AuthAccountType, email string) {
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		if err := ctrl.sendPasswordResetEmail(ctx, accountType, email); err != nil {
+			slog.WarnContext(ctx, "Failed to send password reset email", "error", err)
+		}
+	}()
+}
+
+// ConfirmPasswordReset sets a new password using a reset token
+func (ctrl *Controller) ConfirmPasswordReset(c *gin.Context) {
+	var req dto.PasswordResetConfirmRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.Abort(c, apperror.Binding(err, "Token, password, and retype password are required"))
+		return
+	}
+
+	if req.Password != req.RetypePassword {
+		apperror.Abort(c, apperror.BadRequest("Passwords do not match"))
+		return
+	}
+
+	if err := utils.CheckPasswordStrength(req.Password); err != nil {
+		apperror.Abort(c, apperror.BadRequest(err.Error()))
+		return
+	}
+
+	hashedPassword, err := utils.HashPassword(req.Password)
+	if err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
+		return
+	}
+
+	err = ctrl.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		token, err := ctrl.consumeAuthToken(tx, req.Token, models.AuthTokenPurposePasswordReset)
+		if err != nil {
+			return err
+		}
+
+		if token.AccountType == models.AuthAccountTypeAdmin {
+			return tx.Model(&models.Admin{}).Where("id = ?", token.AccountID).Update("password", hashedPassword).Error
+		}
+		return tx.Model(&models.User{}).Where("id = ?", token.AccountID).Update("password", hashedPassword).Error
+	})
+
+	if err == errInvalidAuthToken {
+		apperror.Abort(c, apperror.BadRequest("Reset link is invalid or has expired"))
+		return
+	}
+	if err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password has been reset successfully"})
+}
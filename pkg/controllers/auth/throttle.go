@@ -0,0 +1,41 @@
+package auth
+
+import (
+	"backend_pandhi/pkg/apperror"
+	"backend_pandhi/pkg/models"
+	"backend_pandhi/pkg/security"
+	"backend_pandhi/pkg/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// loginBlocked writes a 429 and returns true when the account or the client IP
+// is backing off or locked out after repeated failed sign-ins
+func (ctrl *Controller) loginBlocked(c *gin.Context, accountType models.AuthAccountType, email string) bool {
+	status := security.CheckLogin(ctrl.App, accountType, email, c.ClientIP())
+	if status.Allowed {
+		return false
+	}
+
+	retryAfter := int(status.RetryAfter.Seconds()) + 1
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+
+	message := "Too many failed login attempts. Please try again shortly."
+	if status.Locked {
+		message = "Account temporarily locked due to too many failed login attempts."
+	}
+	apperror.Abort(c, apperror.TooManyRequests(message).With("retryAfterSeconds", retryAfter))
+	return true
+}
+
+// passwordMatches checks password against hash. A missing hash (unknown account
+// or passwordless login) still costs one bcrypt comparison so response timing
+// does not reveal whether the email is registered.
+func passwordMatches(hash *string, password string) bool {
+	if hash == nil {
+		utils.DummyComparePassword(password)
+		return false
+	}
+	return utils.ComparePassword(*hash, password) == nil
+}
@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"backend_pandhi/pkg/models"
+	"backend_pandhi/pkg/services"
+	"backend_pandhi/pkg/utils"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	passwordResetTokenTTL      = 1 * time.Hour
+	emailVerificationTokenTTL  = 24 * time.Hour
+	verificationResendCooldown = 1 * time.Minute
+)
+
+var errInvalidAuthToken = errors.New("invalid or expired token")
+
+// issueAuthToken invalidates any outstanding tokens for the same account and
+// purpose, stores the hash of a fresh token and returns the raw token
+func (ctrl *Controller) issueAuthToken(tx *gorm.DB, accountType models.AuthAccountType, accountID int, purpose models.AuthTokenPurpose, ttl time.Duration) (string, error) {
+	rawToken, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	now := ctrl.Clock.Now()
+	if err := tx.Model(&models.AuthToken{}).
+		Where(`"accountType" = ? AND "accountId" = ? AND purpose = ? AND "usedAt" IS NULL`, accountType, accountID, purpose).
+		Update("usedAt", now).Error; err != nil {
+		return "", err
+	}
+
+	token := models.AuthToken{
+		AccountType: accountType,
+		AccountID:   accountID,
+		Purpose:     purpose,
+		TokenHash:   utils.HashToken(rawToken),
+		ExpiresAt:   now.Add(ttl),
+		CreatedAt:   now,
+	}
+	if err := tx.Create(&token).Error; err != nil {
+		return "", err
+	}
+
+	return rawToken, nil
+}
+
+// consumeAuthToken marks a valid, unused token as used and returns it. The
+// update is conditional on "usedAt" IS NULL so concurrent requests cannot
+// both redeem the same token.
+func (ctrl *Controller) consumeAuthToken(tx *gorm.DB, rawToken string, purpose models.AuthTokenPurpose) (*models.AuthToken, error) {
+	var token models.AuthToken
+	if err := tx.Where(`"tokenHash" = ? AND purpose = ?`, utils.HashToken(rawToken), purpose).
+		First(&token).Error; err != nil {
+		return nil, errInvalidAuthToken
+	}
+
+	now := ctrl.Clock.Now()
+	if token.UsedAt != nil || now.After(token.ExpiresAt) {
+		return nil, errInvalidAuthToken
+	}
+
+	result := tx.Model(&models.AuthToken{}).
+		Where(`id = ? AND "usedAt" IS NULL`, token.ID).
+		Update("usedAt", now)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, errInvalidAuthToken
+	}
+
+	token.UsedAt = &now
+	return &token, nil
+}
+
+// buildAppLink builds a link into the web app carrying a token
+func (ctrl *Controller) buildAppLink(path, token string) string {
+	return fmt.Sprintf("%s%s?token=%s", ctrl.Config.AppBaseURL, path, url.QueryEscape(token))
+}
+
+// sendVerificationEmail issues a verification token for a customer and emails it
+func (ctrl *Controller) sendVerificationEmail(ctx context.Context, user models.User) error {
+	var rawToken string
+	err := ctrl.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		rawToken, err = ctrl.issueAuthToken(tx, models.AuthAccountTypeUser, user.ID, models.AuthTokenPurposeEmailVerification, emailVerificationTokenTTL)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf(
+		"Hi %s,\n\nPlease confirm your email address by opening the link below:\n\n%s\n\nIf the link does not open, use this code in the app: %s\n\nThis link expires in 24 hours.\n",
+		user.Name, ctrl.buildAppLink("/verify-email", rawToken), rawToken,
+	)
+	return services.SendEmail(ctx, ctrl.Mailer, user.Email, "Verify your email address", body)
+}
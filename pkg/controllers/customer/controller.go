@@ -0,0 +1,13 @@
+package customer
+
+import "backend_pandhi/pkg/app"
+
+// Controller serves the customer app endpoints
+type Controller struct {
+	*app.App
+}
+
+// New returns a controller backed by a
+func New(a *app.App) *Controller {
+	return &Controller{App: a}
+}
@@ -1,8 +1,10 @@
 package customer
 
 import (
-	"backend_pandhi/pkg/database"
+	"backend_pandhi/pkg/apperror"
+	"backend_pandhi/pkg/dto"
 	"backend_pandhi/pkg/models"
+	"backend_pandhi/pkg/repository"
 	"math"
 	"net/http"
 	"strconv"
@@ -12,39 +14,39 @@ import (
 )
 
 // GetCoupons retrieves all available (unused by customer) coupons for an outlet
-func GetCoupons(c *gin.Context) {
+func (ctrl *Controller) GetCoupons(c *gin.Context) {
 	outletIDStr := c.Param("outletId")
 	outletID, err := strconv.Atoi(outletIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid outlet ID"})
+		apperror.Abort(c, apperror.BadRequest("Invalid outlet ID"))
 		return
 	}
 
 	// Get user from context
 	userInterface, exists := c.Get("user")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "User not found."})
+		apperror.Abort(c, apperror.Unauthorized("User not found."))
 		return
 	}
 
 	user, ok := userInterface.(models.User)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid user data."})
+		apperror.Abort(c, apperror.Unauthorized("Invalid user data."))
 		return
 	}
 
 	// Get current time
-	currentTime := time.Now()
+	currentTime := ctrl.Clock.Now()
 
 	// Fetch coupons
 	var coupons []models.Coupon
-	if err := database.DB.
-		Where("outlet_id = ? AND is_active = ? AND valid_from <= ? AND valid_until >= ?",
+	if err := ctrl.DB.WithContext(c.Request.Context()).
+		Where(`"outletId" = ? AND "isActive" = ? AND "validFrom" <= ? AND "validUntil" >= ?`,
 			outletID, true, currentTime, currentTime).
-		Preload("Usages", "user_id = ?", user.ID).
-		Order("created_at DESC").
+		Preload("Usages", `"userId" = ?`, user.ID).
+		Order(`"createdAt" DESC`).
 		Find(&coupons).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to fetch coupons", "error": err.Error()})
+		apperror.Abort(c, apperror.Internal("Failed to fetch coupons", err))
 		return
 	}
 
@@ -86,48 +88,42 @@ func GetCoupons(c *gin.Context) {
 }
 
 // ApplyCoupon validates and applies a coupon to the cart
-func ApplyCoupon(c *gin.Context) {
-	var req struct {
-		Code         string  `json:"code" binding:"required"`
-		CurrentTotal float64 `json:"currentTotal" binding:"required"`
-		OutletID     int     `json:"outletId" binding:"required"`
-	}
+func (ctrl *Controller) ApplyCoupon(c *gin.Context) {
+	var req dto.ApplyCouponRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Missing or invalid fields: code, currentTotal, and outletId are required"})
+		apperror.Abort(c, apperror.Binding(err, "Missing or invalid fields: code, currentTotal, and outletId are required"))
 		return
 	}
 
 	if req.CurrentTotal < 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Missing or invalid fields: code, currentTotal, and outletId are required"})
+		apperror.Abort(c, apperror.BadRequest("Missing or invalid fields: code, currentTotal, and outletId are required"))
 		return
 	}
 
 	// Get user from context
 	userInterface, exists := c.Get("user")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "User not found."})
+		apperror.Abort(c, apperror.Unauthorized("User not found."))
 		return
 	}
 
 	user, ok := userInterface.(models.User)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid user data."})
+		apperror.Abort(c, apperror.Unauthorized("Invalid user data."))
 		return
 	}
 
 	// Get customer details with cart
-	var customer models.CustomerDetails
-	if err := database.DB.
-		Preload("Cart.Items.Product").
-		Where("user_id = ?", user.ID).
-		First(&customer).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"message": "Customer not found"})
+	customer, err := repository.Users(ctrl.DB.WithContext(c.Request.Context()).Preload("Cart.Items.Product")).
+		Customer(user.ID)
+	if err != nil {
+		apperror.Abort(c, apperror.NotFound("Customer not found"))
 		return
 	}
 
 	if customer.Cart == nil {
-		c.JSON(http.StatusNotFound, gin.H{"message": "Cart not found for customer"})
+		apperror.Abort(c, apperror.NotFound("Cart not found for customer"))
 		return
 	}
 
@@ -138,65 +134,59 @@ func ApplyCoupon(c *gin.Context) {
 	}
 
 	if math.Abs(calculatedTotal-req.CurrentTotal) > 0.01 {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message":         "Provided currentTotal does not match calculated cart total",
-			"calculatedTotal": calculatedTotal,
-			"providedTotal":   req.CurrentTotal,
-		})
+		apperror.Abort(c, apperror.BadRequest("Provided currentTotal does not match calculated cart total").
+			With("calculatedTotal", calculatedTotal).
+			With("providedTotal", req.CurrentTotal))
 		return
 	}
 
 	// Fetch coupon
 	var coupon models.Coupon
-	if err := database.DB.Where("code = ?", req.Code).First(&coupon).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"message": "Invalid or inactive coupon"})
+	if err := ctrl.DB.WithContext(c.Request.Context()).Where("code = ?", req.Code).First(&coupon).Error; err != nil {
+		apperror.Abort(c, apperror.NotFound("Invalid or inactive coupon"))
 		return
 	}
 
 	if !coupon.IsActive {
-		c.JSON(http.StatusNotFound, gin.H{"message": "Invalid or inactive coupon"})
+		apperror.Abort(c, apperror.NotFound("Invalid or inactive coupon"))
 		return
 	}
 
 	// Check coupon validity
-	currentTime := time.Now()
+	currentTime := ctrl.Clock.Now()
 	if currentTime.Before(coupon.ValidFrom) || currentTime.After(coupon.ValidUntil) {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message":           "Coupon is not valid for the current date and time",
-			"currentTimeIST":    formatDateForIST(currentTime),
-			"couponValidFrom":   formatDateForIST(coupon.ValidFrom),
-			"couponValidUntil":  formatDateForIST(coupon.ValidUntil),
-			"timezone":          "IST (UTC+5:30)",
-		})
+		apperror.Abort(c, apperror.BadRequest("Coupon is not valid for the current date and time").
+			With("currentTimeIST", formatDateForIST(currentTime)).
+			With("couponValidFrom", formatDateForIST(coupon.ValidFrom)).
+			With("couponValidUntil", formatDateForIST(coupon.ValidUntil)).
+			With("timezone", "IST (UTC+5:30)"))
 		return
 	}
 
 	// Check outlet
 	if coupon.OutletID != nil && *coupon.OutletID != req.OutletID {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Coupon is not valid for the selected outlet"})
+		apperror.Abort(c, apperror.BadRequest("Coupon is not valid for the selected outlet"))
 		return
 	}
 
 	// Check if already used
 	var existingUsage models.CouponUsage
-	if err := database.DB.
-		Where("user_id = ? AND coupon_id = ?", user.ID, coupon.ID).
+	if err := ctrl.DB.WithContext(c.Request.Context()).
+		Where(`"userId" = ? AND "couponId" = ?`, user.ID, coupon.ID).
 		First(&existingUsage).Error; err == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Coupon already used by this customer"})
+		apperror.Abort(c, apperror.BadRequest("Coupon already used by this customer"))
 		return
 	}
 
 	// Check usage limit
 	if coupon.UsedCount >= coupon.UsageLimit {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Coupon usage limit reached"})
+		apperror.Abort(c, apperror.BadRequest("Coupon usage limit reached"))
 		return
 	}
 
 	// Check minimum order value
 	if req.CurrentTotal < coupon.MinOrderValue {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "Minimum order value of ₹" + strconv.FormatFloat(coupon.MinOrderValue, 'f', 2, 64) + " required. Your current order value is ₹" + strconv.FormatFloat(req.CurrentTotal, 'f', 2, 64),
-		})
+		apperror.Abort(c, apperror.BadRequest("Minimum order value of ₹" + strconv.FormatFloat(coupon.MinOrderValue, 'f', 2, 64) + " required. Your current order value is ₹" + strconv.FormatFloat(req.CurrentTotal, 'f', 2, 64)))
 		return
 	}
 
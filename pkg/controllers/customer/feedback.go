@@ -1,8 +1,14 @@
 package customer
 
 import (
-	"backend_pandhi/pkg/database"
+	"backend_pandhi/pkg/alerts"
+	"backend_pandhi/pkg/apperror"
+	"backend_pandhi/pkg/dto"
 	"backend_pandhi/pkg/models"
+	"backend_pandhi/pkg/moderation"
+	"backend_pandhi/pkg/pagination"
+	"backend_pandhi/pkg/ratings"
+	"backend_pandhi/pkg/repository"
 	"net/http"
 	"strconv"
 	"time"
@@ -11,102 +17,38 @@ import (
 	"gorm.io/gorm"
 )
 
-// UpdateProductStats recalculates product rating statistics
-func UpdateProductStats(productID int) error {
-	now := time.Now()
-	thirtyDaysAgo := now.AddDate(0, 0, -30)
-
-	// Get 30-day feedback
-	var feedback30d []models.Feedback
-	database.DB.Where("product_id = ? AND created_at >= ?", productID, thirtyDaysAgo).
-		Find(&feedback30d)
-
-	// Calculate weighted scores for 30 days
-	var totalWeightedSum30d float64
-	for _, f := range feedback30d {
-		weightedScore := (f.RatingOverall * 0.4) +
-			(f.RatingTaste * 0.3) +
-			(f.RatingQuality * 0.2) +
-			(f.RatingQuantity * 0.1)
-		totalWeightedSum30d += weightedScore
-	}
-
-	ratingCount30d := len(feedback30d)
-	trendScore := 0.0
-	if ratingCount30d > 0 {
-		trendScore = totalWeightedSum30d / float64(ratingCount30d)
-	}
-
-	// Get lifetime feedback
-	var feedbackLifetime []models.Feedback
-	database.DB.Where("product_id = ?", productID).Find(&feedbackLifetime)
-
-	// Calculate weighted scores for lifetime
-	var totalWeightedSumLifetime float64
-	for _, f := range feedbackLifetime {
-		weightedScore := (f.RatingOverall * 0.4) +
-			(f.RatingTaste * 0.3) +
-			(f.RatingQuality * 0.2) +
-			(f.RatingQuantity * 0.1)
-		totalWeightedSumLifetime += weightedScore
-	}
-
-	ratingCountLifetime := len(feedbackLifetime)
-	averageRatingLifetime := 0.0
-	if ratingCountLifetime > 0 {
-		averageRatingLifetime = totalWeightedSumLifetime / float64(ratingCountLifetime)
-	}
-
-	// Update product
-	return database.DB.Model(&models.Product{}).Where("id = ?", productID).Updates(map[string]interface{}{
-		"rating_sum_30d":          totalWeightedSum30d,
-		"rating_count_30d":        ratingCount30d,
-		"trend_score":             trendScore,
-		"rating_sum_lifetime":     totalWeightedSumLifetime,
-		"rating_count_lifetime":   ratingCountLifetime,
-		"average_rating_lifetime": averageRatingLifetime,
-	}).Error
-}
-
 // SubmitFeedback submits feedback for order items
-func SubmitFeedback(c *gin.Context) {
-	var req struct {
-		OrderID int `json:"orderId" binding:"required"`
-		Items   []struct {
-			ProductID      int     `json:"productId" binding:"required"`
-			RatingOverall  float64 `json:"ratingOverall" binding:"required"`
-			RatingTaste    float64 `json:"ratingTaste"`
-			RatingQuality  float64 `json:"ratingQuality"`
-			RatingQuantity float64 `json:"ratingQuantity"`
-			Comment        string  `json:"comment"`
-		} `json:"items" binding:"required"`
-	}
+func (ctrl *Controller) SubmitFeedback(c *gin.Context) {
+	var req dto.SubmitFeedbackRequest
 
-	if err := c.ShouldBindJSON(&req); err != nil || len(req.Items) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "No feedback items provided"})
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.Abort(c, apperror.Binding(err, "No feedback items provided"))
+		return
+	}
+	if len(req.Items) == 0 {
+		apperror.Abort(c, apperror.Validation("No feedback items provided", apperror.FieldError{
+			Field:   "items",
+			Message: "must contain at least one item",
+		}))
 		return
 	}
 
 	// Get user from context
 	userInterface, exists := c.Get("user")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "User not found."})
+		apperror.Abort(c, apperror.Unauthorized("User not found."))
 		return
 	}
 
 	user, ok := userInterface.(models.User)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "Invalid user data."})
+		apperror.Abort(c, apperror.Unauthorized("Invalid user data."))
 		return
 	}
 
 	// Verify order exists and belongs to user
-	var order models.Order
-	if err := database.DB.
-		Joins("JOIN customer_details ON customer_details.id = orders.customer_id").
-		Where("orders.id = ? AND customer_details.user_id = ?", req.OrderID, user.ID).
-		First(&order).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Order not found or unauthorized"})
+	if _, err := repository.Orders(ctrl.DB.WithContext(c.Request.Context())).ForUser(req.OrderID, user.ID); err != nil {
+		apperror.Abort(c, apperror.NotFound("Order not found or unauthorized"))
 		return
 	}
 
@@ -116,25 +58,26 @@ func SubmitFeedback(c *gin.Context) {
 		productIDs[i] = item.ProductID
 	}
 
-	var existingFeedback []models.Feedback
-	database.DB.Where("order_id = ? AND product_id IN ?", req.OrderID, productIDs).
-		Find(&existingFeedback)
+	existingFeedback, err := repository.Products(ctrl.DB.WithContext(c.Request.Context())).OrderFeedback(req.OrderID, productIDs)
+	if err != nil {
+		apperror.Abort(c, apperror.Internal("Failed to submit feedback. Please try again.", err))
+		return
+	}
 
 	if len(existingFeedback) > 0 {
 		alreadyRatedProducts := make([]int, len(existingFeedback))
 		for i, f := range existingFeedback {
 			alreadyRatedProducts[i] = f.ProductID
 		}
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success":               false,
-			"message":               "Some products have already been rated",
-			"alreadyRatedProducts":  alreadyRatedProducts,
-		})
+		apperror.Abort(c, apperror.BadRequest("Some products have already been rated").With("alreadyRatedProducts", alreadyRatedProducts))
 		return
 	}
 
+	// Comments with blocked words are held for an admin to review
+	filter := moderation.NewFilter(ctrl.Config.FeedbackBlockedWords)
+
 	// Use transaction to create all feedbacks
-	err := database.DB.Transaction(func(tx *gorm.DB) error {
+	err = ctrl.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
 		for _, item := range req.Items {
 			feedback := models.Feedback{
 				UserID:         user.ID,
@@ -148,29 +91,34 @@ func SubmitFeedback(c *gin.Context) {
 			if item.Comment != "" {
 				feedback.Comment = &item.Comment
 			}
+			feedback.Status, feedback.ModerationReason = filter.Screen(feedback.Comment)
 
 			if err := tx.Create(&feedback).Error; err != nil {
 				return err
 			}
+
+			// Product stats count the feedback as part of the same change
+			if err := ratings.Add(tx, feedback); err != nil {
+				return err
+			}
+
+			// Negative feedback opens a follow-up ticket for the outlet
+			var product models.Product
+			if err := tx.First(&product, item.ProductID).Error; err != nil {
+				return err
+			}
+			if _, err := alerts.Check(tx, feedback, product); err != nil {
+				return err
+			}
 		}
 		return nil
 	})
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"message": "Failed to submit feedback. Please try again.",
-		})
+		apperror.Abort(c, apperror.Internal("Failed to submit feedback. Please try again.", err))
 		return
 	}
 
-	// Update product stats asynchronously
-	go func() {
-		for _, item := range req.Items {
-			UpdateProductStats(item.ProductID)
-		}
-	}()
-
 	c.JSON(http.StatusCreated, gin.H{
 		"success": true,
 		"message": "Feedback submitted successfully",
@@ -178,33 +126,28 @@ func SubmitFeedback(c *gin.Context) {
 }
 
 // GetPendingFeedback retrieves orders with unrated items
-func GetPendingFeedback(c *gin.Context) {
+func (ctrl *Controller) GetPendingFeedback(c *gin.Context) {
 	// Get user from context
 	userInterface, exists := c.Get("user")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "User not found."})
+		apperror.Abort(c, apperror.Unauthorized("User not found."))
 		return
 	}
 
 	user, ok := userInterface.(models.User)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "Invalid user data."})
+		apperror.Abort(c, apperror.Unauthorized("Invalid user data."))
 		return
 	}
 
 	// Find delivered orders in last 48 hours
-	fortyEightHoursAgo := time.Now().Add(-48 * time.Hour)
+	fortyEightHoursAgo := ctrl.Clock.Now().Add(-48 * time.Hour)
 
-	var orders []models.Order
-	database.DB.
-		Joins("JOIN customer_details ON customer_details.id = orders.customer_id").
-		Where("customer_details.user_id = ? AND orders.status = ? AND orders.delivered_at >= ?",
-			user.ID, models.OrderStatusDelivered, fortyEightHoursAgo).
+	orders, _ := repository.Orders(ctrl.DB.WithContext(c.Request.Context()).
 		Preload("Items.Product").
 		Preload("Feedbacks").
-		Order("delivered_at DESC").
-		Limit(5).
-		Find(&orders)
+		Limit(5)).
+		DeliveredForUser(user.ID, fortyEightHoursAgo)
 
 	var pendingFeedback *gin.H
 
@@ -244,40 +187,40 @@ func GetPendingFeedback(c *gin.Context) {
 }
 
 // GetFeedbackStatusForOrder retrieves feedback status for a specific order
-func GetFeedbackStatusForOrder(c *gin.Context) {
+func (ctrl *Controller) GetFeedbackStatusForOrder(c *gin.Context) {
 	orderIDStr := c.Param("orderId")
 	orderID, err := strconv.Atoi(orderIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid order ID"})
+		apperror.Abort(c, apperror.BadRequest("Invalid order ID"))
 		return
 	}
 
 	// Get user from context
 	userInterface, exists := c.Get("user")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "User not found."})
+		apperror.Abort(c, apperror.Unauthorized("User not found."))
 		return
 	}
 
 	user, ok := userInterface.(models.User)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "Invalid user data."})
+		apperror.Abort(c, apperror.Unauthorized("Invalid user data."))
 		return
 	}
 
 	// Fetch order
 	var order models.Order
-	if err := database.DB.
+	if err := ctrl.DB.WithContext(c.Request.Context()).
 		Preload("Items.Product").
 		Preload("Feedbacks").
 		Preload("Customer").
 		First(&order, orderID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Order not found"})
+		apperror.Abort(c, apperror.NotFound("Order not found"))
 		return
 	}
 
 	if order.Customer.UserID != user.ID {
-		c.JSON(http.StatusForbidden, gin.H{"success": false, "message": "Unauthorized"})
+		apperror.Abort(c, apperror.Forbidden("Unauthorized"))
 		return
 	}
 
@@ -314,58 +257,61 @@ func GetFeedbackStatusForOrder(c *gin.Context) {
 	})
 }
 
-// GetProductReviews retrieves paginated reviews for a product
-func GetProductReviews(c *gin.Context) {
+// ProductReviewsList lists the sorts and filters accepted by GetProductReviews
+var ProductReviewsList = pagination.Spec{
+	DefaultLimit: 10,
+	MaxLimit:     50,
+	DefaultSort:  "-createdAt",
+	Sorts: map[string]pagination.Sort{
+		"createdAt":     {Column: `"createdAt"`, Field: "CreatedAt", Type: pagination.TypeTime},
+		"ratingOverall": {Column: `"ratingOverall"`, Field: "RatingOverall", Type: pagination.TypeFloat},
+	},
+	Filters: map[string]pagination.Filter{
+		"ratingOverall": {Column: `"ratingOverall"`, Type: pagination.TypeFloat, Ops: pagination.Range},
+	},
+}
+
+// GetProductReviews retrieves a page of the published reviews of a product
+func (ctrl *Controller) GetProductReviews(c *gin.Context) {
 	productIDStr := c.Param("productId")
 	productID, err := strconv.Atoi(productIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid product ID"})
+		apperror.Abort(c, apperror.BadRequest("Invalid product ID"))
 		return
 	}
 
-	page := 1
-	if pageStr := c.Query("page"); pageStr != "" {
-		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
-			page = p
-		}
-	}
-
-	limit := 10
-	if limitStr := c.Query("limit"); limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
-			limit = l
-		}
+	list, err := pagination.Parse(c, ProductReviewsList)
+	if err != nil {
+		apperror.Abort(c, err)
+		return
 	}
 
-	skip := (page - 1) * limit
-
-	// Fetch reviews
 	var reviews []models.Feedback
-	database.DB.
-		Where("product_id = ?", productID).
+	if err := list.Apply(ctrl.DB.WithContext(c.Request.Context()).
+		Where(`"productId" = ? AND status = ?`, productID, models.FeedbackStatusApproved)).
 		Preload("User").
-		Order("created_at DESC").
-		Offset(skip).
-		Limit(limit).
-		Find(&reviews)
-
-	// Get total count
-	var total int64
-	database.DB.Model(&models.Feedback{}).
-		Where("product_id = ?", productID).
-		Count(&total)
+		Find(&reviews).Error; err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
+		return
+	}
+	reviews, meta := pagination.Trim(list, reviews)
 
 	// Format reviews
 	formattedReviews := make([]gin.H, len(reviews))
 	for i, r := range reviews {
+		var reply *gin.H
+		if r.Reply != nil {
+			reply = &gin.H{"text": *r.Reply, "repliedAt": r.RepliedAt}
+		}
 		formattedReviews[i] = gin.H{
-			"id":              r.ID,
-			"ratingOverall":   r.RatingOverall,
-			"ratingTaste":     r.RatingTaste,
-			"ratingQuality":   r.RatingQuality,
-			"ratingQuantity":  r.RatingQuantity,
-			"comment":         r.Comment,
-			"createdAt":       r.CreatedAt,
+			"id":             r.ID,
+			"ratingOverall":  r.RatingOverall,
+			"ratingTaste":    r.RatingTaste,
+			"ratingQuality":  r.RatingQuality,
+			"ratingQuantity": r.RatingQuantity,
+			"comment":        r.Comment,
+			"createdAt":      r.CreatedAt,
+			"reply":          reply,
 			"user": gin.H{
 				"name":  r.User.Name,
 				"image": r.User.ImageURL,
@@ -373,16 +319,9 @@ func GetProductReviews(c *gin.Context) {
 		}
 	}
 
-	totalPages := int((total + int64(limit) - 1) / int64(limit))
-
 	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"reviews": formattedReviews,
-		"pagination": gin.H{
-			"page":       page,
-			"limit":      limit,
-			"total":      total,
-			"totalPages": totalPages,
-		},
+		"success":    true,
+		"reviews":    formattedReviews,
+		"pagination": meta,
 	})
 }
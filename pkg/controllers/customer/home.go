@@ -1,9 +1,9 @@
 package customer
 
 import (
-	"backend_pandhi/pkg/database"
+	"backend_pandhi/pkg/apperror"
 	"backend_pandhi/pkg/models"
-	"backend_pandhi/pkg/services"
+	"backend_pandhi/pkg/repository"
 	"net/http"
 	"strconv"
 	"time"
@@ -12,31 +12,31 @@ import (
 )
 
 // GetProductsAndStocks fetches all products with their stock availability and user's remaining quota
-func GetProductsAndStocks(c *gin.Context) {
+func (ctrl *Controller) GetProductsAndStocks(c *gin.Context) {
 	// Get user from context (set by AuthenticateToken middleware)
 	userInterface, exists := c.Get("user")
 	if !exists {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "User not found in request."})
+		apperror.Abort(c, apperror.BadRequest("User not found in request."))
 		return
 	}
 
 	user, ok := userInterface.(models.User)
 	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid user data."})
+		apperror.Abort(c, apperror.BadRequest("Invalid user data."))
 		return
 	}
 
 	if user.OutletID == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Outlet ID not found in request."})
+		apperror.Abort(c, apperror.BadRequest("Outlet ID not found in request."))
 		return
 	}
 
 	outletID := *user.OutletID
 
 	// Fetch all products for the outlet
-	var products []models.Product
-	if err := database.DB.Where("outlet_id = ?", outletID).Find(&products).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal server error"})
+	products, err := repository.Products(ctrl.DB.WithContext(c.Request.Context())).ForOutlet(outletID)
+	if err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
 		return
 	}
 
@@ -45,11 +45,10 @@ func GetProductsAndStocks(c *gin.Context) {
 	userID := user.ID
 
 	// Get today's date at midnight
-	today := time.Now()
+	today := ctrl.Clock.Now()
 	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, today.Location())
 
-	var quota models.UserFreeQuota
-	err := database.DB.Where("user_id = ? AND consumption_date = ?", userID, today).First(&quota).Error
+	quota, err := repository.Users(ctrl.DB.WithContext(c.Request.Context())).FreeQuota(userID, today)
 	if err == nil {
 		// Quota record exists, calculate remaining
 		remainingQuota = 5 - quota.QuantityUsed
@@ -63,13 +62,13 @@ func GetProductsAndStocks(c *gin.Context) {
 
 	for _, product := range products {
 		// Fetch inventory for this product
-		var inventory models.Inventory
-		inventoryExists := database.DB.Where("product_id = ?", product.ID).First(&inventory).Error == nil
+		inventory, err := repository.Inventory(ctrl.DB.WithContext(c.Request.Context())).ForProduct(product.ID)
+		inventoryExists := err == nil
 
 		// Get signed URL for image
 		imageURL := ""
 		if product.ImageURL != nil {
-			url, _ := services.GetSignedURL(*product.ImageURL)
+			url, _ := ctrl.Storage.GetSignedURL(*product.ImageURL)
 			imageURL = url
 		}
 
@@ -107,27 +106,26 @@ func GetProductsAndStocks(c *gin.Context) {
 }
 
 // GetCurrentQuota returns the user's current remaining free quota for today
-func GetCurrentQuota(c *gin.Context) {
+func (ctrl *Controller) GetCurrentQuota(c *gin.Context) {
 	// Get user from context
 	userInterface, exists := c.Get("user")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "User not found."})
+		apperror.Abort(c, apperror.Unauthorized("User not found."))
 		return
 	}
 
 	user, ok := userInterface.(models.User)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid user data."})
+		apperror.Abort(c, apperror.Unauthorized("Invalid user data."))
 		return
 	}
 
 	// Get today's date at midnight
-	today := time.Now()
+	today := ctrl.Clock.Now()
 	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, today.Location())
 
 	// Check if quota record exists for today
-	var quota models.UserFreeQuota
-	err := database.DB.Where("user_id = ? AND consumption_date = ?", user.ID, today).First(&quota).Error
+	quota, err := repository.Users(ctrl.DB.WithContext(c.Request.Context())).FreeQuota(user.ID, today)
 
 	remainingQuota := 5 // Default quota
 	quantityUsed := 0
@@ -149,24 +147,24 @@ func GetCurrentQuota(c *gin.Context) {
 }
 
 // GetAvailableDatesAndSlotsForCustomer returns available delivery dates and time slots
-func GetAvailableDatesAndSlotsForCustomer(c *gin.Context) {
+func (ctrl *Controller) GetAvailableDatesAndSlotsForCustomer(c *gin.Context) {
 	outletIDStr := c.Param("outletId")
 	if outletIDStr == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Outlet ID is required"})
+		apperror.Abort(c, apperror.BadRequest("Outlet ID is required"))
 		return
 	}
 
 	outletID, err := strconv.Atoi(outletIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid Outlet ID"})
+		apperror.Abort(c, apperror.BadRequest("Invalid Outlet ID"))
 		return
 	}
 
-	today := time.Now()
+	today := ctrl.Clock.Now()
 	next30Days := today.AddDate(0, 0, 30)
 
 	var nonAvailable []models.OutletAvailability
-	database.DB.Where("outlet_id = ? AND date >= ? AND date <= ?",
+	ctrl.DB.WithContext(c.Request.Context()).Where(`"outletId" = ? AND date >= ? AND date <= ?`,
 		outletID, today, next30Days).Find(&nonAvailable)
 
 	allSlots := []string{"SLOT_11_12", "SLOT_12_13", "SLOT_13_14", "SLOT_14_15", "SLOT_15_16", "SLOT_16_17"}
@@ -218,10 +216,10 @@ func GetAvailableDatesAndSlotsForCustomer(c *gin.Context) {
 }
 
 // GetOutlets returns all active outlets
-func GetOutlets(c *gin.Context) {
+func (ctrl *Controller) GetOutlets(c *gin.Context) {
 	var outlets []models.Outlet
-	if err := database.DB.Where("is_active = ?", true).Find(&outlets).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal server error"})
+	if err := ctrl.DB.WithContext(c.Request.Context()).Where(`"isActive" = ?`, true).Find(&outlets).Error; err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
 		return
 	}
 
@@ -1,9 +1,11 @@
 package customer
 
 import (
-	"backend_pandhi/pkg/database"
+	"backend_pandhi/pkg/apperror"
+	"backend_pandhi/pkg/dto"
+	"backend_pandhi/pkg/metrics"
 	"backend_pandhi/pkg/models"
-	"backend_pandhi/pkg/services"
+	"backend_pandhi/pkg/repository"
 	"fmt"
 	"math"
 	"net/http"
@@ -16,43 +18,31 @@ import (
 // Removed deprecated razorpayOrderService init
 
 // CustomerAppOrder creates a new customer order with quota segregation, inventory, coupons, and payment
-func CustomerAppOrder(c *gin.Context) {
-	var req struct {
-		TotalAmount             float64 `json:"totalAmount"`
-		PaymentMethod           string  `json:"paymentMethod" binding:"required"`
-		DeliverySlot            string  `json:"deliverySlot" binding:"required"`
-		OutletID                int     `json:"outletId" binding:"required"`
-		CouponCode              *string `json:"couponCode"`
-		RequestedDeliveryDate   *string `json:"requestedDeliveryDate"`
-		Items                   []struct {
-			ProductID int     `json:"productId" binding:"required"`
-			Quantity  int     `json:"quantity" binding:"required"`
-			UnitPrice float64 `json:"unitPrice" binding:"required"`
-		} `json:"items" binding:"required"`
-		PaymentDetails *struct {
-			RazorpayOrderID   string `json:"razorpay_order_id"`
-			RazorpayPaymentID string `json:"razorpay_payment_id"`
-			RazorpaySignature string `json:"razorpay_signature"`
-		} `json:"paymentDetails"`
-	}
+func (ctrl *Controller) CustomerAppOrder(c *gin.Context) {
+	var req dto.CreateOrderRequest
 
-	if err := c.ShouldBindJSON(&req); err != nil || len(req.Items) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "Invalid input: totalAmount, paymentMethod, deliverySlot, outletId, and items are required",
-		})
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.Abort(c, apperror.Binding(err, "Invalid input: totalAmount, paymentMethod, deliverySlot, outletId, and items are required"))
+		return
+	}
+	if len(req.Items) == 0 {
+		apperror.Abort(c, apperror.Validation("Invalid input: totalAmount, paymentMethod, deliverySlot, outletId, and items are required", apperror.FieldError{
+			Field:   "items",
+			Message: "must contain at least one item",
+		}))
 		return
 	}
 
 	// Get user
 	userInterface, exists := c.Get("user")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "User not found."})
+		apperror.Abort(c, apperror.Unauthorized("User not found."))
 		return
 	}
 
 	user, ok := userInterface.(models.User)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid user data."})
+		apperror.Abort(c, apperror.Unauthorized("Invalid user data."))
 		return
 	}
 
@@ -66,7 +56,7 @@ func CustomerAppOrder(c *gin.Context) {
 		PricingBreakdown   gin.H
 	}
 
-	err := database.DB.Transaction(func(tx *gorm.DB) error {
+	err := ctrl.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
 		// ===VALIDATION===
 		if req.OutletID <= 0 {
 			return fmt.Errorf("Invalid outletId: must be a positive number")
@@ -95,8 +85,8 @@ func CustomerAppOrder(c *gin.Context) {
 		}
 
 		// Validate customer
-		var customer models.CustomerDetails
-		if err := tx.Where("user_id = ?", user.ID).First(&customer).Error; err != nil {
+		customer, err := repository.Users(tx).Customer(user.ID)
+		if err != nil {
 			return fmt.Errorf("Customer not found")
 		}
 
@@ -157,11 +147,10 @@ func CustomerAppOrder(c *gin.Context) {
 			totalCompanyPaidQty = companyPaidCount
 
 			// Check current quota usage
-			today := time.Now()
+			today := ctrl.Clock.Now()
 			today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, today.Location())
 
-			var currentQuota models.UserFreeQuota
-			tx.Where("user_id = ? AND consumption_date = ?", user.ID, today).First(&currentQuota)
+			currentQuota, _ := repository.Users(tx).FreeQuota(user.ID, today)
 
 			used := currentQuota.QuantityUsed
 			remainingFreeQuota := int(math.Max(0, 5-float64(used)))
@@ -197,17 +186,20 @@ func CustomerAppOrder(c *gin.Context) {
 
 			// Update quota
 			if freeItemsCount > 0 {
-				var quota models.UserFreeQuota
-				err := tx.Where("user_id = ? AND consumption_date = ?", user.ID, today).First(&quota).Error
+				quota, err := repository.Users(tx).FreeQuota(user.ID, today)
 				if err == gorm.ErrRecordNotFound {
 					quota = models.UserFreeQuota{
 						UserID:           user.ID,
 						ConsumptionDate:  today,
 						QuantityUsed:     freeItemsCount,
 					}
-					tx.Create(&quota)
-				} else {
-					tx.Model(&quota).Update("quantity_used", quota.QuantityUsed+freeItemsCount)
+					if err := tx.Create(&quota).Error; err != nil {
+						return err
+					}
+				} else if err != nil {
+					return err
+				} else if err := repository.Users(tx).AddFreeQuota(&quota, freeItemsCount); err != nil {
+					return err
 				}
 			}
 		}
@@ -251,7 +243,7 @@ func CustomerAppOrder(c *gin.Context) {
 			}
 
 			// Check validity dates
-			currentTime := time.Now()
+			currentTime := ctrl.Clock.Now()
 			if currentTime.Before(c.ValidFrom) || currentTime.After(c.ValidUntil) {
 				return fmt.Errorf("Coupon is not valid for the current date and time")
 			}
@@ -263,7 +255,7 @@ func CustomerAppOrder(c *gin.Context) {
 
 			// Check existing usage
 			var existingUsage models.CouponUsage
-			if tx.Where("user_id = ? AND coupon_id = ?", user.ID, c.ID).First(&existingUsage).Error == nil {
+			if tx.Where(`"userId" = ? AND "couponId" = ?`, user.ID, c.ID).First(&existingUsage).Error == nil {
 				return fmt.Errorf("Coupon already used by this customer")
 			}
 
@@ -302,7 +294,7 @@ func CustomerAppOrder(c *gin.Context) {
 				return fmt.Errorf("Invalid payment details for online payment")
 			}
 
-			if !services.VerifyPaymentSignature(req.PaymentDetails.RazorpayOrderID, req.PaymentDetails.RazorpayPaymentID, req.PaymentDetails.RazorpaySignature) {
+			if !ctrl.Payments.VerifySignature(req.PaymentDetails.RazorpayOrderID, req.PaymentDetails.RazorpayPaymentID, req.PaymentDetails.RazorpaySignature) {
 				return fmt.Errorf("Payment verification failed: Invalid signature")
 			}
 
@@ -364,8 +356,8 @@ func CustomerAppOrder(c *gin.Context) {
 		var inventoryUpdates []gin.H
 
 		for _, item := range req.Items {
-			var inventory models.Inventory
-			if err := tx.Where("product_id = ?", item.ProductID).First(&inventory).Error; err != nil {
+			inventory, err := repository.Inventory(tx).ForOutletProduct(req.OutletID, item.ProductID)
+			if err != nil {
 				stockValidationErrors = append(stockValidationErrors, fmt.Sprintf("Product %d not found in inventory", item.ProductID))
 				continue
 			}
@@ -391,25 +383,28 @@ func CustomerAppOrder(c *gin.Context) {
 		// Perform inventory deduction
 		for _, update := range inventoryUpdates {
 			productID := update["productId"].(int)
-			newStock := update["newStock"].(int)
 			requestedQuantity := update["requestedQuantity"].(int)
 
-			tx.Model(&models.Inventory{}).Where("product_id = ?", productID).Update("quantity", newStock)
+			if err := repository.Inventory(tx).Adjust(req.OutletID, productID, -requestedQuantity); err != nil {
+				return err
+			}
 
-			tx.Create(&models.StockHistory{
+			if err := tx.Create(&models.StockHistory{
 				ProductID: productID,
 				OutletID:  req.OutletID,
 				Quantity:  requestedQuantity,
 				Action:    models.StockActionRemove,
-			})
+			}).Error; err != nil {
+				return err
+			}
 		}
 
 		result.StockUpdates = inventoryUpdates
 
 		// ===WALLET PAYMENT===
 		if req.PaymentMethod == "WALLET" {
-			var wallet models.Wallet
-			if err := tx.Where("customer_id = ?", customer.ID).First(&wallet).Error; err != nil {
+			wallet, err := repository.Wallets(tx).ForCustomer(customer.ID)
+			if err != nil {
 				return fmt.Errorf("Wallet not found")
 			}
 
@@ -417,12 +412,9 @@ func CustomerAppOrder(c *gin.Context) {
 				return fmt.Errorf("Insufficient wallet balance. Available: %.2f, Required: %.2f", wallet.Balance, finalTotalAmount)
 			}
 
-			now := time.Now()
-			tx.Model(&wallet).Updates(map[string]interface{}{
-				"balance":    wallet.Balance - finalTotalAmount,
-				"total_used": wallet.TotalUsed + finalTotalAmount,
-				"last_order": &now,
-			})
+			if err := repository.Wallets(tx).Debit(&wallet, finalTotalAmount, ctrl.Clock.Now()); err != nil {
+				return err
+			}
 
 			wt := models.WalletTransaction{
 				WalletID: wallet.ID,
@@ -430,12 +422,14 @@ func CustomerAppOrder(c *gin.Context) {
 				Method:   models.PaymentMethodWallet,
 				Status:   models.WalletTransTypeDeduct,
 			}
-			tx.Create(&wt)
+			if err := tx.Create(&wt).Error; err != nil {
+				return err
+			}
 			result.WalletTransaction = &wt
 		}
 
 		// ===CREATE ORDER===
-		deliveryDate := time.Now()
+		deliveryDate := ctrl.Clock.Now()
 		deliveryDate = time.Date(deliveryDate.Year(), deliveryDate.Month(), deliveryDate.Day(), 0, 0, 0, 0, deliveryDate.Location())
 		deliverySlot := models.DeliverySlot(req.DeliverySlot)
 
@@ -455,7 +449,9 @@ func CustomerAppOrder(c *gin.Context) {
 			order.RazorpayPaymentID = razorpayPaymentID
 		}
 
-		tx.Create(&order)
+		if err := tx.Create(&order).Error; err != nil {
+			return err
+		}
 
 		// Create order items
 		for _, item := range req.Items {
@@ -466,44 +462,58 @@ func CustomerAppOrder(c *gin.Context) {
 				UnitPrice: item.UnitPrice,
 				Status:    models.OrderItemStatusNotDelivered,
 			}
-			tx.Create(&orderItem)
+			if err := tx.Create(&orderItem).Error; err != nil {
+				return err
+			}
 		}
 
 		// Clear cart
 		var cart models.Cart
-		if tx.Where("customer_id = ?", customer.ID).First(&cart).Error == nil {
-			tx.Where("cart_id = ?", cart.ID).Delete(&models.CartItem{})
+		if tx.Where(`"customerId" = ?`, customer.ID).First(&cart).Error == nil {
+			if err := tx.Where(`"cartId" = ?`, cart.ID).Delete(&models.CartItem{}).Error; err != nil {
+				return err
+			}
 		}
 
 		// Apply coupon usage
 		if coupon != nil {
-			tx.Create(&models.CouponUsage{
+			if err := tx.Create(&models.CouponUsage{
 				CouponID: coupon.ID,
 				OrderID:  order.ID,
 				UserID:   user.ID,
 				Amount:   couponDiscount,
-			})
-			tx.Model(coupon).Update("used_count", coupon.UsedCount+1)
+			}).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(coupon).Update("usedCount", coupon.UsedCount+1).Error; err != nil {
+				return err
+			}
 		}
 
 		// Reload order with relationships
-		tx.Preload("Items.Product").
+		if err := tx.Preload("Items.Product").
 			Preload("Customer.User").
 			Preload("Outlet").
-			First(&order, order.ID)
+			First(&order, order.ID).Error; err != nil {
+			return err
+		}
 
 		result.Order = order
 		return nil
 	})
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Failed to place order",
-			"error":   err.Error(),
-		})
+		apperror.Abort(c, apperror.Internal("Failed to place order", err))
 		return
 	}
 
+	metrics.OrderPlaced(models.OrderTypeApp, result.Order.PaymentMethod)
+	for _, update := range result.StockUpdates {
+		if update["newStock"].(int) == 0 {
+			metrics.StockOut(req.OutletID)
+		}
+	}
+
 	// Format response
 	items := make([]gin.H, len(result.Order.Items))
 	for i, item := range result.Order.Items {
@@ -1,49 +1,51 @@
 package customer
 
 import (
-	"backend_pandhi/pkg/database"
+	"backend_pandhi/pkg/apperror"
+	"backend_pandhi/pkg/dto"
 	"backend_pandhi/pkg/models"
-	"backend_pandhi/pkg/services"
+	"backend_pandhi/pkg/pagination"
+	"backend_pandhi/pkg/repository"
 	"fmt"
 	"net/http"
 	"strconv"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
 // CustomerAppOngoingOrderList retrieves ongoing (PENDING) orders
-func CustomerAppOngoingOrderList(c *gin.Context) {
+func (ctrl *Controller) CustomerAppOngoingOrderList(c *gin.Context) {
 	// Get user
 	userInterface, exists := c.Get("user")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "User not found."})
+		apperror.Abort(c, apperror.Unauthorized("User not found."))
 		return
 	}
 
 	user, ok := userInterface.(models.User)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid user data."})
+		apperror.Abort(c, apperror.Unauthorized("Invalid user data."))
 		return
 	}
 
 	// Get customer
-	var customer models.CustomerDetails
-	if err := database.DB.Where("user_id = ?", user.ID).First(&customer).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"message": "Customer not found"})
+	customer, err := repository.Users(ctrl.DB.WithContext(c.Request.Context())).Customer(user.ID)
+	if err != nil {
+		apperror.Abort(c, apperror.NotFound("Customer not found"))
 		return
 	}
 
 	// Fetch ongoing orders
-	var orders []models.Order
-	if err := database.DB.
-		Where("customer_id = ? AND status = ?", customer.ID, models.OrderStatusPending).
+	orders, err := repository.Orders(ctrl.DB.WithContext(c.Request.Context()).
 		Preload("Items.Product").
-		Preload("Outlet").
-		Order("created_at DESC").
-		Find(&orders).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal server error"})
+		Preload("Outlet")).
+		List(repository.OrderFilter{
+			CustomerID: customer.ID,
+			Statuses:   []models.OrderStatus{models.OrderStatusPending},
+		})
+	if err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
 		return
 	}
 
@@ -63,7 +65,7 @@ func CustomerAppOngoingOrderList(c *gin.Context) {
 // Helper to get signed URL
 			var imageURL *string
 			if item.Product.ImageURL != nil {
-				signedURL, _ := services.GetSignedURL(*item.Product.ImageURL)
+				signedURL, _ := ctrl.Storage.GetSignedURL(*item.Product.ImageURL)
 				imageURL = &signedURL
 			}
 
@@ -107,49 +109,73 @@ func CustomerAppOngoingOrderList(c *gin.Context) {
 	})
 }
 
+// CustomerOrderHistoryList lists the sorts and filters accepted by CustomerAppOrderHistory
+var CustomerOrderHistoryList = pagination.Spec{
+	DefaultLimit: 20,
+	MaxLimit:     100,
+	DefaultSort:  "-createdAt",
+	Sorts: map[string]pagination.Sort{
+		"createdAt": {Column: `"createdAt"`, Field: "CreatedAt", Type: pagination.TypeTime},
+	},
+	Filters: map[string]pagination.Filter{
+		"status":    {Column: "status", Type: pagination.TypeString, Ops: []pagination.Op{pagination.OpEq, pagination.OpIn}},
+		"createdAt": {Column: `"createdAt"`, Type: pagination.TypeTime, Ops: pagination.Range},
+	},
+}
+
 // CustomerAppOrderHistory retrieves completed orders
-func CustomerAppOrderHistory(c *gin.Context) {
+func (ctrl *Controller) CustomerAppOrderHistory(c *gin.Context) {
 	// Get user
 	userInterface, exists := c.Get("user")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "User not found."})
+		apperror.Abort(c, apperror.Unauthorized("User not found."))
 		return
 	}
 
 	user, ok := userInterface.(models.User)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid user data."})
+		apperror.Abort(c, apperror.Unauthorized("Invalid user data."))
 		return
 	}
 
 	// Get customer
-	var customer models.CustomerDetails
-	if err := database.DB.Where("user_id = ?", user.ID).First(&customer).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"message": "Customer not found"})
+	customer, err := repository.Users(ctrl.DB.WithContext(c.Request.Context())).Customer(user.ID)
+	if err != nil {
+		apperror.Abort(c, apperror.NotFound("Customer not found"))
+		return
+	}
+
+	list, err := pagination.Parse(c, CustomerOrderHistoryList)
+	if err != nil {
+		apperror.Abort(c, err)
 		return
 	}
 
 	// Fetch completed orders
 	var orders []models.Order
-	if err := database.DB.
-		Where("customer_id = ? AND status IN ?", customer.ID, []models.OrderStatus{
-			models.OrderStatusDelivered,
-			models.OrderStatusCancelled,
-			models.OrderStatusPartiallyDelivered,
-			models.OrderStatusPartialCancel,
-		}).
+	if err := list.Apply(repository.Orders(ctrl.DB.WithContext(c.Request.Context())).
+		Where(repository.OrderFilter{
+			CustomerID: customer.ID,
+			Statuses: []models.OrderStatus{
+				models.OrderStatusDelivered,
+				models.OrderStatusCancelled,
+				models.OrderStatusPartiallyDelivered,
+				models.OrderStatusPartialCancel,
+			},
+		})).
 		Preload("Items.Product").
 		Preload("Outlet").
-		Order("created_at DESC").
 		Find(&orders).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal server error"})
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
 		return
 	}
+	orders, meta := pagination.Trim(list, orders)
 
 	if len(orders) == 0 {
 		c.JSON(http.StatusOK, gin.H{
-			"message": "No order history found",
-			"orders":  []interface{}{},
+			"message":    "No order history found",
+			"orders":     []interface{}{},
+			"pagination": meta,
 		})
 		return
 	}
@@ -162,7 +188,7 @@ func CustomerAppOrderHistory(c *gin.Context) {
 // Helper to get signed URL
 			var imageURL *string
 			if item.Product.ImageURL != nil {
-				signedURL, _ := services.GetSignedURL(*item.Product.ImageURL)
+				signedURL, _ := ctrl.Storage.GetSignedURL(*item.Product.ImageURL)
 				imageURL = &signedURL
 			}
 
@@ -202,74 +228,69 @@ func CustomerAppOrderHistory(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Order history retrieved",
-		"orders":  formattedOrders,
+		"message":    "Order history retrieved",
+		"orders":     formattedOrders,
+		"pagination": meta,
 	})
 }
 
 // CustomerAppCancelOrder cancels a pending order
-func CustomerAppCancelOrder(c *gin.Context) {
+func (ctrl *Controller) CustomerAppCancelOrder(c *gin.Context) {
 	orderIDStr := c.Param("orderId")
 	orderID, err := strconv.Atoi(orderIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid order ID"})
+		apperror.Abort(c, apperror.BadRequest("Invalid order ID"))
 		return
 	}
 
 	// Get user
 	userInterface, exists := c.Get("user")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "User not found."})
+		apperror.Abort(c, apperror.Unauthorized("User not found."))
 		return
 	}
 
 	user, ok := userInterface.(models.User)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid user data."})
+		apperror.Abort(c, apperror.Unauthorized("Invalid user data."))
 		return
 	}
 
 	// Get customer
-	var customer models.CustomerDetails
-	if err := database.DB.Where("user_id = ?", user.ID).First(&customer).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"message": "Customer not found"})
+	customer, err := repository.Users(ctrl.DB.WithContext(c.Request.Context())).Customer(user.ID)
+	if err != nil {
+		apperror.Abort(c, apperror.NotFound("Customer not found"))
 		return
 	}
 
 	// Fetch order
-	var order models.Order
-	if err := database.DB.
-		Where("id = ? AND customer_id = ?", orderID, customer.ID).
-		First(&order).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"message": "Order not found"})
+	order, err := repository.Orders(ctrl.DB.WithContext(c.Request.Context())).ForCustomer(orderID, customer.ID)
+	if err != nil {
+		apperror.Abort(c, apperror.NotFound("Order not found"))
 		return
 	}
 
 	// Check if order can be cancelled
 	if order.Status != string(models.OrderStatusPending) {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "Only pending orders can be cancelled",
-			"status":  order.Status,
-		})
+		apperror.Abort(c, apperror.BadRequest("Only pending orders can be cancelled").With("status", order.Status))
 		return
 	}
 
 	// Update order status
-	err = database.DB.Transaction(func(tx *gorm.DB) error {
-		if err := tx.Model(&order).Update("status", models.OrderStatusCancelled).Error; err != nil {
+	err = ctrl.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		if err := repository.Orders(tx).SetStatus(&order, models.OrderStatusCancelled, nil); err != nil {
 			return err
 		}
 
 		// Refund if paid via wallet
 		if order.PaymentMethod == "WALLET" {
-			var wallet models.Wallet
-			if err := tx.Where("customer_id = ?", customer.ID).First(&wallet).Error; err != nil {
+			wallet, err := repository.Wallets(tx).ForCustomer(customer.ID)
+			if err != nil {
 				return err
 			}
 
 			// Credit wallet
-			wallet.Balance += order.TotalAmount
-			if err := tx.Save(&wallet).Error; err != nil {
+			if err := repository.Wallets(tx).Refund(&wallet, order.TotalAmount); err != nil {
 				return err
 			}
 
@@ -279,7 +300,7 @@ func CustomerAppCancelOrder(c *gin.Context) {
 				Amount:      order.TotalAmount,
 				Status:      models.TransactionTypeCredit,
 				Description: fmt.Sprintf("Refund for order #%d", order.ID),
-				CreatedAt:   time.Now(),
+				CreatedAt:   ctrl.Clock.Now(),
 			}
 			if err := tx.Create(&transaction).Error; err != nil {
 				return err
@@ -287,20 +308,15 @@ func CustomerAppCancelOrder(c *gin.Context) {
 		}
 
 		// Restore inventory
-		var orderItems []models.OrderItem
-		if err := tx.Where("order_id = ?", order.ID).Find(&orderItems).Error; err != nil {
+		orderItems, err := repository.Orders(tx).Items(order.ID)
+		if err != nil {
 			return err
 		}
 
 		for _, item := range orderItems {
-			// Find inventory
-			var inventory models.Inventory
-			if err := tx.Where("product_id = ? AND outlet_id = ?", item.ProductID, order.OutletID).First(&inventory).Error; err == nil {
-				// Add stock back
-				inventory.Quantity += item.Quantity
-				if err := tx.Save(&inventory).Error; err != nil {
-					return err
-				}
+			// Add stock back
+			if err := repository.Inventory(tx).Adjust(order.OutletID, item.ProductID, item.Quantity); err != nil {
+				return err
 			}
 		}
 
@@ -308,10 +324,7 @@ func CustomerAppCancelOrder(c *gin.Context) {
 	})
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Failed to cancel order and process refund",
-			"error":   err.Error(),
-		})
+		apperror.Abort(c, apperror.Internal("Failed to cancel order and process refund", err))
 		return
 	}
 
@@ -323,13 +336,11 @@ func CustomerAppCancelOrder(c *gin.Context) {
 }
 
 // CreateRazorpayOrder creates a Razorpay order for payment
-func CreateRazorpayOrder(c *gin.Context) {
-	var req struct {
-		Amount float64 `json:"amount" binding:"required"`
-	}
+func (ctrl *Controller) CreateRazorpayOrder(c *gin.Context) {
+	var req dto.AmountRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Amount is required"})
+		apperror.Abort(c, apperror.Binding(err, "Amount is required"))
 		return
 	}
 
@@ -343,9 +354,9 @@ func CreateRazorpayOrder(c *gin.Context) {
 	}
 
 	// Create order with reference notes
-	order, err := services.CreateRazorpayOrder(req.Amount, "INR", fmt.Sprintf("order_%d_%d", userID, time.Now().Unix()))
+	order, err := ctrl.Payments.CreateOrder(c.Request.Context(), req.Amount, "INR", fmt.Sprintf("order_%d_%d", userID, ctrl.Clock.Now().Unix()))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to create Razorpay order", "error": err.Error()})
+		apperror.Abort(c, apperror.Internal("Failed to create Razorpay order", err))
 		return
 	}
 
@@ -356,21 +367,17 @@ func CreateRazorpayOrder(c *gin.Context) {
 }
 
 // VerifyRazorpayPayment verifies Razorpay payment
-func VerifyRazorpayPayment(c *gin.Context) {
-	var req struct {
-		RazorpayOrderID   string `json:"razorpay_order_id" binding:"required"`
-		RazorpayPaymentID string `json:"razorpay_payment_id" binding:"required"`
-		RazorpaySignature string `json:"razorpay_signature" binding:"required"`
-	}
+func (ctrl *Controller) VerifyRazorpayPayment(c *gin.Context) {
+	var req dto.RazorpayPaymentRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Missing payment details"})
+		apperror.Abort(c, apperror.Binding(err, "Missing payment details"))
 		return
 	}
 
-	isValid := services.VerifyPaymentSignature(req.RazorpayOrderID, req.RazorpayPaymentID, req.RazorpaySignature)
+	isValid := ctrl.Payments.VerifySignature(req.RazorpayOrderID, req.RazorpayPaymentID, req.RazorpaySignature)
 	if !isValid {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid payment signature"})
+		apperror.Abort(c, apperror.BadRequest("Invalid payment signature"))
 		return
 	}
 
@@ -1,41 +1,45 @@
 package customer
 
 import (
-	"backend_pandhi/pkg/database"
+	"backend_pandhi/pkg/apperror"
+	"backend_pandhi/pkg/dto"
+	"backend_pandhi/pkg/media"
 	"backend_pandhi/pkg/models"
-	"backend_pandhi/pkg/services"
+	"backend_pandhi/pkg/repository"
+	"backend_pandhi/pkg/utils"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // GetProfile retrieves the customer's profile information
-func GetProfile(c *gin.Context) {
+func (ctrl *Controller) GetProfile(c *gin.Context) {
 	// Get user from context
 	userInterface, exists := c.Get("user")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "User not found."})
+		apperror.Abort(c, apperror.Unauthorized("User not found."))
 		return
 	}
 
 	user, ok := userInterface.(models.User)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid user data."})
+		apperror.Abort(c, apperror.Unauthorized("Invalid user data."))
 		return
 	}
 
 	// Load customer info
 	var userWithCustomer models.User
-	if err := database.DB.
+	if err := ctrl.DB.WithContext(c.Request.Context()).
 		Preload("CustomerInfo").
 		First(&userWithCustomer, user.ID).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal server error", "error": err.Error()})
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
 		return
 	}
 
 	if userWithCustomer.CustomerInfo == nil {
-		c.JSON(http.StatusNotFound, gin.H{"message": "Customer not found"})
+		apperror.Abort(c, apperror.NotFound("Customer not found"))
 		return
 	}
 
@@ -52,7 +56,7 @@ func GetProfile(c *gin.Context) {
 	}
 
 	if userWithCustomer.ImageURL != nil {
-		signedURL, _ := services.GetSignedURL(*userWithCustomer.ImageURL)
+		signedURL, _ := ctrl.Storage.GetSignedURL(*userWithCustomer.ImageURL)
 		response["imageUrl"] = signedURL
 	}
 
@@ -60,20 +64,13 @@ func GetProfile(c *gin.Context) {
 }
 
 // EditProfile updates the customer's profile information
-func EditProfile(c *gin.Context) {
-	var req struct {
-		Name        *string                `json:"name" form:"name"`
-		Phone       *string                `json:"phone" form:"phone"`
-		Email       *string                `json:"email" form:"email"`
-		Bio         *string                `json:"bio" form:"bio"`
-		YearOfStudy *string                `json:"yearOfStudy" form:"yearOfStudy"`
-		Degree      *models.TypeOfDegree   `json:"degree" form:"degree"`
-	}
+func (ctrl *Controller) EditProfile(c *gin.Context) {
+	var req dto.EditProfileRequest
 
 	if err := c.ShouldBind(&req); err != nil {
 		// Check if at least one field is provided
 		if req.Name == nil && req.Phone == nil && req.Email == nil && req.Bio == nil && req.YearOfStudy == nil && req.Degree == nil {
-			c.JSON(http.StatusBadRequest, gin.H{"message": "No updates provided"})
+			apperror.Abort(c, apperror.BadRequest("No updates provided"))
 			return
 		}
 	}
@@ -81,27 +78,27 @@ func EditProfile(c *gin.Context) {
 	// Get user from context
 	userInterface, exists := c.Get("user")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "User not found."})
+		apperror.Abort(c, apperror.Unauthorized("User not found."))
 		return
 	}
 
 	user, ok := userInterface.(models.User)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid user data."})
+		apperror.Abort(c, apperror.Unauthorized("Invalid user data."))
 		return
 	}
 
 	// Load existing user with customer info
 	var existingUser models.User
-	if err := database.DB.
+	if err := ctrl.DB.WithContext(c.Request.Context()).
 		Preload("CustomerInfo").
 		First(&existingUser, user.ID).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal server error", "error": err.Error()})
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
 		return
 	}
 
 	if existingUser.CustomerInfo == nil {
-		c.JSON(http.StatusNotFound, gin.H{"message": "Customer not found"})
+		apperror.Abort(c, apperror.NotFound("Customer not found"))
 		return
 	}
 
@@ -111,15 +108,19 @@ func EditProfile(c *gin.Context) {
 		f, err := file.Open()
 		if err == nil {
 			defer f.Close()
-			imageURL, err := services.UploadImageFromReader(f, file.Filename)
+			imageURL, err := ctrl.Storage.UploadImageFromReader(c.Request.Context(), f, file.Filename)
 			if err == nil {
-				// Delete old image
-				if existingUser.ImageURL != nil {
-					_ = services.DeleteImage(*existingUser.ImageURL)
+				// Update image URL and delete the old image once that commits
+				previous := existingUser.ImageURL
+				if err := ctrl.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+					if err := repository.Users(tx).SetImageURL(&existingUser, &imageURL); err != nil {
+						return err
+					}
+					return media.QueueDelete(tx, previous)
+				}); err != nil {
+					apperror.Abort(c, apperror.Internal("Failed to update profile image", err))
+					return
 				}
-				// Update image URL
-				database.DB.Model(&existingUser).Update("image_url", imageURL)
-				existingUser.ImageURL = &imageURL
 			}
 		}
 	}
@@ -130,14 +131,28 @@ func EditProfile(c *gin.Context) {
 		updates["name"] = *req.Name
 	}
 	if req.Phone != nil {
-		updates["phone"] = *req.Phone
+		phone, err := utils.NormalizePhone(ctrl.Config, *req.Phone)
+		if err != nil {
+			apperror.Abort(c, apperror.BadRequest("Invalid phone number"))
+			return
+		}
+		var other models.User
+		if ctrl.DB.WithContext(c.Request.Context()).Where("phone = ? AND id <> ?", phone, existingUser.ID).First(&other).Error == nil {
+			apperror.Abort(c, apperror.BadRequest("Phone number is already registered"))
+			return
+		}
+		updates["phone"] = phone
 	}
 	if req.Email != nil {
 		updates["email"] = *req.Email
+		// A new address has to be verified again
+		if *req.Email != existingUser.Email {
+			updates["isVerified"] = false
+		}
 	}
 
 	if len(updates) > 0 {
-		database.DB.Model(&existingUser).Updates(updates)
+		ctrl.DB.WithContext(c.Request.Context()).Model(&existingUser).Updates(updates)
 	}
 
 	// Update customer info fields
@@ -156,11 +171,11 @@ func EditProfile(c *gin.Context) {
 	}
 
 	if len(customerUpdates) > 0 {
-		database.DB.Model(&existingUser.CustomerInfo).Updates(customerUpdates)
+		ctrl.DB.WithContext(c.Request.Context()).Model(&existingUser.CustomerInfo).Updates(customerUpdates)
 	}
 
 	// Reload user with updated data
-	database.DB.Preload("CustomerInfo").First(&existingUser, user.ID)
+	ctrl.DB.WithContext(c.Request.Context()).Preload("CustomerInfo").First(&existingUser, user.ID)
 
 	responseUser := gin.H{
 		"id":          existingUser.ID,
@@ -174,7 +189,7 @@ func EditProfile(c *gin.Context) {
 	}
 
 	if existingUser.ImageURL != nil {
-		signedURL, _ := services.GetSignedURL(*existingUser.ImageURL)
+		signedURL, _ := ctrl.Storage.GetSignedURL(*existingUser.ImageURL)
 		responseUser["imageUrl"] = signedURL
 	}
 
@@ -1,53 +1,66 @@
 package customer
 
 import (
-	"backend_pandhi/pkg/database"
+	"backend_pandhi/pkg/apperror"
+	"backend_pandhi/pkg/dto"
 	"backend_pandhi/pkg/models"
+	"backend_pandhi/pkg/pagination"
 	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+// CustomerTicketsList lists the sorts and filters accepted by GetCustomerTickets
+var CustomerTicketsList = pagination.Spec{
+	DefaultLimit: 20,
+	MaxLimit:     100,
+	DefaultSort:  "-createdAt",
+	Sorts: map[string]pagination.Sort{
+		"createdAt": {Column: `"createdAt"`, Field: "CreatedAt", Type: pagination.TypeTime},
+	},
+	Filters: map[string]pagination.Filter{
+		"status": {
+			Column: "status",
+			Type:   pagination.TypeString,
+			Values: []string{string(models.TicketStatusOpen), string(models.TicketStatusInProgress), string(models.TicketStatusClosed)},
+		},
+	},
+}
+
 // CreateTicket creates a new support ticket
-func CreateTicket(c *gin.Context) {
-	var req struct {
-		Title       string `json:"title" binding:"required"`
-		Description string `json:"description" binding:"required"`
-		Priority    string `json:"priority" binding:"required"`
-		IssueType   string `json:"issueType"`
-	}
+func (ctrl *Controller) CreateTicket(c *gin.Context) {
+	var req dto.CreateTicketRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Please provide title, description, and priority"})
+		apperror.Abort(c, apperror.Binding(err, "Please provide title, description, and priority"))
 		return
 	}
 
 	// Get user from context
 	userInterface, exists := c.Get("user")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "User not found."})
+		apperror.Abort(c, apperror.Unauthorized("User not found."))
 		return
 	}
 
 	user, ok := userInterface.(models.User)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid user data."})
+		apperror.Abort(c, apperror.Unauthorized("Invalid user data."))
 		return
 	}
 
 	// Load customer info
 	var userWithCustomer models.User
-	if err := database.DB.Preload("CustomerInfo").First(&userWithCustomer, user.ID).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal server error"})
+	if err := ctrl.DB.WithContext(c.Request.Context()).Preload("CustomerInfo").First(&userWithCustomer, user.ID).Error; err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
 		return
 	}
 
 	if userWithCustomer.CustomerInfo == nil {
-		c.JSON(http.StatusNotFound, gin.H{"message": "Customer not found"})
+		apperror.Abort(c, apperror.NotFound("Customer not found"))
 		return
 	}
 
@@ -60,13 +73,13 @@ func CreateTicket(c *gin.Context) {
 		Status:      models.TicketStatusOpen,
 	}
 
-	if err := database.DB.Create(&ticket).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal server error"})
+	if err := ctrl.DB.WithContext(c.Request.Context()).Create(&ticket).Error; err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
 		return
 	}
 
 	// Reload with customer info
-	database.DB.Preload("Customer.User").First(&ticket, ticket.ID)
+	ctrl.DB.WithContext(c.Request.Context()).Preload("Customer.User").First(&ticket, ticket.ID)
 
 	issueType := req.IssueType
 	if issueType == "" {
@@ -77,7 +90,7 @@ func CreateTicket(c *gin.Context) {
 		"message": "Ticket created successfully",
 		"ticket": gin.H{
 			"id":           ticket.ID,
-			"ticketNumber": fmt.Sprintf("TKT-%d-%03d", time.Now().Year(), ticket.ID),
+			"ticketNumber": fmt.Sprintf("TKT-%d-%03d", ctrl.Clock.Now().Year(), ticket.ID),
 			"title":        ticket.Title,
 			"description":  ticket.Description,
 			"priority":     ticket.Priority,
@@ -89,42 +102,48 @@ func CreateTicket(c *gin.Context) {
 }
 
 // GetCustomerTickets retrieves all tickets for the authenticated customer
-func GetCustomerTickets(c *gin.Context) {
+func (ctrl *Controller) GetCustomerTickets(c *gin.Context) {
 	// Get user from context
 	userInterface, exists := c.Get("user")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "User not found."})
+		apperror.Abort(c, apperror.Unauthorized("User not found."))
 		return
 	}
 
 	user, ok := userInterface.(models.User)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid user data."})
+		apperror.Abort(c, apperror.Unauthorized("Invalid user data."))
 		return
 	}
 
 	// Load customer info
 	var userWithCustomer models.User
-	if err := database.DB.Preload("CustomerInfo").First(&userWithCustomer, user.ID).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal server error"})
+	if err := ctrl.DB.WithContext(c.Request.Context()).Preload("CustomerInfo").First(&userWithCustomer, user.ID).Error; err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
 		return
 	}
 
 	if userWithCustomer.CustomerInfo == nil {
-		c.JSON(http.StatusNotFound, gin.H{"message": "Customer not found"})
+		apperror.Abort(c, apperror.NotFound("Customer not found"))
+		return
+	}
+
+	list, err := pagination.Parse(c, CustomerTicketsList)
+	if err != nil {
+		apperror.Abort(c, err)
 		return
 	}
 
 	// Fetch tickets
 	var tickets []models.Ticket
-	if err := database.DB.
-		Where("customer_id = ?", userWithCustomer.CustomerInfo.ID).
-		Order("created_at DESC").
+	if err := list.Apply(ctrl.DB.WithContext(c.Request.Context()).
+		Where(`"customerId" = ? AND NOT internal`, userWithCustomer.CustomerInfo.ID)).
 		Preload("Customer.User").
 		Find(&tickets).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal server error"})
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
 		return
 	}
+	tickets, meta := pagination.Trim(list, tickets)
 
 	// Format tickets
 	var ongoing []gin.H
@@ -187,50 +206,51 @@ func GetCustomerTickets(c *gin.Context) {
 			"ongoing":   ongoing,
 			"completed": completed,
 		},
+		"pagination": meta,
 	})
 }
 
 // GetTicketDetails retrieves details of a specific ticket
-func GetTicketDetails(c *gin.Context) {
+func (ctrl *Controller) GetTicketDetails(c *gin.Context) {
 	ticketIDStr := c.Param("ticketId")
 	ticketID, err := strconv.Atoi(ticketIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Provide valid ticket ID"})
+		apperror.Abort(c, apperror.BadRequest("Provide valid ticket ID"))
 		return
 	}
 
 	// Get user from context
 	userInterface, exists := c.Get("user")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "User not found."})
+		apperror.Abort(c, apperror.Unauthorized("User not found."))
 		return
 	}
 
 	user, ok := userInterface.(models.User)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid user data."})
+		apperror.Abort(c, apperror.Unauthorized("Invalid user data."))
 		return
 	}
 
 	// Load customer info
 	var userWithCustomer models.User
-	if err := database.DB.Preload("CustomerInfo").First(&userWithCustomer, user.ID).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal server error"})
+	if err := ctrl.DB.WithContext(c.Request.Context()).Preload("CustomerInfo").First(&userWithCustomer, user.ID).Error; err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
 		return
 	}
 
 	if userWithCustomer.CustomerInfo == nil {
-		c.JSON(http.StatusNotFound, gin.H{"message": "Customer not found"})
+		apperror.Abort(c, apperror.NotFound("Customer not found"))
 		return
 	}
 
 	// Fetch ticket
 	var ticket models.Ticket
-	if err := database.DB.
-		Where("id = ? AND customer_id = ?", ticketID, userWithCustomer.CustomerInfo.ID).
+	if err := ctrl.DB.WithContext(c.Request.Context()).
+		Where(`id = ? AND "customerId" = ? AND NOT internal`, ticketID, userWithCustomer.CustomerInfo.ID).
 		Preload("Customer.User").
 		First(&ticket).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"message": "Ticket not found"})
+		apperror.Abort(c, apperror.NotFound("Ticket not found"))
 		return
 	}
 
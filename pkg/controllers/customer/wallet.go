@@ -1,12 +1,14 @@
 package customer
 
 import (
-	"backend_pandhi/pkg/database"
+	"backend_pandhi/pkg/apperror"
+	"backend_pandhi/pkg/dto"
+	"backend_pandhi/pkg/metrics"
 	"backend_pandhi/pkg/models"
-	"backend_pandhi/pkg/services"
+	"backend_pandhi/pkg/repository"
+	"errors"
 	"fmt"
 	"net/http"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -15,46 +17,44 @@ import (
 // Removed deprecated razorpayService init
 
 // CreateWalletRechargeOrder creates a Razorpay order for wallet recharge
-func CreateWalletRechargeOrder(c *gin.Context) {
-	var req struct {
-		Amount float64 `json:"amount" binding:"required"`
-	}
+func (ctrl *Controller) CreateWalletRechargeOrder(c *gin.Context) {
+	var req dto.AmountRequest
 
-	if err := c.ShouldBindJSON(&req); err != nil || req.Amount <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "Invalid amount",
-			"error":   "Amount must be greater than 0",
-		})
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.Abort(c, apperror.Binding(err, "Invalid amount"))
+		return
+	}
+	if req.Amount <= 0 {
+		apperror.Abort(c, apperror.Validation("Invalid amount", apperror.FieldError{
+			Field:   "amount",
+			Message: "must be greater than 0",
+		}))
 		return
 	}
 
 	// Get user from context
 	userInterface, exists := c.Get("user")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "User not found."})
+		apperror.Abort(c, apperror.Unauthorized("User not found."))
 		return
 	}
 
 	user, ok := userInterface.(models.User)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid user data."})
+		apperror.Abort(c, apperror.Unauthorized("Invalid user data."))
 		return
 	}
 
 	// Get customer details
-	var customer models.CustomerDetails
-	if err := database.DB.Where("user_id = ?", user.ID).First(&customer).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"message": "Customer details not found"})
+	if _, err := repository.Users(ctrl.DB.WithContext(c.Request.Context())).Customer(user.ID); err != nil {
+		apperror.Abort(c, apperror.NotFound("Customer details not found"))
 		return
 	}
 
 	// Create Razorpay order
-	order, err := services.CreateRazorpayOrder(req.Amount, "INR", string(rune(user.ID)))
+	order, err := ctrl.Payments.CreateOrder(c.Request.Context(), req.Amount, "INR", string(rune(user.ID)))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Failed to create payment order",
-			"error":   err.Error(),
-		})
+		apperror.Abort(c, apperror.Internal("Failed to create payment order", err))
 		return
 	}
 
@@ -77,37 +77,30 @@ func CreateWalletRechargeOrder(c *gin.Context) {
 }
 
 // VerifyWalletRecharge verifies payment and processes wallet recharge
-func VerifyWalletRecharge(c *gin.Context) {
-	var req struct {
-		RazorpayOrderID   string `json:"razorpay_order_id" binding:"required"`
-		RazorpayPaymentID string `json:"razorpay_payment_id" binding:"required"`
-		RazorpaySignature string `json:"razorpay_signature" binding:"required"`
-	}
+func (ctrl *Controller) VerifyWalletRecharge(c *gin.Context) {
+	var req dto.RazorpayPaymentRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Missing payment verification details"})
+		apperror.Abort(c, apperror.Binding(err, "Missing payment verification details"))
 		return
 	}
 
 	// Get user
 	userInterface, exists := c.Get("user")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "User not found."})
+		apperror.Abort(c, apperror.Unauthorized("User not found."))
 		return
 	}
 
 	user, ok := userInterface.(models.User)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid user data."})
+		apperror.Abort(c, apperror.Unauthorized("Invalid user data."))
 		return
 	}
 
 	// Verify signature
-	if !services.VerifyPaymentSignature(req.RazorpayOrderID, req.RazorpayPaymentID, req.RazorpaySignature) {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "Payment verification failed",
-			"error":   "Invalid signature",
-		})
+	if !ctrl.Payments.VerifySignature(req.RazorpayOrderID, req.RazorpayPaymentID, req.RazorpaySignature) {
+		apperror.Abort(c, apperror.BadRequest("Payment verification failed").WithCode("INVALID_SIGNATURE"))
 		return
 	}
 
@@ -145,28 +138,22 @@ func VerifyWalletRecharge(c *gin.Context) {
 	// I will add FetchPaymentDetails to `pkg/services/razorpay.go` in a subsequent step.
 	// For this file, I'll update it to call `services.FetchPaymentDetails`.
 	
-	payment, err := services.FetchPaymentDetails(req.RazorpayPaymentID)
+	payment, err := ctrl.Payments.FetchPayment(c.Request.Context(), req.RazorpayPaymentID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Failed to fetch payment details",
-			"error":   err.Error(),
-		})
+		apperror.Abort(c, apperror.Internal("Failed to fetch payment details", err))
 		return
 	}
 
 	status, _ := payment["status"].(string)
 	if status != "captured" && status != "authorized" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "Payment not successful",
-			"status":  status,
-		})
+		apperror.Abort(c, apperror.BadRequest("Payment not successful").With("status", status))
 		return
 	}
 
 	// Get customer
-	var customer models.CustomerDetails
-	if err := database.DB.Where("user_id = ?", user.ID).First(&customer).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"message": "Customer details not found"})
+	customer, err := repository.Users(ctrl.DB.WithContext(c.Request.Context())).Customer(user.ID)
+	if err != nil {
+		apperror.Abort(c, apperror.NotFound("Customer details not found"))
 		return
 	}
 
@@ -176,10 +163,9 @@ func VerifyWalletRecharge(c *gin.Context) {
 		Transaction models.WalletTransaction
 	}
 
-	err = database.DB.Transaction(func(tx *gorm.DB) error {
+	err = ctrl.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
 		// Check if already processed
-		var existing models.WalletTransaction
-		if tx.Where("razorpay_payment_id = ?", req.RazorpayPaymentID).First(&existing).Error == nil {
+		if _, err := repository.Wallets(tx).TransactionByPaymentID(req.RazorpayPaymentID); err == nil {
 			return fmt.Errorf("Payment already processed")
 		}
 
@@ -190,11 +176,10 @@ func VerifyWalletRecharge(c *gin.Context) {
 		grossAmount := float64(payment["amount"].(int)) / 100 // Convert from paise
 
 		// Update wallet
-		var wallet models.Wallet
-		err := tx.Where("customer_id = ?", customer.ID).First(&wallet).Error
-		if err != nil {
+		wallet, err := repository.Wallets(tx).ForCustomer(customer.ID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
 			// Create new wallet
-			now := time.Now()
+			now := ctrl.Clock.Now()
 			wallet = models.Wallet{
 				CustomerID:     customer.ID,
 				Balance:        walletAmount,
@@ -202,15 +187,13 @@ func VerifyWalletRecharge(c *gin.Context) {
 				TotalUsed:      0,
 				LastRecharged:  &now,
 			}
-			tx.Create(&wallet)
-		} else {
-			// Update wallet
-			now := time.Now()
-			tx.Model(&wallet).Updates(map[string]interface{}{
-				"balance":         wallet.Balance + walletAmount,
-				"total_recharged": wallet.TotalRecharged + walletAmount,
-				"last_recharged":  &now,
-			})
+			if err := tx.Create(&wallet).Error; err != nil {
+				return err
+			}
+		} else if err != nil {
+			return err
+		} else if err := repository.Wallets(tx).Recharge(&wallet, walletAmount, ctrl.Clock.Now()); err != nil {
+			return err
 		}
 
 		// Create transaction
@@ -229,7 +212,9 @@ func VerifyWalletRecharge(c *gin.Context) {
 			RazorpayOrderID:    &req.RazorpayOrderID,
 			Status:             models.WalletTransTypeRecharge,
 		}
-		tx.Create(&transaction)
+		if err := tx.Create(&transaction).Error; err != nil {
+			return err
+		}
 
 		result.Wallet = wallet
 		result.Transaction = transaction
@@ -238,19 +223,15 @@ func VerifyWalletRecharge(c *gin.Context) {
 
 	if err != nil {
 		if err.Error() == "Payment already processed" {
-			c.JSON(http.StatusConflict, gin.H{
-				"message": "Payment already processed",
-				"error":   err.Error(),
-			})
+			apperror.Abort(c, apperror.Conflict("Payment already processed").Wrap(err))
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"message": "Wallet recharge verification failed",
-				"error":   err.Error(),
-			})
+			apperror.Abort(c, apperror.Internal("Wallet recharge verification failed", err))
 		}
 		return
 	}
 
+	metrics.WalletRecharged(result.Transaction.Method, result.Transaction.Amount)
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Wallet recharged successfully",
 		"wallet": gin.H{
@@ -275,30 +256,29 @@ func VerifyWalletRecharge(c *gin.Context) {
 }
 
 // GetWalletDetails retrieves the customer's wallet details
-func GetWalletDetails(c *gin.Context) {
+func (ctrl *Controller) GetWalletDetails(c *gin.Context) {
 	// Get user
 	userInterface, exists := c.Get("user")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "User not found."})
+		apperror.Abort(c, apperror.Unauthorized("User not found."))
 		return
 	}
 
 	user, ok := userInterface.(models.User)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid user data."})
+		apperror.Abort(c, apperror.Unauthorized("Invalid user data."))
 		return
 	}
 
 	// Get customer
-	var customer models.CustomerDetails
-	if err := database.DB.Where("user_id = ?", user.ID).First(&customer).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"message": "Customer details not found"})
+	customer, err := repository.Users(ctrl.DB.WithContext(c.Request.Context())).Customer(user.ID)
+	if err != nil {
+		apperror.Abort(c, apperror.NotFound("Customer details not found"))
 		return
 	}
 
 	// Get or create wallet
-	var wallet models.Wallet
-	err := database.DB.Where("customer_id = ?", customer.ID).First(&wallet).Error
+	wallet, err := repository.Wallets(ctrl.DB.WithContext(c.Request.Context())).ForCustomer(customer.ID)
 	if err != nil {
 		wallet = models.Wallet{
 			CustomerID:     customer.ID,
@@ -306,7 +286,7 @@ func GetWalletDetails(c *gin.Context) {
 			TotalRecharged: 0,
 			TotalUsed:      0,
 		}
-		database.DB.Create(&wallet)
+		ctrl.DB.WithContext(c.Request.Context()).Create(&wallet)
 	}
 
 	c.JSON(http.StatusOK, gin.H{
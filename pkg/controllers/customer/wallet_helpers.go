@@ -1,39 +1,43 @@
 package customer
 
 import (
-	"backend_pandhi/pkg/database"
+	"backend_pandhi/pkg/apperror"
+	"backend_pandhi/pkg/dto"
 	"backend_pandhi/pkg/models"
+	"backend_pandhi/pkg/pagination"
+	"backend_pandhi/pkg/repository"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 )
 
 // RechargeWallet handles legacy cash wallet recharge (manual)
-func RechargeWallet(c *gin.Context) {
-	var req struct {
-		Amount float64 `json:"amount" binding:"required"`
-	}
+func (ctrl *Controller) RechargeWallet(c *gin.Context) {
+	var req dto.AmountRequest
 
-	if err := c.ShouldBindJSON(&req); err != nil || req.Amount <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid amount"})
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.Abort(c, apperror.Binding(err, "Invalid amount"))
+		return
+	}
+	if req.Amount <= 0 {
+		apperror.Abort(c, apperror.Validation("Invalid amount", apperror.FieldError{Field: "amount", Message: "must be greater than 0"}))
 		return
 	}
 
 	userInterface, exists := c.Get("user")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "User not found."})
+		apperror.Abort(c, apperror.Unauthorized("User not found."))
 		return
 	}
 
 	user, ok := userInterface.(models.User)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid user data."})
+		apperror.Abort(c, apperror.Unauthorized("Invalid user data."))
 		return
 	}
 
-	var customer models.CustomerDetails
-	if err := database.DB.Where("user_id = ?", user.ID).First(&customer).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"message": "Customer not found"})
+	if _, err := repository.Users(ctrl.DB.WithContext(c.Request.Context())).Customer(user.ID); err != nil {
+		apperror.Abort(c, apperror.NotFound("Customer not found"))
 		return
 	}
 
@@ -45,36 +49,32 @@ func RechargeWallet(c *gin.Context) {
 }
 
 // RecentTrans retrieves recent wallet transactions
-func RecentTrans(c *gin.Context) {
+func (ctrl *Controller) RecentTrans(c *gin.Context) {
 	userInterface, exists := c.Get("user")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "User not found."})
+		apperror.Abort(c, apperror.Unauthorized("User not found."))
 		return
 	}
 
 	user, ok := userInterface.(models.User)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid user data."})
+		apperror.Abort(c, apperror.Unauthorized("Invalid user data."))
 		return
 	}
 
-	var customer models.CustomerDetails
-	if err := database.DB.Where("user_id = ?", user.ID).First(&customer).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"message": "Customer not found"})
+	customer, err := repository.Users(ctrl.DB.WithContext(c.Request.Context())).Customer(user.ID)
+	if err != nil {
+		apperror.Abort(c, apperror.NotFound("Customer not found"))
 		return
 	}
 
-	var wallet models.Wallet
-	if err := database.DB.Where("customer_id = ?", customer.ID).First(&wallet).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"message": "Wallet not found"})
+	wallet, err := repository.Wallets(ctrl.DB.WithContext(c.Request.Context())).ForCustomer(customer.ID)
+	if err != nil {
+		apperror.Abort(c, apperror.NotFound("Wallet not found"))
 		return
 	}
 
-	var transactions []models.WalletTransaction
-	database.DB.Where("wallet_id = ?", wallet.ID).
-		Order("created_at DESC").
-		Limit(10).
-		Find(&transactions)
+	transactions, _ := repository.Wallets(ctrl.DB.WithContext(c.Request.Context()).Limit(10)).Transactions(wallet.ID)
 
 	result := make([]gin.H, len(transactions))
 	for i, tx := range transactions {
@@ -93,36 +93,60 @@ func RecentTrans(c *gin.Context) {
 	})
 }
 
+// RechargeHistoryList lists the sorts and filters accepted by GetRechargeHistory
+var RechargeHistoryList = pagination.Spec{
+	DefaultLimit: 20,
+	MaxLimit:     100,
+	DefaultSort:  "-createdAt",
+	Sorts: map[string]pagination.Sort{
+		"createdAt": {Column: `"createdAt"`, Field: "CreatedAt", Type: pagination.TypeTime},
+		"amount":    {Column: "amount", Field: "Amount", Type: pagination.TypeFloat},
+	},
+	Filters: map[string]pagination.Filter{
+		"createdAt": {Column: `"createdAt"`, Type: pagination.TypeTime, Ops: pagination.Range},
+	},
+}
+
 // GetRechargeHistory retrieves wallet recharge history
-func GetRechargeHistory(c *gin.Context) {
+func (ctrl *Controller) GetRechargeHistory(c *gin.Context) {
 	userInterface, exists := c.Get("user")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "User not found."})
+		apperror.Abort(c, apperror.Unauthorized("User not found."))
 		return
 	}
 
 	user, ok := userInterface.(models.User)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid user data."})
+		apperror.Abort(c, apperror.Unauthorized("Invalid user data."))
+		return
+	}
+
+	list, err := pagination.Parse(c, RechargeHistoryList)
+	if err != nil {
+		apperror.Abort(c, err)
 		return
 	}
 
-	var customer models.CustomerDetails
-	if err := database.DB.Where("user_id = ?", user.ID).First(&customer).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"message": "Customer not found"})
+	customer, err := repository.Users(ctrl.DB.WithContext(c.Request.Context())).Customer(user.ID)
+	if err != nil {
+		apperror.Abort(c, apperror.NotFound("Customer not found"))
 		return
 	}
 
-	var wallet models.Wallet
-	if err := database.DB.Where("customer_id = ?", customer.ID).First(&wallet).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"message": "Wallet not found"})
+	wallet, err := repository.Wallets(ctrl.DB.WithContext(c.Request.Context())).ForCustomer(customer.ID)
+	if err != nil {
+		apperror.Abort(c, apperror.NotFound("Wallet not found"))
 		return
 	}
 
 	var transactions []models.WalletTransaction
-	database.DB.Where("wallet_id = ? AND status = ?", wallet.ID, models.WalletTransTypeRecharge).
-		Order("created_at DESC").
-		Find(&transactions)
+	if err := list.Apply(repository.Wallets(ctrl.DB.WithContext(c.Request.Context())).
+		TransactionQuery(wallet.ID, models.WalletTransTypeRecharge)).
+		Find(&transactions).Error; err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
+		return
+	}
+	transactions, meta := pagination.Trim(list, transactions)
 
 	result := make([]gin.H, len(transactions))
 	for i, tx := range transactions {
@@ -138,17 +162,20 @@ func GetRechargeHistory(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"message":        "Recharge history retrieved",
 		"rechargeHistory": result,
+		"pagination":      meta,
 	})
 }
 
 // GetServiceChargeBreakdown returns service charge breakdown (currently 0%)
-func GetServiceChargeBreakdown(c *gin.Context) {
-	var req struct {
-		Amount float64 `json:"amount" binding:"required"`
-	}
+func (ctrl *Controller) GetServiceChargeBreakdown(c *gin.Context) {
+	var req dto.AmountRequest
 
-	if err := c.ShouldBindJSON(&req); err != nil || req.Amount <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid amount"})
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.Abort(c, apperror.Binding(err, "Invalid amount"))
+		return
+	}
+	if req.Amount <= 0 {
+		apperror.Abort(c, apperror.Validation("Invalid amount", apperror.FieldError{Field: "amount", Message: "must be greater than 0"}))
 		return
 	}
 
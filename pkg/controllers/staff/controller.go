@@ -0,0 +1,13 @@
+package staff
+
+import "backend_pandhi/pkg/app"
+
+// Controller serves the staff app endpoints
+type Controller struct {
+	*app.App
+}
+
+// New returns a controller backed by a
+func New(a *app.App) *Controller {
+	return &Controller{App: a}
+}
@@ -0,0 +1,173 @@
+package staff
+
+import (
+	"backend_pandhi/pkg/apperror"
+	"backend_pandhi/pkg/audit"
+	"backend_pandhi/pkg/dto"
+	"backend_pandhi/pkg/models"
+	"backend_pandhi/pkg/moderation"
+	"backend_pandhi/pkg/pagination"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// FeedbackList lists the sorts and filters accepted by GetFeedback
+var FeedbackList = pagination.Spec{
+	DefaultLimit: 50,
+	MaxLimit:     200,
+	DefaultSort:  "-createdAt",
+	Sorts: map[string]pagination.Sort{
+		"createdAt":     {Column: `"Feedback"."createdAt"`, Field: "CreatedAt", Type: pagination.TypeTime},
+		"ratingOverall": {Column: `"Feedback"."ratingOverall"`, Field: "RatingOverall", Type: pagination.TypeFloat},
+	},
+	Filters: map[string]pagination.Filter{
+		"productId":     {Column: `"Feedback"."productId"`, Type: pagination.TypeInt},
+		"ratingOverall": {Column: `"Feedback"."ratingOverall"`, Type: pagination.TypeFloat, Ops: pagination.Range},
+		"createdAt":     {Column: `"Feedback"."createdAt"`, Type: pagination.TypeTime, Ops: pagination.Range},
+	},
+	IDColumn: `"Feedback".id`,
+}
+
+// errOtherOutlet means the feedback is on another outlet's product
+var errOtherOutlet = errors.New("feedback belongs to another outlet")
+
+// GetFeedback returns a page of the feedback on the outlet's products that
+// an admin has not hidden
+func (ctrl *Controller) GetFeedback(c *gin.Context) {
+	userInterface, exists := c.Get("user")
+	if !exists {
+		apperror.Abort(c, apperror.Unauthorized("User not found."))
+		return
+	}
+
+	user, ok := userInterface.(models.User)
+	if !ok {
+		apperror.Abort(c, apperror.Unauthorized("Invalid user data."))
+		return
+	}
+
+	if user.OutletID == nil {
+		apperror.Abort(c, apperror.BadRequest("Staff not assigned to outlet"))
+		return
+	}
+
+	list, err := pagination.Parse(c, FeedbackList)
+	if err != nil {
+		apperror.Abort(c, err)
+		return
+	}
+
+	var feedback []models.Feedback
+	if err := list.Apply(ctrl.DB.WithContext(c.Request.Context()).
+		Joins(`JOIN "Product" ON "Product".id = "Feedback"."productId"`).
+		Where(`"Product"."outletId" = ? AND "Feedback".status <> ?`, *user.OutletID, models.FeedbackStatusHidden)).
+		Preload("Product").
+		Preload("User").
+		Find(&feedback).Error; err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
+		return
+	}
+	feedback, meta := pagination.Trim(list, feedback)
+
+	allFeedback := make([]gin.H, len(feedback))
+	for i, f := range feedback {
+		allFeedback[i] = gin.H{
+			"id":               f.ID,
+			"orderId":          f.OrderID,
+			"productId":        f.ProductID,
+			"productName":      f.Product.Name,
+			"customerName":     f.User.Name,
+			"ratingOverall":    f.RatingOverall,
+			"ratingTaste":      f.RatingTaste,
+			"ratingQuality":    f.RatingQuality,
+			"ratingQuantity":   f.RatingQuantity,
+			"comment":          f.Comment,
+			"createdAt":        f.CreatedAt,
+			"status":           f.Status,
+			"moderationReason": f.ModerationReason,
+			"moderatedAt":      f.ModeratedAt,
+			"reply":            f.Reply,
+			"repliedAt":        f.RepliedAt,
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"feedback":   allFeedback,
+		"pagination": meta,
+	})
+}
+
+// ReplyToFeedback sets the outlet's public reply to feedback on one of its
+// products
+func (ctrl *Controller) ReplyToFeedback(c *gin.Context) {
+	userInterface, exists := c.Get("user")
+	if !exists {
+		apperror.Abort(c, apperror.Unauthorized("User not found."))
+		return
+	}
+
+	user, ok := userInterface.(models.User)
+	if !ok {
+		apperror.Abort(c, apperror.Unauthorized("Invalid user data."))
+		return
+	}
+
+	feedbackID, err := strconv.Atoi(c.Param("feedbackId"))
+	if err != nil {
+		apperror.Abort(c, apperror.BadRequest("Valid feedbackId is required"))
+		return
+	}
+
+	var req dto.FeedbackReplyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.Abort(c, apperror.Binding(err, "Provide a reply"))
+		return
+	}
+	if strings.TrimSpace(req.Reply) == "" {
+		apperror.Abort(c, apperror.BadRequest("Provide a reply"))
+		return
+	}
+
+	var feedback models.Feedback
+	err = ctrl.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		before, err := moderation.Find(tx, feedbackID)
+		if err != nil {
+			return err
+		}
+		if user.OutletID == nil || before.Product.OutletID != *user.OutletID {
+			return errOtherOutlet
+		}
+		if err := moderation.Reply(tx, before, strings.TrimSpace(req.Reply), ctrl.Clock.Now()); err != nil {
+			return err
+		}
+		if err := tx.First(&feedback, feedbackID).Error; err != nil {
+			return err
+		}
+		return audit.Record(c, tx, audit.Entry{
+			Action:     audit.ActionFeedbackReply,
+			EntityType: audit.EntityFeedback,
+			EntityID:   feedbackID,
+			OutletID:   &before.Product.OutletID,
+			Before:     gin.H{"reply": before.Reply},
+			After:      gin.H{"reply": feedback.Reply},
+		})
+	})
+	switch {
+	case errors.Is(err, moderation.ErrNotFound):
+		apperror.Abort(c, apperror.NotFound("Feedback not found"))
+		return
+	case errors.Is(err, errOtherOutlet):
+		apperror.Abort(c, apperror.Forbidden("Feedback belongs to another outlet"))
+		return
+	case err != nil:
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Reply posted", "feedback": feedback})
+}
@@ -1,8 +1,12 @@
 package staff
 
 import (
-	"backend_pandhi/pkg/database"
+	"backend_pandhi/pkg/alerts"
+	"backend_pandhi/pkg/apperror"
+	"backend_pandhi/pkg/dto"
 	"backend_pandhi/pkg/models"
+	"backend_pandhi/pkg/repository"
+	"errors"
 	"fmt"
 	"math"
 	"net/http"
@@ -14,44 +18,36 @@ import (
 )
 
 // GetHomeDetails returns dashboard overview statistics
-func GetHomeDetails(c *gin.Context) {
+func (ctrl *Controller) GetHomeDetails(c *gin.Context) {
 	userInterface, exists := c.Get("user")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "User not found."})
+		apperror.Abort(c, apperror.Unauthorized("User not found."))
 		return
 	}
 
 	user, ok := userInterface.(models.User)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid user data."})
+		apperror.Abort(c, apperror.Unauthorized("Invalid user data."))
 		return
 	}
 
 	// Get outlet ID from user
 	if user.OutletID == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Staff not assigned to outlet"})
+		apperror.Abort(c, apperror.BadRequest("Staff not assigned to outlet"))
 		return
 	}
 
 	outletID := *user.OutletID
 
 	// Calculate order stats
-	type OrderStat struct {
-		Type         models.OrderType
-		DeliverySlot *models.DeliverySlot
-		Count        int64
-		TotalAmount  float64
-	}
-
-	var orderStats []OrderStat
-	database.DB.Model(&models.Order{}).
-		Select("type, delivery_slot, COUNT(*) as count, COALESCE(SUM(total_amount), 0) as total_amount").
-		Where("outlet_id = ? AND status IN ?", outletID, []models.OrderStatus{
+	completed := repository.OrderFilter{
+		OutletID: outletID,
+		Statuses: []models.OrderStatus{
 			models.OrderStatusDelivered,
 			models.OrderStatusPartiallyDelivered,
-		}).
-		Group("type, delivery_slot").
-		Scan(&orderStats)
+		},
+	}
+	orderStats, _ := repository.Orders(ctrl.DB.WithContext(c.Request.Context())).SlotStats(completed)
 
 	totalRevenue := 0.0
 	appOrders := int64(0)
@@ -86,52 +82,26 @@ func GetHomeDetails(c *gin.Context) {
 	}
 
 	// Get best seller
-	type BestSellerResult struct {
-		ProductID    int
-		TotalQuantity int
-	}
-
-	var bestSellerResult BestSellerResult
-	err := database.DB.Model(&models.OrderItem{}).
-		Select("product_id, SUM(quantity) as total_quantity").
-		Joins("JOIN orders ON orders.id = order_items.order_id").
-		Where("orders.outlet_id = ? AND orders.status IN ?", outletID, []models.OrderStatus{
-			models.OrderStatusDelivered,
-			models.OrderStatusPartiallyDelivered,
-		}).
-		Group("product_id").
-		Order("total_quantity DESC").
-		Limit(1).
-		Scan(&bestSellerResult).Error
+	bestSellers, err := repository.Orders(ctrl.DB.WithContext(c.Request.Context()).Limit(1)).ProductQuantities(completed)
 
 	var bestSellerProduct *gin.H
-	if err == nil && bestSellerResult.ProductID > 0 {
+	if err == nil && len(bestSellers) > 0 {
 		var product models.Product
-		if err := database.DB.First(&product, bestSellerResult.ProductID).Error; err == nil {
+		if err := ctrl.DB.WithContext(c.Request.Context()).First(&product, bestSellers[0].ProductID).Error; err == nil {
 			bestSellerProduct = &gin.H{
 				"id":           product.ID,
 				"name":         product.Name,
 				"imageUrl":     product.ImageURL,
-				"quantitySold": bestSellerResult.TotalQuantity,
+				"quantitySold": bestSellers[0].Quantity,
 			}
 		}
 	}
 
 	// Total wallet recharge
-	var totalRechargedAmount float64
-	database.DB.Model(&models.Wallet{}).
-		Select("COALESCE(SUM(total_recharged), 0)").
-		Joins("JOIN customer_details ON customer_details.id = wallets.customer_id").
-		Joins("JOIN users ON users.id = customer_details.user_id").
-		Where("users.outlet_id = ?", outletID).
-		Scan(&totalRechargedAmount)
+	totalRechargedAmount, _ := repository.Wallets(ctrl.DB.WithContext(c.Request.Context())).TotalRechargedForOutlet(outletID)
 
 	// Low stock products
-	var lowStock []models.Inventory
-	database.DB.Where("outlet_id = ?", outletID).
-		Where("quantity < threshold").
-		Preload("Product").
-		Find(&lowStock)
+	lowStock, _ := repository.Inventory(ctrl.DB.WithContext(c.Request.Context()).Preload("Product")).LowStock(outletID)
 
 	lowStockProducts := make([]gin.H, len(lowStock))
 	for i, inv := range lowStock {
@@ -144,6 +114,13 @@ func GetHomeDetails(c *gin.Context) {
 		}
 	}
 
+	// Negative feedback received today
+	negativeFeedback, err := alerts.DailyDigest(ctrl.DB.WithContext(c.Request.Context()), outletID, ctrl.Clock.Now())
+	if err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"totalRevenue":          totalRevenue,
 		"appOrders":             appOrders,
@@ -152,20 +129,26 @@ func GetHomeDetails(c *gin.Context) {
 		"bestSellerProduct":     bestSellerProduct,
 		"totalRechargedAmount":  totalRechargedAmount,
 		"lowStockProducts":      lowStockProducts,
+		"negativeFeedback":      negativeFeedback,
 	})
 }
 
 // RecentOrders returns paginated recent orders for an outlet
-func RecentOrders(c *gin.Context) {
+func (ctrl *Controller) RecentOrders(c *gin.Context) {
 	outletIDStr := c.Param("outletId")
 	outletID, err := strconv.Atoi(outletIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid outlet ID"})
+		apperror.Abort(c, apperror.BadRequest("Invalid outlet ID"))
 		return
 	}
 
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	p := apperror.NewParser()
+	page := p.Int("page", c.DefaultQuery("page", "1"))
+	limit := p.Int("limit", c.DefaultQuery("limit", "10"))
+	if err := p.Err("Invalid pagination parameters"); err != nil {
+		apperror.Abort(c, err)
+		return
+	}
 	if page < 1 {
 		page = 1
 	}
@@ -175,24 +158,22 @@ func RecentOrders(c *gin.Context) {
 	skip := (page - 1) * limit
 
 	// Count total orders
-	var totalOrders int64
-	database.DB.Model(&models.Order{}).Where("outlet_id = ?", outletID).Count(&totalOrders)
+	outletOrders := repository.OrderFilter{OutletID: outletID}
+	totalOrders, _ := repository.Orders(ctrl.DB.WithContext(c.Request.Context())).Count(outletOrders)
 
 	// Fetch orders
-	var orders []models.Order
-	database.DB.Where("outlet_id = ?", outletID).
+	orders, _ := repository.Orders(ctrl.DB.WithContext(c.Request.Context()).
 		Preload("Customer.User").
 		Preload("Items.Product").
-		Order("created_at DESC").
 		Limit(limit).
-		Offset(skip).
-		Find(&orders)
+		Offset(skip)).
+		List(outletOrders)
 
 	formatted := make([]gin.H, len(orders))
 	for i, order := range orders {
 		customerName := "Walk-in Customer"
 		if order.Customer != nil {
-			database.DB.Preload("User").First(&order.Customer, order.Customer.ID)
+			ctrl.DB.WithContext(c.Request.Context()).Preload("User").First(&order.Customer, order.Customer.ID)
 			if order.Customer.User.ID > 0 {
 				customerName = order.Customer.User.Name
 			}
@@ -231,24 +212,24 @@ func RecentOrders(c *gin.Context) {
 }
 
 // GetTicketsCount returns ticket count for staff's outlet
-func GetTicketsCount(c *gin.Context) {
+func (ctrl *Controller) GetTicketsCount(c *gin.Context) {
 	userInterface, exists := c.Get("user")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "User not found."})
+		apperror.Abort(c, apperror.Unauthorized("User not found."))
 		return
 	}
 
 	user, ok := userInterface.(models.User)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid user data."})
+		apperror.Abort(c, apperror.Unauthorized("Invalid user data."))
 		return
 	}
 
 	var ticketCount int64
-	database.DB.Model(&models.Ticket{}).
-		Joins("JOIN customer_details ON customer_details.id = tickets.customer_id").
-		Joins("JOIN users ON users.id = customer_details.user_id").
-		Where("users.outlet_id = ?", user.OutletID).
+	ctrl.DB.WithContext(c.Request.Context()).Model(&models.Ticket{}).
+		Joins(`JOIN "CustomerDetails" ON "CustomerDetails".id = "Ticket"."customerId"`).
+		Joins(`JOIN "User" ON "User".id = "CustomerDetails"."userId"`).
+		Where(`"User"."outletId" = ?`, user.OutletID).
 		Count(&ticketCount)
 
 	c.JSON(http.StatusOK, gin.H{
@@ -258,7 +239,7 @@ func GetTicketsCount(c *gin.Context) {
 }
 
 // GetOrder returns single order details
-func GetOrder(c *gin.Context) {
+func (ctrl *Controller) GetOrder(c *gin.Context) {
 	orderIDStr := c.Param("orderId")
 	outletIDStr := c.Param("outletId")
 
@@ -266,18 +247,17 @@ func GetOrder(c *gin.Context) {
 	outletID, err2 := strconv.Atoi(outletIDStr)
 
 	if err1 != nil || err2 != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Provide valid orderId and outletId"})
+		apperror.Abort(c, apperror.BadRequest("Provide valid orderId and outletId"))
 		return
 	}
 
-	var order models.Order
-	if err := database.DB.
-		Where("id = ? AND outlet_id = ?", orderID, outletID).
+	order, err := repository.Orders(ctrl.DB.WithContext(c.Request.Context()).
 		Preload("Customer.User").
 		Preload("Outlet").
-		Preload("Items.Product").
-		First(&order).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"message": "Order not found or does not belong to this outlet"})
+		Preload("Items.Product")).
+		ForOutlet(orderID, outletID)
+	if err != nil {
+		apperror.Abort(c, apperror.NotFound("Order not found or does not belong to this outlet"))
 		return
 	}
 
@@ -313,104 +293,104 @@ func GetOrder(c *gin.Context) {
 }
 
 // UpdateOrder updates order status with stock management and refunds
-func UpdateOrder(c *gin.Context) {
-	var req struct {
-		OrderID      int    `json:"orderId" binding:"required"`
-		OrderItemIDs []int  `json:"orderItemIds"`
-		Status       string `json:"status" binding:"required"`
-		OutletID     int    `json:"outletId" binding:"required"`
-	}
+func (ctrl *Controller) UpdateOrder(c *gin.Context) {
+	var req dto.UpdateOrderStatusRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Provide orderId, status, and outletId"})
+		apperror.Abort(c, apperror.Binding(err, "Provide orderId, status, and outletId"))
 		return
 	}
 
 	userInterface, exists := c.Get("user")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "User not found."})
+		apperror.Abort(c, apperror.Unauthorized("User not found."))
 		return
 	}
 
 	user, ok := userInterface.(models.User)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid user data."})
+		apperror.Abort(c, apperror.Unauthorized("Invalid user data."))
 		return
 	}
 
 	// Verify staff outlet matches request outlet
 	if user.OutletID != nil && *user.OutletID != req.OutletID {
-		c.JSON(http.StatusForbidden, gin.H{"message": "You can only update orders for your assigned outlet"})
+		apperror.Abort(c, apperror.Forbidden("You can only update orders for your assigned outlet"))
 		return
 	}
 
 	// Fetch order with relationships
-	var order models.Order
-	if err := database.DB.
-		Where("id = ? AND outlet_id = ?", req.OrderID, req.OutletID).
+	order, err := repository.Orders(ctrl.DB.WithContext(c.Request.Context()).
 		Preload("Items").
-		Preload("Customer").
-		First(&order).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"message": "Order not found for this outlet"})
+		Preload("Customer")).
+		ForOutlet(req.OrderID, req.OutletID)
+	if err != nil {
+		apperror.Abort(c, apperror.NotFound("Order not found for this outlet"))
 		return
 	}
 
 	// === CANCELLED ===
 	if req.Status == "CANCELLED" {
 		if order.Status != string(models.OrderStatusPending) {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"message": fmt.Sprintf("Cannot cancel order. Order status is %s", order.Status),
-			})
+			apperror.Abort(c, apperror.BadRequest(fmt.Sprintf("Cannot cancel order. Order status is %s", order.Status)))
 			return
 		}
 
-		err := database.DB.Transaction(func(tx *gorm.DB) error {
+		err := ctrl.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
 			// Update order status
-			tx.Model(&order).Updates(map[string]interface{}{
-				"status":       models.OrderStatusCancelled,
-				"delivered_at": nil,
-			})
+			if err := repository.Orders(tx).SetStatus(&order, models.OrderStatusCancelled, nil); err != nil {
+				return err
+			}
 
 			// Restore stock for all items
 			for _, item := range order.Items {
-				tx.Model(&models.Inventory{}).
-					Where("product_id = ?", item.ProductID).
-					Update("quantity", gorm.Expr("quantity + ?", item.Quantity))
+				if err := repository.Inventory(tx).Adjust(order.OutletID, item.ProductID, item.Quantity); err != nil {
+					return err
+				}
 
-				tx.Create(&models.StockHistory{
+				if err := tx.Create(&models.StockHistory{
 					ProductID: item.ProductID,
 					OutletID:  order.OutletID,
 					Quantity:  item.Quantity,
 					Action:    models.StockActionAdd,
-				})
+				}).Error; err != nil {
+					return err
+				}
 			}
 
 			// Refund logic for APP orders
 			if order.Type == models.OrderTypeApp && order.CustomerID != nil {
-				var wallet models.Wallet
-				if err := tx.Where("customer_id = ?", *order.CustomerID).First(&wallet).Error; err == nil {
-					now := time.Now()
-					tx.Model(&wallet).Updates(map[string]interface{}{
-						"balance": gorm.Expr("balance + ?", order.TotalAmount),
-					})
-
-					tx.Create(&models.WalletTransaction{
+				wallet, err := repository.Wallets(tx).ForCustomer(*order.CustomerID)
+				if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+					return err
+				}
+				if err == nil {
+					if err := repository.Wallets(tx).Refund(&wallet, order.TotalAmount); err != nil {
+						return err
+					}
+
+					if err := tx.Create(&models.WalletTransaction{
 						WalletID: wallet.ID,
 						Amount:   order.TotalAmount,
 						Method:   order.PaymentMethod,
 						Status:   models.WalletTransTypeRecharge,
-					})
-					_ = now
+					}).Error; err != nil {
+						return err
+					}
 				}
 			}
 
 			// Refund coupon
 			var couponUsage models.CouponUsage
-			if tx.Where("order_id = ?", req.OrderID).First(&couponUsage).Error == nil {
-				tx.Delete(&couponUsage)
-				tx.Model(&models.Coupon{}).
+			if tx.Where(`"orderId" = ?`, req.OrderID).First(&couponUsage).Error == nil {
+				if err := tx.Delete(&couponUsage).Error; err != nil {
+					return err
+				}
+				if err := tx.Model(&models.Coupon{}).
 					Where("id = ?", couponUsage.CouponID).
-					Update("used_count", gorm.Expr("used_count - 1"))
+					Update("usedCount", gorm.Expr(`"usedCount" - 1`)).Error; err != nil {
+					return err
+				}
 			}
 
 			// Restore quota for free items
@@ -422,12 +402,12 @@ func UpdateOrder(c *gin.Context) {
 			}
 
 			if totalFreeQty > 0 && order.Customer != nil {
-				today := time.Now().Truncate(24 * time.Hour)
-				var quota models.UserFreeQuota
-				if tx.Where("user_id = ? AND consumption_date = ?", order.Customer.UserID, today).
-					First(&quota).Error == nil {
+				today := ctrl.Clock.Now().Truncate(24 * time.Hour)
+				if quota, err := repository.Users(tx).FreeQuota(order.Customer.UserID, today); err == nil {
 					if quota.QuantityUsed >= totalFreeQty {
-						tx.Model(&quota).Update("quantity_used", gorm.Expr("quantity_used - ?", totalFreeQty))
+						if err := repository.Users(tx).AddFreeQuota(&quota, -totalFreeQty); err != nil {
+							return err
+						}
 					}
 				}
 			}
@@ -436,7 +416,7 @@ func UpdateOrder(c *gin.Context) {
 		})
 
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to cancel order", "error": err.Error()})
+			apperror.Abort(c, apperror.Internal("Failed to cancel order", err))
 			return
 		}
 
@@ -447,25 +427,21 @@ func UpdateOrder(c *gin.Context) {
 	// === DELIVERED ===
 	if req.Status == "DELIVERED" {
 		if order.Status == string(models.OrderStatusCancelled) {
-			c.JSON(http.StatusBadRequest, gin.H{"message": "Cannot mark a cancelled order as delivered."})
+			apperror.Abort(c, apperror.BadRequest("Cannot mark a cancelled order as delivered."))
 			return
 		}
 
-		err := database.DB.Transaction(func(tx *gorm.DB) error {
-			tx.Model(&models.OrderItem{}).
-				Where("order_id = ? AND status != ?", order.ID, models.OrderItemStatusDelivered).
-				Update("status", models.OrderItemStatusDelivered)
+		err := ctrl.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+			if err := repository.Orders(tx).DeliverAllItems(order.ID); err != nil {
+				return err
+			}
 
-			now := time.Now()
-			tx.Model(&order).Updates(map[string]interface{}{
-				"status":       models.OrderStatusDelivered,
-				"delivered_at": &now,
-			})
-			return nil
+			now := ctrl.Clock.Now()
+			return repository.Orders(tx).SetStatus(&order, models.OrderStatusDelivered, &now)
 		})
 
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to update order"})
+			apperror.Abort(c, apperror.Internal("Failed to update order", err))
 			return
 		}
 
@@ -476,19 +452,21 @@ func UpdateOrder(c *gin.Context) {
 	// === PARTIALLY_DELIVERED ===
 	if req.Status == "PARTIALLY_DELIVERED" {
 		if len(req.OrderItemIDs) == 0 {
-			c.JSON(http.StatusBadRequest, gin.H{"message": "Provide at least one orderItemId to deliver"})
+			apperror.Abort(c, apperror.BadRequest("Provide at least one orderItemId to deliver"))
 			return
 		}
 
-		err := database.DB.Transaction(func(tx *gorm.DB) error {
+		err := ctrl.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
 			// Update selected items
-			tx.Model(&models.OrderItem{}).
-				Where("id IN ?", req.OrderItemIDs).
-				Update("status", models.OrderItemStatusDelivered)
+			if err := repository.Orders(tx).DeliverItems(req.OrderItemIDs); err != nil {
+				return err
+			}
 
 			// Check if all items delivered
 			var updatedOrder models.Order
-			tx.Preload("Items").First(&updatedOrder, order.ID)
+			if err := tx.Preload("Items").First(&updatedOrder, order.ID).Error; err != nil {
+				return err
+			}
 
 			allDelivered := true
 			for _, item := range updatedOrder.Items {
@@ -502,19 +480,15 @@ func UpdateOrder(c *gin.Context) {
 			var deliveredAt *time.Time
 			if allDelivered {
 				status = models.OrderStatusDelivered
-				now := time.Now()
+				now := ctrl.Clock.Now()
 				deliveredAt = &now
 			}
 
-			tx.Model(&order).Updates(map[string]interface{}{
-				"status":       status,
-				"delivered_at": deliveredAt,
-			})
-			return nil
+			return repository.Orders(tx).SetStatus(&order, status, deliveredAt)
 		})
 
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to update order"})
+			apperror.Abort(c, apperror.Internal("Failed to update order", err))
 			return
 		}
 
@@ -530,9 +504,7 @@ func UpdateOrder(c *gin.Context) {
 	// === PARTIAL_CANCEL ===
 	if req.Status == "PARTIAL_CANCEL" {
 		if order.Status != string(models.OrderStatusPartiallyDelivered) {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"message": fmt.Sprintf("Cannot partially cancel order. Order status is %s", order.Status),
-			})
+			apperror.Abort(c, apperror.BadRequest(fmt.Sprintf("Cannot partially cancel order. Order status is %s", order.Status)))
 			return
 		}
 
@@ -544,7 +516,7 @@ func UpdateOrder(c *gin.Context) {
 		}
 
 		if len(undeliveredItems) == 0 {
-			c.JSON(http.StatusBadRequest, gin.H{"message": "No undelivered items to cancel"})
+			apperror.Abort(c, apperror.BadRequest("No undelivered items to cancel"))
 			return
 		}
 
@@ -553,39 +525,47 @@ func UpdateOrder(c *gin.Context) {
 			refundAmount += float64(item.Quantity) * item.UnitPrice
 		}
 
-		err := database.DB.Transaction(func(tx *gorm.DB) error {
-			now := time.Now()
-			tx.Model(&order).Updates(map[string]interface{}{
-				"status":       models.OrderStatusDelivered,
-				"delivered_at": &now,
-			})
+		err := ctrl.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+			now := ctrl.Clock.Now()
+			if err := repository.Orders(tx).SetStatus(&order, models.OrderStatusDelivered, &now); err != nil {
+				return err
+			}
 
 			// Restore stock
 			for _, item := range undeliveredItems {
-				tx.Model(&models.Inventory{}).
-					Where("product_id = ?", item.ProductID).
-					Update("quantity", gorm.Expr("quantity + ?", item.Quantity))
+				if err := repository.Inventory(tx).Adjust(order.OutletID, item.ProductID, item.Quantity); err != nil {
+					return err
+				}
 
-				tx.Create(&models.StockHistory{
+				if err := tx.Create(&models.StockHistory{
 					ProductID: item.ProductID,
 					OutletID:  order.OutletID,
 					Quantity:  item.Quantity,
 					Action:    models.StockActionAdd,
-				})
+				}).Error; err != nil {
+					return err
+				}
 			}
 
 			// Refund for APP orders
 			if order.Type == models.OrderTypeApp && order.CustomerID != nil && refundAmount > 0 {
-				var wallet models.Wallet
-				if err := tx.Where("customer_id = ?", *order.CustomerID).First(&wallet).Error; err == nil {
-					tx.Model(&wallet).Update("balance", gorm.Expr("balance + ?", refundAmount))
+				wallet, err := repository.Wallets(tx).ForCustomer(*order.CustomerID)
+				if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+					return err
+				}
+				if err == nil {
+					if err := repository.Wallets(tx).Refund(&wallet, refundAmount); err != nil {
+						return err
+					}
 
-					tx.Create(&models.WalletTransaction{
+					if err := tx.Create(&models.WalletTransaction{
 						WalletID: wallet.ID,
 						Amount:   refundAmount,
 						Method:   order.PaymentMethod,
 						Status:   models.WalletTransTypeRecharge,
-					})
+					}).Error; err != nil {
+						return err
+					}
 				}
 			}
 
@@ -598,12 +578,12 @@ func UpdateOrder(c *gin.Context) {
 			}
 
 			if totalFreeQty > 0 && order.Customer != nil {
-				today := time.Now().Truncate(24 * time.Hour)
-				var quota models.UserFreeQuota
-				if tx.Where("user_id = ? AND consumption_date = ?", order.Customer.UserID, today).
-					First(&quota).Error == nil {
+				today := ctrl.Clock.Now().Truncate(24 * time.Hour)
+				if quota, err := repository.Users(tx).FreeQuota(order.Customer.UserID, today); err == nil {
 					if quota.QuantityUsed >= totalFreeQty {
-						tx.Model(&quota).Update("quantity_used", gorm.Expr("quantity_used - ?", totalFreeQty))
+						if err := repository.Users(tx).AddFreeQuota(&quota, -totalFreeQty); err != nil {
+							return err
+						}
 					}
 				}
 			}
@@ -612,7 +592,7 @@ func UpdateOrder(c *gin.Context) {
 		})
 
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to cancel items"})
+			apperror.Abort(c, apperror.Internal("Failed to cancel items", err))
 			return
 		}
 
@@ -622,5 +602,5 @@ func UpdateOrder(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid status value"})
+	apperror.Abort(c, apperror.BadRequest("Invalid status value"))
 }
@@ -1,30 +1,33 @@
 package staff
 
 import (
-	"backend_pandhi/pkg/database"
+	"backend_pandhi/pkg/apperror"
+	"backend_pandhi/pkg/audit"
+	"backend_pandhi/pkg/dto"
+	"backend_pandhi/pkg/metrics"
 	"backend_pandhi/pkg/models"
+	"backend_pandhi/pkg/pagination"
+	"backend_pandhi/pkg/repository"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // GetStocks returns current inventory levels for an outlet
-func GetStocks(c *gin.Context) {
+func (ctrl *Controller) GetStocks(c *gin.Context) {
 	outletIDStr := c.Param("outletId")
 	outletID, err := strconv.Atoi(outletIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Provide outletId"})
+		apperror.Abort(c, apperror.BadRequest("Provide outletId"))
 		return
 	}
 
-	var products []models.Product
-	if err := database.DB.
-		Where("outlet_id = ?", outletID).
-		Preload("Inventory").
-		Find(&products).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal server error"})
+	products, err := repository.Products(ctrl.DB.WithContext(c.Request.Context()).Preload("Inventory")).ForOutlet(outletID)
+	if err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
 		return
 	}
 
@@ -56,46 +59,55 @@ func GetStocks(c *gin.Context) {
 }
 
 // AddStock adds inventory quantity
-func AddStock(c *gin.Context) {
-	var req struct {
-		ProductID     int `json:"productId" binding:"required"`
-		OutletID      int `json:"outletId" binding:"required"`
-		AddedQuantity int `json:"addedQuantity" binding:"required"`
-	}
+func (ctrl *Controller) AddStock(c *gin.Context) {
+	var req dto.AddStockRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Required fields are missing"})
+		apperror.Abort(c, apperror.Binding(err, "Required fields are missing"))
 		return
 	}
 
 	if req.AddedQuantity <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Added quantity must be greater than 0"})
+		apperror.Abort(c, apperror.BadRequest("Added quantity must be greater than 0"))
 		return
 	}
 
 	// Find inventory
-	var inventory models.Inventory
-	if err := database.DB.Where("product_id = ?", req.ProductID).First(&inventory).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"message": "Product inventory not found"})
+	inventory, err := repository.Inventory(ctrl.DB.WithContext(c.Request.Context())).ForProduct(req.ProductID)
+	if err != nil {
+		apperror.Abort(c, apperror.NotFound("Product inventory not found"))
 		return
 	}
 
-	// Update inventory
-	if err := database.DB.Model(&inventory).
-		Update("quantity", inventory.Quantity+req.AddedQuantity).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to update stock"})
-		return
-	}
+	// Update inventory with its history and audit entry
+	previous := inventory.Quantity
+	err = ctrl.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		if err := repository.Inventory(tx).SetQuantity(&inventory, previous+req.AddedQuantity); err != nil {
+			return err
+		}
 
-	// Create stock history
-	database.DB.Create(&models.StockHistory{
-		ProductID: req.ProductID,
-		OutletID:  req.OutletID,
-		Quantity:  req.AddedQuantity,
-		Action:    models.StockActionAdd,
-	})
+		if err := tx.Create(&models.StockHistory{
+			ProductID: req.ProductID,
+			OutletID:  req.OutletID,
+			Quantity:  req.AddedQuantity,
+			Action:    models.StockActionAdd,
+		}).Error; err != nil {
+			return err
+		}
 
-	inventory.Quantity += req.AddedQuantity
+		return audit.Record(c, tx, audit.Entry{
+			Action:     audit.ActionStockAdd,
+			EntityType: audit.EntityInventory,
+			EntityID:   inventory.ID,
+			OutletID:   &inventory.OutletID,
+			Before:     gin.H{"productId": inventory.ProductID, "quantity": previous},
+			After:      gin.H{"productId": inventory.ProductID, "quantity": inventory.Quantity},
+		})
+	})
+	if err != nil {
+		apperror.Abort(c, apperror.Internal("Failed to update stock", err))
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"message":          "Stock updated successfully",
@@ -104,49 +116,65 @@ func AddStock(c *gin.Context) {
 }
 
 // DeductStock removes inventory quantity
-func DeductStock(c *gin.Context) {
-	var req struct {
-		ProductID int `json:"productId" binding:"required"`
-		OutletID  int `json:"outletId" binding:"required"`
-		Quantity  int `json:"quantity" binding:"required"`
-	}
+func (ctrl *Controller) DeductStock(c *gin.Context) {
+	var req dto.DeductStockRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Provide valid productId, outletId, and quantity."})
+		apperror.Abort(c, apperror.Binding(err, "Provide valid productId, outletId, and quantity."))
 		return
 	}
 
 	if req.Quantity <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Quantity must be greater than 0"})
+		apperror.Abort(c, apperror.BadRequest("Quantity must be greater than 0"))
 		return
 	}
 
 	// Find inventory
-	var inventory models.Inventory
-	if err := database.DB.
-		Where("product_id = ? AND outlet_id = ?", req.ProductID, req.OutletID).
-		First(&inventory).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"message": "Inventory record not found."})
+	inventory, err := repository.Inventory(ctrl.DB.WithContext(c.Request.Context())).ForOutletProduct(req.OutletID, req.ProductID)
+	if err != nil {
+		apperror.Abort(c, apperror.NotFound("Inventory record not found."))
 		return
 	}
 
 	// Check sufficient stock
 	if inventory.Quantity < req.Quantity {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Insufficient stock available."})
+		apperror.Abort(c, apperror.BadRequest("Insufficient stock available."))
 		return
 	}
 
-	// Update inventory
-	newQuantity := inventory.Quantity - req.Quantity
-	database.DB.Model(&inventory).Update("quantity", newQuantity)
+	// Update inventory with its history and audit entry
+	previous := inventory.Quantity
+	newQuantity := previous - req.Quantity
+	err = ctrl.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		if err := repository.Inventory(tx).SetQuantity(&inventory, newQuantity); err != nil {
+			return err
+		}
+
+		if err := tx.Create(&models.StockHistory{
+			ProductID: req.ProductID,
+			OutletID:  req.OutletID,
+			Quantity:  req.Quantity,
+			Action:    models.StockActionRemove,
+		}).Error; err != nil {
+			return err
+		}
 
-	// Create stock history
-	database.DB.Create(&models.StockHistory{
-		ProductID: req.ProductID,
-		OutletID:  req.OutletID,
-		Quantity:  req.Quantity,
-		Action:    models.StockActionRemove,
+		return audit.Record(c, tx, audit.Entry{
+			Action:     audit.ActionStockDeduct,
+			EntityType: audit.EntityInventory,
+			EntityID:   inventory.ID,
+			OutletID:   &inventory.OutletID,
+			Before:     gin.H{"productId": inventory.ProductID, "quantity": previous},
+			After:      gin.H{"productId": inventory.ProductID, "quantity": newQuantity},
+		})
 	})
+	if err != nil {
+		apperror.Abort(c, apperror.Internal("Failed to update stock", err))
+		return
+	}
+	if newQuantity == 0 {
+		metrics.StockOut(inventory.OutletID)
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"message":         "Stock deducted successfully",
@@ -154,50 +182,73 @@ func DeductStock(c *gin.Context) {
 	})
 }
 
-// StockHistory returns stock movement history for date range
-func StockHistory(c *gin.Context) {
-	var req struct {
-		OutletID  int    `json:"outletId" binding:"required"`
-		StartDate string `json:"startDate" binding:"required"`
-		EndDate   string `json:"endDate" binding:"required"`
-	}
+// StockHistoryList lists the sorts and filters accepted by StockHistory
+var StockHistoryList = pagination.Spec{
+	DefaultLimit: 100,
+	MaxLimit:     500,
+	DefaultSort:  "-timestamp",
+	Sorts: map[string]pagination.Sort{
+		"timestamp": {Column: "timestamp", Field: "Timestamp", Type: pagination.TypeTime},
+	},
+	Filters: map[string]pagination.Filter{
+		"productId": {Column: `"productId"`, Type: pagination.TypeInt, Ops: []pagination.Op{pagination.OpEq, pagination.OpIn}},
+		"action": {
+			Column: "action",
+			Type:   pagination.TypeString,
+			Values: []string{string(models.StockActionAdd), string(models.StockActionRemove)},
+		},
+	},
+}
+
+// StockHistory returns a page of stock movements for a date range
+func (ctrl *Controller) StockHistory(c *gin.Context) {
+	var req dto.StockHistoryRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "outletId, startDate, and endDate are required."})
+		apperror.Abort(c, apperror.Binding(err, "outletId, startDate, and endDate are required."))
 		return
 	}
 
 	// Parse dates
 	from, err1 := time.Parse("2006-01-02", req.StartDate)
 	if err1 != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid startDate format. Use YYYY-MM-DD"})
+		apperror.Abort(c, apperror.BadRequest("Invalid startDate format. Use YYYY-MM-DD"))
 		return
 	}
 
 	to, err2 := time.Parse("2006-01-02", req.EndDate)
 	if err2 != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid endDate format. Use YYYY-MM-DD"})
+		apperror.Abort(c, apperror.BadRequest("Invalid endDate format. Use YYYY-MM-DD"))
 		return
 	}
 
 	// Set end time to end of day
 	to = time.Date(to.Year(), to.Month(), to.Day(), 23, 59, 59, 999999999, to.Location())
 
-	// Fetch history
+	list, err := pagination.Parse(c, StockHistoryList)
+	if err != nil {
+		apperror.Abort(c, err)
+		return
+	}
+
 	var history []models.StockHistory
-	database.DB.
-		Where("outlet_id = ? AND action IN ? AND timestamp >= ? AND timestamp <= ?",
+	if err := list.Apply(ctrl.DB.WithContext(c.Request.Context()).
+		Where(`"outletId" = ? AND action IN ? AND timestamp >= ? AND timestamp <= ?`,
 			req.OutletID,
 			[]models.StockAction{models.StockActionAdd, models.StockActionRemove},
 			from,
 			to,
-		).
+		)).
 		Preload("Product").
-		Order("timestamp DESC").
-		Find(&history)
+		Find(&history).Error; err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
+		return
+	}
+	history, meta := pagination.Trim(list, history)
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Stock history fetched",
-		"history": history,
+		"message":    "Stock history fetched",
+		"history":    history,
+		"pagination": meta,
 	})
 }
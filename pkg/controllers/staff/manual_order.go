@@ -1,8 +1,12 @@
 package staff
 
 import (
-	"backend_pandhi/pkg/database"
+	"backend_pandhi/pkg/apperror"
+	"backend_pandhi/pkg/dto"
+	"backend_pandhi/pkg/metrics"
 	"backend_pandhi/pkg/models"
+	"backend_pandhi/pkg/repository"
+	"fmt"
 	"net/http"
 	"strconv"
 	"time"
@@ -12,49 +16,44 @@ import (
 )
 
 // AddManualOrder creates a manual/phone order with inventory deduction
-func AddManualOrder(c *gin.Context) {
-	var req struct {
-		OutletID      int     `json:"outletId" binding:"required"`
-		TotalAmount   float64 `json:"totalAmount" binding:"required"`
-		PaymentMethod string  `json:"paymentMethod" binding:"required"`
-		Status        string  `json:"status"`
-		Items         []struct {
-			ProductID int     `json:"productId" binding:"required"`
-			Quantity  int     `json:"quantity" binding:"required"`
-			UnitPrice float64 `json:"unitPrice" binding:"required"`
-		} `json:"items" binding:"required"`
-	}
+func (ctrl *Controller) AddManualOrder(c *gin.Context) {
+	var req dto.ManualOrderRequest
 
-	if err := c.ShouldBindJSON(&req); err != nil || len(req.Items) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Missing required fields"})
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.Abort(c, apperror.Binding(err, "Missing required fields"))
+		return
+	}
+	if len(req.Items) == 0 {
+		apperror.Abort(c, apperror.Validation("Missing required fields", apperror.FieldError{
+			Field:   "items",
+			Message: "must contain at least one item",
+		}))
 		return
 	}
 
-	// Validate inventory
+	// Validate inventory, noting the items this order sells out
+	stockOuts := 0
 	for _, item := range req.Items {
-		var inventory models.Inventory
-		if err := database.DB.
-			Where("outlet_id = ? AND product_id = ?", req.OutletID, item.ProductID).
-			First(&inventory).Error; err != nil {
-			c.JSON(http.StatusNotFound, gin.H{
-				"message": "Inventory not found for product ID " + string(rune(item.ProductID)),
-			})
+		inventory, err := repository.Inventory(ctrl.DB.WithContext(c.Request.Context())).ForOutletProduct(req.OutletID, item.ProductID)
+		if err != nil {
+			apperror.Abort(c, apperror.NotFound(fmt.Sprintf("Inventory not found for product ID %d", item.ProductID)))
 			return
 		}
 
 		if inventory.Quantity < item.Quantity {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"message": "Insufficient inventory for product ID " + string(rune(item.ProductID)),
-			})
+			apperror.Abort(c, apperror.BadRequest(fmt.Sprintf("Insufficient inventory for product ID %d", item.ProductID)))
 			return
 		}
+		if inventory.Quantity == item.Quantity {
+			stockOuts++
+		}
 	}
 
 	// Create order in transaction
 	var createdOrder models.Order
-	err := database.DB.Transaction(func(tx *gorm.DB) error {
-		today := time.Now().Truncate(24 * time.Hour)
-		now := time.Now()
+	err := ctrl.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		today := ctrl.Clock.Now().Truncate(24 * time.Hour)
+		now := ctrl.Clock.Now()
 
 		order := models.Order{
 			OutletID:      req.OutletID,
@@ -81,24 +80,33 @@ func AddManualOrder(c *gin.Context) {
 				UnitPrice: item.UnitPrice,
 				Status:    models.OrderItemStatusDelivered,
 			}
-			tx.Create(&orderItem)
+			if err := tx.Create(&orderItem).Error; err != nil {
+				return err
+			}
 
 			// Deduct inventory
-			tx.Model(&models.Inventory{}).
-				Where("outlet_id = ? AND product_id = ?", req.OutletID, item.ProductID).
-				Update("quantity", gorm.Expr("quantity - ?", item.Quantity))
+			if err := repository.Inventory(tx).Adjust(req.OutletID, item.ProductID, -item.Quantity); err != nil {
+				return err
+			}
 		}
 
-		tx.Preload("Items").First(&order, order.ID)
+		if err := tx.Preload("Items").First(&order, order.ID).Error; err != nil {
+			return err
+		}
 		createdOrder = order
 		return nil
 	})
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal server error"})
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
 		return
 	}
 
+	metrics.OrderPlaced(models.OrderTypeManual, createdOrder.PaymentMethod)
+	for i := 0; i < stockOuts; i++ {
+		metrics.StockOut(req.OutletID)
+	}
+
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "Manual order created",
 		"order":   createdOrder,
@@ -106,19 +114,20 @@ func AddManualOrder(c *gin.Context) {
 }
 
 // GetProducts returns available products with stock for manual orders
-func GetProducts(c *gin.Context) {
+func (ctrl *Controller) GetProducts(c *gin.Context) {
 	outletIDStr := c.Param("outletId")
 	outletID, err := strconv.Atoi(outletIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Provide a valid outletId"})
+		apperror.Abort(c, apperror.BadRequest("Provide a valid outletId"))
 		return
 	}
 
 	// Fetch products with inventory > 0
-	var inventories []models.Inventory
-	database.DB.Where("outlet_id = ? AND quantity > 0", outletID).
-		Preload("Product").
-		Find(&inventories)
+	inventories, err := repository.Inventory(ctrl.DB.WithContext(c.Request.Context()).Preload("Product")).InStock(outletID)
+	if err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
+		return
+	}
 
 	availableProducts := make([]gin.H, len(inventories))
 	for i, inv := range inventories {
@@ -1,8 +1,9 @@
 package staff
 
 import (
-	"backend_pandhi/pkg/database"
+	"backend_pandhi/pkg/apperror"
 	"backend_pandhi/pkg/models"
+	"backend_pandhi/pkg/repository"
 	"net/http"
 	"strconv"
 
@@ -10,31 +11,31 @@ import (
 )
 
 //OutletCurrentOrder returns current orders for notification purposes
-func OutletCurrentOrder(c *gin.Context) {
+func (ctrl *Controller) OutletCurrentOrder(c *gin.Context) {
 	outletIDStr := c.Param("outletId")
 	outletID, err := strconv.Atoi(outletIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid outlet ID"})
+		apperror.Abort(c, apperror.BadRequest("Invalid outlet ID"))
 		return
 	}
 
 	// Fetch pending/in-progress orders
-	var orders []models.Order
-	database.DB.
-		Where("outlet_id = ? AND status IN ?", outletID, []models.OrderStatus{
-			models.OrderStatusPending,
-			models.OrderStatusPartiallyDelivered,
-		}).
+	orders, _ := repository.Orders(ctrl.DB.WithContext(c.Request.Context()).
 		Preload("Customer.User").
-		Preload("Items.Product").
-		Order("created_at DESC").
-		Find(&orders)
+		Preload("Items.Product")).
+		List(repository.OrderFilter{
+			OutletID: outletID,
+			Statuses: []models.OrderStatus{
+				models.OrderStatusPending,
+				models.OrderStatusPartiallyDelivered,
+			},
+		})
 
 	formattedOrders := make([]gin.H, len(orders))
 	for i, order := range orders {
 		customerName := "Walk-in Customer"
 		if order.Customer != nil {
-			database.DB.Preload("User").First(&order.Customer, order.Customer.ID)
+			ctrl.DB.WithContext(c.Request.Context()).Preload("User").First(&order.Customer, order.Customer.ID)
 			if order.Customer.User.ID > 0 {
 				customerName = order.Customer.User.Name
 			}
@@ -1,74 +1,79 @@
 package staff
 
 import (
-	"backend_pandhi/pkg/database"
+	"backend_pandhi/pkg/apperror"
 	"backend_pandhi/pkg/models"
+	"backend_pandhi/pkg/pagination"
 	"net/http"
 	"strconv"
-	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
-// GetOrderHistory returns historical orders based on query filters
-func GetOrderHistory(c *gin.Context) {
+// OrderHistoryList lists the sorts and filters accepted by GetOrderHistory
+var OrderHistoryList = pagination.Spec{
+	DefaultLimit: 50,
+	MaxLimit:     200,
+	DefaultSort:  "-createdAt",
+	Sorts: map[string]pagination.Sort{
+		"createdAt":   {Column: `"createdAt"`, Field: "CreatedAt", Type: pagination.TypeTime},
+		"totalAmount": {Column: `"totalAmount"`, Field: "TotalAmount", Type: pagination.TypeFloat},
+	},
+	Filters: map[string]pagination.Filter{
+		"status":        {Column: "status", Type: pagination.TypeString, Ops: []pagination.Op{pagination.OpEq, pagination.OpNe, pagination.OpIn}},
+		"type":          {Column: "type", Type: pagination.TypeString, Values: []string{string(models.OrderTypeApp), string(models.OrderTypeManual)}},
+		"paymentMethod": {Column: `"paymentMethod"`, Type: pagination.TypeString, Ops: []pagination.Op{pagination.OpEq, pagination.OpIn}},
+		"createdAt":     {Column: `"createdAt"`, Type: pagination.TypeTime, Ops: pagination.Range},
+		// startDate/endDate are the older names for createdAt[gte]/createdAt[lte]
+		"startDate": {Column: `"createdAt"`, Type: pagination.TypeTime, DefaultOp: pagination.OpGte},
+		"endDate":   {Column: `"createdAt"`, Type: pagination.TypeTime, DefaultOp: pagination.OpLte},
+	},
+}
+
+// GetOrderHistory returns a page of the outlet's orders based on query filters
+func (ctrl *Controller) GetOrderHistory(c *gin.Context) {
 	userInterface, exists := c.Get("user")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "User not found."})
+		apperror.Abort(c, apperror.Unauthorized("User not found."))
 		return
 	}
 
 	user, ok := userInterface.(models.User)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid user data."})
+		apperror.Abort(c, apperror.Unauthorized("Invalid user data."))
 		return
 	}
 
 	// Get outlet ID from user
 	if user.OutletID == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Staff not assigned to outlet"})
+		apperror.Abort(c, apperror.BadRequest("Staff not assigned to outlet"))
 		return
 	}
 
 	outletID := *user.OutletID
 
-	// Get query parameters
-	status := c.Query("status")
-	orderType := c.Query("type")
-	startDate := c.Query("startDate")
-	endDate := c.Query("endDate")
-
-	query := database.DB.Where("outlet_id = ?", outletID)
-
-	// Apply filters
-	if status != "" {
-		query = query.Where("status = ?", status)
-	}
-	if orderType != "" {
-		query = query.Where("type = ?", orderType)
-	}
-	if startDate != "" {
-		query = query.Where("created_at >= ?", startDate)
-	}
-	if endDate != "" {
-		query = query.Where("created_at <= ?", endDate)
+	list, err := pagination.Parse(c, OrderHistoryList)
+	if err != nil {
+		apperror.Abort(c, err)
+		return
 	}
 
 	var orders []models.Order
-	query.Preload("Customer.User").
+	if err := list.Apply(ctrl.DB.WithContext(c.Request.Context()).Where(`"outletId" = ?`, outletID)).
+		Preload("Customer.User").
 		Preload("Items.Product").
 		Preload("Outlet").
-		Order("created_at DESC").
-		Find(&orders)
+		Find(&orders).Error; err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
+		return
+	}
+	orders, meta := pagination.Trim(list, orders)
 
 	formattedOrders := make([]gin.H, len(orders))
 	for i, order := range orders {
 		customerName := "Walk-in Customer"
-		if order.Customer != nil {
-			database.DB.Preload("User").First(&order.Customer, order.Customer.ID)
-			if order.Customer.User.ID > 0 {
-				customerName = order.Customer.User.Name
-			}
+		if order.Customer != nil && order.Customer.User.ID > 0 {
+			customerName = order.Customer.User.Name
 		}
 
 		items := make([]gin.H, len(order.Items))
@@ -99,33 +104,34 @@ func GetOrderHistory(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Order history retrieved",
-		"orders":  formattedOrders,
-		"count":   len(formattedOrders),
+		"message":    "Order history retrieved",
+		"orders":     formattedOrders,
+		"count":      len(formattedOrders),
+		"pagination": meta,
 	})
 }
 
 // GetAvailableDatesAndSlotsForStaff returns available delivery dates and slots
-func GetAvailableDatesAndSlotsForStaff(c *gin.Context) {
+func (ctrl *Controller) GetAvailableDatesAndSlotsForStaff(c *gin.Context) {
 	outletIDStr := c.Param("outletId")
 	outletID, err := strconv.Atoi(outletIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid outlet ID"})
+		apperror.Abort(c, apperror.BadRequest("Invalid outlet ID"))
 		return
 	}
 
 	// Verify outlet exists
 	var outlet models.Outlet
-	if err := database.DB.First(&outlet, outletID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"message": "Outlet not found"})
+	if err := ctrl.DB.WithContext(c.Request.Context()).First(&outlet, outletID).Error; err != nil {
+		apperror.Abort(c, apperror.NotFound("Outlet not found"))
 		return
 	}
 
-	today := time.Now()
+	today := ctrl.Clock.Now()
 	next30Days := today.AddDate(0, 0, 30)
 
 	var nonAvailable []models.OutletAvailability
-	database.DB.Where("outlet_id = ? AND date >= ? AND date <= ?",
+	ctrl.DB.WithContext(c.Request.Context()).Where(`"outletId" = ? AND date >= ? AND date <= ?`,
 		outletID, today, next30Days).Find(&nonAvailable)
 
 	allSlots := []string{"SLOT_11_12", "SLOT_12_13", "SLOT_13_14", "SLOT_14_15", "SLOT_15_16", "SLOT_16_17"}
@@ -1,39 +1,41 @@
 package staff
 
 import (
-	"backend_pandhi/pkg/database"
+	"backend_pandhi/pkg/apperror"
+	"backend_pandhi/pkg/dto"
+	"backend_pandhi/pkg/media"
 	"backend_pandhi/pkg/models"
-	"backend_pandhi/pkg/services"
+	"backend_pandhi/pkg/repository"
+	"backend_pandhi/pkg/utils"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // GetStaffProfile retrieves staff profile information
-func GetStaffProfile(c *gin.Context) {
+func (ctrl *Controller) GetStaffProfile(c *gin.Context) {
 	userInterface, exists := c.Get("user")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "User not found."})
+		apperror.Abort(c, apperror.Unauthorized("User not found."))
 		return
 	}
 
 	user, ok := userInterface.(models.User)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid user data."})
+		apperror.Abort(c, apperror.Unauthorized("Invalid user data."))
 		return
 	}
 
-	// Get staff details with outlet
-	var staff models.StaffDetails
-	if err := database.DB.Where("user_id = ?", user.ID).
-		Preload("Outlet").
-		First(&staff).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"message": "Staff not found"})
+	// Get staff details
+	staff, err := repository.Users(ctrl.DB.WithContext(c.Request.Context())).Staff(user.ID)
+	if err != nil {
+		apperror.Abort(c, apperror.NotFound("Staff not found"))
 		return
 	}
 
 	// Get user with outlet
-	database.DB.Preload("Outlet").First(&user, user.ID)
+	ctrl.DB.WithContext(c.Request.Context()).Preload("Outlet").First(&user, user.ID)
 
 	var outlet *gin.H
 	if user.Outlet != nil {
@@ -52,10 +54,11 @@ func GetStaffProfile(c *gin.Context) {
 		"imageUrl":    nil,
 		"designation": staff.StaffRole,
 		"outlet":      outlet,
+		"isOnDuty":    staff.IsOnDuty,
 	}
 
 	if user.ImageURL != nil {
-		signedURL, _ := services.GetSignedURL(*user.ImageURL)
+		signedURL, _ := ctrl.Storage.GetSignedURL(*user.ImageURL)
 		response["imageUrl"] = signedURL
 	}
 
@@ -65,41 +68,77 @@ func GetStaffProfile(c *gin.Context) {
 	})
 }
 
-// UpdateStaffProfile updates staff profile information
-func UpdateStaffProfile(c *gin.Context) {
-	var req struct {
-		Name        *string `json:"name"`
-		Phone       *string `json:"phone"`
-		Designation *string `json:"designation"`
+// SetDuty marks the staff member on or off duty. Feedback alerts for the
+// outlet are pushed to the staff on duty.
+func (ctrl *Controller) SetDuty(c *gin.Context) {
+	userInterface, exists := c.Get("user")
+	if !exists {
+		apperror.Abort(c, apperror.Unauthorized("User not found."))
+		return
 	}
 
+	user, ok := userInterface.(models.User)
+	if !ok {
+		apperror.Abort(c, apperror.Unauthorized("Invalid user data."))
+		return
+	}
+
+	var req dto.SetDutyRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input"})
+		apperror.Abort(c, apperror.Binding(err, "isOnDuty is required"))
+		return
+	}
+
+	result := ctrl.DB.WithContext(c.Request.Context()).Model(&models.StaffDetails{}).
+		Where(`"userId" = ?`, user.ID).
+		Update("isOnDuty", *req.IsOnDuty)
+	if result.Error != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", result.Error))
+		return
+	}
+	if result.RowsAffected == 0 {
+		apperror.Abort(c, apperror.NotFound("Staff not found"))
+		return
+	}
+
+	message := "You are off duty"
+	if *req.IsOnDuty {
+		message = "You are on duty"
+	}
+	c.JSON(http.StatusOK, gin.H{"message": message, "isOnDuty": *req.IsOnDuty})
+}
+
+// UpdateStaffProfile updates staff profile information
+func (ctrl *Controller) UpdateStaffProfile(c *gin.Context) {
+	var req dto.UpdateStaffProfileRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.Abort(c, apperror.Binding(err, "Invalid input"))
 		return
 	}
 
 	userInterface, exists := c.Get("user")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "User not found."})
+		apperror.Abort(c, apperror.Unauthorized("User not found."))
 		return
 	}
 
 	user, ok := userInterface.(models.User)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid user data."})
+		apperror.Abort(c, apperror.Unauthorized("Invalid user data."))
 		return
 	}
 
 	// Check if at least one field is provided
 	if req.Name == nil && req.Phone == nil && req.Designation == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "No updates provided"})
+		apperror.Abort(c, apperror.BadRequest("No updates provided"))
 		return
 	}
 
 	// Get staff details
-	var staff models.StaffDetails
-	if err := database.DB.Where("user_id = ?", user.ID).First(&staff).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"message": "Staff not found"})
+	staff, err := repository.Users(ctrl.DB.WithContext(c.Request.Context())).Staff(user.ID)
+	if err != nil {
+		apperror.Abort(c, apperror.NotFound("Staff not found"))
 		return
 	}
 
@@ -109,21 +148,37 @@ func UpdateStaffProfile(c *gin.Context) {
 		updates["name"] = *req.Name
 	}
 	if req.Phone != nil {
-		updates["phone"] = *req.Phone
+		phone, err := utils.NormalizePhone(ctrl.Config, *req.Phone)
+		if err != nil {
+			apperror.Abort(c, apperror.BadRequest("Invalid phone number"))
+			return
+		}
+		var other models.User
+		if ctrl.DB.WithContext(c.Request.Context()).Where("phone = ? AND id <> ?", phone, user.ID).First(&other).Error == nil {
+			apperror.Abort(c, apperror.BadRequest("Phone number is already registered"))
+			return
+		}
+		updates["phone"] = phone
 	}
 
 	if len(updates) > 0 {
-		database.DB.Model(&user).Updates(updates)
+		if err := ctrl.DB.WithContext(c.Request.Context()).Model(&user).Updates(updates).Error; err != nil {
+			apperror.Abort(c, apperror.Internal("Failed to update profile", err))
+			return
+		}
 	}
 
 	// Update staff designation
 	if req.Designation != nil {
-		database.DB.Model(&staff).Update("staff_role", *req.Designation)
+		if err := repository.Users(ctrl.DB.WithContext(c.Request.Context())).SetStaffRole(&staff, *req.Designation); err != nil {
+			apperror.Abort(c, apperror.Internal("Failed to update profile", err))
+			return
+		}
 	}
 
 	// Reload data
-	database.DB.Preload("Outlet").First(&user, user.ID)
-	database.DB.First(&staff, staff.ID)
+	ctrl.DB.WithContext(c.Request.Context()).Preload("Outlet").First(&user, user.ID)
+	ctrl.DB.WithContext(c.Request.Context()).First(&staff, staff.ID)
 
 	var outlet *gin.H
 	if user.Outlet != nil {
@@ -142,10 +197,11 @@ func UpdateStaffProfile(c *gin.Context) {
 		"imageUrl":    nil,
 		"designation": staff.StaffRole,
 		"outlet":      outlet,
+		"isOnDuty":    staff.IsOnDuty,
 	}
 
 	if user.ImageURL != nil {
-		signedURL, _ := services.GetSignedURL(*user.ImageURL)
+		signedURL, _ := ctrl.Storage.GetSignedURL(*user.ImageURL)
 		response["imageUrl"] = signedURL
 	}
 
@@ -156,42 +212,43 @@ func UpdateStaffProfile(c *gin.Context) {
 }
 
 // UploadStaffImage uploads profile image
-func UploadStaffImage(c *gin.Context) {
+func (ctrl *Controller) UploadStaffImage(c *gin.Context) {
 	file, err := c.FormFile("image")
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "No image uploaded"})
+		apperror.Abort(c, apperror.BadRequest("No image uploaded"))
 		return
 	}
 
 	f, err := file.Open()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to handle image file"})
+		apperror.Abort(c, apperror.Internal("Failed to handle image file", err))
 		return
 	}
 	defer f.Close()
 
 	// Upload to GCP
-	imageURL, err := services.UploadImageFromReader(f, file.Filename)
+	imageURL, err := ctrl.Storage.UploadImageFromReader(c.Request.Context(), f, file.Filename)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to upload image", "error": err.Error()})
+		apperror.Abort(c, apperror.Internal("Failed to upload image", err))
 		return
 	}
 
 	userInterface, _ := c.Get("user")
 	user := userInterface.(models.User)
 
-	// Delete old image if exists
-	if user.ImageURL != nil {
-		_ = services.DeleteImage(*user.ImageURL)
-	}
-
-	// Update user record
-	if err := database.DB.Model(&user).Update("image_url", imageURL).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to update user profile"})
+	// Update user record and delete the old image once that commits
+	previous := user.ImageURL
+	if err := ctrl.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		if err := repository.Users(tx).SetImageURL(&user, &imageURL); err != nil {
+			return err
+		}
+		return media.QueueDelete(tx, previous)
+	}); err != nil {
+		apperror.Abort(c, apperror.Internal("Failed to update user profile", err))
 		return
 	}
 
-	signedURL, _ := services.GetSignedURL(imageURL)
+	signedURL, _ := ctrl.Storage.GetSignedURL(imageURL)
 
 	c.JSON(http.StatusOK, gin.H{
 		"message":  "Image uploaded successfully",
@@ -200,32 +257,36 @@ func UploadStaffImage(c *gin.Context) {
 }
 
 // DeleteStaffImage deletes profile image
-func DeleteStaffImage(c *gin.Context) {
+func (ctrl *Controller) DeleteStaffImage(c *gin.Context) {
 	userInterface, exists := c.Get("user")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "User not found."})
+		apperror.Abort(c, apperror.Unauthorized("User not found."))
 		return
 	}
 
 	user, ok := userInterface.(models.User)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid user data."})
+		apperror.Abort(c, apperror.Unauthorized("Invalid user data."))
 		return
 	}
 
 	if user.ImageURL == nil || *user.ImageURL == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "No image to delete"})
+		apperror.Abort(c, apperror.BadRequest("No image to delete"))
 		return
 	}
 
-	// Delete from GCP Storage
-	if err := services.DeleteImage(*user.ImageURL); err != nil {
-		// Log error but continue
+	// Clear image URL and delete the image once that commits
+	previous := user.ImageURL
+	if err := ctrl.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		if err := repository.Users(tx).SetImageURL(&user, nil); err != nil {
+			return err
+		}
+		return media.QueueDelete(tx, previous)
+	}); err != nil {
+		apperror.Abort(c, apperror.Internal("Failed to delete image", err))
+		return
 	}
 
-	// Clear image URL
-	database.DB.Model(&user).Update("image_url", nil)
-
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Image deleted successfully",
 		"user": gin.H{
@@ -1,44 +1,46 @@
 package staff
 
 import (
-	"backend_pandhi/pkg/database"
+	"backend_pandhi/pkg/apperror"
+	"backend_pandhi/pkg/dto"
 	"backend_pandhi/pkg/models"
+	"backend_pandhi/pkg/repository"
 	"net/http"
 	"strconv"
-	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 // GetSalesTrend returns revenue by dates
-func GetSalesTrend(c *gin.Context) {
+func (ctrl *Controller) GetSalesTrend(c *gin.Context) {
 	outletIDStr := c.Param("outletId")
 	outletID, err := strconv.Atoi(outletIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid outlet ID"})
+		apperror.Abort(c, apperror.BadRequest("Invalid outlet ID"))
 		return
 	}
 
-	var req struct {
-		From string `json:"from" binding:"required"`
-		To   string `json:"to" binding:"required"`
-	}
+	var req dto.DateRangeRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "from and to dates are required"})
+		apperror.Abort(c, apperror.Binding(err, "from and to dates are required"))
 		return
 	}
 
-	from, _ := time.Parse("2006-01-02", req.From)
-	to, _ := time.Parse("2006-01-02", req.To)
+	p := apperror.NewParser()
+	from := p.Date("from", req.From)
+	to := p.Date("to", req.To)
+	if err := p.Err("Invalid date range"); err != nil {
+		apperror.Abort(c, err)
+		return
+	}
 
-	var orders []models.Order
-	database.DB.Where("outlet_id = ? AND created_at >= ? AND created_at <= ? AND status IN ?",
-		outletID,
-		from,
-		to,
-		[]models.OrderStatus{models.OrderStatusDelivered, models.OrderStatusPartiallyDelivered},
-	).Select("total_amount, created_at").Find(&orders)
+	orders, _ := repository.Orders(ctrl.DB.WithContext(c.Request.Context())).List(repository.OrderFilter{
+		OutletID: outletID,
+		Statuses: []models.OrderStatus{models.OrderStatusDelivered, models.OrderStatusPartiallyDelivered},
+		From:     from,
+		To:       to,
+	})
 
 	// Group by date
 	dailyRevenue := make(map[string]float64)
@@ -61,32 +63,32 @@ func GetSalesTrend(c *gin.Context) {
 }
 
 // GetOrderTypeBreakdown returns manual vs app order count
-func GetOrderTypeBreakdown(c *gin.Context) {
+func (ctrl *Controller) GetOrderTypeBreakdown(c *gin.Context) {
 	outletIDStr := c.Param("outletId")
 	outletID, err := strconv.Atoi(outletIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid outlet ID"})
+		apperror.Abort(c, apperror.BadRequest("Invalid outlet ID"))
 		return
 	}
 
-	var req struct {
-		From string `json:"from" binding:"required"`
-		To   string `json:"to" binding:"required"`
-	}
+	var req dto.DateRangeRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "from and to dates are required"})
+		apperror.Abort(c, apperror.Binding(err, "from and to dates are required"))
 		return
 	}
 
-	from, _ := time.Parse("2006-01-02", req.From)
-	to, _ := time.Parse("2006-01-02", req.To)
+	p := apperror.NewParser()
+	from := p.Date("from", req.From)
+	to := p.Date("to", req.To)
+	if err := p.Err("Invalid date range"); err != nil {
+		apperror.Abort(c, err)
+		return
+	}
 
-	var appOrders, manualOrders int64
-	database.DB.Model(&models.Order{}).Where("outlet_id = ? AND type = ? AND created_at >= ? AND created_at <= ?",
-		outletID, models.OrderTypeApp, from, to).Count(&appOrders)
-	database.DB.Model(&models.Order{}).Where("outlet_id = ? AND type = ? AND created_at >= ? AND created_at <= ?",
-		outletID, models.OrderTypeManual, from, to).Count(&manualOrders)
+	orders := repository.Orders(ctrl.DB.WithContext(c.Request.Context()))
+	appOrders, _ := orders.Count(repository.OrderFilter{OutletID: outletID, Type: models.OrderTypeApp, From: from, To: to})
+	manualOrders, _ := orders.Count(repository.OrderFilter{OutletID: outletID, Type: models.OrderTypeManual, From: from, To: to})
 
 	c.JSON(http.StatusOK, gin.H{
 		"appOrders":    appOrders,
@@ -95,30 +97,30 @@ func GetOrderTypeBreakdown(c *gin.Context) {
 }
 
 // GetNewCustomersTrend returns new customers by date
-func GetNewCustomersTrend(c *gin.Context) {
+func (ctrl *Controller) GetNewCustomersTrend(c *gin.Context) {
 	outletIDStr := c.Param("outletId")
 	outletID, err := strconv.Atoi(outletIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid outlet ID"})
+		apperror.Abort(c, apperror.BadRequest("Invalid outlet ID"))
 		return
 	}
 
-	var req struct {
-		From string `json:"from" binding:"required"`
-		To   string `json:"to" binding:"required"`
-	}
+	var req dto.DateRangeRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "from and to dates are required"})
+		apperror.Abort(c, apperror.Binding(err, "from and to dates are required"))
 		return
 	}
 
-	from, _ := time.Parse("2006-01-02", req.From)
-	to, _ := time.Parse("2006-01-02", req.To)
+	p := apperror.NewParser()
+	from := p.Date("from", req.From)
+	to := p.Date("to", req.To)
+	if err := p.Err("Invalid date range"); err != nil {
+		apperror.Abort(c, err)
+		return
+	}
 
-	var users []models.User
-	database.DB.Where("outlet_id = ? AND role = ? AND created_at >= ? AND created_at <= ?",
-		outletID, models.RoleCustomer, from, to).Select("created_at").Find(&users)
+	users, _ := repository.Users(ctrl.DB.WithContext(c.Request.Context())).NewCustomers(outletID, from, to)
 
 	// Group by date
 	dailyNewCustomers := make(map[string]int)
@@ -140,40 +142,35 @@ func GetNewCustomersTrend(c *gin.Context) {
 }
 
 // GetCategoryBreakdown returns order count by category
-func GetCategoryBreakdown(c *gin.Context) {
+func (ctrl *Controller) GetCategoryBreakdown(c *gin.Context) {
 	outletIDStr := c.Param("outletId")
 	outletID, err := strconv.Atoi(outletIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid outlet ID"})
+		apperror.Abort(c, apperror.BadRequest("Invalid outlet ID"))
 		return
 	}
 
-	var req struct {
-		From string `json:"from" binding:"required"`
-		To   string `json:"to" binding:"required"`
-	}
+	var req dto.DateRangeRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "from and to dates are required"})
+		apperror.Abort(c, apperror.Binding(err, "from and to dates are required"))
 		return
 	}
 
-	from, _ := time.Parse("2006-01-02", req.From)
-	to, _ := time.Parse("2006-01-02", req.To)
-
-	type CategoryData struct {
-		ProductID int
-		Quantity  int
+	p := apperror.NewParser()
+	from := p.Date("from", req.From)
+	to := p.Date("to", req.To)
+	if err := p.Err("Invalid date range"); err != nil {
+		apperror.Abort(c, err)
+		return
 	}
 
-	var categoryData []CategoryData
-	database.DB.Model(&models.OrderItem{}).
-		Select("product_id, SUM(quantity) as quantity").
-		Joins("JOIN orders ON orders.id = order_items.order_id").
-		Where("orders.outlet_id = ? AND orders.created_at >= ? AND orders.created_at <= ? AND orders.status IN ?",
-			outletID, from, to, []models.OrderStatus{models.OrderStatusDelivered, models.OrderStatusPartiallyDelivered}).
-		Group("product_id").
-		Scan(&categoryData)
+	categoryData, _ := repository.Orders(ctrl.DB.WithContext(c.Request.Context())).ProductQuantities(repository.OrderFilter{
+		OutletID: outletID,
+		Statuses: []models.OrderStatus{models.OrderStatusDelivered, models.OrderStatusPartiallyDelivered},
+		From:     from,
+		To:       to,
+	})
 
 	// Get product categories
 	productIDs := make([]int, len(categoryData))
@@ -182,7 +179,7 @@ func GetCategoryBreakdown(c *gin.Context) {
 	}
 
 	var products []models.Product
-	database.DB.Where("id IN ?", productIDs).Select("id, category").Find(&products)
+	ctrl.DB.WithContext(c.Request.Context()).Where("id IN ?", productIDs).Select("id, category").Find(&products)
 
 	productCategoryMap := make(map[int]string)
 	for _, product := range products {
@@ -209,89 +206,94 @@ func GetCategoryBreakdown(c *gin.Context) {
 }
 
 // GetDeliveryTimeOrders returns orders by delivery time slot
-func GetDeliveryTimeOrders(c *gin.Context) {
+func (ctrl *Controller) GetDeliveryTimeOrders(c *gin.Context) {
 	outletIDStr := c.Param("outletId")
 	outletID, err := strconv.Atoi(outletIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid outlet ID"})
+		apperror.Abort(c, apperror.BadRequest("Invalid outlet ID"))
 		return
 	}
 
-	var req struct {
-		From string `json:"from" binding:"required"`
-		To   string `json:"to" binding:"required"`
-	}
+	var req dto.DateRangeRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "from and to dates are required"})
+		apperror.Abort(c, apperror.Binding(err, "from and to dates are required"))
 		return
 	}
 
-	from, _ := time.Parse("2006-01-02", req.From)
-	to, _ := time.Parse("2006-01-02", req.To)
-
-	type SlotData struct {
-		DeliverySlot string
-		Count        int64
+	p := apperror.NewParser()
+	from := p.Date("from", req.From)
+	to := p.Date("to", req.To)
+	if err := p.Err("Invalid date range"); err != nil {
+		apperror.Abort(c, err)
+		return
 	}
 
-	var slotData []SlotData
-	database.DB.Model(&models.Order{}).
-		Select("delivery_slot, COUNT(*) as count").
-		Where("outlet_id = ? AND created_at >= ? AND created_at <= ? AND status IN ? AND delivery_slot IS NOT NULL",
-			outletID, from, to, []models.OrderStatus{models.OrderStatusDelivered, models.OrderStatusPartiallyDelivered}).
-		Group("delivery_slot").
-		Scan(&slotData)
+	slotStats, _ := repository.Orders(ctrl.DB.WithContext(c.Request.Context())).SlotStats(repository.OrderFilter{
+		OutletID: outletID,
+		Statuses: []models.OrderStatus{models.OrderStatusDelivered, models.OrderStatusPartiallyDelivered},
+		From:     from,
+		To:       to,
+	})
 
+	// Stats come per order type; add them up per slot
 	type Result struct {
 		DeliverySlot string `json:"deliverySlot"`
 		OrderCount   int64  `json:"orderCount"`
 	}
-	result := make([]Result, len(slotData))
-	for i, data := range slotData {
-		result[i] = Result{DeliverySlot: data.DeliverySlot, OrderCount: data.Count}
+	result := []Result{}
+	slotIndex := make(map[models.DeliverySlot]int)
+	for _, stat := range slotStats {
+		if stat.DeliverySlot == nil {
+			continue
+		}
+		i, ok := slotIndex[*stat.DeliverySlot]
+		if !ok {
+			i = len(result)
+			slotIndex[*stat.DeliverySlot] = i
+			result = append(result, Result{DeliverySlot: string(*stat.DeliverySlot)})
+		}
+		result[i].OrderCount += stat.Count
 	}
 
 	c.JSON(http.StatusOK, result)
 }
 
 // GetCancellationRefunds returns cancelled orders and refunds by date
-func GetCancellationRefunds(c *gin.Context) {
+func (ctrl *Controller) GetCancellationRefunds(c *gin.Context) {
 	outletIDStr := c.Param("outletId")
 	outletID, err := strconv.Atoi(outletIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid outlet ID"})
+		apperror.Abort(c, apperror.BadRequest("Invalid outlet ID"))
 		return
 	}
 
-	var req struct {
-		From string `json:"from" binding:"required"`
-		To   string `json:"to" binding:"required"`
-	}
+	var req dto.DateRangeRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "from and to dates are required"})
+		apperror.Abort(c, apperror.Binding(err, "from and to dates are required"))
 		return
 	}
 
-	from, _ := time.Parse("2006-01-02", req.From)
-	to, _ := time.Parse("2006-01-02", req.To)
+	p := apperror.NewParser()
+	from := p.Date("from", req.From)
+	to := p.Date("to", req.To)
+	if err := p.Err("Invalid date range"); err != nil {
+		apperror.Abort(c, err)
+		return
+	}
 
 	// Get cancelled orders
-	var cancelledOrders []models.Order
-	database.DB.Where("outlet_id = ? AND created_at >= ? AND created_at <= ? AND status IN ?",
-		outletID, from, to, []models.OrderStatus{models.OrderStatusCancelled, models.OrderStatusPartialCancel}).
-		Select("created_at, status").Find(&cancelledOrders)
+	cancelledOrders, _ := repository.Orders(ctrl.DB.WithContext(c.Request.Context())).List(repository.OrderFilter{
+		OutletID: outletID,
+		Statuses: []models.OrderStatus{models.OrderStatusCancelled, models.OrderStatusPartialCancel},
+		From:     from,
+		To:       to,
+	})
 
 	// Get refunds
-	var refunds []models.WalletTransaction
-	database.DB.
-		Joins("JOIN wallets ON wallets.id = wallet_transactions.wallet_id").
-		Joins("JOIN customer_details ON customer_details.id = wallets.customer_id").
-		Joins("JOIN users ON users.id = customer_details.user_id").
-		Where("users.outlet_id = ? AND wallet_transactions.status = ? AND wallet_transactions.created_at >= ? AND wallet_transactions.created_at <= ?",
-			outletID, models.WalletTransTypeDeduct, from, to).
-		Select("wallet_transactions.created_at").Find(&refunds)
+	refunds, _ := repository.Wallets(ctrl.DB.WithContext(c.Request.Context())).
+		OutletTransactions(outletID, models.WalletTransTypeDeduct, from, to)
 
 	// Group by date
 	dailyData := make(map[string]struct {
@@ -327,40 +329,35 @@ func GetCancellationRefunds(c *gin.Context) {
 }
 
 // GetQuantitySold returns quantity sold by product
-func GetQuantitySold(c *gin.Context) {
+func (ctrl *Controller) GetQuantitySold(c *gin.Context) {
 	outletIDStr := c.Param("outletId")
 	outletID, err := strconv.Atoi(outletIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid outlet ID"})
+		apperror.Abort(c, apperror.BadRequest("Invalid outlet ID"))
 		return
 	}
 
-	var req struct {
-		From string `json:"from" binding:"required"`
-		To   string `json:"to" binding:"required"`
-	}
+	var req dto.DateRangeRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "from and to dates are required"})
+		apperror.Abort(c, apperror.Binding(err, "from and to dates are required"))
 		return
 	}
 
-	from, _ := time.Parse("2006-01-02", req.From)
-	to, _ := time.Parse("2006-01-02", req.To)
-
-	type QuantityData struct {
-		ProductID int
-		Quantity  int
+	p := apperror.NewParser()
+	from := p.Date("from", req.From)
+	to := p.Date("to", req.To)
+	if err := p.Err("Invalid date range"); err != nil {
+		apperror.Abort(c, err)
+		return
 	}
 
-	var quantityData []QuantityData
-	database.DB.Model(&models.OrderItem{}).
-		Select("product_id, SUM(quantity) as quantity").
-		Joins("JOIN orders ON orders.id = order_items.order_id").
-		Where("orders.outlet_id = ? AND orders.created_at >= ? AND orders.created_at <= ? AND orders.status IN ?",
-			outletID, from, to, []models.OrderStatus{models.OrderStatusDelivered, models.OrderStatusPartiallyDelivered}).
-		Group("product_id").
-		Scan(&quantityData)
+	quantityData, _ := repository.Orders(ctrl.DB.WithContext(c.Request.Context())).ProductQuantities(repository.OrderFilter{
+		OutletID: outletID,
+		Statuses: []models.OrderStatus{models.OrderStatusDelivered, models.OrderStatusPartiallyDelivered},
+		From:     from,
+		To:       to,
+	})
 
 	// Get product names
 	productIDs := make([]int, len(quantityData))
@@ -369,7 +366,7 @@ func GetQuantitySold(c *gin.Context) {
 	}
 
 	var products []models.Product
-	database.DB.Where("id IN ?", productIDs).Select("id, name").Find(&products)
+	ctrl.DB.WithContext(c.Request.Context()).Where("id IN ?", productIDs).Select("id, name").Find(&products)
 
 	productNameMap := make(map[int]string)
 	for _, product := range products {
@@ -1,12 +1,13 @@
 package staff
 
 import (
-	"backend_pandhi/pkg/database"
+	"backend_pandhi/pkg/apperror"
+	"backend_pandhi/pkg/dto"
 	"backend_pandhi/pkg/models"
+	"backend_pandhi/pkg/repository"
 	"bytes"
 	"image/png"
 	"net/http"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/pquerna/otp/totp"
@@ -14,83 +15,77 @@ import (
 )
 
 // ChangePassword updates staff password
-func ChangePassword(c *gin.Context) {
-	var req struct {
-		CurrentPassword string `json:"currentPassword" binding:"required"`
-		NewPassword     string `json:"newPassword" binding:"required"`
-		ConfirmPassword string `json:"confirmPassword" binding:"required"`
-	}
+func (ctrl *Controller) ChangePassword(c *gin.Context) {
+	var req dto.ChangePasswordRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "Current password, new password, and confirm password are required",
-		})
+		apperror.Abort(c, apperror.Binding(err, "Current password, new password, and confirm password are required"))
 		return
 	}
 
 	if req.NewPassword != req.ConfirmPassword {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "New passwords do not match"})
+		apperror.Abort(c, apperror.BadRequest("New passwords do not match"))
 		return
 	}
 
 	if len(req.NewPassword) < 6 {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "New password must be at least 6 characters long"})
+		apperror.Abort(c, apperror.BadRequest("New password must be at least 6 characters long"))
 		return
 	}
 
 	userInterface, exists := c.Get("user")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "User not found."})
+		apperror.Abort(c, apperror.Unauthorized("User not found."))
 		return
 	}
 
 	user, ok := userInterface.(models.User)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid user data."})
+		apperror.Abort(c, apperror.Unauthorized("Invalid user data."))
 		return
 	}
 
 	// Verify current password
 	if user.Password == nil || *user.Password == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Password not set"})
+		apperror.Abort(c, apperror.BadRequest("Password not set"))
 		return
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(*user.Password), []byte(req.CurrentPassword)); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Current password is incorrect"})
+		apperror.Abort(c, apperror.BadRequest("Current password is incorrect"))
 		return
 	}
 
 	// Hash new password
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), 10)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to hash password"})
+		apperror.Abort(c, apperror.Internal("Failed to hash password", err))
 		return
 	}
 
 	// Update password
-	database.DB.Model(&user).Update("password", string(hashedPassword))
+	ctrl.DB.WithContext(c.Request.Context()).Model(&user).Update("password", string(hashedPassword))
 
 	c.JSON(http.StatusOK, gin.H{"message": "Password changed successfully"})
 }
 
 // Get2FAStatus returns 2FA status
-func Get2FAStatus(c *gin.Context) {
+func (ctrl *Controller) Get2FAStatus(c *gin.Context) {
 	userInterface, exists := c.Get("user")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "User not found."})
+		apperror.Abort(c, apperror.Unauthorized("User not found."))
 		return
 	}
 
 	user, ok := userInterface.(models.User)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid user data."})
+		apperror.Abort(c, apperror.Unauthorized("Invalid user data."))
 		return
 	}
 
-	var staff models.StaffDetails
-	if err := database.DB.Where("user_id = ?", user.ID).First(&staff).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"message": "Staff not found"})
+	staff, err := repository.Users(ctrl.DB.WithContext(c.Request.Context())).Staff(user.ID)
+	if err != nil {
+		apperror.Abort(c, apperror.NotFound("Staff not found"))
 		return
 	}
 
@@ -102,22 +97,22 @@ func Get2FAStatus(c *gin.Context) {
 }
 
 // Generate2FASetup generates QR code for 2FA setup
-func Generate2FASetup(c *gin.Context) {
+func (ctrl *Controller) Generate2FASetup(c *gin.Context) {
 	userInterface, exists := c.Get("user")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "User not found."})
+		apperror.Abort(c, apperror.Unauthorized("User not found."))
 		return
 	}
 
 	user, ok := userInterface.(models.User)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid user data."})
+		apperror.Abort(c, apperror.Unauthorized("Invalid user data."))
 		return
 	}
 
-	var staff models.StaffDetails
-	if err := database.DB.Where("user_id = ?", user.ID).First(&staff).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"message": "Staff not found"})
+	staff, err := repository.Users(ctrl.DB.WithContext(c.Request.Context())).Staff(user.ID)
+	if err != nil {
+		apperror.Abort(c, apperror.NotFound("Staff not found"))
 		return
 	}
 
@@ -127,14 +122,14 @@ func Generate2FASetup(c *gin.Context) {
 		AccountName: user.Email,
 	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to generate 2FA key"})
+		apperror.Abort(c, apperror.Internal("Failed to generate 2FA key", err))
 		return
 	}
 
 	// Store secret in DB (but not enabled yet)
 	secret := key.Secret()
-	if err := database.DB.Model(&staff).Update("two_factor_secret", secret).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to save 2FA secret"})
+	if err := repository.Users(ctrl.DB.WithContext(c.Request.Context())).SetTwoFactorSecret(&staff, secret); err != nil {
+		apperror.Abort(c, apperror.Internal("Failed to save 2FA secret", err))
 		return
 	}
 
@@ -142,7 +137,7 @@ func Generate2FASetup(c *gin.Context) {
 	var buf bytes.Buffer
 	img, err := key.Image(200, 200)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to generate QR code"})
+		apperror.Abort(c, apperror.Internal("Failed to generate QR code", err))
 		return
 	}
 	png.Encode(&buf, img)
@@ -155,43 +150,41 @@ func Generate2FASetup(c *gin.Context) {
 }
 
 // Enable2FA enables 2FA after token verification
-func Enable2FA(c *gin.Context) {
-	var req struct {
-		Token string `json:"token" binding:"required"`
-	}
+func (ctrl *Controller) Enable2FA(c *gin.Context) {
+	var req dto.Enable2FARequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Token is required"})
+		apperror.Abort(c, apperror.Binding(err, "Token is required"))
 		return
 	}
 
 	userInterface, exists := c.Get("user")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "User not found."})
+		apperror.Abort(c, apperror.Unauthorized("User not found."))
 		return
 	}
 
 	user, ok := userInterface.(models.User)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid user data."})
+		apperror.Abort(c, apperror.Unauthorized("Invalid user data."))
 		return
 	}
 
-	var staff models.StaffDetails
-	if err := database.DB.Where("user_id = ?", user.ID).First(&staff).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"message": "Staff not found"})
+	staff, err := repository.Users(ctrl.DB.WithContext(c.Request.Context())).Staff(user.ID)
+	if err != nil {
+		apperror.Abort(c, apperror.NotFound("Staff not found"))
 		return
 	}
 
 	if staff.TwoFactorSecret == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "2FA setup not initiated"})
+		apperror.Abort(c, apperror.BadRequest("2FA setup not initiated"))
 		return
 	}
 
 	// Verify token
 	valid := totp.Validate(req.Token, *staff.TwoFactorSecret)
 	if !valid {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid 2FA token"})
+		apperror.Abort(c, apperror.BadRequest("Invalid 2FA token"))
 		return
 	}
 
@@ -204,15 +197,8 @@ func Enable2FA(c *gin.Context) {
 	}
 
 	// Enable 2FA
-	now := time.Now()
-	updates := map[string]interface{}{
-		"two_factor_enabled":      true,
-		"two_factor_enabled_at":   now,
-		"two_factor_backup_codes": backupCodes, // Gorm handles JSON serialization for StringArray
-	}
-
-	if err := database.DB.Model(&staff).Updates(updates).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to enable 2FA"})
+	if err := repository.Users(ctrl.DB.WithContext(c.Request.Context())).EnableTwoFactor(&staff, backupCodes, ctrl.Clock.Now()); err != nil {
+		apperror.Abort(c, apperror.Internal("Failed to enable 2FA", err))
 		return
 	}
 
@@ -223,48 +209,45 @@ func Enable2FA(c *gin.Context) {
 }
 
 // Disable2FA disables 2FA
-func Disable2FA(c *gin.Context) {
-	var req struct {
-		CurrentPassword string  `json:"currentPassword" binding:"required"`
-		Token           *string `json:"token"`
-	}
+func (ctrl *Controller) Disable2FA(c *gin.Context) {
+	var req dto.Disable2FARequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Current password is required to disable 2FA"})
+		apperror.Abort(c, apperror.Binding(err, "Current password is required to disable 2FA"))
 		return
 	}
 
 	userInterface, exists := c.Get("user")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "User not found."})
+		apperror.Abort(c, apperror.Unauthorized("User not found."))
 		return
 	}
 
 	user, ok := userInterface.(models.User)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid user data."})
+		apperror.Abort(c, apperror.Unauthorized("Invalid user data."))
 		return
 	}
 
-	var staff models.StaffDetails
-	if err := database.DB.Where("user_id = ?", user.ID).First(&staff).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"message": "Staff not found"})
+	staff, err := repository.Users(ctrl.DB.WithContext(c.Request.Context())).Staff(user.ID)
+	if err != nil {
+		apperror.Abort(c, apperror.NotFound("Staff not found"))
 		return
 	}
 
 	if !staff.TwoFactorEnabled {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "2FA is not enabled"})
+		apperror.Abort(c, apperror.BadRequest("2FA is not enabled"))
 		return
 	}
 
 	// Verify current password
 	if user.Password == nil || *user.Password == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Password not set"})
+		apperror.Abort(c, apperror.BadRequest("Password not set"))
 		return
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(*user.Password), []byte(req.CurrentPassword)); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Current password is incorrect"})
+		apperror.Abort(c, apperror.BadRequest("Current password is incorrect"))
 		return
 	}
 
@@ -273,45 +256,43 @@ func Disable2FA(c *gin.Context) {
 		if staff.TwoFactorSecret != nil {
 			valid := totp.Validate(*req.Token, *staff.TwoFactorSecret)
 			if !valid {
-				c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid 2FA token"})
+				apperror.Abort(c, apperror.BadRequest("Invalid 2FA token"))
 				return
 			}
 		}
 	}
 
 	// Disable 2FA
-	database.DB.Model(&staff).Updates(map[string]interface{}{
-		"two_factor_enabled":      false,
-		"two_factor_secret":       nil,
-		"two_factor_backup_codes": nil,
-		"two_factor_enabled_at":   nil,
-	})
+	if err := repository.Users(ctrl.DB.WithContext(c.Request.Context())).DisableTwoFactor(&staff); err != nil {
+		apperror.Abort(c, apperror.Internal("Failed to disable 2FA", err))
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "2FA disabled successfully"})
 }
 
 // GetBackupCodesCount returns remaining backup codes count
-func GetBackupCodesCount(c *gin.Context) {
+func (ctrl *Controller) GetBackupCodesCount(c *gin.Context) {
 	userInterface, exists := c.Get("user")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "User not found."})
+		apperror.Abort(c, apperror.Unauthorized("User not found."))
 		return
 	}
 
 	user, ok := userInterface.(models.User)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid user data."})
+		apperror.Abort(c, apperror.Unauthorized("Invalid user data."))
 		return
 	}
 
-	var staff models.StaffDetails
-	if err := database.DB.Where("user_id = ?", user.ID).First(&staff).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"message": "Staff not found"})
+	staff, err := repository.Users(ctrl.DB.WithContext(c.Request.Context())).Staff(user.ID)
+	if err != nil {
+		apperror.Abort(c, apperror.NotFound("Staff not found"))
 		return
 	}
 
 	if !staff.TwoFactorEnabled {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "2FA is not enabled"})
+		apperror.Abort(c, apperror.BadRequest("2FA is not enabled"))
 		return
 	}
 
@@ -1,44 +1,69 @@
 package staff
 
 import (
-	"backend_pandhi/pkg/database"
+	"backend_pandhi/pkg/apperror"
+	"backend_pandhi/pkg/audit"
+	"backend_pandhi/pkg/dto"
+	"backend_pandhi/pkg/metrics"
 	"backend_pandhi/pkg/models"
+	"backend_pandhi/pkg/pagination"
+	"backend_pandhi/pkg/repository"
 	"net/http"
 	"strconv"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
+// RechargeHistoryList lists the sorts and filters accepted by GetRechargeHistory
+var RechargeHistoryList = pagination.Spec{
+	DefaultLimit: 50,
+	MaxLimit:     200,
+	DefaultSort:  "-createdAt",
+	Sorts: map[string]pagination.Sort{
+		"createdAt": {Column: `"WalletTransaction"."createdAt"`, Field: "CreatedAt", Type: pagination.TypeTime},
+	},
+	Filters: map[string]pagination.Filter{
+		"method":    {Column: `"WalletTransaction".method`, Type: pagination.TypeString, Ops: []pagination.Op{pagination.OpEq, pagination.OpIn}},
+		"createdAt": {Column: `"WalletTransaction"."createdAt"`, Type: pagination.TypeTime, Ops: pagination.Range},
+	},
+	IDColumn: `"WalletTransaction".id`,
+}
+
 // GetRechargeHistory returns wallet recharge history for an outlet
-func GetRechargeHistory(c *gin.Context) {
+func (ctrl *Controller) GetRechargeHistory(c *gin.Context) {
 	outletIDStr := c.Param("outletId")
 	outletID, err := strconv.Atoi(outletIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid outlet ID"})
+		apperror.Abort(c, apperror.BadRequest("Invalid outlet ID"))
+		return
+	}
+
+	list, err := pagination.Parse(c, RechargeHistoryList)
+	if err != nil {
+		apperror.Abort(c, err)
 		return
 	}
 
 	// Fetch wallet transactions for customers in this outlet
 	var transactions []models.WalletTransaction
-	database.DB.
-		Joins("JOIN wallets ON wallets.id = wallet_transactions.wallet_id").
-		Joins("JOIN customer_details ON customer_details.id = wallets.customer_id").
-		Joins("JOIN users ON users.id = customer_details.user_id").
-		Where("users.outlet_id = ? AND wallet_transactions.status = ?", outletID, models.WalletTransTypeRecharge).
+	if err := list.Apply(ctrl.DB.WithContext(c.Request.Context()).
+		Joins(`JOIN "Wallet" ON "Wallet".id = "WalletTransaction"."walletId"`).
+		Joins(`JOIN "CustomerDetails" ON "CustomerDetails".id = "Wallet"."customerId"`).
+		Joins(`JOIN "User" ON "User".id = "CustomerDetails"."userId"`).
+		Where(`"User"."outletId" = ? AND "WalletTransaction".status = ?`, outletID, models.WalletTransTypeRecharge)).
 		Preload("Wallet.Customer.User").
-		Order("wallet_transactions.created_at DESC").
-		Find(&transactions)
+		Find(&transactions).Error; err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
+		return
+	}
+	transactions, meta := pagination.Trim(list, transactions)
 
 	formattedTransactions := make([]gin.H, len(transactions))
 	for i, tx := range transactions {
 		customerName := "Unknown"
-		if tx.Wallet.Customer.ID > 0 {
-			database.DB.Preload("User").First(&tx.Wallet.Customer, tx.Wallet.Customer.ID)
-			if tx.Wallet.Customer.User.ID > 0 {
-				customerName = tx.Wallet.Customer.User.Name
-			}
+		if tx.Wallet.Customer.User.ID > 0 {
+			customerName = tx.Wallet.Customer.User.Name
 		}
 
 		formattedTransactions[i] = gin.H{
@@ -53,26 +78,44 @@ func GetRechargeHistory(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"message":      "Recharge history fetched",
 		"transactions": formattedTransactions,
+		"pagination":   meta,
 	})
 }
 
 // AddRecharge manually adds wallet balance (cash recharge by staff)
-func AddRecharge(c *gin.Context) {
-	var req struct {
-		CustomerID int     `json:"customerId" binding:"required"`
-		Amount     float64 `json:"amount" binding:"required"`
-	}
+func (ctrl *Controller) AddRecharge(c *gin.Context) {
+	var req dto.AddRechargeRequest
 
-	if err := c.ShouldBindJSON(&req); err != nil || req.Amount <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Provide valid customerId and amount"})
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.Abort(c, apperror.Binding(err, "Provide valid customerId and amount"))
+		return
+	}
+	if req.Amount <= 0 {
+		apperror.Abort(c, apperror.Validation("Provide valid customerId and amount", apperror.FieldError{
+			Field:   "amount",
+			Message: "must be greater than 0",
+		}))
 		return
 	}
 
+	// Outlet of the recharging staff member, for the audit trail
+	var outletID *int
+	if u, ok := c.Get("user"); ok {
+		if staffUser, ok := u.(models.User); ok {
+			outletID = staffUser.OutletID
+		}
+	}
+
 	// Find or create wallet
 	var wallet models.Wallet
-	err := database.DB.Transaction(func(tx *gorm.DB) error {
-		result := tx.Where("customer_id = ?", req.CustomerID).First(&wallet)
-		if result.Error == gorm.ErrRecordNotFound {
+	err := ctrl.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		var before interface{}
+		var err error
+		wallet, err = repository.Wallets(tx).ForCustomer(req.CustomerID)
+		if err == nil {
+			before = gin.H{"balance": wallet.Balance, "totalRecharged": wallet.TotalRecharged}
+		}
+		if err == gorm.ErrRecordNotFound {
 			// Create wallet if doesn't exist
 			wallet = models.Wallet{
 				CustomerID:     req.CustomerID,
@@ -80,39 +123,53 @@ func AddRecharge(c *gin.Context) {
 				TotalRecharged: req.Amount,
 				TotalUsed:      0,
 			}
-			now := time.Now()
+			now := ctrl.Clock.Now()
 			wallet.LastRecharged = &now
-			tx.Create(&wallet)
-		} else if result.Error == nil {
+			if err := tx.Create(&wallet).Error; err != nil {
+				return err
+			}
+		} else if err == nil {
 			// Update existing wallet
-			now := time.Now()
-			tx.Model(&wallet).Updates(map[string]interface{}{
-				"balance":         wallet.Balance + req.Amount,
-				"total_recharged": wallet.TotalRecharged + req.Amount,
-				"last_recharged":  &now,
-			})
-			wallet.Balance += req.Amount
-			wallet.TotalRecharged += req.Amount
+			if err := repository.Wallets(tx).Recharge(&wallet, req.Amount, ctrl.Clock.Now()); err != nil {
+				return err
+			}
 		} else {
-			return result.Error
+			return err
 		}
 
 		// Create transaction record
-		tx.Create(&models.WalletTransaction{
+		if err := tx.Create(&models.WalletTransaction{
 			WalletID: wallet.ID,
 			Amount:   req.Amount,
 			Method:   models.PaymentMethodCash,
 			Status:   models.WalletTransTypeRecharge,
-		})
+		}).Error; err != nil {
+			return err
+		}
 
-		return nil
+		return audit.Record(c, tx, audit.Entry{
+			Action:     audit.ActionWalletRecharge,
+			EntityType: audit.EntityWallet,
+			EntityID:   wallet.ID,
+			OutletID:   outletID,
+			Before:     before,
+			After: gin.H{
+				"customerId":     req.CustomerID,
+				"amount":         req.Amount,
+				"method":         models.PaymentMethodCash,
+				"balance":        wallet.Balance,
+				"totalRecharged": wallet.TotalRecharged,
+			},
+		})
 	})
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to recharge wallet"})
+		apperror.Abort(c, apperror.Internal("Failed to recharge wallet", err))
 		return
 	}
 
+	metrics.WalletRecharged(models.PaymentMethodCash, req.Amount)
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Wallet recharged successfully",
 		"wallet":  wallet,
@@ -0,0 +1,165 @@
+package superadmin
+
+import (
+	"backend_pandhi/pkg/alerts"
+	"backend_pandhi/pkg/apperror"
+	"backend_pandhi/pkg/audit"
+	"backend_pandhi/pkg/dto"
+	"backend_pandhi/pkg/models"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetAlertRules lists the rules that open tickets for an outlet's negative
+// feedback
+func (ctrl *Controller) GetAlertRules(c *gin.Context) {
+	outletID, err := strconv.Atoi(c.Param("outletId"))
+	if err != nil {
+		apperror.Abort(c, apperror.BadRequest("Provide valid OutletId"))
+		return
+	}
+
+	rules := []models.FeedbackAlertRule{}
+	if err := ctrl.DB.WithContext(c.Request.Context()).
+		Where(`"outletId" = ?`, outletID).
+		Order("id").
+		Find(&rules).Error; err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
+		return
+	}
+
+	var def *models.FeedbackAlertRule
+	if len(rules) == 0 {
+		rule := alerts.DefaultRule
+		rule.OutletID = outletID
+		def = &rule
+	}
+
+	c.JSON(http.StatusOK, gin.H{"rules": rules, "default": def})
+}
+
+// CreateAlertRule adds a feedback alert rule to an outlet. An outlet's own
+// rules replace the default rule.
+func (ctrl *Controller) CreateAlertRule(c *gin.Context) {
+	var req dto.AlertRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.Abort(c, apperror.Binding(err, "name and maxRating between 1 and 5 are required"))
+		return
+	}
+	if req.OutletID == 0 {
+		apperror.Abort(c, apperror.BadRequest("outletId is required"))
+		return
+	}
+
+	var outlet models.Outlet
+	if err := ctrl.DB.WithContext(c.Request.Context()).First(&outlet, req.OutletID).Error; err != nil {
+		apperror.Abort(c, apperror.NotFound("Outlet not found"))
+		return
+	}
+
+	rule := models.FeedbackAlertRule{OutletID: outlet.ID, IsActive: true}
+	applyAlertRule(&rule, req)
+	if err := ctrl.DB.WithContext(c.Request.Context()).Create(&rule).Error; err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Alert rule created", "rule": rule})
+}
+
+// UpdateAlertRule replaces the conditions of a feedback alert rule
+func (ctrl *Controller) UpdateAlertRule(c *gin.Context) {
+	ruleID, err := strconv.Atoi(c.Param("ruleId"))
+	if err != nil {
+		apperror.Abort(c, apperror.BadRequest("Valid ruleId is required"))
+		return
+	}
+
+	var req dto.AlertRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.Abort(c, apperror.Binding(err, "name and maxRating between 1 and 5 are required"))
+		return
+	}
+
+	var before, rule models.FeedbackAlertRule
+	if err := ctrl.DB.WithContext(c.Request.Context()).First(&before, ruleID).Error; err != nil {
+		apperror.Abort(c, apperror.NotFound("Alert rule not found"))
+		return
+	}
+	rule = before
+	applyAlertRule(&rule, req)
+
+	err = ctrl.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&rule).Error; err != nil {
+			return err
+		}
+		return audit.Record(c, tx, audit.Entry{
+			Action:     audit.ActionAlertRuleUpdate,
+			EntityType: audit.EntityAlertRule,
+			EntityID:   ruleID,
+			OutletID:   &rule.OutletID,
+			Before:     before,
+			After:      rule,
+		})
+	})
+	if err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Alert rule updated", "rule": rule})
+}
+
+// DeleteAlertRule removes a feedback alert rule. Removing an outlet's last
+// rule brings back the default rule.
+func (ctrl *Controller) DeleteAlertRule(c *gin.Context) {
+	ruleID, err := strconv.Atoi(c.Param("ruleId"))
+	if err != nil {
+		apperror.Abort(c, apperror.BadRequest("Valid ruleId is required"))
+		return
+	}
+
+	var rule models.FeedbackAlertRule
+	if err := ctrl.DB.WithContext(c.Request.Context()).First(&rule, ruleID).Error; err != nil {
+		apperror.Abort(c, apperror.NotFound("Alert rule not found"))
+		return
+	}
+
+	err = ctrl.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&models.FeedbackAlertRule{}, ruleID).Error; err != nil {
+			return err
+		}
+		return audit.Record(c, tx, audit.Entry{
+			Action:     audit.ActionAlertRuleDelete,
+			EntityType: audit.EntityAlertRule,
+			EntityID:   ruleID,
+			OutletID:   &rule.OutletID,
+			Before:     rule,
+		})
+	})
+	if err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Alert rule deleted successfully"})
+}
+
+// applyAlertRule copies a request onto a rule; the outlet never changes
+func applyAlertRule(rule *models.FeedbackAlertRule, req dto.AlertRuleRequest) {
+	rule.Name = strings.TrimSpace(req.Name)
+	rule.MaxRating = req.MaxRating
+	rule.Categories = models.StringList(req.Categories)
+	rule.Keywords = models.StringList(req.Keywords)
+	rule.Priority = models.PriorityHigh
+	if req.Priority != "" {
+		rule.Priority = models.Priority(req.Priority)
+	}
+	if req.IsActive != nil {
+		rule.IsActive = *req.IsActive
+	}
+}
@@ -1,8 +1,10 @@
 package superadmin
 
 import (
-	"backend_pandhi/pkg/database"
+	"backend_pandhi/pkg/apperror"
+	"backend_pandhi/pkg/dto"
 	"backend_pandhi/pkg/models"
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
@@ -12,22 +14,22 @@ import (
 )
 
 // GetOutletAppFeatures returns app features for outlet
-func GetOutletAppFeatures(c *gin.Context) {
+func (ctrl *Controller) GetOutletAppFeatures(c *gin.Context) {
 	outletIDStr := c.Param("outletId")
 	outletID, err := strconv.Atoi(outletIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Valid outletId is required"})
+		apperror.Abort(c, apperror.BadRequest("Valid outletId is required"))
 		return
 	}
 
 	var outlet models.Outlet
-	if err := database.DB.First(&outlet, outletID).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Outlet not found"})
+	if err := ctrl.DB.WithContext(c.Request.Context()).First(&outlet, outletID).Error; err != nil {
+		apperror.Abort(c, apperror.BadRequest("Outlet not found"))
 		return
 	}
 
 	var features []models.OutletAppManagement
-	database.DB.Where(`"outletId" = ?`, outletID).Find(&features)
+	ctrl.DB.WithContext(c.Request.Context()).Where(`"outletId" = ?`, outletID).Find(&features)
 
 	allFeatures := []string{"APP", "UPI", "LIVE_COUNTER", "COUPONS"}
 	featureStatus := make(map[string]bool)
@@ -47,66 +49,70 @@ func GetOutletAppFeatures(c *gin.Context) {
 }
 
 // UpdateOutletAppFeatures updates app features for outlet
-func UpdateOutletAppFeatures(c *gin.Context) {
-	var req struct {
-		OutletID int `json:"outletId" binding:"required"`
-		Features []struct {
-			Feature   string `json:"feature" binding:"required"`
-			IsEnabled bool   `json:"isEnabled"`
-		} `json:"features" binding:"required"`
-	}
+func (ctrl *Controller) UpdateOutletAppFeatures(c *gin.Context) {
+	var req dto.UpdateAppFeaturesRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "outletId and features array are required"})
+		apperror.Abort(c, apperror.Binding(err, "outletId and features array are required"))
 		return
 	}
 
 	validFeatures := map[string]bool{"APP": true, "UPI": true, "LIVE_COUNTER": true, "COUPONS": true}
 	for _, f := range req.Features {
 		if !validFeatures[f.Feature] {
-			c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid feature. Must be APP, UPI, LIVE_COUNTER, or COUPONS"})
+			apperror.Abort(c, apperror.BadRequest("Invalid feature. Must be APP, UPI, LIVE_COUNTER, or COUPONS"))
 			return
 		}
 	}
 
 	var outlet models.Outlet
-	if err := database.DB.First(&outlet, req.OutletID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"message": "Outlet not found"})
+	if err := ctrl.DB.WithContext(c.Request.Context()).First(&outlet, req.OutletID).Error; err != nil {
+		apperror.Abort(c, apperror.NotFound("Outlet not found"))
 		return
 	}
 
-	database.DB.Transaction(func(tx *gorm.DB) error {
+	err := ctrl.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
 		for _, f := range req.Features {
 			var existing models.OutletAppManagement
 			err := tx.Where(`"outletId" = ? AND feature = ?`, req.OutletID, f.Feature).First(&existing).Error
 			if err == nil {
-				tx.Model(&existing).Update("isEnabled", f.IsEnabled)
-			} else {
+				if err := tx.Model(&existing).Update("isEnabled", f.IsEnabled).Error; err != nil {
+					return err
+				}
+			} else if errors.Is(err, gorm.ErrRecordNotFound) {
 				newFeature := models.OutletAppManagement{
 					OutletID:  req.OutletID,
 					Feature:   models.OutletAppFeature(f.Feature),
 					IsEnabled: f.IsEnabled,
 				}
-				tx.Create(&newFeature)
+				if err := tx.Create(&newFeature).Error; err != nil {
+					return err
+				}
+			} else {
+				return err
 			}
 		}
 		return nil
 	})
+	if err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Outlet app features updated successfully"})
 }
 
 // GetOutletNonAvailabilityPreview returns non-availability preview
-func GetOutletNonAvailabilityPreview(c *gin.Context) {
+func (ctrl *Controller) GetOutletNonAvailabilityPreview(c *gin.Context) {
 	outletIDStr := c.Param("outletId")
 	outletID, err := strconv.Atoi(outletIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Valid outletId is required"})
+		apperror.Abort(c, apperror.BadRequest("Valid outletId is required"))
 		return
 	}
 
 	var nonAvailable []models.OutletAvailability
-	database.DB.Where(`"outletId" = ?`, outletID).Find(&nonAvailable)
+	ctrl.DB.WithContext(c.Request.Context()).Where(`"outletId" = ?`, outletID).Find(&nonAvailable)
 
 	previewData := []gin.H{}
 	for _, entry := range nonAvailable {
@@ -123,23 +129,19 @@ func GetOutletNonAvailabilityPreview(c *gin.Context) {
 }
 
 // SetOutletAvailability sets non-available slots for dates
-func SetOutletAvailability(c *gin.Context) {
-	var req struct {
-		OutletID          int `json:"outletId" binding:"required"`
-		NonAvailableDates []struct {
-			Date              string   `json:"date" binding:"required"`
-			NonAvailableSlots []string `json:"nonAvailableSlots" binding:"required"`
-		} `json:"nonAvailableDates" binding:"required"`
-	}
+func (ctrl *Controller) SetOutletAvailability(c *gin.Context) {
+	var req dto.SetOutletAvailabilityRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "outletId and nonAvailableDates array are required"})
+		apperror.Abort(c, apperror.Binding(err, "outletId and nonAvailableDates array are required"))
 		return
 	}
 
-	database.DB.Transaction(func(tx *gorm.DB) error {
+	err := ctrl.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
 		// Delete existing
-		tx.Where(`"outletId" = ?`, req.OutletID).Delete(&models.OutletAvailability{})
+		if err := tx.Where(`"outletId" = ?`, req.OutletID).Delete(&models.OutletAvailability{}).Error; err != nil {
+			return err
+		}
 
 		// Create new
 		for _, entry := range req.NonAvailableDates {
@@ -160,28 +162,34 @@ func SetOutletAvailability(c *gin.Context) {
 				Date:              parsedDate,
 				NonAvailableSlots: jsonArray,
 			}
-			tx.Create(&availability)
+			if err := tx.Create(&availability).Error; err != nil {
+				return err
+			}
 		}
 		return nil
 	})
+	if err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Outlet availability updated successfully"})
 }
 
 // GetAvailableDatesAndSlots returns available dates and slots for next 30 days
-func GetAvailableDatesAndSlots(c *gin.Context) {
+func (ctrl *Controller) GetAvailableDatesAndSlots(c *gin.Context) {
 	outletIDStr := c.Param("outletId")
 	outletID, err := strconv.Atoi(outletIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Valid outletId is required"})
+		apperror.Abort(c, apperror.BadRequest("Valid outletId is required"))
 		return
 	}
 
-	today := time.Now()
+	today := ctrl.Clock.Now()
 	next30Days := today.AddDate(0, 0, 30)
 
 	var nonAvailable []models.OutletAvailability
-	database.DB.Where(`"outletId" = ? AND date >= ? AND date <= ?`,
+	ctrl.DB.WithContext(c.Request.Context()).Where(`"outletId" = ? AND date >= ? AND date <= ?`,
 		outletID, today, next30Days).Find(&nonAvailable)
 
 	allSlots := []string{"SLOT_11_12", "SLOT_12_13", "SLOT_13_14", "SLOT_14_15", "SLOT_15_16", "SLOT_16_17"}
@@ -0,0 +1,13 @@
+package superadmin
+
+import "backend_pandhi/pkg/app"
+
+// Controller serves the admin and superadmin console endpoints
+type Controller struct {
+	*app.App
+}
+
+// New returns a controller backed by a
+func New(a *app.App) *Controller {
+	return &Controller{App: a}
+}
@@ -1,32 +1,26 @@
 package superadmin
 
 import (
-	"backend_pandhi/pkg/database"
+	"backend_pandhi/pkg/apperror"
+	"backend_pandhi/pkg/audit"
+	"backend_pandhi/pkg/dto"
 	"backend_pandhi/pkg/models"
+	"backend_pandhi/pkg/pagination"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // CreateCoupon creates a new coupon
-func CreateCoupon(c *gin.Context) {
-	var req struct {
-		Code          string  `json:"code" binding:"required"`
-		Description   *string `json:"description"`
-		RewardValue   string  `json:"rewardValue" binding:"required"`
-		MinOrderValue float64 `json:"minOrderValue" binding:"required"`
-		ValidFrom     string  `json:"validFrom" binding:"required"`
-		ValidUntil    string  `json:"validUntil" binding:"required"`
-		IsActive      *bool   `json:"isActive"`
-		UsageLimit    *int    `json:"usageLimit"`
-		OutletID      int     `json:"outletId" binding:"required"`
-	}
+func (ctrl *Controller) CreateCoupon(c *gin.Context) {
+	var req dto.CreateCouponRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "code, rewardValue, minOrderValue, validFrom, and validUntil are required"})
+		apperror.Abort(c, apperror.Binding(err, "code, rewardValue, minOrderValue, validFrom, and validUntil are required"))
 		return
 	}
 
@@ -36,17 +30,22 @@ func CreateCoupon(c *gin.Context) {
 		percentStr := strings.TrimSuffix(req.RewardValue, "%")
 		percentage, err := strconv.ParseFloat(percentStr, 64)
 		if err != nil || percentage <= 0 || percentage > 100 {
-			c.JSON(http.StatusBadRequest, gin.H{"message": "rewardValue must be a valid percentage between 1% and 100%"})
+			apperror.Abort(c, apperror.BadRequest("rewardValue must be a valid percentage between 1% and 100%"))
 			return
 		}
 		parsedRewardValue = percentage / 100
 	} else {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "rewardValue must be provided as a percentage (e.g., '10%')"})
+		apperror.Abort(c, apperror.BadRequest("rewardValue must be provided as a percentage (e.g., '10%')"))
 		return
 	}
 
-	validFrom, _ := time.Parse(time.RFC3339, req.ValidFrom)
-	validUntil, _ := time.Parse(time.RFC3339, req.ValidUntil)
+	p := apperror.NewParser()
+	validFrom := p.Time("validFrom", time.RFC3339, req.ValidFrom)
+	validUntil := p.Time("validUntil", time.RFC3339, req.ValidUntil)
+	if err := p.Err("Invalid coupon validity period"); err != nil {
+		apperror.Abort(c, err)
+		return
+	}
 
 	isActive := true
 	if req.IsActive != nil {
@@ -75,8 +74,8 @@ func CreateCoupon(c *gin.Context) {
 		OutletID:      &req.OutletID,
 	}
 
-	if err := database.DB.Create(&coupon).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal server error", "error": err.Error()})
+	if err := ctrl.DB.WithContext(c.Request.Context()).Create(&coupon).Error; err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
 		return
 	}
 
@@ -86,41 +85,82 @@ func CreateCoupon(c *gin.Context) {
 	})
 }
 
-// GetCoupons returns all coupons for an outlet
-func GetCoupons(c *gin.Context) {
+// CouponsList lists the sorts and filters accepted by GetCoupons
+var CouponsList = pagination.Spec{
+	DefaultLimit: 50,
+	MaxLimit:     200,
+	DefaultSort:  "-createdAt",
+	Sorts: map[string]pagination.Sort{
+		"createdAt":  {Column: `"createdAt"`, Field: "CreatedAt", Type: pagination.TypeTime},
+		"validUntil": {Column: `"validUntil"`, Field: "ValidUntil", Type: pagination.TypeTime},
+		"code":       {Column: "code", Field: "Code", Type: pagination.TypeString},
+	},
+	Filters: map[string]pagination.Filter{
+		"isActive":   {Column: `"isActive"`, Type: pagination.TypeBool},
+		"code":       {Column: "code", Type: pagination.TypeString, Ops: []pagination.Op{pagination.OpEq, pagination.OpContains}},
+		"validUntil": {Column: `"validUntil"`, Type: pagination.TypeTime, Ops: pagination.Range},
+	},
+}
+
+// GetCoupons returns a page of an outlet's coupons
+func (ctrl *Controller) GetCoupons(c *gin.Context) {
 	outletIDStr := c.Param("outletId")
 	outletID, err := strconv.Atoi(outletIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid outlet ID"})
+		apperror.Abort(c, apperror.BadRequest("Invalid outlet ID"))
+		return
+	}
+
+	list, err := pagination.Parse(c, CouponsList)
+	if err != nil {
+		apperror.Abort(c, err)
 		return
 	}
 
 	var coupons []models.Coupon
-	database.DB.Where(`"outletId" = ?`, outletID).Find(&coupons)
+	if err := list.Apply(ctrl.DB.WithContext(c.Request.Context()).Where(`"outletId" = ?`, outletID)).
+		Find(&coupons).Error; err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
+		return
+	}
+	coupons, meta := pagination.Trim(list, coupons)
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Coupons fetched successfully",
-		"data":    coupons,
+		"message":    "Coupons fetched successfully",
+		"data":       coupons,
+		"pagination": meta,
 	})
 }
 
 // DeleteCoupon deletes a coupon
-func DeleteCoupon(c *gin.Context) {
+func (ctrl *Controller) DeleteCoupon(c *gin.Context) {
 	couponIDStr := c.Param("couponId")
 	couponID, err := strconv.Atoi(couponIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Valid couponId is required"})
+		apperror.Abort(c, apperror.BadRequest("Valid couponId is required"))
 		return
 	}
 
-	result := database.DB.Delete(&models.Coupon{}, couponID)
-	if result.RowsAffected == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"message": "Coupon not found"})
+	var coupon models.Coupon
+	if err := ctrl.DB.WithContext(c.Request.Context()).First(&coupon, couponID).Error; err != nil {
+		apperror.Abort(c, apperror.NotFound("Coupon not found"))
 		return
 	}
 
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal server error", "error": result.Error.Error()})
+	err = ctrl.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&models.Coupon{}, couponID).Error; err != nil {
+			return err
+		}
+		return audit.Record(c, tx, audit.Entry{
+			Action:     audit.ActionCouponDelete,
+			EntityType: audit.EntityCoupon,
+			EntityID:   couponID,
+			OutletID:   coupon.OutletID,
+			Before:     coupon,
+		})
+	})
+	if err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
 		return
 	}
 
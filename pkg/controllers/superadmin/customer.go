@@ -1,29 +1,76 @@
 package superadmin
 
 import (
-	"backend_pandhi/pkg/database"
+	"backend_pandhi/pkg/apperror"
 	"backend_pandhi/pkg/models"
+	"backend_pandhi/pkg/pagination"
+	"backend_pandhi/pkg/repository"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
 
-// GetOutletCustomers returns customers for an outlet with wallet and order stats
-func GetOutletCustomers(c *gin.Context) {
+// OutletCustomersList lists the sorts and filters accepted by GetOutletCustomers and GetCustomersWithWallet
+var OutletCustomersList = pagination.Spec{
+	DefaultLimit: 50,
+	MaxLimit:     200,
+	DefaultSort:  "name",
+	Sorts: map[string]pagination.Sort{
+		"id":        {Column: `"User".id`, Field: "ID", Type: pagination.TypeInt},
+		"name":      {Column: `"User".name`, Field: "Name", Type: pagination.TypeString},
+		"createdAt": {Column: `"User"."createdAt"`, Field: "CreatedAt", Type: pagination.TypeTime},
+	},
+	Filters: map[string]pagination.Filter{
+		"name":  {Column: `"User".name`, Type: pagination.TypeString, Ops: []pagination.Op{pagination.OpEq, pagination.OpContains}},
+		"email": {Column: `"User".email`, Type: pagination.TypeString, Ops: []pagination.Op{pagination.OpEq, pagination.OpContains}},
+		"phone": {Column: `"User".phone`, Type: pagination.TypeString, Ops: []pagination.Op{pagination.OpEq, pagination.OpContains}},
+	},
+	IDColumn: `"User".id`,
+}
+
+// GetOutletCustomers returns a page of customers for an outlet with wallet and order stats
+func (ctrl *Controller) GetOutletCustomers(c *gin.Context) {
 	outletIDStr := c.Param("outletId")
 	outletID, err := strconv.Atoi(outletIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid outlet ID"})
+		apperror.Abort(c, apperror.BadRequest("Invalid outlet ID"))
+		return
+	}
+
+	list, err := pagination.Parse(c, OutletCustomersList)
+	if err != nil {
+		apperror.Abort(c, err)
 		return
 	}
 
-	// Get users with customer info
 	var users []models.User
-	database.DB.Where(`"outletId" = ? AND role = ?`, outletID, models.RoleCustomer).
+	if err := list.Apply(ctrl.DB.WithContext(c.Request.Context()).Where(`"User"."outletId" = ? AND "User".role = ?`, outletID, models.RoleCustomer)).
 		Preload("CustomerInfo.Wallet").
-		Preload("CustomerInfo.Orders").
-		Find(&users)
+		Find(&users).Error; err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
+		return
+	}
+	users, meta := pagination.Trim(list, users)
+
+	// Order stats for this page only, aggregated in the database
+	customerIDs := make([]int, 0, len(users))
+	for _, user := range users {
+		if user.CustomerInfo != nil {
+			customerIDs = append(customerIDs, user.CustomerInfo.ID)
+		}
+	}
+	statsByCustomer := make(map[int]repository.CustomerOrderStats)
+	if len(customerIDs) > 0 {
+		stats, err := repository.Orders(ctrl.DB.WithContext(c.Request.Context())).CustomerStats(customerIDs)
+		if err != nil {
+			apperror.Abort(c, apperror.Internal("Internal server error", err))
+			return
+		}
+		for _, s := range stats {
+			statsByCustomer[s.CustomerID] = s
+		}
+	}
 
 	formattedCustomers := make([]gin.H, len(users))
 	for i, user := range users {
@@ -31,8 +78,7 @@ func GetOutletCustomers(c *gin.Context) {
 		var walletID *int
 		var yearOfStudy *int
 		var walletBalance float64
-		var totalOrders int64
-		var totalPurchase float64
+		var stats repository.CustomerOrderStats
 		var lastOrderDate *string
 
 		if user.CustomerInfo != nil {
@@ -46,21 +92,9 @@ func GetOutletCustomers(c *gin.Context) {
 				walletBalance = user.CustomerInfo.Wallet.Balance
 			}
 
-			// Calculate order stats
-			totalOrders = int64(len(user.CustomerInfo.Orders))
-			for _, order := range user.CustomerInfo.Orders {
-				totalPurchase += order.TotalAmount
-			}
-
-			// Get last order date
-			if len(user.CustomerInfo.Orders) > 0 {
-				latest := user.CustomerInfo.Orders[0].CreatedAt
-				for _, order := range user.CustomerInfo.Orders {
-					if order.CreatedAt.After(latest) {
-						latest = order.CreatedAt
-					}
-				}
-				dateStr := latest.Format("2006-01-02T15:04:05Z")
+			stats = statsByCustomer[user.CustomerInfo.ID]
+			if stats.LastOrderAt != nil {
+				dateStr := stats.LastOrderAt.UTC().Format("2006-01-02T15:04:05Z")
 				lastOrderDate = &dateStr
 			}
 		}
@@ -73,8 +107,8 @@ func GetOutletCustomers(c *gin.Context) {
 			"yearOfStudy":       yearOfStudy,
 			"phoneNo":           user.Phone,
 			"walletBalance":     walletBalance,
-			"totalOrders":       totalOrders,
-			"totalPurchaseCost": totalPurchase,
+			"totalOrders":       stats.TotalOrders,
+			"totalPurchaseCost": stats.TotalPurchase,
 			"lastOrderDate":     lastOrderDate,
 		}
 	}
@@ -85,7 +119,8 @@ func GetOutletCustomers(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message":   message,
-		"customers": formattedCustomers,
+		"message":    message,
+		"customers":  formattedCustomers,
+		"pagination": meta,
 	})
 }
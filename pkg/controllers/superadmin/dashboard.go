@@ -1,9 +1,13 @@
 package superadmin
 
 import (
-	"backend_pandhi/pkg/database"
+	"backend_pandhi/pkg/apperror"
+	"backend_pandhi/pkg/audit"
+	"backend_pandhi/pkg/dto"
 	"backend_pandhi/pkg/models"
-	"backend_pandhi/pkg/services"
+	"backend_pandhi/pkg/pagination"
+	"backend_pandhi/pkg/repository"
+	"errors"
 	"fmt"
 	"net/http"
 	"regexp"
@@ -14,38 +18,44 @@ import (
 	"gorm.io/gorm"
 )
 
+// completedOrderStatuses are the statuses of orders that count as revenue
+var completedOrderStatuses = []models.OrderStatus{models.OrderStatusDelivered, models.OrderStatusPartiallyDelivered}
+
 // GetDashboardOverview returns overall statistics
-func GetDashboardOverview(c *gin.Context) {
+func (ctrl *Controller) GetDashboardOverview(c *gin.Context) {
 	var totalActiveOutlets int64
-	database.DB.Model(&models.Outlet{}).Where(`"isActive" = ?`, true).Count(&totalActiveOutlets)
+	ctrl.DB.WithContext(c.Request.Context()).Model(&models.Outlet{}).Where(`"isActive" = ?`, true).Count(&totalActiveOutlets)
 
-	var totalRevenue float64
-	database.DB.Model(&models.Order{}).
-		Where("status IN ?", []models.OrderStatus{models.OrderStatusDelivered, models.OrderStatusPartiallyDelivered}).
-		Select("COALESCE(SUM(\"totalAmount\"), 0)").Scan(&totalRevenue)
+	orders := repository.Orders(ctrl.DB.WithContext(c.Request.Context()))
+	totalRevenue, err := orders.Revenue(repository.OrderFilter{Statuses: completedOrderStatuses})
+	if err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
+		return
+	}
 
-	var totalCustomers int64
-	database.DB.Model(&models.CustomerDetails{}).Count(&totalCustomers)
+	totalCustomers, err := repository.Users(ctrl.DB.WithContext(c.Request.Context())).CountCustomers()
+	if err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
+		return
+	}
 
-	var totalOrders int64
-	database.DB.Model(&models.Order{}).Count(&totalOrders)
+	totalOrders, err := orders.Count(repository.OrderFilter{})
+	if err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
+		return
+	}
 
 	// Top performing outlet
-	type OutletRevenue struct {
-		OutletID    int
-		TotalAmount float64
+	topOutlets, err := orders.TopOutlets(repository.OrderFilter{}, 1)
+	if err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
+		return
 	}
-	var topOutlet OutletRevenue
-	database.DB.Model(&models.Order{}).
-		Select("\"outletId\", SUM(\"totalAmount\") as \"totalAmount\"").
-		Group("\"outletId\"").
-		Order("\"totalAmount\" DESC").
-		Limit(1).Scan(&topOutlet)
 
 	var topOutletDetails *models.Outlet
-	if topOutlet.OutletID > 0 {
+	if len(topOutlets) > 0 {
 		topOutletDetails = &models.Outlet{}
-		database.DB.Select("id, name").First(topOutletDetails, topOutlet.OutletID)
+		ctrl.DB.WithContext(c.Request.Context()).Select("id, name").First(topOutletDetails, topOutlets[0].OutletID)
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -58,29 +68,28 @@ func GetDashboardOverview(c *gin.Context) {
 }
 
 // GetRevenueTrend returns daily revenue trend
-func GetRevenueTrend(c *gin.Context) {
-	var req struct {
-		From string `json:"from" binding:"required"`
-		To   string `json:"to" binding:"required"`
-	}
+func (ctrl *Controller) GetRevenueTrend(c *gin.Context) {
+	var req dto.DateRangeRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "from and to dates are required"})
+		apperror.Abort(c, apperror.Binding(err, "from and to dates are required"))
 		return
 	}
 
-	from, _ := time.Parse("2006-01-02", req.From)
-	to, _ := time.Parse("2006-01-02", req.To)
+	p := apperror.NewParser()
+	from := p.Date("from", req.From)
+	to := p.Date("to", req.To)
+	if err := p.Err("Invalid date range"); err != nil {
+		apperror.Abort(c, err)
+		return
+	}
 	to = to.Add(23*time.Hour + 59*time.Minute)
 
-	var orders []struct {
-		TotalAmount float64
-		CreatedAt   time.Time
+	orders, err := repository.Orders(ctrl.DB.WithContext(c.Request.Context())).
+		List(repository.OrderFilter{Statuses: completedOrderStatuses, From: from, To: to})
+	if err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
+		return
 	}
-	database.DB.Model(&models.Order{}).
-		Select("\"totalAmount\", \"createdAt\"").
-		Where("\"createdAt\" >= ? AND \"createdAt\" <= ? AND status IN ?",
-			from, to, []models.OrderStatus{models.OrderStatusDelivered, models.OrderStatusPartiallyDelivered}).
-		Find(&orders)
 
 	dailyRevenue := make(map[string]float64)
 	for _, order := range orders {
@@ -97,30 +106,28 @@ func GetRevenueTrend(c *gin.Context) {
 }
 
 // GetOrderStatusDistribution returns order counts by status
-func GetOrderStatusDistribution(c *gin.Context) {
-	var req struct {
-		From string `json:"from" binding:"required"`
-		To   string `json:"to" binding:"required"`
-	}
+func (ctrl *Controller) GetOrderStatusDistribution(c *gin.Context) {
+	var req dto.DateRangeRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "from and to dates are required"})
+		apperror.Abort(c, apperror.Binding(err, "from and to dates are required"))
 		return
 	}
 
-	from, _ := time.Parse("2006-01-02", req.From)
-	to, _ := time.Parse("2006-01-02", req.To)
+	p := apperror.NewParser()
+	from := p.Date("from", req.From)
+	to := p.Date("to", req.To)
+	if err := p.Err("Invalid date range"); err != nil {
+		apperror.Abort(c, err)
+		return
+	}
 	to = to.Add(23*time.Hour + 59*time.Minute)
 
-	type StatusCount struct {
-		Status models.OrderStatus
-		Count  int64
+	statusCounts, err := repository.Orders(ctrl.DB.WithContext(c.Request.Context())).
+		StatusCounts(repository.OrderFilter{From: from, To: to})
+	if err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
+		return
 	}
-	var statusCounts []StatusCount
-	database.DB.Model(&models.Order{}).
-		Select("status, COUNT(*) as count").
-		Where("\"createdAt\" >= ? AND \"createdAt\" <= ?", from, to).
-		Group("status").
-		Scan(&statusCounts)
 
 	result := gin.H{
 		"delivered":           int64(0),
@@ -146,30 +153,28 @@ func GetOrderStatusDistribution(c *gin.Context) {
 }
 
 // GetOrderSourceDistribution returns APP vs MANUAL counts
-func GetOrderSourceDistribution(c *gin.Context) {
-	var req struct {
-		From string `json:"from" binding:"required"`
-		To   string `json:"to" binding:"required"`
-	}
+func (ctrl *Controller) GetOrderSourceDistribution(c *gin.Context) {
+	var req dto.DateRangeRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "from and to dates are required"})
+		apperror.Abort(c, apperror.Binding(err, "from and to dates are required"))
 		return
 	}
 
-	from, _ := time.Parse("2006-01-02", req.From)
-	to, _ := time.Parse("2006-01-02", req.To)
+	p := apperror.NewParser()
+	from := p.Date("from", req.From)
+	to := p.Date("to", req.To)
+	if err := p.Err("Invalid date range"); err != nil {
+		apperror.Abort(c, err)
+		return
+	}
 	to = to.Add(23*time.Hour + 59*time.Minute)
 
-	type TypeCount struct {
-		Type  models.OrderType
-		Count int64
+	typeCounts, err := repository.Orders(ctrl.DB.WithContext(c.Request.Context())).
+		TypeCounts(repository.OrderFilter{From: from, To: to})
+	if err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
+		return
 	}
-	var typeCounts []TypeCount
-	database.DB.Model(&models.Order{}).
-		Select("type, COUNT(*) as count").
-		Where("\"createdAt\" >= ? AND \"createdAt\" <= ?", from, to).
-		Group("type").
-		Scan(&typeCounts)
 
 	result := gin.H{
 		"appOrders":    int64(0),
@@ -188,18 +193,20 @@ func GetOrderSourceDistribution(c *gin.Context) {
 }
 
 // GetTopSellingItems returns top 3 products by quantity
-func GetTopSellingItems(c *gin.Context) {
-	var req struct {
-		From string `json:"from" binding:"required"`
-		To   string `json:"to" binding:"required"`
-	}
+func (ctrl *Controller) GetTopSellingItems(c *gin.Context) {
+	var req dto.DateRangeRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "from and to dates are required"})
+		apperror.Abort(c, apperror.Binding(err, "from and to dates are required"))
 		return
 	}
 
-	from, _ := time.Parse("2006-01-02", req.From)
-	to, _ := time.Parse("2006-01-02", req.To)
+	p := apperror.NewParser()
+	from := p.Date("from", req.From)
+	to := p.Date("to", req.To)
+	if err := p.Err("Invalid date range"); err != nil {
+		apperror.Abort(c, err)
+		return
+	}
 	to = to.Add(23*time.Hour + 59*time.Minute)
 
 	type ProductStats struct {
@@ -208,51 +215,53 @@ func GetTopSellingItems(c *gin.Context) {
 		TotalOrders  int     `json:"totalOrders"`
 		TotalRevenue float64 `json:"totalRevenue"`
 	}
-	var stats []ProductStats
-	database.DB.Table("\"OrderItem\"").
-		Select("\"OrderItem\".\"productId\", \"Product\".name as product_name, SUM(\"OrderItem\".quantity) as total_orders, SUM(\"OrderItem\".quantity * \"OrderItem\".\"unitPrice\") as total_revenue").
-		Joins("JOIN \"Order\" ON \"Order\".id = \"OrderItem\".\"orderId\"").
-		Joins("JOIN \"Product\" ON \"Product\".id = \"OrderItem\".\"productId\"").
-		Where("\"Order\".\"createdAt\" >= ? AND \"Order\".\"createdAt\" <= ? AND \"Order\".status IN ?",
-			from, to, []models.OrderStatus{models.OrderStatusDelivered, models.OrderStatusPartiallyDelivered}).
-		Group("\"OrderItem\".\"productId\", \"Product\".name").
-		Order("total_orders DESC").
-		Limit(3).
-		Scan(&stats)
+	sales, err := repository.Orders(ctrl.DB.WithContext(c.Request.Context())).
+		ProductSales(repository.OrderFilter{Statuses: completedOrderStatuses, From: from, To: to}, 3)
+	if err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
+		return
+	}
+
+	stats := make([]ProductStats, len(sales))
+	for i, sale := range sales {
+		stats[i] = ProductStats{
+			ProductID:    sale.ProductID,
+			ProductName:  sale.ProductName,
+			TotalOrders:  sale.Quantity,
+			TotalRevenue: sale.Revenue,
+		}
+	}
 
 	c.JSON(http.StatusOK, stats)
 }
 
 // GetPeakTimeSlots returns order counts by delivery slot
-func GetPeakTimeSlots(c *gin.Context) {
-	var req struct {
-		From string `json:"from" binding:"required"`
-		To   string `json:"to" binding:"required"`
-	}
+func (ctrl *Controller) GetPeakTimeSlots(c *gin.Context) {
+	var req dto.DateRangeRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "from and to dates are required"})
+		apperror.Abort(c, apperror.Binding(err, "from and to dates are required"))
 		return
 	}
 
-	from, _ := time.Parse("2006-01-02", req.From)
-	to, _ := time.Parse("2006-01-02", req.To)
+	p := apperror.NewParser()
+	from := p.Date("from", req.From)
+	to := p.Date("to", req.To)
+	if err := p.Err("Invalid date range"); err != nil {
+		apperror.Abort(c, err)
+		return
+	}
 	to = to.Add(23*time.Hour + 59*time.Minute)
 
-	type SlotCount struct {
-		DeliverySlot string
-		Count        int64
+	slots, err := repository.Orders(ctrl.DB.WithContext(c.Request.Context())).
+		SlotCounts(repository.OrderFilter{From: from, To: to})
+	if err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
+		return
 	}
-	var slots []SlotCount
-	database.DB.Model(&models.Order{}).
-		Select("\"deliverySlot\", COUNT(*) as count").
-		Where("\"createdAt\" >= ? AND \"createdAt\" <= ? AND \"deliverySlot\" IS NOT NULL", from, to).
-		Group("\"deliverySlot\"").
-		Order("count DESC").
-		Scan(&slots)
 
 	result := []gin.H{}
 	for _, slot := range slots {
-		displayName := formatSlotForDisplay(slot.DeliverySlot)
+		displayName := formatSlotForDisplay(string(slot.DeliverySlot))
 		result = append(result, gin.H{
 			"timeSlot":    slot.DeliverySlot,
 			"displayName": displayName,
@@ -295,9 +304,9 @@ func formatHour(hour int) string {
 }
 
 // GetPendingAdminVerifications returns unverified admins
-func GetPendingAdminVerifications(c *gin.Context) {
+func (ctrl *Controller) GetPendingAdminVerifications(c *gin.Context) {
 	var admins []models.Admin
-	database.DB.Select(`id, email, name, phone, "aadharUrl", "panUrl", "createdAt"`).
+	ctrl.DB.WithContext(c.Request.Context()).Select(`id, email, name, phone, "aadharUrl", "panUrl", "createdAt"`).
 		Where(`"isVerified" = ?`, false).
 		Find(&admins)
 
@@ -306,10 +315,10 @@ func GetPendingAdminVerifications(c *gin.Context) {
 		aadharURL := ""
 		panURL := ""
 		if admin.AadharURL != nil {
-			aadharURL, _ = services.GetSignedURL(*admin.AadharURL)
+			aadharURL, _ = ctrl.Storage.GetSignedURL(*admin.AadharURL)
 		}
 		if admin.PanURL != nil {
-			panURL, _ = services.GetSignedURL(*admin.PanURL)
+			panURL, _ = ctrl.Storage.GetSignedURL(*admin.PanURL)
 		}
 
 		adminsWithSignedURLs = append(adminsWithSignedURLs, gin.H{
@@ -327,64 +336,75 @@ func GetPendingAdminVerifications(c *gin.Context) {
 }
 
 // VerifyAdmin verifies admin and assigns outlets
-func VerifyAdmin(c *gin.Context) {
-	adminIDStr := c.Param("adminId")
-	adminID, _ := strconv.Atoi(adminIDStr)
-
-	var req struct {
-		OutletIDs []int `json:"outletIds" binding:"required"`
+func (ctrl *Controller) VerifyAdmin(c *gin.Context) {
+	adminID, ok := apperror.ParamInt(c, "adminId")
+	if !ok {
+		return
 	}
+
+	var req dto.VerifyAdminRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "At least one outletId is required for verification"})
+		apperror.Abort(c, apperror.Binding(err, "At least one outletId is required for verification"))
 		return
 	}
 
 	var admin models.Admin
-	if err := database.DB.First(&admin, adminID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"message": "Admin not found"})
+	if err := ctrl.DB.WithContext(c.Request.Context()).First(&admin, adminID).Error; err != nil {
+		apperror.Abort(c, apperror.NotFound("Admin not found"))
 		return
 	}
 
 	if admin.IsVerified {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Admin is already verified"})
+		apperror.Abort(c, apperror.BadRequest("Admin is already verified"))
 		return
 	}
 
 	// Validate outlets
 	var validOutlets []models.Outlet
-	database.DB.Where(`id IN ? AND "isActive" = ?`, req.OutletIDs, true).Find(&validOutlets)
+	ctrl.DB.WithContext(c.Request.Context()).Where(`id IN ? AND "isActive" = ?`, req.OutletIDs, true).Find(&validOutlets)
 	if len(validOutlets) != len(req.OutletIDs) {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "One or more outlets are invalid or inactive"})
+		apperror.Abort(c, apperror.BadRequest("One or more outlets are invalid or inactive"))
 		return
 	}
 
 	// Begin verification
-	database.DB.Transaction(func(tx *gorm.DB) error {
-		tx.Model(&admin).Update("isVerified", true)
+	err := ctrl.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&admin).Update("isVerified", true).Error; err != nil {
+			return err
+		}
 
 		// Create AdminOutlet relations
 		for _, outletID := range req.OutletIDs {
 			adminOutlet := models.AdminOutlet{AdminID: adminID, OutletID: outletID}
-			tx.Create(&adminOutlet)
+			if err := tx.Create(&adminOutlet).Error; err != nil {
+				return err
+			}
 
 			// Create default permissions
-			defaultPerms := []string{
-				"ORDER_MANAGEMENT", "STAFF_MANAGEMENT", "INVENTORY_MANAGEMENT",
-				"EXPENDITURE_MANAGEMENT", "WALLET_MANAGEMENT", "CUSTOMER_MANAGEMENT",
-				"TICKET_MANAGEMENT", "NOTIFICATIONS_MANAGEMENT", "PRODUCT_MANAGEMENT",
-				"APP_MANAGEMENT", "REPORTS_ANALYTICS", "SETTINGS", "ONBOARDING", "ADMIN_MANAGEMENT",
-			}
-			for _, permType := range defaultPerms {
+			for _, permType := range models.DefaultAdminPermissions {
 				perm := models.AdminPermission{
 					AdminOutletID: adminOutlet.ID,
-					Type:          models.AdminPermissionType(permType),
+					Type:          permType,
 					IsGranted:     false,
 				}
-				tx.Create(&perm)
+				if err := tx.Create(&perm).Error; err != nil {
+					return err
+				}
 			}
 		}
-		return nil
+
+		return audit.Record(c, tx, audit.Entry{
+			Action:     audit.ActionAdminVerify,
+			EntityType: audit.EntityAdmin,
+			EntityID:   admin.ID,
+			Before:     gin.H{"isVerified": false, "outletIds": []int{}},
+			After:      gin.H{"isVerified": true, "outletIds": req.OutletIDs},
+		})
 	})
+	if err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Admin verified successfully",
@@ -398,9 +418,9 @@ func VerifyAdmin(c *gin.Context) {
 }
 
 // GetVerifiedAdmins returns verified admins
-func GetVerifiedAdmins(c *gin.Context) {
+func (ctrl *Controller) GetVerifiedAdmins(c *gin.Context) {
 	var admins []models.Admin
-	database.DB.Where(`"isVerified" = ?`, true).
+	ctrl.DB.WithContext(c.Request.Context()).Where(`"isVerified" = ?`, true).
 		Preload("Outlets").
 		Find(&admins)
 
@@ -425,23 +445,25 @@ func GetVerifiedAdmins(c *gin.Context) {
 }
 
 // GetAdminDetails returns single admin details
-func GetAdminDetails(c *gin.Context) {
-	adminIDStr := c.Param("adminId")
-	adminID, _ := strconv.Atoi(adminIDStr)
+func (ctrl *Controller) GetAdminDetails(c *gin.Context) {
+	adminID, ok := apperror.ParamInt(c, "adminId")
+	if !ok {
+		return
+	}
 
 	var admin models.Admin
-	if err := database.DB.Preload("Outlets.Outlet").Preload("Outlets.Permissions").First(&admin, adminID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"message": "Admin not found"})
+	if err := ctrl.DB.WithContext(c.Request.Context()).Preload("Outlets.Outlet").Preload("Outlets.Permissions").First(&admin, adminID).Error; err != nil {
+		apperror.Abort(c, apperror.NotFound("Admin not found"))
 		return
 	}
 
 	aadharURL := ""
 	panURL := ""
 	if admin.AadharURL != nil {
-		aadharURL, _ = services.GetSignedURL(*admin.AadharURL)
+		aadharURL, _ = ctrl.Storage.GetSignedURL(*admin.AadharURL)
 	}
 	if admin.PanURL != nil {
-		panURL, _ = services.GetSignedURL(*admin.PanURL)
+		panURL, _ = ctrl.Storage.GetSignedURL(*admin.PanURL)
 	}
 
 	outlets := []gin.H{}
@@ -477,50 +499,49 @@ func GetAdminDetails(c *gin.Context) {
 }
 
 // DeleteAdmin deletes an admin
-func DeleteAdmin(c *gin.Context) {
-	adminIDStr := c.Param("adminId")
-	adminID, _ := strconv.Atoi(adminIDStr)
+func (ctrl *Controller) DeleteAdmin(c *gin.Context) {
+	adminID, ok := apperror.ParamInt(c, "adminId")
+	if !ok {
+		return
+	}
 
 	var admin models.Admin
-	if err := database.DB.First(&admin, adminID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"message": "Admin not found"})
+	if err := ctrl.DB.WithContext(c.Request.Context()).First(&admin, adminID).Error; err != nil {
+		apperror.Abort(c, apperror.NotFound("Admin not found"))
 		return
 	}
 
 	// Prevent self-deletion (optional)
 	user, _ := c.Get("user")
 	if authUser, ok := user.(*models.User); ok && authUser.Role == models.RoleSuperAdmin && authUser.ID == adminID {
-		c.JSON(http.StatusForbidden, gin.H{"message": "Cannot delete your own account"})
+		apperror.Abort(c, apperror.Forbidden("Cannot delete your own account"))
 		return
 	}
 
-	database.DB.Delete(&admin)
+	ctrl.DB.WithContext(c.Request.Context()).Delete(&admin)
 
 	c.JSON(http.StatusOK, gin.H{"message": "Admin deleted successfully"})
 }
 
 // MapOutletsToAdmin maps outlets to an admin
-func MapOutletsToAdmin(c *gin.Context) {
-	var req struct {
-		AdminID   int   `json:"adminId" binding:"required"`
-		OutletIDs []int `json:"outletIds" binding:"required"`
-	}
+func (ctrl *Controller) MapOutletsToAdmin(c *gin.Context) {
+	var req dto.MapOutletsToAdminRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "adminId and a non-empty array of outletIds are required"})
+		apperror.Abort(c, apperror.Binding(err, "adminId and a non-empty array of outletIds are required"))
 		return
 	}
 
 	var admin models.Admin
-	if err := database.DB.Preload("Outlets").First(&admin, req.AdminID).Error; err != nil || !admin.IsVerified {
-		c.JSON(http.StatusNotFound, gin.H{"message": "Admin not found or not verified"})
+	if err := ctrl.DB.WithContext(c.Request.Context()).Preload("Outlets").First(&admin, req.AdminID).Error; err != nil || !admin.IsVerified {
+		apperror.Abort(c, apperror.NotFound("Admin not found or not verified"))
 		return
 	}
 
 	// Validate outlets
 	var validOutlets []models.Outlet
-	database.DB.Where(`id IN ? AND "isActive" = ?`, req.OutletIDs, true).Find(&validOutlets)
+	ctrl.DB.WithContext(c.Request.Context()).Where(`id IN ? AND "isActive" = ?`, req.OutletIDs, true).Find(&validOutlets)
 	if len(validOutlets) != len(req.OutletIDs) {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "One or more outlets are invalid or inactive"})
+		apperror.Abort(c, apperror.BadRequest("One or more outlets are invalid or inactive"))
 		return
 	}
 
@@ -545,11 +566,11 @@ func MapOutletsToAdmin(c *gin.Context) {
 
 	for _, oid := range newoutlets {
 		adminOutlet := models.AdminOutlet{AdminID: req.AdminID, OutletID: oid}
-		database.DB.Create(&adminOutlet)
+		ctrl.DB.WithContext(c.Request.Context()).Create(&adminOutlet)
 	}
 
 	// Reload
-	database.DB.Preload("Outlets.Outlet").First(&admin, req.AdminID)
+	ctrl.DB.WithContext(c.Request.Context()).Preload("Outlets.Outlet").First(&admin, req.AdminID)
 
 	outlets := []gin.H{}
 	for _, ao := range admin.Outlets {
@@ -571,19 +592,16 @@ func MapOutletsToAdmin(c *gin.Context) {
 }
 
 // AssignAdminPermissions assigns permissions to admin for specific outlets
-func AssignAdminPermissions(c *gin.Context) {
-	var req struct {
-		AdminID     int                    `json:"adminId" binding:"required"`
-		Permissions map[int][]gin.H `json:"permissions" binding:"required"`
-	}
+func (ctrl *Controller) AssignAdminPermissions(c *gin.Context) {
+	var req dto.AssignAdminPermissionsRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "adminId and a non-empty permissions object are required"})
+		apperror.Abort(c, apperror.Binding(err, "adminId and a non-empty permissions object are required"))
 		return
 	}
 
 	var admin models.Admin
-	if err := database.DB.Preload("Outlets").First(&admin, req.AdminID).Error; err != nil || !admin.IsVerified {
-		c.JSON(http.StatusNotFound, gin.H{"message": "Admin not found or not verified"})
+	if err := ctrl.DB.WithContext(c.Request.Context()).Preload("Outlets").First(&admin, req.AdminID).Error; err != nil || !admin.IsVerified {
+		apperror.Abort(c, apperror.NotFound("Admin not found or not verified"))
 		return
 	}
 
@@ -595,33 +613,63 @@ func AssignAdminPermissions(c *gin.Context) {
 	// Validate requested outlets
 	for outletID := range req.Permissions {
 		if !adminOutletIDs[outletID] {
-			c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("Outlet %d is not mapped to this admin", outletID)})
+			apperror.Abort(c, apperror.BadRequest(fmt.Sprintf("Outlet %d is not mapped to this admin", outletID)))
 			return
 		}
 	}
 
 	// Update permissions
-	for outletID, perms := range req.Permissions {
-		var adminOutlet models.AdminOutlet
-		database.DB.Where(`"adminId" = ? AND "outletId" = ?`, req.AdminID, outletID).First(&adminOutlet)
-
-		for _, permObj := range perms {
-			permType := models.AdminPermissionType(permObj["type"].(string))
-			isGranted, _ := permObj["isGranted"].(bool)
-
-			var existing models.AdminPermission
-			err := database.DB.Where(`"adminOutletId" = ? AND type = ?`, adminOutlet.ID, permType).First(&existing).Error
-			if err == nil {
-				database.DB.Model(&existing).Update("isGranted", isGranted)
-			} else {
-				perm := models.AdminPermission{
-					AdminOutletID: adminOutlet.ID,
-					Type:          models.AdminPermissionType(permType),
-					IsGranted:     isGranted,
+	err := ctrl.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		for outletID, perms := range req.Permissions {
+			var adminOutlet models.AdminOutlet
+			if err := tx.Where(`"adminId" = ? AND "outletId" = ?`, req.AdminID, outletID).First(&adminOutlet).Error; err != nil {
+				return err
+			}
+
+			for _, permObj := range perms {
+				permType := permObj.Type
+				isGranted := permObj.IsGranted
+
+				var existing models.AdminPermission
+				err := tx.Where(`"adminOutletId" = ? AND type = ?`, adminOutlet.ID, permType).First(&existing).Error
+				before := gin.H{}
+				switch {
+				case err == nil:
+					before[string(permType)] = existing.IsGranted
+					if err := tx.Model(&existing).Update("isGranted", isGranted).Error; err != nil {
+						return err
+					}
+				case errors.Is(err, gorm.ErrRecordNotFound):
+					perm := models.AdminPermission{
+						AdminOutletID: adminOutlet.ID,
+						Type:          models.AdminPermissionType(permType),
+						IsGranted:     isGranted,
+					}
+					if err := tx.Create(&perm).Error; err != nil {
+						return err
+					}
+				default:
+					return err
+				}
+
+				oid := outletID
+				if err := audit.Record(c, tx, audit.Entry{
+					Action:     audit.ActionAdminPermissionsUpdate,
+					EntityType: audit.EntityAdmin,
+					EntityID:   req.AdminID,
+					OutletID:   &oid,
+					Before:     before,
+					After:      gin.H{string(permType): isGranted},
+				}); err != nil {
+					return err
 				}
-				database.DB.Create(&perm)
 			}
 		}
+		return nil
+	})
+	if err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -631,55 +679,80 @@ func AssignAdminPermissions(c *gin.Context) {
 }
 
 // VerifyStaff verifies a staff member
-func VerifyStaff(c *gin.Context) {
-	userIDStr := c.Param("userId")
-	userID, _ := strconv.Atoi(userIDStr)
-
-	var req struct {
-		OutletID  int    `json:"outletId" binding:"required"`
-		StaffRole string `json:"staffRole"`
+func (ctrl *Controller) VerifyStaff(c *gin.Context) {
+	userID, ok := apperror.ParamInt(c, "userId")
+	if !ok {
+		return
 	}
+
+	var req dto.VerifyStaffRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "outletId is required for verification"})
+		apperror.Abort(c, apperror.Binding(err, "outletId is required for verification"))
 		return
 	}
 
 	var user models.User
-	if err := database.DB.Preload("StaffInfo").First(&user, userID).Error; err != nil || user.Role != models.RoleStaff {
-		c.JSON(http.StatusNotFound, gin.H{"message": "Staff not found"})
+	if err := ctrl.DB.WithContext(c.Request.Context()).Preload("StaffInfo").First(&user, userID).Error; err != nil || user.Role != models.RoleStaff {
+		apperror.Abort(c, apperror.NotFound("Staff not found"))
 		return
 	}
 
 	if user.IsVerified {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Staff is already verified"})
+		apperror.Abort(c, apperror.BadRequest("Staff is already verified"))
 		return
 	}
 
-	database.DB.Model(&user).Updates(map[string]interface{}{
-		"isVerified": true,
-		"outletId":   req.OutletID,
-	})
+	var previousOutletID *int
+	if user.OutletID != nil {
+		id := *user.OutletID
+		previousOutletID = &id
+	}
 
-	// Create or update staff details
-	if user.StaffInfo == nil {
-		staffInfo := models.StaffDetails{
-			UserID:    userID,
-			StaffRole: req.StaffRole,
+	err := ctrl.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Updates(map[string]interface{}{
+			"isVerified": true,
+			"outletId":   req.OutletID,
+		}).Error; err != nil {
+			return err
 		}
-		database.DB.Create(&staffInfo)
-
-		// Create default permissions
-		defaultPerms := []string{"BILLING", "PRODUCT_INSIGHTS", "REPORTS", "INVENTORY"}
-		for _, permType := range defaultPerms {
-			perm := models.StaffPermission{
-				StaffID:   staffInfo.ID,
-				Type:      models.PermissionType(permType),
-				IsGranted: false,
+
+		// Create or update staff details
+		if user.StaffInfo == nil {
+			staffInfo := models.StaffDetails{
+				UserID:    userID,
+				StaffRole: req.StaffRole,
+			}
+			if err := tx.Create(&staffInfo).Error; err != nil {
+				return err
 			}
-			database.DB.Create(&perm)
+
+			// Create default permissions
+			for _, permType := range models.DefaultStaffPermissions {
+				perm := models.StaffPermission{
+					StaffID:   staffInfo.ID,
+					Type:      permType,
+					IsGranted: false,
+				}
+				if err := tx.Create(&perm).Error; err != nil {
+					return err
+				}
+			}
+		} else if err := tx.Model(user.StaffInfo).Update("staffRole", req.StaffRole).Error; err != nil {
+			return err
 		}
-	} else {
-		database.DB.Model(user.StaffInfo).Update("staffRole", req.StaffRole)
+
+		return audit.Record(c, tx, audit.Entry{
+			Action:     audit.ActionStaffVerify,
+			EntityType: audit.EntityUser,
+			EntityID:   userID,
+			OutletID:   &req.OutletID,
+			Before:     gin.H{"isVerified": false, "outletId": previousOutletID},
+			After:      gin.H{"isVerified": true, "outletId": req.OutletID, "staffRole": req.StaffRole},
+		})
+	})
+	if err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -688,22 +761,57 @@ func VerifyStaff(c *gin.Context) {
 	})
 }
 
-// GetUnverifiedStaff returns unverified staff
-func GetUnverifiedStaff(c *gin.Context) {
+// StaffAccountsList lists the sorts and filters accepted by GetUnverifiedStaff
+// and GetVerifiedStaff
+var StaffAccountsList = pagination.Spec{
+	DefaultLimit: 50,
+	MaxLimit:     200,
+	DefaultSort:  "-createdAt",
+	Sorts: map[string]pagination.Sort{
+		"createdAt": {Column: `"createdAt"`, Field: "CreatedAt", Type: pagination.TypeTime},
+	},
+	Filters: map[string]pagination.Filter{
+		"outletId": {Column: `"outletId"`, Type: pagination.TypeInt},
+		"name":     {Column: "name", Type: pagination.TypeString, DefaultOp: pagination.OpContains},
+	},
+}
+
+// GetUnverifiedStaff returns a page of unverified staff
+func (ctrl *Controller) GetUnverifiedStaff(c *gin.Context) {
+	list, err := pagination.Parse(c, StaffAccountsList)
+	if err != nil {
+		apperror.Abort(c, err)
+		return
+	}
+
 	var users []models.User
-	database.DB.Where(`role = ? AND "isVerified" = ?`, models.RoleStaff, false).
-		Select(`id, name, email, phone, "createdAt"`).
-		Find(&users)
+	if err := list.Apply(repository.Users(ctrl.DB.WithContext(c.Request.Context())).StaffAccounts(false).
+		Select(`id, name, email, phone, "createdAt"`)).
+		Find(&users).Error; err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
+		return
+	}
+	users, meta := pagination.Trim(list, users)
 
-	c.JSON(http.StatusOK, users)
+	c.JSON(http.StatusOK, gin.H{"staff": users, "pagination": meta})
 }
 
-// GetVerifiedStaff returns verified staff
-func GetVerifiedStaff(c *gin.Context) {
+// GetVerifiedStaff returns a page of verified staff
+func (ctrl *Controller) GetVerifiedStaff(c *gin.Context) {
+	list, err := pagination.Parse(c, StaffAccountsList)
+	if err != nil {
+		apperror.Abort(c, err)
+		return
+	}
+
 	var users []models.User
-	database.DB.Where(`role = ? AND "isVerified" = ?`, models.RoleStaff, true).
-		Select(`id, name, email, phone, "outletId", "createdAt"`).
-		Find(&users)
+	if err := list.Apply(repository.Users(ctrl.DB.WithContext(c.Request.Context())).StaffAccounts(true).
+		Select(`id, name, email, phone, "outletId", "createdAt"`)).
+		Find(&users).Error; err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
+		return
+	}
+	users, meta := pagination.Trim(list, users)
 
-	c.JSON(http.StatusOK, users)
+	c.JSON(http.StatusOK, gin.H{"staff": users, "pagination": meta})
 }
@@ -1,8 +1,10 @@
 package superadmin
 
 import (
-	"backend_pandhi/pkg/database"
+	"backend_pandhi/pkg/apperror"
+	"backend_pandhi/pkg/dto"
 	"backend_pandhi/pkg/models"
+	"backend_pandhi/pkg/pagination"
 	"net/http"
 	"strconv"
 	"time"
@@ -11,19 +13,11 @@ import (
 )
 
 // AddExpense adds a new expense
-func AddExpense(c *gin.Context) {
-	var req struct {
-		OutletID    int     `json:"outletId" binding:"required"`
-		Description string  `json:"description" binding:"required"`
-		Category    string  `json:"category" binding:"required"`
-		Amount      float64 `json:"amount" binding:"required"`
-		Method      string  `json:"method" binding:"required"`
-		PaidTo      string  `json:"paidTo" binding:"required"`
-		ExpenseDate string  `json:"expenseDate" binding:"required"`
-	}
+func (ctrl *Controller) AddExpense(c *gin.Context) {
+	var req dto.AddExpenseRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Please provide all required fields"})
+		apperror.Abort(c, apperror.Binding(err, "Please provide all required fields"))
 		return
 	}
 
@@ -36,18 +30,18 @@ func AddExpense(c *gin.Context) {
 		}
 	}
 	if !valid {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid payment method. Must be one of: UPI, CARD, CASH, WALLET"})
+		apperror.Abort(c, apperror.BadRequest("Invalid payment method. Must be one of: UPI, CARD, CASH, WALLET"))
 		return
 	}
 
 	if req.Amount <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Amount must be a positive number"})
+		apperror.Abort(c, apperror.BadRequest("Amount must be a positive number"))
 		return
 	}
 
 	parsedDate, err := time.Parse("2006-01-02", req.ExpenseDate)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid expenseDate: Must be a valid date"})
+		apperror.Abort(c, apperror.BadRequest("Invalid expenseDate: Must be a valid date"))
 		return
 	}
 
@@ -61,7 +55,7 @@ func AddExpense(c *gin.Context) {
 		ExpenseDate: parsedDate,
 	}
 
-	database.DB.Create(&expense)
+	ctrl.DB.WithContext(c.Request.Context()).Create(&expense)
 
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "Expense created successfully",
@@ -69,22 +63,54 @@ func AddExpense(c *gin.Context) {
 	})
 }
 
+// ExpensesList lists the sorts and filters accepted by GetExpenses and
+// GetExpenseByDate
+var ExpensesList = pagination.Spec{
+	DefaultLimit: 50,
+	MaxLimit:     200,
+	DefaultSort:  "-expenseDate",
+	Sorts: map[string]pagination.Sort{
+		"expenseDate": {Column: `"expenseDate"`, Field: "ExpenseDate", Type: pagination.TypeTime},
+		"createdAt":   {Column: `"createdAt"`, Field: "CreatedAt", Type: pagination.TypeTime},
+		"amount":      {Column: "amount", Field: "Amount", Type: pagination.TypeFloat},
+	},
+	Filters: map[string]pagination.Filter{
+		"category": {Column: "category", Type: pagination.TypeString, Ops: []pagination.Op{pagination.OpEq, pagination.OpContains}},
+		"method": {
+			Column: "method",
+			Type:   pagination.TypeString,
+			Ops:    []pagination.Op{pagination.OpEq, pagination.OpIn},
+			Values: []string{"UPI", "CARD", "CASH", "WALLET"},
+		},
+		"amount": {Column: "amount", Type: pagination.TypeFloat, Ops: pagination.Range},
+	},
+}
+
 // GetExpenses returns expenses for last 2 weeks
-func GetExpenses(c *gin.Context) {
+func (ctrl *Controller) GetExpenses(c *gin.Context) {
 	outletIDStr := c.Param("outletId")
 	outletID, err := strconv.Atoi(outletIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid outlet ID"})
+		apperror.Abort(c, apperror.BadRequest("Invalid outlet ID"))
 		return
 	}
 
-	twoWeeksAgo := time.Now().AddDate(0, 0, -14)
+	list, err := pagination.Parse(c, ExpensesList)
+	if err != nil {
+		apperror.Abort(c, err)
+		return
+	}
+
+	twoWeeksAgo := ctrl.Clock.Now().AddDate(0, 0, -14)
 
 	var expenses []models.Expense
-	database.DB.Where(`"outletId" = ? AND "expenseDate" >= ? AND "expenseDate" <= ?`,
-		outletID, twoWeeksAgo, time.Now()).
-		Order(`"expenseDate" DESC`).
-		Find(&expenses)
+	if err := list.Apply(ctrl.DB.WithContext(c.Request.Context()).Where(`"outletId" = ? AND "expenseDate" >= ? AND "expenseDate" <= ?`,
+		outletID, twoWeeksAgo, ctrl.Clock.Now())).
+		Find(&expenses).Error; err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
+		return
+	}
+	expenses, meta := pagination.Trim(list, expenses)
 
 	message := "Expenses retrieved successfully"
 	if len(expenses) == 0 {
@@ -92,36 +118,48 @@ func GetExpenses(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message":  message,
-		"expenses": expenses,
+		"message":    message,
+		"expenses":   expenses,
+		"pagination": meta,
 	})
 }
 
 // GetExpenseByDate returns expenses within date range
-func GetExpenseByDate(c *gin.Context) {
-	var req struct {
-		OutletID int    `json:"outletId" binding:"required"`
-		From     string `json:"from" binding:"required"`
-		To       string `json:"to" binding:"required"`
-	}
+func (ctrl *Controller) GetExpenseByDate(c *gin.Context) {
+	var req dto.ExpenseByDateRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Provide all the details"})
+		apperror.Abort(c, apperror.Binding(err, "Provide all the details"))
 		return
 	}
 
-	from, _ := time.Parse("2006-01-02", req.From)
-	to, _ := time.Parse("2006-01-02", req.To)
+	p := apperror.NewParser()
+	from := p.Date("from", req.From)
+	to := p.Date("to", req.To)
+	if err := p.Err("Invalid date range"); err != nil {
+		apperror.Abort(c, err)
+		return
+	}
+
+	list, err := pagination.Parse(c, ExpensesList)
+	if err != nil {
+		apperror.Abort(c, err)
+		return
+	}
 
 	var expenses []models.Expense
-	database.DB.Where(`"outletId" = ? AND "expenseDate" >= ? AND "expenseDate" <= ?`,
-		req.OutletID, from, to).
-		Order(`"expenseDate" DESC`).
-		Find(&expenses)
+	if err := list.Apply(ctrl.DB.WithContext(c.Request.Context()).Where(`"outletId" = ? AND "expenseDate" >= ? AND "expenseDate" <= ?`,
+		req.OutletID, from, to)).
+		Find(&expenses).Error; err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
+		return
+	}
+	expenses, meta := pagination.Trim(list, expenses)
 
 	c.JSON(http.StatusOK, gin.H{
-		"message":  "Expenses fetched successfully",
-		"count":    len(expenses),
-		"expenses": expenses,
+		"message":    "Expenses fetched successfully",
+		"count":      len(expenses),
+		"expenses":   expenses,
+		"pagination": meta,
 	})
 }
@@ -0,0 +1,212 @@
+package superadmin
+
+import (
+	"backend_pandhi/pkg/apperror"
+	"backend_pandhi/pkg/audit"
+	"backend_pandhi/pkg/dto"
+	"backend_pandhi/pkg/models"
+	"backend_pandhi/pkg/moderation"
+	"backend_pandhi/pkg/pagination"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// OutletFeedbackList lists the sorts and filters accepted by GetOutletFeedback
+var OutletFeedbackList = pagination.Spec{
+	DefaultLimit: 50,
+	MaxLimit:     200,
+	DefaultSort:  "-createdAt",
+	Sorts: map[string]pagination.Sort{
+		"createdAt":     {Column: `"Feedback"."createdAt"`, Field: "CreatedAt", Type: pagination.TypeTime},
+		"ratingOverall": {Column: `"Feedback"."ratingOverall"`, Field: "RatingOverall", Type: pagination.TypeFloat},
+	},
+	Filters: map[string]pagination.Filter{
+		"status": {
+			Column: `"Feedback".status`,
+			Type:   pagination.TypeString,
+			Ops:    []pagination.Op{pagination.OpEq, pagination.OpIn},
+			Values: []string{string(models.FeedbackStatusPending), string(models.FeedbackStatusApproved), string(models.FeedbackStatusHidden)},
+		},
+		"productId":     {Column: `"Feedback"."productId"`, Type: pagination.TypeInt},
+		"ratingOverall": {Column: `"Feedback"."ratingOverall"`, Type: pagination.TypeFloat, Ops: pagination.Range},
+		"createdAt":     {Column: `"Feedback"."createdAt"`, Type: pagination.TypeTime, Ops: pagination.Range},
+	},
+	IDColumn: `"Feedback".id`,
+}
+
+// GetOutletFeedback returns a page of the feedback left on an outlet's
+// products, in every moderation status
+func (ctrl *Controller) GetOutletFeedback(c *gin.Context) {
+	outletID, err := strconv.Atoi(c.Param("outletId"))
+	if err != nil {
+		apperror.Abort(c, apperror.BadRequest("Provide valid OutletId"))
+		return
+	}
+
+	list, err := pagination.Parse(c, OutletFeedbackList)
+	if err != nil {
+		apperror.Abort(c, err)
+		return
+	}
+
+	var feedback []models.Feedback
+	if err := list.Apply(ctrl.DB.WithContext(c.Request.Context()).
+		Joins(`JOIN "Product" ON "Product".id = "Feedback"."productId"`).
+		Where(`"Product"."outletId" = ?`, outletID)).
+		Preload("Product").
+		Preload("User").
+		Find(&feedback).Error; err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
+		return
+	}
+	feedback, meta := pagination.Trim(list, feedback)
+
+	allFeedback := make([]gin.H, len(feedback))
+	for i, f := range feedback {
+		allFeedback[i] = gin.H{
+			"id":               f.ID,
+			"orderId":          f.OrderID,
+			"productId":        f.ProductID,
+			"productName":      f.Product.Name,
+			"customerName":     f.User.Name,
+			"ratingOverall":    f.RatingOverall,
+			"ratingTaste":      f.RatingTaste,
+			"ratingQuality":    f.RatingQuality,
+			"ratingQuantity":   f.RatingQuantity,
+			"comment":          f.Comment,
+			"createdAt":        f.CreatedAt,
+			"status":           f.Status,
+			"moderationReason": f.ModerationReason,
+			"moderatedAt":      f.ModeratedAt,
+			"reply":            f.Reply,
+			"repliedAt":        f.RepliedAt,
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"feedback":   allFeedback,
+		"pagination": meta,
+	})
+}
+
+// HideFeedback takes feedback out of public reviews and product ratings
+func (ctrl *Controller) HideFeedback(c *gin.Context) {
+	ctrl.moderateFeedback(c, audit.ActionFeedbackHide, moderation.Hide, "Feedback hidden")
+}
+
+// RestoreFeedback publishes hidden or held feedback again
+func (ctrl *Controller) RestoreFeedback(c *gin.Context) {
+	ctrl.moderateFeedback(c, audit.ActionFeedbackRestore, moderation.Restore, "Feedback restored")
+}
+
+func (ctrl *Controller) moderateFeedback(
+	c *gin.Context,
+	action string,
+	change func(tx *gorm.DB, id int, reason string, now time.Time) (models.Feedback, error),
+	message string,
+) {
+	feedbackID, err := strconv.Atoi(c.Param("feedbackId"))
+	if err != nil {
+		apperror.Abort(c, apperror.BadRequest("Valid feedbackId is required"))
+		return
+	}
+
+	var req dto.ModerateFeedbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.Abort(c, apperror.Binding(err, "Provide a reason"))
+		return
+	}
+	if strings.TrimSpace(req.Reason) == "" {
+		apperror.Abort(c, apperror.BadRequest("Provide a reason"))
+		return
+	}
+
+	var feedback models.Feedback
+	err = ctrl.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		before, err := change(tx, feedbackID, strings.TrimSpace(req.Reason), ctrl.Clock.Now())
+		if err != nil {
+			return err
+		}
+		if err := tx.First(&feedback, feedbackID).Error; err != nil {
+			return err
+		}
+		return audit.Record(c, tx, audit.Entry{
+			Action:     action,
+			EntityType: audit.EntityFeedback,
+			EntityID:   feedbackID,
+			OutletID:   &before.Product.OutletID,
+			Before:     gin.H{"status": before.Status, "moderationReason": before.ModerationReason},
+			After:      gin.H{"status": feedback.Status, "moderationReason": feedback.ModerationReason},
+		})
+	})
+	switch {
+	case errors.Is(err, moderation.ErrNotFound):
+		apperror.Abort(c, apperror.NotFound("Feedback not found"))
+		return
+	case errors.Is(err, moderation.ErrUnchanged):
+		apperror.Abort(c, apperror.Conflict("Feedback already has this status"))
+		return
+	case err != nil:
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": message, "feedback": feedback})
+}
+
+// ReplyToFeedback sets the outlet's public reply to feedback
+func (ctrl *Controller) ReplyToFeedback(c *gin.Context) {
+	feedbackID, err := strconv.Atoi(c.Param("feedbackId"))
+	if err != nil {
+		apperror.Abort(c, apperror.BadRequest("Valid feedbackId is required"))
+		return
+	}
+
+	var req dto.FeedbackReplyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.Abort(c, apperror.Binding(err, "Provide a reply"))
+		return
+	}
+	if strings.TrimSpace(req.Reply) == "" {
+		apperror.Abort(c, apperror.BadRequest("Provide a reply"))
+		return
+	}
+
+	var feedback models.Feedback
+	err = ctrl.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		before, err := moderation.Find(tx, feedbackID)
+		if err != nil {
+			return err
+		}
+		if err := moderation.Reply(tx, before, strings.TrimSpace(req.Reply), ctrl.Clock.Now()); err != nil {
+			return err
+		}
+		if err := tx.First(&feedback, feedbackID).Error; err != nil {
+			return err
+		}
+		return audit.Record(c, tx, audit.Entry{
+			Action:     audit.ActionFeedbackReply,
+			EntityType: audit.EntityFeedback,
+			EntityID:   feedbackID,
+			OutletID:   &before.Product.OutletID,
+			Before:     gin.H{"reply": before.Reply},
+			After:      gin.H{"reply": feedback.Reply},
+		})
+	})
+	switch {
+	case errors.Is(err, moderation.ErrNotFound):
+		apperror.Abort(c, apperror.NotFound("Feedback not found"))
+		return
+	case err != nil:
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Reply posted", "feedback": feedback})
+}
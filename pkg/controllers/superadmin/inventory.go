@@ -1,26 +1,35 @@
 package superadmin
 
 import (
-	"backend_pandhi/pkg/database"
+	"backend_pandhi/pkg/apperror"
+	"backend_pandhi/pkg/audit"
+	"backend_pandhi/pkg/dto"
+	"backend_pandhi/pkg/metrics"
 	"backend_pandhi/pkg/models"
+	"backend_pandhi/pkg/pagination"
+	"backend_pandhi/pkg/repository"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // GetStocks returns inventory for an outlet
-func GetStocks(c *gin.Context) {
+func (ctrl *Controller) GetStocks(c *gin.Context) {
 	outletIDStr := c.Param("outletId")
 	outletID, err := strconv.Atoi(outletIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Provide outletId"})
+		apperror.Abort(c, apperror.BadRequest("Provide outletId"))
 		return
 	}
 
-	var products []models.Product
-	database.DB.Where(`"outletId" = ?`, outletID).Preload("Inventory").Find(&products)
+	products, err := repository.Products(ctrl.DB.WithContext(c.Request.Context()).Preload("Inventory")).ForOutlet(outletID)
+	if err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
+		return
+	}
 
 	if len(products) == 0 {
 		c.JSON(http.StatusOK, gin.H{"message": "No products found for this outlet."})
@@ -50,38 +59,50 @@ func GetStocks(c *gin.Context) {
 }
 
 // AddStock adds inventory quantity
-func AddStock(c *gin.Context) {
-	var req struct {
-		ProductID     int `json:"productId" binding:"required"`
-		OutletID      int `json:"outletId" binding:"required"`
-		AddedQuantity int `json:"addedQuantity" binding:"required"`
-	}
+func (ctrl *Controller) AddStock(c *gin.Context) {
+	var req dto.AddStockRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Required fields are missing"})
+		apperror.Abort(c, apperror.Binding(err, "Required fields are missing"))
 		return
 	}
 
 	// Find inventory
-	var inventory models.Inventory
-	if err := database.DB.Where(`"productId" = ?`, req.ProductID).First(&inventory).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"message": "Product inventory not found"})
+	inventory, err := repository.Inventory(ctrl.DB.WithContext(c.Request.Context())).ForProduct(req.ProductID)
+	if err != nil {
+		apperror.Abort(c, apperror.NotFound("Product inventory not found"))
 		return
 	}
 
-	// Update inventory
-	database.DB.Model(&inventory).Update("quantity", inventory.Quantity+req.AddedQuantity)
+	// Update inventory with its history and audit entry
+	previous := inventory.Quantity
+	err = ctrl.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		if err := repository.Inventory(tx).SetQuantity(&inventory, previous+req.AddedQuantity); err != nil {
+			return err
+		}
 
-	// Create history
-	database.DB.Create(&models.StockHistory{
-		ProductID: req.ProductID,
-		OutletID:  req.OutletID,
-		Quantity:  req.AddedQuantity,
-		Action:    models.StockActionAdd,
-	})
+		if err := tx.Create(&models.StockHistory{
+			ProductID: req.ProductID,
+			OutletID:  req.OutletID,
+			Quantity:  req.AddedQuantity,
+			Action:    models.StockActionAdd,
+		}).Error; err != nil {
+			return err
+		}
 
-	// Reload
-	database.DB.First(&inventory, inventory.ID)
+		return audit.Record(c, tx, audit.Entry{
+			Action:     audit.ActionStockAdd,
+			EntityType: audit.EntityInventory,
+			EntityID:   inventory.ID,
+			OutletID:   &inventory.OutletID,
+			Before:     gin.H{"productId": inventory.ProductID, "quantity": previous},
+			After:      gin.H{"productId": inventory.ProductID, "quantity": inventory.Quantity},
+		})
+	})
+	if err != nil {
+		apperror.Abort(c, apperror.Internal("Failed to update stock", err))
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"message":          "Stock updated successfully",
@@ -90,73 +111,127 @@ func AddStock(c *gin.Context) {
 }
 
 // DeductStock removes inventory quantity
-func DeductStock(c *gin.Context) {
-	var req struct {
-		ProductID int `json:"productId" binding:"required"`
-		OutletID  int `json:"outletId" binding:"required"`
-		Quantity  int `json:"quantity" binding:"required"`
-	}
+func (ctrl *Controller) DeductStock(c *gin.Context) {
+	var req dto.DeductStockRequest
 
-	if err := c.ShouldBindJSON(&req); err != nil || req.Quantity <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Provide valid productId, outletId, and quantity."})
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.Abort(c, apperror.Binding(err, "Provide valid productId, outletId, and quantity."))
+		return
+	}
+	if req.Quantity <= 0 {
+		apperror.Abort(c, apperror.Validation("Provide valid productId, outletId, and quantity.", apperror.FieldError{
+			Field:   "quantity",
+			Message: "must be greater than 0",
+		}))
 		return
 	}
 
 	// Find inventory
-	var inventory models.Inventory
-	if err := database.DB.Where(`"productId" = ?`, req.ProductID).First(&inventory).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"message": "Inventory record not found."})
+	inventory, err := repository.Inventory(ctrl.DB.WithContext(c.Request.Context())).ForProduct(req.ProductID)
+	if err != nil {
+		apperror.Abort(c, apperror.NotFound("Inventory record not found."))
 		return
 	}
 
 	if inventory.Quantity < req.Quantity {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Insufficient stock available"})
+		apperror.Abort(c, apperror.BadRequest("Insufficient stock available"))
 		return
 	}
 
-	// Update inventory
-	database.DB.Model(&inventory).Update("quantity", inventory.Quantity-req.Quantity)
+	// Update inventory with its history and audit entry
+	previous := inventory.Quantity
+	err = ctrl.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		if err := repository.Inventory(tx).SetQuantity(&inventory, previous-req.Quantity); err != nil {
+			return err
+		}
 
-	// Create history
-	database.DB.Create(&models.StockHistory{
-		ProductID: req.ProductID,
-		OutletID:  req.OutletID,
-		Quantity:  req.Quantity,
-		Action:    models.StockActionRemove,
+		if err := tx.Create(&models.StockHistory{
+			ProductID: req.ProductID,
+			OutletID:  req.OutletID,
+			Quantity:  req.Quantity,
+			Action:    models.StockActionRemove,
+		}).Error; err != nil {
+			return err
+		}
+
+		return audit.Record(c, tx, audit.Entry{
+			Action:     audit.ActionStockDeduct,
+			EntityType: audit.EntityInventory,
+			EntityID:   inventory.ID,
+			OutletID:   &inventory.OutletID,
+			Before:     gin.H{"productId": inventory.ProductID, "quantity": previous},
+			After:      gin.H{"productId": inventory.ProductID, "quantity": inventory.Quantity},
+		})
 	})
+	if err != nil {
+		apperror.Abort(c, apperror.Internal("Failed to update stock", err))
+		return
+	}
+	if inventory.Quantity == 0 {
+		metrics.StockOut(inventory.OutletID)
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"message":         "Stock deducted successfully",
-		"currentQuantity": inventory.Quantity - req.Quantity,
+		"currentQuantity": inventory.Quantity,
 	})
 }
 
-// StockHistory returns stock movement history
-func StockHistory(c *gin.Context) {
-	var req struct {
-		OutletID  int    `json:"outletId" binding:"required"`
-		StartDate string `json:"startDate" binding:"required"`
-		EndDate   string `json:"endDate" binding:"required"`
-	}
+// StockHistoryList lists the sorts and filters accepted by StockHistory
+var StockHistoryList = pagination.Spec{
+	DefaultLimit: 100,
+	MaxLimit:     500,
+	DefaultSort:  "-timestamp",
+	Sorts: map[string]pagination.Sort{
+		"timestamp": {Column: "timestamp", Field: "Timestamp", Type: pagination.TypeTime},
+	},
+	Filters: map[string]pagination.Filter{
+		"productId": {Column: `"productId"`, Type: pagination.TypeInt, Ops: []pagination.Op{pagination.OpEq, pagination.OpIn}},
+		"action": {
+			Column: "action",
+			Type:   pagination.TypeString,
+			Values: []string{string(models.StockActionAdd), string(models.StockActionRemove)},
+		},
+	},
+}
+
+// StockHistory returns a page of stock movements for a date range
+func (ctrl *Controller) StockHistory(c *gin.Context) {
+	var req dto.StockHistoryRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "outletId, startDate, and endDate are required."})
+		apperror.Abort(c, apperror.Binding(err, "outletId, startDate, and endDate are required."))
 		return
 	}
 
-	from, _ := time.Parse("2006-01-02", req.StartDate)
-	to, _ := time.Parse("2006-01-02", req.EndDate)
+	p := apperror.NewParser()
+	from := p.Date("startDate", req.StartDate)
+	to := p.Date("endDate", req.EndDate)
+	if err := p.Err("Invalid date range"); err != nil {
+		apperror.Abort(c, err)
+		return
+	}
 	to = to.Add(23*time.Hour + 59*time.Minute + 59*time.Second)
 
+	list, err := pagination.Parse(c, StockHistoryList)
+	if err != nil {
+		apperror.Abort(c, err)
+		return
+	}
+
 	var history []models.StockHistory
-	database.DB.Where(`"outletId" = ? AND action IN ? AND timestamp >= ? AND timestamp <= ?`,
-		req.OutletID, []models.StockAction{models.StockActionAdd, models.StockActionRemove}, from, to).
+	if err := list.Apply(ctrl.DB.WithContext(c.Request.Context()).Where(`"outletId" = ? AND action IN ? AND timestamp >= ? AND timestamp <= ?`,
+		req.OutletID, []models.StockAction{models.StockActionAdd, models.StockActionRemove}, from, to)).
 		Preload("Product").
-		Order("timestamp DESC").
-		Find(&history)
+		Find(&history).Error; err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
+		return
+	}
+	history, meta := pagination.Trim(list, history)
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Stock history fetched",
-		"history": history,
+		"message":    "Stock history fetched",
+		"history":    history,
+		"pagination": meta,
 	})
 }
@@ -0,0 +1,93 @@
+package superadmin
+
+import (
+	"backend_pandhi/pkg/apperror"
+	"backend_pandhi/pkg/audit"
+	"backend_pandhi/pkg/jobs"
+	"backend_pandhi/pkg/models"
+	"backend_pandhi/pkg/pagination"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// FailedJobsList lists the sorts and filters accepted by GetFailedJobs
+var FailedJobsList = pagination.Spec{
+	DefaultLimit: 50,
+	MaxLimit:     200,
+	DefaultSort:  "-finishedAt",
+	Sorts: map[string]pagination.Sort{
+		"finishedAt": {Column: `"finishedAt"`, Field: "FinishedAt", Type: pagination.TypeTime},
+		"createdAt":  {Column: `"createdAt"`, Field: "CreatedAt", Type: pagination.TypeTime},
+	},
+	Filters: map[string]pagination.Filter{
+		"kind":       {Column: "kind", Type: pagination.TypeString, Ops: []pagination.Op{pagination.OpEq, pagination.OpIn}},
+		"finishedAt": {Column: `"finishedAt"`, Type: pagination.TypeTime, Ops: pagination.Range},
+	},
+}
+
+// GetFailedJobs lists background jobs that used up their attempts
+func (ctrl *Controller) GetFailedJobs(c *gin.Context) {
+	list, err := pagination.Parse(c, FailedJobsList)
+	if err != nil {
+		apperror.Abort(c, err)
+		return
+	}
+
+	query := ctrl.DB.WithContext(c.Request.Context()).Model(&models.Job{}).Where("status = ?", models.JobStatusFailed)
+
+	var failed []models.Job
+	if err := list.Apply(query).Find(&failed).Error; err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
+		return
+	}
+	failed, meta := pagination.Trim(list, failed)
+
+	c.JSON(http.StatusOK, gin.H{"jobs": failed, "pagination": meta})
+}
+
+// RetryJob queues a failed job again with a fresh set of attempts
+func (ctrl *Controller) RetryJob(c *gin.Context) {
+	jobID, err := strconv.Atoi(c.Param("jobId"))
+	if err != nil {
+		apperror.Abort(c, apperror.BadRequest("Valid jobId is required"))
+		return
+	}
+
+	var job models.Job
+	err = ctrl.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		before, err := jobs.Retry(tx, jobID, ctrl.Clock.Now())
+		if err != nil {
+			return err
+		}
+		if err := tx.First(&job, jobID).Error; err != nil {
+			return err
+		}
+		return audit.Record(c, tx, audit.Entry{
+			Action:     audit.ActionJobRetry,
+			EntityType: audit.EntityJob,
+			EntityID:   jobID,
+			Before:     before,
+			After:      job,
+		})
+	})
+	switch {
+	case errors.Is(err, jobs.ErrNotFound):
+		apperror.Abort(c, apperror.NotFound("Job not found"))
+		return
+	case errors.Is(err, jobs.ErrNotFailed):
+		apperror.Abort(c, apperror.Conflict("Only failed jobs can be retried"))
+		return
+	case errors.Is(err, jobs.ErrDuplicate):
+		apperror.Abort(c, apperror.Conflict("An identical job is already queued"))
+		return
+	case err != nil:
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Job queued for retry", "job": job})
+}
@@ -1,9 +1,10 @@
 package superadmin
 
 import (
-	"backend_pandhi/pkg/database"
+	"backend_pandhi/pkg/apperror"
+	"backend_pandhi/pkg/dto"
 	"backend_pandhi/pkg/models"
-	"backend_pandhi/pkg/services"
+	"backend_pandhi/pkg/pagination"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -13,31 +14,23 @@ import (
 )
 
 // CreateScheduledNotification creates a scheduled notification
-func CreateScheduledNotification(c *gin.Context) {
-	var req struct {
-		Title         string  `json:"title" binding:"required"`
-		Message       string  `json:"message" binding:"required"`
-		Priority      *string `json:"priority"`
-		ImageURL      *string `json:"imageUrl"`
-		ScheduledDate string  `json:"scheduledDate" binding:"required"`
-		ScheduledTime string  `json:"scheduledTime" binding:"required"`
-		OutletID      int     `json:"outletId" binding:"required"`
-	}
+func (ctrl *Controller) CreateScheduledNotification(c *gin.Context) {
+	var req dto.ScheduleNotificationRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Title, message, scheduled date, scheduled time, and outlet ID are required"})
+		apperror.Abort(c, apperror.Binding(err, "Title, message, scheduled date, scheduled time, and outlet ID are required"))
 		return
 	}
 
 	scheduledAtStr := req.ScheduledDate + "T" + req.ScheduledTime + "+05:30"
 	scheduledAt, err := time.Parse("2006-01-02T15:04:05Z07:00", scheduledAtStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid date/time format"})
+		apperror.Abort(c, apperror.BadRequest("Invalid date/time format"))
 		return
 	}
 
-	if scheduledAt.Before(time.Now()) {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Scheduled time must be in the future"})
+	if scheduledAt.Before(ctrl.Clock.Now()) {
+		apperror.Abort(c, apperror.BadRequest("Scheduled time must be in the future"))
 		return
 	}
 
@@ -55,7 +48,7 @@ func CreateScheduledNotification(c *gin.Context) {
 		IsSent:      false,
 	}
 
-	database.DB.Create(&notification)
+	ctrl.DB.WithContext(c.Request.Context()).Create(&notification)
 
 	c.JSON(http.StatusCreated, gin.H{
 		"success": true,
@@ -64,26 +57,58 @@ func CreateScheduledNotification(c *gin.Context) {
 	})
 }
 
+// ScheduledNotificationsList lists the sorts and filters accepted by
+// GetScheduledNotifications
+var ScheduledNotificationsList = pagination.Spec{
+	DefaultLimit: 50,
+	MaxLimit:     200,
+	DefaultSort:  "-scheduledAt",
+	Sorts: map[string]pagination.Sort{
+		"scheduledAt": {Column: `"scheduledAt"`, Field: "ScheduledAt", Type: pagination.TypeTime},
+		"createdAt":   {Column: `"createdAt"`, Field: "CreatedAt", Type: pagination.TypeTime},
+	},
+	Filters: map[string]pagination.Filter{
+		"isSent":      {Column: `"isSent"`, Type: pagination.TypeBool},
+		"scheduledAt": {Column: `"scheduledAt"`, Type: pagination.TypeTime, Ops: pagination.Range},
+	},
+}
+
 // GetScheduledNotifications returns scheduled notifications for an outlet
-func GetScheduledNotifications(c *gin.Context) {
-	outletIDStr := c.Param("outletId")
-	outletID, _ := strconv.Atoi(outletIDStr)
+func (ctrl *Controller) GetScheduledNotifications(c *gin.Context) {
+	outletID, ok := apperror.ParamInt(c, "outletId")
+	if !ok {
+		return
+	}
+
+	list, err := pagination.Parse(c, ScheduledNotificationsList)
+	if err != nil {
+		apperror.Abort(c, err)
+		return
+	}
 
 	var notifications []models.ScheduledNotification
-	database.DB.Where(`"outletId" = ?`, outletID).Find(&notifications)
+	if err := list.Apply(ctrl.DB.WithContext(c.Request.Context()).Where(`"outletId" = ?`, outletID)).
+		Find(&notifications).Error; err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
+		return
+	}
+	notifications, meta := pagination.Trim(list, notifications)
 
 	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"data":    notifications,
+		"success":    true,
+		"data":       notifications,
+		"pagination": meta,
 	})
 }
 
 // CancelScheduledNotification cancels a scheduled notification
-func CancelScheduledNotification(c *gin.Context) {
-	notificationIDStr := c.Param("notificationId")
-	notificationID, _ := strconv.Atoi(notificationIDStr)
+func (ctrl *Controller) CancelScheduledNotification(c *gin.Context) {
+	notificationID, ok := apperror.ParamInt(c, "notificationId")
+	if !ok {
+		return
+	}
 
-	database.DB.Delete(&models.ScheduledNotification{}, notificationID)
+	ctrl.DB.WithContext(c.Request.Context()).Delete(&models.ScheduledNotification{}, notificationID)
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
@@ -92,26 +117,22 @@ func CancelScheduledNotification(c *gin.Context) {
 }
 
 // SendImmediateNotification sends notification to all outlet customers
-func SendImmediateNotification(c *gin.Context) {
-	var req struct {
-		Title    string `json:"title" binding:"required"`
-		Message  string `json:"message" binding:"required"`
-		OutletID int    `json:"outletId" binding:"required"`
-	}
+func (ctrl *Controller) SendImmediateNotification(c *gin.Context) {
+	var req dto.SendNotificationRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Title, message, and outlet ID are required"})
+		apperror.Abort(c, apperror.Binding(err, "Title, message, and outlet ID are required"))
 		return
 	}
 
 	// Get device tokens for customers
 	var deviceTokens []models.UserDeviceToken
-	database.DB.Joins(`JOIN "User" ON "User".id = "UserDeviceToken"."userId"`).Where(`"User"."outletId" = ? AND "User".role = ? AND "UserDeviceToken"."isActive" = ?`,
+	ctrl.DB.WithContext(c.Request.Context()).Joins(`JOIN "User" ON "User".id = "UserDeviceToken"."userId"`).Where(`"User"."outletId" = ? AND "User".role = ? AND "UserDeviceToken"."isActive" = ?`,
 			req.OutletID, models.RoleCustomer, true).
 		Find(&deviceTokens)
 
 	if len(deviceTokens) == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "No device tokens found for this outlet"})
+		apperror.Abort(c, apperror.NotFound("No device tokens found for this outlet"))
 		return
 	}
 
@@ -125,9 +146,9 @@ func SendImmediateNotification(c *gin.Context) {
 		"outletId": strconv.Itoa(req.OutletID),
 		"type":     "immediate",
 	}
-	results, err := services.SendBulkPushNotifications(tokens, req.Title, req.Message, data)
+	results, err := ctrl.Push.SendBulk(c.Request.Context(), tokens, req.Title, req.Message, data)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Failed to send notification", "error": err.Error()})
+		apperror.Abort(c, apperror.Internal("Failed to send notification", err))
 		return
 	}
 
@@ -142,15 +163,17 @@ func SendImmediateNotification(c *gin.Context) {
 }
 
 // GetNotificationStats returns notification statistics
-func GetNotificationStats(c *gin.Context) {
-	outletIDStr := c.Param("outletId")
-	outletID, _ := strconv.Atoi(outletIDStr)
+func (ctrl *Controller) GetNotificationStats(c *gin.Context) {
+	outletID, ok := apperror.ParamInt(c, "outletId")
+	if !ok {
+		return
+	}
 
 	var total int64
-	database.DB.Model(&models.ScheduledNotification{}).Where(`"outletId" = ?`, outletID).Count(&total)
+	ctrl.DB.WithContext(c.Request.Context()).Model(&models.ScheduledNotification{}).Where(`"outletId" = ?`, outletID).Count(&total)
 
 	var sent int64
-	database.DB.Model(&models.ScheduledNotification{}).Where(`"outletId" = ? AND "isSent" = ?`, outletID, true).Count(&sent)
+	ctrl.DB.WithContext(c.Request.Context()).Model(&models.ScheduledNotification{}).Where(`"outletId" = ? AND "isSent" = ?`, outletID, true).Count(&sent)
 
 	pending := total - sent
 
@@ -165,8 +188,15 @@ func GetNotificationStats(c *gin.Context) {
 }
 
 // TestFCMService tests FCM service status
-func TestFCMService(c *gin.Context) {
-	status := services.GetServiceStatus()
+func (ctrl *Controller) TestFCMService(c *gin.Context) {
+	status := gin.H{
+		"initialized": ctrl.Push.Ready(),
+		"service":     "Firebase Cloud Messaging",
+		"status":      "not initialized",
+	}
+	if ctrl.Push.Ready() {
+		status["status"] = "connected"
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
@@ -176,26 +206,22 @@ func TestFCMService(c *gin.Context) {
 }
 
 // TestSingleDeviceNotification tests notification to single device
-func TestSingleDeviceNotification(c *gin.Context) {
-	var req struct {
-		DeviceToken string `json:"deviceToken" binding:"required"`
-		Title       string `json:"title" binding:"required"`
-		Message     string `json:"message" binding:"required"`
-	}
+func (ctrl *Controller) TestSingleDeviceNotification(c *gin.Context) {
+	var req dto.TestNotificationRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Device token, title, and message are required"})
+		apperror.Abort(c, apperror.Binding(err, "Device token, title, and message are required"))
 		return
 	}
 
 	data := map[string]string{
 		"type":      "test",
-		"timestamp": time.Now().Format(time.RFC3339),
+		"timestamp": ctrl.Clock.Now().Format(time.RFC3339),
 	}
 
-	result, err := services.SendPushNotification(req.DeviceToken, req.Title, req.Message, data)
+	result, err := ctrl.Push.Send(c.Request.Context(), req.DeviceToken, req.Title, req.Message, data)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Failed to send test notification", "error": err.Error()})
+		apperror.Abort(c, apperror.Internal("Failed to send test notification", err))
 		return
 	}
 
@@ -207,9 +233,9 @@ func TestSingleDeviceNotification(c *gin.Context) {
 }
 
 // GetLowStockNotifications returns low stock items (from dashboard controller in Express)
-func GetLowStockNotifications(c *gin.Context) {
+func (ctrl *Controller) GetLowStockNotifications(c *gin.Context) {
 	var inventory []models.Inventory
-	database.DB.Preload("Product").
+	ctrl.DB.WithContext(c.Request.Context()).Preload("Product").
 		Where("quantity < threshold").
 		Find(&inventory)
 
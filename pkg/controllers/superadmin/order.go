@@ -1,91 +1,99 @@
 package superadmin
 
 import (
-	"backend_pandhi/pkg/database"
+	"backend_pandhi/pkg/apperror"
+	"backend_pandhi/pkg/dto"
 	"backend_pandhi/pkg/models"
-	"log"
+	"backend_pandhi/pkg/pagination"
+	"backend_pandhi/pkg/repository"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
 
-// OutletTotalOrders returns all orders for an outlet with customer and item details
-func OutletTotalOrders(c *gin.Context) {
+// OutletOrdersList lists the sorts and filters accepted by OutletTotalOrders
+var OutletOrdersList = pagination.Spec{
+	DefaultLimit: 50,
+	MaxLimit:     200,
+	DefaultSort:  "-createdAt",
+	Sorts: map[string]pagination.Sort{
+		"createdAt":   {Column: `"createdAt"`, Field: "CreatedAt", Type: pagination.TypeTime},
+		"totalAmount": {Column: `"totalAmount"`, Field: "TotalAmount", Type: pagination.TypeFloat},
+	},
+	Filters: map[string]pagination.Filter{
+		"status":        {Column: "status", Type: pagination.TypeString, Ops: []pagination.Op{pagination.OpEq, pagination.OpNe, pagination.OpIn}},
+		"type":          {Column: "type", Type: pagination.TypeString, Values: []string{string(models.OrderTypeApp), string(models.OrderTypeManual)}},
+		"paymentMethod": {Column: `"paymentMethod"`, Type: pagination.TypeString, Ops: []pagination.Op{pagination.OpEq, pagination.OpIn}},
+		"createdAt":     {Column: `"createdAt"`, Type: pagination.TypeTime, Ops: pagination.Range},
+		"totalAmount":   {Column: `"totalAmount"`, Type: pagination.TypeFloat, Ops: pagination.Range},
+	},
+}
+
+// OutletTotalOrders returns a page of an outlet's orders with customer and item details
+func (ctrl *Controller) OutletTotalOrders(c *gin.Context) {
 	outletIDStr := c.Param("outletId")
 	outletID, err := strconv.Atoi(outletIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid outlet ID"})
+		apperror.Abort(c, apperror.BadRequest("Invalid outlet ID"))
 		return
 	}
 
-	log.Printf("🔍 [OutletTotalOrders] Fetching orders for outlet ID: %d", outletID)
+	list, err := pagination.Parse(c, OutletOrdersList)
+	if err != nil {
+		apperror.Abort(c, err)
+		return
+	}
 
 	var orders []models.Order
-	result := database.DB.Where(`"outletId" = ?`, outletID).
+	result := list.Apply(repository.Orders(ctrl.DB.WithContext(c.Request.Context())).Where(repository.OrderFilter{OutletID: outletID})).
 		Preload("Customer.User").
 		Preload("Items.Product").
-		Order(`"createdAt" DESC`).
 		Find(&orders)
 
 	if result.Error != nil {
-		log.Printf("❌ [OutletTotalOrders] Database error: %v", result.Error)
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to fetch orders", "error": result.Error.Error()})
+		apperror.Abort(c, apperror.Internal("Failed to fetch orders", result.Error))
 		return
 	}
+	orders, meta := pagination.Trim(list, orders)
 
-	log.Printf("📦 [OutletTotalOrders] Found %d orders in database", len(orders))
-	
-	if len(orders) == 0 {
-		log.Printf("⚠️  [OutletTotalOrders] No orders found for outlet ID: %d", outletID)
-		c.JSON(http.StatusOK, []gin.H{})
-		return
-	}
-
-	formatted := make([]gin.H, len(orders))
+	formatted := make([]dto.OutletOrder, len(orders))
 	for i, order := range orders {
-		log.Printf("🔄 [OutletTotalOrders] Processing order #%d (ID: %d)", i+1, order.ID)
-		
 		customerName := "WalkIn"
 		var customerPhone *string
 
-		if order.Customer != nil {
-			database.DB.Preload("User").First(&order.Customer, order.Customer.ID)
-			if order.Customer.User.ID > 0 {
-				customerName = order.Customer.User.Name
-				customerPhone = order.Customer.User.Phone
-			}
+		if order.Customer != nil && order.Customer.User.ID > 0 {
+			customerName = order.Customer.User.Name
+			customerPhone = order.Customer.User.Phone
 		}
 
-		log.Printf("   Customer: %s, Items count: %d", customerName, len(order.Items))
-
-		items := make([]gin.H, len(order.Items))
+		items := make([]dto.OutletOrderItem, len(order.Items))
 		for j, item := range order.Items {
-			items[j] = gin.H{
-				"productName": item.Product.Name,
-				"quantity":    item.Quantity,
-				"unitPrice":   item.UnitPrice,
-				"totalPrice":  item.UnitPrice * float64(item.Quantity),
+			items[j] = dto.OutletOrderItem{
+				ProductName: item.Product.Name,
+				Quantity:    item.Quantity,
+				UnitPrice:   item.UnitPrice,
+				TotalPrice:  item.UnitPrice * float64(item.Quantity),
 			}
 		}
 
-		formatted[i] = gin.H{
-			"orderId":       order.ID,
-			"orderTime":     order.CreatedAt,
-			"totalAmount":   order.TotalAmount,
-			"paymentMethod": order.PaymentMethod,
-			"status":        order.Status,
-			"customerName":  customerName,
-			"customerPhone": customerPhone,
-			"deliveryDate":  order.DeliveryDate,
-			"deliverySlot":  order.DeliverySlot,
-			"type":          order.Type,
-			"items":         items,
+		formatted[i] = dto.OutletOrder{
+			OrderID:       order.ID,
+			OrderTime:     order.CreatedAt,
+			TotalAmount:   order.TotalAmount,
+			PaymentMethod: order.PaymentMethod,
+			Status:        order.Status,
+			CustomerName:  customerName,
+			CustomerPhone: customerPhone,
+			DeliveryDate:  order.DeliveryDate,
+			DeliverySlot:  order.DeliverySlot,
+			Type:          order.Type,
+			Items:         items,
 		}
 	}
 
-	log.Printf("✅ [OutletTotalOrders] Returning %d formatted orders", len(formatted))
-	log.Printf("📋 [OutletTotalOrders] Sample response: %+v", formatted[0])
-
-	c.JSON(http.StatusOK, formatted)
+	c.JSON(http.StatusOK, dto.OutletOrdersResponse{
+		Orders: formatted,
+		Page:   dto.Page{Pagination: meta},
+	})
 }
@@ -1,33 +1,31 @@
 package superadmin
 
 import (
-	"backend_pandhi/pkg/database"
+	"backend_pandhi/pkg/apperror"
+	"backend_pandhi/pkg/audit"
+	"backend_pandhi/pkg/dto"
 	"backend_pandhi/pkg/models"
+	"backend_pandhi/pkg/ratings"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // AddOutlets creates a new outlet
-func AddOutlets(c *gin.Context) {
-	var req struct {
-		Name       string `json:"name" binding:"required"`
-		Address    string `json:"address" binding:"required"`
-		Phone      string `json:"phone" binding:"required"`
-		Email      string `json:"email" binding:"required"`
-		StaffCount int    `json:"staffCount"`
-	}
+func (ctrl *Controller) AddOutlets(c *gin.Context) {
+	var req dto.AddOutletRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Provide all outlet details"})
+		apperror.Abort(c, apperror.Binding(err, "Provide all outlet details"))
 		return
 	}
 
 	// Check existing outlet
 	var existing models.Outlet
-	if err := database.DB.Where("email = ?", req.Email).First(&existing).Error; err == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Outlet already exists"})
+	if err := ctrl.DB.WithContext(c.Request.Context()).Where("email = ?", req.Email).First(&existing).Error; err == nil {
+		apperror.Abort(c, apperror.BadRequest("Outlet already exists"))
 		return
 	}
 
@@ -40,8 +38,8 @@ func AddOutlets(c *gin.Context) {
 		StaffCount: req.StaffCount,
 	}
 
-	if err := database.DB.Create(&outlet).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal server error"})
+	if err := ctrl.DB.WithContext(c.Request.Context()).Create(&outlet).Error; err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
 		return
 	}
 
@@ -52,26 +50,85 @@ func AddOutlets(c *gin.Context) {
 }
 
 // GetOutlets returns all outlets
-func GetOutlets(c *gin.Context) {
+func (ctrl *Controller) GetOutlets(c *gin.Context) {
 	var outlets []models.Outlet
-	database.DB.Find(&outlets)
+	ctrl.DB.WithContext(c.Request.Context()).Find(&outlets)
 
 	c.JSON(http.StatusOK, gin.H{"outlets": outlets})
 }
 
 // RemoveOutlets deletes an outlet
-func RemoveOutlets(c *gin.Context) {
+func (ctrl *Controller) RemoveOutlets(c *gin.Context) {
 	outletIDStr := c.Param("outletId")
 	outletID, err := strconv.Atoi(outletIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Provide OutletId to delete"})
+		apperror.Abort(c, apperror.BadRequest("Provide OutletId to delete"))
 		return
 	}
 
-	if err := database.DB.Delete(&models.Outlet{}, outletID).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Internal Server Error"})
+	if err := ctrl.DB.WithContext(c.Request.Context()).Delete(&models.Outlet{}, outletID).Error; err != nil {
+		apperror.Abort(c, apperror.BadRequest("Internal Server Error"))
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Deleted Outlet"})
 }
+
+// UpdateOutletRatingWeights sets how an outlet's feedback ratings weigh in
+// product scores and queues a recompute of its products
+func (ctrl *Controller) UpdateOutletRatingWeights(c *gin.Context) {
+	outletID, err := strconv.Atoi(c.Param("outletId"))
+	if err != nil {
+		apperror.Abort(c, apperror.BadRequest("Valid outletId is required"))
+		return
+	}
+
+	var req dto.RatingWeights
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.Abort(c, apperror.Binding(err, "overall, taste, quality and quantity weights are required"))
+		return
+	}
+	weights := ratings.Weights(req)
+	if err := weights.Validate(); err != nil {
+		apperror.Abort(c, apperror.BadRequest(err.Error()))
+		return
+	}
+
+	var outlet models.Outlet
+	if err := ctrl.DB.WithContext(c.Request.Context()).First(&outlet, outletID).Error; err != nil {
+		apperror.Abort(c, apperror.NotFound("Outlet not found"))
+		return
+	}
+
+	err = ctrl.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Outlet{}).Where("id = ?", outletID).Updates(map[string]interface{}{
+			"ratingWeightOverall":  weights.Overall,
+			"ratingWeightTaste":    weights.Taste,
+			"ratingWeightQuality":  weights.Quality,
+			"ratingWeightQuantity": weights.Quantity,
+		}).Error; err != nil {
+			return err
+		}
+		// Existing scores were weighed with the old weights
+		if err := ratings.EnqueueOutletRecompute(tx, outletID); err != nil {
+			return err
+		}
+		return audit.Record(c, tx, audit.Entry{
+			Action:     audit.ActionOutletRatingWeights,
+			EntityType: audit.EntityOutlet,
+			EntityID:   outletID,
+			OutletID:   &outletID,
+			Before:     ratings.WeightsOf(outlet),
+			After:      weights,
+		})
+	})
+	if err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Rating weights updated; product scores are being recomputed",
+		"weights": weights,
+	})
+}
@@ -1,9 +1,9 @@
 package superadmin
 
 import (
-	"backend_pandhi/pkg/database"
+	"backend_pandhi/pkg/apperror"
+	"backend_pandhi/pkg/audit"
 	"backend_pandhi/pkg/models"
-	"backend_pandhi/pkg/services"
 	"io"
 	"net/http"
 	"strconv"
@@ -14,12 +14,14 @@ import (
 )
 
 // GetProducts returns all products for an outlet
-func GetProducts(c *gin.Context) {
-	outletIDStr := c.Param("outletId")
-	outletID, _ := strconv.Atoi(outletIDStr)
+func (ctrl *Controller) GetProducts(c *gin.Context) {
+	outletID, ok := apperror.ParamInt(c, "outletId")
+	if !ok {
+		return
+	}
 
 	var products []models.Product
-	query := database.DB.Preload("Inventory").Order("name ASC")
+	query := ctrl.DB.WithContext(c.Request.Context()).Preload("Inventory").Order("name ASC")
 	if outletID > 0 {
 		query = query.Where(`"outletId" = ?`, outletID)
 	}
@@ -30,7 +32,7 @@ func GetProducts(c *gin.Context) {
 	for i, product := range products {
 		imageURL := ""
 		if product.ImageURL != nil {
-			signedURL, _ := services.GetSignedURL(*product.ImageURL)
+			signedURL, _ := ctrl.Storage.GetSignedURL(*product.ImageURL)
 			imageURL = signedURL
 		}
 
@@ -56,7 +58,7 @@ func GetProducts(c *gin.Context) {
 }
 
 // AddProduct creates a new product with image upload
-func AddProduct(c *gin.Context) {
+func (ctrl *Controller) AddProduct(c *gin.Context) {
 	name := c.PostForm("name")
 	description := c.PostForm("description")
 	priceStr := c.PostForm("price")
@@ -68,17 +70,25 @@ func AddProduct(c *gin.Context) {
 	companyPaidStr := c.PostForm("companyPaid")
 
 	if name == "" || description == "" || priceStr == "" || outletIDStr == "" || category == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Provide all the fields"})
+		apperror.Abort(c, apperror.BadRequest("Provide all the fields"))
 		return
 	}
 
-	price, _ := strconv.ParseFloat(priceStr, 64)
-	outletID, _ := strconv.Atoi(outletIDStr)
-	threshold, _ := strconv.Atoi(thresholdStr)
-	if threshold == 0 {
-		threshold = 10
+	p := apperror.NewParser()
+	price := p.Float("price", priceStr)
+	outletID := p.Int("outletId", outletIDStr)
+	threshold := 10
+	if t := p.OptionalInt("threshold", thresholdStr); t != nil && *t != 0 {
+		threshold = *t
+	}
+	minValue := 0
+	if m := p.OptionalInt("minValue", minValueStr); m != nil {
+		minValue = *m
+	}
+	if err := p.Err("Invalid product fields"); err != nil {
+		apperror.Abort(c, err)
+		return
 	}
-	minValue, _ := strconv.Atoi(minValueStr)
 
 	isVeg := true
 	if isVegStr == "false" {
@@ -91,8 +101,8 @@ func AddProduct(c *gin.Context) {
 
 	// Check existing
 	var existing models.Product
-	if err := database.DB.Where("name = ?", crtName).First(&existing).Error; err == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Product already available"})
+	if err := ctrl.DB.WithContext(c.Request.Context()).Where("name = ?", crtName).First(&existing).Error; err == nil {
+		apperror.Abort(c, apperror.BadRequest("Product already available"))
 		return
 	}
 
@@ -102,7 +112,7 @@ func AddProduct(c *gin.Context) {
 	if err == nil {
 		defer file.Close()
 		fileBytes, _ := io.ReadAll(file)
-		uploadedURL, uploadErr := services.UploadImage(fileBytes, "product-image.jpg")
+		uploadedURL, uploadErr := ctrl.Storage.UploadImage(c.Request.Context(), fileBytes, "product-image.jpg")
 		if uploadErr == nil {
 			imageURL = &uploadedURL
 		}
@@ -110,7 +120,7 @@ func AddProduct(c *gin.Context) {
 
 	// Create product in transaction
 	var newProduct models.Product
-	database.DB.Transaction(func(tx *gorm.DB) error {
+	err = ctrl.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
 		newProduct = models.Product{
 			Name:        crtName,
 			Description: &description,
@@ -134,7 +144,9 @@ func AddProduct(c *gin.Context) {
 			Threshold: threshold,
 			Quantity:  minValue,
 		}
-		tx.Create(&inventory)
+		if err := tx.Create(&inventory).Error; err != nil {
+			return err
+		}
 
 		// Create stock history
 		stockHistory := models.StockHistory{
@@ -143,10 +155,12 @@ func AddProduct(c *gin.Context) {
 			Quantity:  minValue,
 			Action:    models.StockActionAdd,
 		}
-		tx.Create(&stockHistory)
-
-		return nil
+		return tx.Create(&stockHistory).Error
 	})
+	if err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
+		return
+	}
 
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "Product Created",
@@ -160,17 +174,17 @@ func AddProduct(c *gin.Context) {
 }
 
 // DeleteProduct deletes a product
-func DeleteProduct(c *gin.Context) {
+func (ctrl *Controller) DeleteProduct(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Provide productID"})
+		apperror.Abort(c, apperror.BadRequest("Provide productID"))
 		return
 	}
 
-	result := database.DB.Delete(&models.Product{}, id)
+	result := ctrl.DB.WithContext(c.Request.Context()).Delete(&models.Product{}, id)
 	if result.RowsAffected == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"message": "No product found with that id"})
+		apperror.Abort(c, apperror.NotFound("No product found with that id"))
 		return
 	}
 
@@ -178,11 +192,11 @@ func DeleteProduct(c *gin.Context) {
 }
 
 // UpdateProduct updates product details with image upload
-func UpdateProduct(c *gin.Context) {
+func (ctrl *Controller) UpdateProduct(c *gin.Context) {
 	productIDStr := c.Param("id")
 	productID, err := strconv.Atoi(productIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid product ID"})
+		apperror.Abort(c, apperror.BadRequest("Invalid product ID"))
 		return
 	}
 
@@ -197,35 +211,44 @@ func UpdateProduct(c *gin.Context) {
 	companyPaidStr := c.PostForm("companyPaid")
 
 	if name == "" || description == "" || priceStr == "" || category == "" || outletIDStr == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Missing required fields"})
+		apperror.Abort(c, apperror.BadRequest("Missing required fields"))
+		return
+	}
+
+	p := apperror.NewParser()
+	price := p.Float("price", priceStr)
+	outletID := p.Int("outletId", outletIDStr)
+	threshold := 10
+	if t := p.OptionalInt("threshold", thresholdStr); t != nil && *t != 0 {
+		threshold = *t
+	}
+	minValue := 0
+	if m := p.OptionalInt("minValue", minValueStr); m != nil {
+		minValue = *m
+	}
+	if err := p.Err("Invalid product fields"); err != nil {
+		apperror.Abort(c, err)
 		return
 	}
 
-	price, _ := strconv.ParseFloat(priceStr, 64)
 	if price <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Price must be greater than 0"})
+		apperror.Abort(c, apperror.BadRequest("Price must be greater than 0"))
 		return
 	}
 
 	// Get existing product
 	var existingProduct models.Product
-	if err := database.DB.Preload("Inventory").First(&existingProduct, productID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Product not found"})
+	if err := ctrl.DB.WithContext(c.Request.Context()).Preload("Inventory").First(&existingProduct, productID).Error; err != nil {
+		apperror.Abort(c, apperror.NotFound("Product not found"))
 		return
 	}
 
 	crtName := strings.ToLower(name)
-	minValue, _ := strconv.Atoi(minValueStr)
-	threshold, _ := strconv.Atoi(thresholdStr)
-	if threshold == 0 {
-		threshold = 10
-	}
-	outletID, _ := strconv.Atoi(outletIDStr)
 
 	// Check duplicate
 	var duplicate models.Product
-	if err := database.DB.Where("name = ? AND id != ?", crtName, productID).First(&duplicate).Error; err == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Product with this name already exists"})
+	if err := ctrl.DB.WithContext(c.Request.Context()).Where("name = ? AND id != ?", crtName, productID).First(&duplicate).Error; err == nil {
+		apperror.Abort(c, apperror.BadRequest("Product with this name already exists"))
 		return
 	}
 
@@ -235,7 +258,7 @@ func UpdateProduct(c *gin.Context) {
 	if err == nil {
 		defer file.Close()
 		fileBytes, _ := io.ReadAll(file)
-		uploadedURL, uploadErr := services.UploadImage(fileBytes, "product-image.jpg")
+		uploadedURL, uploadErr := ctrl.Storage.UploadImage(c.Request.Context(), fileBytes, "product-image.jpg")
 		if uploadErr == nil {
 			imageURL = &uploadedURL
 		}
@@ -251,9 +274,22 @@ func UpdateProduct(c *gin.Context) {
 		companyPaid = true
 	}
 
+	before := gin.H{
+		"name":        existingProduct.Name,
+		"description": existingProduct.Description,
+		"price":       existingProduct.Price,
+		"imageUrl":    existingProduct.ImageURL,
+		"category":    existingProduct.Category,
+		"minValue":    existingProduct.MinValue,
+		"outletId":    existingProduct.OutletID,
+		"isVeg":       existingProduct.IsVeg,
+		"companyPaid": existingProduct.CompanyPaid,
+		"threshold":   existingProduct.Inventory.Threshold,
+	}
+
 	// Update in transaction
-	database.DB.Transaction(func(tx *gorm.DB) error {
-		tx.Model(&existingProduct).Updates(map[string]interface{}{
+	err = ctrl.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&existingProduct).Updates(map[string]interface{}{
 			"name":        crtName,
 			"description": description,
 			"price":       price,
@@ -263,26 +299,54 @@ func UpdateProduct(c *gin.Context) {
 			"outletId":    outletID,
 			"isVeg":       isVeg,
 			"companyPaid": companyPaid,
-		})
+		}).Error; err != nil {
+			return err
+		}
 
-		tx.Model(&existingProduct.Inventory).Updates(map[string]interface{}{
+		if err := tx.Model(&existingProduct.Inventory).Updates(map[string]interface{}{
 			"threshold": threshold,
 			"outletId":  outletID,
-		})
+		}).Error; err != nil {
+			return err
+		}
 
-		tx.Create(&models.StockHistory{
+		if err := tx.Create(&models.StockHistory{
 			ProductID: productID,
 			OutletID:  outletID,
 			Quantity:  existingProduct.Inventory.Quantity,
 			Action:    models.StockActionUpdate,
-		})
+		}).Error; err != nil {
+			return err
+		}
 
-		return nil
+		return audit.Record(c, tx, audit.Entry{
+			Action:     audit.ActionProductUpdate,
+			EntityType: audit.EntityProduct,
+			EntityID:   productID,
+			OutletID:   &outletID,
+			Before:     before,
+			After: gin.H{
+				"name":        crtName,
+				"description": &description,
+				"price":       price,
+				"imageUrl":    imageURL,
+				"category":    category,
+				"minValue":    &minValue,
+				"outletId":    outletID,
+				"isVeg":       isVeg,
+				"companyPaid": companyPaid,
+				"threshold":   threshold,
+			},
+		})
 	})
+	if err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
+		return
+	}
 
 	// Reload
 	var productWithInventory models.Product
-	database.DB.Preload("Inventory").First(&productWithInventory, productID)
+	ctrl.DB.WithContext(c.Request.Context()).Preload("Inventory").First(&productWithInventory, productID)
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
@@ -1,31 +1,36 @@
 package superadmin
 
 import (
-	"backend_pandhi/pkg/database"
+	"backend_pandhi/pkg/apperror"
+	"backend_pandhi/pkg/dto"
 	"backend_pandhi/pkg/models"
+	"backend_pandhi/pkg/repository"
 	"net/http"
-	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 // GetOutletSalesReport returns sales by product
-func GetOutletSalesReport(c *gin.Context) {
-	outletIDStr := c.Param("outletId")
-	outletID, _ := strconv.Atoi(outletIDStr)
-
-	var req struct {
-		From string `json:"from" binding:"required"`
-		To   string `json:"to" binding:"required"`
+func (ctrl *Controller) GetOutletSalesReport(c *gin.Context) {
+	outletID, ok := apperror.ParamInt(c, "outletId")
+	if !ok {
+		return
 	}
+
+	var req dto.DateRangeRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "from and to dates are required"})
+		apperror.Abort(c, apperror.Binding(err, "from and to dates are required"))
 		return
 	}
 
-	from, _ := time.Parse("2006-01-02", req.From)
-	to, _ := time.Parse("2006-01-02", req.To)
+	p := apperror.NewParser()
+	from := p.Date("from", req.From)
+	to := p.Date("to", req.To)
+	if err := p.Err("Invalid date range"); err != nil {
+		apperror.Abort(c, err)
+		return
+	}
 
 	type SalesData struct {
 		ProductID   int     `json:"productId"`
@@ -35,7 +40,7 @@ func GetOutletSalesReport(c *gin.Context) {
 		Revenue     float64 `json:"revenue"`
 	}
 	var sales []SalesData
-	database.DB.Table(`"OrderItem"`).Select(`"OrderItem"."productId", "Product".name as product_name, COUNT(DISTINCT "OrderItem"."orderId") as total_orders, SUM("OrderItem".quantity) as quantity, SUM("OrderItem".quantity * "OrderItem"."unitPrice") as revenue`).
+	ctrl.DB.WithContext(c.Request.Context()).Table(`"OrderItem"`).Select(`"OrderItem"."productId" as product_id, "Product".name as product_name, COUNT(DISTINCT "OrderItem"."orderId") as total_orders, SUM("OrderItem".quantity) as quantity, SUM("OrderItem".quantity * "OrderItem"."unitPrice") as revenue`).
 		Joins(`JOIN "Order" ON "Order".id = "OrderItem"."orderId"`).
 		Joins(`JOIN "Product" ON "Product".id = "OrderItem"."productId"`).
 		Where(`"Order"."outletId" = ? AND "Order"."createdAt" >= ? AND "Order"."createdAt" <= ? AND "Order".status IN ?`,
@@ -47,21 +52,25 @@ func GetOutletSalesReport(c *gin.Context) {
 }
 
 // GetOutletRevenueByItems returns revenue by product
-func GetOutletRevenueByItems(c *gin.Context) {
-	outletIDStr := c.Param("outletId")
-	outletID, _ := strconv.Atoi(outletIDStr)
-
-	var req struct {
-		From string `json:"from" binding:"required"`
-		To   string `json:"to" binding:"required"`
+func (ctrl *Controller) GetOutletRevenueByItems(c *gin.Context) {
+	outletID, ok := apperror.ParamInt(c, "outletId")
+	if !ok {
+		return
 	}
+
+	var req dto.DateRangeRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "from and to dates are required"})
+		apperror.Abort(c, apperror.Binding(err, "from and to dates are required"))
 		return
 	}
 
-	from, _ := time.Parse("2006-01-02", req.From)
-	to, _ := time.Parse("2006-01-02", req.To)
+	p := apperror.NewParser()
+	from := p.Date("from", req.From)
+	to := p.Date("to", req.To)
+	if err := p.Err("Invalid date range"); err != nil {
+		apperror.Abort(c, err)
+		return
+	}
 
 	type RevenueData struct {
 		ProductID   int     `json:"productId"`
@@ -69,7 +78,7 @@ func GetOutletRevenueByItems(c *gin.Context) {
 		Revenue     float64 `json:"revenue"`
 	}
 	var revenue []RevenueData
-	database.DB.Table(`"OrderItem"`).Select(`"OrderItem"."productId", "Product".name as product_name, SUM("OrderItem".quantity * "OrderItem"."unitPrice") as revenue`).
+	ctrl.DB.WithContext(c.Request.Context()).Table(`"OrderItem"`).Select(`"OrderItem"."productId" as product_id, "Product".name as product_name, SUM("OrderItem".quantity * "OrderItem"."unitPrice") as revenue`).
 		Joins(`JOIN "Order" ON "Order".id = "OrderItem"."orderId"`).
 		Joins(`JOIN "Product" ON "Product".id = "OrderItem"."productId"`).
 		Where(`"Order"."outletId" = ? AND "Order"."createdAt" >= ? AND "Order"."createdAt" <= ? AND "Order".status IN ?`,
@@ -81,39 +90,49 @@ func GetOutletRevenueByItems(c *gin.Context) {
 }
 
 // GetRevenueSplit returns revenue by type (APP, MANUAL, WALLET)
-func GetRevenueSplit(c *gin.Context) {
-	outletIDStr := c.Param("outletId")
-	outletID, _ := strconv.Atoi(outletIDStr)
-
-	var req struct {
-		From string `json:"from" binding:"required"`
-		To   string `json:"to" binding:"required"`
+func (ctrl *Controller) GetRevenueSplit(c *gin.Context) {
+	outletID, ok := apperror.ParamInt(c, "outletId")
+	if !ok {
+		return
 	}
+
+	var req dto.DateRangeRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "from and to dates are required"})
+		apperror.Abort(c, apperror.Binding(err, "from and to dates are required"))
 		return
 	}
 
-	from, _ := time.Parse("2006-01-02", req.From)
-	to, _ := time.Parse("2006-01-02", req.To)
+	p := apperror.NewParser()
+	from := p.Date("from", req.From)
+	to := p.Date("to", req.To)
+	if err := p.Err("Invalid date range"); err != nil {
+		apperror.Abort(c, err)
+		return
+	}
 
-	var appOrderRevenue float64
-	database.DB.Model(&models.Order{}).
-		Where(`"outletId" = ? AND type = ? AND status IN ? AND "createdAt" >= ? AND "createdAt" <= ?`,
-			outletID, models.OrderTypeApp, []models.OrderStatus{models.OrderStatusDelivered, models.OrderStatusPartiallyDelivered}, from, to).
-		Select(`COALESCE(SUM("totalAmount"), 0)`).Scan(&appOrderRevenue)
+	orders := repository.Orders(ctrl.DB.WithContext(c.Request.Context()))
+	filter := repository.OrderFilter{OutletID: outletID, Statuses: completedOrderStatuses, From: from, To: to}
 
-	var manualOrderRevenue float64
-	database.DB.Model(&models.Order{}).
-		Where(`"outletId" = ? AND type = ? AND status IN ? AND "createdAt" >= ? AND "createdAt" <= ?`,
-			outletID, models.OrderTypeManual, []models.OrderStatus{models.OrderStatusDelivered, models.OrderStatusPartiallyDelivered}, from, to).
-		Select(`COALESCE(SUM("totalAmount"), 0)`).Scan(&manualOrderRevenue)
+	filter.Type = models.OrderTypeApp
+	appOrderRevenue, err := orders.Revenue(filter)
+	if err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
+		return
+	}
 
-	var walletRechargeRevenue float64
-	database.DB.Model(&models.WalletTransaction{}).
-		Where(`status = ? AND "createdAt" >= ? AND "createdAt" <= ?`,
-			models.WalletTransTypeRecharge, from, to).
-		Select(`COALESCE(SUM(amount), 0)`).Scan(&walletRechargeRevenue)
+	filter.Type = models.OrderTypeManual
+	manualOrderRevenue, err := orders.Revenue(filter)
+	if err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
+		return
+	}
+
+	walletRechargeRevenue, err := repository.Wallets(ctrl.DB.WithContext(c.Request.Context())).
+		TransactionTotal(models.WalletTransTypeRecharge, from, to)
+	if err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
+		return
+	}
 
 	totalRevenue := appOrderRevenue + manualOrderRevenue + walletRechargeRevenue
 
@@ -126,28 +145,32 @@ func GetRevenueSplit(c *gin.Context) {
 }
 
 // GetWalletRechargeByDay returns daily wallet recharge revenue
-func GetWalletRechargeByDay(c *gin.Context) {
-	outletIDStr := c.Param("outletId")
-	outletID, _ := strconv.Atoi(outletIDStr)
-
-	var req struct {
-		From string `json:"from" binding:"required"`
-		To   string `json:"to" binding:"required"`
+func (ctrl *Controller) GetWalletRechargeByDay(c *gin.Context) {
+	outletID, ok := apperror.ParamInt(c, "outletId")
+	if !ok {
+		return
 	}
+
+	var req dto.DateRangeRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "from and to dates are required"})
+		apperror.Abort(c, apperror.Binding(err, "from and to dates are required"))
 		return
 	}
 
-	from, _ := time.Parse("2006-01-02", req.From)
-	to, _ := time.Parse("2006-01-02", req.To)
+	p := apperror.NewParser()
+	from := p.Date("from", req.From)
+	to := p.Date("to", req.To)
+	if err := p.Err("Invalid date range"); err != nil {
+		apperror.Abort(c, err)
+		return
+	}
 
 	type DailyRecharge struct {
-		CreatedAt time.Time
+		CreatedAt time.Time `gorm:"column:createdAt"`
 		Amount    float64
 	}
 	var recharges []DailyRecharge
-	database.DB.Table(`"WalletTransaction"`).Select(`"createdAt", amount`).
+	ctrl.DB.WithContext(c.Request.Context()).Table(`"WalletTransaction"`).Select(`"createdAt", amount`).
 		Joins(`JOIN "Wallet" ON "Wallet".id = "WalletTransaction"."walletId"`).
 		Joins(`JOIN "CustomerDetails" ON "CustomerDetails".id = "Wallet"."customerId"`).
 		Joins(`JOIN "User" ON "User".id = "CustomerDetails"."userId"`).
@@ -170,15 +193,15 @@ func GetWalletRechargeByDay(c *gin.Context) {
 }
 
 // GetProfitLossTrends returns monthly profit/loss for a year
-func GetProfitLossTrends(c *gin.Context) {
-	outletIDStr := c.Param("outletId")
-	outletID, _ := strconv.Atoi(outletIDStr)
-
-	var req struct {
-		Year int `json:"year" binding:"required"`
+func (ctrl *Controller) GetProfitLossTrends(c *gin.Context) {
+	outletID, ok := apperror.ParamInt(c, "outletId")
+	if !ok {
+		return
 	}
+
+	var req dto.ProfitLossRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "year is required"})
+		apperror.Abort(c, apperror.Binding(err, "year is required"))
 		return
 	}
 
@@ -186,22 +209,19 @@ func GetProfitLossTrends(c *gin.Context) {
 	yearEnd := time.Date(req.Year, 12, 31, 23, 59, 59, 999, time.UTC)
 
 	// Get orders
-	var orders []struct {
-		TotalAmount float64
-		CreatedAt   time.Time
+	orders, err := repository.Orders(ctrl.DB.WithContext(c.Request.Context())).
+		List(repository.OrderFilter{OutletID: outletID, Statuses: completedOrderStatuses, From: yearStart, To: yearEnd})
+	if err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
+		return
 	}
-	database.DB.Model(&models.Order{}).
-		Select(`"totalAmount", "createdAt"`).
-		Where(`"outletId" = ? AND status IN ? AND "createdAt" >= ? AND "createdAt" <= ?`,
-			outletID, []models.OrderStatus{models.OrderStatusDelivered, models.OrderStatusPartiallyDelivered}, yearStart, yearEnd).
-		Scan(&orders)
 
 	// Get expenses
 	var expenses []struct {
 		Amount    float64
-		CreatedAt time.Time
+		CreatedAt time.Time `gorm:"column:createdAt"`
 	}
-	database.DB.Model(&models.Expense{}).
+	ctrl.DB.WithContext(c.Request.Context()).Model(&models.Expense{}).
 		Select(`amount, "createdAt"`).
 		Where(`"outletId" = ? AND "createdAt" >= ? AND "createdAt" <= ?`,
 			outletID, yearStart, yearEnd).
@@ -255,25 +275,29 @@ func GetProfitLossTrends(c *gin.Context) {
 }
 
 // GetCustomerOverview returns new vs returning customers
-func GetCustomerOverview(c *gin.Context) {
-	outletIDStr := c.Param("outletId")
-	outletID, _ := strconv.Atoi(outletIDStr)
-
-	var req struct {
-		From string `json:"from" binding:"required"`
-		To   string `json:"to" binding:"required"`
+func (ctrl *Controller) GetCustomerOverview(c *gin.Context) {
+	outletID, ok := apperror.ParamInt(c, "outletId")
+	if !ok {
+		return
 	}
+
+	var req dto.DateRangeRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "from and to dates are required"})
+		apperror.Abort(c, apperror.Binding(err, "from and to dates are required"))
 		return
 	}
 
-	from, _ := time.Parse("2006-01-02", req.From)
-	to, _ := time.Parse("2006-01-02", req.To)
+	p := apperror.NewParser()
+	from := p.Date("from", req.From)
+	to := p.Date("to", req.To)
+	if err := p.Err("Invalid date range"); err != nil {
+		apperror.Abort(c, err)
+		return
+	}
 
 	// Get orders in period
 	var orders []models.Order
-	database.DB.Where(`"outletId" = ? AND "createdAt" >= ? AND "createdAt" <= ? AND "customerId" IS NOT NULL`,
+	ctrl.DB.WithContext(c.Request.Context()).Where(`"outletId" = ? AND "createdAt" >= ? AND "createdAt" <= ? AND "customerId" IS NOT NULL`,
 		outletID, from, to).
 		Find(&orders)
 
@@ -288,7 +312,7 @@ func GetCustomerOverview(c *gin.Context) {
 	returningCount := 0
 	for customerID := range customerIDs {
 		var priorOrder models.Order
-		err := database.DB.Where(`"customerId" = ? AND "createdAt" < ?`, customerID, from).First(&priorOrder).Error
+		err := ctrl.DB.WithContext(c.Request.Context()).Where(`"customerId" = ? AND "createdAt" < ?`, customerID, from).First(&priorOrder).Error
 		if err == nil {
 			returningCount++
 		} else {
@@ -303,24 +327,28 @@ func GetCustomerOverview(c *gin.Context) {
 }
 
 // GetCustomerPerOrder returns customers per order by day
-func GetCustomerPerOrder(c *gin.Context) {
-	outletIDStr := c.Param("outletId")
-	outletID, _ := strconv.Atoi(outletIDStr)
-
-	var req struct {
-		From string `json:"from" binding:"required"`
-		To   string `json:"to" binding:"required"`
+func (ctrl *Controller) GetCustomerPerOrder(c *gin.Context) {
+	outletID, ok := apperror.ParamInt(c, "outletId")
+	if !ok {
+		return
 	}
+
+	var req dto.DateRangeRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "from and to dates are required"})
+		apperror.Abort(c, apperror.Binding(err, "from and to dates are required"))
 		return
 	}
 
-	from, _ := time.Parse("2006-01-02", req.From)
-	to, _ := time.Parse("2006-01-02", req.To)
+	p := apperror.NewParser()
+	from := p.Date("from", req.From)
+	to := p.Date("to", req.To)
+	if err := p.Err("Invalid date range"); err != nil {
+		apperror.Abort(c, err)
+		return
+	}
 
 	var orders []models.Order
-	database.DB.Where(`"outletId" = ? AND "createdAt" >= ? AND "createdAt" <= ? AND "customerId" IS NOT NULL AND status IN ?`,
+	ctrl.DB.WithContext(c.Request.Context()).Where(`"outletId" = ? AND "createdAt" >= ? AND "createdAt" <= ? AND "customerId" IS NOT NULL AND status IN ?`,
 		outletID, from, to, []models.OrderStatus{models.OrderStatusDelivered, models.OrderStatusPartiallyDelivered}).
 		Find(&orders)
 
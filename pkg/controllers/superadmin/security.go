@@ -0,0 +1,136 @@
+package superadmin
+
+import (
+	"backend_pandhi/pkg/apperror"
+	"backend_pandhi/pkg/dto"
+	"backend_pandhi/pkg/models"
+	"backend_pandhi/pkg/pagination"
+	"backend_pandhi/pkg/security"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UnlockLogin clears a login lockout. Admins may unlock customer and staff
+// accounts; admin accounts, SuperAdmin accounts and IP addresses need a SuperAdmin.
+func (ctrl *Controller) UnlockLogin(c *gin.Context) {
+	var req dto.UnlockLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.Abort(c, apperror.Binding(err, "Invalid request body"))
+		return
+	}
+	if req.Scope == "" {
+		req.Scope = models.LoginThrottleScopeAccount
+	}
+	if req.AccountType == "" {
+		req.AccountType = models.AuthAccountTypeUser
+	}
+
+	actor, isSuperAdmin := currentActor(c)
+
+	var key string
+	switch req.Scope {
+	case models.LoginThrottleScopeAccount:
+		if req.Email == "" {
+			apperror.Abort(c, apperror.BadRequest("email is required"))
+			return
+		}
+
+		switch req.AccountType {
+		case models.AuthAccountTypeUser:
+			var user models.User
+			if err := ctrl.DB.WithContext(c.Request.Context()).Where("email = ?", req.Email).First(&user).Error; err == nil &&
+				user.Role == models.RoleSuperAdmin && !isSuperAdmin {
+				apperror.Abort(c, apperror.Forbidden("Only SuperAdmin can unlock this account"))
+				return
+			}
+		case models.AuthAccountTypeAdmin:
+			if !isSuperAdmin {
+				apperror.Abort(c, apperror.Forbidden("Only SuperAdmin can unlock admin accounts"))
+				return
+			}
+		default:
+			apperror.Abort(c, apperror.BadRequest("accountType must be USER or ADMIN"))
+			return
+		}
+		key = security.AccountKey(req.AccountType, req.Email)
+
+	case models.LoginThrottleScopeIP:
+		if !isSuperAdmin {
+			apperror.Abort(c, apperror.Forbidden("Only SuperAdmin can unlock IP addresses"))
+			return
+		}
+		if req.IPAddress == "" {
+			apperror.Abort(c, apperror.BadRequest("ipAddress is required"))
+			return
+		}
+		key = req.IPAddress
+
+	default:
+		apperror.Abort(c, apperror.BadRequest("scope must be ACCOUNT or IP"))
+		return
+	}
+
+	if err := security.Unlock(ctrl.App, req.Scope, key, actor); err != nil {
+		if errors.Is(err, security.ErrNotLocked) {
+			apperror.Abort(c, apperror.NotFound("No active lockout found"))
+			return
+		}
+		apperror.Abort(c, apperror.Internal("Internal server error", nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Login unlocked successfully"})
+}
+
+// LoginLockoutsList lists the sorts and filters accepted by GetLoginLockouts
+var LoginLockoutsList = pagination.Spec{
+	DefaultLimit: 50,
+	MaxLimit:     200,
+	DefaultSort:  "-createdAt",
+	Sorts: map[string]pagination.Sort{
+		"createdAt": {Column: `"createdAt"`, Field: "CreatedAt", Type: pagination.TypeTime},
+	},
+	Filters: map[string]pagination.Filter{
+		"scope":     {Column: "scope", Type: pagination.TypeString, Values: []string{string(models.LoginThrottleScopeAccount), string(models.LoginThrottleScopeIP)}},
+		"key":       {Column: "key", Type: pagination.TypeString, Ops: []pagination.Op{pagination.OpEq, pagination.OpContains}},
+		"createdAt": {Column: `"createdAt"`, Type: pagination.TypeTime, Ops: pagination.Range},
+	},
+}
+
+// GetLoginLockouts lists recent lockout events, optionally only active ones
+func (ctrl *Controller) GetLoginLockouts(c *gin.Context) {
+	list, err := pagination.Parse(c, LoginLockoutsList)
+	if err != nil {
+		apperror.Abort(c, err)
+		return
+	}
+
+	query := ctrl.DB.WithContext(c.Request.Context()).Model(&models.LoginLockoutEvent{})
+	if c.Query("active") == "true" {
+		query = query.Where(`"unlockedAt" IS NULL AND "lockedUntil" > ?`, ctrl.Clock.Now())
+	}
+
+	var events []models.LoginLockoutEvent
+	if err := list.Apply(query).Find(&events).Error; err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
+		return
+	}
+	events, meta := pagination.Trim(list, events)
+
+	c.JSON(http.StatusOK, gin.H{"lockouts": events, "pagination": meta})
+}
+
+// currentActor describes the authenticated SuperAdmin or admin for audit fields
+func currentActor(c *gin.Context) (string, bool) {
+	userInterface, _ := c.Get("user")
+	switch u := userInterface.(type) {
+	case models.User:
+		return fmt.Sprintf("%s:%d", u.Role, u.ID), u.Role == models.RoleSuperAdmin
+	case gin.H:
+		return fmt.Sprintf("%v:%v", u["role"], u["id"]), false
+	}
+	return "unknown", false
+}
@@ -1,9 +1,15 @@
 package superadmin
 
 import (
-	"backend_pandhi/pkg/database"
+	"backend_pandhi/pkg/apperror"
+	"backend_pandhi/pkg/audit"
+	"backend_pandhi/pkg/dto"
+	"backend_pandhi/pkg/media"
 	"backend_pandhi/pkg/models"
-	"backend_pandhi/pkg/services"
+	"backend_pandhi/pkg/pagination"
+	"backend_pandhi/pkg/repository"
+	"backend_pandhi/pkg/utils"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -15,26 +21,28 @@ import (
 )
 
 // OutletAddStaff creates a new staff member
-func OutletAddStaff(c *gin.Context) {
-	var req struct {
-		Email       string   `json:"email" binding:"required"`
-		Password    string   `json:"password" binding:"required"`
-		Name        string   `json:"name" binding:"required"`
-		Phone       string   `json:"phone" binding:"required"`
-		OutletID    int      `json:"outletId" binding:"required"`
-		StaffRole   string   `json:"staffRole" binding:"required"`
-		Permissions []string `json:"permissions"`
-	}
+func (ctrl *Controller) OutletAddStaff(c *gin.Context) {
+	var req dto.AddStaffRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Please provide email, password, fullName, and phone."})
+		apperror.Abort(c, apperror.Binding(err, "Please provide email, password, fullName, and phone."))
+		return
+	}
+
+	phone, err := utils.NormalizePhone(ctrl.Config, req.Phone)
+	if err != nil {
+		apperror.Abort(c, apperror.BadRequest("Invalid phone number."))
 		return
 	}
 
 	// Check existing user
 	var existing models.User
-	if err := database.DB.Where("email = ?", req.Email).First(&existing).Error; err == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "User with this email already exists."})
+	if err := ctrl.DB.WithContext(c.Request.Context()).Where("email = ?", req.Email).First(&existing).Error; err == nil {
+		apperror.Abort(c, apperror.BadRequest("User with this email already exists."))
+		return
+	}
+	if err := ctrl.DB.WithContext(c.Request.Context()).Where("phone = ?", phone).First(&existing).Error; err == nil {
+		apperror.Abort(c, apperror.BadRequest("User with this phone number already exists."))
 		return
 	}
 
@@ -44,11 +52,11 @@ func OutletAddStaff(c *gin.Context) {
 
 	// Create user with staff info in transaction
 	var newUser models.User
-	err := database.DB.Transaction(func(tx *gorm.DB) error {
+	err = ctrl.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
 		newUser = models.User{
 			Name:     req.Name,
 			Email:    req.Email,
-			Phone:    &req.Phone,
+			Phone:    &phone,
 			Password: &passwordStr,
 			OutletID: &req.OutletID,
 			Role:     models.RoleStaff,
@@ -75,7 +83,9 @@ func OutletAddStaff(c *gin.Context) {
 					Type:      models.PermissionType(permType),
 					IsGranted: true,
 				}
-				tx.Create(&perm)
+				if err := tx.Create(&perm).Error; err != nil {
+					return err
+				}
 			}
 		}
 
@@ -83,7 +93,7 @@ func OutletAddStaff(c *gin.Context) {
 	})
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal server error"})
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
 		return
 	}
 
@@ -98,74 +108,117 @@ func OutletAddStaff(c *gin.Context) {
 }
 
 // OutletStaffPermission updates staff permissions
-func OutletStaffPermission(c *gin.Context) {
-	var req struct {
-		Permission string `json:"permission" binding:"required"`
-		Grant      bool   `json:"grant"`
-		StaffID    int    `json:"staffId" binding:"required"`
-	}
+func (ctrl *Controller) OutletStaffPermission(c *gin.Context) {
+	var req dto.StaffPermissionRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input"})
+		apperror.Abort(c, apperror.Binding(err, "Invalid input"))
 		return
 	}
 
-	// Find existing permission
-	var existing models.StaffPermission
-	err := database.DB.Where("staff_id = ? AND type = ?", req.StaffID, req.Permission).First(&existing).Error
+	// Outlet of the staff member, for the audit trail
+	var outletID *int
+	var staffInfo models.StaffDetails
+	if ctrl.DB.WithContext(c.Request.Context()).Preload("User").First(&staffInfo, req.StaffID).Error == nil {
+		outletID = staffInfo.User.OutletID
+	}
 
-	if err == nil {
-		// Update existing
-		database.DB.Model(&existing).Update("is_granted", req.Grant)
-		message := "granted"
-		if !req.Grant {
-			message = "revoked"
-		}
-		c.JSON(http.StatusOK, gin.H{
-			"message":    fmt.Sprintf("Permission %s", message),
-			"permission": existing,
-		})
-	} else {
-		// Create new
-		perm := models.StaffPermission{
-			StaffID:   req.StaffID,
-			Type:      models.PermissionType(req.Permission),
-			IsGranted: req.Grant,
-		}
-		database.DB.Create(&perm)
-		message := "granted"
-		if !req.Grant {
-			message = "revoked"
+	// Update or create the permission with its audit entry
+	var perm models.StaffPermission
+	err := ctrl.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		before := gin.H{}
+		existing, err := repository.Users(tx).StaffPermission(req.StaffID, models.PermissionType(req.Permission))
+		switch {
+		case err == nil:
+			before[req.Permission] = existing.IsGranted
+			if err := repository.Users(tx).SetPermissionGranted(&existing, req.Grant); err != nil {
+				return err
+			}
+			perm = existing
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return err
+		default:
+			perm = models.StaffPermission{
+				StaffID:   req.StaffID,
+				Type:      models.PermissionType(req.Permission),
+				IsGranted: req.Grant,
+			}
+			if err := tx.Create(&perm).Error; err != nil {
+				return err
+			}
 		}
-		c.JSON(http.StatusOK, gin.H{
-			"message":    fmt.Sprintf("Permission %s", message),
-			"permission": perm,
+
+		return audit.Record(c, tx, audit.Entry{
+			Action:     audit.ActionStaffPermissionsUpdate,
+			EntityType: audit.EntityStaff,
+			EntityID:   req.StaffID,
+			OutletID:   outletID,
+			Before:     before,
+			After:      gin.H{req.Permission: req.Grant},
 		})
+	})
+	if err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
+		return
+	}
+
+	message := "granted"
+	if !req.Grant {
+		message = "revoked"
 	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":    fmt.Sprintf("Permission %s", message),
+		"permission": perm,
+	})
 }
 
-// GetOutletStaff returns all staff for an outlet
-func GetOutletStaff(c *gin.Context) {
+// OutletStaffList lists the sorts and filters accepted by GetOutletStaff.
+// StaffDetails has no creation time, so pages follow the staff id.
+var OutletStaffList = pagination.Spec{
+	DefaultLimit: 50,
+	MaxLimit:     200,
+	DefaultSort:  "id",
+	Sorts: map[string]pagination.Sort{
+		"id": {Column: `"StaffDetails".id`, Field: "ID", Type: pagination.TypeInt},
+	},
+	Filters: map[string]pagination.Filter{
+		"staffRole": {Column: `"StaffDetails"."staffRole"`, Type: pagination.TypeString},
+	},
+	IDColumn: `"StaffDetails".id`,
+}
+
+// GetOutletStaff returns a page of an outlet's staff
+func (ctrl *Controller) GetOutletStaff(c *gin.Context) {
 	outletIDStr := c.Param("outletId")
 	outletID, err := strconv.Atoi(outletIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid outlet ID"})
+		apperror.Abort(c, apperror.BadRequest("Invalid outlet ID"))
+		return
+	}
+
+	list, err := pagination.Parse(c, OutletStaffList)
+	if err != nil {
+		apperror.Abort(c, err)
 		return
 	}
 
 	var staffDetails []models.StaffDetails
-	database.DB.Joins(`JOIN "User" ON "User".id = "StaffDetails"."userId"`).
-		Where(`"User"."outletId" = ? AND "User".role = ?`, outletID, models.RoleStaff).
+	if err := list.Apply(ctrl.DB.WithContext(c.Request.Context()).Joins(`JOIN "User" ON "User".id = "StaffDetails"."userId"`).
+		Where(`"User"."outletId" = ? AND "User".role = ?`, outletID, models.RoleStaff)).
 		Preload("User").
 		Preload("Permissions").
-		Find(&staffDetails)
+		Find(&staffDetails).Error; err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
+		return
+	}
+	staffDetails, meta := pagination.Trim(list, staffDetails)
 
 	// Get signed URLs for images
 	staffsWithSignedURLs := make([]gin.H, len(staffDetails))
 	for i, staff := range staffDetails {
 		imageURL := ""
 		if staff.User.ImageURL != nil {
-			signedURL, _ := services.GetSignedURL(*staff.User.ImageURL)
+			signedURL, _ := ctrl.Storage.GetSignedURL(*staff.User.ImageURL)
 			imageURL = signedURL
 		}
 
@@ -186,22 +239,22 @@ func GetOutletStaff(c *gin.Context) {
 		}
 	}
 
-	c.JSON(http.StatusOK, gin.H{"staffs": staffsWithSignedURLs})
+	c.JSON(http.StatusOK, gin.H{"staffs": staffsWithSignedURLs, "pagination": meta})
 }
 
 // OutletUpdateStaff updates staff member details
-func OutletUpdateStaff(c *gin.Context) {
+func (ctrl *Controller) OutletUpdateStaff(c *gin.Context) {
 	staffIDStr := c.Param("staffId")
 	staffID, err := strconv.Atoi(staffIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid staff ID"})
+		apperror.Abort(c, apperror.BadRequest("Invalid staff ID"))
 		return
 	}
 
 	// Get staff details
 	var staffDetails models.StaffDetails
-	if err := database.DB.Preload("User").First(&staffDetails, staffID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"message": "Staff member not found"})
+	if err := ctrl.DB.WithContext(c.Request.Context()).Preload("User").First(&staffDetails, staffID).Error; err != nil {
+		apperror.Abort(c, apperror.NotFound("Staff member not found"))
 		return
 	}
 
@@ -211,21 +264,17 @@ func OutletUpdateStaff(c *gin.Context) {
 	phone := c.PostForm("phone")
 	staffRole := c.PostForm("staffRole")
 
-	imageURL := staffDetails.User.ImageURL
+	previousImage := staffDetails.User.ImageURL
+	imageURL := previousImage
 
 	// Handle image upload
 	file, _, err := c.Request.FormFile("image")
 	if err == nil {
 		defer file.Close()
 
-		// Delete old image
-		if imageURL != nil {
-			services.DeleteImage(*imageURL)
-		}
-
 		// Upload new image
 		fileBytes, _ := io.ReadAll(file)
-		newImageURL, uploadErr := services.UploadImage(fileBytes, "staff-image.jpg")
+		newImageURL, uploadErr := ctrl.Storage.UploadImage(c.Request.Context(), fileBytes, "staff-image.jpg")
 		if uploadErr == nil {
 			imageURL = &newImageURL
 		}
@@ -240,21 +289,46 @@ func OutletUpdateStaff(c *gin.Context) {
 		updates["email"] = email
 	}
 	if phone != "" {
-		updates["phone"] = phone
+		normalized, err := utils.NormalizePhone(ctrl.Config, phone)
+		if err != nil {
+			apperror.Abort(c, apperror.BadRequest("Invalid phone number"))
+			return
+		}
+		var other models.User
+		if ctrl.DB.WithContext(c.Request.Context()).Where("phone = ? AND id <> ?", normalized, staffDetails.UserID).First(&other).Error == nil {
+			apperror.Abort(c, apperror.BadRequest("Phone number is already registered"))
+			return
+		}
+		updates["phone"] = normalized
 	}
 	if imageURL != nil {
 		updates["imageUrl"] = *imageURL
 	}
 
-	database.DB.Model(&staffDetails.User).Updates(updates)
+	// A replaced image is deleted once the update commits
+	if err := ctrl.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&staffDetails.User).Updates(updates).Error; err != nil {
+			return err
+		}
+		if imageURL != previousImage {
+			return media.QueueDelete(tx, previousImage)
+		}
+		return nil
+	}); err != nil {
+		apperror.Abort(c, apperror.Internal("Failed to update staff", err))
+		return
+	}
 
 	// Update staff role
 	if staffRole != "" {
-		database.DB.Model(&staffDetails).Update("staff_role", staffRole)
+		if err := repository.Users(ctrl.DB.WithContext(c.Request.Context())).SetStaffRole(&staffDetails, staffRole); err != nil {
+			apperror.Abort(c, apperror.Internal("Failed to update staff", err))
+			return
+		}
 	}
 
 	// Reload
-	database.DB.Preload("User").First(&staffDetails, staffID)
+	ctrl.DB.WithContext(c.Request.Context()).Preload("User").First(&staffDetails, staffID)
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Staff updated successfully",
@@ -263,52 +337,59 @@ func OutletUpdateStaff(c *gin.Context) {
 }
 
 // OutletDeleteStaff deletes a staff member
-func OutletDeleteStaff(c *gin.Context) {
+func (ctrl *Controller) OutletDeleteStaff(c *gin.Context) {
 	staffIDStr := c.Param("staffId")
 	staffID, err := strconv.Atoi(staffIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid staff ID"})
+		apperror.Abort(c, apperror.BadRequest("Invalid staff ID"))
 		return
 	}
 
 	var staffDetails models.StaffDetails
-	if err := database.DB.Preload("User").First(&staffDetails, staffID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"message": "Staff member not found"})
+	if err := ctrl.DB.WithContext(c.Request.Context()).Preload("User").First(&staffDetails, staffID).Error; err != nil {
+		apperror.Abort(c, apperror.NotFound("Staff member not found"))
 		return
 	}
 
 	// Delete in transaction
-	database.DB.Transaction(func(tx *gorm.DB) error {
+	err = ctrl.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
 		// Delete permissions
-		tx.Where("staff_id = ?", staffID).Delete(&models.StaffPermission{})
+		if err := repository.Users(tx).DeleteStaffPermissions(staffID); err != nil {
+			return err
+		}
 		// Delete staff details
-		tx.Delete(&staffDetails)
+		if err := tx.Delete(&staffDetails).Error; err != nil {
+			return err
+		}
 		// Delete user
-		tx.Delete(&staffDetails.User)
-		return nil
+		return tx.Delete(&staffDetails.User).Error
 	})
+	if err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Staff member deleted successfully"})
 }
 
 // GetStaffById returns single staff details
-func GetStaffById(c *gin.Context) {
+func (ctrl *Controller) GetStaffById(c *gin.Context) {
 	staffIDStr := c.Param("staffId")
 	staffID, err := strconv.Atoi(staffIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid staff ID"})
+		apperror.Abort(c, apperror.BadRequest("Invalid staff ID"))
 		return
 	}
 
 	var staff models.StaffDetails
-	if err := database.DB.Preload("User").Preload("Permissions").First(&staff, staffID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"message": "Staff member not found"})
+	if err := ctrl.DB.WithContext(c.Request.Context()).Preload("User").Preload("Permissions").First(&staff, staffID).Error; err != nil {
+		apperror.Abort(c, apperror.NotFound("Staff member not found"))
 		return
 	}
 
 	// Get signed URL for image
 	if staff.User.ImageURL != nil {
-		signedURL, _ := services.GetSignedURL(*staff.User.ImageURL)
+		signedURL, _ := ctrl.Storage.GetSignedURL(*staff.User.ImageURL)
 		staff.User.ImageURL = &signedURL
 	}
 
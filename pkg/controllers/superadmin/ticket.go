@@ -1,8 +1,10 @@
 package superadmin
 
 import (
-	"backend_pandhi/pkg/database"
+	"backend_pandhi/pkg/apperror"
+	"backend_pandhi/pkg/dto"
 	"backend_pandhi/pkg/models"
+	"backend_pandhi/pkg/pagination"
 	"net/http"
 	"strconv"
 	"time"
@@ -10,71 +12,112 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-// GetTickets returns all tickets for an outlet with customer details
-func GetTickets(c *gin.Context) {
+// OutletTicketsList lists the sorts and filters accepted by GetTickets
+var OutletTicketsList = pagination.Spec{
+	DefaultLimit: 50,
+	MaxLimit:     200,
+	DefaultSort:  "-createdAt",
+	Sorts: map[string]pagination.Sort{
+		"createdAt": {Column: `"Ticket"."createdAt"`, Field: "CreatedAt", Type: pagination.TypeTime},
+	},
+	Filters: map[string]pagination.Filter{
+		"status": {
+			Column: `"Ticket".status`,
+			Type:   pagination.TypeString,
+			Ops:    []pagination.Op{pagination.OpEq, pagination.OpIn},
+			Values: []string{string(models.TicketStatusOpen), string(models.TicketStatusInProgress), string(models.TicketStatusClosed)},
+		},
+		"priority": {
+			Column: `"Ticket".priority`,
+			Type:   pagination.TypeString,
+			Ops:    []pagination.Op{pagination.OpEq, pagination.OpIn},
+			Values: []string{string(models.PriorityLow), string(models.PriorityMedium), string(models.PriorityHigh)},
+		},
+		"createdAt": {Column: `"Ticket"."createdAt"`, Type: pagination.TypeTime, Ops: pagination.Range},
+		"internal":  {Column: `"Ticket".internal`, Type: pagination.TypeBool},
+	},
+	IDColumn: `"Ticket".id`,
+}
+
+// GetTickets returns a page of an outlet's tickets with customer details
+func (ctrl *Controller) GetTickets(c *gin.Context) {
 	outletIDStr := c.Param("outletId")
 	outletID, err := strconv.Atoi(outletIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Provide valid OutletId"})
+		apperror.Abort(c, apperror.BadRequest("Provide valid OutletId"))
 		return
 	}
 
-	// Get customers with tickets
-	var users []models.User
-	database.DB.Where(`"outletId" = ? AND role = ?`, outletID, models.RoleCustomer).
-		Preload("CustomerInfo.Tickets").
-		Find(&users)
+	list, err := pagination.Parse(c, OutletTicketsList)
+	if err != nil {
+		apperror.Abort(c, err)
+		return
+	}
 
-	// Flatten tickets
-	allTickets := []gin.H{}
-	for _, user := range users {
-		if user.CustomerInfo != nil {
-			for _, ticket := range user.CustomerInfo.Tickets {
-				allTickets = append(allTickets, gin.H{
-					"ticketId":       ticket.ID,
-					"description":    ticket.Description,
-					"priority":       ticket.Priority,
-					"status":         ticket.Status,
-					"createdAt":      ticket.CreatedAt,
-					"customerName":   user.Name,
-					"customerEmail":  user.Email,
-					"resolutionNote": ticket.ResolutionNote,
-					"resolvedAt":     ticket.ResolvedAt,
-				})
-			}
+	var tickets []models.Ticket
+	if err := list.Apply(ctrl.DB.WithContext(c.Request.Context()).
+		Joins(`JOIN "CustomerDetails" ON "CustomerDetails".id = "Ticket"."customerId"`).
+		Joins(`JOIN "User" ON "User".id = "CustomerDetails"."userId"`).
+		Where(`"User"."outletId" = ? AND "User".role = ?`, outletID, models.RoleCustomer)).
+		Preload("Customer.User").
+		Find(&tickets).Error; err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
+		return
+	}
+	tickets, meta := pagination.Trim(list, tickets)
+
+	allTickets := make([]gin.H, len(tickets))
+	for i, ticket := range tickets {
+		allTickets[i] = gin.H{
+			"ticketId":       ticket.ID,
+			"description":    ticket.Description,
+			"priority":       ticket.Priority,
+			"status":         ticket.Status,
+			"createdAt":      ticket.CreatedAt,
+			"customerName":   ticket.Customer.User.Name,
+			"customerEmail":  ticket.Customer.User.Email,
+			"resolutionNote": ticket.ResolutionNote,
+			"resolvedAt":     ticket.ResolvedAt,
+			"internal":       ticket.Internal,
+			"feedbackId":     ticket.FeedbackID,
+			"orderId":        ticket.OrderID,
 		}
 	}
 
-	c.JSON(http.StatusOK, gin.H{"tickets": allTickets})
+	c.JSON(http.StatusOK, gin.H{
+		"tickets":    allTickets,
+		"pagination": meta,
+	})
 }
 
 // TicketClose closes a ticket with resolution note
-func TicketClose(c *gin.Context) {
-	var req struct {
-		TicketID       int    `json:"ticketId" binding:"required"`
-		ResolutionNote string `json:"resolutionNote" binding:"required"`
-		ResolvedAt     string `json:"resolvedAt" binding:"required"`
-	}
+func (ctrl *Controller) TicketClose(c *gin.Context) {
+	var req dto.CloseTicketRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Provide ticketId, resolutionNote, and resolvedAt"})
+		apperror.Abort(c, apperror.Binding(err, "Provide ticketId, resolutionNote, and resolvedAt"))
 		return
 	}
 
-	resolvedTime, _ := time.Parse(time.RFC3339, req.ResolvedAt)
+	p := apperror.NewParser()
+	resolvedTime := p.Time("resolvedAt", time.RFC3339, req.ResolvedAt)
+	if err := p.Err("Invalid resolvedAt"); err != nil {
+		apperror.Abort(c, err)
+		return
+	}
 
 	// Update ticket
-	if err := database.DB.Model(&models.Ticket{}).Where("id = ?", req.TicketID).Updates(map[string]interface{}{
+	if err := ctrl.DB.WithContext(c.Request.Context()).Model(&models.Ticket{}).Where("id = ?", req.TicketID).Updates(map[string]interface{}{
 		"status":         models.TicketStatusClosed,
 		"resolutionNote": req.ResolutionNote,
 		"resolvedAt":     resolvedTime,
 	}).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal server error"})
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
 		return
 	}
 
 	var ticket models.Ticket
-	database.DB.First(&ticket, req.TicketID)
+	ctrl.DB.WithContext(c.Request.Context()).First(&ticket, req.TicketID)
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Ticket closed successfully",
@@ -1,29 +1,39 @@
 package superadmin
 
 import (
-	"backend_pandhi/pkg/database"
+	"backend_pandhi/pkg/apperror"
 	"backend_pandhi/pkg/models"
+	"backend_pandhi/pkg/pagination"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
 // GetCustomersWithWallet returns customers with wallet details
-func GetCustomersWithWallet(c *gin.Context) {
+func (ctrl *Controller) GetCustomersWithWallet(c *gin.Context) {
 	outletIDStr := c.Param("outletId")
 	outletID, err := strconv.Atoi(outletIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Provide outletId"})
+		apperror.Abort(c, apperror.BadRequest("Provide outletId"))
+		return
+	}
+
+	list, err := pagination.Parse(c, OutletCustomersList)
+	if err != nil {
+		apperror.Abort(c, err)
 		return
 	}
 
 	var users []models.User
-	database.DB.Where(`role = ? AND "outletId" = ?`, models.RoleCustomer, outletID).
+	if err := list.Apply(ctrl.DB.WithContext(c.Request.Context()).Where(`"User".role = ? AND "User"."outletId" = ?`, models.RoleCustomer, outletID)).
 		Preload("CustomerInfo.Wallet").
-		Find(&users)
+		Find(&users).Error; err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
+		return
+	}
+	users, meta := pagination.Trim(list, users)
 
 	formatted := make([]gin.H, len(users))
 	for i, user := range users {
@@ -63,67 +73,116 @@ func GetCustomersWithWallet(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Customers with wallet fetched successfully",
-		"count":   len(formatted),
-		"data":    formatted,
+		"message":    "Customers with wallet fetched successfully",
+		"count":      len(formatted),
+		"data":       formatted,
+		"pagination": meta,
 	})
 }
 
-// GetRechargeHistoryByOutlet returns recharge history for outlet
-func GetRechargeHistoryByOutlet(c *gin.Context) {
+// OutletRechargeList lists the sorts and filters accepted by GetRechargeHistoryByOutlet
+var OutletRechargeList = pagination.Spec{
+	DefaultLimit: 50,
+	MaxLimit:     200,
+	DefaultSort:  "-createdAt",
+	Sorts: map[string]pagination.Sort{
+		"createdAt": {Column: `"WalletTransaction"."createdAt"`, Field: "CreatedAt", Type: pagination.TypeTime},
+		"amount":    {Column: `"WalletTransaction".amount`, Field: "Amount", Type: pagination.TypeFloat},
+	},
+	Filters: map[string]pagination.Filter{
+		"status":    {Column: `"WalletTransaction".status`, Type: pagination.TypeString, Ops: []pagination.Op{pagination.OpEq, pagination.OpIn}},
+		"method":    {Column: `"WalletTransaction".method`, Type: pagination.TypeString, Ops: []pagination.Op{pagination.OpEq, pagination.OpIn}},
+		"createdAt": {Column: `"WalletTransaction"."createdAt"`, Type: pagination.TypeTime, Ops: pagination.Range},
+		"amount":    {Column: `"WalletTransaction".amount`, Type: pagination.TypeFloat, Ops: pagination.Range},
+	},
+	IDColumn: `"WalletTransaction".id`,
+}
+
+// GetRechargeHistoryByOutlet returns a page of wallet transactions for an outlet's customers
+func (ctrl *Controller) GetRechargeHistoryByOutlet(c *gin.Context) {
 	outletIDStr := c.Param("outletId")
 	outletID, err := strconv.Atoi(outletIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Provide outletId"})
+		apperror.Abort(c, apperror.BadRequest("Provide outletId"))
 		return
 	}
 
-	var users []models.User
-	database.DB.Where(`role = ? AND "outletId" = ?`, models.RoleCustomer, outletID).
-		Preload("CustomerInfo.Wallet.Transactions", func(db *gorm.DB) *gorm.DB {
-			return db.Order(`"createdAt" DESC`)
-		}).
-		Find(&users)
-
-	history := []gin.H{}
-	for _, user := range users {
-		if user.CustomerInfo != nil && user.CustomerInfo.Wallet != nil {
-			for _, txn := range user.CustomerInfo.Wallet.Transactions {
-				history = append(history, gin.H{
-					"customerName": user.Name,
-					"rechargeId":   txn.ID,
-					"amount":       txn.Amount,
-					"date":         txn.CreatedAt,
-					"method":       txn.Method,
-					"status":       txn.Status,
-				})
-			}
+	list, err := pagination.Parse(c, OutletRechargeList)
+	if err != nil {
+		apperror.Abort(c, err)
+		return
+	}
+
+	var txns []models.WalletTransaction
+	if err := list.Apply(ctrl.DB.WithContext(c.Request.Context()).
+		Joins(`JOIN "Wallet" ON "Wallet".id = "WalletTransaction"."walletId"`).
+		Joins(`JOIN "CustomerDetails" ON "CustomerDetails".id = "Wallet"."customerId"`).
+		Joins(`JOIN "User" ON "User".id = "CustomerDetails"."userId"`).
+		Where(`"User".role = ? AND "User"."outletId" = ?`, models.RoleCustomer, outletID)).
+		Preload("Wallet.Customer.User").
+		Find(&txns).Error; err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
+		return
+	}
+	txns, meta := pagination.Trim(list, txns)
+
+	history := make([]gin.H, len(txns))
+	for i, txn := range txns {
+		history[i] = gin.H{
+			"customerName": txn.Wallet.Customer.User.Name,
+			"rechargeId":   txn.ID,
+			"amount":       txn.Amount,
+			"date":         txn.CreatedAt,
+			"method":       txn.Method,
+			"status":       txn.Status,
 		}
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Recharge history fetched successfully",
-		"count":   len(history),
-		"data":    history,
+		"message":    "Recharge history fetched successfully",
+		"count":      len(history),
+		"data":       history,
+		"pagination": meta,
 	})
 }
 
-// GetOrdersPaidViaWallet returns all wallet-paid orders
-func GetOrdersPaidViaWallet(c *gin.Context) {
+// WalletOrdersList lists the sorts and filters accepted by GetOrdersPaidViaWallet
+var WalletOrdersList = pagination.Spec{
+	DefaultLimit: 50,
+	MaxLimit:     200,
+	DefaultSort:  "-createdAt",
+	Sorts: map[string]pagination.Sort{
+		"createdAt":   {Column: `"createdAt"`, Field: "CreatedAt", Type: pagination.TypeTime},
+		"totalAmount": {Column: `"totalAmount"`, Field: "TotalAmount", Type: pagination.TypeFloat},
+	},
+	Filters: map[string]pagination.Filter{
+		"outletId":  {Column: `"outletId"`, Type: pagination.TypeInt},
+		"createdAt": {Column: `"createdAt"`, Type: pagination.TypeTime, Ops: pagination.Range},
+	},
+}
+
+// GetOrdersPaidViaWallet returns a page of wallet-paid orders
+func (ctrl *Controller) GetOrdersPaidViaWallet(c *gin.Context) {
+	list, err := pagination.Parse(c, WalletOrdersList)
+	if err != nil {
+		apperror.Abort(c, err)
+		return
+	}
+
 	var orders []models.Order
-	database.DB.Where(`"paymentMethod" = ?`, models.PaymentMethodWallet).
+	if err := list.Apply(ctrl.DB.WithContext(c.Request.Context()).Where(`"paymentMethod" = ?`, models.PaymentMethodWallet)).
 		Preload("Customer.User").
-		Order(`"createdAt" DESC`).
-		Find(&orders)
+		Find(&orders).Error; err != nil {
+		apperror.Abort(c, apperror.Internal("Internal server error", err))
+		return
+	}
+	orders, meta := pagination.Trim(list, orders)
 
 	result := make([]gin.H, len(orders))
 	for i, order := range orders {
 		customerName := "Unknown"
-		if order.Customer != nil {
-			database.DB.Preload("User").First(&order.Customer, order.Customer.ID)
-			if order.Customer.User.ID > 0 {
-				customerName = order.Customer.User.Name
-			}
+		if order.Customer != nil && order.Customer.User.ID > 0 {
+			customerName = order.Customer.User.Name
 		}
 
 		result[i] = gin.H{
@@ -136,8 +195,9 @@ func GetOrdersPaidViaWallet(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Orders paid via wallet fetched successfully",
-		"count":   len(result),
-		"data":    result,
+		"message":    "Orders paid via wallet fetched successfully",
+		"count":      len(result),
+		"data":       result,
+		"pagination": meta,
 	})
 }
@@ -2,173 +2,70 @@ package database
 
 import (
 	"backend_pandhi/pkg/config"
-	"backend_pandhi/pkg/models"
+	"backend_pandhi/pkg/logging"
+	"backend_pandhi/pkg/metrics"
+	"backend_pandhi/pkg/tracing"
 	"fmt"
-	"log"
+	"log/slog"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
 )
 
-var DB *gorm.DB
-
-// InitDatabase initializes the database connection
-func InitDatabase() error {
-	var err error
-
-	// Configure GORM logger
+// Open connects to the database at cfg.DatabaseURL
+func Open(cfg *config.Config) (*gorm.DB, error) {
+	// GORM logs through slog: statements at debug (LOG_LEVEL=debug to see
+	// them), slow queries at warn and failures at error, all redacted
 	gormConfig := &gorm.Config{
-		Logger:      logger.Default.LogMode(logger.Info),
+		Logger:      logging.NewGormLogger(),
 		PrepareStmt: false,
 	}
 
-	// Development mode - verbose logging
-	if config.IsDevelopment() {
-		gormConfig.Logger = logger.Default.LogMode(logger.Info)
-	} else {
-		// Production mode - only errors
-		gormConfig.Logger = logger.Default.LogMode(logger.Error)
-	}
-
 	// Connect to PostgreSQL with implicit prepared statements disabled
-	DB, err = gorm.Open(postgres.New(postgres.Config{
-		DSN:                  config.AppConfig.DatabaseURL,
+	db, err := gorm.Open(postgres.New(postgres.Config{
+		DSN:                  cfg.DatabaseURL,
 		PreferSimpleProtocol: true, // Disable implicit prepared statements to avoid "prepared statement already exists" errors
 	}), gormConfig)
 	if err != nil {
-		return fmt.Errorf("failed to connect to database: %w", err)
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
 	// Get underlying SQL database
-	sqlDB, err := DB.DB()
+	sqlDB, err := db.DB()
 	if err != nil {
-		return fmt.Errorf("failed to get database instance: %w", err)
+		return nil, fmt.Errorf("failed to get database instance: %w", err)
 	}
 
 	// Set connection pool settings (matching Express.js pg-pool behavior)
 	sqlDB.SetMaxOpenConns(25)
 	sqlDB.SetMaxIdleConns(5)
 
-	log.Println("✅ Database connection established")
-
-	return nil
-}
-
-// AutoMigrate runs auto-migration for all models
-func AutoMigrate() error {
-	log.Println("🔄 Running database migrations...")
-
-	err := DB.AutoMigrate(
-		// Core models
-		&models.Outlet{},
-		&models.User{},
-		&models.CustomerDetails{},
-		&models.StaffDetails{},
-		&models.StaffPermission{},
-
-		// Product & Inventory
-		&models.Product{},
-		&models.Inventory{},
-		&models.StockHistory{},
-
-		// Cart
-		&models.Cart{},
-		&models.CartItem{},
-
-		// Orders
-		&models.Order{},
-		&models.OrderItem{},
-
-		// Wallet
-		&models.Wallet{},
-		&models.WalletTransaction{},
-
-		// Admin
-		&models.Admin{},
-		&models.AdminOutlet{},
-		&models.AdminPermission{},
-
-		// Support
-		&models.Ticket{},
-		&models.Coupon{},
-		&models.CouponUsage{},
-		&models.Expense{},
-
-		// Notifications
-		&models.Notification{},
-		&models.ScheduledNotification{},
-		&models.NotificationDelivery{},
-		&models.UserDeviceToken{},
-
-		// Outlet Management
-		&models.OutletAvailability{},
-		&models.OutletAppManagement{},
-
-		// Feedback & Quota
-		&models.Feedback{},
-		&models.UserFreeQuota{},
-	)
-
-	if err != nil {
-		return fmt.Errorf("failed to run migrations: %w", err)
+	// Query latency and pool statistics for /metrics, and a span per query
+	if err := db.Use(metrics.GormPlugin{}); err != nil {
+		return nil, fmt.Errorf("failed to install metrics plugin: %w", err)
+	}
+	if err := db.Use(tracing.GormPlugin{}); err != nil {
+		return nil, fmt.Errorf("failed to install tracing plugin: %w", err)
+	}
+	if err := metrics.RegisterDBStats(sqlDB); err != nil {
+		return nil, fmt.Errorf("failed to register database metrics: %w", err)
 	}
 
-	log.Println("✅ Database migrations completed")
-
-	// Create indexes matching Prisma schema
-	createIndexes()
-
-	return nil
-}
-
-// createIndexes creates additional indexes to match Prisma schema
-func createIndexes() {
-	log.Println("🔄 Creating additional indexes...")
-
-	// Product indexes
-	DB.Exec(`CREATE INDEX IF NOT EXISTS "idx_product_isveg" ON "Product"("isVeg")`)
-
-	// OutletAvailability indexes
-	DB.Exec(`CREATE INDEX IF NOT EXISTS "OutletAvailability_outletId_date_idx" ON "OutletAvailability"("outletId", "date")`)
-
-	// NotificationDelivery indexes
-	DB.Exec(`CREATE INDEX IF NOT EXISTS "NotificationDelivery_scheduledNotificationId_status_idx" ON "NotificationDelivery"("scheduledNotificationId", "status")`)
-	DB.Exec(`CREATE INDEX IF NOT EXISTS "NotificationDelivery_userId_status_idx" ON "NotificationDelivery"("userId", "status")`)
-
-	// Feedback indexes
-	DB.Exec(`CREATE INDEX IF NOT EXISTS "Feedback_userId_idx" ON "Feedback"("userId")`)
-	DB.Exec(`CREATE INDEX IF NOT EXISTS "Feedback_productId_idx" ON "Feedback"("productId")`)
-
-	// UserFreeQuota indexes
-	DB.Exec(`CREATE INDEX IF NOT EXISTS "UserFreeQuota_userId_consumptionDate_idx" ON "UserFreeQuota"("userId", "consumptionDate")`)
-
-	// OutletAppManagement index
-	DB.Exec(`CREATE INDEX IF NOT EXISTS "OutletAppManagement_outletId_feature_idx" ON "OutletAppManagement"("outletId", "feature")`)
-
-	// Unique constraints
-	DB.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS "AdminOutlet_adminId_outletId_key" ON "AdminOutlet"("adminId", "outletId")`)
-	DB.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS "AdminPermission_adminOutletId_type_key" ON "AdminPermission"("adminOutletId", "type")`)
-	DB.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS "CartItem_cartId_productId_key" ON "CartItem"("cartId", "productId")`)
-	DB.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS "Feedback_orderId_productId_key" ON "Feedback"("orderId", "productId")`)
-	DB.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS "NotificationDelivery_scheduledNotificationId_userId_devi_key" ON "NotificationDelivery"("scheduledNotificationId", "userId", "deviceToken")`)
-	DB.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS "OutletAppManagement_outletId_feature_key" ON "OutletAppManagement"("outletId", "feature")`)
-	DB.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS "OutletAvailability_outletId_date_key" ON "OutletAvailability"("outletId", "date")`)
-	DB.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS "UserFreeQuota_userId_consumptionDate_key" ON "UserFreeQuota"("userId", "consumptionDate")`)
+	slog.Info("Database connection established")
 
-	log.Println("✅ Additional indexes created")
+	return db, nil
 }
 
-// CloseDatabase closes the database connection
-func CloseDatabase() {
-	sqlDB, err := DB.DB()
+// Close closes the database connection
+func Close(db *gorm.DB) {
+	sqlDB, err := db.DB()
 	if err != nil {
-		log.Printf("Error getting database instance: %v", err)
+		slog.Error("Failed to get database instance", "error", err)
 		return
 	}
 	if err := sqlDB.Close(); err != nil {
-		log.Printf("Error closing database: %v", err)
+		slog.Error("Failed to close database", "error", err)
 	} else {
-		log.Println("✅ Database connection closed")
+		slog.Info("Database connection closed")
 	}
 }
@@ -0,0 +1,224 @@
+package dto
+
+import (
+	"backend_pandhi/pkg/models"
+)
+
+// CustomerSignupRequest registers a customer account
+type CustomerSignupRequest struct {
+	Name           string `json:"name" binding:"required"`
+	Email          string `json:"email" binding:"required,email"`
+	Password       string `json:"password" binding:"required"`
+	RetypePassword string `json:"retypePassword" binding:"required"`
+	OutletID       int    `json:"outletId" binding:"required"`
+	Phone          string `json:"phone" binding:"required"`
+	YearOfStudy    *int   `json:"yearOfStudy"`
+}
+
+// SignupRequest registers a staff or admin account pending verification
+type SignupRequest struct {
+	Name           string `json:"name" binding:"required"`
+	Email          string `json:"email" binding:"required,email"`
+	Password       string `json:"password" binding:"required"`
+	RetypePassword string `json:"retypePassword" binding:"required"`
+	Phone          string `json:"phone"`
+}
+
+// SignInRequest is an email and password login
+type SignInRequest struct {
+	Email    string `json:"email" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// GoogleSignInRequest exchanges a Google ID token for a session. OutletID is
+// required when the Google account has no customer account yet.
+type GoogleSignInRequest struct {
+	IDToken     string `json:"idToken" binding:"required"`
+	OutletID    *int   `json:"outletId"`
+	YearOfStudy *int   `json:"yearOfStudy"`
+}
+
+// LoginOTPRequest asks for a one-time login code by SMS
+type LoginOTPRequest struct {
+	Phone string `json:"phone" binding:"required"`
+}
+
+// VerifyLoginOTPRequest signs in with a one-time login code
+type VerifyLoginOTPRequest struct {
+	Phone string `json:"phone" binding:"required"`
+	Code  string `json:"code" binding:"required"`
+}
+
+// PasswordResetRequest asks for a password reset email
+type PasswordResetRequest struct {
+	Email       string                 `json:"email" binding:"required,email"`
+	AccountType models.AuthAccountType `json:"accountType" doc:"Defaults to USER"`
+}
+
+// PasswordResetConfirmRequest sets a new password with a reset token
+type PasswordResetConfirmRequest struct {
+	Token          string `json:"token" binding:"required"`
+	Password       string `json:"password" binding:"required"`
+	RetypePassword string `json:"retypePassword" binding:"required"`
+}
+
+// EmailVerificationRequest confirms an email address with a verification token
+type EmailVerificationRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// ResendEmailVerificationRequest asks for a new verification email
+type ResendEmailVerificationRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// CustomerLoginResponse is returned when a customer signs up or signs in.
+// The token is also set as the token cookie; it is included in the body only
+// when mobile token return is enabled.
+type CustomerLoginResponse struct {
+	Message string          `json:"message"`
+	User    CustomerAccount `json:"user"`
+	Token   string          `json:"token,omitempty"`
+}
+
+// CustomerAccount is the signed-in customer
+type CustomerAccount struct {
+	ID              int                     `json:"id"`
+	Name            string                  `json:"name"`
+	Email           string                  `json:"email"`
+	Phone           *string                 `json:"phone"`
+	Role            models.Role             `json:"role"`
+	OutletID        *int                    `json:"outletId"`
+	Outlet          *models.Outlet          `json:"outlet"`
+	ImageURL        *string                 `json:"imageUrl" doc:"Signed URL"`
+	IsVerified      bool                    `json:"isVerified"`
+	CustomerDetails *CustomerAccountDetails `json:"customerDetails,omitempty"`
+}
+
+// CustomerAccountDetails is the customer profile returned with the account
+type CustomerAccountDetails struct {
+	ID          int            `json:"id"`
+	YearOfStudy *int           `json:"yearOfStudy"`
+	Wallet      *models.Wallet `json:"wallet"`
+	Cart        *models.Cart   `json:"cart"`
+}
+
+// StaffSignupResponse is returned when a staff member signs up
+type StaffSignupResponse struct {
+	Message           string            `json:"message"`
+	User              StaffAccount      `json:"user"`
+	DocumentsUploaded DocumentsUploaded `json:"documentsUploaded"`
+}
+
+// StaffLoginResponse is returned when a staff member signs in
+type StaffLoginResponse struct {
+	Message string       `json:"message"`
+	User    StaffAccount `json:"user"`
+	Token   string       `json:"token"`
+}
+
+// StaffAccount is a staff user
+type StaffAccount struct {
+	ID           int                  `json:"id"`
+	Name         string               `json:"name"`
+	Email        string               `json:"email"`
+	Phone        *string              `json:"phone"`
+	Role         models.Role          `json:"role"`
+	OutletID     *int                 `json:"outletId"`
+	Outlet       *models.Outlet       `json:"outlet"`
+	ImageURL     *string              `json:"imageUrl,omitempty" doc:"Signed URL; sign-in only"`
+	IsVerified   bool                 `json:"isVerified,omitempty" doc:"Signup only"`
+	StaffDetails *StaffAccountDetails `json:"staffDetails,omitempty"`
+}
+
+// StaffAccountDetails is the staff profile returned with the account
+type StaffAccountDetails struct {
+	ID          int                      `json:"id"`
+	StaffRole   string                   `json:"staffRole"`
+	AadharURL   *string                  `json:"aadharUrl,omitempty" doc:"Signup only"`
+	PanURL      *string                  `json:"panUrl,omitempty" doc:"Signup only"`
+	Permissions []models.StaffPermission `json:"permissions"`
+}
+
+// DocumentsUploaded reports which identity documents came with a signup
+type DocumentsUploaded struct {
+	Aadhar bool `json:"aadhar"`
+	Pan    bool `json:"pan"`
+}
+
+// AdminSignupResponse is returned when an admin signs up
+type AdminSignupResponse struct {
+	Message           string            `json:"message"`
+	AdminID           int               `json:"adminId"`
+	DocumentsUploaded DocumentsUploaded `json:"documentsUploaded"`
+}
+
+// AdminLoginResponse is returned when an admin signs in
+type AdminLoginResponse struct {
+	Message string       `json:"message"`
+	Admin   AdminAccount `json:"admin"`
+	Token   string       `json:"token"`
+}
+
+// AdminAccount is an admin with the outlets they manage
+type AdminAccount struct {
+	ID         int                 `json:"id"`
+	Name       string              `json:"name"`
+	Email      string              `json:"email"`
+	Role       models.Role         `json:"role"`
+	IsVerified bool                `json:"isVerified"`
+	Outlets    []AdminOutletAccess `json:"outlets"`
+}
+
+// AdminOutletAccess is one outlet an admin manages and their permissions there
+type AdminOutletAccess struct {
+	OutletID    int                      `json:"outletId"`
+	Outlet      models.Outlet            `json:"outlet"`
+	Permissions []models.AdminPermission `json:"permissions"`
+}
+
+// SuperAdminLoginResponse is returned when a superadmin signs in
+type SuperAdminLoginResponse struct {
+	Message string            `json:"message"`
+	User    SuperAdminAccount `json:"user"`
+	Token   string            `json:"token"`
+}
+
+// SuperAdminAccount is a superadmin user
+type SuperAdminAccount struct {
+	ID       int            `json:"id"`
+	Name     string         `json:"name"`
+	Email    string         `json:"email"`
+	Phone    *string        `json:"phone"`
+	Role     models.Role    `json:"role"`
+	OutletID *int           `json:"outletId"`
+	Outlet   *models.Outlet `json:"outlet"`
+}
+
+// CurrentUserResponse is the account behind the request's token
+type CurrentUserResponse struct {
+	User CurrentUser `json:"user"`
+}
+
+// CurrentUser is any signed-in account. Admins carry outlets and isVerified;
+// customers and staff carry their role's details.
+type CurrentUser struct {
+	ID              int                     `json:"id"`
+	Name            string                  `json:"name"`
+	Email           string                  `json:"email"`
+	Phone           *string                 `json:"phone,omitempty"`
+	Role            models.Role             `json:"role"`
+	OutletID        *int                    `json:"outletId,omitempty"`
+	Outlet          *models.Outlet          `json:"outlet,omitempty"`
+	ImageURL        *string                 `json:"imageUrl,omitempty"`
+	IsVerified      bool                    `json:"isVerified,omitempty"`
+	Outlets         []models.AdminOutlet    `json:"outlets,omitempty"`
+	CustomerDetails *CustomerAccountDetails `json:"customerDetails,omitempty"`
+	StaffDetails    *StaffAccountDetails    `json:"staffDetails,omitempty"`
+}
+
+// LoginOTPResponse confirms a login code was sent
+type LoginOTPResponse struct {
+	Message          string `json:"message"`
+	ExpiresInSeconds int    `json:"expiresInSeconds"`
+}
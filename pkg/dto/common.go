@@ -0,0 +1,108 @@
+// Package dto holds the request and response bodies of the HTTP API.
+//
+// Request types are what the handlers bind, so their json and binding tags
+// are the source of truth for validation. Response types describe the JSON
+// the handlers write; most handlers still assemble gin.H maps, so a change to
+// a response must be made in both places. TestResponsesMatchDocumentedSchemas
+// in pkg/routes fails when a GET handler's output drifts from its type. Both
+// are published through the OpenAPI document built in pkg/routes.
+package dto
+
+import (
+	"backend_pandhi/pkg/pagination"
+)
+
+// MessageResponse is returned by endpoints that only confirm an action
+type MessageResponse struct {
+	Message string `json:"message"`
+}
+
+// SuccessMessageResponse is MessageResponse with the success flag some
+// endpoints include
+type SuccessMessageResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
+// DateRangeRequest selects a YYYY-MM-DD reporting window, inclusive
+type DateRangeRequest struct {
+	From string `json:"from" binding:"required" doc:"YYYY-MM-DD"`
+	To   string `json:"to" binding:"required" doc:"YYYY-MM-DD"`
+}
+
+// AmountRequest carries a rupee amount
+type AmountRequest struct {
+	Amount float64 `json:"amount" binding:"required"`
+}
+
+// RazorpayPaymentRequest is the checkout result the app sends back for
+// signature verification
+type RazorpayPaymentRequest struct {
+	RazorpayOrderID   string `json:"razorpay_order_id" binding:"required"`
+	RazorpayPaymentID string `json:"razorpay_payment_id" binding:"required"`
+	RazorpaySignature string `json:"razorpay_signature" binding:"required"`
+}
+
+// OrderItemInput is one line of a new order
+type OrderItemInput struct {
+	ProductID int     `json:"productId" binding:"required"`
+	Quantity  int     `json:"quantity" binding:"required"`
+	UnitPrice float64 `json:"unitPrice" binding:"required"`
+}
+
+// AddStockRequest adds stock to an outlet's inventory
+type AddStockRequest struct {
+	ProductID     int `json:"productId" binding:"required"`
+	OutletID      int `json:"outletId" binding:"required"`
+	AddedQuantity int `json:"addedQuantity" binding:"required"`
+}
+
+// DeductStockRequest removes stock from an outlet's inventory
+type DeductStockRequest struct {
+	ProductID int `json:"productId" binding:"required"`
+	OutletID  int `json:"outletId" binding:"required"`
+	Quantity  int `json:"quantity" binding:"required"`
+}
+
+// StockHistoryRequest selects an outlet's stock movements between two dates.
+// The same endpoint also accepts the standard list query parameters.
+type StockHistoryRequest struct {
+	OutletID  int    `json:"outletId" binding:"required"`
+	StartDate string `json:"startDate" binding:"required" doc:"YYYY-MM-DD"`
+	EndDate   string `json:"endDate" binding:"required" doc:"YYYY-MM-DD"`
+}
+
+// Page is embedded in list responses
+type Page struct {
+	Pagination pagination.Meta `json:"pagination"`
+}
+
+// HealthResponse summarises readiness for older clients of /api/health
+type HealthResponse struct {
+	Status      string `json:"status" doc:"ok, degraded or down"`
+	Environment string `json:"environment"`
+	Database    string `json:"database" doc:"connected or disconnected"`
+}
+
+// LivenessResponse reports that the process is serving requests
+type LivenessResponse struct {
+	Status string `json:"status" doc:"Always ok"`
+}
+
+// ReadinessResponse reports the state of every dependency. It is served with
+// 503 when Status is down.
+type ReadinessResponse struct {
+	Status      string                     `json:"status" doc:"ok, degraded or down"`
+	Environment string                     `json:"environment"`
+	CheckedAt   string                     `json:"checkedAt"`
+	Components  map[string]HealthComponent `json:"components" doc:"Keyed by component: database, storage, fcm, razorpay, notificationDispatcher"`
+}
+
+// HealthComponent is the result of one readiness check
+type HealthComponent struct {
+	Status    string                 `json:"status" doc:"ok, degraded or down"`
+	Critical  bool                   `json:"critical" doc:"Whether the server is unready while this is down"`
+	LatencyMs float64                `json:"latencyMs"`
+	Message   string                 `json:"message,omitempty"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+}
@@ -0,0 +1,545 @@
+package dto
+
+import (
+	"backend_pandhi/pkg/models"
+	"time"
+)
+
+// UpdateCartItemRequest changes the quantity of one product in the cart
+type UpdateCartItemRequest struct {
+	ProductID int    `json:"productId" binding:"required"`
+	Quantity  int    `json:"quantity" binding:"required"`
+	Action    string `json:"action" binding:"required" doc:"add or remove"`
+}
+
+// ApplyCouponRequest previews a coupon discount for a cart total
+type ApplyCouponRequest struct {
+	Code         string  `json:"code" binding:"required"`
+	CurrentTotal float64 `json:"currentTotal" binding:"required"`
+	OutletID     int     `json:"outletId" binding:"required"`
+}
+
+// SubmitFeedbackRequest rates the products of a delivered order
+type SubmitFeedbackRequest struct {
+	OrderID int                 `json:"orderId" binding:"required"`
+	Items   []FeedbackItemInput `json:"items" binding:"required"`
+}
+
+// FeedbackItemInput is the rating for one product of an order
+type FeedbackItemInput struct {
+	ProductID      int     `json:"productId" binding:"required"`
+	RatingOverall  float64 `json:"ratingOverall" binding:"required"`
+	RatingTaste    float64 `json:"ratingTaste"`
+	RatingQuality  float64 `json:"ratingQuality"`
+	RatingQuantity float64 `json:"ratingQuantity"`
+	Comment        string  `json:"comment"`
+}
+
+// CreateOrderRequest places an app order
+type CreateOrderRequest struct {
+	TotalAmount           float64                 `json:"totalAmount"`
+	PaymentMethod         string                  `json:"paymentMethod" binding:"required"`
+	DeliverySlot          string                  `json:"deliverySlot" binding:"required"`
+	OutletID              int                     `json:"outletId" binding:"required"`
+	CouponCode            *string                 `json:"couponCode"`
+	RequestedDeliveryDate *string                 `json:"requestedDeliveryDate" doc:"YYYY-MM-DD"`
+	Items                 []OrderItemInput        `json:"items" binding:"required"`
+	PaymentDetails        *RazorpayPaymentDetails `json:"paymentDetails" doc:"Required for UPI and CARD"`
+}
+
+// RazorpayPaymentDetails is the checkout result attached to a paid order
+type RazorpayPaymentDetails struct {
+	RazorpayOrderID   string `json:"razorpay_order_id"`
+	RazorpayPaymentID string `json:"razorpay_payment_id"`
+	RazorpaySignature string `json:"razorpay_signature"`
+}
+
+// EditProfileRequest updates the customer's profile. Only the fields sent are
+// changed; it may also be sent as multipart form data.
+type EditProfileRequest struct {
+	Name        *string              `json:"name" form:"name"`
+	Phone       *string              `json:"phone" form:"phone"`
+	Email       *string              `json:"email" form:"email"`
+	Bio         *string              `json:"bio" form:"bio"`
+	YearOfStudy *string              `json:"yearOfStudy" form:"yearOfStudy"`
+	Degree      *models.TypeOfDegree `json:"degree" form:"degree"`
+}
+
+// CreateTicketRequest opens a support ticket
+type CreateTicketRequest struct {
+	Title       string `json:"title" binding:"required"`
+	Description string `json:"description" binding:"required"`
+	Priority    string `json:"priority" binding:"required"`
+	IssueType   string `json:"issueType"`
+}
+
+// ProductsResponse lists the outlet's menu with stock and quota
+type ProductsResponse struct {
+	Products []MenuProduct `json:"products"`
+}
+
+// MenuProduct is a product as shown on the customer menu
+type MenuProduct struct {
+	ID                    int             `json:"id"`
+	Name                  string          `json:"name"`
+	Description           *string         `json:"description"`
+	Price                 float64         `json:"price"`
+	ImageURL              string          `json:"imageUrl" doc:"Signed URL"`
+	OutletID              int             `json:"outletId"`
+	Category              models.Category `json:"category"`
+	MinValue              *int            `json:"minValue"`
+	IsVeg                 bool            `json:"isVeg"`
+	RatingSum30d          float64         `json:"ratingSum30d"`
+	RatingCount30d        int             `json:"ratingCount30d"`
+	TrendScore            float64         `json:"trendScore"`
+	RatingSumLifetime     float64         `json:"ratingSumLifetime"`
+	RatingCountLifetime   int             `json:"ratingCountLifetime"`
+	AverageRatingLifetime float64         `json:"averageRatingLifetime"`
+	CompanyPaid           bool            `json:"companyPaid"`
+	AvailableQuantity     int             `json:"availableQuantity"`
+	RemainingQuota        int             `json:"remainingQuota" doc:"Company-paid items left today"`
+	IsAvailable           bool            `json:"isAvailable"`
+}
+
+// QuotaResponse reports the customer's company-paid quota for today
+type QuotaResponse struct {
+	RemainingQuota int `json:"remainingQuota"`
+	QuantityUsed   int `json:"quantityUsed"`
+	TotalQuota     int `json:"totalQuota"`
+}
+
+// AvailableSlotsResponse lists the dates and delivery slots open for orders
+type AvailableSlotsResponse struct {
+	Message string          `json:"message"`
+	Data    []AvailableDate `json:"data"`
+}
+
+// AvailableDate is one orderable date and its open delivery slots
+type AvailableDate struct {
+	Date  string                `json:"date" doc:"YYYY-MM-DD"`
+	Slots []models.DeliverySlot `json:"slots"`
+}
+
+// OutletsResponse lists outlets
+type OutletsResponse struct {
+	Outlets []models.Outlet `json:"outlets"`
+}
+
+// CartResponse is the customer's cart with products and stock preloaded
+type CartResponse struct {
+	Cart *models.Cart `json:"cart"`
+}
+
+// CustomerProfileResponse is the signed-in customer's profile
+type CustomerProfileResponse struct {
+	ID          int                  `json:"id"`
+	CustomerID  int                  `json:"customerId"`
+	Name        string               `json:"name"`
+	Email       string               `json:"email"`
+	Phone       *string              `json:"phone"`
+	ImageURL    *string              `json:"imageUrl" doc:"Signed URL"`
+	Bio         *string              `json:"bio"`
+	YearOfStudy *int                 `json:"yearOfStudy"`
+	Degree      *models.TypeOfDegree `json:"degree"`
+}
+
+// EditProfileResponse is the profile after an edit
+type EditProfileResponse struct {
+	Message string                `json:"message"`
+	User    EditedCustomerProfile `json:"user"`
+}
+
+// EditedCustomerProfile is the profile returned after an edit
+type EditedCustomerProfile struct {
+	ID          int                  `json:"id"`
+	Name        string               `json:"name"`
+	Email       string               `json:"email"`
+	Phone       *string              `json:"phone"`
+	ImageURL    *string              `json:"imageUrl"`
+	Bio         *string              `json:"bio"`
+	YearOfStudy *int                 `json:"yearOfStudy"`
+	Degree      *models.TypeOfDegree `json:"degree"`
+}
+
+// CreateTicketResponse is the ticket that was opened
+type CreateTicketResponse struct {
+	Message string        `json:"message"`
+	Ticket  CreatedTicket `json:"ticket"`
+}
+
+// CreatedTicket is a newly opened ticket
+type CreatedTicket struct {
+	ID           int                 `json:"id"`
+	TicketNumber string              `json:"ticketNumber" doc:"TKT-<year>-<id>"`
+	Title        string              `json:"title"`
+	Description  string              `json:"description"`
+	Priority     models.Priority     `json:"priority"`
+	Status       models.TicketStatus `json:"status"`
+	CreatedAt    time.Time           `json:"createdAt"`
+	IssueType    string              `json:"issueType"`
+}
+
+// CustomerTicketsResponse groups the customer's tickets by state
+type CustomerTicketsResponse struct {
+	Tickets CustomerTicketGroups `json:"tickets" doc:"The page's tickets, split by status"`
+	Page
+}
+
+// CustomerTicketGroups splits tickets into open and resolved ones
+type CustomerTicketGroups struct {
+	Ongoing   []CustomerTicket `json:"ongoing"`
+	Completed []CustomerTicket `json:"completed"`
+}
+
+// CustomerTicketResponse is one of the customer's tickets
+type CustomerTicketResponse struct {
+	Ticket CustomerTicket `json:"ticket"`
+}
+
+// CustomerTicket is a ticket with its progress as shown to the customer
+type CustomerTicket struct {
+	ID                 string          `json:"id"`
+	TicketNumber       string          `json:"ticketNumber"`
+	Title              string          `json:"title"`
+	Description        string          `json:"description"`
+	Priority           models.Priority `json:"priority"`
+	Status             string          `json:"status" doc:"open or closed"`
+	Progress           string          `json:"progress"`
+	ProgressPercentage int             `json:"progressPercentage"`
+	DateIssued         string          `json:"dateIssued" doc:"YYYY-MM-DD"`
+	ResolvedDate       *string         `json:"resolvedDate" doc:"YYYY-MM-DD"`
+	ResolutionNote     *string         `json:"resolutionNote"`
+	IssueType          string          `json:"issueType"`
+}
+
+// CouponsResponse lists the coupons the customer can still use
+type CouponsResponse struct {
+	Message        string            `json:"message"`
+	Coupons        []AvailableCoupon `json:"coupons"`
+	TotalAvailable int               `json:"totalAvailable"`
+	CurrentTimeIST string            `json:"currentTimeIST"`
+	Timezone       string            `json:"timezone"`
+	Note           string            `json:"note"`
+}
+
+// AvailableCoupon is a coupon with its validity shown in IST
+type AvailableCoupon struct {
+	ID               int       `json:"id"`
+	Code             string    `json:"code"`
+	Description      string    `json:"description"`
+	RewardValue      float64   `json:"rewardValue"`
+	MinOrderValue    float64   `json:"minOrderValue"`
+	ValidFrom        time.Time `json:"validFrom"`
+	ValidUntil       time.Time `json:"validUntil"`
+	IsActive         bool      `json:"isActive"`
+	UsageLimit       int       `json:"usageLimit"`
+	UsedCount        int       `json:"usedCount"`
+	UsageType        *string   `json:"usageType"`
+	OutletID         *int      `json:"outletId"`
+	CreatedAt        time.Time `json:"createdAt"`
+	IsCurrentlyValid bool      `json:"isCurrentlyValid"`
+	ValidFromIST     string    `json:"validFromIST"`
+	ValidUntilIST    string    `json:"validUntilIST"`
+	IsUsedByCustomer bool      `json:"isUsedByCustomer"`
+	RemainingUses    int       `json:"remainingUses"`
+}
+
+// ApplyCouponResponse is the discount a coupon gives on the current total
+type ApplyCouponResponse struct {
+	Message            string  `json:"message"`
+	Discount           float64 `json:"discount"`
+	TotalAfterDiscount float64 `json:"totalAfterDiscount"`
+}
+
+// PendingFeedbackResponse holds the customer's latest delivered order with
+// products they have not rated
+type PendingFeedbackResponse struct {
+	Success bool                  `json:"success"`
+	Pending *PendingFeedbackOrder `json:"pending" doc:"Null when every delivered order is rated"`
+}
+
+// PendingFeedbackOrder is a delivered order waiting for ratings
+type PendingFeedbackOrder struct {
+	OrderID int                   `json:"orderId"`
+	Date    *time.Time            `json:"date"`
+	Items   []PendingFeedbackItem `json:"items"`
+}
+
+// PendingFeedbackItem is a product waiting for a rating
+type PendingFeedbackItem struct {
+	ProductID int     `json:"productId"`
+	Name      string  `json:"name"`
+	ImageURL  *string `json:"imageUrl"`
+}
+
+// FeedbackStatusResponse reports which products of an order have been rated
+type FeedbackStatusResponse struct {
+	Success bool                `json:"success"`
+	Data    OrderFeedbackStatus `json:"data"`
+}
+
+// OrderFeedbackStatus is the rating state of one order
+type OrderFeedbackStatus struct {
+	OrderID           int               `json:"orderId"`
+	Date              time.Time         `json:"date"`
+	Items             []FeedbackProduct `json:"items"`
+	IsFullyRated      bool              `json:"isFullyRated"`
+	ExistingFeedbacks []models.Feedback `json:"existingFeedbacks"`
+}
+
+// FeedbackProduct is a product of a rated order
+type FeedbackProduct struct {
+	ProductID int    `json:"productId"`
+	Name      string `json:"name"`
+}
+
+// ProductReviewsResponse is a page of a product's reviews
+type ProductReviewsResponse struct {
+	Success bool            `json:"success"`
+	Reviews []ProductReview `json:"reviews"`
+	Page
+}
+
+// ProductReview is one customer's review of a product
+type ProductReview struct {
+	ID             int          `json:"id"`
+	RatingOverall  float64      `json:"ratingOverall"`
+	RatingTaste    float64      `json:"ratingTaste"`
+	RatingQuality  float64      `json:"ratingQuality"`
+	RatingQuantity float64      `json:"ratingQuantity"`
+	Comment        *string      `json:"comment"`
+	CreatedAt      time.Time    `json:"createdAt"`
+	Reply          *ReviewReply `json:"reply" doc:"The outlet's public reply, if any"`
+	User           ReviewAuthor `json:"user"`
+}
+
+// ReviewReply is the outlet's public answer to a review
+type ReviewReply struct {
+	Text      string     `json:"text"`
+	RepliedAt *time.Time `json:"repliedAt"`
+}
+
+// ReviewAuthor is the public name and picture of a reviewer
+type ReviewAuthor struct {
+	Name  string  `json:"name"`
+	Image *string `json:"image"`
+}
+
+// CreateOrderResponse is the placed order with its pricing
+type CreateOrderResponse struct {
+	Message           string                  `json:"message"`
+	Order             PlacedOrder             `json:"order"`
+	StockUpdates      []StockUpdate           `json:"stockUpdates"`
+	CouponDiscount    float64                 `json:"couponDiscount"`
+	PricingBreakdown  PricingBreakdown        `json:"pricingBreakdown"`
+	WalletTransaction *OrderWalletTransaction `json:"walletTransaction,omitempty" doc:"Wallet payments only"`
+}
+
+// PlacedOrder is an order as returned when it is placed
+type PlacedOrder struct {
+	ID                int                  `json:"id"`
+	OrderNumber       string               `json:"orderNumber" doc:"#ORD-<id>"`
+	TotalAmount       float64              `json:"totalAmount"`
+	PaymentMethod     models.PaymentMethod `json:"paymentMethod"`
+	Status            string               `json:"status"`
+	DeliverySlot      *models.DeliverySlot `json:"deliverySlot"`
+	DeliveryDate      *time.Time           `json:"deliveryDate"`
+	CreatedAt         time.Time            `json:"createdAt"`
+	Items             []CustomerOrderItem  `json:"items"`
+	RazorpayPaymentID *string              `json:"razorpayPaymentId"`
+}
+
+// CustomerOrderItem is one line of a customer's order
+type CustomerOrderItem struct {
+	ID        int                    `json:"id"`
+	ProductID int                    `json:"productId"`
+	Quantity  int                    `json:"quantity"`
+	UnitPrice float64                `json:"unitPrice"`
+	Status    models.OrderItemStatus `json:"status"`
+	Product   OrderProduct           `json:"product"`
+}
+
+// OrderProduct is the product summary attached to an order line
+type OrderProduct struct {
+	ID          int     `json:"id"`
+	Name        string  `json:"name"`
+	Description *string `json:"description"`
+	Price       float64 `json:"price"`
+	ImageURL    *string `json:"imageUrl"`
+}
+
+// StockUpdate is the inventory change an order caused for one product
+type StockUpdate struct {
+	ProductID         int `json:"productId"`
+	CurrentStock      int `json:"currentStock"`
+	RequestedQuantity int `json:"requestedQuantity"`
+	NewStock          int `json:"newStock"`
+}
+
+// PricingBreakdown splits an order into free, company-paid and regular items
+type PricingBreakdown struct {
+	FreeItems           []PricedItem `json:"freeItems"`
+	PaidCompanyItems    []PricedItem `json:"paidCompanyItems"`
+	RegularItems        []PricedItem `json:"regularItems"`
+	FreeAmount          float64      `json:"freeAmount"`
+	PaidCompanyAmount   float64      `json:"paidCompanyAmount"`
+	RegularAmount       float64      `json:"regularAmount"`
+	TotalCompanyPaidQty int          `json:"totalCompanyPaidQty"`
+}
+
+// PricedItem is a quantity of one product at the price charged for it
+type PricedItem struct {
+	ProductID int     `json:"productId"`
+	Name      string  `json:"name"`
+	Quantity  int     `json:"quantity"`
+	UnitPrice float64 `json:"unitPrice"`
+	Amount    float64 `json:"amount"`
+}
+
+// OrderWalletTransaction is the wallet debit for an order
+type OrderWalletTransaction struct {
+	ID        int                    `json:"id"`
+	Amount    float64                `json:"amount"`
+	Method    models.PaymentMethod   `json:"method"`
+	Status    models.WalletTransType `json:"status"`
+	CreatedAt time.Time              `json:"createdAt"`
+}
+
+// CustomerOrdersResponse lists the customer's ongoing orders
+type CustomerOrdersResponse struct {
+	Message string          `json:"message"`
+	Orders  []CustomerOrder `json:"orders"`
+}
+
+// CustomerOrderHistoryResponse is a page of the customer's past orders
+type CustomerOrderHistoryResponse struct {
+	Message string          `json:"message"`
+	Orders  []CustomerOrder `json:"orders"`
+	Page
+}
+
+// CustomerOrder is an order as listed to the customer
+type CustomerOrder struct {
+	ID            int                  `json:"id"`
+	OrderNumber   string               `json:"orderNumber"`
+	TotalAmount   float64              `json:"totalAmount"`
+	PaymentMethod models.PaymentMethod `json:"paymentMethod"`
+	Status        string               `json:"status"`
+	DeliveryDate  *time.Time           `json:"deliveryDate"`
+	DeliverySlot  *models.DeliverySlot `json:"deliverySlot"`
+	DeliveredAt   *time.Time           `json:"deliveredAt,omitempty" doc:"History only"`
+	CreatedAt     time.Time            `json:"createdAt"`
+	Items         []CustomerOrderItem  `json:"items"`
+	Outlet        OutletSummary        `json:"outlet"`
+}
+
+// OutletSummary names an outlet
+type OutletSummary struct {
+	ID      int     `json:"id"`
+	Name    string  `json:"name"`
+	Address *string `json:"address"`
+}
+
+// CancelOrderResponse confirms a cancellation
+type CancelOrderResponse struct {
+	Message string `json:"message"`
+	OrderID int    `json:"orderId"`
+	Status  string `json:"status"`
+}
+
+// RazorpayOrderResponse is a Razorpay order created for checkout
+type RazorpayOrderResponse struct {
+	Success bool                   `json:"success"`
+	Order   map[string]interface{} `json:"order" doc:"Razorpay order entity"`
+}
+
+// WalletRechargeOrderResponse is the Razorpay order for a wallet top-up
+type WalletRechargeOrderResponse struct {
+	Message   string                 `json:"message"`
+	Order     map[string]interface{} `json:"order" doc:"Razorpay order entity"`
+	Breakdown RechargeBreakdown      `json:"breakdown"`
+}
+
+// RechargeBreakdown is what a wallet top-up costs
+type RechargeBreakdown struct {
+	WalletAmount            float64 `json:"walletAmount"`
+	ServiceCharge           float64 `json:"serviceCharge"`
+	TotalPayable            float64 `json:"totalPayable"`
+	ServiceChargePercentage float64 `json:"serviceChargePercentage"`
+}
+
+// VerifyWalletRechargeResponse is the wallet after a verified top-up
+type VerifyWalletRechargeResponse struct {
+	Message     string                 `json:"message"`
+	Wallet      WalletBalance          `json:"wallet"`
+	Transaction RechargeTransaction    `json:"transaction"`
+	Payment     RazorpayPaymentSummary `json:"payment"`
+}
+
+// WalletBalance is the wallet's running totals
+type WalletBalance struct {
+	Balance        float64    `json:"balance"`
+	TotalRecharged float64    `json:"totalRecharged"`
+	TotalUsed      float64    `json:"totalUsed,omitempty"`
+	LastRecharged  *time.Time `json:"lastRecharged"`
+	LastOrder      *time.Time `json:"lastOrder,omitempty"`
+}
+
+// RechargeTransaction is the wallet credit for a top-up
+type RechargeTransaction struct {
+	ID            int                  `json:"id"`
+	Amount        float64              `json:"amount"`
+	GrossAmount   *float64             `json:"grossAmount"`
+	ServiceCharge *float64             `json:"serviceCharge"`
+	Method        models.PaymentMethod `json:"method"`
+	CreatedAt     time.Time            `json:"createdAt"`
+}
+
+// RazorpayPaymentSummary is the captured Razorpay payment
+type RazorpayPaymentSummary struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+	Method string `json:"method"`
+}
+
+// WalletDetailsResponse is the customer's wallet
+type WalletDetailsResponse struct {
+	Message string        `json:"message"`
+	Wallet  WalletBalance `json:"wallet"`
+}
+
+// RechargeWalletResponse acknowledges a legacy cash recharge request
+type RechargeWalletResponse struct {
+	Message string  `json:"message"`
+	Amount  float64 `json:"amount"`
+}
+
+// RecentTransactionsResponse lists the latest wallet transactions
+type RecentTransactionsResponse struct {
+	Message      string        `json:"message"`
+	Transactions []WalletEntry `json:"transactions"`
+}
+
+// RechargeHistoryResponse lists the wallet's top-ups
+type RechargeHistoryResponse struct {
+	Message         string        `json:"message"`
+	RechargeHistory []WalletEntry `json:"rechargeHistory"`
+	Page
+}
+
+// WalletEntry is one wallet credit or debit
+type WalletEntry struct {
+	ID        int                    `json:"id"`
+	Amount    float64                `json:"amount"`
+	Method    models.PaymentMethod   `json:"method"`
+	Status    models.WalletTransType `json:"status"`
+	CreatedAt time.Time              `json:"createdAt"`
+}
+
+// ServiceChargeBreakdown is what a wallet top-up of the given amount costs
+type ServiceChargeBreakdown struct {
+	WalletAmount  float64 `json:"walletAmount"`
+	ServiceCharge float64 `json:"serviceCharge"`
+	TotalPayable  float64 `json:"totalPayable"`
+	ChargePercent float64 `json:"chargePercent"`
+}
@@ -0,0 +1,452 @@
+package dto
+
+import (
+	"backend_pandhi/pkg/models"
+	"mime/multipart"
+	"time"
+)
+
+// UpdateOrderStatusRequest moves an order, or some of its items, to a new status
+type UpdateOrderStatusRequest struct {
+	OrderID      int    `json:"orderId" binding:"required"`
+	OrderItemIDs []int  `json:"orderItemIds" doc:"Limit the update to these items"`
+	Status       string `json:"status" binding:"required"`
+	OutletID     int    `json:"outletId" binding:"required"`
+}
+
+// ManualOrderRequest records a walk-in order taken at the counter
+type ManualOrderRequest struct {
+	OutletID      int              `json:"outletId" binding:"required"`
+	TotalAmount   float64          `json:"totalAmount" binding:"required"`
+	PaymentMethod string           `json:"paymentMethod" binding:"required"`
+	Status        string           `json:"status"`
+	Items         []OrderItemInput `json:"items" binding:"required"`
+}
+
+// UpdateStaffProfileRequest updates the staff member's profile. Only the
+// fields sent are changed.
+type UpdateStaffProfileRequest struct {
+	Name        *string `json:"name"`
+	Phone       *string `json:"phone"`
+	Designation *string `json:"designation"`
+}
+
+// ChangePasswordRequest changes the signed-in user's password
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"currentPassword" binding:"required"`
+	NewPassword     string `json:"newPassword" binding:"required"`
+	ConfirmPassword string `json:"confirmPassword" binding:"required"`
+}
+
+// Enable2FARequest confirms 2FA setup with a code from the authenticator app
+type Enable2FARequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// Disable2FARequest turns 2FA off
+type Disable2FARequest struct {
+	CurrentPassword string  `json:"currentPassword" binding:"required"`
+	Token           *string `json:"token"`
+}
+
+// AddRechargeRequest tops up a customer's wallet with cash at the counter
+type AddRechargeRequest struct {
+	CustomerID int     `json:"customerId" binding:"required"`
+	Amount     float64 `json:"amount" binding:"required"`
+}
+
+// PageQuery is the page-number pagination used by older list endpoints
+type PageQuery struct {
+	Page  int `form:"page" doc:"Defaults to 1"`
+	Limit int `form:"limit" doc:"1-100, defaults to 10"`
+}
+
+// StaffHomeResponse is the outlet dashboard shown to staff
+type StaffHomeResponse struct {
+	TotalRevenue         float64           `json:"totalRevenue"`
+	AppOrders            int64             `json:"appOrders"`
+	ManualOrders         int64             `json:"manualOrders"`
+	PeakSlot             *string           `json:"peakSlot"`
+	BestSellerProduct    *BestSeller       `json:"bestSellerProduct"`
+	TotalRechargedAmount float64           `json:"totalRechargedAmount"`
+	LowStockProducts     []LowStockProduct `json:"lowStockProducts"`
+	NegativeFeedback     FeedbackDigest    `json:"negativeFeedback"`
+}
+
+// FeedbackDigest is the day's feedback that opened alert tickets
+type FeedbackDigest struct {
+	Date          string               `json:"date" doc:"YYYY-MM-DD"`
+	Count         int                  `json:"count"`
+	Open          int                  `json:"open" doc:"Tickets not yet closed"`
+	AverageRating float64              `json:"averageRating"`
+	Items         []FeedbackDigestItem `json:"items"`
+}
+
+// FeedbackDigestItem is one negative feedback and the ticket it opened
+type FeedbackDigestItem struct {
+	TicketID      int                 `json:"ticketId"`
+	TicketStatus  models.TicketStatus `json:"ticketStatus"`
+	Priority      models.Priority     `json:"priority"`
+	FeedbackID    int                 `json:"feedbackId"`
+	OrderID       int                 `json:"orderId"`
+	ProductID     int                 `json:"productId"`
+	ProductName   string              `json:"productName"`
+	RatingOverall float64             `json:"ratingOverall"`
+	Comment       *string             `json:"comment"`
+	CreatedAt     time.Time           `json:"createdAt"`
+}
+
+// SetDutyRequest marks the staff member on or off duty
+type SetDutyRequest struct {
+	IsOnDuty *bool `json:"isOnDuty" binding:"required"`
+}
+
+// DutyResponse is the staff member's duty status
+type DutyResponse struct {
+	Message  string `json:"message"`
+	IsOnDuty bool   `json:"isOnDuty"`
+}
+
+// BestSeller is the product sold most
+type BestSeller struct {
+	ID           int     `json:"id"`
+	Name         string  `json:"name"`
+	ImageURL     *string `json:"imageUrl"`
+	QuantitySold int     `json:"quantitySold"`
+}
+
+// LowStockProduct is a product whose stock is below its threshold
+type LowStockProduct struct {
+	ProductID int     `json:"productId"`
+	Name      string  `json:"name"`
+	ImageURL  *string `json:"imageUrl"`
+	Quantity  int     `json:"quantity"`
+	Threshold int     `json:"threshold"`
+}
+
+// RecentOrdersResponse is a page of the outlet's latest orders
+type RecentOrdersResponse struct {
+	Message     string        `json:"message"`
+	Orders      []RecentOrder `json:"orders"`
+	Total       int64         `json:"total"`
+	CurrentPage int           `json:"currentPage"`
+	TotalPages  int           `json:"totalPages"`
+}
+
+// RecentOrder is an order on the staff dashboard
+type RecentOrder struct {
+	BillNumber   int                  `json:"billNumber"`
+	CustomerName string               `json:"customerName"`
+	OrderType    models.OrderType     `json:"orderType"`
+	PaymentMode  models.PaymentMethod `json:"paymentMode"`
+	Status       string               `json:"status"`
+	Items        []BillItem           `json:"items"`
+	TotalAmount  float64              `json:"totalAmount"`
+	CreatedAt    time.Time            `json:"createdAt"`
+	DeliveryDate *time.Time           `json:"deliveryDate"`
+	DeliverySlot *models.DeliverySlot `json:"deliverySlot"`
+}
+
+// BillItem is one line of a bill
+type BillItem struct {
+	Name      string  `json:"name"`
+	Quantity  int     `json:"quantity"`
+	UnitPrice float64 `json:"unitPrice"`
+}
+
+// CountResponse reports a count
+type CountResponse struct {
+	Count   int64  `json:"count"`
+	Message string `json:"message"`
+}
+
+// StaffOrderResponse is one of the outlet's orders
+type StaffOrderResponse struct {
+	Order StaffOrderDetail `json:"order"`
+}
+
+// StaffOrderDetail is an order with its lines, as shown to staff
+type StaffOrderDetail struct {
+	OrderID      int              `json:"orderId"`
+	CustomerName string           `json:"customerName"`
+	OutletName   string           `json:"outletName"`
+	OrderStatus  string           `json:"orderStatus"`
+	TotalPrice   float64          `json:"totalPrice"`
+	CreatedAt    time.Time        `json:"createdAt"`
+	Items        []StaffOrderLine `json:"items"`
+}
+
+// StaffOrderLine is one line of an order, as shown to staff
+type StaffOrderLine struct {
+	ID                 int                    `json:"id"`
+	ProductName        string                 `json:"productName"`
+	ProductDescription *string                `json:"productDescription"`
+	Quantity           int                    `json:"quantity"`
+	UnitPrice          float64                `json:"unitPrice"`
+	TotalPrice         float64                `json:"totalPrice"`
+	ItemStatus         models.OrderItemStatus `json:"itemStatus"`
+}
+
+// StocksResponse lists the outlet's stock levels
+type StocksResponse struct {
+	Message string       `json:"message,omitempty" doc:"Set when the outlet has no products"`
+	Stocks  []StockLevel `json:"stocks,omitempty"`
+}
+
+// StockLevel is a product's stock at an outlet
+type StockLevel struct {
+	ID        int             `json:"id"`
+	Name      string          `json:"name"`
+	Category  models.Category `json:"category"`
+	Price     float64         `json:"price"`
+	Quantity  int             `json:"quantity"`
+	Threshold int             `json:"threshold"`
+}
+
+// AddStockResponse is the inventory row before the stock was added
+type AddStockResponse struct {
+	Message          string           `json:"message"`
+	UpdatedInventory models.Inventory `json:"updatedInventory"`
+}
+
+// DeductStockResponse is the stock left after a deduction
+type DeductStockResponse struct {
+	Message         string `json:"message"`
+	CurrentQuantity int    `json:"currentQuantity"`
+}
+
+// StockHistoryResponse is a page of stock movements
+type StockHistoryResponse struct {
+	Message string                `json:"message"`
+	History []models.StockHistory `json:"history"`
+	Page
+}
+
+// ManualOrderResponse is the recorded walk-in order
+type ManualOrderResponse struct {
+	Message string       `json:"message"`
+	Order   models.Order `json:"order"`
+}
+
+// CounterProductsResponse lists the products in stock at the counter
+type CounterProductsResponse struct {
+	Products []CounterProduct `json:"products"`
+}
+
+// CounterProduct is a product available for a walk-in order
+type CounterProduct struct {
+	ID                int             `json:"id"`
+	Name              string          `json:"name"`
+	Description       *string         `json:"description"`
+	Price             float64         `json:"price"`
+	ImageURL          *string         `json:"imageUrl"`
+	Category          models.Category `json:"category"`
+	QuantityAvailable int             `json:"quantityAvailable"`
+}
+
+// CurrentOrdersResponse lists the outlet's orders in progress
+type CurrentOrdersResponse struct {
+	Message string         `json:"message"`
+	Orders  []CurrentOrder `json:"orders"`
+	Count   int            `json:"count"`
+}
+
+// CurrentOrder is an order waiting to be delivered
+type CurrentOrder struct {
+	ID            int                  `json:"id"`
+	CustomerName  string               `json:"customerName"`
+	TotalAmount   float64              `json:"totalAmount"`
+	PaymentMethod models.PaymentMethod `json:"paymentMethod"`
+	Status        string               `json:"status"`
+	Type          models.OrderType     `json:"type"`
+	DeliverySlot  *models.DeliverySlot `json:"deliverySlot"`
+	CreatedAt     time.Time            `json:"createdAt"`
+	Items         []CurrentOrderItem   `json:"items"`
+}
+
+// CurrentOrderItem is one line of an order in progress
+type CurrentOrderItem struct {
+	ProductName string                 `json:"productName"`
+	Quantity    int                    `json:"quantity"`
+	Status      models.OrderItemStatus `json:"status"`
+}
+
+// StaffOrderHistoryResponse is a page of the outlet's past orders
+type StaffOrderHistoryResponse struct {
+	Message string              `json:"message"`
+	Orders  []StaffHistoryOrder `json:"orders"`
+	Count   int                 `json:"count"`
+	Page
+}
+
+// StaffHistoryOrder is a past order, as listed to staff
+type StaffHistoryOrder struct {
+	ID            int                  `json:"id"`
+	OrderNumber   string               `json:"orderNumber"`
+	CustomerName  string               `json:"customerName"`
+	TotalAmount   float64              `json:"totalAmount"`
+	PaymentMethod models.PaymentMethod `json:"paymentMethod"`
+	Status        string               `json:"status"`
+	Type          models.OrderType     `json:"type"`
+	DeliveryDate  *time.Time           `json:"deliveryDate"`
+	DeliverySlot  *models.DeliverySlot `json:"deliverySlot"`
+	DeliveredAt   *time.Time           `json:"deliveredAt"`
+	CreatedAt     time.Time            `json:"createdAt"`
+	Items         []StaffHistoryItem   `json:"items"`
+}
+
+// StaffHistoryItem is one line of a past order
+type StaffHistoryItem struct {
+	ID          int                    `json:"id"`
+	ProductName string                 `json:"productName"`
+	Quantity    int                    `json:"quantity"`
+	UnitPrice   float64                `json:"unitPrice"`
+	Status      models.OrderItemStatus `json:"status"`
+}
+
+// StaffAvailableSlotsResponse lists the dates and delivery slots open for orders
+type StaffAvailableSlotsResponse struct {
+	Message string               `json:"message"`
+	Data    []StaffAvailableDate `json:"data"`
+}
+
+// StaffAvailableDate is one orderable date and its open delivery slots
+type StaffAvailableDate struct {
+	Date           string                `json:"date" doc:"YYYY-MM-DD"`
+	AvailableSlots []models.DeliverySlot `json:"availableSlots"`
+}
+
+// StaffProfileResponse is the staff member's profile
+type StaffProfileResponse struct {
+	Message string       `json:"message"`
+	Profile StaffProfile `json:"profile"`
+}
+
+// StaffProfile is a staff member's profile
+type StaffProfile struct {
+	ID          int            `json:"id"`
+	Name        string         `json:"name"`
+	Email       string         `json:"email"`
+	Phone       *string        `json:"phone"`
+	ImageURL    *string        `json:"imageUrl" doc:"Signed URL"`
+	Designation string         `json:"designation"`
+	Outlet      *OutletSummary `json:"outlet"`
+	IsOnDuty    bool           `json:"isOnDuty"`
+}
+
+// ImageUploadResponse is the signed URL of an uploaded image
+type ImageUploadResponse struct {
+	Message  string `json:"message"`
+	ImageURL string `json:"imageUrl"`
+}
+
+// ImageUploadRequest uploads a profile image
+type ImageUploadRequest struct {
+	Image *multipart.FileHeader `json:"image" form:"image" binding:"required"`
+}
+
+// DeleteImageResponse is the user after their image was removed
+type DeleteImageResponse struct {
+	Message string     `json:"message"`
+	User    ImageOwner `json:"user"`
+}
+
+// ImageOwner is the user an image belonged to
+type ImageOwner struct {
+	ID       int     `json:"id"`
+	Name     string  `json:"name"`
+	ImageURL *string `json:"imageUrl"`
+}
+
+// DailyRevenue is the revenue on one day
+type DailyRevenue struct {
+	Date    string  `json:"date"`
+	Revenue float64 `json:"revenue"`
+}
+
+// OrderTypeBreakdown counts app and counter orders
+type OrderTypeBreakdown struct {
+	AppOrders    int64 `json:"appOrders"`
+	ManualOrders int64 `json:"manualOrders"`
+}
+
+// DailyNewCustomers is the number of customers who signed up on one day
+type DailyNewCustomers struct {
+	Date         string `json:"date"`
+	NewCustomers int    `json:"newCustomers"`
+}
+
+// CategoryOrders is the number of items ordered in one category
+type CategoryOrders struct {
+	Category   string `json:"category"`
+	OrderCount int    `json:"orderCount"`
+}
+
+// SlotOrders is the number of orders delivered in one slot
+type SlotOrders struct {
+	DeliverySlot string `json:"deliverySlot"`
+	OrderCount   int64  `json:"orderCount"`
+}
+
+// DailyCancellations counts cancellations and refunds on one day
+type DailyCancellations struct {
+	Date          string `json:"date"`
+	Cancellations int    `json:"cancellations"`
+	Refunds       int    `json:"refunds"`
+}
+
+// ProductQuantity is the quantity sold of one product
+type ProductQuantity struct {
+	ProductID    int    `json:"productId"`
+	ProductName  string `json:"productName"`
+	QuantitySold int    `json:"quantitySold"`
+}
+
+// TwoFactorStatusResponse reports whether 2FA is on
+type TwoFactorStatusResponse struct {
+	Message            string     `json:"message"`
+	TwoFactorEnabled   bool       `json:"twoFactorEnabled"`
+	TwoFactorEnabledAt *time.Time `json:"twoFactorEnabledAt"`
+}
+
+// TwoFactorSetupResponse is a new authenticator secret
+type TwoFactorSetupResponse struct {
+	Message    string `json:"message"`
+	Secret     string `json:"secret"`
+	OTPAuthURL string `json:"otpAuthUrl"`
+}
+
+// Enable2FAResponse carries the one-time backup codes
+type Enable2FAResponse struct {
+	Message     string   `json:"message"`
+	BackupCodes []string `json:"backupCodes"`
+}
+
+// BackupCodesCountResponse reports how many backup codes are left
+type BackupCodesCountResponse struct {
+	Message        string `json:"message"`
+	RemainingCodes int    `json:"remainingCodes"`
+	TotalCodes     int    `json:"totalCodes"`
+}
+
+// StaffRechargeHistoryResponse is a page of the outlet's wallet recharges
+type StaffRechargeHistoryResponse struct {
+	Message      string           `json:"message"`
+	Transactions []OutletRecharge `json:"transactions"`
+	Page
+}
+
+// OutletRecharge is a wallet recharge made at an outlet
+type OutletRecharge struct {
+	ID           int                  `json:"id"`
+	CustomerName string               `json:"customerName"`
+	Amount       float64              `json:"amount"`
+	Method       models.PaymentMethod `json:"method"`
+	CreatedAt    time.Time            `json:"createdAt"`
+}
+
+// AddRechargeResponse is the customer's wallet after a counter recharge
+type AddRechargeResponse struct {
+	Message string        `json:"message"`
+	Wallet  models.Wallet `json:"wallet"`
+}
@@ -0,0 +1,835 @@
+package dto
+
+import (
+	"backend_pandhi/pkg/models"
+	"mime/multipart"
+	"time"
+)
+
+// AddOutletRequest creates an outlet
+type AddOutletRequest struct {
+	Name       string `json:"name" binding:"required"`
+	Address    string `json:"address" binding:"required"`
+	Phone      string `json:"phone" binding:"required"`
+	Email      string `json:"email" binding:"required"`
+	StaffCount int    `json:"staffCount"`
+}
+
+// RatingWeights are the shares of an outlet's four feedback ratings in
+// product scores
+type RatingWeights struct {
+	Overall  float64 `json:"overall" binding:"min=0,max=1"`
+	Taste    float64 `json:"taste" binding:"min=0,max=1"`
+	Quality  float64 `json:"quality" binding:"min=0,max=1"`
+	Quantity float64 `json:"quantity" binding:"min=0,max=1" doc:"The four weights sum to 1"`
+}
+
+// AddStaffRequest creates a verified staff account for an outlet
+type AddStaffRequest struct {
+	Email       string   `json:"email" binding:"required"`
+	Password    string   `json:"password" binding:"required"`
+	Name        string   `json:"name" binding:"required"`
+	Phone       string   `json:"phone" binding:"required"`
+	OutletID    int      `json:"outletId" binding:"required"`
+	StaffRole   string   `json:"staffRole" binding:"required"`
+	Permissions []string `json:"permissions"`
+}
+
+// StaffPermissionRequest grants or revokes one staff permission
+type StaffPermissionRequest struct {
+	Permission string `json:"permission" binding:"required"`
+	Grant      bool   `json:"grant"`
+	StaffID    int    `json:"staffId" binding:"required"`
+}
+
+// AddExpenseRequest records an outlet expense
+type AddExpenseRequest struct {
+	OutletID    int     `json:"outletId" binding:"required"`
+	Description string  `json:"description" binding:"required"`
+	Category    string  `json:"category" binding:"required"`
+	Amount      float64 `json:"amount" binding:"required"`
+	Method      string  `json:"method" binding:"required"`
+	PaidTo      string  `json:"paidTo" binding:"required"`
+	ExpenseDate string  `json:"expenseDate" binding:"required" doc:"YYYY-MM-DD"`
+}
+
+// ExpenseByDateRequest selects an outlet's expenses in a date range
+type ExpenseByDateRequest struct {
+	OutletID int    `json:"outletId" binding:"required"`
+	From     string `json:"from" binding:"required" doc:"YYYY-MM-DD"`
+	To       string `json:"to" binding:"required" doc:"YYYY-MM-DD"`
+}
+
+// CloseTicketRequest resolves a support ticket
+type CloseTicketRequest struct {
+	TicketID       int    `json:"ticketId" binding:"required"`
+	ResolutionNote string `json:"resolutionNote" binding:"required"`
+	ResolvedAt     string `json:"resolvedAt" binding:"required" doc:"RFC 3339 timestamp"`
+}
+
+// CreateCouponRequest creates a coupon for an outlet
+type CreateCouponRequest struct {
+	Code          string  `json:"code" binding:"required"`
+	Description   *string `json:"description"`
+	RewardValue   string  `json:"rewardValue" binding:"required" doc:"Flat amount, or a percentage such as 10%"`
+	MinOrderValue float64 `json:"minOrderValue" binding:"required"`
+	ValidFrom     string  `json:"validFrom" binding:"required"`
+	ValidUntil    string  `json:"validUntil" binding:"required"`
+	IsActive      *bool   `json:"isActive"`
+	UsageLimit    *int    `json:"usageLimit"`
+	OutletID      int     `json:"outletId" binding:"required"`
+}
+
+// ScheduleNotificationRequest schedules a push notification to an outlet's customers
+type ScheduleNotificationRequest struct {
+	Title         string  `json:"title" binding:"required"`
+	Message       string  `json:"message" binding:"required"`
+	Priority      *string `json:"priority"`
+	ImageURL      *string `json:"imageUrl"`
+	ScheduledDate string  `json:"scheduledDate" binding:"required" doc:"YYYY-MM-DD"`
+	ScheduledTime string  `json:"scheduledTime" binding:"required" doc:"HH:MM"`
+	OutletID      int     `json:"outletId" binding:"required"`
+}
+
+// SendNotificationRequest pushes a notification to an outlet's customers now
+type SendNotificationRequest struct {
+	Title    string `json:"title" binding:"required"`
+	Message  string `json:"message" binding:"required"`
+	OutletID int    `json:"outletId" binding:"required"`
+}
+
+// TestNotificationRequest pushes a notification to a single device token
+type TestNotificationRequest struct {
+	DeviceToken string `json:"deviceToken" binding:"required"`
+	Title       string `json:"title" binding:"required"`
+	Message     string `json:"message" binding:"required"`
+}
+
+// UpdateAppFeaturesRequest turns app features on or off for an outlet
+type UpdateAppFeaturesRequest struct {
+	OutletID int               `json:"outletId" binding:"required"`
+	Features []AppFeatureInput `json:"features" binding:"required"`
+}
+
+// AppFeatureInput is the desired state of one app feature
+type AppFeatureInput struct {
+	Feature   string `json:"feature" binding:"required"`
+	IsEnabled bool   `json:"isEnabled"`
+}
+
+// SetOutletAvailabilityRequest replaces an outlet's unavailable dates and slots
+type SetOutletAvailabilityRequest struct {
+	OutletID          int                    `json:"outletId" binding:"required"`
+	NonAvailableDates []UnavailableDateInput `json:"nonAvailableDates" binding:"required"`
+}
+
+// UnavailableDateInput lists the delivery slots closed on one date
+type UnavailableDateInput struct {
+	Date              string   `json:"date" binding:"required" doc:"YYYY-MM-DD"`
+	NonAvailableSlots []string `json:"nonAvailableSlots" binding:"required"`
+}
+
+// ProfitLossRequest selects the year for the monthly profit and loss report
+type ProfitLossRequest struct {
+	Year int `json:"year" binding:"required"`
+}
+
+// VerifyAdminRequest approves an admin and maps them to outlets
+type VerifyAdminRequest struct {
+	OutletIDs []int `json:"outletIds" binding:"required"`
+}
+
+// MapOutletsToAdminRequest adds outlets to a verified admin
+type MapOutletsToAdminRequest struct {
+	AdminID   int   `json:"adminId" binding:"required"`
+	OutletIDs []int `json:"outletIds" binding:"required"`
+}
+
+// AssignAdminPermissionsRequest sets an admin's permissions per outlet
+type AssignAdminPermissionsRequest struct {
+	AdminID     int                                 `json:"adminId" binding:"required"`
+	Permissions map[int][]AdminPermissionGrantInput `json:"permissions" binding:"required,dive,dive" doc:"Keyed by outlet id"`
+}
+
+// AdminPermissionGrantInput grants or revokes one admin permission
+type AdminPermissionGrantInput struct {
+	Type      models.AdminPermissionType `json:"type" binding:"required"`
+	IsGranted bool                       `json:"isGranted"`
+}
+
+// VerifyStaffRequest approves a staff signup and assigns an outlet
+type VerifyStaffRequest struct {
+	OutletID  int    `json:"outletId" binding:"required"`
+	StaffRole string `json:"staffRole"`
+}
+
+// UnlockLoginRequest clears a login lockout. Give an email to unlock an
+// account, an IP address to unlock a client, or both.
+type UnlockLoginRequest struct {
+	Scope       models.LoginThrottleScope `json:"scope"`
+	AccountType models.AuthAccountType    `json:"accountType"`
+	Email       string                    `json:"email"`
+	IPAddress   string                    `json:"ipAddress"`
+}
+
+// ProductForm creates or replaces a product. It is sent as multipart form
+// data so an image can be attached.
+type ProductForm struct {
+	Name        string                `json:"name" form:"name" binding:"required"`
+	Description string                `json:"description" form:"description" binding:"required"`
+	Price       float64               `json:"price" form:"price" binding:"required"`
+	OutletID    int                   `json:"outletId" form:"outletId" binding:"required"`
+	Category    models.Category       `json:"category" form:"category" binding:"required"`
+	Threshold   *int                  `json:"threshold" form:"threshold" doc:"Low-stock threshold, defaults to 10"`
+	MinValue    *int                  `json:"minValue" form:"minValue"`
+	IsVeg       *bool                 `json:"isVeg" form:"isVeg" doc:"Defaults to true"`
+	CompanyPaid *bool                 `json:"companyPaid" form:"companyPaid"`
+	Image       *multipart.FileHeader `json:"image" form:"image"`
+}
+
+// UpdateStaffForm updates a staff member. It is sent as multipart form data
+// so an image can be attached; only the fields sent are changed.
+type UpdateStaffForm struct {
+	Name      string                `json:"name" form:"name"`
+	Email     string                `json:"email" form:"email"`
+	Phone     string                `json:"phone" form:"phone"`
+	StaffRole string                `json:"staffRole" form:"staffRole"`
+	Image     *multipart.FileHeader `json:"image" form:"image"`
+}
+
+// AuditEventsQuery selects the audit export format
+type AuditEventsQuery struct {
+	Format string `form:"format" binding:"omitempty,oneof=csv" doc:"csv downloads the filtered events instead of a JSON page"`
+}
+
+// LoginLockoutsQuery narrows the lockout list
+type LoginLockoutsQuery struct {
+	Active bool `form:"active" doc:"Only lockouts still in force"`
+}
+
+// OutletResponse is a created outlet
+type OutletResponse struct {
+	Message string        `json:"message"`
+	Outlet  models.Outlet `json:"outlet"`
+}
+
+// RatingWeightsResponse is an outlet's updated rating weights
+type RatingWeightsResponse struct {
+	Message string        `json:"message"`
+	Weights RatingWeights `json:"weights"`
+}
+
+// AdminProductsResponse lists an outlet's products with their inventory
+type AdminProductsResponse struct {
+	Success bool           `json:"success"`
+	Data    []AdminProduct `json:"data"`
+}
+
+// AdminProduct is a product as managed by admins
+type AdminProduct struct {
+	ID          int               `json:"id"`
+	Name        string            `json:"name"`
+	Description *string           `json:"description"`
+	Price       float64           `json:"price"`
+	ImageURL    *string           `json:"imageUrl" doc:"Signed URL"`
+	OutletID    int               `json:"outletId"`
+	Category    models.Category   `json:"category"`
+	MinValue    *int              `json:"minValue"`
+	IsVeg       bool              `json:"isVeg"`
+	CompanyPaid bool              `json:"companyPaid"`
+	Inventory   *models.Inventory `json:"inventory"`
+}
+
+// CreateProductResponse is a summary of the created product
+type CreateProductResponse struct {
+	Message string         `json:"message"`
+	Product CreatedProduct `json:"product"`
+}
+
+// CreatedProduct summarises a created product
+type CreatedProduct struct {
+	Name     string  `json:"name"`
+	Price    float64 `json:"price"`
+	MinValue *int    `json:"minValue"`
+	ImageURL *string `json:"imageUrl"`
+}
+
+// UpdateProductResponse is the product after an update
+type UpdateProductResponse struct {
+	Success bool           `json:"success"`
+	Message string         `json:"message"`
+	Data    models.Product `json:"data"`
+}
+
+// AddStaffResponse is the created staff account
+type AddStaffResponse struct {
+	Message string       `json:"message"`
+	User    CreatedStaff `json:"user"`
+}
+
+// CreatedStaff is a newly created staff account
+type CreatedStaff struct {
+	ID    int         `json:"id"`
+	Email string      `json:"email"`
+	Role  models.Role `json:"role"`
+}
+
+// StaffPermissionResponse is the permission after a grant or revoke
+type StaffPermissionResponse struct {
+	Message    string                 `json:"message"`
+	Permission models.StaffPermission `json:"permission"`
+}
+
+// OutletStaffResponse lists an outlet's staff
+type OutletStaffResponse struct {
+	Staffs []OutletStaffMember `json:"staffs"`
+	Page
+}
+
+// OutletStaffMember is a staff member with their permissions
+type OutletStaffMember struct {
+	ID          int                      `json:"id"`
+	UserID      int                      `json:"userId"`
+	StaffRole   string                   `json:"staffRole"`
+	Permissions []models.StaffPermission `json:"permissions"`
+	User        StaffUser                `json:"user"`
+}
+
+// StaffUser is the user account of a staff member
+type StaffUser struct {
+	ID       int         `json:"id"`
+	Name     string      `json:"name"`
+	Email    string      `json:"email"`
+	Phone    *string     `json:"phone"`
+	Role     models.Role `json:"role"`
+	OutletID *int        `json:"outletId"`
+	ImageURL *string     `json:"imageUrl" doc:"Signed URL"`
+}
+
+// UpdateStaffResponse is the staff member after an update
+type UpdateStaffResponse struct {
+	Message string              `json:"message"`
+	Staff   models.StaffDetails `json:"staff"`
+}
+
+// StaffDetailsResponse is one staff member
+type StaffDetailsResponse struct {
+	Staff models.StaffDetails `json:"staff"`
+}
+
+// ExpenseResponse is a recorded expense
+type ExpenseResponse struct {
+	Message string         `json:"message"`
+	Expense models.Expense `json:"expense"`
+}
+
+// ExpensesResponse lists expenses
+type ExpensesResponse struct {
+	Message  string           `json:"message"`
+	Count    int              `json:"count,omitempty" doc:"Expenses on this page; date range queries only"`
+	Expenses []models.Expense `json:"expenses"`
+	Page
+}
+
+// OutletTicketsResponse is a page of tickets raised by an outlet's customers
+type OutletTicketsResponse struct {
+	Tickets []OutletTicket `json:"tickets"`
+	Page
+}
+
+// OutletTicket is a support ticket as shown to admins
+type OutletTicket struct {
+	TicketID       int                 `json:"ticketId"`
+	Description    string              `json:"description"`
+	Priority       models.Priority     `json:"priority"`
+	Status         models.TicketStatus `json:"status"`
+	CreatedAt      time.Time           `json:"createdAt"`
+	CustomerName   string              `json:"customerName"`
+	CustomerEmail  string              `json:"customerEmail"`
+	ResolutionNote *string             `json:"resolutionNote"`
+	ResolvedAt     *time.Time          `json:"resolvedAt"`
+	Internal       bool                `json:"internal" doc:"Opened by a feedback alert rule; hidden from the customer"`
+	FeedbackID     *int                `json:"feedbackId"`
+	OrderID        *int                `json:"orderId"`
+}
+
+// CloseTicketResponse is the resolved ticket
+type CloseTicketResponse struct {
+	Message string        `json:"message"`
+	Ticket  models.Ticket `json:"ticket"`
+}
+
+// OutletFeedbackResponse is a page of the feedback on an outlet's products
+type OutletFeedbackResponse struct {
+	Feedback []ModeratedFeedback `json:"feedback"`
+	Page
+}
+
+// ModeratedFeedback is feedback as shown to moderators
+type ModeratedFeedback struct {
+	ID               int                   `json:"id"`
+	OrderID          int                   `json:"orderId"`
+	ProductID        int                   `json:"productId"`
+	ProductName      string                `json:"productName"`
+	CustomerName     string                `json:"customerName"`
+	RatingOverall    float64               `json:"ratingOverall"`
+	RatingTaste      float64               `json:"ratingTaste"`
+	RatingQuality    float64               `json:"ratingQuality"`
+	RatingQuantity   float64               `json:"ratingQuantity"`
+	Comment          *string               `json:"comment"`
+	CreatedAt        time.Time             `json:"createdAt"`
+	Status           models.FeedbackStatus `json:"status"`
+	ModerationReason *string               `json:"moderationReason"`
+	ModeratedAt      *time.Time            `json:"moderatedAt"`
+	Reply            *string               `json:"reply"`
+	RepliedAt        *time.Time            `json:"repliedAt"`
+}
+
+// ModerateFeedbackRequest hides or restores feedback
+type ModerateFeedbackRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// FeedbackReplyRequest sets the outlet's public reply to feedback
+type FeedbackReplyRequest struct {
+	Reply string `json:"reply" binding:"required"`
+}
+
+// FeedbackResponse is feedback after a moderation change or reply
+type FeedbackResponse struct {
+	Message  string          `json:"message"`
+	Feedback models.Feedback `json:"feedback"`
+}
+
+// CouponResponse is a created coupon
+type CouponResponse struct {
+	Message string        `json:"message"`
+	Data    models.Coupon `json:"data"`
+}
+
+// CouponListResponse lists coupons
+type CouponListResponse struct {
+	Message string          `json:"message"`
+	Data    []models.Coupon `json:"data"`
+	Page
+}
+
+// OutletCustomersResponse is a page of an outlet's customers with their totals
+type OutletCustomersResponse struct {
+	Message   string           `json:"message"`
+	Customers []OutletCustomer `json:"customers"`
+	Page
+}
+
+// OutletCustomer is a customer with their wallet and order totals
+type OutletCustomer struct {
+	CustomerID        *int    `json:"customerId"`
+	WalletID          *int    `json:"walletId"`
+	Name              string  `json:"name"`
+	Email             string  `json:"email"`
+	YearOfStudy       *int    `json:"yearOfStudy"`
+	PhoneNo           *string `json:"phoneNo"`
+	WalletBalance     float64 `json:"walletBalance"`
+	TotalOrders       int64   `json:"totalOrders"`
+	TotalPurchaseCost float64 `json:"totalPurchaseCost"`
+	LastOrderDate     *string `json:"lastOrderDate" doc:"RFC 3339 timestamp"`
+}
+
+// WalletCustomersResponse is a page of an outlet's customers with wallet totals
+type WalletCustomersResponse struct {
+	Message string           `json:"message"`
+	Count   int              `json:"count"`
+	Data    []WalletCustomer `json:"data"`
+	Page
+}
+
+// WalletCustomer is a customer's wallet totals
+type WalletCustomer struct {
+	UserID         int        `json:"userId"`
+	Name           string     `json:"name"`
+	Email          string     `json:"email"`
+	Phone          *string    `json:"phone"`
+	CustomerID     *int       `json:"customerId"`
+	WalletID       *int       `json:"walletId"`
+	Balance        float64    `json:"balance"`
+	TotalRecharged float64    `json:"totalRecharged"`
+	TotalUsed      float64    `json:"totalUsed"`
+	LastRecharged  *time.Time `json:"lastRecharged"`
+	LastOrder      *time.Time `json:"lastOrder"`
+}
+
+// OutletRechargesResponse is a page of wallet recharges at an outlet
+type OutletRechargesResponse struct {
+	Message string           `json:"message"`
+	Count   int              `json:"count"`
+	Data    []RechargeRecord `json:"data"`
+	Page
+}
+
+// RechargeRecord is one wallet recharge
+type RechargeRecord struct {
+	CustomerName string                 `json:"customerName"`
+	RechargeID   int                    `json:"rechargeId"`
+	Amount       float64                `json:"amount"`
+	Date         time.Time              `json:"date"`
+	Method       models.PaymentMethod   `json:"method"`
+	Status       models.WalletTransType `json:"status"`
+}
+
+// WalletOrdersResponse is a page of orders paid from wallets
+type WalletOrdersResponse struct {
+	Message string        `json:"message"`
+	Count   int           `json:"count"`
+	Data    []WalletOrder `json:"data"`
+	Page
+}
+
+// WalletOrder is an order paid from a wallet
+type WalletOrder struct {
+	OrderID       int                  `json:"orderId"`
+	CustomerName  string               `json:"customerName"`
+	OrderTotal    float64              `json:"orderTotal"`
+	OrderDate     time.Time            `json:"orderDate"`
+	PaymentMethod models.PaymentMethod `json:"paymentMethod"`
+}
+
+// OutletOrdersResponse is a page of an outlet's orders
+type OutletOrdersResponse struct {
+	Orders []OutletOrder `json:"orders"`
+	Page
+}
+
+// OutletOrder is an order as listed to admins
+type OutletOrder struct {
+	OrderID       int                  `json:"orderId"`
+	OrderTime     time.Time            `json:"orderTime"`
+	TotalAmount   float64              `json:"totalAmount"`
+	PaymentMethod models.PaymentMethod `json:"paymentMethod"`
+	Status        string               `json:"status"`
+	CustomerName  string               `json:"customerName"`
+	CustomerPhone *string              `json:"customerPhone"`
+	DeliveryDate  *time.Time           `json:"deliveryDate"`
+	DeliverySlot  *models.DeliverySlot `json:"deliverySlot"`
+	Type          models.OrderType     `json:"type"`
+	Items         []OutletOrderItem    `json:"items"`
+}
+
+// OutletOrderItem is one line of an order as listed to admins
+type OutletOrderItem struct {
+	ProductName string  `json:"productName"`
+	Quantity    int     `json:"quantity"`
+	UnitPrice   float64 `json:"unitPrice"`
+	TotalPrice  float64 `json:"totalPrice"`
+}
+
+// AppFeaturesResponse reports which app features are on for an outlet
+type AppFeaturesResponse struct {
+	Message string          `json:"message"`
+	Data    map[string]bool `json:"data" doc:"Keyed by feature"`
+}
+
+// NonAvailabilityResponse lists the outlet's closed dates and slots
+type NonAvailabilityResponse struct {
+	Message string            `json:"message"`
+	Data    []UnavailableDate `json:"data"`
+}
+
+// UnavailableDate is a date with the delivery slots closed on it
+type UnavailableDate struct {
+	Date              string   `json:"date" doc:"YYYY-MM-DD"`
+	NonAvailableSlots []string `json:"nonAvailableSlots"`
+}
+
+// DashboardOverviewResponse is the headline numbers across all outlets
+type DashboardOverviewResponse struct {
+	TotalActiveOutlets  int64          `json:"totalActiveOutlets"`
+	TotalRevenue        float64        `json:"totalRevenue"`
+	TotalCustomers      int64          `json:"totalCustomers"`
+	TotalOrders         int64          `json:"totalOrders"`
+	TopPerformingOutlet *models.Outlet `json:"topPerformingOutlet" doc:"Only id and name are set"`
+}
+
+// OrderStatusDistribution counts orders by status
+type OrderStatusDistribution struct {
+	Delivered          int64 `json:"delivered"`
+	Pending            int64 `json:"pending"`
+	Cancelled          int64 `json:"cancelled"`
+	PartiallyDelivered int64 `json:"partiallyDelivered"`
+}
+
+// TopSellingItem is a product ranked by orders
+type TopSellingItem struct {
+	ProductID    int     `json:"productId"`
+	ProductName  string  `json:"productName"`
+	TotalOrders  int     `json:"totalOrders"`
+	TotalRevenue float64 `json:"totalRevenue"`
+}
+
+// PeakTimeSlot is the number of orders in one delivery slot
+type PeakTimeSlot struct {
+	TimeSlot    string `json:"timeSlot"`
+	DisplayName string `json:"displayName"`
+	OrderCount  int64  `json:"orderCount"`
+}
+
+// PendingAdmin is an admin signup waiting for verification
+type PendingAdmin struct {
+	ID        int       `json:"id"`
+	Email     string    `json:"email"`
+	Name      string    `json:"name"`
+	Phone     *string   `json:"phone"`
+	AadharURL *string   `json:"aadharUrl" doc:"Signed URL"`
+	PanURL    *string   `json:"panUrl" doc:"Signed URL"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// VerifyAdminResponse is the verified admin and their outlets
+type VerifyAdminResponse struct {
+	Message string        `json:"message"`
+	Admin   VerifiedAdmin `json:"admin"`
+}
+
+// VerifiedAdmin is an admin and the outlets they were mapped to
+type VerifiedAdmin struct {
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	Email     string `json:"email"`
+	OutletIDs []int  `json:"outletIds"`
+}
+
+// AdminSummary is a verified admin
+type AdminSummary struct {
+	ID        int       `json:"id"`
+	Email     string    `json:"email"`
+	Name      string    `json:"name"`
+	Phone     *string   `json:"phone"`
+	CreatedAt time.Time `json:"createdAt"`
+	Outlets   []int     `json:"outlets" doc:"Outlet ids"`
+}
+
+// AdminDetailsResponse is an admin with their outlets and permissions
+type AdminDetailsResponse struct {
+	ID         int                 `json:"id"`
+	Email      string              `json:"email"`
+	Phone      *string             `json:"phone"`
+	AadharURL  *string             `json:"aadharUrl" doc:"Signed URL"`
+	PanURL     *string             `json:"panUrl" doc:"Signed URL"`
+	CreatedAt  time.Time           `json:"createdAt"`
+	IsVerified bool                `json:"isVerified"`
+	Outlets    []AdminOutletDetail `json:"outlets"`
+}
+
+// AdminOutletDetail is one outlet an admin manages and their permissions there
+type AdminOutletDetail struct {
+	OutletID    int                         `json:"outletId"`
+	Outlet      OutletAddress               `json:"outlet"`
+	Permissions []AdminPermissionGrantInput `json:"permissions"`
+}
+
+// OutletAddress is an outlet's name and address
+type OutletAddress struct {
+	Name    string  `json:"name"`
+	Address *string `json:"address"`
+}
+
+// MapOutletsResponse is the admin's outlets after mapping
+type MapOutletsResponse struct {
+	Message string            `json:"message"`
+	Admin   AdminOutletsOwner `json:"admin"`
+}
+
+// AdminOutletsOwner is an admin and the outlets they manage
+type AdminOutletsOwner struct {
+	ID      int            `json:"id"`
+	Email   string         `json:"email"`
+	Outlets []MappedOutlet `json:"outlets"`
+}
+
+// MappedOutlet is an outlet mapped to an admin
+type MappedOutlet struct {
+	OutletID int     `json:"outletId"`
+	Name     string  `json:"name"`
+	Address  *string `json:"address"`
+}
+
+// AssignPermissionsResponse confirms an admin's permissions were set
+type AssignPermissionsResponse struct {
+	Message string `json:"message"`
+	AdminID int    `json:"adminId"`
+}
+
+// VerifyStaffResponse confirms a staff signup was approved
+type VerifyStaffResponse struct {
+	Message string `json:"message"`
+	UserID  int    `json:"userId"`
+}
+
+// NotificationResponse is a scheduled notification
+type NotificationResponse struct {
+	Success bool                `json:"success"`
+	Message string              `json:"message"`
+	Data    models.Notification `json:"data"`
+}
+
+// NotificationsResponse lists scheduled notifications
+type NotificationsResponse struct {
+	Success bool                           `json:"success"`
+	Data    []models.ScheduledNotification `json:"data"`
+	Page
+}
+
+// SendNotificationResponse reports how many devices a push reached
+type SendNotificationResponse struct {
+	Success bool           `json:"success"`
+	Message string         `json:"message"`
+	Data    DeliveryReport `json:"data"`
+}
+
+// DeliveryReport counts the devices a push reached
+type DeliveryReport struct {
+	SentCount    int `json:"sentCount"`
+	TotalDevices int `json:"totalDevices"`
+}
+
+// NotificationStatsResponse counts scheduled notifications by state
+type NotificationStatsResponse struct {
+	Success bool              `json:"success"`
+	Data    NotificationStats `json:"data"`
+}
+
+// NotificationStats counts scheduled notifications by state
+type NotificationStats struct {
+	Total   int64 `json:"total"`
+	Sent    int64 `json:"sent"`
+	Pending int64 `json:"pending"`
+}
+
+// FCMStatusResponse reports whether push notifications are configured
+type FCMStatusResponse struct {
+	Success bool                   `json:"success"`
+	Message string                 `json:"message"`
+	Data    map[string]interface{} `json:"data"`
+}
+
+// TestNotificationResponse is the FCM message id of a test push
+type TestNotificationResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+	Data    string `json:"data" doc:"FCM message id"`
+}
+
+// LowStockResponse lists products below their stock threshold
+type LowStockResponse struct {
+	Success bool            `json:"success"`
+	Count   int             `json:"count"`
+	Data    []LowStockAlert `json:"data"`
+}
+
+// LowStockAlert is a product below its stock threshold
+type LowStockAlert struct {
+	ProductID   int    `json:"productId"`
+	ProductName string `json:"productName"`
+	Quantity    int    `json:"quantity"`
+	Threshold   int    `json:"threshold"`
+	OutletID    int    `json:"outletId"`
+}
+
+// ProductSales is a product's orders, quantity and revenue
+type ProductSales struct {
+	ProductID   int     `json:"productId"`
+	ProductName string  `json:"productName"`
+	TotalOrders int     `json:"totalOrders"`
+	Quantity    int     `json:"quantity"`
+	Revenue     float64 `json:"revenue"`
+}
+
+// ProductRevenue is a product's revenue
+type ProductRevenue struct {
+	ProductID   int     `json:"productId"`
+	ProductName string  `json:"productName"`
+	Revenue     float64 `json:"revenue"`
+}
+
+// RevenueSplit breaks revenue down by source
+type RevenueSplit struct {
+	RevenueByAppOrder       float64 `json:"revenueByAppOrder"`
+	RevenueByManualOrder    float64 `json:"revenueByManualOrder"`
+	RevenueByWalletRecharge float64 `json:"revenueByWalletRecharge"`
+	TotalRevenue            float64 `json:"totalRevenue"`
+}
+
+// MonthlyProfitLoss is one month's sales against expenses
+type MonthlyProfitLoss struct {
+	Month    int     `json:"month" doc:"1-12"`
+	Sales    float64 `json:"sales"`
+	Expenses float64 `json:"expenses"`
+	Profit   float64 `json:"profit"`
+	Status   string  `json:"status" doc:"profit or loss"`
+}
+
+// CustomerOverview splits ordering customers into new and returning
+type CustomerOverview struct {
+	NewCustomers       int `json:"newCustomers"`
+	ReturningCustomers int `json:"returningCustomers"`
+}
+
+// DailyCustomersPerOrder is the ratio of distinct customers to orders on a day
+type DailyCustomersPerOrder struct {
+	Date              string  `json:"date"`
+	CustomersPerOrder float64 `json:"customersPerOrder"`
+}
+
+// AuditEventsResponse is a page of audit events
+type AuditEventsResponse struct {
+	Events []models.AuditEvent `json:"events"`
+	Page
+}
+
+// FailedJobsResponse is a page of failed background jobs
+type FailedJobsResponse struct {
+	Jobs []models.Job `json:"jobs"`
+	Page
+}
+
+// JobResponse is a background job after a change
+type JobResponse struct {
+	Message string     `json:"message"`
+	Job     models.Job `json:"job"`
+}
+
+// LoginLockoutsResponse is a page of lockout events
+type LoginLockoutsResponse struct {
+	Lockouts []models.LoginLockoutEvent `json:"lockouts"`
+	Page
+}
+
+// AlertRuleRequest creates or replaces an outlet's feedback alert rule.
+// Empty categories or keywords match any feedback.
+type AlertRuleRequest struct {
+	OutletID   int      `json:"outletId" doc:"Required when creating; ignored when updating"`
+	Name       string   `json:"name" binding:"required"`
+	MaxRating  float64  `json:"maxRating" binding:"required,min=1,max=5" doc:"Highest overall rating that triggers the rule"`
+	Categories []string `json:"categories" binding:"omitempty,dive,oneof=Meals Starters Desserts Beverages SpecialFoods"`
+	Keywords   []string `json:"keywords" binding:"omitempty,dive,required" doc:"Words or phrases in the comment"`
+	Priority   string   `json:"priority" binding:"omitempty,oneof=LOW MEDIUM HIGH" doc:"Of the tickets opened, HIGH by default"`
+	IsActive   *bool    `json:"isActive" doc:"true when creating; unchanged when omitted on update"`
+}
+
+// AlertRulesResponse lists an outlet's feedback alert rules. Default is set
+// when the outlet has none and the built-in rule applies.
+type AlertRulesResponse struct {
+	Rules   []models.FeedbackAlertRule `json:"rules"`
+	Default *models.FeedbackAlertRule  `json:"default"`
+}
+
+// AlertRuleResponse is a feedback alert rule after a change
+type AlertRuleResponse struct {
+	Message string                   `json:"message"`
+	Rule    models.FeedbackAlertRule `json:"rule"`
+}
+
+// StaffAccountsResponse is a page of staff accounts
+type StaffAccountsResponse struct {
+	Staff []models.User `json:"staff"`
+	Page
+}
@@ -0,0 +1,197 @@
+// Package health implements the liveness and readiness checks used by the
+// load balancer.
+//
+// Liveness only says the process is serving HTTP. Readiness checks every
+// dependency; the server is unready (503) when a critical one is down and
+// degraded, but still ready, when only optional ones are.
+package health
+
+import (
+	"backend_pandhi/pkg/app"
+	"backend_pandhi/pkg/dto"
+	"backend_pandhi/pkg/jobs"
+	"backend_pandhi/pkg/notifications"
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Component states, from best to worst
+const (
+	StatusOK       = "ok"
+	StatusDegraded = "degraded"
+	StatusDown     = "down"
+)
+
+const (
+	// dbPingTimeout bounds the Postgres ping so a hung database fails the
+	// check instead of the load balancer's probe
+	dbPingTimeout = 2 * time.Second
+
+	// poolSaturation is the in-use share of the pool above which the
+	// database is reported degraded
+	poolSaturation = 0.9
+)
+
+// Check runs every readiness check
+func Check(ctx context.Context, a *app.App) dto.ReadinessResponse {
+	now := a.Clock.Now()
+	components := map[string]dto.HealthComponent{
+		"database":               checkDatabase(ctx, a),
+		"storage":                checkInitialized(a.Storage.Ready(), "GCP Storage client not initialized"),
+		"fcm":                    checkInitialized(a.Push.Ready(), "FCM client not initialized"),
+		"razorpay":               checkInitialized(a.Payments.Ready(), "Razorpay credentials not configured"),
+		"notificationDispatcher": checkDispatcher(now),
+		"jobWorker":              checkJobWorker(now),
+	}
+
+	status := StatusOK
+	for _, c := range components {
+		switch {
+		case c.Status == StatusDown && c.Critical:
+			status = StatusDown
+		case c.Status != StatusOK && status == StatusOK:
+			status = StatusDegraded
+		}
+	}
+
+	return dto.ReadinessResponse{
+		Status:      status,
+		Environment: a.Config.Environment,
+		CheckedAt:   now.UTC().Format(time.RFC3339),
+		Components:  components,
+	}
+}
+
+func checkDatabase(ctx context.Context, a *app.App) dto.HealthComponent {
+	result := dto.HealthComponent{Status: StatusOK, Critical: true}
+
+	if a.DB == nil {
+		result.Status = StatusDown
+		result.Message = "Database not initialized"
+		return result
+	}
+	sqlDB, err := a.DB.DB()
+	if err != nil {
+		result.Status = StatusDown
+		result.Message = "Database handle unavailable"
+		return result
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, dbPingTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err = sqlDB.PingContext(ctx)
+	result.LatencyMs = milliseconds(time.Since(start))
+	if err != nil {
+		result.Status = StatusDown
+		result.Message = "Ping failed"
+		return result
+	}
+
+	stats := sqlDB.Stats()
+	result.Details = map[string]interface{}{
+		"openConnections": stats.OpenConnections,
+		"inUse":           stats.InUse,
+		"idle":            stats.Idle,
+		"maxOpen":         stats.MaxOpenConnections,
+		"waitCount":       stats.WaitCount,
+		"waitDurationMs":  milliseconds(stats.WaitDuration),
+	}
+	if stats.MaxOpenConnections > 0 {
+		saturation := float64(stats.InUse) / float64(stats.MaxOpenConnections)
+		result.Details["saturation"] = saturation
+		if saturation >= poolSaturation {
+			result.Status = StatusDegraded
+			result.Message = "Connection pool nearly exhausted"
+		}
+	}
+	return result
+}
+
+func checkInitialized(ready bool, message string) dto.HealthComponent {
+	if ready {
+		return dto.HealthComponent{Status: StatusOK}
+	}
+	return dto.HealthComponent{Status: StatusDown, Message: message}
+}
+
+func checkDispatcher(now time.Time) dto.HealthComponent {
+	d := notifications.Default
+	result := dto.HealthComponent{Status: StatusOK}
+
+	last := d.LastHeartbeat()
+	if !last.IsZero() {
+		result.Details = map[string]interface{}{
+			"lastHeartbeat": last.UTC().Format(time.RFC3339),
+		}
+	}
+	if !d.Healthy(now) {
+		result.Status = StatusDown
+		result.Message = "No heartbeat within three dispatch intervals"
+	}
+	return result
+}
+
+func checkJobWorker(now time.Time) dto.HealthComponent {
+	w := jobs.Default
+	result := dto.HealthComponent{Status: StatusOK}
+
+	last := w.LastHeartbeat()
+	if !last.IsZero() {
+		result.Details = map[string]interface{}{
+			"lastHeartbeat": last.UTC().Format(time.RFC3339),
+		}
+	}
+	if !w.Healthy(now) {
+		result.Status = StatusDown
+		result.Message = "No heartbeat within three poll intervals"
+	}
+	return result
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// LiveHandler answers as long as the process can serve requests
+func LiveHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, dto.LivenessResponse{Status: StatusOK})
+}
+
+// ReadyHandler reports every component, with 503 when the server should not
+// receive traffic
+func ReadyHandler(a *app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		report := Check(c.Request.Context(), a)
+		c.JSON(statusCode(report), report)
+	}
+}
+
+// Handler serves the summary /api/health has always returned, now backed by
+// the readiness checks
+func Handler(a *app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		report := Check(c.Request.Context(), a)
+
+		db := "connected"
+		if report.Components["database"].Status == StatusDown {
+			db = "disconnected"
+		}
+		c.JSON(statusCode(report), dto.HealthResponse{
+			Status:      report.Status,
+			Environment: report.Environment,
+			Database:    db,
+		})
+	}
+}
+
+func statusCode(report dto.ReadinessResponse) int {
+	if report.Status == StatusDown {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusOK
+}
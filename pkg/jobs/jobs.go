@@ -0,0 +1,188 @@
+// Package jobs runs background work from a durable queue in the Job table.
+//
+// A kind of job is declared once with Define, which ties a name to a handler
+// taking a typed payload. Enqueueing writes a row, so work queued inside a
+// transaction only exists if the transaction commits and survives restarts.
+// Workers on every server claim due jobs with FOR UPDATE SKIP LOCKED, retry
+// failures with exponential backoff and move jobs that keep failing to the
+// FAILED status, where an admin can inspect and retry them.
+package jobs
+
+import (
+	"backend_pandhi/pkg/app"
+	"backend_pandhi/pkg/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Policy controls how a kind of job is run. Zero fields take the defaults.
+type Policy struct {
+	// MaxAttempts is how many times a job runs before it is marked FAILED;
+	// default 5
+	MaxAttempts int
+	// Timeout bounds one run; default 1 minute
+	Timeout time.Duration
+	// Backoff returns the delay before the next attempt after the given
+	// number of attempts has failed; default 10s doubling up to an hour
+	Backoff func(attempts int) time.Duration
+}
+
+func (p Policy) withDefaults() Policy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = 5
+	}
+	if p.Timeout <= 0 {
+		p.Timeout = time.Minute
+	}
+	if p.Backoff == nil {
+		p.Backoff = ExponentialBackoff
+	}
+	return p
+}
+
+// ExponentialBackoff waits 10s after the first failure and doubles the wait
+// after each further one, up to an hour, with up to 20% jitter so jobs that
+// failed together do not retry together
+func ExponentialBackoff(attempts int) time.Duration {
+	d := time.Hour
+	if attempts < 10 {
+		d = min(10*time.Second<<max(attempts-1, 0), time.Hour)
+	}
+	return d + time.Duration(rand.Int63n(int64(d)/5+1))
+}
+
+// Handler runs one job with its decoded payload. A returned error, or a
+// panic, fails the attempt.
+type Handler[T any] func(ctx context.Context, a *app.App, payload T) error
+
+// Kind is a declared kind of job with payloads of type T
+type Kind[T any] struct {
+	Name   string
+	Policy Policy
+}
+
+// Options are per-job settings for Enqueue
+type Options struct {
+	// RunAt delays the job; zero runs it as soon as a worker is free
+	RunAt time.Time
+	// UniqueKey drops the job when a pending job has the same key, so
+	// repeated requests for the same work queue it once
+	UniqueKey string
+}
+
+// kind is the untyped view of a Kind used by workers
+type kind struct {
+	policy Policy
+	run    func(ctx context.Context, a *app.App, payload []byte) error
+}
+
+var registry = map[string]kind{}
+
+// Define declares a kind of job. It is meant for package-level variables and
+// panics when the name is taken.
+func Define[T any](name string, handler Handler[T], policy Policy) *Kind[T] {
+	if _, ok := registry[name]; ok {
+		panic(fmt.Sprintf("jobs: kind %q defined twice", name))
+	}
+	policy = policy.withDefaults()
+	registry[name] = kind{
+		policy: policy,
+		run: func(ctx context.Context, a *app.App, payload []byte) error {
+			var v T
+			if err := json.Unmarshal(payload, &v); err != nil {
+				return fmt.Errorf("invalid payload: %w", err)
+			}
+			return handler(ctx, a, v)
+		},
+	}
+	return &Kind[T]{Name: name, Policy: policy}
+}
+
+// Enqueue queues a job through db. Pass the transaction that made the change
+// the job follows up on, so the job is only queued if it commits.
+func (k *Kind[T]) Enqueue(db *gorm.DB, payload T, opts Options) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s payload: %w", k.Name, err)
+	}
+
+	job := models.Job{
+		Kind:        k.Name,
+		Payload:     string(data),
+		Status:      models.JobStatusPending,
+		MaxAttempts: k.Policy.MaxAttempts,
+		RunAt:       opts.RunAt,
+	}
+	query := db
+	if job.RunAt.IsZero() {
+		query = query.Omit("runAt")
+	}
+	if opts.UniqueKey != "" {
+		job.UniqueKey = &opts.UniqueKey
+		// The target matches the partial unique index on pending jobs
+		query = query.Clauses(clause.OnConflict{
+			Columns:     []clause.Column{{Name: "uniqueKey"}},
+			TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: `"status" = 'PENDING'`}}},
+			DoNothing:   true,
+		})
+	}
+	if err := query.Create(&job).Error; err != nil {
+		return fmt.Errorf("failed to enqueue %s job: %w", k.Name, err)
+	}
+	return nil
+}
+
+var (
+	// ErrNotFound means no job has the ID
+	ErrNotFound = errors.New("job not found")
+
+	// ErrNotFailed means the job has not failed, so there is nothing to retry
+	ErrNotFailed = errors.New("job has not failed")
+
+	// ErrDuplicate means a pending job with the same unique key already
+	// exists
+	ErrDuplicate = errors.New("an identical job is already pending")
+)
+
+// Retry queues a FAILED job again with a fresh set of attempts. It returns
+// the job before the change.
+func Retry(tx *gorm.DB, id int, now time.Time) (models.Job, error) {
+	var job models.Job
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&job, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return job, ErrNotFound
+		}
+		return job, err
+	}
+	if job.Status != models.JobStatusFailed {
+		return job, ErrNotFailed
+	}
+	if job.UniqueKey != nil {
+		var pending int64
+		if err := tx.Model(&models.Job{}).
+			Where(`"uniqueKey" = ? AND status = ?`, *job.UniqueKey, models.JobStatusPending).
+			Count(&pending).Error; err != nil {
+			return job, err
+		}
+		if pending > 0 {
+			return job, ErrDuplicate
+		}
+	}
+
+	err := tx.Model(&models.Job{}).Where("id = ?", job.ID).Updates(map[string]interface{}{
+		"status":      models.JobStatusPending,
+		"attempts":    0,
+		"runAt":       now,
+		"finishedAt":  nil,
+		"lockedBy":    nil,
+		"lockedUntil": nil,
+	}).Error
+	return job, err
+}
@@ -0,0 +1,155 @@
+package jobs_test
+
+import (
+	"backend_pandhi/pkg/app"
+	"backend_pandhi/pkg/jobs"
+	"backend_pandhi/pkg/models"
+	"backend_pandhi/pkg/testenv"
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(testenv.Main(m))
+}
+
+type echo struct {
+	Value string `json:"value"`
+}
+
+var (
+	calls []string
+
+	echoJob = jobs.Define("test.echo", func(ctx context.Context, a *app.App, p echo) error {
+		calls = append(calls, p.Value)
+		return nil
+	}, jobs.Policy{})
+
+	brokenJob = jobs.Define("test.broken", func(ctx context.Context, a *app.App, p echo) error {
+		return errors.New("broken")
+	}, jobs.Policy{MaxAttempts: 2, Backoff: func(int) time.Duration { return 0 }})
+)
+
+func TestExponentialBackoff(t *testing.T) {
+	for _, tc := range []struct {
+		attempts int
+		min      time.Duration
+	}{
+		{1, 10 * time.Second},
+		{2, 20 * time.Second},
+		{4, 80 * time.Second},
+		{20, time.Hour},
+	} {
+		d := jobs.ExponentialBackoff(tc.attempts)
+		if d < tc.min || d > tc.min+tc.min/5 {
+			t.Errorf("ExponentialBackoff(%d) = %v, want %v plus up to 20%%", tc.attempts, d, tc.min)
+		}
+	}
+}
+
+func TestUniqueKeyQueuesOnce(t *testing.T) {
+	env := testenv.New(t)
+	calls = nil
+
+	for _, v := range []string{"a", "b"} {
+		if err := echoJob.Enqueue(env.DB, echo{Value: v}, jobs.Options{UniqueKey: "same"}); err != nil {
+			t.Fatalf("Enqueue: %v", err)
+		}
+	}
+	if err := echoJob.Enqueue(env.DB, echo{Value: "later"}, jobs.Options{RunAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	ran, err := jobs.RunDue(context.Background(), env.App)
+	if err != nil {
+		t.Fatalf("RunDue: %v", err)
+	}
+	if ran != 1 || len(calls) != 1 || calls[0] != "a" {
+		t.Errorf("ran %d job(s) with %v, want only the first unique one", ran, calls)
+	}
+
+	// Once the first has run, the key is free again
+	if err := echoJob.Enqueue(env.DB, echo{Value: "c"}, jobs.Options{UniqueKey: "same"}); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	var pending int64
+	env.DB.Model(&models.Job{}).Where("status = ?", models.JobStatusPending).Count(&pending)
+	if pending != 2 {
+		t.Errorf("%d pending jobs, want the scheduled one and the new one", pending)
+	}
+}
+
+func TestFailedJobsAreDeadLetteredAndRetried(t *testing.T) {
+	env := testenv.New(t)
+	ctx := context.Background()
+
+	if err := brokenJob.Enqueue(env.DB, echo{}, jobs.Options{}); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	if _, err := jobs.RunDue(ctx, env.App); err != nil {
+		t.Fatalf("RunDue: %v", err)
+	}
+
+	var job models.Job
+	if err := env.DB.First(&job).Error; err != nil {
+		t.Fatal(err)
+	}
+	if job.Status != models.JobStatusFailed || job.Attempts != 2 || job.LastError == nil || *job.LastError != "broken" {
+		t.Fatalf("job = %s after %d attempts (%v), want FAILED after 2", job.Status, job.Attempts, job.LastError)
+	}
+
+	if _, err := jobs.Retry(env.DB, job.ID, time.Now()); err != nil {
+		t.Fatalf("Retry: %v", err)
+	}
+	env.Reload(t, &job)
+	if job.Status != models.JobStatusPending || job.Attempts != 0 {
+		t.Errorf("retried job = %s with %d attempts, want PENDING with 0", job.Status, job.Attempts)
+	}
+	if _, err := jobs.Retry(env.DB, job.ID, time.Now()); !errors.Is(err, jobs.ErrNotFailed) {
+		t.Errorf("second Retry = %v, want ErrNotFailed", err)
+	}
+}
+
+func TestExpiredLeasesAreReclaimedUntilAttemptsRunOut(t *testing.T) {
+	env := testenv.New(t)
+	calls = nil
+
+	worker, expired := "gone:1", time.Now().Add(-time.Minute)
+	stuck := func(value string, attempts int) models.Job {
+		job := models.Job{
+			Kind:        echoJob.Name,
+			Payload:     `{"value":"` + value + `"}`,
+			Status:      models.JobStatusRunning,
+			Attempts:    attempts,
+			MaxAttempts: 2,
+			RunAt:       expired,
+			LockedBy:    &worker,
+			LockedUntil: &expired,
+		}
+		if err := env.DB.Create(&job).Error; err != nil {
+			t.Fatal(err)
+		}
+		return job
+	}
+	retried := stuck("retried", 1)
+	exhausted := stuck("exhausted", 2)
+
+	if _, err := jobs.RunDue(context.Background(), env.App); err != nil {
+		t.Fatalf("RunDue: %v", err)
+	}
+	if len(calls) != 1 || calls[0] != "retried" {
+		t.Errorf("ran %v, want only the job with an attempt left", calls)
+	}
+
+	env.Reload(t, &retried)
+	if retried.Status != models.JobStatusSucceeded || retried.Attempts != 2 {
+		t.Errorf("reclaimed job = %s after %d attempts, want SUCCEEDED after 2", retried.Status, retried.Attempts)
+	}
+	env.Reload(t, &exhausted)
+	if exhausted.Status != models.JobStatusFailed || exhausted.Attempts != 2 || exhausted.FinishedAt == nil {
+		t.Errorf("exhausted job = %s after %d attempts, want FAILED after 2", exhausted.Status, exhausted.Attempts)
+	}
+}
@@ -0,0 +1,370 @@
+package jobs
+
+import (
+	"backend_pandhi/pkg/app"
+	"backend_pandhi/pkg/metrics"
+	"backend_pandhi/pkg/models"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// leaseGrace is added to a kind's timeout to get the lease of a claimed job,
+// so a job is only taken over once its worker has certainly given up on it
+const leaseGrace = 30 * time.Second
+
+// pruneInterval is how often finished jobs past the retention are deleted
+const pruneInterval = time.Hour
+
+// Worker claims due jobs and runs up to Concurrency of them at a time. It
+// records a heartbeat after every poll, which the readiness check reads.
+type Worker struct {
+	App          *app.App
+	Concurrency  int
+	PollInterval time.Duration
+	// Retention is how long SUCCEEDED and SUPERSEDED jobs are kept
+	Retention time.Duration
+
+	id        string
+	lastBeat  atomic.Int64
+	lastPrune time.Time
+
+	running   sync.WaitGroup
+	stopped   chan struct{}
+	runCtx    context.Context
+	cancelRun context.CancelFunc
+}
+
+// Default is the worker started by the server
+var Default = &Worker{Concurrency: 4, PollInterval: 2 * time.Second, Retention: 7 * 24 * time.Hour}
+
+// Start runs Default for a in the background until ctx is cancelled, with
+// the JOB_* settings
+func Start(ctx context.Context, a *app.App) {
+	Default.App = a
+	Default.Concurrency = a.Config.JobConcurrency
+	Default.PollInterval = a.Config.JobPollInterval
+	Default.Retention = a.Config.JobRetention
+	Default.Start(ctx)
+}
+
+// Start polls for jobs in the background until ctx is cancelled. Jobs that
+// are running then keep going until Drain.
+func (w *Worker) Start(ctx context.Context) {
+	w.init(ctx)
+	w.stopped = make(chan struct{})
+	go w.poll(ctx)
+}
+
+// init names the worker and sets up the context its jobs run in, which
+// outlives ctx until Drain gives up
+func (w *Worker) init(ctx context.Context) {
+	host, _ := os.Hostname()
+	w.id = fmt.Sprintf("%s:%d", host, os.Getpid())
+	w.runCtx, w.cancelRun = context.WithCancel(context.WithoutCancel(ctx))
+}
+
+// Drain waits for the jobs being run after the ctx given to Start has been
+// cancelled. When ctx expires first the running jobs are cancelled and put
+// back in the queue without using up an attempt.
+func (w *Worker) Drain(ctx context.Context) error {
+	if w.stopped == nil {
+		return nil
+	}
+	<-w.stopped
+
+	done := make(chan struct{})
+	go func() {
+		w.running.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		w.cancelRun()
+		<-done
+		return ctx.Err()
+	}
+}
+
+func (w *Worker) poll(ctx context.Context) {
+	defer close(w.stopped)
+
+	ticker := time.NewTicker(w.PollInterval)
+	defer ticker.Stop()
+
+	slots := make(chan struct{}, w.Concurrency)
+	for {
+		// Keep claiming while the queue fills every free slot
+		for ctx.Err() == nil {
+			free := cap(slots) - len(slots)
+			if free == 0 {
+				break
+			}
+			jobs, err := w.claim(ctx, free)
+			if err != nil {
+				slog.ErrorContext(ctx, "Failed to claim jobs", "error", err)
+				break
+			}
+			for _, job := range jobs {
+				slots <- struct{}{}
+				w.running.Add(1)
+				go func(job models.Job) {
+					defer func() {
+						<-slots
+						w.running.Done()
+					}()
+					w.run(job)
+				}(job)
+			}
+			if len(jobs) < free {
+				break
+			}
+		}
+		w.prune(ctx)
+		w.lastBeat.Store(w.App.Clock.Now().UnixNano())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunDue runs every due job one after the other until none is left and
+// returns how many ran, whatever their outcome
+func RunDue(ctx context.Context, a *app.App) (int, error) {
+	w := &Worker{App: a}
+	w.init(ctx)
+	defer w.cancelRun()
+
+	ran := 0
+	for ctx.Err() == nil {
+		jobs, err := w.claim(ctx, 1)
+		if err != nil || len(jobs) == 0 {
+			return ran, err
+		}
+		w.run(jobs[0])
+		ran++
+	}
+	return ran, ctx.Err()
+}
+
+// LastHeartbeat returns when the worker last polled, or the zero time if it
+// has not run
+func (w *Worker) LastHeartbeat() time.Time {
+	ns := w.lastBeat.Load()
+	if ns == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, ns)
+}
+
+// Healthy reports whether a poll completed within the last three intervals
+func (w *Worker) Healthy(now time.Time) bool {
+	last := w.LastHeartbeat()
+	return !last.IsZero() && now.Sub(last) <= 3*w.PollInterval
+}
+
+// claim locks up to n due jobs, and jobs whose lease has run out, and marks
+// them running under this worker. A job whose lease ran out on its last
+// attempt is marked FAILED instead of being run again.
+func (w *Worker) claim(ctx context.Context, n int) ([]models.Job, error) {
+	now := w.App.Clock.Now()
+	var jobs []models.Job
+	err := w.App.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var exhausted []models.Job
+		if err := tx.Model(&exhausted).
+			Clauses(clause.Returning{Columns: []clause.Column{{Name: "id"}, {Name: "kind"}, {Name: "attempts"}}}).
+			Where(`status = ? AND "lockedUntil" < ? AND attempts >= "maxAttempts"`, models.JobStatusRunning, now).
+			Updates(map[string]interface{}{
+				"status":      models.JobStatusFailed,
+				"finishedAt":  now,
+				"lockedUntil": nil,
+				"lastError":   "lease expired during the last attempt",
+			}).Error; err != nil {
+			return err
+		}
+		for _, job := range exhausted {
+			slog.ErrorContext(ctx, "Job lease expired on its last attempt", "job_id", job.ID, "kind", job.Kind, "attempts", job.Attempts)
+			metrics.JobRan(job.Kind, "failed")
+		}
+
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where(`(status = ? AND "runAt" <= ?) OR (status = ? AND "lockedUntil" < ? AND attempts < "maxAttempts")`,
+				models.JobStatusPending, now, models.JobStatusRunning, now).
+			Order(`"runAt"`).
+			Limit(n).
+			Find(&jobs).Error; err != nil {
+			return err
+		}
+
+		for i := range jobs {
+			job := &jobs[i]
+			lockedUntil := now.Add(policyOf(job.Kind).Timeout + leaseGrace)
+			job.Status = models.JobStatusRunning
+			job.Attempts++
+			job.LockedBy = &w.id
+			job.LockedUntil = &lockedUntil
+			if err := tx.Model(&models.Job{}).Where("id = ?", job.ID).Updates(map[string]interface{}{
+				"status":      job.Status,
+				"attempts":    job.Attempts,
+				"lockedBy":    job.LockedBy,
+				"lockedUntil": job.LockedUntil,
+			}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return jobs, err
+}
+
+// policyOf returns the policy of a kind, or the defaults for a kind this
+// binary does not know
+func policyOf(name string) Policy {
+	if k, ok := registry[name]; ok {
+		return k.policy
+	}
+	return Policy{}.withDefaults()
+}
+
+// run runs a claimed job and records the outcome
+func (w *Worker) run(job models.Job) {
+	policy := policyOf(job.Kind)
+	err := w.call(job, policy)
+
+	switch {
+	case err == nil:
+		w.finish(job, models.JobStatusSucceeded, nil)
+		metrics.JobRan(job.Kind, "succeeded")
+	case w.runCtx.Err() != nil:
+		// Cut short by shutdown, which is not the job's fault
+		w.release(job)
+		metrics.JobRan(job.Kind, "released")
+	case job.Attempts >= job.MaxAttempts:
+		slog.Error("Job failed for the last time", "job_id", job.ID, "kind", job.Kind, "attempts", job.Attempts, "error", err)
+		w.finish(job, models.JobStatusFailed, err)
+		metrics.JobRan(job.Kind, "failed")
+	default:
+		slog.Warn("Job failed; will retry", "job_id", job.ID, "kind", job.Kind, "attempts", job.Attempts, "error", err)
+		w.retry(job, policy.Backoff(job.Attempts), err)
+		metrics.JobRan(job.Kind, "retried")
+	}
+}
+
+// call runs the handler of the job's kind, turning a panic into an error
+func (w *Worker) call(job models.Job, policy Policy) (err error) {
+	k, ok := registry[job.Kind]
+	if !ok {
+		return fmt.Errorf("no handler for job kind %q", job.Kind)
+	}
+
+	ctx, cancel := context.WithTimeout(w.runCtx, policy.Timeout)
+	defer cancel()
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return k.run(ctx, w.App, []byte(job.Payload))
+}
+
+// owned narrows an update to the job while it is still held by this
+// attempt, so a worker whose lease ran out cannot overwrite the next one
+func (w *Worker) owned(job models.Job) *gorm.DB {
+	return w.App.DB.Model(&models.Job{}).
+		Where(`id = ? AND status = ? AND attempts = ? AND "lockedBy" = ?`, job.ID, models.JobStatusRunning, job.Attempts, w.id)
+}
+
+// requeueable narrows an update that makes the job pending again to when
+// no pending job has the same unique key
+func requeueable(query *gorm.DB, job models.Job) *gorm.DB {
+	if job.UniqueKey == nil {
+		return query
+	}
+	return query.Where(`NOT EXISTS (SELECT 1 FROM "Job" WHERE "uniqueKey" = ? AND status = ?)`,
+		*job.UniqueKey, models.JobStatusPending)
+}
+
+func (w *Worker) finish(job models.Job, status models.JobStatus, cause error) {
+	updates := map[string]interface{}{
+		"status":      status,
+		"finishedAt":  w.App.Clock.Now(),
+		"lockedUntil": nil,
+	}
+	if cause != nil {
+		updates["lastError"] = cause.Error()
+	}
+	if err := w.owned(job).Updates(updates).Error; err != nil {
+		slog.Error("Failed to record job outcome", "job_id", job.ID, "status", status, "error", err)
+	}
+}
+
+// retry puts the job back in the queue after delay. When an identical job
+// has been queued meanwhile, that one does the work instead.
+func (w *Worker) retry(job models.Job, delay time.Duration, cause error) {
+	updates := map[string]interface{}{
+		"status":      models.JobStatusPending,
+		"runAt":       w.App.Clock.Now().Add(delay),
+		"lastError":   cause.Error(),
+		"lockedBy":    nil,
+		"lockedUntil": nil,
+	}
+	result := requeueable(w.owned(job), job).Updates(updates)
+	if result.Error != nil {
+		slog.Error("Failed to reschedule job", "job_id", job.ID, "error", result.Error)
+		return
+	}
+	if result.RowsAffected == 0 && job.UniqueKey != nil {
+		w.finish(job, models.JobStatusSuperseded, cause)
+	}
+}
+
+// release returns a job interrupted by shutdown to the queue, giving back
+// the attempt it used
+func (w *Worker) release(job models.Job) {
+	result := requeueable(w.owned(job), job).Updates(map[string]interface{}{
+		"status":      models.JobStatusPending,
+		"attempts":    job.Attempts - 1,
+		"runAt":       w.App.Clock.Now(),
+		"lockedBy":    nil,
+		"lockedUntil": nil,
+	})
+	if result.Error != nil {
+		slog.Error("Failed to release job", "job_id", job.ID, "error", result.Error)
+		return
+	}
+	if result.RowsAffected == 0 && job.UniqueKey != nil {
+		w.finish(job, models.JobStatusSuperseded, nil)
+	}
+}
+
+// prune deletes finished jobs older than the retention, at most once per
+// pruneInterval. FAILED jobs stay until someone retries them.
+func (w *Worker) prune(ctx context.Context) {
+	now := w.App.Clock.Now()
+	if now.Sub(w.lastPrune) < pruneInterval {
+		return
+	}
+	w.lastPrune = now
+
+	result := w.App.DB.WithContext(ctx).
+		Where(`status IN ? AND "finishedAt" < ?`,
+			[]models.JobStatus{models.JobStatusSucceeded, models.JobStatusSuperseded}, now.Add(-w.Retention)).
+		Delete(&models.Job{})
+	if result.Error != nil {
+		slog.ErrorContext(ctx, "Failed to prune finished jobs", "error", result.Error)
+	} else if result.RowsAffected > 0 {
+		slog.InfoContext(ctx, "Pruned finished jobs", "count", result.RowsAffected)
+	}
+}
@@ -0,0 +1,93 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// SlowQueryThreshold is the duration above which a query is logged at warn
+const SlowQueryThreshold = 200 * time.Millisecond
+
+// GormLogger sends GORM's output to slog. Statements are logged at debug, so
+// they only appear with LOG_LEVEL=debug; slow queries at warn and failures at
+// error. The SQL passes through the redactor like every other attribute, and
+// queries run with db.WithContext(c.Request.Context()) carry the request ID.
+type GormLogger struct {
+	level gormlogger.LogLevel
+}
+
+// NewGormLogger returns a logger that forwards everything to slog and lets
+// the slog level decide what is written
+func NewGormLogger() *GormLogger {
+	return &GormLogger{level: gormlogger.Info}
+}
+
+// LogMode implements gormlogger.Interface
+func (l *GormLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	return &GormLogger{level: level}
+}
+
+// Info implements gormlogger.Interface
+func (l *GormLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= gormlogger.Info {
+		slog.InfoContext(ctx, fmt.Sprintf(msg, args...))
+	}
+}
+
+// Warn implements gormlogger.Interface
+func (l *GormLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= gormlogger.Warn {
+		slog.WarnContext(ctx, fmt.Sprintf(msg, args...))
+	}
+}
+
+// Error implements gormlogger.Interface
+func (l *GormLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= gormlogger.Error {
+		slog.ErrorContext(ctx, fmt.Sprintf(msg, args...))
+	}
+}
+
+// Trace implements gormlogger.Interface
+func (l *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if l.level <= gormlogger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	var (
+		level slog.Level
+		msg   string
+	)
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound) && l.level >= gormlogger.Error:
+		level, msg = slog.LevelError, "Query failed"
+	case elapsed > SlowQueryThreshold && l.level >= gormlogger.Warn:
+		level, msg = slog.LevelWarn, "Slow query"
+	case l.level >= gormlogger.Info:
+		level, msg = slog.LevelDebug, "Query"
+	default:
+		return
+	}
+
+	if !slog.Default().Enabled(ctx, level) {
+		return
+	}
+
+	sql, rows := fc()
+	attrs := []any{
+		"sql", sql,
+		"rows", rows,
+		"duration_ms", float64(elapsed.Microseconds()) / 1000,
+	}
+	if err != nil && level == slog.LevelError {
+		attrs = append(attrs, "error", err)
+	}
+	slog.Log(ctx, level, msg, attrs...)
+}
@@ -0,0 +1,108 @@
+// Package logging configures the process-wide slog logger.
+//
+// Every record goes through the redactor in redact.go, so emails, phone
+// numbers, tokens and payment signatures never reach the log sink, and picks
+// up the request ID carried by its context. Call sites use the slog package
+// directly, with the *Context variants inside request handlers:
+//
+//	slog.WarnContext(c.Request.Context(), "Failed to send email", "error", err)
+package logging
+
+import (
+	"backend_pandhi/pkg/config"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+type requestIDKey struct{}
+
+// Init replaces the default slog logger using LOG_LEVEL and LOG_FORMAT. It
+// also routes the standard log package through slog so libraries that still
+// use it are redacted too.
+func Init(cfg *config.Config) error {
+	format := strings.ToLower(cfg.LogFormat)
+	if format == "" {
+		format = "text"
+		if cfg.IsProduction() {
+			format = "json"
+		}
+	}
+
+	handler, err := NewHandler(os.Stderr, cfg.LogLevel, format)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(slog.New(handler))
+	return nil
+}
+
+// NewHandler builds a redacting handler that writes text or json records at
+// or above level
+func NewHandler(w io.Writer, level slog.Leveler, format string) (slog.Handler, error) {
+	opts := &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: redactAttr,
+	}
+
+	var h slog.Handler
+	switch format {
+	case "json":
+		h = slog.NewJSONHandler(w, opts)
+	case "text":
+		h = slog.NewTextHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("unknown log format %q (want json or text)", format)
+	}
+	return contextHandler{h}, nil
+}
+
+// WithRequestID returns a copy of ctx carrying the request ID
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID stored in ctx, if any
+func RequestID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// Fatal logs msg at error level and exits
+func Fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+// contextHandler adds the request ID and trace IDs from the record's context
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if ctx != nil {
+		if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+			r.AddAttrs(slog.String("trace_id", sc.TraceID().String()), slog.String("span_id", sc.SpanID().String()))
+		}
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,103 @@
+package logging
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+// Attributes whose key contains one of these are dropped entirely
+var secretKeys = []string{
+	"password",
+	"token",
+	"secret",
+	"signature",
+	"authorization",
+	"cookie",
+	"otp",
+}
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	// E.164 numbers as stored by utils.NormalizePhone, and bare Indian mobiles
+	phonePattern  = regexp.MustCompile(`\+\d{10,15}\b|\b[6-9]\d{9}\b`)
+	jwtPattern    = regexp.MustCompile(`eyJ[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]+`)
+	bearerPattern = regexp.MustCompile(`(?i)\bbearer\s+[^\s"']+`)
+	// token=..., signature=... and friends in URLs and form bodies
+	queryPattern = regexp.MustCompile(`(?i)\b(token|signature|otp|code|secret|password)=[^&\s"']+`)
+	// "use this code in the app: ..." in emails and similar labelled values;
+	// short values such as "status code: 500" are left alone
+	labelPattern = regexp.MustCompile(`(?i)(\b(?:token|otp|code|secret|password)\b[^:=\n]{0,20}:\s*)[A-Za-z0-9_\-]{6,}`)
+	// Razorpay signatures are hex HMAC-SHA256 digests
+	hexDigestPattern = regexp.MustCompile(`\b[a-fA-F0-9]{64}\b`)
+	bcryptPattern    = regexp.MustCompile(`\$2[aby]\$\d{2}\$[./A-Za-z0-9]{53}`)
+)
+
+// Redact masks personal data and credentials in s. Emails keep their first
+// character and domain, phone numbers their last four digits, so logs stay
+// useful for support without identifying anyone.
+func Redact(s string) string {
+	if s == "" {
+		return s
+	}
+	s = jwtPattern.ReplaceAllString(s, redacted)
+	s = bearerPattern.ReplaceAllString(s, "Bearer "+redacted)
+	s = queryPattern.ReplaceAllString(s, "$1="+redacted)
+	s = labelPattern.ReplaceAllString(s, "${1}"+redacted)
+	s = bcryptPattern.ReplaceAllString(s, redacted)
+	s = hexDigestPattern.ReplaceAllString(s, redacted)
+	s = emailPattern.ReplaceAllStringFunc(s, maskEmail)
+	s = phonePattern.ReplaceAllStringFunc(s, maskPhone)
+	return s
+}
+
+func maskEmail(email string) string {
+	at := strings.LastIndexByte(email, '@')
+	if at <= 0 {
+		return redacted
+	}
+	return email[:1] + "***" + email[at:]
+}
+
+func maskPhone(phone string) string {
+	if len(phone) <= 4 {
+		return redacted
+	}
+	return strings.Repeat("*", len(phone)-4) + phone[len(phone)-4:]
+}
+
+// redactAttr is the slog ReplaceAttr hook. It also sees the message, so
+// values formatted into it are masked as well.
+func redactAttr(groups []string, a slog.Attr) slog.Attr {
+	if a.Key == slog.TimeKey || a.Key == slog.LevelKey || a.Key == slog.SourceKey {
+		return a
+	}
+
+	key := strings.ToLower(a.Key)
+	for _, k := range secretKeys {
+		if strings.Contains(key, k) {
+			return slog.String(a.Key, redacted)
+		}
+	}
+
+	v := a.Value.Resolve()
+	switch v.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, Redact(v.String()))
+	case slog.KindAny:
+		switch x := v.Any().(type) {
+		case error:
+			return slog.String(a.Key, Redact(x.Error()))
+		case fmt.Stringer:
+			return slog.String(a.Key, Redact(x.String()))
+		case []byte:
+			return slog.String(a.Key, Redact(string(x)))
+		default:
+			return slog.String(a.Key, Redact(fmt.Sprintf("%+v", x)))
+		}
+	}
+	return a
+}
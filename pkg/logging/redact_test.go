@@ -0,0 +1,46 @@
+package logging_test
+
+import (
+	"backend_pandhi/pkg/logging"
+	"strings"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	hexDigest := strings.Repeat("9f2c", 16)
+	bcryptHash := "$2a$10$" + strings.Repeat("N9qo8uLOickgx2ZMRZoMye", 3)[:53]
+
+	for _, tc := range []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"empty", "", ""},
+		{"nothing personal", "order 42 delivered in 12345 ms", "order 42 delivered in 12345 ms"},
+
+		{"email", "login failed for asha.k@example.com", "login failed for a***@example.com"},
+		{"several emails", "from a@b.io to Ravi+test@mail.example.org", "from a***@b.io to R***@mail.example.org"},
+		{"email of digits", "9876543210@example.com", "9***@example.com"},
+
+		{"E.164 phone", "otp sent to +919876543210", "otp sent to *********3210"},
+		{"bare mobile", "call 9876543210 now", "call ******3210 now"},
+		{"landline-like digits stay", "ref 2212345678", "ref 2212345678"},
+
+		{"JWT", "token eyJhbGciOiJIUzI1NiJ9.eyJpZCI6MX0.c2lnbmF0dXJl rejected", "token [REDACTED] rejected"},
+		{"bearer header", "Authorization: Bearer abc.def-123", "Authorization: Bearer [REDACTED]"},
+		{"token in a URL", "GET /verify-email?token=abc123&next=/home", "GET /verify-email?token=[REDACTED]&next=/home"},
+		{"code in a form", "phone=x&code=123456", "phone=x&code=[REDACTED]"},
+		{"password field", "Password=hunter2 rejected", "Password=[REDACTED] rejected"},
+		{"code in an email", "If the link does not open, use this code in the app: Xk3_9fQ-2bLmZ0aPq7Rt\n", "If the link does not open, use this code in the app: [REDACTED]\n"},
+		{"labelled OTP", "OTP: 482913", "OTP: [REDACTED]"},
+		{"status codes stay", "upstream status code: 502", "upstream status code: 502"},
+
+		{"signature parameter", "signature=deadbeef&orderId=7", "signature=[REDACTED]&orderId=7"},
+		{"Razorpay signature", "razorpay_signature " + hexDigest + " mismatch", "razorpay_signature [REDACTED] mismatch"},
+		{"bcrypt hash", "hash " + bcryptHash, "hash [REDACTED]"},
+	} {
+		if got := logging.Redact(tc.in); got != tc.want {
+			t.Errorf("%s: Redact(%q) = %q, want %q", tc.name, tc.in, got, tc.want)
+		}
+	}
+}
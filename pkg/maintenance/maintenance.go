@@ -0,0 +1,247 @@
+// Package maintenance implements the operator tasks run from the command
+// line: bootstrapping accounts and repairing derived data. Each task writes
+// through the same models, repositories and audit log as the HTTP handlers.
+package maintenance
+
+import (
+	"backend_pandhi/pkg/app"
+	"backend_pandhi/pkg/audit"
+	"backend_pandhi/pkg/models"
+	"backend_pandhi/pkg/ratings"
+	"backend_pandhi/pkg/repository"
+	"backend_pandhi/pkg/utils"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrNotFound is returned when the named account or record does not exist
+	ErrNotFound = errors.New("not found")
+	// ErrConflict is returned when the task has already been done
+	ErrConflict = errors.New("conflict")
+)
+
+// CreateSuperAdmin creates a verified SUPERADMIN user
+func CreateSuperAdmin(ctx context.Context, a *app.App, name, email, password string) (models.User, error) {
+	email = strings.TrimSpace(email)
+	if err := utils.CheckPasswordStrength(password); err != nil {
+		return models.User{}, err
+	}
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	user := models.User{
+		Name:       name,
+		Email:      email,
+		Password:   &hashedPassword,
+		Role:       models.RoleSuperAdmin,
+		IsVerified: true,
+	}
+	err = a.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.User{}).Where("email = ?", email).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return fmt.Errorf("%w: a user with email %s already exists", ErrConflict, email)
+		}
+		return tx.Create(&user).Error
+	})
+	return user, err
+}
+
+// VerifyAdmin verifies an admin and links it to the given active outlets
+// with the default permissions, none granted, as the superadmin dashboard does
+func VerifyAdmin(ctx context.Context, a *app.App, email string, outletIDs []int) (models.Admin, error) {
+	var admin models.Admin
+	err := a.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("email = ?", strings.TrimSpace(email)).First(&admin).Error; err != nil {
+			return notFound(err, "no admin with email %s", email)
+		}
+		if admin.IsVerified {
+			return fmt.Errorf("%w: admin %s is already verified", ErrConflict, admin.Email)
+		}
+
+		var outlets []models.Outlet
+		if err := tx.Where(`id IN ? AND "isActive" = ?`, outletIDs, true).Find(&outlets).Error; err != nil {
+			return err
+		}
+		if len(outlets) != len(outletIDs) {
+			return fmt.Errorf("%w: one or more outlets are invalid or inactive", ErrNotFound)
+		}
+
+		if err := tx.Model(&admin).Update("isVerified", true).Error; err != nil {
+			return err
+		}
+		for _, outletID := range outletIDs {
+			link := models.AdminOutlet{AdminID: admin.ID, OutletID: outletID}
+			if err := tx.Create(&link).Error; err != nil {
+				return err
+			}
+			for _, permType := range models.DefaultAdminPermissions {
+				perm := models.AdminPermission{AdminOutletID: link.ID, Type: permType}
+				if err := tx.Create(&perm).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		return audit.Record(nil, tx, audit.Entry{
+			Action:     audit.ActionAdminVerify,
+			EntityType: audit.EntityAdmin,
+			EntityID:   admin.ID,
+			Before:     gin.H{"isVerified": false, "outletIds": []int{}},
+			After:      gin.H{"isVerified": true, "outletIds": outletIDs},
+		})
+	})
+	return admin, err
+}
+
+// VerifyStaff verifies a staff user, assigns the outlet and role and creates
+// the default permissions, none granted, when the staff details are new
+func VerifyStaff(ctx context.Context, a *app.App, email string, outletID int, staffRole string) (models.User, error) {
+	var user models.User
+	err := a.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Preload("StaffInfo").
+			Where("email = ? AND role = ?", strings.TrimSpace(email), models.RoleStaff).
+			First(&user).Error; err != nil {
+			return notFound(err, "no staff user with email %s", email)
+		}
+		if user.IsVerified {
+			return fmt.Errorf("%w: staff %s is already verified", ErrConflict, user.Email)
+		}
+
+		var outlet models.Outlet
+		if err := tx.Where(`id = ? AND "isActive" = ?`, outletID, true).First(&outlet).Error; err != nil {
+			return notFound(err, "no active outlet %d", outletID)
+		}
+
+		previousOutletID := user.OutletID
+		if err := tx.Model(&user).Updates(map[string]interface{}{
+			"isVerified": true,
+			"outletId":   outletID,
+		}).Error; err != nil {
+			return err
+		}
+
+		if user.StaffInfo == nil {
+			staffInfo := models.StaffDetails{UserID: user.ID, StaffRole: staffRole}
+			if err := tx.Create(&staffInfo).Error; err != nil {
+				return err
+			}
+			for _, permType := range models.DefaultStaffPermissions {
+				if err := tx.Create(&models.StaffPermission{StaffID: staffInfo.ID, Type: permType}).Error; err != nil {
+					return err
+				}
+			}
+		} else if err := repository.Users(tx).SetStaffRole(user.StaffInfo, staffRole); err != nil {
+			return err
+		}
+
+		return audit.Record(nil, tx, audit.Entry{
+			Action:     audit.ActionStaffVerify,
+			EntityType: audit.EntityUser,
+			EntityID:   user.ID,
+			OutletID:   &outletID,
+			Before:     gin.H{"isVerified": false, "outletId": previousOutletID},
+			After:      gin.H{"isVerified": true, "outletId": outletID, "staffRole": staffRole},
+		})
+	})
+	return user, err
+}
+
+// ResetPassword sets a new password on a user, or on an admin when
+// accountType is AuthAccountTypeAdmin
+func ResetPassword(ctx context.Context, a *app.App, accountType models.AuthAccountType, email, password string) error {
+	if err := utils.CheckPasswordStrength(password); err != nil {
+		return err
+	}
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	var model interface{} = &models.User{}
+	if accountType == models.AuthAccountTypeAdmin {
+		model = &models.Admin{}
+	}
+	result := a.DB.WithContext(ctx).Model(model).
+		Where("email = ?", strings.TrimSpace(email)).
+		Update("password", hashedPassword)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: no %s account with email %s", ErrNotFound, strings.ToLower(string(accountType)), email)
+	}
+	return nil
+}
+
+// RecomputeRatings recalculates the rating aggregates of the given products,
+// or of every product when none are given, and returns how many were updated
+func RecomputeRatings(ctx context.Context, a *app.App, productIDs ...int) (int, error) {
+	n, err := ratings.Recompute(a.DB.WithContext(ctx), a.Clock.Now(), productIDs...)
+	return int(n), err
+}
+
+// WalletDrift is a wallet whose stored balance differs from the sum of its
+// transactions
+type WalletDrift struct {
+	WalletID int
+	Balance  float64
+	Ledger   float64
+}
+
+// ReconcileWallets returns the wallets whose balance does not match their
+// transactions. With fix, each balance is set to its transaction total.
+func ReconcileWallets(ctx context.Context, a *app.App, fix bool) ([]WalletDrift, error) {
+	var drifts []WalletDrift
+	err := a.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		ledgers, err := repository.Wallets(tx).Ledgers()
+		if err != nil {
+			return err
+		}
+		for _, l := range ledgers {
+			// Balances are rupees with paise; anything under half a paisa is
+			// floating point noise
+			if math.Abs(l.Balance-l.Ledger) < 0.005 {
+				continue
+			}
+			drifts = append(drifts, WalletDrift(l))
+			if !fix {
+				continue
+			}
+			if err := repository.Wallets(tx).SetBalance(l.WalletID, l.Ledger); err != nil {
+				return err
+			}
+			if err := audit.Record(nil, tx, audit.Entry{
+				Action:     audit.ActionWalletReconcile,
+				EntityType: audit.EntityWallet,
+				EntityID:   l.WalletID,
+				Before:     gin.H{"balance": l.Balance},
+				After:      gin.H{"balance": l.Ledger},
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return drifts, err
+}
+
+// notFound wraps gorm's missing-record error in ErrNotFound and returns any
+// other error as is
+func notFound(err error, format string, args ...interface{}) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("%w: %s", ErrNotFound, fmt.Sprintf(format, args...))
+	}
+	return err
+}
@@ -0,0 +1,114 @@
+package maintenance_test
+
+import (
+	"backend_pandhi/pkg/maintenance"
+	"backend_pandhi/pkg/models"
+	"backend_pandhi/pkg/testenv"
+	"backend_pandhi/pkg/utils"
+	"context"
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(testenv.Main(m))
+}
+
+func TestCreateSuperAdmin(t *testing.T) {
+	env := testenv.New(t)
+	ctx := context.Background()
+
+	user, err := maintenance.CreateSuperAdmin(ctx, env.App, "Root", "root@example.com", testenv.Password)
+	if err != nil {
+		t.Fatalf("CreateSuperAdmin: %v", err)
+	}
+	if user.Role != models.RoleSuperAdmin || !user.IsVerified {
+		t.Errorf("created %s user, verified %v", user.Role, user.IsVerified)
+	}
+
+	_, err = maintenance.CreateSuperAdmin(ctx, env.App, "Root", "root@example.com", testenv.Password)
+	if !errors.Is(err, maintenance.ErrConflict) {
+		t.Errorf("second CreateSuperAdmin = %v, want ErrConflict", err)
+	}
+}
+
+func TestVerifyAdmin(t *testing.T) {
+	env := testenv.New(t)
+	ctx := context.Background()
+	outlet := env.Outlet(t)
+
+	admin := models.Admin{Name: "Pending", Email: "pending@example.com", Password: "x"}
+	if err := env.DB.Create(&admin).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := maintenance.VerifyAdmin(ctx, env.App, admin.Email, []int{outlet.ID + 1000}); !errors.Is(err, maintenance.ErrNotFound) {
+		t.Errorf("unknown outlet: %v, want ErrNotFound", err)
+	}
+	if _, err := maintenance.VerifyAdmin(ctx, env.App, admin.Email, []int{outlet.ID}); err != nil {
+		t.Fatalf("VerifyAdmin: %v", err)
+	}
+
+	env.Reload(t, &admin)
+	if !admin.IsVerified {
+		t.Error("admin is not verified")
+	}
+	var perms int64
+	env.DB.Model(&models.AdminPermission{}).Count(&perms)
+	if int(perms) != len(models.DefaultAdminPermissions) {
+		t.Errorf("created %d permissions, want %d", perms, len(models.DefaultAdminPermissions))
+	}
+
+	if _, err := maintenance.VerifyAdmin(ctx, env.App, admin.Email, []int{outlet.ID}); !errors.Is(err, maintenance.ErrConflict) {
+		t.Errorf("second VerifyAdmin = %v, want ErrConflict", err)
+	}
+}
+
+func TestResetPassword(t *testing.T) {
+	env := testenv.New(t)
+	ctx := context.Background()
+	customer := env.Customer(t, env.Outlet(t), 0)
+
+	if err := maintenance.ResetPassword(ctx, env.App, models.AuthAccountTypeUser, customer.Email, "new-password"); err != nil {
+		t.Fatalf("ResetPassword: %v", err)
+	}
+	env.Reload(t, &customer)
+	if utils.ComparePassword(*customer.Password, "new-password") != nil {
+		t.Error("password was not changed")
+	}
+
+	err := maintenance.ResetPassword(ctx, env.App, models.AuthAccountTypeAdmin, customer.Email, "new-password")
+	if !errors.Is(err, maintenance.ErrNotFound) {
+		t.Errorf("admin reset of a user email = %v, want ErrNotFound", err)
+	}
+}
+
+func TestReconcileWallets(t *testing.T) {
+	env := testenv.New(t)
+	ctx := context.Background()
+
+	// The fixture funds the wallet without a transaction
+	customer := env.Customer(t, env.Outlet(t), 100)
+	wallet := *customer.CustomerInfo.Wallet
+	env.DB.Create(&models.WalletTransaction{WalletID: wallet.ID, Amount: 60, Method: models.PaymentMethodCash, Status: models.WalletTransTypeRecharge})
+
+	drifts, err := maintenance.ReconcileWallets(ctx, env.App, false)
+	if err != nil {
+		t.Fatalf("ReconcileWallets: %v", err)
+	}
+	if len(drifts) != 1 || drifts[0].Balance != 100 || drifts[0].Ledger != 60 {
+		t.Fatalf("drifts = %+v, want wallet %d at 100 against 60", drifts, wallet.ID)
+	}
+
+	if _, err := maintenance.ReconcileWallets(ctx, env.App, true); err != nil {
+		t.Fatalf("ReconcileWallets fix: %v", err)
+	}
+	env.Reload(t, &wallet)
+	if wallet.Balance != 60 {
+		t.Errorf("balance after fix = %v, want 60", wallet.Balance)
+	}
+	if drifts, _ := maintenance.ReconcileWallets(ctx, env.App, false); len(drifts) != 0 {
+		t.Errorf("drifts after fix = %+v", drifts)
+	}
+}
@@ -0,0 +1,37 @@
+// Package media cleans up uploaded images once nothing refers to them.
+package media
+
+import (
+	"backend_pandhi/pkg/app"
+	"backend_pandhi/pkg/jobs"
+	"context"
+	"log/slog"
+
+	"gorm.io/gorm"
+)
+
+// DeleteRequest is the payload of a delete job
+type DeleteRequest struct {
+	ImageURL string `json:"imageUrl"`
+}
+
+// DeleteJob removes a replaced or cleared image from storage. Storage errors
+// are retried; an image that is already gone counts as deleted.
+var DeleteJob = jobs.Define("media.delete_image", func(ctx context.Context, a *app.App, req DeleteRequest) error {
+	if !a.Storage.Ready() {
+		slog.WarnContext(ctx, "Image storage is not configured; old image not deleted")
+		return nil
+	}
+	return a.Storage.DeleteImage(ctx, req.ImageURL)
+}, jobs.Policy{})
+
+// QueueDelete queues the deletion of an image in the transaction that stops
+// referring to it, so the file is only removed once that change commits
+func QueueDelete(tx *gorm.DB, imageURL *string) error {
+	if imageURL == nil || *imageURL == "" {
+		return nil
+	}
+	return DeleteJob.Enqueue(tx, DeleteRequest{ImageURL: *imageURL}, jobs.Options{
+		UniqueKey: DeleteJob.Name + ":" + *imageURL,
+	})
+}
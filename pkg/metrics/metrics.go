@@ -0,0 +1,180 @@
+// Package metrics exposes Prometheus metrics for HTTP traffic, database
+// queries and business events.
+//
+// Metrics live in their own registry rather than the global default one, so
+// only what is defined here (plus the Go runtime and process collectors) is
+// served. Business events are recorded through the small helpers at the end
+// of this file; call them after the change they count has been committed.
+package metrics
+
+import (
+	"backend_pandhi/pkg/apperror"
+	"backend_pandhi/pkg/models"
+	"crypto/subtle"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "ups"
+
+// Registry holds every metric served on /metrics
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route template, method and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	httpRequestsInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "http_requests_in_flight",
+		Help:      "HTTP requests currently being served.",
+	})
+
+	ordersPlaced = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "orders_placed_total",
+		Help:      "Orders placed, by order type and payment method.",
+	}, []string{"type", "payment_method"})
+
+	walletRecharges = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "wallet_recharges_total",
+		Help:      "Wallet recharges, by payment method.",
+	}, []string{"method"})
+
+	walletRechargeAmount = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "wallet_recharge_amount_rupees_total",
+		Help:      "Rupees credited to wallets by recharges, by payment method.",
+	}, []string{"method"})
+
+	pushNotifications = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "push_notifications_total",
+		Help:      "Push notifications by delivery result (sent or failed).",
+	}, []string{"result"})
+
+	stockOuts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "stock_outs_total",
+		Help:      "Inventory rows that reached zero, by outlet.",
+	}, []string{"outlet_id"})
+
+	razorpayVerificationFailures = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "razorpay_verification_failures_total",
+		Help:      "Razorpay payment signatures that failed verification.",
+	})
+
+	jobRuns = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "job_runs_total",
+		Help:      "Background job runs, by kind and result (succeeded, retried, failed or released).",
+	}, []string{"kind", "result"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequestDuration,
+		httpRequestsInFlight,
+		dbQueryDuration,
+		ordersPlaced,
+		walletRecharges,
+		walletRechargeAmount,
+		pushNotifications,
+		stockOuts,
+		razorpayVerificationFailures,
+		jobRuns,
+	)
+}
+
+// Middleware records the latency of every request. Routes are labelled by
+// their template (/api/customer/orders/:id) so IDs do not create new series;
+// requests that match no route share the "unmatched" label.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		httpRequestsInFlight.Inc()
+
+		c.Next()
+
+		httpRequestsInFlight.Dec()
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		httpRequestDuration.
+			WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).
+			Observe(time.Since(start).Seconds())
+	}
+}
+
+// Handler serves the registry in the Prometheus text format. Scrapers must
+// send "Authorization: Bearer <token>"; with no token the endpoint does not
+// exist.
+func Handler(token string) gin.HandlerFunc {
+	h := promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+
+	return func(c *gin.Context) {
+		if token == "" {
+			apperror.Abort(c, apperror.NotFound("Route not found"))
+			return
+		}
+
+		given, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			apperror.Abort(c, apperror.Unauthorized("Invalid metrics token."))
+			return
+		}
+
+		h.ServeHTTP(c.Writer, c.Request)
+	}
+}
+
+// OrderPlaced counts a committed order
+func OrderPlaced(orderType models.OrderType, method models.PaymentMethod) {
+	ordersPlaced.WithLabelValues(string(orderType), string(method)).Inc()
+}
+
+// WalletRecharged counts a committed wallet recharge of amount rupees
+func WalletRecharged(method models.PaymentMethod, amount float64) {
+	walletRecharges.WithLabelValues(string(method)).Inc()
+	walletRechargeAmount.WithLabelValues(string(method)).Add(amount)
+}
+
+// PushSent counts delivered push notifications
+func PushSent(n int) {
+	pushNotifications.WithLabelValues("sent").Add(float64(n))
+}
+
+// PushFailed counts push notifications that were not delivered
+func PushFailed(n int) {
+	pushNotifications.WithLabelValues("failed").Add(float64(n))
+}
+
+// StockOut counts an inventory row that reached zero
+func StockOut(outletID int) {
+	stockOuts.WithLabelValues(strconv.Itoa(outletID)).Inc()
+}
+
+// RazorpayVerificationFailed counts a payment signature that did not verify
+func RazorpayVerificationFailed() {
+	razorpayVerificationFailures.Inc()
+}
+
+// JobRan counts a finished run of a background job
+func JobRan(kind, result string) {
+	jobRuns.WithLabelValues(kind, result).Inc()
+}
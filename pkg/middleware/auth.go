@@ -1,18 +1,19 @@
 package middleware
 
 import (
-	"backend_pandhi/pkg/database"
+	"backend_pandhi/pkg/app"
+	"backend_pandhi/pkg/apperror"
 	"backend_pandhi/pkg/models"
+	"backend_pandhi/pkg/repository"
 	"backend_pandhi/pkg/utils"
-	"log"
-	"net/http"
+	"log/slog"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
 // AuthenticateToken middleware - mirrors Express.js authenticateToken
-func AuthenticateToken() gin.HandlerFunc {
+func AuthenticateToken(a *app.App) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Allow OPTIONS requests (preflight)
 		if c.Request.Method == "OPTIONS" {
@@ -41,38 +42,34 @@ func AuthenticateToken() gin.HandlerFunc {
 
 		// No token provided
 		if token == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"message": "Access denied. No token provided."})
-			c.Abort()
+			apperror.Abort(c, apperror.Unauthorized("Access denied. No token provided."))
 			return
 		}
 
 		// Verify token
-		claims, err := utils.VerifyToken(token)
+		claims, err := utils.VerifyToken(a.Config, token)
 		if err != nil {
 			if err.Error() == "Token is expired" {
-				c.JSON(http.StatusUnauthorized, gin.H{"message": "Token expired."})
+				apperror.Abort(c, apperror.Unauthorized("Token expired."))
 			} else {
-				c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid token."})
+				apperror.Abort(c, apperror.Unauthorized("Invalid token."))
 			}
-			c.Abort()
 			return
 		}
 
 		// Fetch user/admin from database based on role
 		if claims.Role == "ADMIN" {
 			var admin models.Admin
-			if err := database.DB.
+			if err := a.DB.WithContext(c.Request.Context()).
 				Preload("Outlets.Outlet").
 				Preload("Outlets.Permissions").
 				First(&admin, claims.ID).Error; err != nil {
-				c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid token. Admin not found."})
-				c.Abort()
+				apperror.Abort(c, apperror.Unauthorized("Invalid token. Admin not found."))
 				return
 			}
 
 			if !admin.IsVerified {
-				c.JSON(http.StatusForbidden, gin.H{"message": "Admin not verified."})
-				c.Abort()
+				apperror.Abort(c, apperror.Forbidden("Admin not verified."))
 				return
 			}
 
@@ -89,7 +86,7 @@ func AuthenticateToken() gin.HandlerFunc {
 		} else {
 			// Regular user (CUSTOMER, STAFF, SUPERADMIN)
 			var user models.User
-			query := database.DB
+			query := a.DB
 
 			if claims.Role == models.RoleCustomer {
 				query = query.Preload("CustomerInfo.Wallet").Preload("CustomerInfo.Cart").Preload("Outlet")
@@ -102,16 +99,14 @@ func AuthenticateToken() gin.HandlerFunc {
 
 			if err := query.First(&user, claims.ID).Error; err != nil {
 				// Log the error for debugging
-				log.Printf("Error fetching user %d (Role: %s): %v", claims.ID, claims.Role, err)
-				c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid token. User not found."})
-				c.Abort()
+				slog.WarnContext(c.Request.Context(), "Failed to fetch token user", "user_id", claims.ID, "role", claims.Role, "error", err)
+				apperror.Abort(c, apperror.Unauthorized("Invalid token. User not found."))
 				return
 			}
 
 			// Check verification for STAFF
 			if user.Role == models.RoleStaff && !user.IsVerified {
-				c.JSON(http.StatusForbidden, gin.H{"message": "Staff not verified."})
-				c.Abort()
+				apperror.Abort(c, apperror.Forbidden("Staff not verified."))
 				return
 			}
 
@@ -129,8 +124,7 @@ func AuthorizeRoles(roles ...models.Role) gin.HandlerFunc {
 		// Get user from context (set by AuthenticateToken)
 		userInterface, exists := c.Get("user")
 		if !exists {
-			c.JSON(http.StatusUnauthorized, gin.H{"message": "Authentication required."})
-			c.Abort()
+			apperror.Abort(c, apperror.Unauthorized("Authentication required."))
 			return
 		}
 
@@ -143,8 +137,7 @@ func AuthorizeRoles(roles ...models.Role) gin.HandlerFunc {
 		} else if userMap, ok := userInterface.(gin.H); ok {
 			userRole = userMap["role"].(models.Role)
 		} else {
-			c.JSON(http.StatusUnauthorized, gin.H{"message": "Authentication required."})
-			c.Abort()
+			apperror.Abort(c, apperror.Unauthorized("Authentication required."))
 			return
 		}
 
@@ -157,15 +150,14 @@ func AuthorizeRoles(roles ...models.Role) gin.HandlerFunc {
 		}
 
 		// User doesn't have required role
-		c.JSON(http.StatusForbidden, gin.H{"message": "Access denied. Insufficient permissions."})
-		c.Abort()
+		apperror.Abort(c, apperror.Forbidden("Access denied. Insufficient permissions."))
 	}
 }
 
 // RestrictToSuperAdmin - convenience middleware for SUPERADMIN only
-func RestrictToSuperAdmin() gin.HandlerFunc {
+func RestrictToSuperAdmin(a *app.App) gin.HandlerFunc {
 	return gin.HandlerFunc(func(c *gin.Context) {
-		AuthenticateToken()(c)
+		AuthenticateToken(a)(c)
 		if c.IsAborted() {
 			return
 		}
@@ -174,9 +166,9 @@ func RestrictToSuperAdmin() gin.HandlerFunc {
 }
 
 // RestrictToStaff - convenience middleware for STAFF only
-func RestrictToStaff() gin.HandlerFunc {
+func RestrictToStaff(a *app.App) gin.HandlerFunc {
 	return gin.HandlerFunc(func(c *gin.Context) {
-		AuthenticateToken()(c)
+		AuthenticateToken(a)(c)
 		if c.IsAborted() {
 			return
 		}
@@ -185,9 +177,9 @@ func RestrictToStaff() gin.HandlerFunc {
 }
 
 // RestrictToCustomer - convenience middleware for CUSTOMER only
-func RestrictToCustomer() gin.HandlerFunc {
+func RestrictToCustomer(a *app.App) gin.HandlerFunc {
 	return gin.HandlerFunc(func(c *gin.Context) {
-		AuthenticateToken()(c)
+		AuthenticateToken(a)(c)
 		if c.IsAborted() {
 			return
 		}
@@ -196,9 +188,9 @@ func RestrictToCustomer() gin.HandlerFunc {
 }
 
 // RestrictToSuperAdminOrAdmin - allow SUPERADMIN or ADMIN
-func RestrictToSuperAdminOrAdmin() gin.HandlerFunc {
+func RestrictToSuperAdminOrAdmin(a *app.App) gin.HandlerFunc {
 	return gin.HandlerFunc(func(c *gin.Context) {
-		AuthenticateToken()(c)
+		AuthenticateToken(a)(c)
 		if c.IsAborted() {
 			return
 		}
@@ -207,9 +199,9 @@ func RestrictToSuperAdminOrAdmin() gin.HandlerFunc {
 }
 
 // RestrictToSuperAdminOrAdminOrCustomer - allow SUPERADMIN, ADMIN, or CUSTOMER
-func RestrictToSuperAdminOrAdminOrCustomer() gin.HandlerFunc {
+func RestrictToSuperAdminOrAdminOrCustomer(a *app.App) gin.HandlerFunc {
 	return gin.HandlerFunc(func(c *gin.Context) {
-		AuthenticateToken()(c)
+		AuthenticateToken(a)(c)
 		if c.IsAborted() {
 			return
 		}
@@ -218,10 +210,10 @@ func RestrictToSuperAdminOrAdminOrCustomer() gin.HandlerFunc {
 }
 
 // RestrictToStaffWithPermission - check if staff has specific permission
-func RestrictToStaffWithPermission(permissionType models.PermissionType) gin.HandlerFunc {
+func RestrictToStaffWithPermission(a *app.App, permissionType models.PermissionType) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// First authenticate
-		AuthenticateToken()(c)
+		AuthenticateToken(a)(c)
 		if c.IsAborted() {
 			return
 		}
@@ -229,15 +221,13 @@ func RestrictToStaffWithPermission(permissionType models.PermissionType) gin.Han
 		// Get user from context
 		userInterface, exists := c.Get("user")
 		if !exists {
-			c.JSON(http.StatusUnauthorized, gin.H{"message": "Authentication required."})
-			c.Abort()
+			apperror.Abort(c, apperror.Unauthorized("Authentication required."))
 			return
 		}
 
 		user, ok := userInterface.(models.User)
 		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"message": "Authentication required."})
-			c.Abort()
+			apperror.Abort(c, apperror.Unauthorized("Authentication required."))
 			return
 		}
 
@@ -249,25 +239,20 @@ func RestrictToStaffWithPermission(permissionType models.PermissionType) gin.Han
 
 		// Check if STAFF with permission
 		if user.Role != models.RoleStaff {
-			c.JSON(http.StatusForbidden, gin.H{"message": "Unauthorized: Must be STAFF, ADMIN, or SUPERADMIN."})
-			c.Abort()
+			apperror.Abort(c, apperror.Forbidden("Unauthorized: Must be STAFF, ADMIN, or SUPERADMIN."))
 			return
 		}
 
 		// Check staff permissions
-		var staffDetails models.StaffDetails
-		if err := database.DB.
-			Preload("Permissions", "type = ? AND is_granted = ?", permissionType, true).
-			Where("user_id = ?", user.ID).
-			First(&staffDetails).Error; err != nil {
-			c.JSON(http.StatusForbidden, gin.H{"message": "Unauthorized: " + string(permissionType) + " permission required."})
-			c.Abort()
+		staffDetails, err := repository.Users(a.DB.WithContext(c.Request.Context())).
+			StaffWithPermission(user.ID, permissionType)
+		if err != nil {
+			apperror.Abort(c, apperror.Forbidden("Unauthorized: " + string(permissionType) + " permission required."))
 			return
 		}
 
 		if len(staffDetails.Permissions) == 0 {
-			c.JSON(http.StatusForbidden, gin.H{"message": "Unauthorized: " + string(permissionType) + " permission required."})
-			c.Abort()
+			apperror.Abort(c, apperror.Forbidden("Unauthorized: " + string(permissionType) + " permission required."))
 			return
 		}
 
@@ -0,0 +1,267 @@
+package middleware
+
+import (
+	"backend_pandhi/pkg/app"
+	"backend_pandhi/pkg/apperror"
+	"backend_pandhi/pkg/models"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	idempotencyHeader    = "Idempotency-Key"
+	idempotencyMaxKeyLen = 255
+
+	// An IN_PROGRESS record older than this belongs to a request that died
+	// (e.g. the instance crashed) and may be taken over by a retry
+	idempotencyStaleAfter = 2 * time.Minute
+)
+
+var (
+	idempotencySweepMu   sync.Mutex
+	idempotencyLastSweep time.Time
+)
+
+// IdempotencyStore holds the records behind Idempotency
+type IdempotencyStore interface {
+	// Claim stores record as IN_PROGRESS. It returns false when another
+	// request already holds a live record for the key.
+	Claim(ctx context.Context, record *models.IdempotencyRecord, now time.Time) (bool, error)
+	// Find returns the record holding a key
+	Find(ctx context.Context, scope, key string) (models.IdempotencyRecord, error)
+	// Complete stores the response a claimed record replays
+	Complete(ctx context.Context, id, status int, contentType string, body []byte) error
+	// Release deletes a claimed record so the key can be retried
+	Release(ctx context.Context, id int) error
+}
+
+// Idempotency makes a POST safe to retry. When the client sends an
+// Idempotency-Key header, the first request runs normally and its response is
+// stored; retries with the same key and body get the stored response back,
+// the same key with a different body is rejected, and a retry that arrives
+// while the original is still running gets 409. Server errors (5xx) are not
+// stored so the client can retry them. Requests without the header are
+// unaffected. Must run after AuthenticateToken so keys are scoped per user.
+func Idempotency(a *app.App) gin.HandlerFunc {
+	return IdempotencyWithStore(a, NewPostgresIdempotencyStore(a.DB))
+}
+
+// IdempotencyWithStore is Idempotency with records kept in store
+func IdempotencyWithStore(a *app.App, store IdempotencyStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(idempotencyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > idempotencyMaxKeyLen {
+			apperror.Abort(c, apperror.BadRequest("Idempotency-Key must be at most 255 characters"))
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			apperror.Abort(c, apperror.BadRequest("Failed to read request body"))
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		now := a.Clock.Now()
+		scope := clientKey(c, a.Config)
+		path := c.FullPath()
+		hash := idempotencyRequestHash(c.Request.Method, path, body)
+
+		record := models.IdempotencyRecord{
+			Scope:       scope,
+			Key:         key,
+			Method:      c.Request.Method,
+			Path:        path,
+			RequestHash: hash,
+			Status:      models.IdempotencyStatusInProgress,
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(a.Config.IdempotencyTTL),
+		}
+
+		claimed, err := store.Claim(c.Request.Context(), &record, now)
+		if err != nil {
+			apperror.Abort(c, apperror.Internal("Internal server error", err))
+			return
+		}
+
+		if !claimed {
+			existing, err := store.Find(c.Request.Context(), scope, key)
+			if err != nil {
+				// The holder finished with a 5xx and released the key in between
+				c.Header("Retry-After", "1")
+				apperror.Abort(c, apperror.Conflict("A request with this Idempotency-Key is already in progress"))
+				return
+			}
+
+			switch {
+			case existing.RequestHash != hash:
+				apperror.Abort(c, apperror.Unprocessable("Idempotency-Key was already used with a different request"))
+			case existing.Status == models.IdempotencyStatusInProgress:
+				c.Header("Retry-After", "1")
+				apperror.Abort(c, apperror.Conflict("A request with this Idempotency-Key is already in progress"))
+			default:
+				contentType := "application/json; charset=utf-8"
+				if existing.ResponseType != nil {
+					contentType = *existing.ResponseType
+				}
+				status := http.StatusOK
+				if existing.ResponseCode != nil {
+					status = *existing.ResponseCode
+				}
+				c.Header("Idempotent-Replayed", "true")
+				c.Data(status, contentType, existing.ResponseBody)
+			}
+			c.Abort()
+			return
+		}
+
+		// Capture the handler's response so it can be replayed
+		writer := &idempotencyResponseWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		// The bookkeeping below outlives the client: a disconnect after the
+		// handler committed must still store the response
+		ctx := context.WithoutCancel(c.Request.Context())
+
+		// Release the key only when nothing was committed, i.e. the handler
+		// panicked or answered 5xx, so the client can retry
+		release := true
+		defer func() {
+			if release {
+				if err := store.Release(ctx, record.ID); err != nil {
+					slog.WarnContext(ctx, "Failed to release idempotency key", "error", err)
+				}
+			}
+		}()
+
+		c.Next()
+
+		status := writer.Status()
+		if status >= http.StatusInternalServerError {
+			return
+		}
+		release = false
+
+		contentType := writer.Header().Get("Content-Type")
+		if err := store.Complete(ctx, record.ID, status, contentType, writer.body.Bytes()); err != nil {
+			// Keep the claim: retries get 409 rather than running the request again
+			slog.ErrorContext(ctx, "Failed to store idempotent response", "error", err)
+		}
+	}
+}
+
+// PostgresIdempotencyStore keeps records in the IdempotencyRecord table so a
+// retry may land on any instance
+type PostgresIdempotencyStore struct {
+	db *gorm.DB
+}
+
+// NewPostgresIdempotencyStore creates a store backed by db
+func NewPostgresIdempotencyStore(db *gorm.DB) *PostgresIdempotencyStore {
+	return &PostgresIdempotencyStore{db: db}
+}
+
+// Claim inserts an IN_PROGRESS record for the key. Expired records and stale
+// in-progress records are replaced.
+func (s *PostgresIdempotencyStore) Claim(ctx context.Context, record *models.IdempotencyRecord, now time.Time) (bool, error) {
+	sweepIdempotencyRecords(s.db, now)
+	db := s.db.WithContext(ctx)
+
+	// Clear out a dead record first so the insert below can claim the key
+	if err := db.
+		Where(`scope = ? AND key = ? AND ("expiresAt" < ? OR (status = ? AND "createdAt" < ?))`,
+			record.Scope, record.Key, now,
+			models.IdempotencyStatusInProgress, now.Add(-idempotencyStaleAfter)).
+		Delete(&models.IdempotencyRecord{}).Error; err != nil {
+		return false, err
+	}
+
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// Find returns the record holding a key
+func (s *PostgresIdempotencyStore) Find(ctx context.Context, scope, key string) (models.IdempotencyRecord, error) {
+	var record models.IdempotencyRecord
+	err := s.db.WithContext(ctx).Where("scope = ? AND key = ?", scope, key).First(&record).Error
+	return record, err
+}
+
+// Complete marks the record completed with the response to replay
+func (s *PostgresIdempotencyStore) Complete(ctx context.Context, id, status int, contentType string, body []byte) error {
+	return s.db.WithContext(ctx).Model(&models.IdempotencyRecord{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":       models.IdempotencyStatusCompleted,
+			"responseCode": status,
+			"responseType": contentType,
+			"responseBody": body,
+		}).Error
+}
+
+// Release deletes the record
+func (s *PostgresIdempotencyStore) Release(ctx context.Context, id int) error {
+	return s.db.WithContext(ctx).Delete(&models.IdempotencyRecord{}, id).Error
+}
+
+func idempotencyRequestHash(method, path string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method))
+	h.Write([]byte{0})
+	h.Write([]byte(path))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// sweepIdempotencyRecords deletes expired records at most every ten minutes
+func sweepIdempotencyRecords(db *gorm.DB, now time.Time) {
+	idempotencySweepMu.Lock()
+	if now.Sub(idempotencyLastSweep) < 10*time.Minute {
+		idempotencySweepMu.Unlock()
+		return
+	}
+	idempotencyLastSweep = now
+	idempotencySweepMu.Unlock()
+
+	go func() {
+		if err := db.Where(`"expiresAt" < ?`, now).
+			Delete(&models.IdempotencyRecord{}).Error; err != nil {
+			slog.Warn("Failed to sweep idempotency records", "error", err)
+		}
+	}()
+}
+
+// idempotencyResponseWriter copies everything written to the client
+type idempotencyResponseWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *idempotencyResponseWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *idempotencyResponseWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
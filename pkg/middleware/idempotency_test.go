@@ -0,0 +1,294 @@
+package middleware
+
+import (
+	"backend_pandhi/pkg/app"
+	"backend_pandhi/pkg/config"
+	"backend_pandhi/pkg/models"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// memoryIdempotencyStore follows the Postgres store's claim rules without a
+// database
+type memoryIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]*models.IdempotencyRecord
+	nextID  int
+
+	completeErr error // returned by Complete when set
+}
+
+func newMemoryIdempotencyStore() *memoryIdempotencyStore {
+	return &memoryIdempotencyStore{records: make(map[string]*models.IdempotencyRecord)}
+}
+
+func (s *memoryIdempotencyStore) Claim(ctx context.Context, record *models.IdempotencyRecord, now time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k := record.Scope + "\x00" + record.Key
+	if existing, ok := s.records[k]; ok {
+		stale := existing.Status == models.IdempotencyStatusInProgress && existing.CreatedAt.Before(now.Add(-idempotencyStaleAfter))
+		if !existing.ExpiresAt.Before(now) && !stale {
+			return false, nil
+		}
+	}
+	s.nextID++
+	record.ID = s.nextID
+	stored := *record
+	s.records[k] = &stored
+	return true, nil
+}
+
+func (s *memoryIdempotencyStore) Find(ctx context.Context, scope, key string) (models.IdempotencyRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if record, ok := s.records[scope+"\x00"+key]; ok {
+		return *record, nil
+	}
+	return models.IdempotencyRecord{}, gorm.ErrRecordNotFound
+}
+
+func (s *memoryIdempotencyStore) Complete(ctx context.Context, id, status int, contentType string, body []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.completeErr != nil {
+		return s.completeErr
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	for _, record := range s.records {
+		if record.ID == id {
+			record.Status = models.IdempotencyStatusCompleted
+			record.ResponseCode = &status
+			record.ResponseType = &contentType
+			record.ResponseBody = append([]byte(nil), body...)
+		}
+	}
+	return nil
+}
+
+func (s *memoryIdempotencyStore) Release(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	for k, record := range s.records {
+		if record.ID == id {
+			delete(s.records, k)
+		}
+	}
+	return nil
+}
+
+type testClock struct{ now time.Time }
+
+func (c *testClock) Now() time.Time { return c.now }
+
+type idempotentRequest struct {
+	user    int
+	key     string
+	body    string
+	advance time.Duration // move the clock before sending
+
+	wantStatus   int
+	wantBody     string // checked when set
+	wantReplayed bool
+}
+
+func TestIdempotency(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	longKey := strings.Repeat("k", idempotencyMaxKeyLen+1)
+
+	for _, tc := range []struct {
+		name     string
+		requests []idempotentRequest
+	}{
+		{"requests without a key always run", []idempotentRequest{
+			{user: 1, body: `{"a":1}`, wantStatus: http.StatusCreated, wantBody: `{"order":1}`},
+			{user: 1, body: `{"a":1}`, wantStatus: http.StatusCreated, wantBody: `{"order":2}`},
+		}},
+		{"a retry replays the stored response", []idempotentRequest{
+			{user: 1, key: "k1", body: `{"a":1}`, wantStatus: http.StatusCreated, wantBody: `{"order":1}`},
+			{user: 1, key: "k1", body: `{"a":1}`, wantStatus: http.StatusCreated, wantBody: `{"order":1}`, wantReplayed: true},
+			{user: 1, key: "k2", body: `{"a":1}`, wantStatus: http.StatusCreated, wantBody: `{"order":2}`},
+		}},
+		{"the same key with another body is rejected", []idempotentRequest{
+			{user: 1, key: "k1", body: `{"a":1}`, wantStatus: http.StatusCreated, wantBody: `{"order":1}`},
+			{user: 1, key: "k1", body: `{"a":2}`, wantStatus: http.StatusUnprocessableEntity},
+		}},
+		{"keys are scoped per user", []idempotentRequest{
+			{user: 1, key: "k1", body: `{"a":1}`, wantStatus: http.StatusCreated, wantBody: `{"order":1}`},
+			{user: 2, key: "k1", body: `{"a":1}`, wantStatus: http.StatusCreated, wantBody: `{"order":2}`},
+		}},
+		{"server errors release the key", []idempotentRequest{
+			{user: 1, key: "k1", body: `{"fail":true}`, wantStatus: http.StatusInternalServerError},
+			{user: 1, key: "k1", body: `{"fail":true}`, wantStatus: http.StatusInternalServerError},
+			{user: 1, key: "k1", body: `{"a":1}`, wantStatus: http.StatusCreated, wantBody: `{"order":3}`},
+		}},
+		{"panics release the key", []idempotentRequest{
+			{user: 1, key: "k1", body: `{"panic":true}`, wantStatus: http.StatusInternalServerError},
+			{user: 1, key: "k1", body: `{"panic":true}`, wantStatus: http.StatusInternalServerError},
+			{user: 1, key: "k1", body: `{"a":1}`, wantStatus: http.StatusCreated, wantBody: `{"order":3}`},
+		}},
+		{"client errors are stored", []idempotentRequest{
+			{user: 1, key: "k1", body: `not json`, wantStatus: http.StatusBadRequest},
+			{user: 1, key: "k1", body: `not json`, wantStatus: http.StatusBadRequest, wantReplayed: true},
+		}},
+		{"expired records are replaced", []idempotentRequest{
+			{user: 1, key: "k1", body: `{"a":1}`, wantStatus: http.StatusCreated, wantBody: `{"order":1}`},
+			{user: 1, key: "k1", body: `{"a":1}`, advance: 25 * time.Hour, wantStatus: http.StatusCreated, wantBody: `{"order":2}`},
+		}},
+		{"overlong keys are rejected", []idempotentRequest{
+			{user: 1, key: longKey, body: `{"a":1}`, wantStatus: http.StatusBadRequest},
+		}},
+	} {
+		router, _, clock := idempotencyRouter()
+		for i, req := range tc.requests {
+			clock.now = clock.now.Add(req.advance)
+			w := sendIdempotent(router, req.user, req.key, req.body)
+
+			if w.Code != req.wantStatus {
+				t.Errorf("%s: request %d = %d %s, want %d", tc.name, i+1, w.Code, w.Body, req.wantStatus)
+				continue
+			}
+			if req.wantBody != "" && w.Body.String() != req.wantBody {
+				t.Errorf("%s: request %d body = %s, want %s", tc.name, i+1, w.Body, req.wantBody)
+			}
+			if replayed := w.Header().Get("Idempotent-Replayed") == "true"; replayed != req.wantReplayed {
+				t.Errorf("%s: request %d replayed = %v, want %v", tc.name, i+1, replayed, req.wantReplayed)
+			}
+		}
+	}
+}
+
+func TestIdempotencyInProgress(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router, store, clock := idempotencyRouter()
+	body := `{"a":1}`
+
+	// Another instance is still running the first request
+	if _, err := store.Claim(context.Background(), &models.IdempotencyRecord{
+		Scope:       "user:1",
+		Key:         "k1",
+		RequestHash: idempotencyRequestHash(http.MethodPost, "/orders", []byte(body)),
+		Status:      models.IdempotencyStatusInProgress,
+		CreatedAt:   clock.now,
+		ExpiresAt:   clock.now.Add(24 * time.Hour),
+	}, clock.now); err != nil {
+		t.Fatal(err)
+	}
+
+	w := sendIdempotent(router, 1, "k1", body)
+	if w.Code != http.StatusConflict || w.Header().Get("Retry-After") != "1" {
+		t.Errorf("retry while in progress = %d, Retry-After %q, want 409 with Retry-After 1", w.Code, w.Header().Get("Retry-After"))
+	}
+	if w := sendIdempotent(router, 1, "k1", `{"a":2}`); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("different body while in progress = %d, want 422", w.Code)
+	}
+
+	// A request that never finished is taken over once stale
+	clock.now = clock.now.Add(idempotencyStaleAfter + time.Second)
+	if w := sendIdempotent(router, 1, "k1", body); w.Code != http.StatusCreated || w.Body.String() != `{"order":1}` {
+		t.Errorf("retry of a stale request = %d %s, want 201 from the handler", w.Code, w.Body)
+	}
+}
+
+func TestIdempotencyKeepsCommittedKeys(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// The response cannot be stored: the claim stays so a retry cannot run
+	// the request a second time
+	router, store, _ := idempotencyRouter()
+	store.completeErr = errors.New("connection reset")
+	if w := sendIdempotent(router, 1, "k1", `{"a":1}`); w.Code != http.StatusCreated {
+		t.Fatalf("first request = %d %s", w.Code, w.Body)
+	}
+	if w := sendIdempotent(router, 1, "k1", `{"a":1}`); w.Code != http.StatusConflict {
+		t.Errorf("retry after a failed store = %d %s, want 409", w.Code, w.Body)
+	}
+
+	// The client hangs up once the handler has committed: the response is
+	// still stored for its retry
+	router, _, _ = idempotencyRouter()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(`{"a":1}`)).WithContext(ctx)
+	req.Header.Set("X-Test-User", "1")
+	req.Header.Set(idempotencyHeader, "k1")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	w := sendIdempotent(router, 1, "k1", `{"a":1}`)
+	if w.Code != http.StatusCreated || w.Body.String() != `{"order":1}` || w.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("retry after a disconnect = %d %s, want the replayed order 1", w.Code, w.Body)
+	}
+}
+
+// idempotencyRouter serves POST /orders behind Idempotency. The handler
+// numbers the orders it creates and fails or panics when the body asks it to.
+func idempotencyRouter() (*gin.Engine, *memoryIdempotencyStore, *testClock) {
+	clock := &testClock{now: time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)}
+	store := newMemoryIdempotencyStore()
+	a := &app.App{Config: &config.Config{IdempotencyTTL: 24 * time.Hour}, Clock: clock}
+
+	var mu sync.Mutex
+	orders := 0
+	router := gin.New()
+	router.Use(gin.RecoveryWithWriter(io.Discard))
+	router.POST("/orders",
+		func(c *gin.Context) {
+			var user models.User
+			fmt.Sscan(c.GetHeader("X-Test-User"), &user.ID)
+			c.Set("user", user)
+		},
+		IdempotencyWithStore(a, store),
+		func(c *gin.Context) {
+			var req struct {
+				Fail  bool `json:"fail"`
+				Panic bool `json:"panic"`
+			}
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.AbortWithStatus(http.StatusBadRequest)
+				return
+			}
+			mu.Lock()
+			orders++
+			n := orders
+			mu.Unlock()
+			if req.Panic {
+				panic("handler failed")
+			}
+			if req.Fail {
+				c.JSON(http.StatusInternalServerError, gin.H{"order": n})
+				return
+			}
+			c.JSON(http.StatusCreated, gin.H{"order": n})
+		},
+	)
+	return router, store, clock
+}
+
+func sendIdempotent(router *gin.Engine, user int, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Test-User", fmt.Sprint(user))
+	if key != "" {
+		req.Header.Set(idempotencyHeader, key)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
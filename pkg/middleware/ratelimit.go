@@ -0,0 +1,327 @@
+package middleware
+
+import (
+	"backend_pandhi/pkg/app"
+	"backend_pandhi/pkg/apperror"
+	"backend_pandhi/pkg/config"
+	"backend_pandhi/pkg/models"
+	"backend_pandhi/pkg/utils"
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RateLimitPolicy is a token bucket: Limit requests may burst at once and the
+// bucket refills at Limit tokens per Window
+type RateLimitPolicy struct {
+	Name   string
+	Limit  int
+	Window time.Duration
+}
+
+func (p RateLimitPolicy) refillPerSecond() float64 {
+	return float64(p.Limit) / p.Window.Seconds()
+}
+
+// RateLimitResult is the outcome of taking one token from a bucket
+type RateLimitResult struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration // until one token is available
+	Reset      time.Duration // until the bucket is full again
+}
+
+// RateLimitStore holds token bucket state
+type RateLimitStore interface {
+	Take(ctx context.Context, key string, policy RateLimitPolicy, now time.Time) (RateLimitResult, error)
+}
+
+// RateLimiter applies the configured rate limit policies
+type RateLimiter struct {
+	app      *app.App
+	store    RateLimitStore
+	policies map[string]RateLimitPolicy
+	enabled  bool
+}
+
+// NewRateLimiter parses the configured policies and selects the store
+// (RATE_LIMIT_STORE=memory|postgres)
+func NewRateLimiter(a *app.App) (*RateLimiter, error) {
+	cfg := a.Config
+	l := &RateLimiter{app: a, enabled: cfg.RateLimitEnabled}
+
+	policies, err := ParseRateLimitPolicies(cfg.RateLimitPolicies)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := policies["default"]; !ok {
+		policies["default"] = RateLimitPolicy{Name: "default", Limit: 300, Window: time.Minute}
+	}
+	l.policies = policies
+
+	switch cfg.RateLimitStore {
+	case "postgres":
+		l.store = NewPostgresRateLimitStore(a.DB)
+	case "memory", "":
+		l.store = NewMemoryRateLimitStore()
+	default:
+		return nil, fmt.Errorf("unknown RATE_LIMIT_STORE %q", cfg.RateLimitStore)
+	}
+	return l, nil
+}
+
+// SetStore replaces the store (used by tests and tooling)
+func (l *RateLimiter) SetStore(s RateLimitStore) {
+	l.store = s
+}
+
+// ParseRateLimitPolicies parses "name=limit/window,..." e.g. "coupon=10/1m"
+func ParseRateLimitPolicies(spec string) (map[string]RateLimitPolicy, error) {
+	policies := make(map[string]RateLimitPolicy)
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		name, rule, ok := strings.Cut(part, "=")
+		limitStr, windowStr, ok2 := strings.Cut(rule, "/")
+		if !ok || !ok2 {
+			return nil, fmt.Errorf("invalid rate limit policy %q (want name=limit/window)", part)
+		}
+
+		limit, err := strconv.Atoi(strings.TrimSpace(limitStr))
+		if err != nil || limit <= 0 {
+			return nil, fmt.Errorf("invalid limit in rate limit policy %q", part)
+		}
+		window, err := time.ParseDuration(strings.TrimSpace(windowStr))
+		if err != nil || window <= 0 {
+			return nil, fmt.Errorf("invalid window in rate limit policy %q", part)
+		}
+
+		name = strings.TrimSpace(name)
+		policies[name] = RateLimitPolicy{Name: name, Limit: limit, Window: window}
+	}
+	return policies, nil
+}
+
+// Limit limits requests under the named policy. Clients are identified by
+// user ID when they carry a valid token and by IP otherwise. Unknown policy
+// names fall back to "default".
+func (l *RateLimiter) Limit(policyName string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !l.enabled || l.store == nil || c.Request.Method == http.MethodOptions {
+			c.Next()
+			return
+		}
+
+		policy, ok := l.policies[policyName]
+		if !ok {
+			policy = l.policies["default"]
+		}
+
+		key := policy.Name + ":" + clientKey(c, l.app.Config)
+		result, err := l.store.Take(c.Request.Context(), key, policy, l.app.Clock.Now())
+		if err != nil {
+			// Fail open: a store outage must not take the API down
+			slog.WarnContext(c.Request.Context(), "Rate limit store error", "error", err)
+			c.Next()
+			return
+		}
+
+		c.Header("RateLimit-Policy", fmt.Sprintf("%d;w=%d", policy.Limit, int(policy.Window.Seconds())))
+		c.Header("RateLimit-Limit", strconv.Itoa(policy.Limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+
+		if !result.Allowed {
+			retryAfter := ceilSeconds(result.RetryAfter)
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			apperror.Abort(c, apperror.TooManyRequests("Too many requests. Please slow down.").With("retryAfterSeconds", retryAfter))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// clientKey identifies the caller: the authenticated user if
+// AuthenticateToken already ran, else a valid bearer/cookie token, else the IP
+func clientKey(c *gin.Context, cfg *config.Config) string {
+	if userInterface, exists := c.Get("user"); exists {
+		switch u := userInterface.(type) {
+		case models.User:
+			return fmt.Sprintf("user:%d", u.ID)
+		case gin.H:
+			return fmt.Sprintf("admin:%v", u["id"])
+		}
+	}
+
+	token := ""
+	if cookieToken, err := c.Cookie("token"); err == nil && cookieToken != "" {
+		token = cookieToken
+	} else if parts := strings.Split(c.GetHeader("Authorization"), " "); len(parts) == 2 && parts[0] == "Bearer" {
+		token = parts[1]
+	}
+	if token != "" {
+		if claims, err := utils.VerifyToken(cfg, token); err == nil {
+			if claims.Role == models.RoleAdmin {
+				return fmt.Sprintf("admin:%d", claims.ID)
+			}
+			return fmt.Sprintf("user:%d", claims.ID)
+		}
+	}
+
+	return "ip:" + c.ClientIP()
+}
+
+func ceilSeconds(d time.Duration) int {
+	if d <= 0 {
+		return 0
+	}
+	return int(math.Ceil(d.Seconds()))
+}
+
+// takeToken applies one request to a bucket holding tokens as of updatedAt
+func takeToken(tokens float64, updatedAt time.Time, policy RateLimitPolicy, now time.Time) (float64, RateLimitResult) {
+	rate := policy.refillPerSecond()
+	capacity := float64(policy.Limit)
+
+	if elapsed := now.Sub(updatedAt).Seconds(); elapsed > 0 {
+		tokens = math.Min(capacity, tokens+elapsed*rate)
+	}
+
+	result := RateLimitResult{}
+	if tokens >= 1 {
+		tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration((1 - tokens) / rate * float64(time.Second))
+	}
+	result.Remaining = int(math.Floor(tokens))
+	result.Reset = time.Duration((capacity - tokens) / rate * float64(time.Second))
+	return tokens, result
+}
+
+// MemoryRateLimitStore keeps buckets in process memory. Limits are per
+// instance; use the Postgres store when running several instances.
+type MemoryRateLimitStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*memoryBucket
+	lastSweep time.Time
+}
+
+type memoryBucket struct {
+	tokens    float64
+	updatedAt time.Time
+	fullAt    time.Time
+}
+
+// NewMemoryRateLimitStore creates an empty in-memory store
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{buckets: make(map[string]*memoryBucket)}
+}
+
+// Take removes one token from the bucket for key
+func (s *MemoryRateLimitStore) Take(ctx context.Context, key string, policy RateLimitPolicy, now time.Time) (RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Drop buckets that have refilled completely; they are equivalent to new ones
+	if now.Sub(s.lastSweep) > time.Minute {
+		for k, b := range s.buckets {
+			if now.After(b.fullAt) {
+				delete(s.buckets, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &memoryBucket{tokens: float64(policy.Limit), updatedAt: now}
+		s.buckets[key] = b
+	}
+
+	tokens, result := takeToken(b.tokens, b.updatedAt, policy, now)
+	b.tokens = tokens
+	b.updatedAt = now
+	b.fullAt = now.Add(result.Reset)
+	return result, nil
+}
+
+// PostgresRateLimitStore keeps buckets in the RateLimitBucket table so every
+// instance shares the same limits
+type PostgresRateLimitStore struct {
+	db *gorm.DB
+
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+// NewPostgresRateLimitStore creates a store backed by db
+func NewPostgresRateLimitStore(db *gorm.DB) *PostgresRateLimitStore {
+	return &PostgresRateLimitStore{db: db}
+}
+
+// Take removes one token from the bucket for key inside a row-locked transaction
+func (s *PostgresRateLimitStore) Take(ctx context.Context, key string, policy RateLimitPolicy, now time.Time) (RateLimitResult, error) {
+	s.sweep(now)
+
+	var result RateLimitResult
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.RateLimitBucket{
+			Key:       key,
+			Tokens:    float64(policy.Limit),
+			UpdatedAt: now,
+		}).Error; err != nil {
+			return err
+		}
+
+		var bucket models.RateLimitBucket
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("key = ?", key).
+			First(&bucket).Error; err != nil {
+			return err
+		}
+
+		var tokens float64
+		tokens, result = takeToken(bucket.Tokens, bucket.UpdatedAt, policy, now)
+
+		return tx.Model(&models.RateLimitBucket{}).
+			Where("key = ?", key).
+			Updates(map[string]interface{}{
+				"tokens":    tokens,
+				"updatedAt": now,
+			}).Error
+	})
+	return result, err
+}
+
+// sweep deletes idle buckets at most every ten minutes
+func (s *PostgresRateLimitStore) sweep(now time.Time) {
+	s.mu.Lock()
+	if now.Sub(s.lastSweep) < 10*time.Minute {
+		s.mu.Unlock()
+		return
+	}
+	s.lastSweep = now
+	s.mu.Unlock()
+
+	go func() {
+		if err := s.db.Where(`"updatedAt" < ?`, now.Add(-24*time.Hour)).
+			Delete(&models.RateLimitBucket{}).Error; err != nil {
+			slog.Warn("Failed to sweep rate limit buckets", "error", err)
+		}
+	}()
+}
@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTakeToken(t *testing.T) {
+	// Refills one token a second
+	policy := RateLimitPolicy{Name: "test", Limit: 10, Window: 10 * time.Second}
+	now := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+
+	for _, tc := range []struct {
+		name       string
+		tokens     float64
+		updatedAt  time.Time
+		wantTokens float64
+		want       RateLimitResult
+	}{
+		{"full bucket", 10, now, 9, RateLimitResult{Allowed: true, Remaining: 9, Reset: time.Second}},
+		{"fractional tokens", 2.5, now, 1.5, RateLimitResult{Allowed: true, Remaining: 1, Reset: 8500 * time.Millisecond}},
+		{"empty bucket", 0.25, now, 0.25, RateLimitResult{RetryAfter: 750 * time.Millisecond, Reset: 9750 * time.Millisecond}},
+		{"refills with time", 0, now.Add(-3 * time.Second), 2, RateLimitResult{Allowed: true, Remaining: 2, Reset: 8 * time.Second}},
+		{"refill stops at the limit", 4, now.Add(-time.Hour), 9, RateLimitResult{Allowed: true, Remaining: 9, Reset: time.Second}},
+		{"clock skew does not refill", 0.5, now.Add(5 * time.Second), 0.5, RateLimitResult{RetryAfter: 500 * time.Millisecond, Reset: 9500 * time.Millisecond}},
+	} {
+		tokens, got := takeToken(tc.tokens, tc.updatedAt, policy, now)
+		if tokens != tc.wantTokens || got != tc.want {
+			t.Errorf("%s: takeToken = %v, %+v, want %v, %+v", tc.name, tokens, got, tc.wantTokens, tc.want)
+		}
+	}
+}
+
+func TestMemoryRateLimitStoreBurst(t *testing.T) {
+	store := NewMemoryRateLimitStore()
+	policy := RateLimitPolicy{Name: "test", Limit: 3, Window: 3 * time.Second}
+	now := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	take := func(key string, at time.Time) RateLimitResult {
+		result, err := store.Take(context.Background(), key, policy, at)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	for i := 0; i < policy.Limit; i++ {
+		if result := take("a", now); !result.Allowed {
+			t.Fatalf("request %d was limited: %+v", i+1, result)
+		}
+	}
+	if result := take("a", now); result.Allowed || result.RetryAfter != time.Second {
+		t.Errorf("request over the burst = %+v, want limited for 1s", result)
+	}
+	if result := take("b", now); !result.Allowed {
+		t.Errorf("another key shares the bucket: %+v", result)
+	}
+	if result := take("a", now.Add(time.Second)); !result.Allowed || result.Remaining != 0 {
+		t.Errorf("request after a refill = %+v, want allowed with none remaining", result)
+	}
+}
+
+func TestParseRateLimitPolicies(t *testing.T) {
+	for _, tc := range []struct {
+		spec string
+		want map[string]RateLimitPolicy // nil for invalid
+	}{
+		{"", map[string]RateLimitPolicy{}},
+		{"coupon=10/1m", map[string]RateLimitPolicy{
+			"coupon": {Name: "coupon", Limit: 10, Window: time.Minute},
+		}},
+		{" coupon = 10 / 1m , otp=5/10m,", map[string]RateLimitPolicy{
+			"coupon": {Name: "coupon", Limit: 10, Window: time.Minute},
+			"otp":    {Name: "otp", Limit: 5, Window: 10 * time.Minute},
+		}},
+		{"coupon=10/1m,coupon=20/1h", map[string]RateLimitPolicy{
+			"coupon": {Name: "coupon", Limit: 20, Window: time.Hour},
+		}},
+		{"coupon", nil},
+		{"coupon=10", nil},
+		{"coupon=0/1m", nil},
+		{"coupon=-1/1m", nil},
+		{"coupon=ten/1m", nil},
+		{"coupon=10/0s", nil},
+		{"coupon=10/soon", nil},
+		{"coupon=10/1m,otp", nil},
+	} {
+		got, err := ParseRateLimitPolicies(tc.spec)
+		if tc.want == nil {
+			if err == nil {
+				t.Errorf("ParseRateLimitPolicies(%q) = %v, want an error", tc.spec, got)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("ParseRateLimitPolicies(%q) = %v, %v, want %v", tc.spec, got, err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"backend_pandhi/pkg/logging"
+	"backend_pandhi/pkg/models"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader carries the request ID in both directions
+const RequestIDHeader = "X-Request-ID"
+
+// Incoming IDs are reused only if they look like IDs, so a client cannot
+// inject arbitrary text into the logs
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._\-]{1,64}$`)
+
+// RequestID assigns every request an ID, taken from X-Request-ID when the
+// caller (usually the load balancer) sent a usable one. The ID is echoed in
+// the response, stored under "requestID" and added to the request context so
+// slog records and GORM queries made with that context include it.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !requestIDPattern.MatchString(id) {
+			id = newRequestID()
+		}
+
+		c.Set("requestID", id)
+		c.Header(RequestIDHeader, id)
+		c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), id))
+
+		c.Next()
+	}
+}
+
+// RequestLogger writes one record per request once the response is sent.
+// Only the path is logged; query strings can carry tokens.
+func RequestLogger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+
+		attrs := []any{
+			"method", c.Request.Method,
+			"route", c.FullPath(),
+			"path", c.Request.URL.Path,
+			"status", status,
+			"duration_ms", float64(time.Since(start).Microseconds()) / 1000,
+			"client_ip", c.ClientIP(),
+			"bytes", c.Writer.Size(),
+		}
+		if userInterface, exists := c.Get("user"); exists {
+			switch u := userInterface.(type) {
+			case models.User:
+				attrs = append(attrs, "user_id", u.ID, "role", u.Role)
+			case gin.H:
+				attrs = append(attrs, "admin_id", u["id"], "role", u["role"])
+			}
+		}
+
+		slog.Log(c.Request.Context(), level, "HTTP request", attrs...)
+	}
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"backend_pandhi/pkg/app"
+	"backend_pandhi/pkg/apperror"
+	"backend_pandhi/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireVerifiedEmail blocks unverified customers when REQUIRE_EMAIL_VERIFICATION
+// is enabled. Must run after AuthenticateToken.
+func RequireVerifiedEmail(a *app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !a.Config.RequireEmailVerification {
+			c.Next()
+			return
+		}
+
+		userInterface, exists := c.Get("user")
+		if !exists {
+			apperror.Abort(c, apperror.Unauthorized("Authentication required."))
+			return
+		}
+
+		user, ok := userInterface.(models.User)
+		if ok && user.Role == models.RoleCustomer && !user.IsVerified {
+			apperror.Abort(c, apperror.Forbidden("Please verify your email address before placing orders.").WithCode("EMAIL_NOT_VERIFIED"))
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,446 @@
+// Package migrations applies the versioned SQL files in sql/.
+//
+// Each migration is a pair of files, NNNN_name.up.sql and NNNN_name.down.sql,
+// embedded in the binary. Applied migrations are recorded in
+// schema_migrations together with a checksum of their up file, so a migration
+// that was edited after it ran is reported instead of silently skipped.
+//
+// Because applied files cannot change, a later migration that prepares data
+// for an earlier one declares it with a "-- before: NNNN" line. It then runs
+// ahead of that version on databases where both are still pending, and in
+// its own place everywhere else.
+//
+// Every command holds a Postgres advisory lock for its whole run, so when
+// several instances start at once only one of them migrates and the others
+// wait for it and then find nothing to do.
+package migrations
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed sql/*.sql
+var files embed.FS
+
+// lockKey identifies the advisory lock taken while migrating. Any constant
+// works as long as nothing else in the database uses it.
+const lockKey int64 = 0x2b9c_41d6_6f3e_8a51
+
+// BaselineVersion is the last migration already present in databases created
+// by the Prisma migrations of the original server
+const BaselineVersion = 1
+
+var (
+	fileName        = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+	beforeDirective = regexp.MustCompile(`(?m)^-- before: (\d+)$`)
+)
+
+var (
+	// ErrChecksumMismatch means an applied migration's file has changed since
+	// it ran
+	ErrChecksumMismatch = errors.New("applied migration has been modified")
+
+	// ErrUnknownVersion means the database has a migration this binary does
+	// not include, typically because a newer release already ran
+	ErrUnknownVersion = errors.New("database has a migration unknown to this binary")
+
+	// ErrNotBaselined means the schema exists but schema_migrations is empty
+	ErrNotBaselined = errors.New("database has tables but no migration history; run `migrate baseline` first")
+)
+
+// Migration is one versioned schema change
+type Migration struct {
+	Version  int
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+
+	// Before is the earlier version this migration runs ahead of when both
+	// are pending, or 0
+	Before int
+}
+
+// Status describes a migration and whether it has been applied. Migrations
+// recorded in the database but missing from the binary have an empty Up.
+type Status struct {
+	Migration
+	Applied   bool
+	Baselined bool
+	AppliedAt time.Time
+	Modified  bool
+	Missing   bool
+}
+
+// Pending reports whether the migration still has to run
+func (s Status) Pending() bool {
+	return !s.Applied
+}
+
+// Load parses the embedded migration files in version order
+func Load() ([]Migration, error) {
+	entries, err := fs.ReadDir(files, "sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("unexpected migration file name %q", entry.Name())
+		}
+		version, _ := strconv.Atoi(match[1])
+		body, err := files.ReadFile(path.Join("sql", entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %04d has two names: %s and %s", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(body)
+			m.Checksum = checksum(body)
+			if before := beforeDirective.FindStringSubmatch(m.Up); before != nil {
+				m.Before, _ = strconv.Atoi(before[1])
+			}
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %04d_%s needs both an up and a down file", m.Version, m.Name)
+		}
+		if _, ok := byVersion[m.Before]; m.Before != 0 && (m.Before >= m.Version || !ok) {
+			return nil, fmt.Errorf("migration %04d_%s runs before %04d, which is not an earlier migration", m.Version, m.Name, m.Before)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+func checksum(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// Migrator runs the embedded migrations against one database
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+// New returns a Migrator for db with the embedded migrations
+func New(db *sql.DB) (*Migrator, error) {
+	migrations, err := Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load migrations: %w", err)
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+type record struct {
+	name      string
+	checksum  string
+	appliedAt time.Time
+	baselined bool
+}
+
+// withLock runs fn on a single connection holding the migration lock, after
+// making sure schema_migrations exists. The lock is session-level, so every
+// statement has to go through conn.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockKey); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer func() {
+		// Unlock even when ctx was cancelled mid-run
+		if _, err := conn.ExecContext(context.WithoutCancel(ctx), "SELECT pg_advisory_unlock($1)", lockKey); err != nil {
+			slog.WarnContext(ctx, "Failed to release migration lock", "error", err)
+		}
+	}()
+
+	if _, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		name       TEXT NOT NULL,
+		checksum   TEXT NOT NULL,
+		baselined  BOOLEAN NOT NULL DEFAULT false,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	return fn(conn)
+}
+
+func applied(ctx context.Context, conn *sql.Conn) (map[int]record, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version, name, checksum, baselined, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	records := map[int]record{}
+	for rows.Next() {
+		var version int
+		var r record
+		if err := rows.Scan(&version, &r.name, &r.checksum, &r.baselined, &r.appliedAt); err != nil {
+			return nil, err
+		}
+		records[version] = r
+	}
+	return records, rows.Err()
+}
+
+func (m *Migrator) status(ctx context.Context, conn *sql.Conn) ([]Status, error) {
+	records, err := applied(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations)+len(records))
+	for _, mig := range m.migrations {
+		s := Status{Migration: mig}
+		if r, ok := records[mig.Version]; ok {
+			s.Applied = true
+			s.Baselined = r.baselined
+			s.AppliedAt = r.appliedAt
+			s.Modified = r.checksum != mig.Checksum
+			delete(records, mig.Version)
+		}
+		statuses = append(statuses, s)
+	}
+	for version, r := range records {
+		statuses = append(statuses, Status{
+			Migration: Migration{Version: version, Name: r.name, Checksum: r.checksum},
+			Applied:   true,
+			Baselined: r.baselined,
+			AppliedAt: r.appliedAt,
+			Missing:   true,
+		})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
+
+// Status lists every known migration, applied or not
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	var statuses []Status
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		var err error
+		statuses, err = m.status(ctx, conn)
+		return err
+	})
+	return statuses, err
+}
+
+// verify refuses to go on when the recorded history does not match the
+// embedded files
+func verify(statuses []Status) error {
+	for _, s := range statuses {
+		switch {
+		case s.Missing:
+			return fmt.Errorf("%w: %04d_%s", ErrUnknownVersion, s.Version, s.Name)
+		case s.Modified:
+			return fmt.Errorf("%w: %04d_%s", ErrChecksumMismatch, s.Version, s.Name)
+		}
+	}
+	return nil
+}
+
+// Up applies every pending migration in order, each in its own transaction,
+// and returns the ones it applied
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		statuses, err := m.status(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := verify(statuses); err != nil {
+			return err
+		}
+
+		if !anyApplied(statuses) {
+			// A Prisma-created database would fail on the first CREATE TABLE;
+			// say what to do instead
+			var exists bool
+			if err := conn.QueryRowContext(ctx, `SELECT to_regclass('"User"') IS NOT NULL`).Scan(&exists); err != nil {
+				return err
+			}
+			if exists {
+				return ErrNotBaselined
+			}
+		}
+
+		for _, s := range pending(statuses) {
+			start := time.Now()
+			if err := run(ctx, conn, s.Up,
+				"INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3)",
+				s.Version, s.Name, s.Checksum); err != nil {
+				return fmt.Errorf("migration %04d_%s failed: %w", s.Version, s.Name, err)
+			}
+			slog.InfoContext(ctx, "Applied migration", "version", s.Version, "name", s.Name, "duration_ms", time.Since(start).Milliseconds())
+			done = append(done, s.Migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down rolls back the most recent steps applied migrations, newest first, and
+// returns the ones it rolled back
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		statuses, err := m.status(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := verify(statuses); err != nil {
+			return err
+		}
+
+		for i := len(statuses) - 1; i >= 0 && len(done) < steps; i-- {
+			s := statuses[i]
+			if !s.Applied {
+				continue
+			}
+			if err := run(ctx, conn, s.Down,
+				"DELETE FROM schema_migrations WHERE version = $1", s.Version); err != nil {
+				return fmt.Errorf("rollback of %04d_%s failed: %w", s.Version, s.Name, err)
+			}
+			slog.InfoContext(ctx, "Rolled back migration", "version", s.Version, "name", s.Name)
+			done = append(done, s.Migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Baseline records every migration up to and including version as applied
+// without running it, for databases whose schema was created some other way.
+// It only works on a database with no migration history.
+func (m *Migrator) Baseline(ctx context.Context, version int) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		statuses, err := m.status(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if anyApplied(statuses) {
+			return errors.New("database already has migration history; baseline only applies to an unmanaged database")
+		}
+		if !known(statuses, version) {
+			return fmt.Errorf("no migration with version %d", version)
+		}
+
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		for _, s := range statuses {
+			if s.Version > version {
+				break
+			}
+			if _, err := tx.ExecContext(ctx,
+				"INSERT INTO schema_migrations (version, name, checksum, baselined) VALUES ($1, $2, $3, true)",
+				s.Version, s.Name, s.Checksum); err != nil {
+				return err
+			}
+			done = append(done, s.Migration)
+		}
+		return tx.Commit()
+	})
+	return done, err
+}
+
+// run executes body and the bookkeeping statement in one transaction. body
+// may hold several statements; without arguments the driver sends it with
+// the simple protocol, which allows that.
+func run(ctx context.Context, conn *sql.Conn, body, bookkeeping string, args ...any) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if strings.TrimSpace(body) != "" {
+		if _, err := tx.ExecContext(ctx, body); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, bookkeeping, args...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// pending returns the migrations Up has to run, in the order to run them
+func pending(statuses []Status) []Status {
+	var ordered []Status
+	queued := map[int]bool{}
+	for _, s := range statuses {
+		if !s.Pending() || queued[s.Version] {
+			continue
+		}
+		for _, early := range statuses {
+			if early.Pending() && early.Before == s.Version && !queued[early.Version] {
+				ordered = append(ordered, early)
+				queued[early.Version] = true
+			}
+		}
+		ordered = append(ordered, s)
+		queued[s.Version] = true
+	}
+	return ordered
+}
+
+func anyApplied(statuses []Status) bool {
+	for _, s := range statuses {
+		if s.Applied {
+			return true
+		}
+	}
+	return false
+}
+
+func known(statuses []Status, version int) bool {
+	for _, s := range statuses {
+		if s.Version == version {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,46 @@
+package migrations
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestLoadReadsBeforeDirective(t *testing.T) {
+	loaded, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	for _, m := range loaded {
+		if m.Name == "normalize_user_phones" && m.Before != 3 {
+			t.Errorf("%04d_%s runs before %d, want 3", m.Version, m.Name, m.Before)
+		}
+	}
+}
+
+func TestPendingRunsDataFixesEarly(t *testing.T) {
+	status := func(version, before int, applied bool) Status {
+		return Status{Migration: Migration{Version: version, Before: before}, Applied: applied}
+	}
+	versions := func(statuses []Status) []int {
+		var out []int
+		for _, s := range statuses {
+			out = append(out, s.Version)
+		}
+		return out
+	}
+
+	for _, tc := range []struct {
+		name     string
+		statuses []Status
+		want     []int
+	}{
+		{"fresh database", []Status{status(1, 0, false), status(2, 0, false), status(3, 0, false), status(4, 2, false)}, []int{1, 4, 2, 3}},
+		{"earlier migration applied", []Status{status(1, 0, true), status(2, 0, true), status(3, 0, false), status(4, 2, false)}, []int{3, 4}},
+		{"nothing early", []Status{status(1, 0, true), status(2, 0, false), status(3, 0, false)}, []int{2, 3}},
+		{"all applied", []Status{status(1, 0, true), status(2, 1, true)}, nil},
+	} {
+		if got := versions(pending(tc.statuses)); !slices.Equal(got, tc.want) {
+			t.Errorf("%s: pending = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+-- Drops the whole application schema. Only useful on development databases.
+
+DROP TABLE IF EXISTS "UserFreeQuota" CASCADE;
+DROP TABLE IF EXISTS "Feedback" CASCADE;
+DROP TABLE IF EXISTS "OutletAppManagement" CASCADE;
+DROP TABLE IF EXISTS "OutletAvailability" CASCADE;
+DROP TABLE IF EXISTS "UserDeviceToken" CASCADE;
+DROP TABLE IF EXISTS "NotificationDelivery" CASCADE;
+DROP TABLE IF EXISTS "ScheduledNotification" CASCADE;
+DROP TABLE IF EXISTS "Notification" CASCADE;
+DROP TABLE IF EXISTS "Expense" CASCADE;
+DROP TABLE IF EXISTS "CouponUsage" CASCADE;
+DROP TABLE IF EXISTS "Coupon" CASCADE;
+DROP TABLE IF EXISTS "Ticket" CASCADE;
+DROP TABLE IF EXISTS "AdminPermission" CASCADE;
+DROP TABLE IF EXISTS "AdminOutlet" CASCADE;
+DROP TABLE IF EXISTS "Admin" CASCADE;
+DROP TABLE IF EXISTS "WalletTransaction" CASCADE;
+DROP TABLE IF EXISTS "Wallet" CASCADE;
+DROP TABLE IF EXISTS "OrderItem" CASCADE;
+DROP TABLE IF EXISTS "Order" CASCADE;
+DROP TABLE IF EXISTS "CartItem" CASCADE;
+DROP TABLE IF EXISTS "Cart" CASCADE;
+DROP TABLE IF EXISTS "StockHistory" CASCADE;
+DROP TABLE IF EXISTS "Inventory" CASCADE;
+DROP TABLE IF EXISTS "Product" CASCADE;
+DROP TABLE IF EXISTS "StaffPermission" CASCADE;
+DROP TABLE IF EXISTS "StaffDetails" CASCADE;
+DROP TABLE IF EXISTS "CustomerDetails" CASCADE;
+DROP TABLE IF EXISTS "User" CASCADE;
+DROP TABLE IF EXISTS "Outlet" CASCADE;
@@ -0,0 +1,20 @@
+-- Single-use password reset and email verification tokens
+
+-- CreateTable
+CREATE TABLE "AuthToken" (
+    "id" SERIAL NOT NULL,
+    "accountType" TEXT NOT NULL,
+    "accountId" INTEGER NOT NULL,
+    "purpose" TEXT NOT NULL,
+    "tokenHash" TEXT NOT NULL,
+    "expiresAt" TIMESTAMP(3) NOT NULL,
+    "usedAt" TIMESTAMP(3),
+    "createdAt" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT "AuthToken_pkey" PRIMARY KEY ("id")
+);
+
+-- CreateIndex
+CREATE UNIQUE INDEX "AuthToken_tokenHash_key" ON "AuthToken"("tokenHash");
+
+-- CreateIndex
+CREATE INDEX "AuthToken_accountType_accountId_purpose_idx" ON "AuthToken"("accountType", "accountId", "purpose");
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS "User_phone_key";
+DROP TABLE IF EXISTS "PhoneOTP";
@@ -0,0 +1,19 @@
+-- SMS login codes. Phone numbers become unique so a code identifies one user.
+
+-- CreateTable
+CREATE TABLE "PhoneOTP" (
+    "id" SERIAL NOT NULL,
+    "phone" TEXT NOT NULL,
+    "codeHash" TEXT NOT NULL,
+    "expiresAt" TIMESTAMP(3) NOT NULL,
+    "attempts" INTEGER NOT NULL DEFAULT 0,
+    "consumedAt" TIMESTAMP(3),
+    "createdAt" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT "PhoneOTP_pkey" PRIMARY KEY ("id")
+);
+
+-- CreateIndex
+CREATE INDEX "PhoneOTP_phone_createdAt_idx" ON "PhoneOTP"("phone", "createdAt");
+
+-- CreateIndex
+CREATE UNIQUE INDEX "User_phone_key" ON "User"("phone");
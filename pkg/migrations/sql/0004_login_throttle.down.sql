@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "LoginLockoutEvent";
+DROP TABLE IF EXISTS "LoginThrottle";
@@ -0,0 +1,34 @@
+-- Failed sign-in counters and the lockouts they cause
+
+-- CreateTable
+CREATE TABLE "LoginThrottle" (
+    "id" SERIAL NOT NULL,
+    "scope" TEXT NOT NULL,
+    "key" TEXT NOT NULL,
+    "failures" INTEGER NOT NULL DEFAULT 0,
+    "lastFailureAt" TIMESTAMP(3) NOT NULL,
+    "blockedUntil" TIMESTAMP(3),
+    "lockedUntil" TIMESTAMP(3),
+    "updatedAt" TIMESTAMP(3) NOT NULL,
+    CONSTRAINT "LoginThrottle_pkey" PRIMARY KEY ("id")
+);
+
+-- CreateTable
+CREATE TABLE "LoginLockoutEvent" (
+    "id" SERIAL NOT NULL,
+    "scope" TEXT NOT NULL,
+    "key" TEXT NOT NULL,
+    "ipAddress" TEXT NOT NULL,
+    "failures" INTEGER NOT NULL,
+    "lockedUntil" TIMESTAMP(3) NOT NULL,
+    "unlockedAt" TIMESTAMP(3),
+    "unlockedBy" TEXT,
+    "createdAt" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT "LoginLockoutEvent_pkey" PRIMARY KEY ("id")
+);
+
+-- CreateIndex
+CREATE UNIQUE INDEX "LoginThrottle_scope_key_key" ON "LoginThrottle"("scope", "key");
+
+-- CreateIndex
+CREATE INDEX "LoginLockoutEvent_scope_key_createdAt_idx" ON "LoginLockoutEvent"("scope", "key", "createdAt");
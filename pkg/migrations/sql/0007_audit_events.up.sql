@@ -0,0 +1,43 @@
+-- Append-only audit log. The trigger rejects updates and deletes so rows
+-- cannot be rewritten even with direct database access.
+
+-- CreateTable
+CREATE TABLE "AuditEvent" (
+    "id" SERIAL NOT NULL,
+    "actorId" INTEGER,
+    "actorRole" TEXT NOT NULL,
+    "actorEmail" TEXT NOT NULL,
+    "outletId" INTEGER,
+    "action" TEXT NOT NULL,
+    "entityType" TEXT NOT NULL,
+    "entityId" TEXT NOT NULL,
+    "before" JSONB,
+    "after" JSONB,
+    "ipAddress" TEXT NOT NULL,
+    "userAgent" TEXT NOT NULL,
+    "createdAt" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT "AuditEvent_pkey" PRIMARY KEY ("id")
+);
+
+-- CreateIndex
+CREATE INDEX "AuditEvent_createdAt_idx" ON "AuditEvent"("createdAt");
+
+-- CreateIndex
+CREATE INDEX "AuditEvent_outletId_createdAt_idx" ON "AuditEvent"("outletId", "createdAt");
+
+-- CreateIndex
+CREATE INDEX "AuditEvent_entityType_entityId_idx" ON "AuditEvent"("entityType", "entityId");
+
+-- CreateIndex
+CREATE INDEX "AuditEvent_actorRole_actorId_idx" ON "AuditEvent"("actorRole", "actorId");
+
+-- CreateFunction
+CREATE FUNCTION "AuditEvent_immutable"() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'AuditEvent is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+-- CreateTrigger
+CREATE TRIGGER "AuditEvent_immutable" BEFORE UPDATE OR DELETE ON "AuditEvent"
+    FOR EACH ROW EXECUTE FUNCTION "AuditEvent_immutable"();
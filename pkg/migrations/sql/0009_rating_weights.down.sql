@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS "Feedback_createdAt_idx";
+ALTER TABLE "Outlet" DROP COLUMN IF EXISTS "ratingWeightQuantity";
+ALTER TABLE "Outlet" DROP COLUMN IF EXISTS "ratingWeightQuality";
+ALTER TABLE "Outlet" DROP COLUMN IF EXISTS "ratingWeightTaste";
+ALTER TABLE "Outlet" DROP COLUMN IF EXISTS "ratingWeightOverall";
@@ -0,0 +1,12 @@
+-- Per-outlet weights of the four feedback ratings in a product's score.
+-- They sum to 1; the defaults are the weights used before.
+
+-- AlterTable
+ALTER TABLE "Outlet" ADD COLUMN "ratingWeightOverall" DOUBLE PRECISION NOT NULL DEFAULT 0.4;
+ALTER TABLE "Outlet" ADD COLUMN "ratingWeightTaste" DOUBLE PRECISION NOT NULL DEFAULT 0.3;
+ALTER TABLE "Outlet" ADD COLUMN "ratingWeightQuality" DOUBLE PRECISION NOT NULL DEFAULT 0.2;
+ALTER TABLE "Outlet" ADD COLUMN "ratingWeightQuantity" DOUBLE PRECISION NOT NULL DEFAULT 0.1;
+
+-- The nightly roll reads the last 30 days of feedback
+-- CreateIndex
+CREATE INDEX "Feedback_createdAt_idx" ON "Feedback"("createdAt");
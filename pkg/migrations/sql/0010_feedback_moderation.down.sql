@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS "Feedback_productId_status_createdAt_idx";
+ALTER TABLE "Feedback" DROP COLUMN IF EXISTS "repliedAt";
+ALTER TABLE "Feedback" DROP COLUMN IF EXISTS "reply";
+ALTER TABLE "Feedback" DROP COLUMN IF EXISTS "moderatedAt";
+ALTER TABLE "Feedback" DROP COLUMN IF EXISTS "moderationReason";
+ALTER TABLE "Feedback" DROP COLUMN IF EXISTS "status";
@@ -0,0 +1,13 @@
+-- Moderation of feedback comments and public replies to them. Existing
+-- feedback stays published. HIDDEN feedback is left out of reviews and
+-- product rating aggregates.
+
+-- AlterTable
+ALTER TABLE "Feedback" ADD COLUMN "status" TEXT NOT NULL DEFAULT 'APPROVED';
+ALTER TABLE "Feedback" ADD COLUMN "moderationReason" TEXT;
+ALTER TABLE "Feedback" ADD COLUMN "moderatedAt" TIMESTAMP(3);
+ALTER TABLE "Feedback" ADD COLUMN "reply" TEXT;
+ALTER TABLE "Feedback" ADD COLUMN "repliedAt" TIMESTAMP(3);
+
+-- CreateIndex
+CREATE INDEX "Feedback_productId_status_createdAt_idx" ON "Feedback"("productId", "status", "createdAt");
@@ -0,0 +1,8 @@
+ALTER TABLE "StaffDetails" DROP COLUMN IF EXISTS "isOnDuty";
+ALTER TABLE "Ticket" DROP CONSTRAINT IF EXISTS "Ticket_orderId_fkey";
+ALTER TABLE "Ticket" DROP CONSTRAINT IF EXISTS "Ticket_feedbackId_fkey";
+DROP INDEX IF EXISTS "Ticket_feedbackId_key";
+ALTER TABLE "Ticket" DROP COLUMN IF EXISTS "orderId";
+ALTER TABLE "Ticket" DROP COLUMN IF EXISTS "feedbackId";
+ALTER TABLE "Ticket" DROP COLUMN IF EXISTS "internal";
+DROP TABLE IF EXISTS "FeedbackAlertRule";
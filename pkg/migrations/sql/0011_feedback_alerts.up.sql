@@ -0,0 +1,45 @@
+-- Per-outlet rules that turn negative feedback into internal follow-up
+-- tickets. An outlet without rules uses the built-in low rating rule.
+
+-- CreateTable
+CREATE TABLE "FeedbackAlertRule" (
+    "id" SERIAL NOT NULL,
+    "outletId" INTEGER NOT NULL,
+    "name" TEXT NOT NULL,
+    "maxRating" DOUBLE PRECISION NOT NULL,
+    "categories" JSONB NOT NULL DEFAULT '[]',
+    "keywords" JSONB NOT NULL DEFAULT '[]',
+    "priority" TEXT NOT NULL DEFAULT 'HIGH',
+    "isActive" BOOLEAN NOT NULL DEFAULT true,
+    "createdAt" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updatedAt" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT "FeedbackAlertRule_pkey" PRIMARY KEY ("id")
+);
+
+-- CreateIndex
+CREATE INDEX "FeedbackAlertRule_outletId_idx" ON "FeedbackAlertRule"("outletId");
+
+-- AddForeignKey
+ALTER TABLE "FeedbackAlertRule" ADD CONSTRAINT "FeedbackAlertRule_outletId_fkey" FOREIGN KEY ("outletId") REFERENCES "Outlet"("id") ON DELETE CASCADE ON UPDATE CASCADE;
+
+-- Internal tickets are opened by the system about a customer's feedback and
+-- are not shown to the customer. Each feedback opens at most one.
+
+-- AlterTable
+ALTER TABLE "Ticket" ADD COLUMN "internal" BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE "Ticket" ADD COLUMN "feedbackId" INTEGER;
+ALTER TABLE "Ticket" ADD COLUMN "orderId" INTEGER;
+
+-- CreateIndex
+CREATE UNIQUE INDEX "Ticket_feedbackId_key" ON "Ticket"("feedbackId");
+
+-- AddForeignKey
+ALTER TABLE "Ticket" ADD CONSTRAINT "Ticket_feedbackId_fkey" FOREIGN KEY ("feedbackId") REFERENCES "Feedback"("id") ON DELETE SET NULL ON UPDATE CASCADE;
+
+-- AddForeignKey
+ALTER TABLE "Ticket" ADD CONSTRAINT "Ticket_orderId_fkey" FOREIGN KEY ("orderId") REFERENCES "Order"("id") ON DELETE SET NULL ON UPDATE CASCADE;
+
+-- Staff mark themselves on duty to receive alerts
+
+-- AlterTable
+ALTER TABLE "StaffDetails" ADD COLUMN "isOnDuty" BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1 @@
+DROP TRIGGER IF EXISTS "AuditEvent_immutable_truncate" ON "AuditEvent";
@@ -0,0 +1 @@
+-- Normalized phones are kept; the numbers as originally entered are gone.
@@ -0,0 +1,64 @@
+-- Rewrites User phones in E.164 as utils.NormalizePhone does, with its default
+-- country code 91. Numbers that do not normalize are left as entered. If two
+-- accounts end up with the same number the migration fails and lists them, so
+-- an operator can decide which account keeps it before running it again.
+--
+-- before: 0003
+-- 0003 creates the unique index, which has to hold for the normalized numbers,
+-- so databases that have not applied it yet run this first and report any
+-- conflict here. Where the index already exists it is dropped while phones are
+-- rewritten and created again afterwards.
+
+DO $$
+DECLARE
+    had_index BOOLEAN := to_regclass('"User_phone_key"') IS NOT NULL;
+    conflicts TEXT;
+BEGIN
+    IF had_index THEN
+        DROP INDEX "User_phone_key";
+    END IF;
+
+    UPDATE "User" SET "phone" = NULL WHERE btrim("phone", E' \t\r\n') = '';
+
+    WITH parsed AS (
+        SELECT "id",
+               btrim("phone", E' \t\r\n') ~ '^(\+|00)' AS international,
+               regexp_replace(regexp_replace(btrim("phone", E' \t\r\n'), '^(\+|00)', ''), '[ .()-]', '', 'g') AS digits
+        FROM "User"
+        WHERE "phone" IS NOT NULL
+    ), numbered AS (
+        SELECT "id",
+               CASE
+                   WHEN international THEN digits
+                   WHEN digits LIKE '0%' THEN '91' || ltrim(digits, '0')
+                   WHEN length(digits) > 10 AND digits LIKE '91%' THEN digits
+                   ELSE '91' || digits
+               END AS number
+        FROM parsed
+        WHERE digits ~ '^[0-9]+$'
+    )
+    UPDATE "User" u SET "phone" = '+' || n.number
+    FROM numbered n
+    WHERE u."id" = n."id"
+      AND length(n.number) BETWEEN 8 AND 15
+      AND n.number NOT LIKE '0%';
+
+    -- Only the IDs are reported so the numbers stay out of the logs
+    SELECT string_agg(ids, '; ' ORDER BY ids)
+    INTO conflicts
+    FROM (
+        SELECT string_agg("id"::text, ', ' ORDER BY "id") AS ids
+        FROM "User"
+        WHERE "phone" IS NOT NULL
+        GROUP BY "phone"
+        HAVING count(*) > 1
+    ) duplicates;
+
+    IF conflicts IS NOT NULL THEN
+        RAISE EXCEPTION 'Users share a phone number once normalized (one group per number): %. Change or clear the phone of all but one account for each number, then migrate again.', conflicts;
+    END IF;
+
+    IF had_index THEN
+        CREATE UNIQUE INDEX "User_phone_key" ON "User"("phone");
+    END IF;
+END $$;
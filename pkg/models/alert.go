@@ -0,0 +1,57 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// StringList is a list of strings stored as a JSONB array
+type StringList []string
+
+// Scan implements the sql.Scanner interface
+func (l *StringList) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*l = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, l)
+	case string:
+		return json.Unmarshal([]byte(v), l)
+	}
+	return fmt.Errorf("cannot scan %T into StringList", value)
+}
+
+// Value implements the driver.Valuer interface. A nil list is stored as an
+// empty array.
+func (l StringList) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal(l)
+	return string(b), err
+}
+
+// FeedbackAlertRule model - feedback on an outlet's products opens an
+// internal ticket when its overall rating is at most MaxRating, the product
+// is in one of Categories and the comment has one of Keywords. Empty lists
+// match anything.
+type FeedbackAlertRule struct {
+	ID         int        `gorm:"primaryKey;autoIncrement;column:id" json:"id"`
+	OutletID   int        `gorm:"not null;column:outletId" json:"outletId"`
+	Name       string     `gorm:"not null;column:name" json:"name"`
+	MaxRating  float64    `gorm:"not null;column:maxRating" json:"maxRating"`
+	Categories StringList `gorm:"type:jsonb;not null;column:categories" json:"categories"`
+	Keywords   StringList `gorm:"type:jsonb;not null;column:keywords" json:"keywords"`
+	Priority   Priority   `gorm:"type:text;default:'HIGH';column:priority" json:"priority"`
+	IsActive   bool       `gorm:"not null;column:isActive" json:"isActive"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime;column:createdAt" json:"createdAt"`
+	UpdatedAt  time.Time  `gorm:"autoUpdateTime;column:updatedAt" json:"updatedAt"`
+}
+
+// TableName specifies the table name for FeedbackAlertRule model
+func (FeedbackAlertRule) TableName() string {
+	return "FeedbackAlertRule"
+}
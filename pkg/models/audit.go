@@ -0,0 +1,44 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AuditEvent model - append-only record of who changed what. Before and After
+// hold only the fields that changed, as JSON objects.
+type AuditEvent struct {
+	ID         int       `gorm:"primaryKey;autoIncrement;column:id" json:"id"`
+	ActorID    *int      `gorm:"column:actorId" json:"actorId"`
+	ActorRole  string    `gorm:"not null;column:actorRole" json:"actorRole"`
+	ActorEmail string    `gorm:"column:actorEmail" json:"actorEmail"`
+	OutletID   *int      `gorm:"column:outletId" json:"outletId"`
+	Action     string    `gorm:"not null;column:action" json:"action"`
+	EntityType string    `gorm:"not null;column:entityType" json:"entityType"`
+	EntityID   string    `gorm:"column:entityId" json:"entityId"`
+	Before     *string   `gorm:"type:jsonb;column:before" json:"before"`
+	After      *string   `gorm:"type:jsonb;column:after" json:"after"`
+	IPAddress  string    `gorm:"column:ipAddress" json:"ipAddress"`
+	UserAgent  string    `gorm:"column:userAgent" json:"userAgent"`
+	CreatedAt  time.Time `gorm:"autoCreateTime;column:createdAt" json:"createdAt"`
+}
+
+// TableName specifies the table name for AuditEvent model
+func (AuditEvent) TableName() string {
+	return "AuditEvent"
+}
+
+// ErrAuditEventImmutable is returned when code tries to change an audit event
+var ErrAuditEventImmutable = errors.New("audit events are append-only")
+
+// BeforeUpdate keeps audit events append-only
+func (AuditEvent) BeforeUpdate(tx *gorm.DB) error {
+	return ErrAuditEventImmutable
+}
+
+// BeforeDelete keeps audit events append-only
+func (AuditEvent) BeforeDelete(tx *gorm.DB) error {
+	return ErrAuditEventImmutable
+}
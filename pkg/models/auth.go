@@ -0,0 +1,77 @@
+package models
+
+import (
+	"time"
+)
+
+// AuthToken model - single-use password reset and email verification tokens.
+// Only the SHA-256 hash of the token is stored; the raw value is emailed.
+type AuthToken struct {
+	ID          int              `gorm:"primaryKey;autoIncrement;column:id" json:"id"`
+	AccountType AuthAccountType  `gorm:"type:text;not null;column:accountType" json:"accountType"`
+	AccountID   int              `gorm:"not null;column:accountId" json:"accountId"`
+	Purpose     AuthTokenPurpose `gorm:"type:text;not null;column:purpose" json:"purpose"`
+	TokenHash   string           `gorm:"unique;not null;column:tokenHash" json:"-"`
+	ExpiresAt   time.Time        `gorm:"not null;column:expiresAt" json:"expiresAt"`
+	UsedAt      *time.Time       `gorm:"column:usedAt" json:"usedAt"`
+	CreatedAt   time.Time        `gorm:"autoCreateTime;column:createdAt" json:"createdAt"`
+}
+
+// TableName specifies the table name for AuthToken model
+func (AuthToken) TableName() string {
+	return "AuthToken"
+}
+
+// PhoneOTP model - one-time login codes sent by SMS. The code is stored as an
+// HMAC so a database leak does not expose live codes.
+type PhoneOTP struct {
+	ID         int        `gorm:"primaryKey;autoIncrement;column:id" json:"id"`
+	Phone      string     `gorm:"not null;column:phone" json:"phone"`
+	CodeHash   string     `gorm:"not null;column:codeHash" json:"-"`
+	ExpiresAt  time.Time  `gorm:"not null;column:expiresAt" json:"expiresAt"`
+	Attempts   int        `gorm:"default:0;column:attempts" json:"attempts"`
+	ConsumedAt *time.Time `gorm:"column:consumedAt" json:"consumedAt"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime;column:createdAt" json:"createdAt"`
+}
+
+// TableName specifies the table name for PhoneOTP model
+func (PhoneOTP) TableName() string {
+	return "PhoneOTP"
+}
+
+// LoginThrottle model - running count of failed sign-ins for one account or
+// one client IP. Key is "<accountType>:<email>" for accounts and the IP
+// address for IPs.
+type LoginThrottle struct {
+	ID            int                `gorm:"primaryKey;autoIncrement;column:id" json:"id"`
+	Scope         LoginThrottleScope `gorm:"type:text;not null;column:scope" json:"scope"`
+	Key           string             `gorm:"not null;column:key" json:"key"`
+	Failures      int                `gorm:"default:0;column:failures" json:"failures"`
+	LastFailureAt time.Time          `gorm:"not null;column:lastFailureAt" json:"lastFailureAt"`
+	BlockedUntil  *time.Time         `gorm:"column:blockedUntil" json:"blockedUntil"`
+	LockedUntil   *time.Time         `gorm:"column:lockedUntil" json:"lockedUntil"`
+	UpdatedAt     time.Time          `gorm:"autoUpdateTime;column:updatedAt" json:"updatedAt"`
+}
+
+// TableName specifies the table name for LoginThrottle model
+func (LoginThrottle) TableName() string {
+	return "LoginThrottle"
+}
+
+// LoginLockoutEvent model - one row each time an account or IP gets locked out
+type LoginLockoutEvent struct {
+	ID          int                `gorm:"primaryKey;autoIncrement;column:id" json:"id"`
+	Scope       LoginThrottleScope `gorm:"type:text;not null;column:scope" json:"scope"`
+	Key         string             `gorm:"not null;column:key" json:"key"`
+	IPAddress   string             `gorm:"column:ipAddress" json:"ipAddress"`
+	Failures    int                `gorm:"not null;column:failures" json:"failures"`
+	LockedUntil time.Time          `gorm:"not null;column:lockedUntil" json:"lockedUntil"`
+	UnlockedAt  *time.Time         `gorm:"column:unlockedAt" json:"unlockedAt"`
+	UnlockedBy  *string            `gorm:"column:unlockedBy" json:"unlockedBy"`
+	CreatedAt   time.Time          `gorm:"autoCreateTime;column:createdAt" json:"createdAt"`
+}
+
+// TableName specifies the table name for LoginLockoutEvent model
+func (LoginLockoutEvent) TableName() string {
+	return "LoginLockoutEvent"
+}
@@ -54,6 +54,15 @@ const (
 	AdminPermissionAdminManagement        AdminPermissionType = "ADMIN_MANAGEMENT"
 )
 
+// DefaultAdminPermissions are created, not granted, for each outlet of a newly
+// verified admin
+var DefaultAdminPermissions = []AdminPermissionType{
+	AdminPermissionOrderManagement, AdminPermissionStaffManagement, AdminPermissionInventoryManagement,
+	AdminPermissionExpenditureManagement, AdminPermissionWalletManagement, AdminPermissionCustomerManagement,
+	AdminPermissionTicketManagement, AdminPermissionNotificationsManagement, AdminPermissionProductManagement,
+	AdminPermissionAppManagement, AdminPermissionReportsAnalytics, AdminPermissionSettings, AdminPermissionOnboarding, AdminPermissionAdminManagement,
+}
+
 // PermissionType enum
 type PermissionType string
 
@@ -64,6 +73,11 @@ const (
 	PermissionTypeInventory       PermissionType = "INVENTORY"
 )
 
+// DefaultStaffPermissions are created, not granted, for newly verified staff
+var DefaultStaffPermissions = []PermissionType{
+	PermissionTypeBilling, PermissionTypeProductInsights, PermissionTypeReports, PermissionTypeInventory,
+}
+
 // PaymentMethod enum
 type PaymentMethod string
 
@@ -114,6 +128,17 @@ const (
 	TicketStatusClosed     TicketStatus = "CLOSED"
 )
 
+// FeedbackStatus enum - whether a feedback comment is published. PENDING
+// comments were held by the keyword filter; HIDDEN feedback was removed by
+// an admin and no longer counts in product ratings.
+type FeedbackStatus string
+
+const (
+	FeedbackStatusPending  FeedbackStatus = "PENDING"
+	FeedbackStatusApproved FeedbackStatus = "APPROVED"
+	FeedbackStatusHidden   FeedbackStatus = "HIDDEN"
+)
+
 // StockAction enum
 type StockAction string
 
@@ -164,3 +189,48 @@ const (
 	NotificationStatusFailed    NotificationStatus = "FAILED"
 	NotificationStatusDelivered NotificationStatus = "DELIVERED"
 )
+
+// AuthAccountType enum - which table an auth token or login attempt refers to
+type AuthAccountType string
+
+const (
+	AuthAccountTypeUser  AuthAccountType = "USER"
+	AuthAccountTypeAdmin AuthAccountType = "ADMIN"
+)
+
+// AuthTokenPurpose enum
+type AuthTokenPurpose string
+
+const (
+	AuthTokenPurposePasswordReset     AuthTokenPurpose = "PASSWORD_RESET"
+	AuthTokenPurposeEmailVerification AuthTokenPurpose = "EMAIL_VERIFICATION"
+)
+
+// LoginThrottleScope enum - what a failed sign-in counter is keyed by
+type LoginThrottleScope string
+
+const (
+	LoginThrottleScopeAccount LoginThrottleScope = "ACCOUNT"
+	LoginThrottleScopeIP      LoginThrottleScope = "IP"
+)
+
+// IdempotencyStatus enum
+type IdempotencyStatus string
+
+const (
+	IdempotencyStatusInProgress IdempotencyStatus = "IN_PROGRESS"
+	IdempotencyStatusCompleted  IdempotencyStatus = "COMPLETED"
+)
+
+// JobStatus enum - where a background job is in its life. FAILED jobs have
+// used up their attempts and wait for someone to retry them; SUPERSEDED jobs
+// failed while an identical job was already queued, which does their work.
+type JobStatus string
+
+const (
+	JobStatusPending    JobStatus = "PENDING"
+	JobStatusRunning    JobStatus = "RUNNING"
+	JobStatusSucceeded  JobStatus = "SUCCEEDED"
+	JobStatusFailed     JobStatus = "FAILED"
+	JobStatusSuperseded JobStatus = "SUPERSEDED"
+)
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+)
+
+// IdempotencyRecord model - the stored outcome of a request sent with an
+// Idempotency-Key header, replayed when the client retries
+type IdempotencyRecord struct {
+	ID           int               `gorm:"primaryKey;autoIncrement;column:id" json:"id"`
+	Scope        string            `gorm:"not null;column:scope" json:"scope"`
+	Key          string            `gorm:"not null;column:key" json:"key"`
+	Method       string            `gorm:"not null;column:method" json:"method"`
+	Path         string            `gorm:"not null;column:path" json:"path"`
+	RequestHash  string            `gorm:"not null;column:requestHash" json:"requestHash"`
+	Status       IdempotencyStatus `gorm:"type:text;not null;column:status" json:"status"`
+	ResponseCode *int              `gorm:"column:responseCode" json:"responseCode"`
+	ResponseType *string           `gorm:"column:responseType" json:"responseType"`
+	ResponseBody []byte            `gorm:"column:responseBody" json:"-"`
+	CreatedAt    time.Time         `gorm:"autoCreateTime;column:createdAt" json:"createdAt"`
+	ExpiresAt    time.Time         `gorm:"not null;column:expiresAt" json:"expiresAt"`
+}
+
+// TableName specifies the table name for IdempotencyRecord model
+func (IdempotencyRecord) TableName() string {
+	return "IdempotencyRecord"
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+)
+
+// Job model - a unit of background work. Payload is the JSON argument of the
+// handler registered for Kind.
+type Job struct {
+	ID          int        `gorm:"primaryKey;autoIncrement;column:id" json:"id"`
+	Kind        string     `gorm:"not null;column:kind" json:"kind"`
+	Payload     string     `gorm:"type:jsonb;not null;column:payload" json:"payload"`
+	Status      JobStatus  `gorm:"type:text;not null;column:status" json:"status"`
+	UniqueKey   *string    `gorm:"column:uniqueKey" json:"uniqueKey"`
+	Attempts    int        `gorm:"not null;column:attempts" json:"attempts"`
+	MaxAttempts int        `gorm:"not null;column:maxAttempts" json:"maxAttempts"`
+	RunAt       time.Time  `gorm:"not null;column:runAt" json:"runAt"`
+	LockedBy    *string    `gorm:"column:lockedBy" json:"lockedBy"`
+	LockedUntil *time.Time `gorm:"column:lockedUntil" json:"lockedUntil"`
+	LastError   *string    `gorm:"column:lastError" json:"lastError"`
+	FinishedAt  *time.Time `gorm:"column:finishedAt" json:"finishedAt"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime;column:createdAt" json:"createdAt"`
+	UpdatedAt   time.Time  `gorm:"autoUpdateTime;column:updatedAt" json:"updatedAt"`
+}
+
+// TableName specifies the table name for Job model
+func (Job) TableName() string {
+	return "Job"
+}
@@ -6,15 +6,21 @@ import (
 
 // Outlet model - mirrors Prisma Outlet model
 type Outlet struct {
-	ID         int       `gorm:"primaryKey;autoIncrement" json:"id"`
-	Name       string    `gorm:"unique;not null" json:"name"`
-	Address    *string   `json:"address"`
-	Email      *string   `gorm:"unique" json:"email"`
-	IsActive   bool      `gorm:"default:true" json:"isActive"`
-	CreatedAt  time.Time `gorm:"autoCreateTime" json:"createdAt"`
-	UpdatedAt  time.Time `gorm:"autoUpdateTime" json:"updatedAt"`
-	StaffCount int       `gorm:"default:0" json:"staffCount"`
-	Phone      *string   `json:"phone"`
+	ID         int       `gorm:"primaryKey;autoIncrement;column:id" json:"id"`
+	Name       string    `gorm:"unique;not null;column:name" json:"name"`
+	Address    *string   `gorm:"column:address" json:"address"`
+	Email      *string   `gorm:"unique;column:email" json:"email"`
+	IsActive   bool      `gorm:"default:true;column:isActive" json:"isActive"`
+	CreatedAt  time.Time `gorm:"autoCreateTime;column:createdAt" json:"createdAt"`
+	UpdatedAt  time.Time `gorm:"autoUpdateTime;column:updatedAt" json:"updatedAt"`
+	StaffCount int       `gorm:"default:0;column:staffCount" json:"staffCount"`
+	Phone      *string   `gorm:"column:phone" json:"phone"`
+
+	// Weights of the four feedback ratings in product scores; they sum to 1
+	RatingWeightOverall  float64 `gorm:"default:0.4;column:ratingWeightOverall" json:"ratingWeightOverall"`
+	RatingWeightTaste    float64 `gorm:"default:0.3;column:ratingWeightTaste" json:"ratingWeightTaste"`
+	RatingWeightQuality  float64 `gorm:"default:0.2;column:ratingWeightQuality" json:"ratingWeightQuality"`
+	RatingWeightQuantity float64 `gorm:"default:0.1;column:ratingWeightQuantity" json:"ratingWeightQuantity"`
 
 	// Relationships
 	Admins                 []AdminOutlet           `gorm:"foreignKey:OutletID" json:"admins,omitempty"`
@@ -45,7 +51,7 @@ type User struct {
 	Role       Role      `gorm:"type:text;default:'CUSTOMER';column:role" json:"role"`
 	OutletID   *int      `gorm:"column:outletId" json:"outletId"`
 	CreatedAt  time.Time `gorm:"autoCreateTime;column:createdAt" json:"createdAt"`
-	Phone      *string   `gorm:"column:phone" json:"phone"`
+	Phone      *string   `gorm:"unique;column:phone" json:"phone"` // E.164
 	GoogleID   *string   `gorm:"unique;column:googleId" json:"googleId"`
 	IsVerified bool      `gorm:"default:false;column:isVerified" json:"isVerified"`
 	ImageURL   *string   `gorm:"column:imageUrl" json:"imageUrl"`
@@ -98,6 +104,8 @@ type StaffDetails struct {
 	TwoFactorSecret      *string    `gorm:"column:twoFactorSecret" json:"-"` // Don't expose secret
 	AadharURL            *string    `gorm:"column:aadharUrl" json:"aadharUrl"`
 	PanURL               *string    `gorm:"column:panUrl" json:"panUrl"`
+	// IsOnDuty staff receive feedback alerts for their outlet
+	IsOnDuty bool `gorm:"default:false;column:isOnDuty" json:"isOnDuty"`
 
 	// Relationships
 	User        User              `gorm:"foreignKey:UserID;references:ID" json:"user,omitempty"`
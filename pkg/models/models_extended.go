@@ -155,8 +155,15 @@ type Ticket struct {
 	ResolutionNote *string      `gorm:"column:resolutionNote" json:"resolutionNote"`
 	ImageURL       *string      `gorm:"column:imageUrl" json:"imageUrl"`
 
+	// Internal tickets follow up on negative feedback and are hidden from
+	// the customer
+	Internal   bool `gorm:"default:false;column:internal" json:"internal"`
+	FeedbackID *int `gorm:"column:feedbackId" json:"feedbackId"`
+	OrderID    *int `gorm:"column:orderId" json:"orderId"`
+
 	// Relationships
 	Customer CustomerDetails `gorm:"foreignKey:CustomerID;references:ID" json:"customer,omitempty"`
+	Feedback *Feedback       `gorm:"foreignKey:FeedbackID;references:ID" json:"feedback,omitempty"`
 }
 
 // TableName specifies the table name for Ticket model
@@ -397,6 +404,13 @@ type Feedback struct {
 	Comment        *string   `gorm:"column:comment" json:"comment"`
 	CreatedAt      time.Time `gorm:"autoCreateTime;column:createdAt" json:"createdAt"`
 
+	// Moderation, and the outlet's public reply
+	Status           FeedbackStatus `gorm:"type:text;default:APPROVED;column:status" json:"status"`
+	ModerationReason *string        `gorm:"column:moderationReason" json:"moderationReason"`
+	ModeratedAt      *time.Time     `gorm:"column:moderatedAt" json:"moderatedAt"`
+	Reply            *string        `gorm:"column:reply" json:"reply"`
+	RepliedAt        *time.Time     `gorm:"column:repliedAt" json:"repliedAt"`
+
 	// Relationships
 	User    User    `gorm:"foreignKey:UserID;references:ID" json:"user,omitempty"`
 	Product Product `gorm:"foreignKey:ProductID;references:ID" json:"product,omitempty"`
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+)
+
+// RateLimitBucket model - token bucket state shared by all instances when the
+// Postgres rate limit store is enabled
+type RateLimitBucket struct {
+	Key       string    `gorm:"primaryKey;column:key" json:"key"`
+	Tokens    float64   `gorm:"not null;column:tokens" json:"tokens"`
+	UpdatedAt time.Time `gorm:"not null;column:updatedAt" json:"updatedAt"`
+}
+
+// TableName specifies the table name for RateLimitBucket model
+func (RateLimitBucket) TableName() string {
+	return "RateLimitBucket"
+}
@@ -0,0 +1,150 @@
+// Package moderation decides whether feedback comments are published and
+// records the changes admins and staff make to them.
+//
+// A comment that trips the keyword filter is held as PENDING until an admin
+// restores it. Hiding feedback takes it out of public reviews and out of
+// its product's rating aggregates, which are recomputed in the same
+// transaction; restoring puts it back.
+package moderation
+
+import (
+	"backend_pandhi/pkg/models"
+	"backend_pandhi/pkg/ratings"
+	"errors"
+	"strings"
+	"time"
+	"unicode"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Filter matches comments against a list of blocked words and phrases
+type Filter struct {
+	phrases [][]string
+}
+
+// NewFilter returns a filter for the given words and phrases
+func NewFilter(blocked []string) *Filter {
+	f := &Filter{}
+	for _, b := range blocked {
+		if words := tokenize(b); len(words) > 0 {
+			f.phrases = append(f.phrases, words)
+		}
+	}
+	return f
+}
+
+// Match returns the first blocked word or phrase in text. Case and
+// punctuation are ignored, and the last word of a phrase also matches
+// longer words it starts, so "damn" matches "Damned!".
+func (f *Filter) Match(text string) (string, bool) {
+	words := tokenize(text)
+	for _, phrase := range f.phrases {
+		for i := 0; i+len(phrase) <= len(words); i++ {
+			if matches(words[i:i+len(phrase)], phrase) {
+				return strings.Join(phrase, " "), true
+			}
+		}
+	}
+	return "", false
+}
+
+func matches(words, phrase []string) bool {
+	last := len(phrase) - 1
+	for i, w := range phrase[:last] {
+		if words[i] != w {
+			return false
+		}
+	}
+	return strings.HasPrefix(words[last], phrase[last])
+}
+
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Screen returns the status a new feedback is stored with, and the reason
+// when it is held for review
+func (f *Filter) Screen(comment *string) (models.FeedbackStatus, *string) {
+	if comment == nil {
+		return models.FeedbackStatusApproved, nil
+	}
+	if word, ok := f.Match(*comment); ok {
+		reason := `Held by the keyword filter for "` + word + `"`
+		return models.FeedbackStatusPending, &reason
+	}
+	return models.FeedbackStatusApproved, nil
+}
+
+var (
+	// ErrNotFound means no feedback has the ID
+	ErrNotFound = errors.New("feedback not found")
+
+	// ErrUnchanged means the feedback already has the requested status
+	ErrUnchanged = errors.New("feedback already has this status")
+)
+
+// Hide takes feedback out of public reviews and rating aggregates. It
+// returns the feedback before the change, with its product loaded.
+func Hide(tx *gorm.DB, id int, reason string, now time.Time) (models.Feedback, error) {
+	return moderate(tx, id, models.FeedbackStatusHidden, reason, now)
+}
+
+// Restore publishes hidden or held feedback and counts it in rating
+// aggregates again. It returns the feedback before the change, with its
+// product loaded.
+func Restore(tx *gorm.DB, id int, reason string, now time.Time) (models.Feedback, error) {
+	return moderate(tx, id, models.FeedbackStatusApproved, reason, now)
+}
+
+func moderate(tx *gorm.DB, id int, status models.FeedbackStatus, reason string, now time.Time) (models.Feedback, error) {
+	f, err := Find(tx, id)
+	if err != nil {
+		return f, err
+	}
+	if f.Status == status {
+		return f, ErrUnchanged
+	}
+
+	if err := tx.Model(&models.Feedback{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":           status,
+		"moderationReason": reason,
+		"moderatedAt":      now,
+	}).Error; err != nil {
+		return f, err
+	}
+
+	// Only hiding or unhiding changes which feedback the ratings count
+	if f.Status == models.FeedbackStatusHidden || status == models.FeedbackStatusHidden {
+		if _, err := ratings.Recompute(tx, now, f.ProductID); err != nil {
+			return f, err
+		}
+	}
+	return f, nil
+}
+
+// Reply sets the outlet's public reply to feedback found with Find,
+// replacing an earlier one
+func Reply(tx *gorm.DB, f models.Feedback, text string, now time.Time) error {
+	return tx.Model(&models.Feedback{}).Where("id = ?", f.ID).Updates(map[string]interface{}{
+		"reply":     text,
+		"repliedAt": now,
+	}).Error
+}
+
+// Find locks feedback for a change and loads its product, which tells whose
+// outlet it belongs to
+func Find(tx *gorm.DB, id int) (models.Feedback, error) {
+	var f models.Feedback
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&f, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return f, ErrNotFound
+		}
+		return f, err
+	}
+	err := tx.First(&f.Product, f.ProductID).Error
+	return f, err
+}
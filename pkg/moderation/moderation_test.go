@@ -0,0 +1,91 @@
+package moderation_test
+
+import (
+	"backend_pandhi/pkg/models"
+	"backend_pandhi/pkg/moderation"
+	"backend_pandhi/pkg/ratings"
+	"backend_pandhi/pkg/testenv"
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(testenv.Main(m))
+}
+
+func TestFilterMatch(t *testing.T) {
+	filter := moderation.NewFilter([]string{"damn", "Rotten Egg", " "})
+
+	for _, tc := range []struct {
+		text  string
+		match string
+	}{
+		{"Tasty and hot", ""},
+		{"DAMN good dosa", "damn"},
+		{"damned cold, again!", "damn"},
+		{"smelled like a rotten-eggs factory", "rotten egg"},
+		{"the egg was rotten", ""},
+		{"Amsterdam", ""},
+	} {
+		match, ok := filter.Match(tc.text)
+		if match != tc.match || ok != (tc.match != "") {
+			t.Errorf("Match(%q) = %q, %v, want %q", tc.text, match, ok, tc.match)
+		}
+	}
+}
+
+func TestHideAndRestoreUpdateRatings(t *testing.T) {
+	env := testenv.New(t)
+	now := time.Now()
+	outlet := env.Outlet(t)
+	customer := env.Customer(t, outlet, 0)
+	product := env.Product(t, outlet, 50, 10)
+
+	order := models.Order{
+		CustomerID:    &customer.ID,
+		OutletID:      outlet.ID,
+		TotalAmount:   50,
+		PaymentMethod: models.PaymentMethodWallet,
+		Status:        "DELIVERED",
+		Type:          models.OrderTypeApp,
+	}
+	if err := env.DB.Create(&order).Error; err != nil {
+		t.Fatal(err)
+	}
+	feedback := models.Feedback{
+		UserID: customer.ID, OrderID: order.ID, ProductID: product.ID,
+		RatingOverall: 1, RatingTaste: 1, RatingQuality: 1, RatingQuantity: 1,
+	}
+	if err := env.DB.Create(&feedback).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := ratings.Add(env.DB, feedback); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := moderation.Hide(env.DB, feedback.ID, "Spam", now); err != nil {
+		t.Fatalf("Hide: %v", err)
+	}
+	env.Reload(t, &product)
+	env.Reload(t, &feedback)
+	if feedback.Status != models.FeedbackStatusHidden || product.RatingCountLifetime != 0 || product.RatingCount30d != 0 {
+		t.Errorf("after Hide: feedback %s, product counted %d/%d", feedback.Status, product.RatingCountLifetime, product.RatingCount30d)
+	}
+	if _, err := moderation.Hide(env.DB, feedback.ID, "Spam", now); !errors.Is(err, moderation.ErrUnchanged) {
+		t.Errorf("second Hide = %v, want ErrUnchanged", err)
+	}
+
+	if _, err := moderation.Restore(env.DB, feedback.ID, "Genuine", now); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	env.Reload(t, &product)
+	if product.RatingCountLifetime != 1 || product.RatingCount30d != 1 {
+		t.Errorf("after Restore: product counted %d/%d, want 1/1", product.RatingCountLifetime, product.RatingCount30d)
+	}
+
+	if _, err := moderation.Hide(env.DB, feedback.ID+1000, "Spam", now); !errors.Is(err, moderation.ErrNotFound) {
+		t.Errorf("Hide of unknown feedback = %v, want ErrNotFound", err)
+	}
+}
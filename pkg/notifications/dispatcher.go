@@ -0,0 +1,175 @@
+// Package notifications sends scheduled push notifications when they fall due.
+package notifications
+
+import (
+	"backend_pandhi/pkg/app"
+	"backend_pandhi/pkg/models"
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// batchSize caps how many due notifications one pass claims
+const batchSize = 10
+
+// Dispatcher periodically sends due ScheduledNotifications. It records a
+// heartbeat after every pass, which the readiness check reads.
+type Dispatcher struct {
+	App      *app.App
+	Interval time.Duration
+
+	lastBeat atomic.Int64
+}
+
+// Default is the dispatcher started by the server
+var Default = &Dispatcher{Interval: 30 * time.Second}
+
+// Start runs Default for a in the background until ctx is cancelled, every
+// NOTIFICATION_DISPATCH_INTERVAL_SECONDS
+func Start(ctx context.Context, a *app.App) {
+	Default.App = a
+	Default.Interval = a.Config.NotificationInterval
+	go Default.Run(ctx)
+}
+
+// Run dispatches due notifications every Interval until ctx is cancelled
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.Interval)
+	defer ticker.Stop()
+
+	for {
+		d.tick(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *Dispatcher) tick(ctx context.Context) {
+	// Without FCM every delivery would fail and the notifications would be
+	// used up, so leave them for a later pass
+	if d.App.Push.Ready() {
+		if n, err := DispatchDue(ctx, d.App); err != nil {
+			slog.ErrorContext(ctx, "Failed to dispatch scheduled notifications", "error", err)
+		} else if n > 0 {
+			slog.InfoContext(ctx, "Dispatched scheduled notifications", "count", n)
+		}
+	}
+	d.lastBeat.Store(d.App.Clock.Now().UnixNano())
+}
+
+// LastHeartbeat returns when the dispatcher last completed a pass, or the
+// zero time if it has not run
+func (d *Dispatcher) LastHeartbeat() time.Time {
+	ns := d.lastBeat.Load()
+	if ns == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, ns)
+}
+
+// Healthy reports whether a pass completed within the last three intervals
+func (d *Dispatcher) Healthy(now time.Time) bool {
+	last := d.LastHeartbeat()
+	return !last.IsZero() && now.Sub(last) <= 3*d.Interval
+}
+
+// DispatchDue sends every unsent notification whose time has come and returns
+// how many were sent. Notifications are claimed with SKIP LOCKED and marked
+// sent before delivery, so several servers can run the dispatcher and a
+// notification goes out at most once.
+func DispatchDue(ctx context.Context, a *app.App) (int, error) {
+	sent := 0
+	for {
+		var due []models.ScheduledNotification
+		err := a.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+				Where(`"isSent" = ? AND "scheduledAt" <= ?`, false, a.Clock.Now()).
+				Order(`"scheduledAt"`).
+				Limit(batchSize).
+				Find(&due).Error; err != nil {
+				return err
+			}
+			if len(due) == 0 {
+				return nil
+			}
+
+			ids := make([]int, len(due))
+			for i, n := range due {
+				ids[i] = n.ID
+			}
+			return tx.Model(&models.ScheduledNotification{}).
+				Where("id IN ?", ids).
+				Updates(map[string]interface{}{"isSent": true, "sentAt": a.Clock.Now()}).Error
+		})
+		if err != nil {
+			return sent, fmt.Errorf("failed to claim due notifications: %w", err)
+		}
+
+		for _, n := range due {
+			if err := deliver(ctx, a, n); err != nil {
+				slog.ErrorContext(ctx, "Failed to deliver scheduled notification", "notification_id", n.ID, "error", err)
+				continue
+			}
+			sent++
+		}
+
+		if len(due) < batchSize {
+			return sent, nil
+		}
+	}
+}
+
+// deliver pushes n to every active device of the outlet's customers and
+// records a NotificationDelivery per device
+func deliver(ctx context.Context, a *app.App, n models.ScheduledNotification) error {
+	var devices []models.UserDeviceToken
+	if err := a.DB.WithContext(ctx).
+		Joins(`JOIN "User" ON "User".id = "UserDeviceToken"."userId"`).
+		Where(`"User"."outletId" = ? AND "User".role = ? AND "UserDeviceToken"."isActive" = ?`,
+			n.OutletID, models.RoleCustomer, true).
+		Find(&devices).Error; err != nil {
+		return err
+	}
+
+	data := map[string]string{
+		"outletId":       strconv.Itoa(n.OutletID),
+		"notificationId": strconv.Itoa(n.ID),
+		"type":           "scheduled",
+	}
+
+	for _, device := range devices {
+		delivery := models.NotificationDelivery{
+			ScheduledNotificationID: n.ID,
+			UserID:                  device.UserID,
+			DeviceToken:             device.DeviceToken,
+			Status:                  models.NotificationStatusPending,
+		}
+
+		messageID, err := a.Push.Send(ctx, device.DeviceToken, n.Title, n.Message, data)
+		if err != nil {
+			reason := err.Error()
+			delivery.Status = models.NotificationStatusFailed
+			delivery.FailureReason = &reason
+		} else {
+			now := a.Clock.Now()
+			delivery.Status = models.NotificationStatusSent
+			delivery.SentAt = &now
+			delivery.MessageID = &messageID
+		}
+
+		if err := a.DB.WithContext(ctx).Create(&delivery).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
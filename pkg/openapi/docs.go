@@ -0,0 +1,21 @@
+package openapi
+
+import (
+	_ "embed"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+//go:embed docs.html
+var docsPage string
+
+// DocsHandler serves a self-contained reference page that renders the
+// document at specURL. It has no external assets so it works offline.
+func DocsHandler(specURL string) gin.HandlerFunc {
+	page := []byte(strings.Replace(docsPage, "{{SPEC_URL}}", specURL, 1))
+	return func(c *gin.Context) {
+		c.Data(http.StatusOK, "text/html; charset=utf-8", page)
+	}
+}
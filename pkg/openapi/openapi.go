@@ -0,0 +1,435 @@
+// Package openapi generates the OpenAPI 3 document for the HTTP API.
+//
+// Every route is described by an Operation naming the typed DTOs it reads and
+// writes. Schemas are generated from those Go types by reflection, following
+// the same json, form and binding tags gin uses, so the document cannot
+// disagree with the structs the handlers bind:
+//
+//	reg := openapi.New("UPS API", "...")
+//	openapi.Enum(reg, models.OrderStatusPending, models.OrderStatusDelivered)
+//	reg.Add(openapi.Operation{
+//		Method:   http.MethodPost,
+//		Path:     "/api/auth/signin",
+//		Summary:  "Sign in as a customer",
+//		Tag:      "Auth",
+//		Body:     dto.SignInRequest{},
+//		Response: dto.CustomerLoginResponse{},
+//	})
+//
+// Paths use gin's route template syntax and are converted when the document
+// is built.
+package openapi
+
+import (
+	"backend_pandhi/pkg/apperror"
+	"backend_pandhi/pkg/pagination"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Version is the API version published in the document. Bump the minor
+// version for additive changes and the major version for breaking ones.
+const Version = "1.0.0"
+
+// Operation describes one route
+type Operation struct {
+	Method      string
+	Path        string // gin route template, e.g. /api/customer/outlets/tickets/:ticketId
+	Summary     string
+	Description string
+	Tag         string
+
+	// Roles that may call the route; nil for public routes
+	Roles []string
+
+	Query interface{}      // struct whose form-tagged fields are query parameters
+	List  *pagination.Spec // list endpoints document limit, cursor, sort and filters
+	Body  interface{}      // request body
+	Form  bool             // Body is sent as multipart/form-data instead of JSON
+
+	Response    interface{} // success response body
+	Status      int         // success status, defaults to 200
+	ContentType string      // success content type, defaults to application/json
+
+	// Idempotent routes accept an Idempotency-Key header
+	Idempotent bool
+}
+
+func (op Operation) key() string {
+	return op.Method + " " + op.Path
+}
+
+// Registry holds the operations and enum values that make up the document
+type Registry struct {
+	title       string
+	description string
+	operations  []Operation
+	index       map[string]int
+	enums       map[reflect.Type][]string
+
+	once     sync.Once
+	document []byte
+	err      error
+}
+
+// New creates an empty Registry
+func New(title, description string) *Registry {
+	return &Registry{
+		title:       title,
+		description: description,
+		index:       make(map[string]int),
+		enums:       make(map[reflect.Type][]string),
+	}
+}
+
+// Add registers operations. Describing the same method and path twice is a
+// programming error and panics.
+func (r *Registry) Add(ops ...Operation) {
+	for _, op := range ops {
+		if _, ok := r.index[op.key()]; ok {
+			panic(fmt.Sprintf("openapi: duplicate operation %s", op.key()))
+		}
+		r.index[op.key()] = len(r.operations)
+		r.operations = append(r.operations, op)
+	}
+}
+
+// Lookup returns the operation registered for a gin method and route template
+func (r *Registry) Lookup(method, path string) (Operation, bool) {
+	i, ok := r.index[method+" "+path]
+	if !ok {
+		return Operation{}, false
+	}
+	return r.operations[i], true
+}
+
+// Operations returns the registered operations in registration order
+func (r *Registry) Operations() []Operation {
+	return slices.Clone(r.operations)
+}
+
+// Enum documents the allowed values of a string enum type wherever it appears
+func Enum[T ~string](r *Registry, values ...T) {
+	names := make([]string, len(values))
+	for i, v := range values {
+		names[i] = string(v)
+	}
+	r.enums[reflect.TypeOf(values).Elem()] = names
+}
+
+// Document builds the OpenAPI document
+func (r *Registry) Document() map[string]interface{} {
+	g := newGenerator(r.enums)
+
+	paths := make(map[string]map[string]interface{})
+	var tags []map[string]interface{}
+	seenTags := make(map[string]bool)
+
+	for _, op := range r.operations {
+		path := openAPIPath(op.Path)
+		if paths[path] == nil {
+			paths[path] = make(map[string]interface{})
+		}
+		paths[path][strings.ToLower(op.Method)] = r.operation(g, op)
+
+		if op.Tag != "" && !seenTags[op.Tag] {
+			seenTags[op.Tag] = true
+			tags = append(tags, map[string]interface{}{"name": op.Tag})
+		}
+	}
+
+	errorSchema := g.schema(reflect.TypeOf(errorEnvelope{}))
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       r.title,
+			"description": r.description,
+			"version":     Version,
+		},
+		"servers": []map[string]interface{}{{"url": "/"}},
+		"tags":    tags,
+		"paths":   paths,
+		"components": map[string]interface{}{
+			"schemas": g.components,
+			"securitySchemes": map[string]interface{}{
+				"bearerAuth": map[string]interface{}{
+					"type":         "http",
+					"scheme":       "bearer",
+					"bearerFormat": "JWT",
+					"description":  "JWT from a sign-in endpoint, sent as a Bearer token or the token cookie",
+				},
+			},
+			"responses": map[string]interface{}{
+				"Error": map[string]interface{}{
+					"description": "Error envelope returned by every endpoint",
+					"content": map[string]interface{}{
+						"application/json": map[string]interface{}{"schema": errorSchema},
+					},
+				},
+			},
+		},
+	}
+}
+
+// errorEnvelope mirrors what apperror.Render writes
+type errorEnvelope struct {
+	Success bool                  `json:"success"`
+	Code    string                `json:"code" binding:"required,oneof=BAD_REQUEST VALIDATION_FAILED UNAUTHORIZED FORBIDDEN NOT_FOUND CONFLICT UNPROCESSABLE TOO_MANY_REQUESTS INTERNAL UPSTREAM_FAILED UNAVAILABLE"`
+	Message string                `json:"message" binding:"required"`
+	Fields  []apperror.FieldError `json:"fields,omitempty" doc:"Present for VALIDATION_FAILED"`
+}
+
+func (r *Registry) operation(g *generator, op Operation) map[string]interface{} {
+	out := map[string]interface{}{
+		"operationId": operationID(op),
+		"summary":     op.Summary,
+	}
+	if op.Description != "" {
+		out["description"] = op.Description
+	}
+	if op.Tag != "" {
+		out["tags"] = []string{op.Tag}
+	}
+	if len(op.Roles) > 0 {
+		out["security"] = []map[string][]string{{"bearerAuth": {}}}
+		out["x-roles"] = op.Roles
+	}
+
+	params := pathParameters(op.Path)
+	if op.Query != nil {
+		params = append(params, queryParameters(g, reflect.TypeOf(op.Query))...)
+	}
+	if op.List != nil {
+		params = append(params, listParameters(*op.List)...)
+	}
+	if op.Idempotent {
+		params = append(params, map[string]interface{}{
+			"name":        "Idempotency-Key",
+			"in":          "header",
+			"description": "Client-generated key; retries with the same key replay the first response",
+			"schema":      Schema{"type": "string", "maxLength": 255},
+		})
+	}
+	if len(params) > 0 {
+		out["parameters"] = params
+	}
+
+	if op.Body != nil {
+		contentType := "application/json"
+		if op.Form {
+			contentType = "multipart/form-data"
+		}
+		out["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content": map[string]interface{}{
+				contentType: map[string]interface{}{"schema": g.schemaOf(op.Body)},
+			},
+		}
+	}
+
+	status := op.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	contentType := op.ContentType
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	success := map[string]interface{}{"description": http.StatusText(status)}
+	if op.Response != nil {
+		success["content"] = map[string]interface{}{
+			contentType: map[string]interface{}{"schema": g.schemaOf(op.Response)},
+		}
+	} else if contentType != "application/json" {
+		success["content"] = map[string]interface{}{
+			contentType: map[string]interface{}{"schema": Schema{"type": "string"}},
+		}
+	}
+	out["responses"] = map[string]interface{}{
+		fmt.Sprint(status): success,
+		"default":          map[string]interface{}{"$ref": "#/components/responses/Error"},
+	}
+	return out
+}
+
+// openAPIPath converts gin's :name and *name segments to {name}
+func openAPIPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		if strings.HasPrefix(s, ":") || strings.HasPrefix(s, "*") {
+			segments[i] = "{" + s[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+func operationID(op Operation) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(op.Method))
+	for _, part := range strings.FieldsFunc(op.Path, func(r rune) bool {
+		return r == '/' || r == '-' || r == ':' || r == '*' || r == '_'
+	}) {
+		b.WriteString(exportName(part))
+	}
+	return b.String()
+}
+
+// pathParameters documents each :name segment. Ids are integers; anything
+// else is a string.
+func pathParameters(path string) []map[string]interface{} {
+	var params []map[string]interface{}
+	for _, s := range strings.Split(path, "/") {
+		if !strings.HasPrefix(s, ":") && !strings.HasPrefix(s, "*") {
+			continue
+		}
+		name := s[1:]
+		schema := Schema{"type": "string"}
+		if name == "id" || strings.HasSuffix(name, "Id") {
+			schema = Schema{"type": "integer", "format": "int32"}
+		}
+		params = append(params, map[string]interface{}{
+			"name":     name,
+			"in":       "path",
+			"required": true,
+			"schema":   schema,
+		})
+	}
+	return params
+}
+
+func queryParameters(g *generator, t reflect.Type) []map[string]interface{} {
+	var params []map[string]interface{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("form"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		schema := g.schema(f.Type)
+		param := map[string]interface{}{
+			"name":     name,
+			"in":       "query",
+			"required": applyBinding(schema, f),
+			"schema":   schema,
+		}
+		if doc := f.Tag.Get("doc"); doc != "" {
+			param["description"] = doc
+		}
+		params = append(params, param)
+	}
+	return params
+}
+
+// listParameters documents the query parameters pagination.Parse accepts
+func listParameters(spec pagination.Spec) []map[string]interface{} {
+	spec = spec.WithDefaults()
+
+	sorts := make([]string, 0, 2*len(spec.Sorts))
+	for name := range spec.Sorts {
+		sorts = append(sorts, name, "-"+name)
+	}
+	slices.Sort(sorts)
+
+	params := []map[string]interface{}{
+		{
+			"name":        "limit",
+			"in":          "query",
+			"description": fmt.Sprintf("Page size, capped at %d", spec.MaxLimit),
+			"schema":      Schema{"type": "integer", "minimum": 1, "default": spec.DefaultLimit},
+		},
+		{
+			"name":        "cursor",
+			"in":          "query",
+			"description": "pagination.nextCursor from the previous page",
+			"schema":      Schema{"type": "string"},
+		},
+		{
+			"name":        "sort",
+			"in":          "query",
+			"description": "Sort key; a leading - sorts descending",
+			"schema":      Schema{"type": "string", "enum": sorts, "default": spec.DefaultSort},
+		},
+	}
+
+	names := make([]string, 0, len(spec.Filters))
+	for name := range spec.Filters {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		filter := spec.Filters[name]
+		defaultOp := filter.DefaultOp
+		if defaultOp == "" {
+			defaultOp = pagination.OpEq
+		}
+		ops := filter.Ops
+		if len(ops) == 0 {
+			ops = []pagination.Op{defaultOp}
+		}
+
+		for _, op := range ops {
+			param := name + "[" + string(op) + "]"
+			description := fmt.Sprintf("Filter %s by %s", name, op)
+			if op == defaultOp {
+				param = name
+				if op != pagination.OpEq {
+					description = fmt.Sprintf("Filter %s by %s (%s[%s] also accepted)", name, op, name, op)
+				}
+			}
+			params = append(params, map[string]interface{}{
+				"name":        param,
+				"in":          "query",
+				"description": description,
+				"schema":      filterSchema(filter, op),
+			})
+		}
+	}
+	return params
+}
+
+func filterSchema(filter pagination.Filter, op pagination.Op) Schema {
+	if op == pagination.OpIn || op == pagination.OpContains {
+		return Schema{"type": "string"}
+	}
+	var s Schema
+	switch filter.Type {
+	case pagination.TypeInt:
+		s = Schema{"type": "integer"}
+	case pagination.TypeFloat:
+		s = Schema{"type": "number"}
+	case pagination.TypeBool:
+		s = Schema{"type": "boolean"}
+	case pagination.TypeTime:
+		s = Schema{"type": "string", "description": "RFC 3339 timestamp or YYYY-MM-DD date"}
+	default:
+		s = Schema{"type": "string"}
+	}
+	if len(filter.Values) > 0 {
+		s["enum"] = filter.Values
+	}
+	return s
+}
+
+// Handler serves the document as JSON. It is built on the first request and
+// cached; operations added afterwards are not picked up.
+func (r *Registry) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r.once.Do(func() {
+			r.document, r.err = json.Marshal(r.Document())
+		})
+		if r.err != nil {
+			apperror.Abort(c, apperror.Internal("Failed to build API specification", r.err))
+			return
+		}
+		c.Data(http.StatusOK, "application/json; charset=utf-8", r.document)
+	}
+}
@@ -0,0 +1,273 @@
+package openapi
+
+import (
+	"encoding/json"
+	"mime/multipart"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Schema is a JSON Schema object as used by OpenAPI 3.0
+type Schema map[string]interface{}
+
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	rawJSONType = reflect.TypeOf(json.RawMessage{})
+	fileType    = reflect.TypeOf(multipart.FileHeader{})
+)
+
+// generator turns Go types into schemas. Named struct types become shared
+// components referenced by $ref; everything else is inlined.
+type generator struct {
+	enums      map[reflect.Type][]string
+	components map[string]Schema
+	names      map[reflect.Type]string
+	taken      map[string]reflect.Type
+}
+
+func newGenerator(enums map[reflect.Type][]string) *generator {
+	return &generator{
+		enums:      enums,
+		components: make(map[string]Schema),
+		names:      make(map[reflect.Type]string),
+		taken:      make(map[string]reflect.Type),
+	}
+}
+
+// schemaOf returns the schema for the type of v, or nil when v is nil
+func (g *generator) schemaOf(v interface{}) Schema {
+	if v == nil {
+		return nil
+	}
+	return g.schema(reflect.TypeOf(v))
+}
+
+func (g *generator) schema(t reflect.Type) Schema {
+	if t.Kind() == reflect.Pointer {
+		return nullable(g.schema(t.Elem()))
+	}
+
+	if values, ok := g.enums[t]; ok {
+		return Schema{"type": "string", "enum": values}
+	}
+
+	switch t {
+	case timeType:
+		return Schema{"type": "string", "format": "date-time"}
+	case rawJSONType:
+		return Schema{}
+	case fileType:
+		return Schema{"type": "string", "format": "binary"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return Schema{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return Schema{"type": "integer", "format": "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return Schema{"type": "integer", "format": "int64"}
+	case reflect.Float32:
+		return Schema{"type": "number", "format": "float"}
+	case reflect.Float64:
+		return Schema{"type": "number", "format": "double"}
+	case reflect.String:
+		return Schema{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return Schema{"type": "string", "format": "byte"}
+		}
+		return Schema{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Map:
+		return Schema{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case reflect.Interface:
+		return Schema{}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		return g.ref(t)
+	}
+	return Schema{}
+}
+
+// ref registers t as a component on first use and returns a reference to it.
+// The placeholder breaks cycles between models that refer to each other.
+func (g *generator) ref(t reflect.Type) Schema {
+	name, ok := g.names[t]
+	if !ok {
+		name = g.componentName(t)
+		g.names[t] = name
+		g.components[name] = Schema{}
+		g.components[name] = g.object(t)
+	}
+	return Schema{"$ref": "#/components/schemas/" + name}
+}
+
+// componentName is the type name, qualified by its package when two packages
+// declare types with the same name
+func (g *generator) componentName(t reflect.Type) string {
+	name := exportName(t.Name())
+	if i := strings.Index(name, "["); i >= 0 {
+		name = name[:i]
+	}
+	if other, ok := g.taken[name]; ok && other != t {
+		pkg := t.PkgPath()
+		pkg = pkg[strings.LastIndex(pkg, "/")+1:]
+		name = exportName(pkg) + name
+	}
+	g.taken[name] = t
+	return name
+}
+
+func (g *generator) object(t reflect.Type) Schema {
+	properties := make(map[string]interface{})
+	var required []string
+	g.fields(t, properties, &required)
+
+	s := Schema{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
+
+// fields collects properties following encoding/json's rules: unexported and
+// "-" fields are skipped and untagged embedded structs are flattened
+func (g *generator) fields(t reflect.Type, properties map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				g.fields(ft, properties, required)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		s := g.schema(f.Type)
+		if strings.Contains(opts, "string") {
+			s = Schema{"type": "string"}
+		}
+		if isRequired := applyBinding(s, f); isRequired {
+			*required = append(*required, name)
+		}
+		if doc := f.Tag.Get("doc"); doc != "" {
+			s = describe(s, doc)
+		}
+		properties[name] = s
+	}
+}
+
+// applyBinding copies the validator constraints clients need to know about
+// from a binding tag and reports whether the field is required
+func applyBinding(s Schema, f reflect.StructField) bool {
+	required := false
+	target := s
+	if _, ok := s["$ref"]; ok {
+		target = Schema{}
+	}
+	if _, ok := s["allOf"]; ok {
+		target = Schema{}
+	}
+	kind := f.Type.Kind()
+	if kind == reflect.Pointer {
+		kind = f.Type.Elem().Kind()
+	}
+
+	for _, rule := range strings.Split(f.Tag.Get("binding"), ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "dive":
+			return required
+		case "required":
+			required = true
+		case "email":
+			target["format"] = "email"
+		case "url":
+			target["format"] = "uri"
+		case "oneof":
+			target["enum"] = strings.Fields(param)
+		case "min", "gte":
+			setBound(target, kind, "min", param)
+		case "max", "lte":
+			setBound(target, kind, "max", param)
+		case "gt":
+			setBound(target, kind, "min", param)
+			target["exclusiveMinimum"] = true
+		case "lt":
+			setBound(target, kind, "max", param)
+			target["exclusiveMaximum"] = true
+		}
+	}
+	return required
+}
+
+func setBound(s Schema, kind reflect.Kind, bound, param string) {
+	n, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return
+	}
+	switch kind {
+	case reflect.String:
+		s[bound+"Length"] = int(n)
+	case reflect.Slice, reflect.Array:
+		s[bound+"Items"] = int(n)
+	case reflect.Map:
+		s[bound+"Properties"] = int(n)
+	default:
+		if bound == "min" {
+			s["minimum"] = n
+		} else {
+			s["maximum"] = n
+		}
+	}
+}
+
+// nullable marks s as accepting null. OpenAPI 3.0 ignores siblings of $ref,
+// so references are wrapped in allOf.
+func nullable(s Schema) Schema {
+	if _, ok := s["$ref"]; ok {
+		return Schema{"allOf": []Schema{s}, "nullable": true}
+	}
+	if len(s) == 0 {
+		return s
+	}
+	s["nullable"] = true
+	return s
+}
+
+func describe(s Schema, description string) Schema {
+	if _, ok := s["$ref"]; ok {
+		return Schema{"allOf": []Schema{s}, "description": description}
+	}
+	s["description"] = description
+	return s
+}
+
+func exportName(s string) string {
+	if s == "" {
+		return s
+	}
+	r := []rune(s)
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
@@ -0,0 +1,180 @@
+package pagination
+
+import (
+	"backend_pandhi/pkg/apperror"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Op is a filter comparison
+type Op string
+
+const (
+	OpEq       Op = "eq"
+	OpNe       Op = "ne"
+	OpGt       Op = "gt"
+	OpGte      Op = "gte"
+	OpLt       Op = "lt"
+	OpLte      Op = "lte"
+	OpIn       Op = "in"       // comma-separated values
+	OpContains Op = "contains" // case-insensitive substring, strings only
+)
+
+var opSQL = map[Op]string{
+	OpEq:  "=",
+	OpNe:  "<>",
+	OpGt:  ">",
+	OpGte: ">=",
+	OpLt:  "<",
+	OpLte: "<=",
+}
+
+// Filter is a whitelisted filter. Only the listed operators are accepted; an
+// empty Ops allows DefaultOp only. DefaultOp applies when the parameter has no
+// [op] suffix and defaults to OpEq. Values, when set, restricts the accepted
+// values (for enums).
+type Filter struct {
+	Column    string
+	Type      Type
+	Ops       []Op
+	DefaultOp Op
+	Values    []string
+}
+
+// Range allows equality and the four comparison operators, for dates and amounts
+var Range = []Op{OpEq, OpGt, OpGte, OpLt, OpLte}
+
+type condition struct {
+	sql  string
+	args []interface{}
+}
+
+// parseFilters reads "name=value" and "name[op]=value" query parameters. Plain
+// parameters that are not filters are ignored so endpoints can take other
+// options; a bracketed name that is not whitelisted is an error.
+func parseFilters(c *gin.Context, spec Spec, p *apperror.Parser) []condition {
+	var conditions []condition
+
+	query := c.Request.URL.Query()
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		name, op := key, Op("")
+		if i := strings.Index(key, "["); i > 0 && strings.HasSuffix(key, "]") {
+			name, op = key[:i], Op(key[i+1:len(key)-1])
+		}
+
+		filter, ok := spec.Filters[name]
+		if !ok {
+			if name != key {
+				p.Fail(key, "is not a supported filter")
+			}
+			continue
+		}
+
+		defaultOp := filter.DefaultOp
+		if defaultOp == "" {
+			defaultOp = OpEq
+		}
+		if op == "" {
+			op = defaultOp
+		}
+		allowed := filter.Ops
+		if len(allowed) == 0 {
+			allowed = []Op{defaultOp}
+		}
+		if !slices.Contains(allowed, op) {
+			p.Fail(key, fmt.Sprintf("operator %q is not supported for %s", op, name))
+			continue
+		}
+
+		raw := query.Get(key)
+		if op == OpContains {
+			conditions = append(conditions, condition{
+				sql:  filter.Column + " ILIKE ?",
+				args: []interface{}{"%" + escapeLike(raw) + "%"},
+			})
+			continue
+		}
+		if op == OpIn {
+			var values []interface{}
+			for _, part := range strings.Split(raw, ",") {
+				if v, ok := filterValue(p, key, strings.TrimSpace(part), filter, op); ok {
+					values = append(values, v)
+				}
+			}
+			if len(values) > 0 {
+				conditions = append(conditions, condition{sql: filter.Column + " IN ?", args: []interface{}{values}})
+			}
+			continue
+		}
+
+		if v, ok := filterValue(p, key, raw, filter, op); ok {
+			conditions = append(conditions, condition{
+				sql:  fmt.Sprintf("%s %s ?", filter.Column, opSQL[op]),
+				args: []interface{}{v},
+			})
+		}
+	}
+	return conditions
+}
+
+func filterValue(p *apperror.Parser, key, raw string, filter Filter, op Op) (interface{}, bool) {
+	if len(filter.Values) > 0 && !slices.Contains(filter.Values, raw) {
+		p.Fail(key, "must be one of: "+strings.Join(filter.Values, ", "))
+		return nil, false
+	}
+
+	switch filter.Type {
+	case TypeInt:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			p.Fail(key, "must be a whole number")
+			return nil, false
+		}
+		return n, true
+	case TypeFloat:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			p.Fail(key, "must be a number")
+			return nil, false
+		}
+		return f, true
+	case TypeBool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			p.Fail(key, "must be true or false")
+			return nil, false
+		}
+		return b, true
+	case TypeTime:
+		if t, err := time.Parse(time.RFC3339, raw); err == nil {
+			return t, true
+		}
+		t, err := time.Parse("2006-01-02", raw)
+		if err != nil {
+			p.Fail(key, "must be an RFC 3339 timestamp or YYYY-MM-DD date")
+			return nil, false
+		}
+		// A bare date means the whole day: lte includes it, gt starts after it
+		if op == OpLte || op == OpGt {
+			t = t.Add(24*time.Hour - time.Nanosecond)
+		}
+		return t, true
+	default:
+		return raw, true
+	}
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
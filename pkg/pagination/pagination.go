@@ -0,0 +1,293 @@
+// Package pagination implements keyset (cursor) pagination for list endpoints.
+//
+// Each endpoint declares a Spec with the sort keys and filters it allows.
+// Parse reads the standard query parameters:
+//
+//	limit=50                   page size (capped at Spec.MaxLimit)
+//	cursor=<opaque>            nextCursor from the previous page
+//	sort=-createdAt            sort key, "-" for descending
+//	status=PENDING             filter, equality
+//	createdAt[gte]=2025-01-01  filter with an operator
+//
+// Apply adds the filters, keyset condition, ordering and limit to a query, and
+// Trim cuts the fetched rows down to the page and builds the response metadata.
+package pagination
+
+import (
+	"backend_pandhi/pkg/apperror"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Type is the value type of a sort key or filter column
+type Type int
+
+const (
+	TypeInt Type = iota
+	TypeFloat
+	TypeString
+	TypeTime
+	TypeBool
+)
+
+// Sort is a whitelisted sort key. The column must be NOT NULL for the keyset
+// condition to hold.
+type Sort struct {
+	Column string // SQL column, quoted, e.g. `"createdAt"`
+	Field  string // Go struct field holding the value on each row, e.g. "CreatedAt"
+	Type   Type
+}
+
+// Spec describes what an endpoint allows
+type Spec struct {
+	DefaultLimit int
+	MaxLimit     int
+	Sorts        map[string]Sort
+	DefaultSort  string // e.g. "-createdAt"
+	Filters      map[string]Filter
+
+	// Unique tie-breaker that makes the ordering total; defaults to id / ID
+	IDColumn string
+	IDField  string
+}
+
+// WithDefaults returns a copy of s with unset limits and id columns filled in
+func (s Spec) WithDefaults() Spec {
+	if s.DefaultLimit <= 0 {
+		s.DefaultLimit = 20
+	}
+	if s.MaxLimit <= 0 {
+		s.MaxLimit = 100
+	}
+	if s.IDColumn == "" {
+		s.IDColumn = "id"
+	}
+	if s.IDField == "" {
+		s.IDField = "ID"
+	}
+	return s
+}
+
+// Meta is the pagination metadata returned with every page
+type Meta struct {
+	Limit      int     `json:"limit"`
+	HasMore    bool    `json:"hasMore"`
+	NextCursor *string `json:"nextCursor"`
+}
+
+// Request is a parsed, validated page request
+type Request struct {
+	Limit   int
+	SortKey string
+	Desc    bool
+
+	spec       Spec
+	sort       Sort
+	after      *cursor
+	conditions []condition
+}
+
+type cursor struct {
+	Sort  string      `json:"s"`
+	Desc  bool        `json:"d"`
+	Value interface{} `json:"v"`
+	ID    interface{} `json:"id"`
+}
+
+// Parse validates the request's pagination, sort and filter parameters against
+// spec. Problems are reported together as one validation error.
+func Parse(c *gin.Context, spec Spec) (*Request, error) {
+	spec = spec.WithDefaults()
+
+	p := apperror.NewParser()
+	r := &Request{spec: spec, Limit: spec.DefaultLimit}
+
+	if limit := p.OptionalInt("limit", c.Query("limit")); limit != nil {
+		switch {
+		case *limit < 1:
+			p.Fail("limit", "must be at least 1")
+		case *limit > spec.MaxLimit:
+			r.Limit = spec.MaxLimit
+		default:
+			r.Limit = *limit
+		}
+	}
+
+	sortParam := c.DefaultQuery("sort", spec.DefaultSort)
+	r.SortKey = strings.TrimPrefix(sortParam, "-")
+	r.Desc = strings.HasPrefix(sortParam, "-")
+	key, ok := spec.Sorts[r.SortKey]
+	if !ok {
+		p.Fail("sort", "must be one of: "+strings.Join(sortNames(spec), ", "))
+	}
+	r.sort = key
+
+	if raw := c.Query("cursor"); raw != "" && ok {
+		after, err := decodeCursor(raw, r.SortKey, r.Desc, key)
+		if err != nil {
+			p.Fail("cursor", err.Error())
+		}
+		r.after = after
+	}
+
+	r.conditions = parseFilters(c, spec, p)
+
+	if err := p.Err("Invalid list parameters"); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Filter adds only the filter conditions to db; use it for counts and exports
+func (r *Request) Filter(db *gorm.DB) *gorm.DB {
+	for _, cond := range r.conditions {
+		db = db.Where(cond.sql, cond.args...)
+	}
+	return db
+}
+
+// Apply adds filters, the cursor position, ordering and the limit to db. One
+// extra row is fetched so Trim can tell whether another page exists.
+func (r *Request) Apply(db *gorm.DB) *gorm.DB {
+	db = r.Filter(db)
+
+	op, dir := ">", "ASC"
+	if r.Desc {
+		op, dir = "<", "DESC"
+	}
+
+	if r.after != nil {
+		db = db.Where(
+			fmt.Sprintf("(%[1]s %[3]s ? OR (%[1]s = ? AND %[2]s %[3]s ?))", r.sort.Column, r.spec.IDColumn, op),
+			r.after.Value, r.after.Value, r.after.ID,
+		)
+	}
+
+	return db.
+		Order(fmt.Sprintf("%s %s, %s %s", r.sort.Column, dir, r.spec.IDColumn, dir)).
+		Limit(r.Limit + 1)
+}
+
+// Trim drops the extra row fetched by Apply and returns the page with its
+// metadata. rows must be the slice the query was scanned into.
+func Trim[T any](r *Request, rows []T) ([]T, Meta) {
+	meta := Meta{Limit: r.Limit}
+	if len(rows) <= r.Limit {
+		return rows, meta
+	}
+
+	rows = rows[:r.Limit]
+	meta.HasMore = true
+
+	last := reflect.Indirect(reflect.ValueOf(rows[len(rows)-1]))
+	next, err := encodeCursor(cursor{
+		Sort:  r.SortKey,
+		Desc:  r.Desc,
+		Value: cursorValue(last.FieldByName(r.sort.Field), r.sort.Type),
+		ID:    last.FieldByName(r.spec.IDField).Interface(),
+	})
+	if err == nil {
+		meta.NextCursor = &next
+	}
+	return rows, meta
+}
+
+func cursorValue(v reflect.Value, t Type) interface{} {
+	v = reflect.Indirect(v)
+	if !v.IsValid() {
+		return nil
+	}
+	if t == TypeTime {
+		return v.Interface().(time.Time).Format(time.RFC3339Nano)
+	}
+	return v.Interface()
+}
+
+func encodeCursor(c cursor) (string, error) {
+	raw, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func decodeCursor(raw, sortKey string, desc bool, key Sort) (*cursor, error) {
+	invalid := fmt.Errorf("is invalid")
+
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, invalid
+	}
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, invalid
+	}
+	if c.Sort != sortKey || c.Desc != desc {
+		return nil, fmt.Errorf("does not match the requested sort")
+	}
+
+	value, err := convert(c.Value, key.Type)
+	if err != nil {
+		return nil, invalid
+	}
+	c.Value = value
+
+	// The tie-breaker is an integer id unless the spec says otherwise
+	if n, ok := c.ID.(float64); ok {
+		c.ID = int64(n)
+	}
+	return &c, nil
+}
+
+// convert turns a JSON-decoded cursor value back into its column type
+func convert(v interface{}, t Type) (interface{}, error) {
+	switch t {
+	case TypeInt:
+		n, ok := v.(float64)
+		if !ok {
+			return nil, fmt.Errorf("not a number")
+		}
+		return int64(n), nil
+	case TypeFloat:
+		n, ok := v.(float64)
+		if !ok {
+			return nil, fmt.Errorf("not a number")
+		}
+		return n, nil
+	case TypeTime:
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("not a time")
+		}
+		return time.Parse(time.RFC3339Nano, s)
+	case TypeBool:
+		b, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("not a boolean")
+		}
+		return b, nil
+	default:
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("not a string")
+		}
+		return s, nil
+	}
+}
+
+func sortNames(spec Spec) []string {
+	names := make([]string, 0, len(spec.Sorts))
+	for name := range spec.Sorts {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
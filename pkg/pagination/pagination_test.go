@@ -0,0 +1,222 @@
+package pagination
+
+import (
+	"backend_pandhi/pkg/apperror"
+	"encoding/base64"
+	"errors"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type row struct {
+	ID        int
+	Amount    float64
+	Code      string
+	CreatedAt time.Time
+}
+
+var testSpec = Spec{
+	DefaultLimit: 2,
+	MaxLimit:     5,
+	DefaultSort:  "-createdAt",
+	Sorts: map[string]Sort{
+		"createdAt": {Column: `"createdAt"`, Field: "CreatedAt", Type: TypeTime},
+		"amount":    {Column: "amount", Field: "Amount", Type: TypeFloat},
+		"code":      {Column: "code", Field: "Code", Type: TypeString},
+		"id":        {Column: "id", Field: "ID", Type: TypeInt},
+	},
+	Filters: map[string]Filter{
+		"status": {Column: "status", Type: TypeString, Values: []string{"OPEN", "CLOSED"}},
+		"amount": {Column: "amount", Type: TypeFloat, Ops: Range},
+		"code":   {Column: "code", Type: TypeString, DefaultOp: OpContains},
+	},
+}
+
+func parse(t *testing.T, query url.Values) (*Request, error) {
+	t.Helper()
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/?"+query.Encode(), nil)
+	return Parse(c, testSpec)
+}
+
+// fields returns the fields named in a validation error
+func fields(err error) map[string]string {
+	var appErr *apperror.Error
+	if !errors.As(err, &appErr) {
+		return nil
+	}
+	out := map[string]string{}
+	for _, f := range appErr.Fields {
+		out[f.Field] = f.Message
+	}
+	return out
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	created := time.Date(2025, 3, 1, 9, 30, 15, 123456789, time.UTC)
+	rows := []row{
+		{ID: 7, Amount: 12.5, Code: "a", CreatedAt: created.Add(time.Hour)},
+		{ID: 5, Amount: 10.25, Code: "b", CreatedAt: created},
+		{ID: 3, Amount: 9, Code: "c", CreatedAt: created.Add(-time.Hour)},
+	}
+
+	for _, tc := range []struct {
+		sort  string
+		value interface{}
+	}{
+		{"-createdAt", created},
+		{"createdAt", created},
+		{"-amount", 10.25},
+		{"code", "b"},
+		{"-id", int64(5)},
+	} {
+		first, err := parse(t, url.Values{"sort": {tc.sort}})
+		if err != nil {
+			t.Fatalf("%s: Parse: %v", tc.sort, err)
+		}
+		page, meta := Trim(first, rows)
+		if len(page) != 2 || !meta.HasMore || meta.NextCursor == nil || meta.Limit != 2 {
+			t.Fatalf("%s: Trim = %d rows, %+v, want 2 rows and a next cursor", tc.sort, len(page), meta)
+		}
+
+		next, err := parse(t, url.Values{"sort": {tc.sort}, "cursor": {*meta.NextCursor}})
+		if err != nil {
+			t.Fatalf("%s: Parse with cursor: %v", tc.sort, err)
+		}
+		if next.after == nil {
+			t.Fatalf("%s: cursor was not decoded", tc.sort)
+		}
+		if got := next.after.Value; got != tc.value {
+			if gotTime, ok := got.(time.Time); !ok || !gotTime.Equal(tc.value.(time.Time)) {
+				t.Errorf("%s: cursor value = %#v, want %#v", tc.sort, got, tc.value)
+			}
+		}
+		if next.after.ID != int64(5) {
+			t.Errorf("%s: cursor id = %#v, want 5", tc.sort, next.after.ID)
+		}
+	}
+}
+
+func TestLastPageHasNoCursor(t *testing.T) {
+	r, err := parse(t, url.Values{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	page, meta := Trim(r, []row{{ID: 1}, {ID: 2}})
+	if len(page) != 2 || meta.HasMore || meta.NextCursor != nil {
+		t.Errorf("Trim = %d rows, %+v, want the rows and no cursor", len(page), meta)
+	}
+}
+
+func TestRejectsBadCursors(t *testing.T) {
+	encode := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+	valid, err := encodeCursor(cursor{Sort: "createdAt", Desc: true, Value: "2025-03-01T09:30:15Z", ID: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		sort   string
+		cursor string
+		want   string
+	}{
+		{"not base64", "-createdAt", "%%%", "is invalid"},
+		{"not json", "-createdAt", encode("not json"), "is invalid"},
+		{"truncated", "-createdAt", valid[:len(valid)-4], "is invalid"},
+		{"other sort key", "-amount", valid, "does not match the requested sort"},
+		{"other direction", "createdAt", valid, "does not match the requested sort"},
+		{"value of the wrong type", "-createdAt", encode(`{"s":"createdAt","d":true,"v":42,"id":5}`), "is invalid"},
+		{"unparseable time", "-createdAt", encode(`{"s":"createdAt","d":true,"v":"yesterday","id":5}`), "is invalid"},
+		{"string for a number", "-amount", encode(`{"s":"amount","d":true,"v":"1 OR 1=1","id":5}`), "is invalid"},
+	} {
+		_, err := parse(t, url.Values{"sort": {tc.sort}, "cursor": {tc.cursor}})
+		if got := fields(err)["cursor"]; got != tc.want {
+			t.Errorf("%s: cursor error = %q (%v), want %q", tc.name, got, err, tc.want)
+		}
+	}
+}
+
+func TestLimit(t *testing.T) {
+	for _, tc := range []struct {
+		limit string
+		want  int
+		err   bool
+	}{
+		{"", 2, false},
+		{"1", 1, false},
+		{"5", 5, false},
+		{"6", 5, false},
+		{"1000000", 5, false},
+		{"0", 0, true},
+		{"-3", 0, true},
+		{"ten", 0, true},
+	} {
+		query := url.Values{}
+		if tc.limit != "" {
+			query.Set("limit", tc.limit)
+		}
+		r, err := parse(t, query)
+		if tc.err {
+			if _, ok := fields(err)["limit"]; !ok {
+				t.Errorf("limit=%s: error = %v, want a limit error", tc.limit, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("limit=%s: %v", tc.limit, err)
+			continue
+		}
+		if r.Limit != tc.want {
+			t.Errorf("limit=%s: Limit = %d, want %d", tc.limit, r.Limit, tc.want)
+		}
+	}
+}
+
+func TestFilters(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		query url.Values
+		field string // the field expected in the error, "" for success
+		conds int
+	}{
+		{"whitelisted equality", url.Values{"status": {"OPEN"}}, "", 1},
+		{"range operator", url.Values{"amount[gte]": {"10"}, "amount[lt]": {"20"}}, "", 2},
+		{"default operator", url.Values{"code": {"50%"}}, "", 1},
+		{"unrelated plain parameter", url.Values{"outletId": {"3"}}, "", 0},
+		{"unknown bracketed filter", url.Values{"password[eq]": {"x"}}, "password[eq]", 0},
+		{"operator not allowed", url.Values{"status[gt]": {"OPEN"}}, "status[gt]", 0},
+		{"value not in the enum", url.Values{"status": {"DELETED"}}, "status", 0},
+		{"value of the wrong type", url.Values{"amount[gte]": {"ten"}}, "amount[gte]", 0},
+		{"unknown sort", url.Values{"sort": {"password"}}, "sort", 0},
+	} {
+		r, err := parse(t, tc.query)
+		if tc.field != "" {
+			if _, ok := fields(err)[tc.field]; !ok {
+				t.Errorf("%s: error = %v, want a problem with %s", tc.name, err, tc.field)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if len(r.conditions) != tc.conds {
+			t.Errorf("%s: %d conditions, want %d", tc.name, len(r.conditions), tc.conds)
+		}
+	}
+}
+
+func TestContainsEscapesWildcards(t *testing.T) {
+	r, err := parse(t, url.Values{"code": {`50%_off\`}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := r.conditions[0].args[0]; got != `%50\%\_off\\%` {
+		t.Errorf("pattern = %q, want wildcards escaped", got)
+	}
+}
@@ -0,0 +1,77 @@
+package ratings
+
+import (
+	"backend_pandhi/pkg/app"
+	"backend_pandhi/pkg/jobs"
+	"backend_pandhi/pkg/models"
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// rollHour is the local hour the nightly roll runs at
+const rollHour = 3
+
+// RecomputeRequest is the payload of a recompute job: one product, or every
+// product of an outlet
+type RecomputeRequest struct {
+	ProductID int `json:"productId,omitempty"`
+	OutletID  int `json:"outletId,omitempty"`
+}
+
+// RecomputeJob rebuilds aggregates in the background
+var RecomputeJob = jobs.Define("ratings.recompute", func(ctx context.Context, a *app.App, req RecomputeRequest) error {
+	db := a.DB.WithContext(ctx)
+	productIDs := []int{req.ProductID}
+	if req.OutletID != 0 {
+		productIDs = nil
+		if err := db.Model(&models.Product{}).Where(`"outletId" = ?`, req.OutletID).Pluck("id", &productIDs).Error; err != nil {
+			return err
+		}
+		if len(productIDs) == 0 {
+			return nil
+		}
+	}
+	_, err := Recompute(db, a.Clock.Now(), productIDs...)
+	return err
+}, jobs.Policy{Timeout: 5 * time.Minute})
+
+// EnqueueOutletRecompute queues a rebuild of the aggregates of every product
+// of an outlet through tx, as needed after its weights change
+func EnqueueOutletRecompute(tx *gorm.DB, outletID int) error {
+	return RecomputeJob.Enqueue(tx, RecomputeRequest{OutletID: outletID}, jobs.Options{
+		UniqueKey: fmt.Sprintf("%s:outlet:%d", RecomputeJob.Name, outletID),
+	})
+}
+
+// RollJob rolls the 30-day window of every product and queues itself for
+// the next night. It is defined in init because its handler refers to it.
+var RollJob *jobs.Kind[struct{}]
+
+func init() {
+	RollJob = jobs.Define("ratings.roll", roll, jobs.Policy{Timeout: 10 * time.Minute})
+}
+
+func roll(ctx context.Context, a *app.App, _ struct{}) error {
+	now := a.Clock.Now()
+	db := a.DB.WithContext(ctx)
+	n, err := Roll(db, now)
+	if err != nil {
+		return err
+	}
+	slog.InfoContext(ctx, "Rolled 30-day product ratings", "products", n)
+	return ScheduleRoll(db, now)
+}
+
+// ScheduleRoll queues the roll for the next night unless it is already
+// queued. The server calls it on start so the chain of nightly jobs exists.
+func ScheduleRoll(db *gorm.DB, now time.Time) error {
+	next := time.Date(now.Year(), now.Month(), now.Day(), rollHour, 0, 0, 0, now.Location())
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return RollJob.Enqueue(db, struct{}{}, jobs.Options{RunAt: next, UniqueKey: RollJob.Name})
+}
@@ -0,0 +1,91 @@
+// Package ratings maintains the rating aggregates stored on products.
+//
+// A product's score for one feedback weighs its four ratings with the
+// outlet's weights. New feedback is added to the lifetime and 30-day
+// aggregates in the transaction that inserts it; a nightly job rolls the
+// 30-day window so old feedback drops out of it even for products that get
+// none. Recompute rebuilds both from scratch, which is needed after the
+// weights change or feedback is hidden or restored. Hidden feedback never
+// counts.
+package ratings
+
+import (
+	"backend_pandhi/pkg/models"
+	"backend_pandhi/pkg/repository"
+	"errors"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// window is the span of the trend aggregates
+const window = 30 * 24 * time.Hour
+
+// Weights are the shares of the four ratings in a score. Each is between 0
+// and 1 and together they sum to 1.
+type Weights struct {
+	Overall  float64 `json:"overall"`
+	Taste    float64 `json:"taste"`
+	Quality  float64 `json:"quality"`
+	Quantity float64 `json:"quantity"`
+}
+
+// DefaultWeights are the weights of a new outlet
+var DefaultWeights = Weights{Overall: 0.4, Taste: 0.3, Quality: 0.2, Quantity: 0.1}
+
+// ErrInvalidWeights means the weights are out of range or do not sum to 1
+var ErrInvalidWeights = errors.New("rating weights must be between 0 and 1 and sum to 1")
+
+// WeightsOf returns the weights an outlet scores with
+func WeightsOf(o models.Outlet) Weights {
+	return Weights{
+		Overall:  o.RatingWeightOverall,
+		Taste:    o.RatingWeightTaste,
+		Quality:  o.RatingWeightQuality,
+		Quantity: o.RatingWeightQuantity,
+	}
+}
+
+// Validate reports ErrInvalidWeights unless the weights can be used
+func (w Weights) Validate() error {
+	for _, v := range []float64{w.Overall, w.Taste, w.Quality, w.Quantity} {
+		if v < 0 || v > 1 || math.IsNaN(v) {
+			return ErrInvalidWeights
+		}
+	}
+	if math.Abs(w.Overall+w.Taste+w.Quality+w.Quantity-1) > 1e-9 {
+		return ErrInvalidWeights
+	}
+	return nil
+}
+
+// Score weighs the four ratings of one feedback into a single score
+func (w Weights) Score(f models.Feedback) float64 {
+	return f.RatingOverall*w.Overall +
+		f.RatingTaste*w.Taste +
+		f.RatingQuality*w.Quality +
+		f.RatingQuantity*w.Quantity
+}
+
+// Add counts a new feedback in its product's aggregates. Call it with the
+// transaction that inserts the feedback.
+func Add(tx *gorm.DB, f models.Feedback) error {
+	if f.Status == models.FeedbackStatusHidden {
+		return nil
+	}
+	return repository.Products(tx).AddRating(f)
+}
+
+// Roll recalculates the 30-day aggregates of every product and returns how
+// many were updated
+func Roll(db *gorm.DB, now time.Time) (int64, error) {
+	return repository.Products(db).RollRatings(now.Add(-window))
+}
+
+// Recompute recalculates the 30-day and lifetime aggregates of the given
+// products, or of every product when none is given, and returns how many
+// were updated
+func Recompute(db *gorm.DB, now time.Time, productIDs ...int) (int64, error) {
+	return repository.Products(db).RecomputeRatings(now.Add(-window), productIDs...)
+}
@@ -0,0 +1,103 @@
+package ratings_test
+
+import (
+	"backend_pandhi/pkg/models"
+	"backend_pandhi/pkg/ratings"
+	"backend_pandhi/pkg/testenv"
+	"errors"
+	"math"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(testenv.Main(m))
+}
+
+func TestWeightsValidate(t *testing.T) {
+	for _, tc := range []struct {
+		weights ratings.Weights
+		valid   bool
+	}{
+		{ratings.DefaultWeights, true},
+		{ratings.Weights{Overall: 1}, true},
+		{ratings.Weights{Overall: 0.5, Taste: 0.5, Quality: 0.5}, false},
+		{ratings.Weights{Overall: 1.2, Taste: -0.2}, false},
+		{ratings.Weights{Overall: math.NaN()}, false},
+	} {
+		err := tc.weights.Validate()
+		if valid := err == nil; valid != tc.valid {
+			t.Errorf("%+v: Validate() = %v, want valid %v", tc.weights, err, tc.valid)
+		}
+		if err != nil && !errors.Is(err, ratings.ErrInvalidWeights) {
+			t.Errorf("%+v: Validate() = %v, want ErrInvalidWeights", tc.weights, err)
+		}
+	}
+}
+
+func TestAddMatchesRecomputeAndRollDecays(t *testing.T) {
+	env := testenv.New(t)
+	now := time.Now()
+	outlet := env.Outlet(t)
+	customer := env.Customer(t, outlet, 0)
+	product := env.Product(t, outlet, 50, 10)
+
+	order := models.Order{
+		CustomerID:    &customer.ID,
+		OutletID:      outlet.ID,
+		TotalAmount:   50,
+		PaymentMethod: models.PaymentMethodWallet,
+		Status:        "DELIVERED",
+		Type:          models.OrderTypeApp,
+	}
+	if err := env.DB.Create(&order).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	feedback := []models.Feedback{
+		{RatingOverall: 5, RatingTaste: 4, RatingQuality: 3, RatingQuantity: 2, CreatedAt: now.AddDate(0, 0, -40)},
+		{RatingOverall: 3, RatingTaste: 3, RatingQuality: 3, RatingQuantity: 3, CreatedAt: now},
+	}
+	for i := range feedback {
+		f := &feedback[i]
+		f.UserID, f.OrderID, f.ProductID = customer.ID, order.ID, product.ID
+		if err := env.DB.Create(f).Error; err != nil {
+			t.Fatal(err)
+		}
+		if err := ratings.Add(env.DB, *f); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+	}
+
+	old, recent := ratings.DefaultWeights.Score(feedback[0]), ratings.DefaultWeights.Score(feedback[1])
+	env.Reload(t, &product)
+	// Add does not know the first feedback is old; only the roll does
+	if product.RatingCount30d != 2 || product.RatingCountLifetime != 2 ||
+		!near(product.AverageRatingLifetime, (old+recent)/2) || !near(product.TrendScore, (old+recent)/2) {
+		t.Errorf("after Add: %+v", product)
+	}
+
+	if _, err := ratings.Roll(env.DB, now); err != nil {
+		t.Fatalf("Roll: %v", err)
+	}
+	env.Reload(t, &product)
+	if product.RatingCount30d != 1 || !near(product.TrendScore, recent) || product.RatingCountLifetime != 2 {
+		t.Errorf("after Roll: %+v", product)
+	}
+
+	// Recompute agrees with the incremental aggregates
+	added := product
+	if _, err := ratings.Recompute(env.DB, now, product.ID); err != nil {
+		t.Fatalf("Recompute: %v", err)
+	}
+	env.Reload(t, &product)
+	if product.RatingCount30d != added.RatingCount30d || !near(product.RatingSum30d, added.RatingSum30d) ||
+		product.RatingCountLifetime != added.RatingCountLifetime || !near(product.RatingSumLifetime, added.RatingSumLifetime) {
+		t.Errorf("Recompute = %+v, want the aggregates of %+v", product, added)
+	}
+}
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
@@ -0,0 +1,289 @@
+package repository
+
+import (
+	"backend_pandhi/pkg/migrations"
+	"backend_pandhi/pkg/models"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"gorm.io/gorm/schema"
+)
+
+// allModels is every model with a table
+var allModels = []interface{}{
+	&models.Outlet{}, &models.User{}, &models.CustomerDetails{}, &models.StaffDetails{},
+	&models.StaffPermission{}, &models.Product{}, &models.Inventory{}, &models.StockHistory{},
+	&models.Cart{}, &models.CartItem{}, &models.Order{}, &models.OrderItem{},
+	&models.Wallet{}, &models.WalletTransaction{}, &models.Admin{}, &models.AdminOutlet{},
+	&models.AdminPermission{}, &models.Ticket{}, &models.Coupon{}, &models.CouponUsage{},
+	&models.Expense{}, &models.Notification{}, &models.ScheduledNotification{},
+	&models.NotificationDelivery{}, &models.UserDeviceToken{}, &models.OutletAvailability{},
+	&models.OutletAppManagement{}, &models.Feedback{}, &models.UserFreeQuota{},
+	&models.AuthToken{}, &models.PhoneOTP{}, &models.LoginThrottle{}, &models.LoginLockoutEvent{},
+	&models.RateLimitBucket{}, &models.IdempotencyRecord{}, &models.AuditEvent{}, &models.Job{},
+	&models.FeedbackAlertRule{},
+}
+
+var (
+	createTable = regexp.MustCompile(`(?s)CREATE TABLE "(\w+)" \((.*?)\n\);`)
+	columnDef   = regexp.MustCompile(`(?m)^\s+"(\w+)" `)
+	addColumn   = regexp.MustCompile(`ALTER TABLE "(\w+)" ADD COLUMN (?:IF NOT EXISTS )?"(\w+)"`)
+	dropColumn  = regexp.MustCompile(`ALTER TABLE "(\w+)" DROP COLUMN (?:IF EXISTS )?"(\w+)"`)
+	renameCol   = regexp.MustCompile(`ALTER TABLE "(\w+)" RENAME COLUMN "(\w+)" TO "(\w+)"`)
+	dropTable   = regexp.MustCompile(`DROP TABLE (?:IF EXISTS )?"(\w+)"`)
+)
+
+// migratedSchema replays every up migration and returns the columns of each
+// table, which is the schema the application really runs against
+func migratedSchema(t *testing.T) map[string]map[string]bool {
+	t.Helper()
+	all, err := migrations.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tables := map[string]map[string]bool{}
+	for _, m := range all {
+		// Statements are applied in file order; the patterns used here never
+		// depend on each other within one file except create-then-alter
+		for _, match := range createTable.FindAllStringSubmatch(m.Up, -1) {
+			cols := map[string]bool{}
+			for _, col := range columnDef.FindAllStringSubmatch(match[2], -1) {
+				cols[col[1]] = true
+			}
+			tables[match[1]] = cols
+		}
+		for _, match := range addColumn.FindAllStringSubmatch(m.Up, -1) {
+			tables[match[1]][match[2]] = true
+		}
+		for _, match := range renameCol.FindAllStringSubmatch(m.Up, -1) {
+			delete(tables[match[1]], match[2])
+			tables[match[1]][match[3]] = true
+		}
+		for _, match := range dropColumn.FindAllStringSubmatch(m.Up, -1) {
+			delete(tables[match[1]], match[2])
+		}
+		for _, match := range dropTable.FindAllStringSubmatch(m.Up, -1) {
+			delete(tables, match[1])
+		}
+	}
+	return tables
+}
+
+// TestModelsMatchMigrations checks that every model column exists in the
+// table the migrations create
+func TestModelsMatchMigrations(t *testing.T) {
+	tables := migratedSchema(t)
+	cache := &sync.Map{}
+
+	for _, model := range allModels {
+		s, err := schema.Parse(model, cache, schema.NamingStrategy{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		cols, ok := tables[s.Table]
+		if !ok {
+			t.Errorf("%s: table %q is not created by any migration", s.Name, s.Table)
+			continue
+		}
+		for _, f := range s.Fields {
+			if f.DBName != "" && !cols[f.DBName] {
+				t.Errorf("%s.%s: column %q does not exist on %q", s.Name, f.Name, f.DBName, s.Table)
+			}
+		}
+	}
+}
+
+// sqlMethods are the GORM methods whose string arguments are SQL fragments.
+// The value is the index of the first such argument.
+var sqlMethods = map[string]int{
+	"Where": 0, "Or": 0, "Not": 0, "Having": 0, "Joins": 0, "Order": 0,
+	"Select": 0, "Group": 0, "Distinct": 0, "Pluck": 0, "Update": 0,
+	"UpdateColumn": 0, "Raw": 0, "Exec": 0, "Table": 0, "Expr": 0,
+	"Preload": 1,
+}
+
+var sqlWords = map[string]bool{}
+
+func init() {
+	for _, w := range strings.Fields(`
+		select from where and or not in is null as on join left right inner outer
+		full cross asc desc nulls first last distinct between like ilike true false
+		case when then else end interval group by order limit offset having exists
+		any all union with filter over partition epoch day week month year hour
+		minute second date time timestamp timestamptz text integer int numeric
+		float double precision boolean current_date current_timestamp set update
+		delete insert into values returning for skip locked nowait share of
+		`) {
+		sqlWords[w] = true
+	}
+}
+
+var (
+	singleQuoted = regexp.MustCompile(`'[^']*'`)
+	tokenPattern = regexp.MustCompile(`"(\w+)"(?:\."?(\w+)"?)?|\b([A-Za-z_]\w*)\b(?:\.("?)(\w+)"?)?(\s*\()?|::\s*\w+`)
+	aliasPattern = regexp.MustCompile(`(?i)\bas\s+"?(\w+)"?`)
+)
+
+type reference struct {
+	pos   token.Position
+	value string
+}
+
+// TestRawColumnsExist checks every column written out by hand in a GORM call
+// anywhere under pkg/ against the migrated schema
+func TestRawColumnsExist(t *testing.T) {
+	tables := migratedSchema(t)
+	anyColumn := map[string]bool{}
+	for _, cols := range tables {
+		for col := range cols {
+			anyColumn[col] = true
+		}
+	}
+
+	fset := token.NewFileSet()
+	var refs []reference
+	aliases := map[string]map[string]bool{}
+
+	err := filepath.WalkDir("..", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return err
+		}
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			return err
+		}
+
+		fileAliases := map[string]bool{}
+		aliases[path] = fileAliases
+		add := func(lit *ast.BasicLit) {
+			if lit.Kind != token.STRING {
+				return
+			}
+			value, err := strconv.Unquote(lit.Value)
+			if err != nil || strings.HasPrefix(value, "/") {
+				// Route groups share the Group method name
+				return
+			}
+			for _, m := range aliasPattern.FindAllStringSubmatch(value, -1) {
+				fileAliases[m[1]] = true
+			}
+			refs = append(refs, reference{fset.Position(lit.Pos()), value})
+		}
+
+		ast.Inspect(file, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.CallExpr:
+				sel, ok := n.Fun.(*ast.SelectorExpr)
+				if !ok {
+					return true
+				}
+				// Column maps passed to Updates
+				if sel.Sel.Name == "Updates" && len(n.Args) == 1 {
+					if cl, ok := n.Args[0].(*ast.CompositeLit); ok {
+						for _, elt := range cl.Elts {
+							if kv, ok := elt.(*ast.KeyValueExpr); ok {
+								if lit, ok := kv.Key.(*ast.BasicLit); ok {
+									add(lit)
+								}
+							}
+						}
+					}
+					return true
+				}
+				first, ok := sqlMethods[sel.Sel.Name]
+				if !ok {
+					return true
+				}
+				for _, arg := range n.Args[min(first, len(n.Args)):] {
+					if lit, ok := arg.(*ast.BasicLit); ok {
+						add(lit)
+					}
+				}
+			case *ast.KeyValueExpr:
+				// pagination.Sort and pagination.Filter columns
+				if key, ok := n.Key.(*ast.Ident); ok && key.Name == "Column" {
+					if lit, ok := n.Value.(*ast.BasicLit); ok {
+						add(lit)
+					}
+				}
+			}
+			return true
+		})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(refs) == 0 {
+		t.Fatal("found no SQL fragments; is the walk rooted at pkg/?")
+	}
+
+	var problems []string
+	for _, ref := range refs {
+		for _, unknown := range unknownColumns(ref.value, tables, anyColumn, aliases[ref.pos.Filename]) {
+			problems = append(problems, fmt.Sprintf("%s: %s in %q", ref.pos, unknown, ref.value))
+		}
+	}
+	sort.Strings(problems)
+	for _, p := range problems {
+		t.Error(p)
+	}
+}
+
+// unknownColumns returns every identifier in fragment that is neither a SQL
+// word, an alias, a table nor a column of the table it is qualified with
+func unknownColumns(fragment string, tables map[string]map[string]bool, anyColumn, aliases map[string]bool) []string {
+	fragment = singleQuoted.ReplaceAllString(fragment, "''")
+
+	var unknown []string
+	for _, m := range tokenPattern.FindAllStringSubmatch(fragment, -1) {
+		switch {
+		case strings.HasPrefix(m[0], "::"):
+			// type cast
+		case m[1] != "" && m[2] != "":
+			// "Table"."column"
+			cols, ok := tables[m[1]]
+			if !ok {
+				unknown = append(unknown, fmt.Sprintf("table %q", m[1]))
+			} else if !cols[m[2]] {
+				unknown = append(unknown, fmt.Sprintf("column %q.%q", m[1], m[2]))
+			}
+		case m[1] != "":
+			// "column" or "Table"
+			if _, ok := tables[m[1]]; !ok && !anyColumn[m[1]] && !aliases[m[1]] {
+				unknown = append(unknown, fmt.Sprintf("column %q", m[1]))
+			}
+		case m[5] != "":
+			// table.column with an unquoted table name
+			cols, ok := tables[m[3]]
+			if !ok {
+				unknown = append(unknown, fmt.Sprintf("table %q", m[3]))
+			} else if !cols[m[5]] {
+				unknown = append(unknown, fmt.Sprintf("column %q.%q", m[3], m[5]))
+			}
+		case m[6] != "":
+			// function call
+		default:
+			word := m[3]
+			if sqlWords[strings.ToLower(word)] || aliases[word] || anyColumn[word] {
+				continue
+			}
+			if _, ok := tables[word]; ok {
+				continue
+			}
+			unknown = append(unknown, fmt.Sprintf("column %q", word))
+		}
+	}
+	return unknown
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"backend_pandhi/pkg/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	inventoryProduct       = sql(`{Inventory.ProductID} = ?`)
+	inventoryOutletProduct = sql(`{Inventory.OutletID} = ? AND {Inventory.ProductID} = ?`)
+	inventoryInStock       = sql(`{Inventory.OutletID} = ? AND {Inventory.Quantity} > 0`)
+	inventoryLow           = sql(`{Inventory.OutletID} = ? AND {Inventory.Quantity} < {Inventory.Threshold}`)
+	addToQuantity          = sql(`{Inventory.Quantity} + ?`)
+)
+
+// InventoryRepo queries the stock held of each product
+type InventoryRepo struct {
+	db *gorm.DB
+}
+
+// Inventory returns the inventory queries run on db
+func Inventory(db *gorm.DB) InventoryRepo {
+	return InventoryRepo{db: db}
+}
+
+// ForProduct returns the stock of a product
+func (r InventoryRepo) ForProduct(productID int) (models.Inventory, error) {
+	var inventory models.Inventory
+	err := r.db.Where(inventoryProduct, productID).First(&inventory).Error
+	return inventory, err
+}
+
+// ForOutletProduct returns the stock of a product, provided it belongs to
+// the outlet
+func (r InventoryRepo) ForOutletProduct(outletID, productID int) (models.Inventory, error) {
+	var inventory models.Inventory
+	err := r.db.Where(inventoryOutletProduct, outletID, productID).First(&inventory).Error
+	return inventory, err
+}
+
+// InStock returns the outlet's inventory rows with stock left
+func (r InventoryRepo) InStock(outletID int) ([]models.Inventory, error) {
+	var inventories []models.Inventory
+	err := r.db.Where(inventoryInStock, outletID).Find(&inventories).Error
+	return inventories, err
+}
+
+// LowStock returns the outlet's inventory rows below their threshold
+func (r InventoryRepo) LowStock(outletID int) ([]models.Inventory, error) {
+	var inventories []models.Inventory
+	err := r.db.Where(inventoryLow, outletID).Find(&inventories).Error
+	return inventories, err
+}
+
+// SetQuantity overwrites the stock of an inventory row, including
+// inventory.Quantity
+func (r InventoryRepo) SetQuantity(inventory *models.Inventory, quantity int) error {
+	return r.db.Model(inventory).Update(column("Inventory", "Quantity"), quantity).Error
+}
+
+// Adjust changes the stock of a product at an outlet by delta, which may be
+// negative. The change is made in SQL, so concurrent adjustments are not
+// lost.
+func (r InventoryRepo) Adjust(outletID, productID, delta int) error {
+	return r.db.Model(&models.Inventory{}).
+		Where(inventoryOutletProduct, outletID, productID).
+		Update(column("Inventory", "Quantity"), gorm.Expr(addToQuantity, delta)).Error
+}
@@ -0,0 +1,327 @@
+package repository
+
+import (
+	"backend_pandhi/pkg/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	orderOutlet      = sql(`{Order.OutletID} = ?`)
+	orderCustomer    = sql(`{Order.CustomerID} = ?`)
+	orderType        = sql(`{Order.Type} = ?`)
+	orderStatusIn    = sql(`{Order.Status} IN ?`)
+	orderCreatedFrom = sql(`{Order.CreatedAt} >= ?`)
+	orderCreatedTo   = sql(`{Order.CreatedAt} <= ?`)
+	orderByID        = sql(`{Order.ID} = ?`)
+	newestOrderFirst = sql(`{Order.CreatedAt} DESC`)
+
+	joinCustomerOfOrder = sql(`JOIN {CustomerDetails} ON {CustomerDetails.ID} = {Order.CustomerID}`)
+	customerUser        = sql(`{CustomerDetails.UserID} = ?`)
+	deliveredSince      = sql(`{Order.Status} = ? AND {Order.DeliveredAt} >= ?`)
+	latestDelivery      = sql(`{Order.DeliveredAt} DESC`)
+
+	itemsOfOrder      = sql(`{OrderItem.OrderID} = ?`)
+	undeliveredItems  = sql(`{OrderItem.OrderID} = ? AND {OrderItem.Status} != ?`)
+	itemByID          = sql(`{OrderItem.ID} IN ?`)
+	joinOrderOfItem   = sql(`JOIN {Order} ON {Order.ID} = {OrderItem.OrderID}`)
+	productQuantities = sql(`{OrderItem.ProductID} AS "productId", SUM({OrderItem.Quantity}) AS quantity`)
+	byItemProduct     = sql(`{OrderItem.ProductID}`)
+
+	slotStats = sql(`{Order.Type} AS type, {Order.DeliverySlot} AS "deliverySlot", ` +
+		`COUNT(*) AS count, COALESCE(SUM({Order.TotalAmount}), 0) AS "totalAmount"`)
+	bySlot = sql(`{Order.Type}, {Order.DeliverySlot}`)
+
+	orderRevenue      = sql(`COALESCE(SUM({Order.TotalAmount}), 0)`)
+	outletRevenue     = sql(`{Order.OutletID} AS "outletId", SUM({Order.TotalAmount}) AS "totalAmount"`)
+	byOrderOutlet     = sql(`{Order.OutletID}`)
+	statusCounts      = sql(`{Order.Status} AS status, COUNT(*) AS count`)
+	byOrderStatus     = sql(`{Order.Status}`)
+	typeCounts        = sql(`{Order.Type} AS type, COUNT(*) AS count`)
+	byOrderType       = sql(`{Order.Type}`)
+	slotCounts        = sql(`{Order.DeliverySlot} AS "deliverySlot", COUNT(*) AS count`)
+	hasDeliverySlot   = sql(`{Order.DeliverySlot} IS NOT NULL`)
+	byDeliverySlot    = sql(`{Order.DeliverySlot}`)
+	orderCustomerIn   = sql(`{Order.CustomerID} IN ?`)
+	byOrderCustomer   = sql(`{Order.CustomerID}`)
+	joinProductOfItem = sql(`JOIN {Product} ON {Product.ID} = {OrderItem.ProductID}`)
+	byItemProductName = sql(`{OrderItem.ProductID}, {Product.Name}`)
+
+	customerOrderStats = sql(`{Order.CustomerID} AS "customerId", COUNT(*) AS "totalOrders", ` +
+		`COALESCE(SUM({Order.TotalAmount}), 0) AS "totalPurchase", MAX({Order.CreatedAt}) AS "lastOrderAt"`)
+	productSales = sql(`{OrderItem.ProductID} AS "productId", {Product.Name} AS "productName", ` +
+		`SUM({OrderItem.Quantity}) AS quantity, SUM({OrderItem.Quantity} * {OrderItem.UnitPrice}) AS revenue`)
+)
+
+// OrderFilter selects orders; zero fields are not filtered on. From and To
+// bound the creation time and are inclusive.
+type OrderFilter struct {
+	OutletID   int
+	CustomerID int
+	Type       models.OrderType
+	Statuses   []models.OrderStatus
+	From, To   time.Time
+}
+
+func (f OrderFilter) apply(db *gorm.DB) *gorm.DB {
+	if f.OutletID != 0 {
+		db = db.Where(orderOutlet, f.OutletID)
+	}
+	if f.CustomerID != 0 {
+		db = db.Where(orderCustomer, f.CustomerID)
+	}
+	if f.Type != "" {
+		db = db.Where(orderType, f.Type)
+	}
+	if len(f.Statuses) > 0 {
+		db = db.Where(orderStatusIn, f.Statuses)
+	}
+	if !f.From.IsZero() {
+		db = db.Where(orderCreatedFrom, f.From)
+	}
+	if !f.To.IsZero() {
+		db = db.Where(orderCreatedTo, f.To)
+	}
+	return db
+}
+
+// SlotStat is the number and value of orders of one type in one delivery slot
+type SlotStat struct {
+	Type         models.OrderType     `gorm:"column:type"`
+	DeliverySlot *models.DeliverySlot `gorm:"column:deliverySlot"`
+	Count        int64                `gorm:"column:count"`
+	TotalAmount  float64              `gorm:"column:totalAmount"`
+}
+
+// ProductQuantity is the number of units of a product ordered
+type ProductQuantity struct {
+	ProductID int `gorm:"column:productId"`
+	Quantity  int `gorm:"column:quantity"`
+}
+
+// OutletRevenue is the value of an outlet's orders
+type OutletRevenue struct {
+	OutletID    int     `gorm:"column:outletId"`
+	TotalAmount float64 `gorm:"column:totalAmount"`
+}
+
+// StatusCount is the number of orders in one status
+type StatusCount struct {
+	Status models.OrderStatus `gorm:"column:status"`
+	Count  int64              `gorm:"column:count"`
+}
+
+// TypeCount is the number of orders of one type
+type TypeCount struct {
+	Type  models.OrderType `gorm:"column:type"`
+	Count int64            `gorm:"column:count"`
+}
+
+// SlotCount is the number of orders for one delivery slot
+type SlotCount struct {
+	DeliverySlot models.DeliverySlot `gorm:"column:deliverySlot"`
+	Count        int64               `gorm:"column:count"`
+}
+
+// CustomerOrderStats sums up the orders of one customer
+type CustomerOrderStats struct {
+	CustomerID    int        `gorm:"column:customerId"`
+	TotalOrders   int64      `gorm:"column:totalOrders"`
+	TotalPurchase float64    `gorm:"column:totalPurchase"`
+	LastOrderAt   *time.Time `gorm:"column:lastOrderAt"`
+}
+
+// ProductSales is the units sold of a product and their value
+type ProductSales struct {
+	ProductID   int     `gorm:"column:productId"`
+	ProductName string  `gorm:"column:productName"`
+	Quantity    int     `gorm:"column:quantity"`
+	Revenue     float64 `gorm:"column:revenue"`
+}
+
+// OrderRepo queries orders and their items
+type OrderRepo struct {
+	db *gorm.DB
+}
+
+// Orders returns the order queries run on db
+func Orders(db *gorm.DB) OrderRepo {
+	return OrderRepo{db: db}
+}
+
+// Where returns the orders matching f as a query, for callers that paginate
+// or otherwise refine it
+func (r OrderRepo) Where(f OrderFilter) *gorm.DB {
+	return f.apply(r.db.Model(&models.Order{}))
+}
+
+// List returns the orders matching f, newest first
+func (r OrderRepo) List(f OrderFilter) ([]models.Order, error) {
+	var orders []models.Order
+	err := f.apply(r.db).Order(newestOrderFirst).Find(&orders).Error
+	return orders, err
+}
+
+// Count returns how many orders match f
+func (r OrderRepo) Count(f OrderFilter) (int64, error) {
+	var n int64
+	err := r.Where(f).Count(&n).Error
+	return n, err
+}
+
+// ForCustomer returns an order placed by the customer
+func (r OrderRepo) ForCustomer(orderID, customerID int) (models.Order, error) {
+	var order models.Order
+	err := r.db.Where(orderByID, orderID).Where(orderCustomer, customerID).First(&order).Error
+	return order, err
+}
+
+// ForUser returns an order placed by the customer account of a user
+func (r OrderRepo) ForUser(orderID, userID int) (models.Order, error) {
+	var order models.Order
+	err := r.db.Joins(joinCustomerOfOrder).
+		Where(orderByID, orderID).
+		Where(customerUser, userID).
+		First(&order).Error
+	return order, err
+}
+
+// ForOutlet returns an order of the outlet
+func (r OrderRepo) ForOutlet(orderID, outletID int) (models.Order, error) {
+	var order models.Order
+	err := r.db.Where(orderByID, orderID).Where(orderOutlet, outletID).First(&order).Error
+	return order, err
+}
+
+// DeliveredForUser returns the orders of a user's customer account that were
+// delivered since the given time, most recently delivered first
+func (r OrderRepo) DeliveredForUser(userID int, since time.Time) ([]models.Order, error) {
+	var orders []models.Order
+	err := r.db.Joins(joinCustomerOfOrder).
+		Where(customerUser, userID).
+		Where(deliveredSince, models.OrderStatusDelivered, since).
+		Order(latestDelivery).
+		Find(&orders).Error
+	return orders, err
+}
+
+// SetStatus changes the status of an order and when it was delivered
+func (r OrderRepo) SetStatus(order *models.Order, status models.OrderStatus, deliveredAt *time.Time) error {
+	return r.db.Model(order).Updates(map[string]interface{}{
+		column("Order", "Status"):      status,
+		column("Order", "DeliveredAt"): deliveredAt,
+	}).Error
+}
+
+// Items returns the items of an order
+func (r OrderRepo) Items(orderID int) ([]models.OrderItem, error) {
+	var items []models.OrderItem
+	err := r.db.Where(itemsOfOrder, orderID).Find(&items).Error
+	return items, err
+}
+
+// DeliverAllItems marks every item of an order delivered
+func (r OrderRepo) DeliverAllItems(orderID int) error {
+	return r.db.Model(&models.OrderItem{}).
+		Where(undeliveredItems, orderID, models.OrderItemStatusDelivered).
+		Update(column("OrderItem", "Status"), models.OrderItemStatusDelivered).Error
+}
+
+// DeliverItems marks the given order items delivered
+func (r OrderRepo) DeliverItems(itemIDs []int) error {
+	return r.db.Model(&models.OrderItem{}).
+		Where(itemByID, itemIDs).
+		Update(column("OrderItem", "Status"), models.OrderItemStatusDelivered).Error
+}
+
+// SlotStats returns the orders matching f counted by type and delivery slot
+func (r OrderRepo) SlotStats(f OrderFilter) ([]SlotStat, error) {
+	var stats []SlotStat
+	err := r.Where(f).Select(slotStats).Group(bySlot).Scan(&stats).Error
+	return stats, err
+}
+
+// ProductQuantities returns the units sold of each product in the orders
+// matching f, best sellers first
+func (r OrderRepo) ProductQuantities(f OrderFilter) ([]ProductQuantity, error) {
+	var quantities []ProductQuantity
+	err := f.apply(r.db.Model(&models.OrderItem{}).Joins(joinOrderOfItem)).
+		Select(productQuantities).
+		Group(byItemProduct).
+		Order("quantity DESC").
+		Scan(&quantities).Error
+	return quantities, err
+}
+
+// Revenue returns the total value of the orders matching f
+func (r OrderRepo) Revenue(f OrderFilter) (float64, error) {
+	var total float64
+	err := r.Where(f).Select(orderRevenue).Scan(&total).Error
+	return total, err
+}
+
+// TopOutlets returns the outlets with the most valuable orders matching f,
+// highest first
+func (r OrderRepo) TopOutlets(f OrderFilter, limit int) ([]OutletRevenue, error) {
+	var outlets []OutletRevenue
+	err := r.Where(f).
+		Select(outletRevenue).
+		Group(byOrderOutlet).
+		Order(`"totalAmount" DESC`).
+		Limit(limit).
+		Scan(&outlets).Error
+	return outlets, err
+}
+
+// StatusCounts returns the orders matching f counted by status
+func (r OrderRepo) StatusCounts(f OrderFilter) ([]StatusCount, error) {
+	var counts []StatusCount
+	err := r.Where(f).Select(statusCounts).Group(byOrderStatus).Scan(&counts).Error
+	return counts, err
+}
+
+// TypeCounts returns the orders matching f counted by type
+func (r OrderRepo) TypeCounts(f OrderFilter) ([]TypeCount, error) {
+	var counts []TypeCount
+	err := r.Where(f).Select(typeCounts).Group(byOrderType).Scan(&counts).Error
+	return counts, err
+}
+
+// SlotCounts returns the orders matching f that have a delivery slot counted
+// by slot, busiest first
+func (r OrderRepo) SlotCounts(f OrderFilter) ([]SlotCount, error) {
+	var counts []SlotCount
+	err := r.Where(f).
+		Where(hasDeliverySlot).
+		Select(slotCounts).
+		Group(byDeliverySlot).
+		Order("COUNT(*) DESC").
+		Scan(&counts).Error
+	return counts, err
+}
+
+// CustomerStats returns the order count, value and latest order of each of
+// the given customers that has ordered
+func (r OrderRepo) CustomerStats(customerIDs []int) ([]CustomerOrderStats, error) {
+	var stats []CustomerOrderStats
+	err := r.db.Model(&models.Order{}).
+		Select(customerOrderStats).
+		Where(orderCustomerIn, customerIDs).
+		Group(byOrderCustomer).
+		Scan(&stats).Error
+	return stats, err
+}
+
+// ProductSales returns the best-selling products in the orders matching f,
+// most units first
+func (r OrderRepo) ProductSales(f OrderFilter, limit int) ([]ProductSales, error) {
+	var sales []ProductSales
+	err := f.apply(r.db.Model(&models.OrderItem{}).Joins(joinOrderOfItem).Joins(joinProductOfItem)).
+		Select(productSales).
+		Group(byItemProductName).
+		Order("quantity DESC").
+		Limit(limit).
+		Scan(&sales).Error
+	return sales, err
+}
@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"backend_pandhi/pkg/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	productOutlet    = sql(`{Product.OutletID} = ?`)
+	productByID      = sql(`{Product.ID} = ?`)
+	feedbackForOrder = sql(`{Feedback.OrderID} = ? AND {Feedback.ProductID} IN ?`)
+
+	// score weighs the four ratings of a feedback row with its outlet's
+	// weights
+	score = `{Feedback.RatingOverall} * {Outlet.RatingWeightOverall} +
+		{Feedback.RatingTaste} * {Outlet.RatingWeightTaste} +
+		{Feedback.RatingQuality} * {Outlet.RatingWeightQuality} +
+		{Feedback.RatingQuantity} * {Outlet.RatingWeightQuantity}`
+
+	// addRating counts a new feedback in both windows. The SET expressions
+	// read the values from before the update.
+	addRating = sql(`UPDATE {Product} SET
+		{Product:RatingSumLifetime} = {Product.RatingSumLifetime} + weighed.score,
+		{Product:RatingCountLifetime} = {Product.RatingCountLifetime} + 1,
+		{Product:AverageRatingLifetime} = ({Product.RatingSumLifetime} + weighed.score) / ({Product.RatingCountLifetime} + 1),
+		{Product:RatingSum30d} = {Product.RatingSum30d} + weighed.score,
+		{Product:RatingCount30d} = {Product.RatingCount30d} + 1,
+		{Product:TrendScore} = ({Product.RatingSum30d} + weighed.score) / ({Product.RatingCount30d} + 1)
+	FROM (
+		SELECT {Outlet.ID} AS "outletId",
+			@overall * {Outlet.RatingWeightOverall} +
+			@taste * {Outlet.RatingWeightTaste} +
+			@quality * {Outlet.RatingWeightQuality} +
+			@quantity * {Outlet.RatingWeightQuantity} AS score
+		FROM {Outlet}
+	) AS weighed
+	WHERE {Product.ID} = @product AND weighed."outletId" = {Product.OutletID}`)
+
+	rollRatings = sql(`UPDATE {Product} SET
+		{Product:RatingSum30d} = rolled.sum,
+		{Product:RatingCount30d} = rolled.count,
+		{Product:TrendScore} = CASE WHEN rolled.count > 0 THEN rolled.sum / rolled.count ELSE 0 END
+	FROM (
+		SELECT {Product.ID} AS "productId",
+			COALESCE(SUM(` + score + `), 0) AS sum,
+			COUNT({Feedback.ID}) AS count
+		FROM {Product}
+		JOIN {Outlet} ON {Outlet.ID} = {Product.OutletID}
+		LEFT JOIN {Feedback} ON {Feedback.ProductID} = {Product.ID} AND {Feedback.Status} <> @hidden
+			AND {Feedback.CreatedAt} >= @since
+		GROUP BY {Product.ID}
+	) AS rolled
+	WHERE {Product.ID} = rolled."productId"`)
+
+	recomputeRatings = sql(`UPDATE {Product} SET
+		{Product:RatingSum30d} = totals.sum30d,
+		{Product:RatingCount30d} = totals.count30d,
+		{Product:TrendScore} = CASE WHEN totals.count30d > 0 THEN totals.sum30d / totals.count30d ELSE 0 END,
+		{Product:RatingSumLifetime} = totals.sum,
+		{Product:RatingCountLifetime} = totals.count,
+		{Product:AverageRatingLifetime} = CASE WHEN totals.count > 0 THEN totals.sum / totals.count ELSE 0 END
+	FROM (
+		SELECT {Product.ID} AS "productId",
+			COALESCE(SUM(` + score + `) FILTER (WHERE {Feedback.CreatedAt} >= @since), 0) AS sum30d,
+			COUNT({Feedback.ID}) FILTER (WHERE {Feedback.CreatedAt} >= @since) AS count30d,
+			COALESCE(SUM(` + score + `), 0) AS sum,
+			COUNT({Feedback.ID}) AS count
+		FROM {Product}
+		JOIN {Outlet} ON {Outlet.ID} = {Product.OutletID}
+		LEFT JOIN {Feedback} ON {Feedback.ProductID} = {Product.ID} AND {Feedback.Status} <> @hidden
+		WHERE @all OR {Product.ID} IN @products
+		GROUP BY {Product.ID}
+	) AS totals
+	WHERE {Product.ID} = totals."productId"`)
+)
+
+// ProductRepo queries products and the feedback left on them
+type ProductRepo struct {
+	db *gorm.DB
+}
+
+// Products returns the product queries run on db
+func Products(db *gorm.DB) ProductRepo {
+	return ProductRepo{db: db}
+}
+
+// ForOutlet returns the products an outlet sells
+func (r ProductRepo) ForOutlet(outletID int) ([]models.Product, error) {
+	var products []models.Product
+	err := r.db.Where(productOutlet, outletID).Find(&products).Error
+	return products, err
+}
+
+// AddRating adds one feedback to the aggregates of its product, weighed
+// with the outlet's rating weights
+func (r ProductRepo) AddRating(f models.Feedback) error {
+	return r.db.Exec(addRating, map[string]interface{}{
+		"product":  f.ProductID,
+		"overall":  f.RatingOverall,
+		"taste":    f.RatingTaste,
+		"quality":  f.RatingQuality,
+		"quantity": f.RatingQuantity,
+	}).Error
+}
+
+// RollRatings recalculates the 30-day aggregates of every product from the
+// feedback left since the given time, so old feedback drops out of them.
+// Like RecomputeRatings it leaves out hidden feedback.
+func (r ProductRepo) RollRatings(since time.Time) (int64, error) {
+	result := r.db.Exec(rollRatings, map[string]interface{}{
+		"since":  since,
+		"hidden": models.FeedbackStatusHidden,
+	})
+	return result.RowsAffected, result.Error
+}
+
+// RecomputeRatings recalculates the 30-day and lifetime aggregates of the
+// given products, or of every product when none is given
+func (r ProductRepo) RecomputeRatings(since time.Time, productIDs ...int) (int64, error) {
+	result := r.db.Exec(recomputeRatings, map[string]interface{}{
+		"since":    since,
+		"hidden":   models.FeedbackStatusHidden,
+		"all":      len(productIDs) == 0,
+		"products": nonEmpty(productIDs),
+	})
+	return result.RowsAffected, result.Error
+}
+
+// OrderFeedback returns the feedback already left on the given products of
+// an order
+func (r ProductRepo) OrderFeedback(orderID int, productIDs []int) ([]models.Feedback, error) {
+	var feedback []models.Feedback
+	err := r.db.Where(feedbackForOrder, orderID, productIDs).Find(&feedback).Error
+	return feedback, err
+}
+
+// nonEmpty returns ids, or a list matching no row in place of an empty one,
+// which IN cannot take
+func nonEmpty(ids []int) []int {
+	if len(ids) == 0 {
+		return []int{0}
+	}
+	return ids
+}
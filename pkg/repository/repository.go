@@ -0,0 +1,84 @@
+// Package repository holds the typed queries of the core aggregates: users,
+// orders, inventory, wallets and products.
+//
+// Column and table names are never written out by hand. Each query is built
+// from a template such as `{Order.OutletID} = ?` whose placeholders name a
+// model and a Go field; the template is resolved from the model's GORM schema
+// when the package is initialized, so a misspelt or renamed field panics on
+// start-up instead of silently matching nothing against the database.
+//
+// A repository wraps a *gorm.DB, which may be a transaction or carry
+// Preload, Limit and Offset clauses added by the caller:
+//
+//	orders, err := repository.Orders(db.Preload("Items.Product").Limit(10)).List(filter)
+package repository
+
+import (
+	"backend_pandhi/pkg/models"
+	"fmt"
+	"regexp"
+	"strconv"
+	"sync"
+
+	"gorm.io/gorm/schema"
+)
+
+// tables are the models templates may refer to, by Go type name
+var tables = map[string]interface{}{
+	"Outlet":            &models.Outlet{},
+	"User":              &models.User{},
+	"CustomerDetails":   &models.CustomerDetails{},
+	"StaffDetails":      &models.StaffDetails{},
+	"StaffPermission":   &models.StaffPermission{},
+	"UserFreeQuota":     &models.UserFreeQuota{},
+	"Order":             &models.Order{},
+	"OrderItem":         &models.OrderItem{},
+	"Inventory":         &models.Inventory{},
+	"Wallet":            &models.Wallet{},
+	"WalletTransaction": &models.WalletTransaction{},
+	"Product":           &models.Product{},
+	"Feedback":          &models.Feedback{},
+}
+
+var schemaCache = &sync.Map{}
+
+func parse(model string) *schema.Schema {
+	m, ok := tables[model]
+	if !ok {
+		panic(fmt.Sprintf("repository: unknown model %q", model))
+	}
+	s, err := schema.Parse(m, schemaCache, schema.NamingStrategy{})
+	if err != nil {
+		panic(fmt.Sprintf("repository: parse %s: %v", model, err))
+	}
+	return s
+}
+
+// column returns the bare column name of model.field, for the keys of
+// Update and Updates
+func column(model, field string) string {
+	f := parse(model).LookUpField(field)
+	if f == nil || f.DBName == "" {
+		panic(fmt.Sprintf("repository: %s has no column for field %q", model, field))
+	}
+	return f.DBName
+}
+
+var placeholder = regexp.MustCompile(`\{(\w+)(?:([.:])(\w+))?\}`)
+
+// sql resolves the placeholders of a query template: {Model} becomes the
+// quoted table name, {Model.Field} the quoted, table-qualified column and
+// {Model:Field} the bare quoted column, for the targets of UPDATE ... SET
+func sql(template string) string {
+	return placeholder.ReplaceAllStringFunc(template, func(match string) string {
+		m := placeholder.FindStringSubmatch(match)
+		table := strconv.Quote(parse(m[1]).Table)
+		switch m[2] {
+		case "":
+			return table
+		case ":":
+			return strconv.Quote(column(m[1], m[3]))
+		}
+		return table + "." + strconv.Quote(column(m[1], m[3]))
+	})
+}
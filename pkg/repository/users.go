@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"backend_pandhi/pkg/models"
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	customerByUser     = sql(`{CustomerDetails.UserID} = ?`)
+	staffByUser        = sql(`{StaffDetails.UserID} = ?`)
+	grantedPermission  = sql(`{StaffPermission.Type} = ? AND {StaffPermission.IsGranted} = ?`)
+	permissionOfStaff  = sql(`{StaffPermission.StaffID} = ? AND {StaffPermission.Type} = ?`)
+	permissionsByStaff = sql(`{StaffPermission.StaffID} = ?`)
+	quotaForDay        = sql(`{UserFreeQuota.UserID} = ? AND {UserFreeQuota.ConsumptionDate} = ?`)
+	addQuota           = sql(`{UserFreeQuota.QuantityUsed} + ?`)
+	newCustomers       = sql(`{User.OutletID} = ? AND {User.Role} = ? AND {User.CreatedAt} >= ? AND {User.CreatedAt} <= ?`)
+	byCreatedAt        = sql(`{User.CreatedAt}`)
+	staffAccounts      = sql(`{User.Role} = ? AND {User.IsVerified} = ?`)
+)
+
+// UserRepo queries users and their customer and staff details
+type UserRepo struct {
+	db *gorm.DB
+}
+
+// Users returns the user queries run on db
+func Users(db *gorm.DB) UserRepo {
+	return UserRepo{db: db}
+}
+
+// CountCustomers returns how many customer accounts there are
+func (r UserRepo) CountCustomers() (int64, error) {
+	var n int64
+	err := r.db.Model(&models.CustomerDetails{}).Count(&n).Error
+	return n, err
+}
+
+// StaffAccounts returns the staff users that are verified or not as a query,
+// for callers that paginate or otherwise refine it
+func (r UserRepo) StaffAccounts(verified bool) *gorm.DB {
+	return r.db.Model(&models.User{}).Where(staffAccounts, models.RoleStaff, verified)
+}
+
+// Customer returns the customer details of a user
+func (r UserRepo) Customer(userID int) (models.CustomerDetails, error) {
+	var customer models.CustomerDetails
+	err := r.db.Where(customerByUser, userID).First(&customer).Error
+	return customer, err
+}
+
+// Staff returns the staff details of a user
+func (r UserRepo) Staff(userID int) (models.StaffDetails, error) {
+	var staff models.StaffDetails
+	err := r.db.Where(staffByUser, userID).First(&staff).Error
+	return staff, err
+}
+
+// StaffWithPermission returns the staff details of a user with Permissions
+// holding perm when it is granted, and empty otherwise
+func (r UserRepo) StaffWithPermission(userID int, perm models.PermissionType) (models.StaffDetails, error) {
+	var staff models.StaffDetails
+	err := r.db.Preload("Permissions", grantedPermission, perm, true).
+		Where(staffByUser, userID).
+		First(&staff).Error
+	return staff, err
+}
+
+// SetImageURL stores the profile image of user; nil clears it
+func (r UserRepo) SetImageURL(user *models.User, url *string) error {
+	return r.db.Model(user).Update(column("User", "ImageURL"), url).Error
+}
+
+// SetStaffRole changes the role shown for a staff member
+func (r UserRepo) SetStaffRole(staff *models.StaffDetails, role string) error {
+	return r.db.Model(staff).Update(column("StaffDetails", "StaffRole"), role).Error
+}
+
+// SetTwoFactorSecret stores a TOTP secret that is not enabled yet
+func (r UserRepo) SetTwoFactorSecret(staff *models.StaffDetails, secret string) error {
+	return r.db.Model(staff).Update(column("StaffDetails", "TwoFactorSecret"), secret).Error
+}
+
+// EnableTwoFactor turns on two-factor authentication with the given backup
+// codes
+func (r UserRepo) EnableTwoFactor(staff *models.StaffDetails, backupCodes []string, at time.Time) error {
+	codes, err := json.Marshal(backupCodes)
+	if err != nil {
+		return err
+	}
+	return r.db.Model(staff).Updates(map[string]interface{}{
+		column("StaffDetails", "TwoFactorEnabled"):     true,
+		column("StaffDetails", "TwoFactorEnabledAt"):   at,
+		column("StaffDetails", "TwoFactorBackupCodes"): string(codes),
+	}).Error
+}
+
+// DisableTwoFactor turns off two-factor authentication and forgets the
+// secret and backup codes
+func (r UserRepo) DisableTwoFactor(staff *models.StaffDetails) error {
+	return r.db.Model(staff).Updates(map[string]interface{}{
+		column("StaffDetails", "TwoFactorEnabled"):     false,
+		column("StaffDetails", "TwoFactorSecret"):      nil,
+		column("StaffDetails", "TwoFactorBackupCodes"): nil,
+		column("StaffDetails", "TwoFactorEnabledAt"):   nil,
+	}).Error
+}
+
+// StaffPermission returns one permission row of a staff member
+func (r UserRepo) StaffPermission(staffID int, perm models.PermissionType) (models.StaffPermission, error) {
+	var permission models.StaffPermission
+	err := r.db.Where(permissionOfStaff, staffID, perm).First(&permission).Error
+	return permission, err
+}
+
+// SetPermissionGranted grants or revokes an existing permission row
+func (r UserRepo) SetPermissionGranted(permission *models.StaffPermission, granted bool) error {
+	return r.db.Model(permission).Update(column("StaffPermission", "IsGranted"), granted).Error
+}
+
+// DeleteStaffPermissions removes every permission row of a staff member
+func (r UserRepo) DeleteStaffPermissions(staffID int) error {
+	return r.db.Where(permissionsByStaff, staffID).Delete(&models.StaffPermission{}).Error
+}
+
+// FreeQuota returns the free-item quota a user has used on day
+func (r UserRepo) FreeQuota(userID int, day time.Time) (models.UserFreeQuota, error) {
+	var quota models.UserFreeQuota
+	err := r.db.Where(quotaForDay, userID, day).First(&quota).Error
+	return quota, err
+}
+
+// AddFreeQuota changes the used quota by delta, which may be negative
+func (r UserRepo) AddFreeQuota(quota *models.UserFreeQuota, delta int) error {
+	if err := r.db.Model(quota).
+		Update(column("UserFreeQuota", "QuantityUsed"), gorm.Expr(addQuota, delta)).Error; err != nil {
+		return err
+	}
+	quota.QuantityUsed += delta
+	return nil
+}
+
+// NewCustomers returns the customers of an outlet who signed up between from
+// and to, oldest first
+func (r UserRepo) NewCustomers(outletID int, from, to time.Time) ([]models.User, error) {
+	var users []models.User
+	err := r.db.Where(newCustomers, outletID, models.RoleCustomer, from, to).
+		Order(byCreatedAt).
+		Find(&users).Error
+	return users, err
+}
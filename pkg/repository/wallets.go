@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"backend_pandhi/pkg/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	walletOfCustomer   = sql(`{Wallet.CustomerID} = ?`)
+	addToBalance       = sql(`{Wallet.Balance} + ?`)
+	addToRecharged     = sql(`{Wallet.TotalRecharged} + ?`)
+	addToUsed          = sql(`{Wallet.TotalUsed} + ?`)
+	transactionPayment = sql(`{WalletTransaction.RazorpayPaymentID} = ?`)
+	walletTransactions = sql(`{WalletTransaction.WalletID} = ?`)
+	transactionStatus  = sql(`{WalletTransaction.Status} IN ?`)
+	newestTransaction  = sql(`{WalletTransaction.CreatedAt} DESC`)
+
+	joinCustomerOfWallet = sql(`JOIN {CustomerDetails} ON {CustomerDetails.ID} = {Wallet.CustomerID}`)
+	joinUserOfCustomer   = sql(`JOIN {User} ON {User.ID} = {CustomerDetails.UserID}`)
+	joinWalletOfTrans    = sql(`JOIN {Wallet} ON {Wallet.ID} = {WalletTransaction.WalletID}`)
+	userOutlet           = sql(`{User.OutletID} = ?`)
+	totalRecharged       = sql(`COALESCE(SUM({Wallet.TotalRecharged}), 0)`)
+	outletTransactions   = sql(`{WalletTransaction.Status} = ? AND {WalletTransaction.CreatedAt} >= ? AND {WalletTransaction.CreatedAt} <= ?`)
+
+	ledgerColumns    = sql(`{Wallet.ID} AS wallet_id, {Wallet.Balance} AS balance, COALESCE(SUM({WalletTransaction.Amount}), 0) AS ledger`)
+	joinTransactions = sql(`LEFT JOIN {WalletTransaction} ON {WalletTransaction.WalletID} = {Wallet.ID}`)
+	groupByWallet    = sql(`{Wallet.ID}`)
+	walletByID       = sql(`{Wallet.ID} = ?`)
+
+	transactionsBetween = sql(`{WalletTransaction.Status} = ? AND {WalletTransaction.CreatedAt} >= ? AND {WalletTransaction.CreatedAt} <= ?`)
+	transactionTotal    = sql(`COALESCE(SUM({WalletTransaction.Amount}), 0)`)
+)
+
+// Ledger compares the stored balance of a wallet with the sum of its
+// transactions. Transaction amounts are signed, so the two are equal for a
+// consistent wallet.
+type Ledger struct {
+	WalletID int
+	Balance  float64
+	Ledger   float64
+}
+
+// WalletRepo queries customer wallets and their transactions
+type WalletRepo struct {
+	db *gorm.DB
+}
+
+// Wallets returns the wallet queries run on db
+func Wallets(db *gorm.DB) WalletRepo {
+	return WalletRepo{db: db}
+}
+
+// ForCustomer returns the wallet of a customer
+func (r WalletRepo) ForCustomer(customerID int) (models.Wallet, error) {
+	var wallet models.Wallet
+	err := r.db.Where(walletOfCustomer, customerID).First(&wallet).Error
+	return wallet, err
+}
+
+// Recharge adds amount to the balance and the recharged total. The columns
+// are incremented in SQL, so concurrent recharges are not lost.
+func (r WalletRepo) Recharge(wallet *models.Wallet, amount float64, at time.Time) error {
+	if err := r.db.Model(wallet).Updates(map[string]interface{}{
+		column("Wallet", "Balance"):        gorm.Expr(addToBalance, amount),
+		column("Wallet", "TotalRecharged"): gorm.Expr(addToRecharged, amount),
+		column("Wallet", "LastRecharged"):  at,
+	}).Error; err != nil {
+		return err
+	}
+	wallet.Balance += amount
+	wallet.TotalRecharged += amount
+	wallet.LastRecharged = &at
+	return nil
+}
+
+// Debit takes an order payment from the balance and adds it to the used
+// total
+func (r WalletRepo) Debit(wallet *models.Wallet, amount float64, at time.Time) error {
+	if err := r.db.Model(wallet).Updates(map[string]interface{}{
+		column("Wallet", "Balance"):   gorm.Expr(addToBalance, -amount),
+		column("Wallet", "TotalUsed"): gorm.Expr(addToUsed, amount),
+		column("Wallet", "LastOrder"): at,
+	}).Error; err != nil {
+		return err
+	}
+	wallet.Balance -= amount
+	wallet.TotalUsed += amount
+	wallet.LastOrder = &at
+	return nil
+}
+
+// Refund credits amount back to the balance
+func (r WalletRepo) Refund(wallet *models.Wallet, amount float64) error {
+	if err := r.db.Model(wallet).
+		Update(column("Wallet", "Balance"), gorm.Expr(addToBalance, amount)).Error; err != nil {
+		return err
+	}
+	wallet.Balance += amount
+	return nil
+}
+
+// TransactionByPaymentID returns the transaction recorded for a Razorpay
+// payment
+func (r WalletRepo) TransactionByPaymentID(paymentID string) (models.WalletTransaction, error) {
+	var transaction models.WalletTransaction
+	err := r.db.Where(transactionPayment, paymentID).First(&transaction).Error
+	return transaction, err
+}
+
+// Transactions returns the transactions of a wallet, newest first, limited to
+// the given statuses when there are any
+func (r WalletRepo) Transactions(walletID int, statuses ...models.WalletTransType) ([]models.WalletTransaction, error) {
+	var transactions []models.WalletTransaction
+	err := r.TransactionQuery(walletID, statuses...).Order(newestTransaction).Find(&transactions).Error
+	return transactions, err
+}
+
+// TransactionQuery returns the transactions of a wallet as an unordered
+// query, for callers that paginate it
+func (r WalletRepo) TransactionQuery(walletID int, statuses ...models.WalletTransType) *gorm.DB {
+	db := r.db.Model(&models.WalletTransaction{}).Where(walletTransactions, walletID)
+	if len(statuses) > 0 {
+		db = db.Where(transactionStatus, statuses)
+	}
+	return db
+}
+
+// TotalRechargedForOutlet returns the amount ever recharged into the wallets
+// of an outlet's customers
+func (r WalletRepo) TotalRechargedForOutlet(outletID int) (float64, error) {
+	var total float64
+	err := r.db.Model(&models.Wallet{}).
+		Select(totalRecharged).
+		Joins(joinCustomerOfWallet).
+		Joins(joinUserOfCustomer).
+		Where(userOutlet, outletID).
+		Scan(&total).Error
+	return total, err
+}
+
+// OutletTransactions returns the transactions with the given status made
+// between from and to by customers of an outlet
+func (r WalletRepo) OutletTransactions(outletID int, status models.WalletTransType, from, to time.Time) ([]models.WalletTransaction, error) {
+	var transactions []models.WalletTransaction
+	err := r.db.Joins(joinWalletOfTrans).
+		Joins(joinCustomerOfWallet).
+		Joins(joinUserOfCustomer).
+		Where(userOutlet, outletID).
+		Where(outletTransactions, status, from, to).
+		Find(&transactions).Error
+	return transactions, err
+}
+
+// TransactionTotal returns the sum of the transactions with the given
+// status made between from and to, in every wallet
+func (r WalletRepo) TransactionTotal(status models.WalletTransType, from, to time.Time) (float64, error) {
+	var total float64
+	err := r.db.Model(&models.WalletTransaction{}).
+		Where(transactionsBetween, status, from, to).
+		Select(transactionTotal).
+		Scan(&total).Error
+	return total, err
+}
+
+// Ledgers returns the stored balance and transaction total of every wallet,
+// ordered by wallet
+func (r WalletRepo) Ledgers() ([]Ledger, error) {
+	var ledgers []Ledger
+	err := r.db.Model(&models.Wallet{}).
+		Select(ledgerColumns).
+		Joins(joinTransactions).
+		Group(groupByWallet).
+		Order(groupByWallet).
+		Scan(&ledgers).Error
+	return ledgers, err
+}
+
+// SetBalance overwrites the stored balance of a wallet
+func (r WalletRepo) SetBalance(walletID int, balance float64) error {
+	return r.db.Model(&models.Wallet{}).Where(walletByID, walletID).
+		Update(column("Wallet", "Balance"), balance).Error
+}
@@ -1,35 +1,75 @@
 package routes
 
 import (
+	"backend_pandhi/pkg/app"
 	"backend_pandhi/pkg/controllers/auth"
+	"backend_pandhi/pkg/dto"
 	"backend_pandhi/pkg/middleware"
+	"backend_pandhi/pkg/openapi"
+	"net/http"
 
 	"github.com/gin-gonic/gin"
 )
 
 // RegisterAuthRoutes registers all authentication routes
-func RegisterAuthRoutes(router *gin.RouterGroup) {
+func RegisterAuthRoutes(router *gin.RouterGroup, a *app.App, limiter *middleware.RateLimiter) {
+	ctrl := auth.New(a)
+
 	authGroup := router.Group("/auth")
 	{
 		// Customer auth
-		authGroup.POST("/signup", auth.CustomerSignup)
-		authGroup.POST("/signin", auth.CustomerSignIn)
+		authGroup.POST("/signup", limiter.Limit("signup"), ctrl.CustomerSignup)
+		authGroup.POST("/signin", limiter.Limit("auth"), ctrl.CustomerSignIn)
+
+		// Customer phone OTP login
+		authGroup.POST("/otp/request", limiter.Limit("auth"), ctrl.RequestLoginOTP)
+		authGroup.POST("/otp/verify", limiter.Limit("auth"), ctrl.VerifyLoginOTP)
+
+		// Customer Google sign-in
+		authGroup.POST("/google", limiter.Limit("auth"), ctrl.CustomerGoogleSignIn)
 
 		// Staff auth
-		authGroup.POST("/staff-signup", auth.StaffSignup) // TODO: Add uploadDocuments middleware
-		authGroup.POST("/staff-signin", auth.StaffSignIn)
+		authGroup.POST("/staff-signup", limiter.Limit("signup"), ctrl.StaffSignup) // TODO: Add uploadDocuments middleware
+		authGroup.POST("/staff-signin", limiter.Limit("auth"), ctrl.StaffSignIn)
 
 		// Admin auth
-		authGroup.POST("/admin-signup", auth.AdminSignup) // TODO: Add uploadDocuments middleware
-		authGroup.POST("/admin-signin", auth.AdminSignIn)
+		authGroup.POST("/admin-signup", limiter.Limit("signup"), ctrl.AdminSignup) // TODO: Add uploadDocuments middleware
+		authGroup.POST("/admin-signin", limiter.Limit("auth"), ctrl.AdminSignIn)
 
 		// SuperAdmin auth
-		authGroup.POST("/superadmin-signin", auth.SuperAdminSignIn)
+		authGroup.POST("/superadmin-signin", limiter.Limit("auth"), ctrl.SuperAdminSignIn)
+
+		// Password reset
+		authGroup.POST("/password-reset/request", limiter.Limit("auth"), ctrl.RequestPasswordReset)
+		authGroup.POST("/password-reset/confirm", limiter.Limit("auth"), ctrl.ConfirmPasswordReset)
+
+		// Email verification
+		authGroup.POST("/verify-email/confirm", ctrl.ConfirmEmailVerification)
+		authGroup.POST("/verify-email/resend", limiter.Limit("auth"), ctrl.ResendEmailVerification)
 
 		// Protected routes
-		authGroup.GET("/me", middleware.AuthenticateToken(), auth.CheckAuth)
+		authGroup.GET("/me", middleware.AuthenticateToken(a), ctrl.CheckAuth)
 
 		// Sign out
-		authGroup.POST("/signout", auth.SignOut)
+		authGroup.POST("/signout", ctrl.SignOut)
 	}
 }
+
+var authOperations = []openapi.Operation{
+	{Method: http.MethodPost, Path: "/api/auth/signup", Tag: "Auth", Summary: "Sign up as a customer", Body: dto.CustomerSignupRequest{}, Response: dto.CustomerLoginResponse{}, Status: http.StatusCreated},
+	{Method: http.MethodPost, Path: "/api/auth/signin", Tag: "Auth", Summary: "Sign in as a customer", Body: dto.SignInRequest{}, Response: dto.CustomerLoginResponse{}},
+	{Method: http.MethodPost, Path: "/api/auth/otp/request", Tag: "Auth", Summary: "Send a login code by SMS", Body: dto.LoginOTPRequest{}, Response: dto.LoginOTPResponse{}},
+	{Method: http.MethodPost, Path: "/api/auth/otp/verify", Tag: "Auth", Summary: "Sign in as a customer with a login code", Body: dto.VerifyLoginOTPRequest{}, Response: dto.CustomerLoginResponse{}},
+	{Method: http.MethodPost, Path: "/api/auth/google", Tag: "Auth", Summary: "Sign in as a customer with Google", Description: "Links an existing verified customer with the same email, or creates the customer account on first sign-in. Returns 409 if the email belongs to an unverified account.", Body: dto.GoogleSignInRequest{}, Response: dto.CustomerLoginResponse{}},
+	{Method: http.MethodPost, Path: "/api/auth/staff-signup", Tag: "Auth", Summary: "Sign up as staff", Description: "The account stays unverified until a superadmin approves it.", Body: dto.SignupRequest{}, Response: dto.StaffSignupResponse{}, Status: http.StatusCreated},
+	{Method: http.MethodPost, Path: "/api/auth/staff-signin", Tag: "Auth", Summary: "Sign in as staff", Body: dto.SignInRequest{}, Response: dto.StaffLoginResponse{}},
+	{Method: http.MethodPost, Path: "/api/auth/admin-signup", Tag: "Auth", Summary: "Sign up as an admin", Description: "The account stays unverified until a superadmin approves it.", Body: dto.SignupRequest{}, Response: dto.AdminSignupResponse{}, Status: http.StatusCreated},
+	{Method: http.MethodPost, Path: "/api/auth/admin-signin", Tag: "Auth", Summary: "Sign in as an admin", Body: dto.SignInRequest{}, Response: dto.AdminLoginResponse{}},
+	{Method: http.MethodPost, Path: "/api/auth/superadmin-signin", Tag: "Auth", Summary: "Sign in as a superadmin", Body: dto.SignInRequest{}, Response: dto.SuperAdminLoginResponse{}},
+	{Method: http.MethodPost, Path: "/api/auth/password-reset/request", Tag: "Auth", Summary: "Email a password reset link", Description: "Always succeeds so that registered emails cannot be discovered.", Body: dto.PasswordResetRequest{}, Response: dto.MessageResponse{}},
+	{Method: http.MethodPost, Path: "/api/auth/password-reset/confirm", Tag: "Auth", Summary: "Set a new password with a reset token", Body: dto.PasswordResetConfirmRequest{}, Response: dto.MessageResponse{}},
+	{Method: http.MethodPost, Path: "/api/auth/verify-email/confirm", Tag: "Auth", Summary: "Confirm an email address", Body: dto.EmailVerificationRequest{}, Response: dto.MessageResponse{}},
+	{Method: http.MethodPost, Path: "/api/auth/verify-email/resend", Tag: "Auth", Summary: "Resend the verification email", Body: dto.ResendEmailVerificationRequest{}, Response: dto.MessageResponse{}},
+	{Method: http.MethodGet, Path: "/api/auth/me", Tag: "Auth", Summary: "Get the signed-in account", Roles: anyRole, Response: dto.CurrentUserResponse{}},
+	{Method: http.MethodPost, Path: "/api/auth/signout", Tag: "Auth", Summary: "Sign out", Description: "Clears the token cookie.", Response: dto.MessageResponse{}},
+}
@@ -1,63 +1,102 @@
 package routes
 
 import (
+	"backend_pandhi/pkg/app"
 	"backend_pandhi/pkg/controllers/customer"
+	"backend_pandhi/pkg/dto"
 	"backend_pandhi/pkg/middleware"
+	"backend_pandhi/pkg/openapi"
+	"net/http"
 
 	"github.com/gin-gonic/gin"
 )
 
 // RegisterCustomerRoutes registers all customer-facing API routes
-func RegisterCustomerRoutes(router *gin.RouterGroup) {
+func RegisterCustomerRoutes(router *gin.RouterGroup, a *app.App, limiter *middleware.RateLimiter) {
+	ctrl := customer.New(a)
+
 	customerGroup := router.Group("/customer")
-	customerGroup.Use(middleware.AuthenticateToken(), middleware.AuthorizeRoles("CUSTOMER"))
+	customerGroup.Use(middleware.AuthenticateToken(a), middleware.AuthorizeRoles("CUSTOMER"))
 	{
 		// Products & Home
-		customerGroup.GET("/outlets/get-product/", customer.GetProductsAndStocks)
-		customerGroup.GET("/outlets/get-current-quota", customer.GetCurrentQuota)
-		customerGroup.GET("/outlets/get-appdates/:outletId", customer.GetAvailableDatesAndSlotsForCustomer)
+		customerGroup.GET("/outlets/get-product/", ctrl.GetProductsAndStocks)
+		customerGroup.GET("/outlets/get-current-quota", ctrl.GetCurrentQuota)
+		customerGroup.GET("/outlets/get-appdates/:outletId", ctrl.GetAvailableDatesAndSlotsForCustomer)
 
 		// Cart management
-		customerGroup.PUT("/outlets/update-cart-item", customer.UpdateCartItem)
-		customerGroup.GET("/outlets/get-cart", customer.GetCart)
+		customerGroup.PUT("/outlets/update-cart-item", ctrl.UpdateCartItem)
+		customerGroup.GET("/outlets/get-cart", ctrl.GetCart)
 
 		// Profile management
-		customerGroup.PUT("/outlets/edit-profile", customer.EditProfile) // TODO: Add upload middleware
-		customerGroup.GET("/outlets/get-profile", customer.GetProfile)
+		customerGroup.PUT("/outlets/edit-profile", ctrl.EditProfile) // TODO: Add upload middleware
+		customerGroup.GET("/outlets/get-profile", ctrl.GetProfile)
 
 		// Ticket management
-		customerGroup.POST("/outlets/tickets/create", customer.CreateTicket)
-		customerGroup.GET("/outlets/tickets", customer.GetCustomerTickets)
-		customerGroup.GET("/outlets/tickets/:ticketId", customer.GetTicketDetails)
+		customerGroup.POST("/outlets/tickets/create", ctrl.CreateTicket)
+		customerGroup.GET("/outlets/tickets", ctrl.GetCustomerTickets)
+		customerGroup.GET("/outlets/tickets/:ticketId", ctrl.GetTicketDetails)
 
 		// Coupon management
-		customerGroup.GET("/outlets/coupons/:outletId", customer.GetCoupons)
-		customerGroup.POST("/outlets/apply-coupon", customer.ApplyCoupon)
+		customerGroup.GET("/outlets/coupons/:outletId", ctrl.GetCoupons)
+		customerGroup.POST("/outlets/apply-coupon", limiter.Limit("coupon"), ctrl.ApplyCoupon)
 
 		// Feedback management
-		customerGroup.POST("/feedback/submit", customer.SubmitFeedback)
-		customerGroup.GET("/feedback/pending", customer.GetPendingFeedback)
-		customerGroup.GET("/feedback/order/:orderId", customer.GetFeedbackStatusForOrder)
-		customerGroup.GET("/feedback/product/:productId/reviews", customer.GetProductReviews)
+		customerGroup.POST("/feedback/submit", ctrl.SubmitFeedback)
+		customerGroup.GET("/feedback/pending", ctrl.GetPendingFeedback)
+		customerGroup.GET("/feedback/order/:orderId", ctrl.GetFeedbackStatusForOrder)
+		customerGroup.GET("/feedback/product/:productId/reviews", ctrl.GetProductReviews)
 
 		// Order management
-		customerGroup.POST("/outlets/customer-order/", customer.CustomerAppOrder)                              // STUB - requires quota/inventory/payment integration
-		customerGroup.GET("/outlets/customer-ongoing-order/", customer.CustomerAppOngoingOrderList)
-		customerGroup.GET("/outlets/customer-order-history/", customer.CustomerAppOrderHistory)
-		customerGroup.PUT("/outlets/customer-cancel-order/:orderId", customer.CustomerAppCancelOrder)
-		customerGroup.POST("/outlets/create-razorpay-order", customer.CreateRazorpayOrder)                     // STUB
-		customerGroup.POST("/outlets/verify-razorpay-payment", customer.VerifyRazorpayPayment)                 // STUB
+		customerGroup.POST("/outlets/customer-order/", middleware.RequireVerifiedEmail(a), middleware.Idempotency(a), ctrl.CustomerAppOrder)                              // STUB - requires quota/inventory/payment integration
+		customerGroup.GET("/outlets/customer-ongoing-order/", ctrl.CustomerAppOngoingOrderList)
+		customerGroup.GET("/outlets/customer-order-history/", ctrl.CustomerAppOrderHistory)
+		customerGroup.PUT("/outlets/customer-cancel-order/:orderId", ctrl.CustomerAppCancelOrder)
+		customerGroup.POST("/outlets/create-razorpay-order", limiter.Limit("payment"), ctrl.CreateRazorpayOrder)                     // STUB
+		customerGroup.POST("/outlets/verify-razorpay-payment", ctrl.VerifyRazorpayPayment)                 // STUB
 
 		// Wallet management
-		customerGroup.POST("/outlets/create-wallet-recharge-order", customer.CreateWalletRechargeOrder)
-		customerGroup.POST("/outlets/verify-wallet-recharge", customer.VerifyWalletRecharge)
-		customerGroup.GET("/outlets/get-wallet-details", customer.GetWalletDetails)
-		customerGroup.POST("/outlets/recharge-wallet", customer.RechargeWallet)                        // Legacy cash recharge
-		customerGroup.GET("/outlets/get-recent-recharge", customer.RecentTrans)
-		customerGroup.GET("/outlets/get-recharge-history", customer.GetRechargeHistory)
-		customerGroup.GET("/outlets/service-charge-breakdown", customer.GetServiceChargeBreakdown)
+		customerGroup.POST("/outlets/create-wallet-recharge-order", limiter.Limit("payment"), ctrl.CreateWalletRechargeOrder)
+		customerGroup.POST("/outlets/verify-wallet-recharge", ctrl.VerifyWalletRecharge)
+		customerGroup.GET("/outlets/get-wallet-details", ctrl.GetWalletDetails)
+		customerGroup.POST("/outlets/recharge-wallet", middleware.Idempotency(a), ctrl.RechargeWallet)                        // Legacy cash recharge
+		customerGroup.GET("/outlets/get-recent-recharge", ctrl.RecentTrans)
+		customerGroup.GET("/outlets/get-recharge-history", ctrl.GetRechargeHistory)
+		customerGroup.GET("/outlets/service-charge-breakdown", ctrl.GetServiceChargeBreakdown)
 	}
 
 	// Public customer routes (no auth required)
-	router.GET("/customer/get-outlets/", customer.GetOutlets)
+	router.GET("/customer/get-outlets/", ctrl.GetOutlets)
+}
+
+var customerOperations = []openapi.Operation{
+	{Method: http.MethodGet, Path: "/api/customer/outlets/get-product/", Tag: "Customer", Summary: "List the menu of the customer's outlet", Roles: customerOnly, Response: dto.ProductsResponse{}},
+	{Method: http.MethodGet, Path: "/api/customer/outlets/get-current-quota", Tag: "Customer", Summary: "Get today's company-paid quota", Roles: customerOnly, Response: dto.QuotaResponse{}},
+	{Method: http.MethodGet, Path: "/api/customer/outlets/get-appdates/:outletId", Tag: "Customer", Summary: "List the dates and slots open for orders", Roles: customerOnly, Response: dto.AvailableSlotsResponse{}},
+	{Method: http.MethodPut, Path: "/api/customer/outlets/update-cart-item", Tag: "Customer", Summary: "Add to or remove from the cart", Roles: customerOnly, Body: dto.UpdateCartItemRequest{}, Response: dto.MessageResponse{}},
+	{Method: http.MethodGet, Path: "/api/customer/outlets/get-cart", Tag: "Customer", Summary: "Get the cart", Roles: customerOnly, Response: dto.CartResponse{}},
+	{Method: http.MethodPut, Path: "/api/customer/outlets/edit-profile", Tag: "Customer", Summary: "Edit the profile", Description: "Also accepts multipart form data with an image field.", Roles: customerOnly, Body: dto.EditProfileRequest{}, Response: dto.EditProfileResponse{}},
+	{Method: http.MethodGet, Path: "/api/customer/outlets/get-profile", Tag: "Customer", Summary: "Get the profile", Roles: customerOnly, Response: dto.CustomerProfileResponse{}},
+	{Method: http.MethodPost, Path: "/api/customer/outlets/tickets/create", Tag: "Customer", Summary: "Open a support ticket", Roles: customerOnly, Body: dto.CreateTicketRequest{}, Response: dto.CreateTicketResponse{}, Status: http.StatusCreated},
+	{Method: http.MethodGet, Path: "/api/customer/outlets/tickets", Tag: "Customer", Summary: "List the customer's tickets", Roles: customerOnly, List: &customer.CustomerTicketsList, Response: dto.CustomerTicketsResponse{}},
+	{Method: http.MethodGet, Path: "/api/customer/outlets/tickets/:ticketId", Tag: "Customer", Summary: "Get one of the customer's tickets", Roles: customerOnly, Response: dto.CustomerTicketResponse{}},
+	{Method: http.MethodGet, Path: "/api/customer/outlets/coupons/:outletId", Tag: "Customer", Summary: "List coupons the customer can use", Roles: customerOnly, Response: dto.CouponsResponse{}},
+	{Method: http.MethodPost, Path: "/api/customer/outlets/apply-coupon", Tag: "Customer", Summary: "Preview a coupon discount", Roles: customerOnly, Body: dto.ApplyCouponRequest{}, Response: dto.ApplyCouponResponse{}},
+	{Method: http.MethodPost, Path: "/api/customer/feedback/submit", Tag: "Feedback", Summary: "Rate the products of a delivered order", Roles: customerOnly, Body: dto.SubmitFeedbackRequest{}, Response: dto.SuccessMessageResponse{}, Status: http.StatusCreated},
+	{Method: http.MethodGet, Path: "/api/customer/feedback/pending", Tag: "Feedback", Summary: "List delivered orders waiting for ratings", Roles: customerOnly, Response: dto.PendingFeedbackResponse{}},
+	{Method: http.MethodGet, Path: "/api/customer/feedback/order/:orderId", Tag: "Feedback", Summary: "Get the rating state of an order", Roles: customerOnly, Response: dto.FeedbackStatusResponse{}},
+	{Method: http.MethodGet, Path: "/api/customer/feedback/product/:productId/reviews", Tag: "Feedback", Summary: "List a product's reviews", Roles: customerOnly, List: &customer.ProductReviewsList, Response: dto.ProductReviewsResponse{}},
+	{Method: http.MethodPost, Path: "/api/customer/outlets/customer-order/", Tag: "Orders", Summary: "Place an app order", Description: "Requires a verified email address.", Roles: customerOnly, Body: dto.CreateOrderRequest{}, Response: dto.CreateOrderResponse{}, Status: http.StatusCreated, Idempotent: true},
+	{Method: http.MethodGet, Path: "/api/customer/outlets/customer-ongoing-order/", Tag: "Orders", Summary: "List ongoing orders", Roles: customerOnly, Response: dto.CustomerOrdersResponse{}},
+	{Method: http.MethodGet, Path: "/api/customer/outlets/customer-order-history/", Tag: "Orders", Summary: "List past orders", Roles: customerOnly, List: &customer.CustomerOrderHistoryList, Response: dto.CustomerOrderHistoryResponse{}},
+	{Method: http.MethodPut, Path: "/api/customer/outlets/customer-cancel-order/:orderId", Tag: "Orders", Summary: "Cancel an order", Description: "Restores stock and refunds wallet payments.", Roles: customerOnly, Response: dto.CancelOrderResponse{}},
+	{Method: http.MethodPost, Path: "/api/customer/outlets/create-razorpay-order", Tag: "Payments", Summary: "Create a Razorpay order for checkout", Roles: customerOnly, Body: dto.AmountRequest{}, Response: dto.RazorpayOrderResponse{}},
+	{Method: http.MethodPost, Path: "/api/customer/outlets/verify-razorpay-payment", Tag: "Payments", Summary: "Verify a Razorpay payment signature", Roles: customerOnly, Body: dto.RazorpayPaymentRequest{}, Response: dto.SuccessMessageResponse{}},
+	{Method: http.MethodPost, Path: "/api/customer/outlets/create-wallet-recharge-order", Tag: "Wallet", Summary: "Create a Razorpay order for a wallet top-up", Roles: customerOnly, Body: dto.AmountRequest{}, Response: dto.WalletRechargeOrderResponse{}, Status: http.StatusCreated},
+	{Method: http.MethodPost, Path: "/api/customer/outlets/verify-wallet-recharge", Tag: "Wallet", Summary: "Verify a wallet top-up and credit the wallet", Roles: customerOnly, Body: dto.RazorpayPaymentRequest{}, Response: dto.VerifyWalletRechargeResponse{}},
+	{Method: http.MethodGet, Path: "/api/customer/outlets/get-wallet-details", Tag: "Wallet", Summary: "Get the wallet", Roles: customerOnly, Response: dto.WalletDetailsResponse{}},
+	{Method: http.MethodPost, Path: "/api/customer/outlets/recharge-wallet", Tag: "Wallet", Summary: "Request a cash recharge", Description: "Legacy endpoint; cash recharges are made by staff.", Roles: customerOnly, Body: dto.AmountRequest{}, Response: dto.RechargeWalletResponse{}, Idempotent: true},
+	{Method: http.MethodGet, Path: "/api/customer/outlets/get-recent-recharge", Tag: "Wallet", Summary: "List recent wallet transactions", Roles: customerOnly, Response: dto.RecentTransactionsResponse{}},
+	{Method: http.MethodGet, Path: "/api/customer/outlets/get-recharge-history", Tag: "Wallet", Summary: "List wallet top-ups", Roles: customerOnly, List: &customer.RechargeHistoryList, Response: dto.RechargeHistoryResponse{}},
+	{Method: http.MethodGet, Path: "/api/customer/outlets/service-charge-breakdown", Tag: "Wallet", Summary: "Preview the cost of a wallet top-up", Description: "The amount is read from a JSON body.", Roles: customerOnly, Body: dto.AmountRequest{}, Response: dto.ServiceChargeBreakdown{}},
+	{Method: http.MethodGet, Path: "/api/customer/get-outlets/", Tag: "Customer", Summary: "List outlets", Response: dto.OutletsResponse{}},
 }
@@ -0,0 +1,246 @@
+package routes_test
+
+import (
+	"backend_pandhi/pkg/jobs"
+	"backend_pandhi/pkg/models"
+	"backend_pandhi/pkg/testenv"
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(testenv.Main(m))
+}
+
+func TestCustomerSignup(t *testing.T) {
+	env := testenv.New(t)
+	outlet := env.Outlet(t)
+
+	w := env.Do(t, http.MethodPost, "/api/auth/signup", "", gin.H{
+		"name":           "Asha",
+		"email":          "asha@example.com",
+		"password":       testenv.Password,
+		"retypePassword": testenv.Password,
+		"outletId":       outlet.ID,
+		"phone":          "9876543210",
+	})
+	var resp struct {
+		Token string `json:"token"`
+		User  struct {
+			ID              int  `json:"id"`
+			IsVerified      bool `json:"isVerified"`
+			CustomerDetails struct {
+				Wallet *models.Wallet `json:"wallet"`
+				Cart   *models.Cart   `json:"cart"`
+			} `json:"customerDetails"`
+		} `json:"user"`
+	}
+	testenv.Decode(t, w, http.StatusCreated, &resp)
+
+	if resp.Token == "" {
+		t.Fatal("signup returned no token")
+	}
+	if resp.User.IsVerified {
+		t.Error("new customer is verified before confirming their email")
+	}
+	if resp.User.CustomerDetails.Wallet == nil || resp.User.CustomerDetails.Wallet.Balance != 0 {
+		t.Errorf("wallet = %+v, want an empty wallet", resp.User.CustomerDetails.Wallet)
+	}
+	if resp.User.CustomerDetails.Cart == nil {
+		t.Error("signup created no cart")
+	}
+
+	// The token signs in
+	var me struct {
+		User struct {
+			ID int `json:"id"`
+		} `json:"user"`
+	}
+	testenv.Decode(t, env.Do(t, http.MethodGet, "/api/auth/me", resp.Token, nil), http.StatusOK, &me)
+	if me.User.ID != resp.User.ID {
+		t.Errorf("/me returned user %d, want %d", me.User.ID, resp.User.ID)
+	}
+
+	// The verification email is queued with the account
+	if _, err := jobs.RunDue(context.Background(), env.App); err != nil {
+		t.Fatalf("RunDue: %v", err)
+	}
+	msg, ok := env.Mail.LastMessageTo("asha@example.com")
+	if !ok {
+		t.Fatal("no verification email was sent")
+	}
+	if !strings.Contains(msg.Body, "/verify-email?token=") {
+		t.Errorf("verification email has no link:\n%s", msg.Body)
+	}
+
+	// The same email cannot sign up twice
+	w = env.Do(t, http.MethodPost, "/api/auth/signup", "", gin.H{
+		"name":           "Asha again",
+		"email":          "asha@example.com",
+		"password":       testenv.Password,
+		"retypePassword": testenv.Password,
+		"outletId":       outlet.ID,
+		"phone":          "9876543211",
+	})
+	testenv.Decode(t, w, http.StatusBadRequest, nil)
+}
+
+// placeOrder orders quantity units of product paid from the wallet and
+// returns the order ID
+func placeOrder(t *testing.T, env *testenv.Env, token string, product models.Product, quantity int) int {
+	t.Helper()
+	w := env.Do(t, http.MethodPost, "/api/customer/outlets/customer-order/", token, gin.H{
+		"totalAmount":   product.Price * float64(quantity),
+		"paymentMethod": "WALLET",
+		"deliverySlot":  "SLOT_12_13",
+		"outletId":      product.OutletID,
+		"items": []gin.H{
+			{"productId": product.ID, "quantity": quantity, "unitPrice": product.Price},
+		},
+	})
+	var resp struct {
+		Order struct {
+			ID          int     `json:"id"`
+			TotalAmount float64 `json:"totalAmount"`
+			Status      string  `json:"status"`
+		} `json:"order"`
+	}
+	testenv.Decode(t, w, http.StatusCreated, &resp)
+
+	if resp.Order.TotalAmount != product.Price*float64(quantity) {
+		t.Errorf("order total = %v, want %v", resp.Order.TotalAmount, product.Price*float64(quantity))
+	}
+	if resp.Order.Status != string(models.OrderStatusPending) {
+		t.Errorf("order status = %q, want %q", resp.Order.Status, models.OrderStatusPending)
+	}
+	return resp.Order.ID
+}
+
+func TestPlaceOrderPaidFromWallet(t *testing.T) {
+	env := testenv.New(t)
+	outlet := env.Outlet(t)
+	product := env.Product(t, outlet, 40, 10)
+	customer := env.Customer(t, outlet, 500)
+
+	orderID := placeOrder(t, env, env.Token(t, customer), product, 3)
+
+	wallet := *customer.CustomerInfo.Wallet
+	env.Reload(t, &wallet)
+	if wallet.Balance != 380 || wallet.TotalUsed != 120 {
+		t.Errorf("wallet balance = %v, used = %v; want 380 and 120", wallet.Balance, wallet.TotalUsed)
+	}
+
+	inventory := *product.Inventory
+	env.Reload(t, &inventory)
+	if inventory.Quantity != 7 {
+		t.Errorf("stock = %d, want 7", inventory.Quantity)
+	}
+
+	var items []models.OrderItem
+	if err := env.DB.Where(`"orderId" = ?`, orderID).Find(&items).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || items[0].Quantity != 3 {
+		t.Errorf("order items = %+v, want 3 units of one product", items)
+	}
+
+	var debits int64
+	env.DB.Model(&models.WalletTransaction{}).
+		Where(`"walletId" = ? AND status = ? AND amount = ?`, wallet.ID, models.WalletTransTypeDeduct, -120.0).
+		Count(&debits)
+	if debits != 1 {
+		t.Errorf("found %d wallet debits of 120, want 1", debits)
+	}
+}
+
+func TestPlaceOrderBeyondStockFails(t *testing.T) {
+	env := testenv.New(t)
+	outlet := env.Outlet(t)
+	product := env.Product(t, outlet, 40, 2)
+	customer := env.Customer(t, outlet, 500)
+
+	w := env.Do(t, http.MethodPost, "/api/customer/outlets/customer-order/", env.Token(t, customer), gin.H{
+		"totalAmount":   120,
+		"paymentMethod": "WALLET",
+		"deliverySlot":  "SLOT_12_13",
+		"outletId":      outlet.ID,
+		"items":         []gin.H{{"productId": product.ID, "quantity": 3, "unitPrice": 40}},
+	})
+	if w.Code < 400 {
+		t.Fatalf("status = %d, want an error; body: %s", w.Code, w.Body.String())
+	}
+
+	// Nothing was charged or taken from stock
+	wallet := *customer.CustomerInfo.Wallet
+	env.Reload(t, &wallet)
+	if wallet.Balance != 500 {
+		t.Errorf("wallet balance = %v, want 500", wallet.Balance)
+	}
+	inventory := *product.Inventory
+	env.Reload(t, &inventory)
+	if inventory.Quantity != 2 {
+		t.Errorf("stock = %d, want 2", inventory.Quantity)
+	}
+}
+
+func TestCancelOrderRefundsAndRestocks(t *testing.T) {
+	env := testenv.New(t)
+	outlet := env.Outlet(t)
+	product := env.Product(t, outlet, 25, 10)
+	customer := env.Customer(t, outlet, 200)
+	token := env.Token(t, customer)
+
+	orderID := placeOrder(t, env, token, product, 4)
+
+	w := env.Do(t, http.MethodPut, fmt.Sprintf("/api/customer/outlets/customer-cancel-order/%d", orderID), token, nil)
+	testenv.Decode(t, w, http.StatusOK, nil)
+
+	order := models.Order{ID: orderID}
+	env.Reload(t, &order)
+	if order.Status != string(models.OrderStatusCancelled) {
+		t.Errorf("order status = %q, want %q", order.Status, models.OrderStatusCancelled)
+	}
+
+	wallet := *customer.CustomerInfo.Wallet
+	env.Reload(t, &wallet)
+	if wallet.Balance != 200 {
+		t.Errorf("wallet balance = %v, want the 200 it started with", wallet.Balance)
+	}
+
+	var refunds int64
+	env.DB.Model(&models.WalletTransaction{}).
+		Where(`"walletId" = ? AND status = ? AND amount = ?`, wallet.ID, models.TransactionTypeCredit, 100.0).
+		Count(&refunds)
+	if refunds != 1 {
+		t.Errorf("found %d refunds of 100, want 1", refunds)
+	}
+
+	inventory := *product.Inventory
+	env.Reload(t, &inventory)
+	if inventory.Quantity != 10 {
+		t.Errorf("stock = %d, want the 10 it started with", inventory.Quantity)
+	}
+
+	// A cancelled order cannot be cancelled again
+	w = env.Do(t, http.MethodPut, fmt.Sprintf("/api/customer/outlets/customer-cancel-order/%d", orderID), token, nil)
+	testenv.Decode(t, w, http.StatusBadRequest, nil)
+}
+
+func TestCancelOtherCustomersOrderIsNotFound(t *testing.T) {
+	env := testenv.New(t)
+	outlet := env.Outlet(t)
+	product := env.Product(t, outlet, 25, 10)
+	owner := env.Customer(t, outlet, 200)
+	other := env.Customer(t, outlet, 200)
+
+	orderID := placeOrder(t, env, env.Token(t, owner), product, 1)
+
+	w := env.Do(t, http.MethodPut, fmt.Sprintf("/api/customer/outlets/customer-cancel-order/%d", orderID), env.Token(t, other), nil)
+	testenv.Decode(t, w, http.StatusNotFound, nil)
+}
@@ -0,0 +1,127 @@
+package routes_test
+
+import (
+	"backend_pandhi/pkg/models"
+	"backend_pandhi/pkg/services"
+	"backend_pandhi/pkg/testenv"
+	"crypto/rand"
+	"crypto/rsa"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// googleSignIn serves Google sign-in from a local key and returns a function
+// that signs ID tokens for a Google account
+func googleSignIn(t *testing.T, env *testenv.Env) func(subject, email string) string {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env.App.Google = &services.GoogleTokenVerifier{
+		ClientIDs: []string{"test-client"},
+		Keys:      services.StaticKeySet{"test-key": &key.PublicKey},
+	}
+
+	return func(subject, email string) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+			"iss":            "https://accounts.google.com",
+			"aud":            "test-client",
+			"sub":            subject,
+			"email":          email,
+			"email_verified": true,
+			"name":           "Google User",
+			"exp":            time.Now().Add(time.Hour).Unix(),
+		})
+		token.Header["kid"] = "test-key"
+		signed, err := token.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+}
+
+func TestGoogleSignInLinksAccountByEmail(t *testing.T) {
+	env := testenv.New(t)
+	outlet := env.Outlet(t)
+	customer := env.Customer(t, outlet, 0)
+	if err := env.DB.Model(&customer).Update("isVerified", false).Error; err != nil {
+		t.Fatal(err)
+	}
+	sign := googleSignIn(t, env)
+
+	type signIn struct {
+		User struct {
+			ID int `json:"id"`
+		} `json:"user"`
+	}
+
+	// An unverified account may have been registered by someone else, so
+	// it is not linked
+	w := env.Do(t, http.MethodPost, "/api/auth/google", "", gin.H{"idToken": sign("google-1", customer.Email)})
+	testenv.Decode(t, w, http.StatusConflict, nil)
+	var unlinked models.User
+	env.DB.First(&unlinked, customer.ID)
+	if unlinked.GoogleID != nil {
+		t.Fatalf("unverified user linked to %s", *unlinked.GoogleID)
+	}
+	if err := env.DB.Model(&customer).Update("isVerified", true).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// Once verified, the first sign-in links the Google account to the
+	// customer with that email
+	var first signIn
+	w = env.Do(t, http.MethodPost, "/api/auth/google", "", gin.H{"idToken": sign("google-1", customer.Email)})
+	testenv.Decode(t, w, http.StatusOK, &first)
+	if first.User.ID != customer.ID {
+		t.Fatalf("signed in as user %d, want %d", first.User.ID, customer.ID)
+	}
+	var linked models.User
+	env.DB.First(&linked, customer.ID)
+	if linked.GoogleID == nil || *linked.GoogleID != "google-1" {
+		t.Errorf("user after linking = googleId %v, want google-1", linked.GoogleID)
+	}
+
+	// Later sign-ins match on the Google account
+	var again signIn
+	testenv.Decode(t, env.Do(t, http.MethodPost, "/api/auth/google", "", gin.H{"idToken": sign("google-1", customer.Email)}), http.StatusOK, &again)
+	if again.User.ID != customer.ID {
+		t.Errorf("second sign-in as user %d, want %d", again.User.ID, customer.ID)
+	}
+
+	// Another Google account cannot take over the email
+	w = env.Do(t, http.MethodPost, "/api/auth/google", "", gin.H{"idToken": sign("google-2", customer.Email)})
+	testenv.Decode(t, w, http.StatusConflict, nil)
+
+	// Staff accounts are never linked
+	staff := env.Staff(t, outlet)
+	w = env.Do(t, http.MethodPost, "/api/auth/google", "", gin.H{"idToken": sign("google-3", staff.Email)})
+	testenv.Decode(t, w, http.StatusForbidden, nil)
+
+	// A new email needs an outlet to create the customer
+	w = env.Do(t, http.MethodPost, "/api/auth/google", "", gin.H{"idToken": sign("google-4", "new@example.com")})
+	testenv.Decode(t, w, http.StatusBadRequest, nil)
+	w = env.Do(t, http.MethodPost, "/api/auth/google", "", gin.H{"idToken": sign("google-4", "new@example.com"), "outletId": outlet.ID})
+	testenv.Decode(t, w, http.StatusCreated, nil)
+}
+
+func TestGoogleSignInWithoutKeysIsUnavailable(t *testing.T) {
+	env := testenv.New(t)
+	sign := googleSignIn(t, env)
+
+	jwks := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer jwks.Close()
+	env.App.Google.Keys = services.NewRemoteJWKS(jwks.URL, nil)
+
+	w := env.Do(t, http.MethodPost, "/api/auth/google", "", gin.H{"idToken": sign("google-1", "asha@example.com")})
+	testenv.Decode(t, w, http.StatusServiceUnavailable, nil)
+}
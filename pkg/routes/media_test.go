@@ -0,0 +1,36 @@
+package routes_test
+
+import (
+	"backend_pandhi/pkg/media"
+	"backend_pandhi/pkg/models"
+	"backend_pandhi/pkg/testenv"
+	"net/http"
+	"testing"
+)
+
+func TestRemovedImageIsDeletedInTheBackground(t *testing.T) {
+	env := testenv.New(t)
+	outlet := env.Outlet(t)
+	staff := env.Staff(t, outlet)
+	imageURL := "https://storage.googleapis.com/test-bucket/old.jpg"
+	if err := env.DB.Model(&staff).Update("imageUrl", imageURL).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	w := env.Do(t, http.MethodDelete, "/api/staff/profile/delete-image/", env.Token(t, staff), nil)
+	testenv.Decode(t, w, http.StatusOK, nil)
+
+	var user models.User
+	env.DB.First(&user, staff.ID)
+	if user.ImageURL != nil {
+		t.Errorf("image URL after removal = %q, want none", *user.ImageURL)
+	}
+
+	var job models.Job
+	if err := env.DB.Where("kind = ?", media.DeleteJob.Name).First(&job).Error; err != nil {
+		t.Fatalf("no %s job queued: %v", media.DeleteJob.Name, err)
+	}
+	if job.UniqueKey == nil || *job.UniqueKey != media.DeleteJob.Name+":"+imageURL {
+		t.Errorf("job key = %v, want one for %s", job.UniqueKey, imageURL)
+	}
+}
@@ -0,0 +1,106 @@
+package routes
+
+import (
+	"backend_pandhi/pkg/dto"
+	"backend_pandhi/pkg/models"
+	"backend_pandhi/pkg/openapi"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+const specPath = "/api/openapi.json"
+
+// Roles accepted by the role middleware, as published in the document
+var (
+	anyRole                   = []string{string(models.RoleCustomer), string(models.RoleStaff), string(models.RoleAdmin), string(models.RoleSuperAdmin)}
+	customerOnly              = []string{string(models.RoleCustomer)}
+	staffOnly                 = []string{string(models.RoleStaff)}
+	superAdminOnly            = []string{string(models.RoleSuperAdmin)}
+	superAdminOrAdmin         = []string{string(models.RoleSuperAdmin), string(models.RoleAdmin)}
+	superAdminAdminOrCustomer = []string{string(models.RoleSuperAdmin), string(models.RoleAdmin), string(models.RoleCustomer)}
+)
+
+var rootOperations = []openapi.Operation{
+	{Method: http.MethodGet, Path: "/", Tag: "System", Summary: "Check that the server is running", Response: "", ContentType: "text/plain"},
+	{Method: http.MethodGet, Path: "/api/health", Tag: "System", Summary: "Health summary", Description: "Backed by the readiness checks; 503 when a critical dependency is down.", Response: dto.HealthResponse{}},
+	{Method: http.MethodGet, Path: "/api/health/live", Tag: "System", Summary: "Liveness probe", Response: dto.LivenessResponse{}},
+	{Method: http.MethodGet, Path: "/api/health/ready", Tag: "System", Summary: "Readiness probe", Description: "Checks Postgres (with a timeout and pool saturation), storage, FCM, Razorpay and the notification dispatcher. Returns 503 when a critical dependency is down.", Response: dto.ReadinessResponse{}},
+	{Method: http.MethodGet, Path: specPath, Tag: "System", Summary: "This OpenAPI document", Response: map[string]interface{}{}},
+	{Method: http.MethodGet, Path: "/api/docs", Tag: "System", Summary: "API reference page", Response: "", ContentType: "text/html"},
+	{Method: http.MethodGet, Path: "/metrics", Tag: "System", Summary: "Prometheus metrics", Description: "Requires `Authorization: Bearer <METRICS_TOKEN>`. Returns 404 when no token is configured.", Response: "", ContentType: "text/plain"},
+}
+
+var (
+	specOnce sync.Once
+	spec     *openapi.Registry
+)
+
+// Spec returns the OpenAPI registry describing every route registered by
+// Setup. A route added without a matching operation fails the route tests.
+func Spec() *openapi.Registry {
+	specOnce.Do(func() {
+		spec = openapi.New("UPS API", "Ordering, wallet and outlet management API for the UPS canteen apps.")
+
+		openapi.Enum(spec, models.TypeOfDegreeUG, models.TypeOfDegreePG)
+		openapi.Enum(spec, models.OrderTypeManual, models.OrderTypeApp)
+		openapi.Enum(spec, models.OrderItemStatusNotDelivered, models.OrderItemStatusDelivered)
+		openapi.Enum(spec, models.RoleCustomer, models.RoleStaff, models.RoleSuperAdmin, models.RoleAdmin)
+		openapi.Enum(spec,
+			models.AdminPermissionOrderManagement,
+			models.AdminPermissionStaffManagement,
+			models.AdminPermissionInventoryManagement,
+			models.AdminPermissionExpenditureManagement,
+			models.AdminPermissionWalletManagement,
+			models.AdminPermissionCustomerManagement,
+			models.AdminPermissionTicketManagement,
+			models.AdminPermissionNotificationsManagement,
+			models.AdminPermissionProductManagement,
+			models.AdminPermissionAppManagement,
+			models.AdminPermissionReportsAnalytics,
+			models.AdminPermissionSettings,
+			models.AdminPermissionOnboarding,
+			models.AdminPermissionAdminManagement,
+		)
+		openapi.Enum(spec, models.PermissionTypeBilling, models.PermissionTypeProductInsights, models.PermissionTypeReports, models.PermissionTypeInventory)
+		openapi.Enum(spec, models.PaymentMethodUPI, models.PaymentMethodCard, models.PaymentMethodCash, models.PaymentMethodWallet)
+		openapi.Enum(spec,
+			models.OrderStatusPending,
+			models.OrderStatusDelivered,
+			models.OrderStatusPartiallyDelivered,
+			models.OrderStatusCancelled,
+			models.OrderStatusPartialCancel,
+		)
+		openapi.Enum(spec, models.PriorityLow, models.PriorityMedium, models.PriorityHigh)
+		openapi.Enum(spec, models.CategoryMeals, models.CategoryStarters, models.CategoryDesserts, models.CategoryBeverages, models.CategorySpecialFoods)
+		openapi.Enum(spec, models.TicketStatusOpen, models.TicketStatusInProgress, models.TicketStatusClosed)
+		openapi.Enum(spec, models.StockActionAdd, models.StockActionRemove, models.StockActionUpdate)
+		openapi.Enum(spec, models.WalletTransTypeRecharge, models.WalletTransTypeDeduct, models.TransactionTypeCredit, models.TransactionTypeDebit)
+		openapi.Enum(spec,
+			models.DeliverySlot1112,
+			models.DeliverySlot1213,
+			models.DeliverySlot1314,
+			models.DeliverySlot1415,
+			models.DeliverySlot1516,
+			models.DeliverySlot1617,
+		)
+		openapi.Enum(spec, models.OutletAppFeatureApp, models.OutletAppFeatureUPI, models.OutletAppFeatureLiveCounter, models.OutletAppFeatureCoupons)
+		openapi.Enum(spec, models.NotificationStatusPending, models.NotificationStatusSent, models.NotificationStatusFailed, models.NotificationStatusDelivered)
+		openapi.Enum(spec, models.AuthAccountTypeUser, models.AuthAccountTypeAdmin)
+		openapi.Enum(spec, models.LoginThrottleScopeAccount, models.LoginThrottleScopeIP)
+
+		spec.Add(rootOperations...)
+		spec.Add(authOperations...)
+		spec.Add(customerOperations...)
+		spec.Add(staffOperations...)
+		spec.Add(superAdminOperations...)
+	})
+	return spec
+}
+
+// RegisterDocsRoutes serves the OpenAPI document and a reference page for it
+func RegisterDocsRoutes(router *gin.RouterGroup) {
+	router.GET("/openapi.json", Spec().Handler())
+	router.GET("/docs", openapi.DocsHandler(specPath))
+}
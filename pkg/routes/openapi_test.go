@@ -0,0 +1,66 @@
+package routes
+
+import (
+	"backend_pandhi/pkg/app"
+	"backend_pandhi/pkg/config"
+	"backend_pandhi/pkg/middleware"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newTestRouter registers the routes of an app with no database or services;
+// only the route table is inspected
+func newTestRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	a := &app.App{Config: &config.Config{}, Clock: app.SystemClock{}}
+	limiter, err := middleware.NewRateLimiter(a)
+	if err != nil {
+		panic(err)
+	}
+	router := gin.New()
+	Setup(router, a, limiter)
+	return router
+}
+
+func TestEveryRouteIsDocumented(t *testing.T) {
+	for _, r := range newTestRouter().Routes() {
+		if _, ok := Spec().Lookup(r.Method, r.Path); !ok {
+			t.Errorf("%s %s has no entry in the OpenAPI spec; add one next to its registration", r.Method, r.Path)
+		}
+	}
+}
+
+func TestEveryDocumentedOperationIsRouted(t *testing.T) {
+	routed := make(map[string]bool)
+	for _, r := range newTestRouter().Routes() {
+		routed[r.Method+" "+r.Path] = true
+	}
+	for _, op := range Spec().Operations() {
+		if !routed[op.Method+" "+op.Path] {
+			t.Errorf("OpenAPI spec documents %s %s but no such route is registered", op.Method, op.Path)
+		}
+	}
+}
+
+func TestSpecIsServed(t *testing.T) {
+	w := httptest.NewRecorder()
+	newTestRouter().ServeHTTP(w, httptest.NewRequest(http.MethodGet, specPath, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET %s returned %d", specPath, w.Code)
+	}
+
+	var doc struct {
+		OpenAPI string                     `json:"openapi"`
+		Paths   map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("spec is not valid JSON: %v", err)
+	}
+	if doc.OpenAPI == "" || len(doc.Paths) == 0 {
+		t.Fatalf("spec is missing the openapi version or paths")
+	}
+}
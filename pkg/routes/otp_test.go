@@ -0,0 +1,54 @@
+package routes_test
+
+import (
+	"backend_pandhi/pkg/testenv"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestLoginOTPDoesNotRevealRegistration(t *testing.T) {
+	env := testenv.New(t)
+	outlet := env.Outlet(t)
+	customer := env.Customer(t, outlet, 0)
+	if err := env.DB.Model(&customer).Update("phone", "+919876543210").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	registered := env.Do(t, http.MethodPost, "/api/auth/otp/request", "", gin.H{"phone": "98765 43210"})
+	unregistered := env.Do(t, http.MethodPost, "/api/auth/otp/request", "", gin.H{"phone": "9123456780"})
+	if registered.Code != http.StatusOK || registered.Code != unregistered.Code || registered.Body.String() != unregistered.Body.String() {
+		t.Fatalf("responses differ:\nregistered   %d %s\nunregistered %d %s",
+			registered.Code, registered.Body, unregistered.Code, unregistered.Body)
+	}
+
+	// The code is texted in the background, to the registered number only
+	deadline := time.Now().Add(5 * time.Second)
+	var code string
+	for {
+		if msg, ok := env.SMS.LastMessageTo("+919876543210"); ok {
+			code = msg.Body[:6]
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("no login code was texted")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if _, ok := env.SMS.LastMessageTo("+919123456780"); ok {
+		t.Error("an unregistered number was texted")
+	}
+
+	var login struct {
+		User struct {
+			ID int `json:"id"`
+		} `json:"user"`
+	}
+	w := env.Do(t, http.MethodPost, "/api/auth/otp/verify", "", gin.H{"phone": "+91 98765 43210", "code": code})
+	testenv.Decode(t, w, http.StatusOK, &login)
+	if login.User.ID != customer.ID {
+		t.Errorf("signed in as user %d, want %d", login.User.ID, customer.ID)
+	}
+}
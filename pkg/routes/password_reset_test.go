@@ -0,0 +1,114 @@
+package routes_test
+
+import (
+	"backend_pandhi/pkg/models"
+	"backend_pandhi/pkg/testenv"
+	"backend_pandhi/pkg/utils"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// emailedToken waits for the nth email to an address, counting from 1, and
+// returns the token it carries
+func emailedToken(t *testing.T, env *testenv.Env, to string, n int) string {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var sent []string
+		for _, msg := range env.Mail.Messages() {
+			if msg.To == to {
+				sent = append(sent, msg.Body)
+			}
+		}
+		if len(sent) >= n {
+			_, rest, ok := strings.Cut(sent[n-1], "use this code in the app: ")
+			if !ok {
+				t.Fatalf("email has no token:\n%s", sent[n-1])
+			}
+			token, _, _ := strings.Cut(rest, "\n")
+			return token
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("email %d to %s was not sent", n, to)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestPasswordReset(t *testing.T) {
+	env := testenv.New(t)
+	customer := env.Customer(t, env.Outlet(t), 0)
+	const newPassword = "Fresh-passw0rd"
+
+	// Known and unknown emails get the same answer
+	known := env.Do(t, http.MethodPost, "/api/auth/password-reset/request", "", gin.H{"email": customer.Email})
+	unknown := env.Do(t, http.MethodPost, "/api/auth/password-reset/request", "", gin.H{"email": "nobody@example.com"})
+	if known.Code != http.StatusOK || known.Code != unknown.Code || known.Body.String() != unknown.Body.String() {
+		t.Fatalf("responses differ:\nknown   %d %s\nunknown %d %s", known.Code, known.Body, unknown.Code, unknown.Body)
+	}
+	token := emailedToken(t, env, customer.Email, 1)
+
+	// Only the token's hash is stored
+	var stored models.AuthToken
+	if err := env.DB.Where(`"accountId" = ? AND purpose = ?`, customer.ID, models.AuthTokenPurposePasswordReset).First(&stored).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.TokenHash != utils.HashToken(token) || strings.Contains(stored.TokenHash, token) {
+		t.Errorf("stored token hash %q does not hash the emailed token", stored.TokenHash)
+	}
+
+	confirm := gin.H{"token": token, "password": newPassword, "retypePassword": newPassword}
+	testenv.Decode(t, env.Do(t, http.MethodPost, "/api/auth/password-reset/confirm", "", confirm), http.StatusOK, nil)
+	testenv.Decode(t, env.Do(t, http.MethodPost, "/api/auth/signin", "", gin.H{"email": customer.Email, "password": newPassword}), http.StatusOK, nil)
+
+	// A token works once
+	testenv.Decode(t, env.Do(t, http.MethodPost, "/api/auth/password-reset/confirm", "", confirm), http.StatusBadRequest, nil)
+
+	// and not after it expires
+	testenv.Decode(t, env.Do(t, http.MethodPost, "/api/auth/password-reset/request", "", gin.H{"email": customer.Email}), http.StatusOK, nil)
+	expired := emailedToken(t, env, customer.Email, 2)
+	if err := env.DB.Model(&models.AuthToken{}).Where(`"tokenHash" = ?`, utils.HashToken(expired)).
+		Update("expiresAt", time.Now().Add(-time.Minute)).Error; err != nil {
+		t.Fatal(err)
+	}
+	w := env.Do(t, http.MethodPost, "/api/auth/password-reset/confirm", "", gin.H{"token": expired, "password": testenv.Password, "retypePassword": testenv.Password})
+	testenv.Decode(t, w, http.StatusBadRequest, nil)
+	testenv.Decode(t, env.Do(t, http.MethodPost, "/api/auth/signin", "", gin.H{"email": customer.Email, "password": newPassword}), http.StatusOK, nil)
+}
+
+func TestUnverifiedCustomerCannotOrder(t *testing.T) {
+	env := testenv.New(t)
+	env.App.Config.RequireEmailVerification = true
+	outlet := env.Outlet(t)
+	customer := env.Customer(t, outlet, 500)
+	product := env.Product(t, outlet, 50, 10)
+	if err := env.DB.Model(&customer).Update("isVerified", false).Error; err != nil {
+		t.Fatal(err)
+	}
+	token := env.Token(t, customer)
+
+	w := env.Do(t, http.MethodPost, "/api/customer/outlets/customer-order/", token, gin.H{
+		"totalAmount":   product.Price,
+		"paymentMethod": "WALLET",
+		"deliverySlot":  "SLOT_12_13",
+		"outletId":      outlet.ID,
+		"items":         []gin.H{{"productId": product.ID, "quantity": 1, "unitPrice": product.Price}},
+	})
+	var blocked struct {
+		Code string `json:"code"`
+	}
+	testenv.Decode(t, w, http.StatusForbidden, &blocked)
+	if blocked.Code != "EMAIL_NOT_VERIFIED" {
+		t.Errorf("code = %q, want EMAIL_NOT_VERIFIED", blocked.Code)
+	}
+
+	// Confirming the email lifts the block
+	testenv.Decode(t, env.Do(t, http.MethodPost, "/api/auth/verify-email/resend", "", gin.H{"email": customer.Email}), http.StatusOK, nil)
+	verification := emailedToken(t, env, customer.Email, 1)
+	testenv.Decode(t, env.Do(t, http.MethodPost, "/api/auth/verify-email/confirm", "", gin.H{"token": verification}), http.StatusOK, nil)
+	placeOrder(t, env, token, product, 1)
+}
@@ -0,0 +1,268 @@
+package routes_test
+
+import (
+	"backend_pandhi/pkg/models"
+	"backend_pandhi/pkg/routes"
+	"backend_pandhi/pkg/testenv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestResponsesMatchDocumentedSchemas calls every JSON GET route and checks
+// what the handler wrote against the Response type it is documented with, so
+// a handler that assembles its own map cannot drift from the spec
+func TestResponsesMatchDocumentedSchemas(t *testing.T) {
+	env := testenv.New(t)
+	outlet := env.Outlet(t)
+	customer := env.Customer(t, outlet, 1000)
+	product := env.Product(t, outlet, 50, 100)
+	staff := env.Staff(t, outlet, models.DefaultStaffPermissions...)
+	admin := env.Admin(t, []models.Outlet{outlet})
+	superAdmin := env.SuperAdmin(t)
+	customerToken := env.Token(t, customer)
+
+	// One delivered order awaiting feedback and one still open
+	delivered := placeOrder(t, env, customerToken, product, 1)
+	ongoing := placeOrder(t, env, customerToken, product, 2)
+	if err := env.DB.Model(&models.Order{}).Where("id = ?", delivered).Updates(map[string]interface{}{
+		"status":      models.OrderStatusDelivered,
+		"deliveredAt": time.Now(),
+	}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := env.DB.Model(&models.StaffDetails{}).Where("id = ?", staff.StaffInfo.ID).Update("twoFactorEnabled", true).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	var created struct {
+		Ticket struct {
+			ID int `json:"id"`
+		} `json:"ticket"`
+	}
+	w := env.Do(t, http.MethodPost, "/api/customer/outlets/tickets/create", customerToken, gin.H{
+		"title":       "Late order",
+		"description": "My order is late",
+		"priority":    models.PriorityLow,
+	})
+	testenv.Decode(t, w, http.StatusCreated, &created)
+
+	params := map[string]int{
+		"outletId":  outlet.ID,
+		"orderId":   ongoing,
+		"productId": product.ID,
+		"ticketId":  created.Ticket.ID,
+		"staffId":   staff.StaffInfo.ID,
+		"adminId":   admin.ID,
+	}
+	tokens := map[string]string{
+		"CUSTOMER":   customerToken,
+		"STAFF":      env.Token(t, staff),
+		"ADMIN":      env.AdminToken(t, admin),
+		"SUPERADMIN": env.Token(t, superAdmin),
+	}
+	bodies := map[string]interface{}{
+		"/api/customer/outlets/service-charge-breakdown": gin.H{"amount": 100},
+	}
+
+	for _, op := range routes.Spec().Operations() {
+		if op.Method != http.MethodGet || op.ContentType != "" || op.Response == nil {
+			continue
+		}
+
+		segments := strings.Split(op.Path, "/")
+		for i, segment := range segments {
+			if name, ok := strings.CutPrefix(segment, ":"); ok {
+				id, ok := params[name]
+				if !ok {
+					t.Fatalf("no fixture for :%s in %s", name, op.Path)
+				}
+				segments[i] = fmt.Sprint(id)
+			}
+		}
+		token := ""
+		if len(op.Roles) > 0 {
+			token = tokens[op.Roles[0]]
+		}
+
+		w := env.Do(t, http.MethodGet, strings.Join(segments, "/"), token, bodies[op.Path])
+		status := op.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		if w.Code != status {
+			t.Errorf("GET %s = %d, want %d: %s", op.Path, w.Code, status, w.Body)
+			continue
+		}
+
+		var body interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Errorf("GET %s: %v", op.Path, err)
+			continue
+		}
+		for _, problem := range mismatches("body", reflect.TypeOf(op.Response), body) {
+			t.Errorf("GET %s: %s", op.Path, problem)
+		}
+	}
+}
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	rawJSONType   = reflect.TypeOf(json.RawMessage{})
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// mismatches lists where v, decoded from JSON, could not have been written by
+// encoding/json from a value of type t: undeclared or missing keys and values
+// of the wrong kind
+func mismatches(at string, t reflect.Type, v interface{}) []string {
+	if t.Kind() == reflect.Pointer {
+		if v == nil {
+			return nil
+		}
+		return mismatches(at, t.Elem(), v)
+	}
+
+	switch {
+	case t == timeType:
+		return expectKind(at, v, "string")
+	case t == rawJSONType, t.Kind() == reflect.Interface:
+		return nil
+	case t.Implements(marshalerType), reflect.PointerTo(t).Implements(marshalerType):
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return expectKind(at, v, "boolean")
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return expectKind(at, v, "number")
+	case reflect.String:
+		return expectKind(at, v, "string")
+	case reflect.Slice, reflect.Array:
+		if v == nil && t.Kind() == reflect.Slice {
+			return nil
+		}
+		if t.Elem().Kind() == reflect.Uint8 {
+			return expectKind(at, v, "string")
+		}
+		items, ok := v.([]interface{})
+		if !ok {
+			return expectKind(at, v, "array")
+		}
+		var problems []string
+		for i, item := range items {
+			problems = append(problems, mismatches(fmt.Sprintf("%s[%d]", at, i), t.Elem(), item)...)
+		}
+		return problems
+	case reflect.Map:
+		if v == nil {
+			return nil
+		}
+		object, ok := v.(map[string]interface{})
+		if !ok {
+			return expectKind(at, v, "object")
+		}
+		var problems []string
+		for key, value := range object {
+			problems = append(problems, mismatches(at+"."+key, t.Elem(), value)...)
+		}
+		return problems
+	case reflect.Struct:
+		object, ok := v.(map[string]interface{})
+		if !ok {
+			return expectKind(at, v, "object")
+		}
+		declared := make(map[string]jsonField)
+		jsonFields(t, declared)
+
+		var problems []string
+		for name, f := range declared {
+			value, present := object[name]
+			switch {
+			case !present && !f.omitEmpty:
+				problems = append(problems, fmt.Sprintf("%s.%s is missing", at, name))
+			case present && f.asString:
+				problems = append(problems, expectKind(at+"."+name, value, "string")...)
+			case present:
+				problems = append(problems, mismatches(at+"."+name, f.typ, value)...)
+			}
+		}
+		for name := range object {
+			if _, ok := declared[name]; !ok {
+				problems = append(problems, fmt.Sprintf("%s.%s is not documented", at, name))
+			}
+		}
+		return problems
+	}
+	return nil
+}
+
+type jsonField struct {
+	typ       reflect.Type
+	omitEmpty bool
+	asString  bool
+}
+
+// jsonFields collects the keys encoding/json writes for t, flattening untagged
+// embedded structs the way openapi does
+func jsonFields(t reflect.Type, fields map[string]jsonField) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				jsonFields(ft, fields)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = jsonField{
+			typ:       f.Type,
+			omitEmpty: strings.Contains(opts, "omitempty"),
+			asString:  strings.Contains(opts, "string"),
+		}
+	}
+}
+
+func expectKind(at string, v interface{}, want string) []string {
+	got := "object"
+	switch v.(type) {
+	case nil:
+		got = "null"
+	case bool:
+		got = "boolean"
+	case float64:
+		got = "number"
+	case string:
+		got = "string"
+	case []interface{}:
+		got = "array"
+	}
+	if got != want {
+		return []string{fmt.Sprintf("%s is %s, want %s", at, got, want)}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package routes
+
+import (
+	"backend_pandhi/pkg/app"
+	"backend_pandhi/pkg/health"
+	"backend_pandhi/pkg/metrics"
+	"backend_pandhi/pkg/middleware"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Setup registers every application route on router. Handlers are served
+// from a; limiter applies the stricter per-route rate limit policies.
+func Setup(router *gin.Engine, a *app.App, limiter *middleware.RateLimiter) {
+	// Root route
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, "UPS Backend Server is running...")
+	})
+
+	// API routes group
+	api := router.Group("/api")
+	{
+		// Register auth routes
+		RegisterAuthRoutes(api, a, limiter)
+
+		// Register customer routes
+		RegisterCustomerRoutes(api, a, limiter)
+
+		// Register staff routes
+		RegisterStaffRoutes(api, a, limiter)
+
+		// Register SuperAdmin routes
+		RegisterSuperAdminRoutes(router, a, limiter)
+
+		// API documentation
+		RegisterDocsRoutes(api)
+
+		// Health checks: liveness for restarts, readiness for the load balancer
+		api.GET("/health", health.Handler(a))
+		api.GET("/health/live", health.LiveHandler)
+		api.GET("/health/ready", health.ReadyHandler(a))
+	}
+
+	// Prometheus scrape endpoint (METRICS_TOKEN)
+	router.GET("/metrics", metrics.Handler(a.Config.MetricsToken))
+
+	router.NoRoute(middleware.NotFoundHandler())
+}
@@ -1,66 +1,116 @@
 package routes
 
 import (
+	"backend_pandhi/pkg/app"
 	"backend_pandhi/pkg/controllers/staff"
+	"backend_pandhi/pkg/dto"
 	"backend_pandhi/pkg/middleware"
+	"backend_pandhi/pkg/openapi"
+	"net/http"
 
 	"github.com/gin-gonic/gin"
 )
 
 // RegisterStaffRoutes registers all staff-facing API routes
-func RegisterStaffRoutes(router *gin.RouterGroup) {
+func RegisterStaffRoutes(router *gin.RouterGroup, a *app.App, limiter *middleware.RateLimiter) {
+	ctrl := staff.New(a)
+
 	staffGroup := router.Group("/staff")
-	staffGroup.Use(middleware.AuthenticateToken(), middleware.AuthorizeRoles("STAFF"))
+	staffGroup.Use(middleware.AuthenticateToken(a), middleware.AuthorizeRoles("STAFF"))
 	{
 		// Home management
-		staffGroup.GET("/outlets/get-home-data/", staff.GetHomeDetails)
-		staffGroup.GET("/outlets/get-recent-orders/:outletId/", staff.RecentOrders)
-		staffGroup.GET("/outlets/get-order/:outletId/:orderId/", staff.GetOrder)
-		staffGroup.PUT("/outlets/update-order/", staff.UpdateOrder)
-		staffGroup.GET("/outlets/tickets/count", staff.GetTicketsCount)
+		staffGroup.GET("/outlets/get-home-data/", ctrl.GetHomeDetails)
+		staffGroup.GET("/outlets/get-recent-orders/:outletId/", ctrl.RecentOrders)
+		staffGroup.GET("/outlets/get-order/:outletId/:orderId/", ctrl.GetOrder)
+		staffGroup.PUT("/outlets/update-order/", ctrl.UpdateOrder)
+		staffGroup.GET("/outlets/tickets/count", ctrl.GetTicketsCount)
 
 		// Manual Order
-		staffGroup.POST("/outlets/add-manual-order/", staff.AddManualOrder)
-		staffGroup.GET("/outlets/get-products-in-stock/:outletId", staff.GetProducts)
+		staffGroup.POST("/outlets/add-manual-order/", middleware.Idempotency(a), ctrl.AddManualOrder)
+		staffGroup.GET("/outlets/get-products-in-stock/:outletId", ctrl.GetProducts)
 
 		// Inventory Management
-		staffGroup.GET("/outlets/get-stocks/:outletId/", staff.GetStocks)
-		staffGroup.POST("/outlets/add-stock/", staff.AddStock)
-		staffGroup.POST("/outlets/deduct-stock/", staff.DeductStock)
-		staffGroup.POST("/outlets/get-stock-history", staff.StockHistory)
+		staffGroup.GET("/outlets/get-stocks/:outletId/", ctrl.GetStocks)
+		staffGroup.POST("/outlets/add-stock/", ctrl.AddStock)
+		staffGroup.POST("/outlets/deduct-stock/", ctrl.DeductStock)
+		staffGroup.POST("/outlets/get-stock-history", ctrl.StockHistory)
+
+		// Feedback
+		staffGroup.GET("/outlets/feedback/", ctrl.GetFeedback)
+		staffGroup.PUT("/outlets/feedback/:feedbackId/reply", ctrl.ReplyToFeedback)
 
 		// Notification Management
-		staffGroup.GET("/outlets/get-current-order/:outletId", staff.OutletCurrentOrder)
+		staffGroup.GET("/outlets/get-current-order/:outletId", ctrl.OutletCurrentOrder)
 
 		// Recharge Management
-		staffGroup.GET("/outlets/get-recharge-history/:outletId/", staff.GetRechargeHistory)
-		staffGroup.POST("/outlets/recharge-wallet/", staff.AddRecharge)
+		staffGroup.GET("/outlets/get-recharge-history/:outletId/", ctrl.GetRechargeHistory)
+		staffGroup.POST("/outlets/recharge-wallet/", middleware.Idempotency(a), ctrl.AddRecharge)
 
 		// Order management
-		staffGroup.GET("/outlets/get-order-history/", staff.GetOrderHistory)
-		staffGroup.GET("/outlets/get-orderdates/:outletId/", staff.GetAvailableDatesAndSlotsForStaff)
+		staffGroup.GET("/outlets/get-order-history/", ctrl.GetOrderHistory)
+		staffGroup.GET("/outlets/get-orderdates/:outletId/", ctrl.GetAvailableDatesAndSlotsForStaff)
 
 		// Reports Management
-		staffGroup.POST("/outlets/sales-trend/:outletId/", staff.GetSalesTrend)
-		staffGroup.POST("/outlets/order-type-breakdown/:outletId/", staff.GetOrderTypeBreakdown)
-		staffGroup.POST("/outlets/new-customers-trend/:outletId/", staff.GetNewCustomersTrend)
-		staffGroup.POST("/outlets/category-breakdown/:outletId/", staff.GetCategoryBreakdown)
-		staffGroup.POST("/outlets/delivery-time-orders/:outletId/", staff.GetDeliveryTimeOrders)
-		staffGroup.POST("/outlets/cancellation-refunds/:outletId/", staff.GetCancellationRefunds)
-		staffGroup.POST("/outlets/quantity-sold/:outletId/", staff.GetQuantitySold)
+		staffGroup.POST("/outlets/sales-trend/:outletId/", ctrl.GetSalesTrend)
+		staffGroup.POST("/outlets/order-type-breakdown/:outletId/", ctrl.GetOrderTypeBreakdown)
+		staffGroup.POST("/outlets/new-customers-trend/:outletId/", ctrl.GetNewCustomersTrend)
+		staffGroup.POST("/outlets/category-breakdown/:outletId/", ctrl.GetCategoryBreakdown)
+		staffGroup.POST("/outlets/delivery-time-orders/:outletId/", ctrl.GetDeliveryTimeOrders)
+		staffGroup.POST("/outlets/cancellation-refunds/:outletId/", ctrl.GetCancellationRefunds)
+		staffGroup.POST("/outlets/quantity-sold/:outletId/", ctrl.GetQuantitySold)
 
 		// Profile Management
-		staffGroup.GET("/profile/", staff.GetStaffProfile)
-		staffGroup.PUT("/profile/", staff.UpdateStaffProfile) // TODO: Add upload middleware
-		staffGroup.POST("/profile/upload-image/", staff.UploadStaffImage)
-		staffGroup.DELETE("/profile/delete-image/", staff.DeleteStaffImage)
+		staffGroup.GET("/profile/", ctrl.GetStaffProfile)
+		staffGroup.PUT("/profile/", ctrl.UpdateStaffProfile) // TODO: Add upload middleware
+		staffGroup.PUT("/profile/duty/", ctrl.SetDuty)
+		staffGroup.POST("/profile/upload-image/", ctrl.UploadStaffImage)
+		staffGroup.DELETE("/profile/delete-image/", ctrl.DeleteStaffImage)
 
 		// Security Management
-		staffGroup.POST("/security/change-password/", staff.ChangePassword)
-		staffGroup.GET("/security/2fa-status/", staff.Get2FAStatus)
-		staffGroup.POST("/security/generate-2fa/", staff.Generate2FASetup)
-		staffGroup.POST("/security/enable-2fa/", staff.Enable2FA)
-		staffGroup.POST("/security/disable-2fa/", staff.Disable2FA)
-		staffGroup.GET("/security/backup-codes-count/", staff.GetBackupCodesCount)
+		staffGroup.POST("/security/change-password/", ctrl.ChangePassword)
+		staffGroup.GET("/security/2fa-status/", ctrl.Get2FAStatus)
+		staffGroup.POST("/security/generate-2fa/", ctrl.Generate2FASetup)
+		staffGroup.POST("/security/enable-2fa/", ctrl.Enable2FA)
+		staffGroup.POST("/security/disable-2fa/", ctrl.Disable2FA)
+		staffGroup.GET("/security/backup-codes-count/", ctrl.GetBackupCodesCount)
 	}
 }
+
+var staffOperations = []openapi.Operation{
+	{Method: http.MethodGet, Path: "/api/staff/outlets/get-home-data/", Tag: "Staff", Summary: "Get the outlet dashboard", Roles: staffOnly, Response: dto.StaffHomeResponse{}},
+	{Method: http.MethodGet, Path: "/api/staff/outlets/get-recent-orders/:outletId/", Tag: "Staff", Summary: "List the outlet's latest orders", Roles: staffOnly, Query: dto.PageQuery{}, Response: dto.RecentOrdersResponse{}},
+	{Method: http.MethodGet, Path: "/api/staff/outlets/get-order/:outletId/:orderId/", Tag: "Staff", Summary: "Get one of the outlet's orders", Roles: staffOnly, Response: dto.StaffOrderResponse{}},
+	{Method: http.MethodPut, Path: "/api/staff/outlets/update-order/", Tag: "Staff", Summary: "Deliver or cancel an order or some of its items", Description: "Cancelling restores stock and refunds wallet payments.", Roles: staffOnly, Body: dto.UpdateOrderStatusRequest{}, Response: dto.MessageResponse{}},
+	{Method: http.MethodGet, Path: "/api/staff/outlets/tickets/count", Tag: "Staff", Summary: "Count the outlet's open tickets", Roles: staffOnly, Response: dto.CountResponse{}},
+	{Method: http.MethodPost, Path: "/api/staff/outlets/add-manual-order/", Tag: "Staff", Summary: "Record a counter order", Roles: staffOnly, Body: dto.ManualOrderRequest{}, Response: dto.ManualOrderResponse{}, Status: http.StatusCreated, Idempotent: true},
+	{Method: http.MethodGet, Path: "/api/staff/outlets/get-products-in-stock/:outletId", Tag: "Staff", Summary: "List products in stock for counter orders", Roles: staffOnly, Response: dto.CounterProductsResponse{}},
+	{Method: http.MethodGet, Path: "/api/staff/outlets/get-stocks/:outletId/", Tag: "Inventory", Summary: "List the outlet's stock levels", Roles: staffOnly, Response: dto.StocksResponse{}},
+	{Method: http.MethodPost, Path: "/api/staff/outlets/add-stock/", Tag: "Inventory", Summary: "Add stock", Roles: staffOnly, Body: dto.AddStockRequest{}, Response: dto.AddStockResponse{}},
+	{Method: http.MethodPost, Path: "/api/staff/outlets/deduct-stock/", Tag: "Inventory", Summary: "Deduct stock", Roles: staffOnly, Body: dto.DeductStockRequest{}, Response: dto.DeductStockResponse{}},
+	{Method: http.MethodPost, Path: "/api/staff/outlets/get-stock-history", Tag: "Inventory", Summary: "List stock movements between two dates", Roles: staffOnly, List: &staff.StockHistoryList, Body: dto.StockHistoryRequest{}, Response: dto.StockHistoryResponse{}},
+	{Method: http.MethodGet, Path: "/api/staff/outlets/feedback/", Tag: "Feedback", Summary: "List feedback on the outlet's products", Description: "Feedback hidden by an admin is left out.", Roles: staffOnly, List: &staff.FeedbackList, Response: dto.OutletFeedbackResponse{}},
+	{Method: http.MethodPut, Path: "/api/staff/outlets/feedback/:feedbackId/reply", Tag: "Feedback", Summary: "Reply publicly to feedback", Roles: staffOnly, Body: dto.FeedbackReplyRequest{}, Response: dto.FeedbackResponse{}},
+	{Method: http.MethodGet, Path: "/api/staff/outlets/get-current-order/:outletId", Tag: "Staff", Summary: "List orders in progress", Roles: staffOnly, Response: dto.CurrentOrdersResponse{}},
+	{Method: http.MethodGet, Path: "/api/staff/outlets/get-recharge-history/:outletId/", Tag: "Wallet", Summary: "List wallet recharges at the outlet", Roles: staffOnly, List: &staff.RechargeHistoryList, Response: dto.StaffRechargeHistoryResponse{}},
+	{Method: http.MethodPost, Path: "/api/staff/outlets/recharge-wallet/", Tag: "Wallet", Summary: "Recharge a customer's wallet with cash", Roles: staffOnly, Body: dto.AddRechargeRequest{}, Response: dto.AddRechargeResponse{}, Idempotent: true},
+	{Method: http.MethodGet, Path: "/api/staff/outlets/get-order-history/", Tag: "Staff", Summary: "List the outlet's past orders", Roles: staffOnly, List: &staff.OrderHistoryList, Response: dto.StaffOrderHistoryResponse{}},
+	{Method: http.MethodGet, Path: "/api/staff/outlets/get-orderdates/:outletId/", Tag: "Staff", Summary: "List the dates and slots open for orders", Roles: staffOnly, Response: dto.StaffAvailableSlotsResponse{}},
+	{Method: http.MethodPost, Path: "/api/staff/outlets/sales-trend/:outletId/", Tag: "Staff reports", Summary: "Daily revenue", Roles: staffOnly, Body: dto.DateRangeRequest{}, Response: []dto.DailyRevenue{}},
+	{Method: http.MethodPost, Path: "/api/staff/outlets/order-type-breakdown/:outletId/", Tag: "Staff reports", Summary: "App and counter order counts", Roles: staffOnly, Body: dto.DateRangeRequest{}, Response: dto.OrderTypeBreakdown{}},
+	{Method: http.MethodPost, Path: "/api/staff/outlets/new-customers-trend/:outletId/", Tag: "Staff reports", Summary: "Daily new customers", Roles: staffOnly, Body: dto.DateRangeRequest{}, Response: []dto.DailyNewCustomers{}},
+	{Method: http.MethodPost, Path: "/api/staff/outlets/category-breakdown/:outletId/", Tag: "Staff reports", Summary: "Items ordered by category", Roles: staffOnly, Body: dto.DateRangeRequest{}, Response: []dto.CategoryOrders{}},
+	{Method: http.MethodPost, Path: "/api/staff/outlets/delivery-time-orders/:outletId/", Tag: "Staff reports", Summary: "Orders by delivery slot", Roles: staffOnly, Body: dto.DateRangeRequest{}, Response: []dto.SlotOrders{}},
+	{Method: http.MethodPost, Path: "/api/staff/outlets/cancellation-refunds/:outletId/", Tag: "Staff reports", Summary: "Daily cancellations and refunds", Roles: staffOnly, Body: dto.DateRangeRequest{}, Response: []dto.DailyCancellations{}},
+	{Method: http.MethodPost, Path: "/api/staff/outlets/quantity-sold/:outletId/", Tag: "Staff reports", Summary: "Quantity sold by product", Roles: staffOnly, Body: dto.DateRangeRequest{}, Response: []dto.ProductQuantity{}},
+	{Method: http.MethodGet, Path: "/api/staff/profile/", Tag: "Staff", Summary: "Get the profile", Roles: staffOnly, Response: dto.StaffProfileResponse{}},
+	{Method: http.MethodPut, Path: "/api/staff/profile/", Tag: "Staff", Summary: "Edit the profile", Roles: staffOnly, Body: dto.UpdateStaffProfileRequest{}, Response: dto.StaffProfileResponse{}},
+	{Method: http.MethodPut, Path: "/api/staff/profile/duty/", Tag: "Staff", Summary: "Go on or off duty", Description: "Staff on duty receive push alerts for negative feedback.", Roles: staffOnly, Body: dto.SetDutyRequest{}, Response: dto.DutyResponse{}},
+	{Method: http.MethodPost, Path: "/api/staff/profile/upload-image/", Tag: "Staff", Summary: "Upload a profile image", Roles: staffOnly, Body: dto.ImageUploadRequest{}, Form: true, Response: dto.ImageUploadResponse{}},
+	{Method: http.MethodDelete, Path: "/api/staff/profile/delete-image/", Tag: "Staff", Summary: "Remove the profile image", Roles: staffOnly, Response: dto.DeleteImageResponse{}},
+	{Method: http.MethodPost, Path: "/api/staff/security/change-password/", Tag: "Staff security", Summary: "Change the password", Roles: staffOnly, Body: dto.ChangePasswordRequest{}, Response: dto.MessageResponse{}},
+	{Method: http.MethodGet, Path: "/api/staff/security/2fa-status/", Tag: "Staff security", Summary: "Get the 2FA status", Roles: staffOnly, Response: dto.TwoFactorStatusResponse{}},
+	{Method: http.MethodPost, Path: "/api/staff/security/generate-2fa/", Tag: "Staff security", Summary: "Generate a 2FA secret", Roles: staffOnly, Response: dto.TwoFactorSetupResponse{}},
+	{Method: http.MethodPost, Path: "/api/staff/security/enable-2fa/", Tag: "Staff security", Summary: "Enable 2FA", Roles: staffOnly, Body: dto.Enable2FARequest{}, Response: dto.Enable2FAResponse{}},
+	{Method: http.MethodPost, Path: "/api/staff/security/disable-2fa/", Tag: "Staff security", Summary: "Disable 2FA", Roles: staffOnly, Body: dto.Disable2FARequest{}, Response: dto.MessageResponse{}},
+	{Method: http.MethodGet, Path: "/api/staff/security/backup-codes-count/", Tag: "Staff security", Summary: "Count the remaining backup codes", Roles: staffOnly, Response: dto.BackupCodesCountResponse{}},
+}
@@ -1,110 +1,242 @@
 package routes
 
 import (
+	"backend_pandhi/pkg/app"
 	"backend_pandhi/pkg/controllers/superadmin"
+	"backend_pandhi/pkg/dto"
 	"backend_pandhi/pkg/middleware"
+	"backend_pandhi/pkg/openapi"
+	"net/http"
 
 	"github.com/gin-gonic/gin"
 )
 
-func RegisterSuperAdminRoutes(router *gin.Engine) {
+func RegisterSuperAdminRoutes(router *gin.Engine, a *app.App, limiter *middleware.RateLimiter) {
+	ctrl := superadmin.New(a)
+
 	superadminGroup := router.Group("/api/superadmin")
 
-	// Outlet Management (3 endpoints)
-	superadminGroup.POST("/add-outlet/", middleware.RestrictToSuperAdmin(), superadmin.AddOutlets)
-	superadminGroup.GET("/get-outlets/", middleware.RestrictToSuperAdminOrAdminOrCustomer(), superadmin.GetOutlets)
-	superadminGroup.DELETE("/remove-outlet/:outletId/", middleware.RestrictToSuperAdmin(), superadmin.RemoveOutlets)
+	// Outlet Management (4 endpoints)
+	superadminGroup.POST("/add-outlet/", middleware.RestrictToSuperAdmin(a), ctrl.AddOutlets)
+	superadminGroup.GET("/get-outlets/", middleware.RestrictToSuperAdminOrAdminOrCustomer(a), ctrl.GetOutlets)
+	superadminGroup.DELETE("/remove-outlet/:outletId/", middleware.RestrictToSuperAdmin(a), ctrl.RemoveOutlets)
+	superadminGroup.PUT("/outlets/rating-weights/:outletId", middleware.RestrictToSuperAdminOrAdmin(a), ctrl.UpdateOutletRatingWeights)
 
 	// Staff Management (6 endpoints)
-	superadminGroup.POST("/outlets/add-staff/", middleware.RestrictToSuperAdminOrAdmin(), superadmin.OutletAddStaff)
-	superadminGroup.POST("/outlets/permissions/", middleware.RestrictToSuperAdminOrAdmin(), superadmin.OutletStaffPermission)
-	superadminGroup.GET("/outlets/get-staffs/:outletId", middleware.RestrictToSuperAdminOrAdmin(), superadmin.GetOutletStaff)
-	superadminGroup.PUT("/outlets/update-staff/:staffId", middleware.RestrictToSuperAdminOrAdmin(), superadmin.OutletUpdateStaff)
-	superadminGroup.DELETE("/outlets/delete-staff/:staffId", middleware.RestrictToSuperAdminOrAdmin(), superadmin.OutletDeleteStaff)
-	superadminGroup.GET("/outlets/staff/:staffId", middleware.RestrictToSuperAdminOrAdmin(), superadmin.GetStaffById)
+	superadminGroup.POST("/outlets/add-staff/", middleware.RestrictToSuperAdminOrAdmin(a), ctrl.OutletAddStaff)
+	superadminGroup.POST("/outlets/permissions/", middleware.RestrictToSuperAdminOrAdmin(a), ctrl.OutletStaffPermission)
+	superadminGroup.GET("/outlets/get-staffs/:outletId", middleware.RestrictToSuperAdminOrAdmin(a), ctrl.GetOutletStaff)
+	superadminGroup.PUT("/outlets/update-staff/:staffId", middleware.RestrictToSuperAdminOrAdmin(a), ctrl.OutletUpdateStaff)
+	superadminGroup.DELETE("/outlets/delete-staff/:staffId", middleware.RestrictToSuperAdminOrAdmin(a), ctrl.OutletDeleteStaff)
+	superadminGroup.GET("/outlets/staff/:staffId", middleware.RestrictToSuperAdminOrAdmin(a), ctrl.GetStaffById)
 
 	// Product Management (4 endpoints)
-	superadminGroup.GET("/outlets/get-products/:outletId", middleware.RestrictToSuperAdminOrAdmin(), superadmin.GetProducts)
-	superadminGroup.POST("/outlets/add-product/", middleware.RestrictToSuperAdminOrAdmin(), superadmin.AddProduct)
-	superadminGroup.DELETE("/outlets/delete-product/:id", middleware.RestrictToSuperAdminOrAdmin(), superadmin.DeleteProduct)
-	superadminGroup.PUT("/outlets/update-product/:id", middleware.RestrictToSuperAdminOrAdmin(), superadmin.UpdateProduct)
+	superadminGroup.GET("/outlets/get-products/:outletId", middleware.RestrictToSuperAdminOrAdmin(a), ctrl.GetProducts)
+	superadminGroup.POST("/outlets/add-product/", middleware.RestrictToSuperAdminOrAdmin(a), ctrl.AddProduct)
+	superadminGroup.DELETE("/outlets/delete-product/:id", middleware.RestrictToSuperAdminOrAdmin(a), ctrl.DeleteProduct)
+	superadminGroup.PUT("/outlets/update-product/:id", middleware.RestrictToSuperAdminOrAdmin(a), ctrl.UpdateProduct)
 
 	// Order Management (1 endpoint)
-	superadminGroup.GET("/outlets/:outletId/orders/", middleware.RestrictToSuperAdminOrAdmin(), superadmin.OutletTotalOrders)
+	superadminGroup.GET("/outlets/:outletId/orders/", middleware.RestrictToSuperAdminOrAdmin(a), ctrl.OutletTotalOrders)
 
 	// Inventory Management (4 endpoints)
-	superadminGroup.GET("/outlets/get-stocks/:outletId", middleware.RestrictToSuperAdminOrAdmin(), superadmin.GetStocks)
-	superadminGroup.POST("/outlets/add-stocks/", middleware.RestrictToSuperAdminOrAdmin(), superadmin.AddStock)
-	superadminGroup.POST("/outlets/deduct-stocks/", middleware.RestrictToSuperAdminOrAdmin(), superadmin.DeductStock)
-	superadminGroup.POST("/outlets/get-stock-history", middleware.RestrictToSuperAdminOrAdmin(), superadmin.StockHistory)
+	superadminGroup.GET("/outlets/get-stocks/:outletId", middleware.RestrictToSuperAdminOrAdmin(a), ctrl.GetStocks)
+	superadminGroup.POST("/outlets/add-stocks/", middleware.RestrictToSuperAdminOrAdmin(a), ctrl.AddStock)
+	superadminGroup.POST("/outlets/deduct-stocks/", middleware.RestrictToSuperAdminOrAdmin(a), ctrl.DeductStock)
+	superadminGroup.POST("/outlets/get-stock-history", middleware.RestrictToSuperAdminOrAdmin(a), ctrl.StockHistory)
 
 	// Expense Management (3 endpoints)
-	superadminGroup.POST("/outlets/add-expenses/", middleware.RestrictToSuperAdminOrAdmin(), superadmin.AddExpense)
-	superadminGroup.GET("/outlets/get-expenses/:outletId/", middleware.RestrictToSuperAdminOrAdmin(), superadmin.GetExpenses)
-	superadminGroup.POST("/outlets/get-expenses-bydate/", middleware.RestrictToSuperAdminOrAdmin(), superadmin.GetExpenseByDate)
+	superadminGroup.POST("/outlets/add-expenses/", middleware.RestrictToSuperAdminOrAdmin(a), ctrl.AddExpense)
+	superadminGroup.GET("/outlets/get-expenses/:outletId/", middleware.RestrictToSuperAdminOrAdmin(a), ctrl.GetExpenses)
+	superadminGroup.POST("/outlets/get-expenses-bydate/", middleware.RestrictToSuperAdminOrAdmin(a), ctrl.GetExpenseByDate)
 
 	// Wallet Management (3 endpoints)
-	superadminGroup.GET("/outlets/wallet-history/:outletId/", middleware.RestrictToSuperAdminOrAdmin(), superadmin.GetCustomersWithWallet)
-	superadminGroup.GET("/outlets/recharge-history/:outletId/", middleware.RestrictToSuperAdminOrAdmin(), superadmin.GetRechargeHistoryByOutlet)
-	superadminGroup.GET("/outlets/paid-wallet/", middleware.RestrictToSuperAdminOrAdmin(), superadmin.GetOrdersPaidViaWallet)
+	superadminGroup.GET("/outlets/wallet-history/:outletId/", middleware.RestrictToSuperAdminOrAdmin(a), ctrl.GetCustomersWithWallet)
+	superadminGroup.GET("/outlets/recharge-history/:outletId/", middleware.RestrictToSuperAdminOrAdmin(a), ctrl.GetRechargeHistoryByOutlet)
+	superadminGroup.GET("/outlets/paid-wallet/", middleware.RestrictToSuperAdminOrAdmin(a), ctrl.GetOrdersPaidViaWallet)
 
 	// Customer Management (1 endpoint)
-	superadminGroup.GET("/outlets/customers/:outletId/", middleware.RestrictToSuperAdminOrAdmin(), superadmin.GetOutletCustomers)
+	superadminGroup.GET("/outlets/customers/:outletId/", middleware.RestrictToSuperAdminOrAdmin(a), ctrl.GetOutletCustomers)
 
 	// Ticket Management (2 endpoints)
-	superadminGroup.GET("/outlets/tickets/:outletId", middleware.RestrictToSuperAdminOrAdmin(), superadmin.GetTickets)
-	superadminGroup.POST("/outlets/ticket-close/", middleware.RestrictToSuperAdminOrAdmin(), superadmin.TicketClose)
+	superadminGroup.GET("/outlets/tickets/:outletId", middleware.RestrictToSuperAdminOrAdmin(a), ctrl.GetTickets)
+	superadminGroup.POST("/outlets/ticket-close/", middleware.RestrictToSuperAdminOrAdmin(a), ctrl.TicketClose)
 
 	// Coupon Management (3 endpoints)
-	superadminGroup.POST("/create-coupon/", middleware.RestrictToSuperAdminOrAdmin(), superadmin.CreateCoupon)
-	superadminGroup.GET("/get-coupons/:outletId", middleware.RestrictToSuperAdminOrAdmin(), superadmin.GetCoupons)
-	superadminGroup.DELETE("/delete-coupon/:couponId/", middleware.RestrictToSuperAdminOrAdmin(), superadmin.DeleteCoupon)
+	superadminGroup.POST("/create-coupon/", middleware.RestrictToSuperAdminOrAdmin(a), ctrl.CreateCoupon)
+	superadminGroup.GET("/get-coupons/:outletId", middleware.RestrictToSuperAdminOrAdmin(a), ctrl.GetCoupons)
+	superadminGroup.DELETE("/delete-coupon/:couponId/", middleware.RestrictToSuperAdminOrAdmin(a), ctrl.DeleteCoupon)
 
 	// Notification Management (8 endpoints)
-	superadminGroup.GET("/dashboard/low-stock-notifications", middleware.RestrictToSuperAdminOrAdmin(), superadmin.GetLowStockNotifications)
-	superadminGroup.POST("/notifications/schedule", middleware.RestrictToSuperAdminOrAdmin(), superadmin.CreateScheduledNotification)
-	superadminGroup.GET("/notifications/scheduled/:outletId", middleware.RestrictToSuperAdminOrAdmin(), superadmin.GetScheduledNotifications)
-	superadminGroup.DELETE("/notifications/scheduled/:notificationId", middleware.RestrictToSuperAdminOrAdmin(), superadmin.CancelScheduledNotification)
-	superadminGroup.POST("/notifications/send-immediate", middleware.RestrictToSuperAdminOrAdmin(), superadmin.SendImmediateNotification)
-	superadminGroup.GET("/notifications/stats/:outletId", middleware.RestrictToSuperAdminOrAdmin(), superadmin.GetNotificationStats)
-	superadminGroup.GET("/notifications/fcm-status", middleware.RestrictToSuperAdminOrAdmin(), superadmin.TestFCMService)
-	superadminGroup.POST("/notifications/test-single", middleware.RestrictToSuperAdminOrAdmin(), superadmin.TestSingleDeviceNotification)
+	superadminGroup.GET("/dashboard/low-stock-notifications", middleware.RestrictToSuperAdminOrAdmin(a), ctrl.GetLowStockNotifications)
+	superadminGroup.POST("/notifications/schedule", middleware.RestrictToSuperAdminOrAdmin(a), ctrl.CreateScheduledNotification)
+	superadminGroup.GET("/notifications/scheduled/:outletId", middleware.RestrictToSuperAdminOrAdmin(a), ctrl.GetScheduledNotifications)
+	superadminGroup.DELETE("/notifications/scheduled/:notificationId", middleware.RestrictToSuperAdminOrAdmin(a), ctrl.CancelScheduledNotification)
+	superadminGroup.POST("/notifications/send-immediate", middleware.RestrictToSuperAdminOrAdmin(a), ctrl.SendImmediateNotification)
+	superadminGroup.GET("/notifications/stats/:outletId", middleware.RestrictToSuperAdminOrAdmin(a), ctrl.GetNotificationStats)
+	superadminGroup.GET("/notifications/fcm-status", middleware.RestrictToSuperAdminOrAdmin(a), ctrl.TestFCMService)
+	superadminGroup.POST("/notifications/test-single", middleware.RestrictToSuperAdminOrAdmin(a), ctrl.TestSingleDeviceNotification)
 
 	// App Management (5 endpoints)
-	superadminGroup.GET("/outlets/get-non-availability-preview/:outletId", middleware.RestrictToSuperAdminOrAdmin(), superadmin.GetOutletNonAvailabilityPreview)
-	superadminGroup.POST("/outlets/set-availability/", middleware.RestrictToSuperAdminOrAdmin(), superadmin.SetOutletAvailability)
-	superadminGroup.GET("/outlets/get-available-dates/:outletId", middleware.RestrictToSuperAdminOrAdmin(), superadmin.GetAvailableDatesAndSlots)
-	superadminGroup.GET("/outlets/app-features/:outletId", middleware.RestrictToSuperAdminOrAdminOrCustomer(), superadmin.GetOutletAppFeatures)
-	superadminGroup.POST("/outlets/app-features/", middleware.RestrictToSuperAdminOrAdmin(), superadmin.UpdateOutletAppFeatures)
+	superadminGroup.GET("/outlets/get-non-availability-preview/:outletId", middleware.RestrictToSuperAdminOrAdmin(a), ctrl.GetOutletNonAvailabilityPreview)
+	superadminGroup.POST("/outlets/set-availability/", middleware.RestrictToSuperAdminOrAdmin(a), ctrl.SetOutletAvailability)
+	superadminGroup.GET("/outlets/get-available-dates/:outletId", middleware.RestrictToSuperAdminOrAdmin(a), ctrl.GetAvailableDatesAndSlots)
+	superadminGroup.GET("/outlets/app-features/:outletId", middleware.RestrictToSuperAdminOrAdminOrCustomer(a), ctrl.GetOutletAppFeatures)
+	superadminGroup.POST("/outlets/app-features/", middleware.RestrictToSuperAdminOrAdmin(a), ctrl.UpdateOutletAppFeatures)
 
 	// Reports Management (7 endpoints)
-	superadminGroup.POST("/outlets/sales-report/:outletId/", middleware.RestrictToSuperAdminOrAdmin(), superadmin.GetOutletSalesReport)
-	superadminGroup.POST("/outlets/revenue-report/:outletId/", middleware.RestrictToSuperAdminOrAdmin(), superadmin.GetOutletRevenueByItems)
-	superadminGroup.POST("/outlets/revenue-split/:outletId/", middleware.RestrictToSuperAdminOrAdmin(), superadmin.GetRevenueSplit)
-	superadminGroup.POST("/outlets/wallet-recharge-by-day/:outletId/", middleware.RestrictToSuperAdminOrAdmin(), superadmin.GetWalletRechargeByDay)
-	superadminGroup.POST("/outlets/profit-loss-trends/:outletId/", middleware.RestrictToSuperAdminOrAdmin(), superadmin.GetProfitLossTrends)
-	superadminGroup.POST("/outlets/customer-overview/:outletId/", middleware.RestrictToSuperAdminOrAdmin(), superadmin.GetCustomerOverview)
-	superadminGroup.POST("/outlets/customer-per-order/:outletId/", middleware.RestrictToSuperAdminOrAdmin(), superadmin.GetCustomerPerOrder)
+	superadminGroup.POST("/outlets/sales-report/:outletId/", middleware.RestrictToSuperAdminOrAdmin(a), ctrl.GetOutletSalesReport)
+	superadminGroup.POST("/outlets/revenue-report/:outletId/", middleware.RestrictToSuperAdminOrAdmin(a), ctrl.GetOutletRevenueByItems)
+	superadminGroup.POST("/outlets/revenue-split/:outletId/", middleware.RestrictToSuperAdminOrAdmin(a), ctrl.GetRevenueSplit)
+	superadminGroup.POST("/outlets/wallet-recharge-by-day/:outletId/", middleware.RestrictToSuperAdminOrAdmin(a), ctrl.GetWalletRechargeByDay)
+	superadminGroup.POST("/outlets/profit-loss-trends/:outletId/", middleware.RestrictToSuperAdminOrAdmin(a), ctrl.GetProfitLossTrends)
+	superadminGroup.POST("/outlets/customer-overview/:outletId/", middleware.RestrictToSuperAdminOrAdmin(a), ctrl.GetCustomerOverview)
+	superadminGroup.POST("/outlets/customer-per-order/:outletId/", middleware.RestrictToSuperAdminOrAdmin(a), ctrl.GetCustomerPerOrder)
 
 	// Dashboard Management (6 analytics endpoints)
-	superadminGroup.GET("/dashboard/overview", middleware.RestrictToSuperAdminOrAdmin(), superadmin.GetDashboardOverview)
-	superadminGroup.POST("/dashboard/revenue-trend", middleware.RestrictToSuperAdminOrAdmin(), superadmin.GetRevenueTrend)
-	superadminGroup.POST("/dashboard/order-status-distribution", middleware.RestrictToSuperAdminOrAdmin(), superadmin.GetOrderStatusDistribution)
-	superadminGroup.POST("/dashboard/order-source-distribution", middleware.RestrictToSuperAdminOrAdmin(), superadmin.GetOrderSourceDistribution)
-	superadminGroup.POST("/dashboard/top-selling-items", middleware.RestrictToSuperAdminOrAdmin(), superadmin.GetTopSellingItems)
-	superadminGroup.POST("/dashboard/peak-time-slots", middleware.RestrictToSuperAdminOrAdmin(), superadmin.GetPeakTimeSlots)
+	superadminGroup.GET("/dashboard/overview", middleware.RestrictToSuperAdminOrAdmin(a), ctrl.GetDashboardOverview)
+	superadminGroup.POST("/dashboard/revenue-trend", middleware.RestrictToSuperAdminOrAdmin(a), ctrl.GetRevenueTrend)
+	superadminGroup.POST("/dashboard/order-status-distribution", middleware.RestrictToSuperAdminOrAdmin(a), ctrl.GetOrderStatusDistribution)
+	superadminGroup.POST("/dashboard/order-source-distribution", middleware.RestrictToSuperAdminOrAdmin(a), ctrl.GetOrderSourceDistribution)
+	superadminGroup.POST("/dashboard/top-selling-items", middleware.RestrictToSuperAdminOrAdmin(a), ctrl.GetTopSellingItems)
+	superadminGroup.POST("/dashboard/peak-time-slots", middleware.RestrictToSuperAdminOrAdmin(a), ctrl.GetPeakTimeSlots)
 
 	// Admin Management (7 endpoints)
-	superadminGroup.GET("/pending-admins", middleware.RestrictToSuperAdmin(), superadmin.GetPendingAdminVerifications)
-	superadminGroup.POST("/verify-admin/:adminId", middleware.RestrictToSuperAdmin(), superadmin.VerifyAdmin)
-	superadminGroup.GET("/verified-admins", middleware.RestrictToSuperAdmin(), superadmin.GetVerifiedAdmins)
-	superadminGroup.GET("/admin/:adminId", middleware.RestrictToSuperAdminOrAdmin(), superadmin.GetAdminDetails)
-	superadminGroup.DELETE("/admin/:adminId", middleware.RestrictToSuperAdmin(), superadmin.DeleteAdmin)
-	superadminGroup.POST("/map-outlets-to-admin", middleware.RestrictToSuperAdmin(), superadmin.MapOutletsToAdmin)
-	superadminGroup.POST("/assign-admin-permissions", middleware.RestrictToSuperAdmin(), superadmin.AssignAdminPermissions)
+	superadminGroup.GET("/pending-admins", middleware.RestrictToSuperAdmin(a), ctrl.GetPendingAdminVerifications)
+	superadminGroup.POST("/verify-admin/:adminId", middleware.RestrictToSuperAdmin(a), ctrl.VerifyAdmin)
+	superadminGroup.GET("/verified-admins", middleware.RestrictToSuperAdmin(a), ctrl.GetVerifiedAdmins)
+	superadminGroup.GET("/admin/:adminId", middleware.RestrictToSuperAdminOrAdmin(a), ctrl.GetAdminDetails)
+	superadminGroup.DELETE("/admin/:adminId", middleware.RestrictToSuperAdmin(a), ctrl.DeleteAdmin)
+	superadminGroup.POST("/map-outlets-to-admin", middleware.RestrictToSuperAdmin(a), ctrl.MapOutletsToAdmin)
+	superadminGroup.POST("/assign-admin-permissions", middleware.RestrictToSuperAdmin(a), ctrl.AssignAdminPermissions)
 
 	// Staff Verification (3 endpoints)
-	superadminGroup.POST("/verify-staff/:userId", middleware.RestrictToSuperAdmin(), superadmin.VerifyStaff)
-	superadminGroup.GET("/unverified-staff", middleware.RestrictToSuperAdmin(), superadmin.GetUnverifiedStaff)
-	superadminGroup.GET("/verified-staff", middleware.RestrictToSuperAdmin(), superadmin.GetVerifiedStaff)
+	superadminGroup.POST("/verify-staff/:userId", middleware.RestrictToSuperAdmin(a), ctrl.VerifyStaff)
+	superadminGroup.GET("/unverified-staff", middleware.RestrictToSuperAdmin(a), ctrl.GetUnverifiedStaff)
+	superadminGroup.GET("/verified-staff", middleware.RestrictToSuperAdmin(a), ctrl.GetVerifiedStaff)
+
+	// Login Security (2 endpoints)
+	superadminGroup.POST("/security/unlock-login", middleware.RestrictToSuperAdminOrAdmin(a), ctrl.UnlockLogin)
+	superadminGroup.GET("/security/lockouts", middleware.RestrictToSuperAdmin(a), ctrl.GetLoginLockouts)
+
+	// Audit Log (1 endpoint)
+	superadminGroup.GET("/audit-events", middleware.RestrictToSuperAdmin(a), ctrl.GetAuditEvents)
+
+	// Feedback Moderation (4 endpoints)
+	superadminGroup.GET("/outlets/feedback/:outletId", middleware.RestrictToSuperAdminOrAdmin(a), ctrl.GetOutletFeedback)
+	superadminGroup.POST("/feedback/:feedbackId/hide", middleware.RestrictToSuperAdminOrAdmin(a), ctrl.HideFeedback)
+	superadminGroup.POST("/feedback/:feedbackId/restore", middleware.RestrictToSuperAdminOrAdmin(a), ctrl.RestoreFeedback)
+	superadminGroup.PUT("/feedback/:feedbackId/reply", middleware.RestrictToSuperAdminOrAdmin(a), ctrl.ReplyToFeedback)
+
+	// Feedback Alerts (4 endpoints)
+	superadminGroup.GET("/outlets/alert-rules/:outletId", middleware.RestrictToSuperAdminOrAdmin(a), ctrl.GetAlertRules)
+	superadminGroup.POST("/outlets/alert-rules/", middleware.RestrictToSuperAdminOrAdmin(a), ctrl.CreateAlertRule)
+	superadminGroup.PUT("/alert-rules/:ruleId", middleware.RestrictToSuperAdminOrAdmin(a), ctrl.UpdateAlertRule)
+	superadminGroup.DELETE("/alert-rules/:ruleId", middleware.RestrictToSuperAdminOrAdmin(a), ctrl.DeleteAlertRule)
+
+	// Background Jobs (2 endpoints)
+	superadminGroup.GET("/jobs/failed", middleware.RestrictToSuperAdmin(a), ctrl.GetFailedJobs)
+	superadminGroup.POST("/jobs/:jobId/retry", middleware.RestrictToSuperAdmin(a), ctrl.RetryJob)
+}
+
+var superAdminOperations = []openapi.Operation{
+	{Method: http.MethodPost, Path: "/api/superadmin/add-outlet/", Tag: "Outlets", Summary: "Create an outlet", Roles: superAdminOnly, Body: dto.AddOutletRequest{}, Response: dto.OutletResponse{}, Status: http.StatusCreated},
+	{Method: http.MethodGet, Path: "/api/superadmin/get-outlets/", Tag: "Outlets", Summary: "List outlets", Roles: superAdminAdminOrCustomer, Response: dto.OutletsResponse{}},
+	{Method: http.MethodDelete, Path: "/api/superadmin/remove-outlet/:outletId/", Tag: "Outlets", Summary: "Delete an outlet", Roles: superAdminOnly, Response: dto.MessageResponse{}},
+	{Method: http.MethodPut, Path: "/api/superadmin/outlets/rating-weights/:outletId", Tag: "Outlets", Summary: "Set how feedback ratings weigh in product scores", Roles: superAdminOrAdmin, Body: dto.RatingWeights{}, Response: dto.RatingWeightsResponse{}},
+
+	{Method: http.MethodPost, Path: "/api/superadmin/outlets/add-staff/", Tag: "Staff management", Summary: "Create a staff account", Roles: superAdminOrAdmin, Body: dto.AddStaffRequest{}, Response: dto.AddStaffResponse{}, Status: http.StatusCreated},
+	{Method: http.MethodPost, Path: "/api/superadmin/outlets/permissions/", Tag: "Staff management", Summary: "Grant or revoke a staff permission", Roles: superAdminOrAdmin, Body: dto.StaffPermissionRequest{}, Response: dto.StaffPermissionResponse{}},
+	{Method: http.MethodGet, Path: "/api/superadmin/outlets/get-staffs/:outletId", Tag: "Staff management", Summary: "List an outlet's staff", Roles: superAdminOrAdmin, List: &superadmin.OutletStaffList, Response: dto.OutletStaffResponse{}},
+	{Method: http.MethodPut, Path: "/api/superadmin/outlets/update-staff/:staffId", Tag: "Staff management", Summary: "Update a staff member", Roles: superAdminOrAdmin, Body: dto.UpdateStaffForm{}, Form: true, Response: dto.UpdateStaffResponse{}},
+	{Method: http.MethodDelete, Path: "/api/superadmin/outlets/delete-staff/:staffId", Tag: "Staff management", Summary: "Delete a staff member", Roles: superAdminOrAdmin, Response: dto.MessageResponse{}},
+	{Method: http.MethodGet, Path: "/api/superadmin/outlets/staff/:staffId", Tag: "Staff management", Summary: "Get a staff member", Roles: superAdminOrAdmin, Response: dto.StaffDetailsResponse{}},
+
+	{Method: http.MethodGet, Path: "/api/superadmin/outlets/get-products/:outletId", Tag: "Products", Summary: "List an outlet's products", Roles: superAdminOrAdmin, Response: dto.AdminProductsResponse{}},
+	{Method: http.MethodPost, Path: "/api/superadmin/outlets/add-product/", Tag: "Products", Summary: "Create a product", Roles: superAdminOrAdmin, Body: dto.ProductForm{}, Form: true, Response: dto.CreateProductResponse{}, Status: http.StatusCreated},
+	{Method: http.MethodDelete, Path: "/api/superadmin/outlets/delete-product/:id", Tag: "Products", Summary: "Delete a product", Roles: superAdminOrAdmin, Response: dto.MessageResponse{}},
+	{Method: http.MethodPut, Path: "/api/superadmin/outlets/update-product/:id", Tag: "Products", Summary: "Update a product", Roles: superAdminOrAdmin, Body: dto.ProductForm{}, Form: true, Response: dto.UpdateProductResponse{}},
+
+	{Method: http.MethodGet, Path: "/api/superadmin/outlets/:outletId/orders/", Tag: "Orders", Summary: "List an outlet's orders", Roles: superAdminOrAdmin, List: &superadmin.OutletOrdersList, Response: dto.OutletOrdersResponse{}},
+
+	{Method: http.MethodGet, Path: "/api/superadmin/outlets/get-stocks/:outletId", Tag: "Inventory", Summary: "List an outlet's stock levels", Roles: superAdminOrAdmin, Response: dto.StocksResponse{}},
+	{Method: http.MethodPost, Path: "/api/superadmin/outlets/add-stocks/", Tag: "Inventory", Summary: "Add stock", Roles: superAdminOrAdmin, Body: dto.AddStockRequest{}, Response: dto.AddStockResponse{}},
+	{Method: http.MethodPost, Path: "/api/superadmin/outlets/deduct-stocks/", Tag: "Inventory", Summary: "Deduct stock", Roles: superAdminOrAdmin, Body: dto.DeductStockRequest{}, Response: dto.DeductStockResponse{}},
+	{Method: http.MethodPost, Path: "/api/superadmin/outlets/get-stock-history", Tag: "Inventory", Summary: "List stock movements between two dates", Roles: superAdminOrAdmin, List: &superadmin.StockHistoryList, Body: dto.StockHistoryRequest{}, Response: dto.StockHistoryResponse{}},
+
+	{Method: http.MethodPost, Path: "/api/superadmin/outlets/add-expenses/", Tag: "Expenses", Summary: "Record an expense", Roles: superAdminOrAdmin, Body: dto.AddExpenseRequest{}, Response: dto.ExpenseResponse{}, Status: http.StatusCreated},
+	{Method: http.MethodGet, Path: "/api/superadmin/outlets/get-expenses/:outletId/", Tag: "Expenses", Summary: "List the last two weeks of expenses", Roles: superAdminOrAdmin, List: &superadmin.ExpensesList, Response: dto.ExpensesResponse{}},
+	{Method: http.MethodPost, Path: "/api/superadmin/outlets/get-expenses-bydate/", Tag: "Expenses", Summary: "List expenses in a date range", Roles: superAdminOrAdmin, List: &superadmin.ExpensesList, Body: dto.ExpenseByDateRequest{}, Response: dto.ExpensesResponse{}},
+
+	{Method: http.MethodGet, Path: "/api/superadmin/outlets/wallet-history/:outletId/", Tag: "Wallet", Summary: "List an outlet's customers with wallet totals", Roles: superAdminOrAdmin, List: &superadmin.OutletCustomersList, Response: dto.WalletCustomersResponse{}},
+	{Method: http.MethodGet, Path: "/api/superadmin/outlets/recharge-history/:outletId/", Tag: "Wallet", Summary: "List wallet recharges at an outlet", Roles: superAdminOrAdmin, List: &superadmin.OutletRechargeList, Response: dto.OutletRechargesResponse{}},
+	{Method: http.MethodGet, Path: "/api/superadmin/outlets/paid-wallet/", Tag: "Wallet", Summary: "List orders paid from wallets", Roles: superAdminOrAdmin, List: &superadmin.WalletOrdersList, Response: dto.WalletOrdersResponse{}},
+
+	{Method: http.MethodGet, Path: "/api/superadmin/outlets/customers/:outletId/", Tag: "Customers", Summary: "List an outlet's customers", Roles: superAdminOrAdmin, List: &superadmin.OutletCustomersList, Response: dto.OutletCustomersResponse{}},
+
+	{Method: http.MethodGet, Path: "/api/superadmin/outlets/tickets/:outletId", Tag: "Tickets", Summary: "List tickets raised by an outlet's customers", Roles: superAdminOrAdmin, List: &superadmin.OutletTicketsList, Response: dto.OutletTicketsResponse{}},
+	{Method: http.MethodPost, Path: "/api/superadmin/outlets/ticket-close/", Tag: "Tickets", Summary: "Resolve a ticket", Roles: superAdminOrAdmin, Body: dto.CloseTicketRequest{}, Response: dto.CloseTicketResponse{}},
+
+	{Method: http.MethodGet, Path: "/api/superadmin/outlets/feedback/:outletId", Tag: "Feedback", Summary: "List feedback on an outlet's products", Roles: superAdminOrAdmin, List: &superadmin.OutletFeedbackList, Response: dto.OutletFeedbackResponse{}},
+	{Method: http.MethodPost, Path: "/api/superadmin/feedback/:feedbackId/hide", Tag: "Feedback", Summary: "Hide feedback from reviews and ratings", Roles: superAdminOrAdmin, Body: dto.ModerateFeedbackRequest{}, Response: dto.FeedbackResponse{}},
+	{Method: http.MethodPost, Path: "/api/superadmin/feedback/:feedbackId/restore", Tag: "Feedback", Summary: "Publish hidden or held feedback", Roles: superAdminOrAdmin, Body: dto.ModerateFeedbackRequest{}, Response: dto.FeedbackResponse{}},
+	{Method: http.MethodPut, Path: "/api/superadmin/feedback/:feedbackId/reply", Tag: "Feedback", Summary: "Reply publicly to feedback", Roles: superAdminOrAdmin, Body: dto.FeedbackReplyRequest{}, Response: dto.FeedbackResponse{}},
+	{Method: http.MethodGet, Path: "/api/superadmin/outlets/alert-rules/:outletId", Tag: "Feedback alerts", Summary: "List the rules that open tickets for negative feedback", Description: "default is the built-in rule that applies while the outlet has none of its own.", Roles: superAdminOrAdmin, Response: dto.AlertRulesResponse{}},
+	{Method: http.MethodPost, Path: "/api/superadmin/outlets/alert-rules/", Tag: "Feedback alerts", Summary: "Add a feedback alert rule", Description: "Feedback matching any active rule opens an internal ticket and notifies the outlet's staff on duty.", Roles: superAdminOrAdmin, Body: dto.AlertRuleRequest{}, Response: dto.AlertRuleResponse{}, Status: http.StatusCreated},
+	{Method: http.MethodPut, Path: "/api/superadmin/alert-rules/:ruleId", Tag: "Feedback alerts", Summary: "Change a feedback alert rule", Roles: superAdminOrAdmin, Body: dto.AlertRuleRequest{}, Response: dto.AlertRuleResponse{}},
+	{Method: http.MethodDelete, Path: "/api/superadmin/alert-rules/:ruleId", Tag: "Feedback alerts", Summary: "Delete a feedback alert rule", Roles: superAdminOrAdmin, Response: dto.MessageResponse{}},
+
+	{Method: http.MethodPost, Path: "/api/superadmin/create-coupon/", Tag: "Coupons", Summary: "Create a coupon", Roles: superAdminOrAdmin, Body: dto.CreateCouponRequest{}, Response: dto.CouponResponse{}, Status: http.StatusCreated},
+	{Method: http.MethodGet, Path: "/api/superadmin/get-coupons/:outletId", Tag: "Coupons", Summary: "List an outlet's coupons", Roles: superAdminOrAdmin, List: &superadmin.CouponsList, Response: dto.CouponListResponse{}},
+	{Method: http.MethodDelete, Path: "/api/superadmin/delete-coupon/:couponId/", Tag: "Coupons", Summary: "Delete a coupon", Roles: superAdminOrAdmin, Response: dto.MessageResponse{}},
+
+	{Method: http.MethodGet, Path: "/api/superadmin/dashboard/low-stock-notifications", Tag: "Notifications", Summary: "List products below their stock threshold", Roles: superAdminOrAdmin, Response: dto.LowStockResponse{}},
+	{Method: http.MethodPost, Path: "/api/superadmin/notifications/schedule", Tag: "Notifications", Summary: "Schedule a push notification", Roles: superAdminOrAdmin, Body: dto.ScheduleNotificationRequest{}, Response: dto.NotificationResponse{}, Status: http.StatusCreated},
+	{Method: http.MethodGet, Path: "/api/superadmin/notifications/scheduled/:outletId", Tag: "Notifications", Summary: "List an outlet's scheduled notifications", Roles: superAdminOrAdmin, List: &superadmin.ScheduledNotificationsList, Response: dto.NotificationsResponse{}},
+	{Method: http.MethodDelete, Path: "/api/superadmin/notifications/scheduled/:notificationId", Tag: "Notifications", Summary: "Cancel a scheduled notification", Roles: superAdminOrAdmin, Response: dto.SuccessMessageResponse{}},
+	{Method: http.MethodPost, Path: "/api/superadmin/notifications/send-immediate", Tag: "Notifications", Summary: "Push a notification to an outlet's customers now", Roles: superAdminOrAdmin, Body: dto.SendNotificationRequest{}, Response: dto.SendNotificationResponse{}},
+	{Method: http.MethodGet, Path: "/api/superadmin/notifications/stats/:outletId", Tag: "Notifications", Summary: "Count an outlet's scheduled notifications", Roles: superAdminOrAdmin, Response: dto.NotificationStatsResponse{}},
+	{Method: http.MethodGet, Path: "/api/superadmin/notifications/fcm-status", Tag: "Notifications", Summary: "Report the push notification service status", Roles: superAdminOrAdmin, Response: dto.FCMStatusResponse{}},
+	{Method: http.MethodPost, Path: "/api/superadmin/notifications/test-single", Tag: "Notifications", Summary: "Push a test notification to one device", Roles: superAdminOrAdmin, Body: dto.TestNotificationRequest{}, Response: dto.TestNotificationResponse{}},
+
+	{Method: http.MethodGet, Path: "/api/superadmin/outlets/get-non-availability-preview/:outletId", Tag: "App management", Summary: "List an outlet's closed dates and slots", Roles: superAdminOrAdmin, Response: dto.NonAvailabilityResponse{}},
+	{Method: http.MethodPost, Path: "/api/superadmin/outlets/set-availability/", Tag: "App management", Summary: "Replace an outlet's closed dates and slots", Roles: superAdminOrAdmin, Body: dto.SetOutletAvailabilityRequest{}, Response: dto.MessageResponse{}},
+	{Method: http.MethodGet, Path: "/api/superadmin/outlets/get-available-dates/:outletId", Tag: "App management", Summary: "List the dates and slots open for orders", Roles: superAdminOrAdmin, Response: dto.StaffAvailableSlotsResponse{}},
+	{Method: http.MethodGet, Path: "/api/superadmin/outlets/app-features/:outletId", Tag: "App management", Summary: "Get an outlet's app features", Roles: superAdminAdminOrCustomer, Response: dto.AppFeaturesResponse{}},
+	{Method: http.MethodPost, Path: "/api/superadmin/outlets/app-features/", Tag: "App management", Summary: "Turn an outlet's app features on or off", Roles: superAdminOrAdmin, Body: dto.UpdateAppFeaturesRequest{}, Response: dto.MessageResponse{}},
+
+	{Method: http.MethodPost, Path: "/api/superadmin/outlets/sales-report/:outletId/", Tag: "Reports", Summary: "Sales by product", Roles: superAdminOrAdmin, Body: dto.DateRangeRequest{}, Response: []dto.ProductSales{}},
+	{Method: http.MethodPost, Path: "/api/superadmin/outlets/revenue-report/:outletId/", Tag: "Reports", Summary: "Revenue by product", Roles: superAdminOrAdmin, Body: dto.DateRangeRequest{}, Response: []dto.ProductRevenue{}},
+	{Method: http.MethodPost, Path: "/api/superadmin/outlets/revenue-split/:outletId/", Tag: "Reports", Summary: "Revenue by source", Roles: superAdminOrAdmin, Body: dto.DateRangeRequest{}, Response: dto.RevenueSplit{}},
+	{Method: http.MethodPost, Path: "/api/superadmin/outlets/wallet-recharge-by-day/:outletId/", Tag: "Reports", Summary: "Daily wallet recharges", Roles: superAdminOrAdmin, Body: dto.DateRangeRequest{}, Response: []dto.DailyRevenue{}},
+	{Method: http.MethodPost, Path: "/api/superadmin/outlets/profit-loss-trends/:outletId/", Tag: "Reports", Summary: "Monthly profit and loss", Roles: superAdminOrAdmin, Body: dto.ProfitLossRequest{}, Response: []dto.MonthlyProfitLoss{}},
+	{Method: http.MethodPost, Path: "/api/superadmin/outlets/customer-overview/:outletId/", Tag: "Reports", Summary: "New and returning customers", Roles: superAdminOrAdmin, Body: dto.DateRangeRequest{}, Response: dto.CustomerOverview{}},
+	{Method: http.MethodPost, Path: "/api/superadmin/outlets/customer-per-order/:outletId/", Tag: "Reports", Summary: "Daily customers per order", Roles: superAdminOrAdmin, Body: dto.DateRangeRequest{}, Response: []dto.DailyCustomersPerOrder{}},
+
+	{Method: http.MethodGet, Path: "/api/superadmin/dashboard/overview", Tag: "Dashboard", Summary: "Headline numbers across outlets", Roles: superAdminOrAdmin, Response: dto.DashboardOverviewResponse{}},
+	{Method: http.MethodPost, Path: "/api/superadmin/dashboard/revenue-trend", Tag: "Dashboard", Summary: "Daily revenue", Roles: superAdminOrAdmin, Body: dto.DateRangeRequest{}, Response: []dto.DailyRevenue{}},
+	{Method: http.MethodPost, Path: "/api/superadmin/dashboard/order-status-distribution", Tag: "Dashboard", Summary: "Orders by status", Roles: superAdminOrAdmin, Body: dto.DateRangeRequest{}, Response: dto.OrderStatusDistribution{}},
+	{Method: http.MethodPost, Path: "/api/superadmin/dashboard/order-source-distribution", Tag: "Dashboard", Summary: "App and counter order counts", Roles: superAdminOrAdmin, Body: dto.DateRangeRequest{}, Response: dto.OrderTypeBreakdown{}},
+	{Method: http.MethodPost, Path: "/api/superadmin/dashboard/top-selling-items", Tag: "Dashboard", Summary: "Best-selling products", Roles: superAdminOrAdmin, Body: dto.DateRangeRequest{}, Response: []dto.TopSellingItem{}},
+	{Method: http.MethodPost, Path: "/api/superadmin/dashboard/peak-time-slots", Tag: "Dashboard", Summary: "Orders by delivery slot", Roles: superAdminOrAdmin, Body: dto.DateRangeRequest{}, Response: []dto.PeakTimeSlot{}},
+
+	{Method: http.MethodGet, Path: "/api/superadmin/pending-admins", Tag: "Admins", Summary: "List admin signups waiting for verification", Roles: superAdminOnly, Response: []dto.PendingAdmin{}},
+	{Method: http.MethodPost, Path: "/api/superadmin/verify-admin/:adminId", Tag: "Admins", Summary: "Verify an admin and map them to outlets", Roles: superAdminOnly, Body: dto.VerifyAdminRequest{}, Response: dto.VerifyAdminResponse{}},
+	{Method: http.MethodGet, Path: "/api/superadmin/verified-admins", Tag: "Admins", Summary: "List verified admins", Roles: superAdminOnly, Response: []dto.AdminSummary{}},
+	{Method: http.MethodGet, Path: "/api/superadmin/admin/:adminId", Tag: "Admins", Summary: "Get an admin with their outlets and permissions", Roles: superAdminOrAdmin, Response: dto.AdminDetailsResponse{}},
+	{Method: http.MethodDelete, Path: "/api/superadmin/admin/:adminId", Tag: "Admins", Summary: "Delete an admin", Roles: superAdminOnly, Response: dto.MessageResponse{}},
+	{Method: http.MethodPost, Path: "/api/superadmin/map-outlets-to-admin", Tag: "Admins", Summary: "Add outlets to an admin", Roles: superAdminOnly, Body: dto.MapOutletsToAdminRequest{}, Response: dto.MapOutletsResponse{}},
+	{Method: http.MethodPost, Path: "/api/superadmin/assign-admin-permissions", Tag: "Admins", Summary: "Set an admin's permissions per outlet", Roles: superAdminOnly, Body: dto.AssignAdminPermissionsRequest{}, Response: dto.AssignPermissionsResponse{}},
+
+	{Method: http.MethodPost, Path: "/api/superadmin/verify-staff/:userId", Tag: "Staff management", Summary: "Verify a staff signup", Roles: superAdminOnly, Body: dto.VerifyStaffRequest{}, Response: dto.VerifyStaffResponse{}},
+	{Method: http.MethodGet, Path: "/api/superadmin/unverified-staff", Tag: "Staff management", Summary: "List staff signups waiting for verification", Roles: superAdminOnly, List: &superadmin.StaffAccountsList, Response: dto.StaffAccountsResponse{}},
+	{Method: http.MethodGet, Path: "/api/superadmin/verified-staff", Tag: "Staff management", Summary: "List verified staff", Roles: superAdminOnly, List: &superadmin.StaffAccountsList, Response: dto.StaffAccountsResponse{}},
+
+	{Method: http.MethodPost, Path: "/api/superadmin/security/unlock-login", Tag: "Security", Summary: "Clear a login lockout", Roles: superAdminOrAdmin, Body: dto.UnlockLoginRequest{}, Response: dto.MessageResponse{}},
+	{Method: http.MethodGet, Path: "/api/superadmin/security/lockouts", Tag: "Security", Summary: "List login lockouts", Roles: superAdminOnly, Query: dto.LoginLockoutsQuery{}, List: &superadmin.LoginLockoutsList, Response: dto.LoginLockoutsResponse{}},
+
+	{Method: http.MethodGet, Path: "/api/superadmin/audit-events", Tag: "Audit", Summary: "List audit events", Description: "Pass format=csv to download the filtered events as CSV.", Roles: superAdminOnly, Query: dto.AuditEventsQuery{}, List: &superadmin.AuditEventsList, Response: dto.AuditEventsResponse{}},
+
+	{Method: http.MethodGet, Path: "/api/superadmin/jobs/failed", Tag: "Jobs", Summary: "List background jobs that used up their attempts", Roles: superAdminOnly, List: &superadmin.FailedJobsList, Response: dto.FailedJobsResponse{}},
+	{Method: http.MethodPost, Path: "/api/superadmin/jobs/:jobId/retry", Tag: "Jobs", Summary: "Queue a failed job again", Roles: superAdminOnly, Response: dto.JobResponse{}},
 }
@@ -0,0 +1,225 @@
+// Package security holds login brute-force protection shared by the sign-in
+// handlers and the admin unlock endpoint.
+package security
+
+import (
+	"backend_pandhi/pkg/app"
+	"backend_pandhi/pkg/models"
+	"errors"
+	"log/slog"
+	"math"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// backoffBase is the delay after the first failure; it doubles with each
+	// further failure until the lockout threshold is reached
+	backoffBase = time.Second
+	backoffMax  = 5 * time.Minute
+)
+
+// LoginStatus reports whether a sign-in attempt may proceed
+type LoginStatus struct {
+	Allowed    bool
+	Locked     bool
+	RetryAfter time.Duration
+}
+
+// AccountKey builds the throttle key for an account
+func AccountKey(accountType models.AuthAccountType, email string) string {
+	return string(accountType) + ":" + strings.ToLower(strings.TrimSpace(email))
+}
+
+// CheckLogin reports whether a sign-in for the account from ip may be attempted
+// now. It must be called before the password is checked.
+func CheckLogin(a *app.App, accountType models.AuthAccountType, email, ip string) LoginStatus {
+	var throttles []models.LoginThrottle
+	if err := a.DB.
+		Where("(scope = ? AND key = ?) OR (scope = ? AND key = ?)",
+			models.LoginThrottleScopeAccount, AccountKey(accountType, email),
+			models.LoginThrottleScopeIP, ip).
+		Find(&throttles).Error; err != nil {
+		// Fail open: a throttle outage must not lock everyone out
+		slog.Warn("Failed to read login throttle", "error", err)
+		return LoginStatus{Allowed: true}
+	}
+
+	return loginStatus(throttles, a.Clock.Now())
+}
+
+// loginStatus combines the account and IP throttles; the longest wait wins
+func loginStatus(throttles []models.LoginThrottle, now time.Time) LoginStatus {
+	status := LoginStatus{Allowed: true}
+	for _, t := range throttles {
+		if t.LockedUntil != nil && t.LockedUntil.After(now) {
+			status.Allowed = false
+			status.Locked = true
+			if wait := t.LockedUntil.Sub(now); wait > status.RetryAfter {
+				status.RetryAfter = wait
+			}
+		}
+		if t.BlockedUntil != nil && t.BlockedUntil.After(now) {
+			status.Allowed = false
+			if wait := t.BlockedUntil.Sub(now); wait > status.RetryAfter {
+				status.RetryAfter = wait
+			}
+		}
+	}
+
+	return status
+}
+
+// RecordLoginFailure counts a failed sign-in against both the account and the
+// client IP. Accounts back off exponentially and lock after LOGIN_MAX_FAILURES;
+// IPs only lock, at the much higher LOGIN_IP_MAX_FAILURES, because campus
+// networks put many customers behind one address.
+func RecordLoginFailure(a *app.App, accountType models.AuthAccountType, email, ip string) {
+	account := throttlePolicy{
+		maxFailures: a.Config.LoginMaxFailures,
+		lockout:     a.Config.LoginLockout,
+		window:      a.Config.LoginFailureWindow,
+		backoff:     true,
+	}
+	if err := recordFailure(a, models.LoginThrottleScopeAccount, AccountKey(accountType, email), ip, account); err != nil {
+		slog.Warn("Failed to record login failure for account", "error", err)
+	}
+	if ip == "" {
+		return
+	}
+	address := throttlePolicy{
+		maxFailures: a.Config.LoginIPMaxFailures,
+		lockout:     a.Config.LoginLockout,
+		window:      a.Config.LoginFailureWindow,
+	}
+	if err := recordFailure(a, models.LoginThrottleScopeIP, ip, ip, address); err != nil {
+		slog.Warn("Failed to record login failure for IP", "error", err)
+	}
+}
+
+// RecordLoginSuccess clears the account's failure count. The IP counter is left
+// to expire on its own so one valid login cannot reset a password-spraying run.
+func RecordLoginSuccess(a *app.App, accountType models.AuthAccountType, email string) {
+	if err := a.DB.
+		Where("scope = ? AND key = ?", models.LoginThrottleScopeAccount, AccountKey(accountType, email)).
+		Delete(&models.LoginThrottle{}).Error; err != nil {
+		slog.Warn("Failed to reset login throttle", "error", err)
+	}
+}
+
+// throttlePolicy is how one throttle scope reacts to failed sign-ins
+type throttlePolicy struct {
+	maxFailures int
+	lockout     time.Duration
+	window      time.Duration // failures older than this are forgotten
+	backoff     bool          // block for backoffDelay between failures
+}
+
+// fail counts a failure at now against t and reports whether it locked t
+func (p throttlePolicy) fail(t *models.LoginThrottle, now time.Time) bool {
+	// Start counting afresh once a lockout has expired or the failures are stale
+	lockExpired := t.LockedUntil != nil && !t.LockedUntil.After(now)
+	if lockExpired || now.Sub(t.LastFailureAt) > p.window {
+		t.Failures = 0
+		t.LockedUntil = nil
+	}
+
+	t.Failures++
+	t.LastFailureAt = now
+	t.BlockedUntil = nil
+
+	if t.Failures >= p.maxFailures {
+		lockedUntil := now.Add(p.lockout)
+		t.LockedUntil = &lockedUntil
+		return true
+	}
+	if p.backoff {
+		blockedUntil := now.Add(backoffDelay(t.Failures))
+		t.BlockedUntil = &blockedUntil
+	}
+	return false
+}
+
+// backoffDelay is how long to block after the given number of failures
+func backoffDelay(failures int) time.Duration {
+	delay := time.Duration(float64(backoffBase) * math.Pow(2, float64(failures-1)))
+	if delay > backoffMax {
+		delay = backoffMax
+	}
+	return delay
+}
+
+func recordFailure(a *app.App, scope models.LoginThrottleScope, key, ip string, policy throttlePolicy) error {
+	return a.DB.Transaction(func(tx *gorm.DB) error {
+		now := a.Clock.Now()
+
+		// Make sure the row exists, then lock it so concurrent failures all count
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.LoginThrottle{
+			Scope:         scope,
+			Key:           key,
+			LastFailureAt: now,
+		}).Error; err != nil {
+			return err
+		}
+
+		var t models.LoginThrottle
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("scope = ? AND key = ?", scope, key).
+			First(&t).Error; err != nil {
+			return err
+		}
+
+		if policy.fail(&t, now) {
+			if err := tx.Create(&models.LoginLockoutEvent{
+				Scope:       scope,
+				Key:         key,
+				IPAddress:   ip,
+				Failures:    t.Failures,
+				LockedUntil: *t.LockedUntil,
+			}).Error; err != nil {
+				return err
+			}
+			slog.Warn("Login locked", "scope", scope, "key", key, "until", t.LockedUntil.Format(time.RFC3339), "failures", t.Failures)
+		}
+
+		return tx.Model(&t).Updates(map[string]interface{}{
+			"failures":      t.Failures,
+			"lastFailureAt": t.LastFailureAt,
+			"blockedUntil":  t.BlockedUntil,
+			"lockedUntil":   t.LockedUntil,
+		}).Error
+	})
+}
+
+// ErrNotLocked is returned by Unlock when there is nothing to unlock
+var ErrNotLocked = errors.New("not locked")
+
+// Unlock clears the throttle for an account or IP and marks any active lockout
+// events as released by unlockedBy
+func Unlock(a *app.App, scope models.LoginThrottleScope, key, unlockedBy string) error {
+	return a.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("scope = ? AND key = ?", scope, key).Delete(&models.LoginThrottle{})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		now := a.Clock.Now()
+		events := tx.Model(&models.LoginLockoutEvent{}).
+			Where(`scope = ? AND key = ? AND "unlockedAt" IS NULL AND "lockedUntil" > ?`, scope, key, now).
+			Updates(map[string]interface{}{
+				"unlockedAt": now,
+				"unlockedBy": unlockedBy,
+			})
+		if events.Error != nil {
+			return events.Error
+		}
+
+		if result.RowsAffected == 0 && events.RowsAffected == 0 {
+			return ErrNotLocked
+		}
+		return nil
+	})
+}
@@ -0,0 +1,161 @@
+package security
+
+import (
+	"backend_pandhi/pkg/models"
+	"testing"
+	"time"
+)
+
+func TestBackoffDelay(t *testing.T) {
+	for _, tc := range []struct {
+		failures int
+		want     time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{9, 256 * time.Second},
+		{10, backoffMax},
+		{30, backoffMax},
+	} {
+		if got := backoffDelay(tc.failures); got != tc.want {
+			t.Errorf("backoffDelay(%d) = %v, want %v", tc.failures, got, tc.want)
+		}
+	}
+}
+
+func TestThrottlePolicyFail(t *testing.T) {
+	account := throttlePolicy{maxFailures: 5, lockout: 15 * time.Minute, window: time.Hour, backoff: true}
+	address := throttlePolicy{maxFailures: 100, lockout: 15 * time.Minute, window: time.Hour}
+
+	for _, tc := range []struct {
+		name         string
+		policy       throttlePolicy
+		throttle     models.LoginThrottle
+		wantFailures int
+		wantLocked   *time.Time
+		wantBlocked  *time.Time
+	}{
+		{
+			name:         "first account failure backs off",
+			policy:       account,
+			throttle:     models.LoginThrottle{LastFailureAt: testNow},
+			wantFailures: 1,
+			wantBlocked:  after(time.Second),
+		},
+		{
+			name:         "backoff doubles",
+			policy:       account,
+			throttle:     models.LoginThrottle{Failures: 2, LastFailureAt: testNow.Add(-time.Minute), BlockedUntil: after(-58 * time.Second)},
+			wantFailures: 3,
+			wantBlocked:  after(4 * time.Second),
+		},
+		{
+			name:         "threshold locks instead of blocking",
+			policy:       account,
+			throttle:     models.LoginThrottle{Failures: 4, LastFailureAt: testNow.Add(-time.Minute)},
+			wantFailures: 5,
+			wantLocked:   after(15 * time.Minute),
+		},
+		{
+			name:         "stale failures are forgotten",
+			policy:       account,
+			throttle:     models.LoginThrottle{Failures: 4, LastFailureAt: testNow.Add(-time.Hour - time.Second)},
+			wantFailures: 1,
+			wantBlocked:  after(time.Second),
+		},
+		{
+			name:         "expired lockout starts afresh",
+			policy:       account,
+			throttle:     models.LoginThrottle{Failures: 5, LastFailureAt: testNow.Add(-20 * time.Minute), LockedUntil: after(-5 * time.Minute)},
+			wantFailures: 1,
+			wantBlocked:  after(time.Second),
+		},
+		{
+			name:         "IPs do not back off",
+			policy:       address,
+			throttle:     models.LoginThrottle{Failures: 10, LastFailureAt: testNow.Add(-time.Minute)},
+			wantFailures: 11,
+		},
+		{
+			name:         "IPs lock at their own threshold",
+			policy:       address,
+			throttle:     models.LoginThrottle{Failures: 99, LastFailureAt: testNow.Add(-time.Minute)},
+			wantFailures: 100,
+			wantLocked:   after(15 * time.Minute),
+		},
+	} {
+		throttle := tc.throttle
+		locked := tc.policy.fail(&throttle, testNow)
+
+		if throttle.Failures != tc.wantFailures {
+			t.Errorf("%s: failures = %d, want %d", tc.name, throttle.Failures, tc.wantFailures)
+		}
+		if locked != (tc.wantLocked != nil) || !sameTime(throttle.LockedUntil, tc.wantLocked) {
+			t.Errorf("%s: locked = %v until %v, want until %v", tc.name, locked, throttle.LockedUntil, tc.wantLocked)
+		}
+		if !sameTime(throttle.BlockedUntil, tc.wantBlocked) {
+			t.Errorf("%s: blocked until %v, want %v", tc.name, throttle.BlockedUntil, tc.wantBlocked)
+		}
+		if !throttle.LastFailureAt.Equal(testNow) {
+			t.Errorf("%s: last failure at %v, want %v", tc.name, throttle.LastFailureAt, testNow)
+		}
+	}
+}
+
+func TestLoginStatus(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		throttles []models.LoginThrottle
+		want      LoginStatus
+	}{
+		{"no failures", nil, LoginStatus{Allowed: true}},
+		{
+			"backing off",
+			[]models.LoginThrottle{{Failures: 2, BlockedUntil: after(2 * time.Second)}},
+			LoginStatus{RetryAfter: 2 * time.Second},
+		},
+		{
+			"backoff over",
+			[]models.LoginThrottle{{Failures: 2, BlockedUntil: after(-time.Second)}},
+			LoginStatus{Allowed: true},
+		},
+		{
+			"locked",
+			[]models.LoginThrottle{{Failures: 5, LockedUntil: after(10 * time.Minute)}},
+			LoginStatus{Locked: true, RetryAfter: 10 * time.Minute},
+		},
+		{
+			"lock expired",
+			[]models.LoginThrottle{{Failures: 5, LockedUntil: after(-time.Minute)}},
+			LoginStatus{Allowed: true},
+		},
+		{
+			"longest wait wins",
+			[]models.LoginThrottle{
+				{Scope: models.LoginThrottleScopeAccount, Failures: 3, BlockedUntil: after(4 * time.Second)},
+				{Scope: models.LoginThrottleScopeIP, Failures: 100, LockedUntil: after(15 * time.Minute)},
+			},
+			LoginStatus{Locked: true, RetryAfter: 15 * time.Minute},
+		},
+	} {
+		if got := loginStatus(tc.throttles, testNow); got != tc.want {
+			t.Errorf("%s: status = %+v, want %+v", tc.name, got, tc.want)
+		}
+	}
+}
+
+// testNow is when the tests record and check failures
+var testNow = time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+
+func after(d time.Duration) *time.Time {
+	t := testNow.Add(d)
+	return &t
+}
+
+func sameTime(got, want *time.Time) bool {
+	if got == nil || want == nil {
+		return got == want
+	}
+	return got.Equal(*want)
+}
@@ -0,0 +1,89 @@
+package seed
+
+import "backend_pandhi/pkg/models"
+
+// menuItem is a dish every seeded outlet sells
+type menuItem struct {
+	name     string
+	category models.Category
+	price    float64
+	isVeg    bool
+}
+
+// menu covers every models.Category
+var menu = []menuItem{
+	{"Veg Meals", models.CategoryMeals, 80, true},
+	{"Chicken Biryani", models.CategoryMeals, 140, false},
+	{"Curd Rice", models.CategoryMeals, 60, true},
+	{"Chapati Kurma", models.CategoryMeals, 70, true},
+	{"Paneer Tikka", models.CategoryStarters, 110, true},
+	{"Chicken 65", models.CategoryStarters, 120, false},
+	{"Gobi Manchurian", models.CategoryStarters, 90, true},
+	{"Gulab Jamun", models.CategoryDesserts, 40, true},
+	{"Carrot Halwa", models.CategoryDesserts, 50, true},
+	{"Ice Cream Cup", models.CategoryDesserts, 45, true},
+	{"Filter Coffee", models.CategoryBeverages, 25, true},
+	{"Masala Tea", models.CategoryBeverages, 20, true},
+	{"Fresh Lime Soda", models.CategoryBeverages, 35, true},
+	{"Mango Lassi", models.CategoryBeverages, 50, true},
+	{"Mutton Chukka", models.CategorySpecialFoods, 180, false},
+	{"Ghee Roast Dosa", models.CategorySpecialFoods, 90, true},
+}
+
+var outletNames = []string{
+	"Main Canteen", "Library Cafe", "Hostel Mess", "Sports Complex Kiosk",
+	"Engineering Block Cafe", "Medical Campus Canteen",
+}
+
+var firstNames = []string{
+	"Aarav", "Aditi", "Akash", "Ananya", "Arjun", "Divya", "Farhan", "Gayathri",
+	"Harish", "Ishita", "Karthik", "Lakshmi", "Manoj", "Meera", "Naveen", "Nisha",
+	"Pranav", "Priya", "Rahul", "Revathi", "Sanjay", "Shreya", "Tarun", "Vidya",
+}
+
+var lastNames = []string{
+	"Iyer", "Nair", "Reddy", "Sharma", "Menon", "Khan", "Pillai", "Das",
+	"Rao", "Joseph", "Verma", "Krishnan",
+}
+
+var positiveComments = []string{
+	"Tasty and served hot.",
+	"Great portion for the price.",
+	"Loved it, will order again.",
+	"Fresh and well packed.",
+}
+
+var negativeComments = []string{
+	"Too salty today.",
+	"Arrived cold.",
+	"Portion was smaller than usual.",
+	"Took too long to be ready.",
+}
+
+var ticketSubjects = []struct {
+	title       string
+	description string
+}{
+	{"Wallet recharge not reflected", "I recharged my wallet but the balance did not update."},
+	{"Wrong item delivered", "I received a different item from the one I ordered."},
+	{"Refund not received", "My order was cancelled but I have not received the refund."},
+	{"App shows outlet closed", "The app says the outlet is closed during opening hours."},
+	{"Missing item in order", "One of the items in my order was not handed over."},
+}
+
+var resolutionNotes = []string{
+	"Balance corrected after checking the payment.",
+	"Refund credited to the wallet.",
+	"Explained to the customer; no further action needed.",
+}
+
+var expenseKinds = []struct {
+	category string
+	paidTo   string
+	min, max float64
+}{
+	{"Groceries", "City Wholesale Traders", 2000, 6000},
+	{"Vegetables", "Farm Fresh Supplies", 800, 2500},
+	{"Gas", "Indane Distributor", 1500, 3000},
+	{"Maintenance", "Campus Facilities", 500, 4000},
+}
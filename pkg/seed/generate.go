@@ -0,0 +1,777 @@
+package seed
+
+import (
+	"backend_pandhi/pkg/models"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// customer is a seeded customer and the running state of its wallet
+type customer struct {
+	user    models.User
+	details models.CustomerDetails
+	wallet  models.Wallet
+	joined  time.Time
+	orders  int
+}
+
+// pendingTransaction is a wallet transaction whose description names an order
+// that has no ID yet
+type pendingTransaction struct {
+	transaction models.WalletTransaction
+	order       int // index into generator.orders, or -1
+	format      string
+}
+
+// generator builds the data of one run. All randomness comes from rng and
+// the data is generated in a fixed order, so a seed always produces the same
+// rows.
+type generator struct {
+	opts     Options
+	rng      *rand.Rand
+	now      time.Time
+	start    time.Time // midnight, Months before today
+	today    time.Time // midnight today
+	password string
+	domain   string
+	summary  Summary
+
+	outlets   []models.Outlet
+	products  []models.Product
+	menus     [][]models.Product // products by outlet index
+	quality   map[int]float64    // typical rating by product ID
+	customers [][]*customer      // customers by outlet index, in join order
+
+	orders       []models.Order
+	items        [][]models.OrderItem // items by order index
+	transactions []pendingTransaction
+	feedback     []models.Feedback
+}
+
+func newGenerator(opts Options, now time.Time, hashedPassword string) *generator {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	return &generator{
+		opts:     opts,
+		rng:      rand.New(rand.NewSource(opts.Seed)),
+		now:      now,
+		today:    today,
+		start:    today.AddDate(0, -opts.Months, 0),
+		password: hashedPassword,
+		domain:   domain(opts.Namespace),
+		quality:  map[int]float64{},
+	}
+}
+
+func (g *generator) email(local string, n int) string {
+	return fmt.Sprintf("%s-%d@%s", local, n, g.domain)
+}
+
+func (g *generator) pick(n int) int {
+	return g.rng.Intn(n)
+}
+
+func (g *generator) chance(p float64) bool {
+	return g.rng.Float64() < p
+}
+
+// within returns a time in [from, from+d)
+func (g *generator) within(from time.Time, d time.Duration) time.Time {
+	return from.Add(time.Duration(g.rng.Int63n(int64(d))))
+}
+
+// write generates the data and inserts it through tx
+func (g *generator) write(tx *gorm.DB) error {
+	for _, step := range []func(*gorm.DB) error{
+		g.writeOutlets,
+		g.writeProducts,
+		g.writeStaff,
+		g.writeAdmins,
+		g.writeCustomers,
+		g.writeOrders,
+		g.writeTickets,
+		g.writeExpenses,
+	} {
+		if err := step(tx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *generator) writeOutlets(tx *gorm.DB) error {
+	for i := 0; i < 2*g.opts.Scale; i++ {
+		name := outletNames[i%len(outletNames)]
+		if i >= len(outletNames) {
+			name = fmt.Sprintf("%s %d", name, i/len(outletNames)+1)
+		}
+		email := g.email("outlet", i+1)
+		address := fmt.Sprintf("Block %c, University Campus", 'A'+i%26)
+		phone := fmt.Sprintf("+9180%08d", g.opts.Seed%1000*100000+int64(i))
+		g.outlets = append(g.outlets, models.Outlet{
+			Name:      fmt.Sprintf("%s (%s)", name, g.opts.Namespace),
+			Email:     &email,
+			Address:   &address,
+			Phone:     &phone,
+			IsActive:  true,
+			CreatedAt: g.start.AddDate(0, -1, 0),
+		})
+	}
+	g.summary.Outlets = len(g.outlets)
+	return tx.Create(&g.outlets).Error
+}
+
+func (g *generator) writeProducts(tx *gorm.DB) error {
+	g.menus = make([][]models.Product, len(g.outlets))
+	for i, outlet := range g.outlets {
+		for _, item := range menu {
+			description := fmt.Sprintf("%s from the %s kitchen", item.name, outlet.Name)
+			g.menus[i] = append(g.menus[i], models.Product{
+				Name:        fmt.Sprintf("%s (%s %d)", item.name, g.opts.Namespace, i+1),
+				Description: &description,
+				Price:       item.price,
+				OutletID:    outlet.ID,
+				Category:    item.category,
+				IsVeg:       item.isVeg,
+			})
+		}
+		if err := tx.Create(&g.menus[i]).Error; err != nil {
+			return err
+		}
+		g.products = append(g.products, g.menus[i]...)
+	}
+
+	var inventory []models.Inventory
+	var history []models.StockHistory
+	for _, p := range g.products {
+		g.quality[p.ID] = 2.8 + g.rng.Float64()*2
+		// About one product in six starts below its threshold
+		threshold := 10 + g.pick(16)
+		quantity := threshold + g.pick(120)
+		if g.chance(1.0 / 6) {
+			quantity = g.pick(threshold)
+		}
+		inventory = append(inventory, models.Inventory{ProductID: p.ID, OutletID: p.OutletID, Quantity: quantity, Threshold: threshold})
+		history = append(history, models.StockHistory{ProductID: p.ID, OutletID: p.OutletID, Quantity: quantity, Action: models.StockActionAdd, Timestamp: g.start})
+	}
+	g.summary.Products = len(g.products)
+	if err := tx.CreateInBatches(&inventory, batchSize).Error; err != nil {
+		return err
+	}
+	return tx.CreateInBatches(&history, batchSize).Error
+}
+
+func (g *generator) name() string {
+	return firstNames[g.pick(len(firstNames))] + " " + lastNames[g.pick(len(lastNames))]
+}
+
+func (g *generator) writeStaff(tx *gorm.DB) error {
+	n := 0
+	create := func(outletID *int, verified bool, role string, permissions []models.PermissionType) error {
+		n++
+		user := models.User{
+			Name:       g.name(),
+			Email:      g.email("staff", n),
+			Password:   &g.password,
+			Role:       models.RoleStaff,
+			OutletID:   outletID,
+			IsVerified: verified,
+			CreatedAt:  g.start,
+		}
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		g.summary.Staff++
+		if !verified {
+			return nil
+		}
+
+		staff := models.StaffDetails{UserID: user.ID, StaffRole: role}
+		if err := tx.Create(&staff).Error; err != nil {
+			return err
+		}
+		for _, perm := range models.DefaultStaffPermissions {
+			granted := false
+			for _, p := range permissions {
+				granted = granted || p == perm
+			}
+			if err := tx.Create(&models.StaffPermission{StaffID: staff.ID, Type: perm, IsGranted: granted}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for i := range g.outlets {
+		outletID := g.outlets[i].ID
+		if err := create(&outletID, true, "Manager", models.DefaultStaffPermissions); err != nil {
+			return err
+		}
+		if err := create(&outletID, true, "Staff", []models.PermissionType{models.PermissionTypeBilling}); err != nil {
+			return err
+		}
+		if err := tx.Model(&g.outlets[i]).Update("staffCount", 2).Error; err != nil {
+			return err
+		}
+	}
+	// One sign-up waiting for a superadmin
+	return create(nil, false, "", nil)
+}
+
+func (g *generator) writeAdmins(tx *gorm.DB) error {
+	admin := models.Admin{Name: g.name(), Email: g.email("admin", 1), Password: g.password, IsVerified: true}
+	if err := tx.Create(&admin).Error; err != nil {
+		return err
+	}
+	for _, outlet := range g.outlets {
+		link := models.AdminOutlet{AdminID: admin.ID, OutletID: outlet.ID}
+		if err := tx.Create(&link).Error; err != nil {
+			return err
+		}
+		for _, perm := range models.DefaultAdminPermissions {
+			if err := tx.Create(&models.AdminPermission{AdminOutletID: link.ID, AdminID: &admin.ID, Type: perm, IsGranted: true}).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	// One sign-up waiting for a superadmin
+	pending := models.Admin{Name: g.name(), Email: g.email("admin", 2), Password: g.password}
+	if err := tx.Create(&pending).Error; err != nil {
+		return err
+	}
+	g.summary.Admins = 2
+	return nil
+}
+
+func (g *generator) writeCustomers(tx *gorm.DB) error {
+	g.customers = make([][]*customer, len(g.outlets))
+	period := g.today.Sub(g.start)
+	n := 0
+	for i, outlet := range g.outlets {
+		count := 25 * g.opts.Scale
+		for j := 0; j < count; j++ {
+			n++
+			// A third of the customers predate the history; the rest sign up
+			// steadily through it
+			joined := g.within(g.start.AddDate(0, -1, 0), g.start.Sub(g.start.AddDate(0, -1, 0)))
+			if j >= count/3 {
+				joined = g.start.Add(period * time.Duration(j-count/3) / time.Duration(count-count/3))
+				joined = g.within(joined, 12*time.Hour)
+			}
+			if joined.After(g.now) {
+				joined = g.now
+			}
+			outletID := outlet.ID
+			c := &customer{
+				user: models.User{
+					Name:       g.name(),
+					Email:      g.email("customer", n),
+					Password:   &g.password,
+					Role:       models.RoleCustomer,
+					OutletID:   &outletID,
+					IsVerified: g.chance(0.9),
+					CreatedAt:  joined,
+				},
+				joined: joined,
+			}
+			g.customers[i] = append(g.customers[i], c)
+		}
+	}
+
+	var users []*models.User
+	for _, cs := range g.customers {
+		for _, c := range cs {
+			users = append(users, &c.user)
+		}
+	}
+	if err := tx.CreateInBatches(users, batchSize).Error; err != nil {
+		return err
+	}
+
+	var details []*models.CustomerDetails
+	for _, cs := range g.customers {
+		for _, c := range cs {
+			year := 1 + g.pick(4)
+			degree := models.TypeOfDegreeUG
+			if g.chance(0.25) {
+				degree = models.TypeOfDegreePG
+				year = 1 + g.pick(2)
+			}
+			c.details = models.CustomerDetails{UserID: c.user.ID, YearOfStudy: &year, Degree: &degree}
+			details = append(details, &c.details)
+		}
+	}
+	if err := tx.CreateInBatches(details, batchSize).Error; err != nil {
+		return err
+	}
+
+	var carts []models.Cart
+	var wallets []*models.Wallet
+	for _, cs := range g.customers {
+		for _, c := range cs {
+			carts = append(carts, models.Cart{CustomerID: c.details.ID})
+			c.wallet = models.Wallet{CustomerID: c.details.ID}
+			wallets = append(wallets, &c.wallet)
+		}
+	}
+	if err := tx.CreateInBatches(&carts, batchSize).Error; err != nil {
+		return err
+	}
+	g.summary.Customers = n
+	return tx.CreateInBatches(wallets, batchSize).Error
+}
+
+var slotHours = []struct {
+	slot models.DeliverySlot
+	hour int
+}{
+	{models.DeliverySlot1112, 11},
+	{models.DeliverySlot1213, 12},
+	{models.DeliverySlot1314, 13},
+	{models.DeliverySlot1415, 14},
+	{models.DeliverySlot1516, 15},
+	{models.DeliverySlot1617, 16},
+}
+
+// pastStatuses are the outcomes of orders from earlier days, weighted
+var pastStatuses = []struct {
+	status models.OrderStatus
+	weight float64
+}{
+	{models.OrderStatusDelivered, 0.80},
+	{models.OrderStatusPartiallyDelivered, 0.05},
+	{models.OrderStatusCancelled, 0.09},
+	{models.OrderStatusPartialCancel, 0.06},
+}
+
+// forcedStatuses guarantee every status appears: the first order of the
+// first outlet on each of the last days before today takes one of them
+var forcedStatuses = []models.OrderStatus{
+	models.OrderStatusDelivered,
+	models.OrderStatusPartiallyDelivered,
+	models.OrderStatusCancelled,
+	models.OrderStatusPartialCancel,
+}
+
+func (g *generator) pastStatus() models.OrderStatus {
+	r := g.rng.Float64()
+	for _, s := range pastStatuses {
+		if r < s.weight {
+			return s.status
+		}
+		r -= s.weight
+	}
+	return models.OrderStatusDelivered
+}
+
+func (g *generator) writeOrders(tx *gorm.DB) error {
+	days := int(g.today.Sub(g.start).Hours()/24+0.5) + 1
+	for d := 0; d < days; d++ {
+		day := g.start.AddDate(0, 0, d)
+		// Demand grows through the period and drops at weekends
+		progress := float64(d) / float64(days)
+		factor := 0.7 + 0.6*progress
+		switch day.Weekday() {
+		case time.Saturday:
+			factor *= 0.6
+		case time.Sunday:
+			factor *= 0.3
+		}
+		for i := range g.outlets {
+			count := int(6*float64(g.opts.Scale)*factor + g.rng.Float64()*3)
+			for k := 0; k < count; k++ {
+				var forced models.OrderStatus
+				if i == 0 && k == 0 && days-1-d >= 1 && days-1-d <= len(forcedStatuses) {
+					forced = forcedStatuses[days-1-d-1]
+				}
+				g.order(i, day, forced)
+			}
+		}
+	}
+	// Today's first order is always still open
+	if len(g.orders) > 0 {
+		g.ensurePending()
+	}
+
+	g.summary.Orders = len(g.orders)
+	if err := tx.CreateInBatches(&g.orders, batchSize).Error; err != nil {
+		return err
+	}
+
+	var items []models.OrderItem
+	for i, order := range g.orders {
+		for _, item := range g.items[i] {
+			item.OrderID = order.ID
+			items = append(items, item)
+		}
+	}
+	g.summary.OrderItems = len(items)
+	if err := tx.CreateInBatches(&items, batchSize).Error; err != nil {
+		return err
+	}
+
+	var transactions []models.WalletTransaction
+	for _, p := range g.transactions {
+		t := p.transaction
+		if p.order >= 0 {
+			t.Description = fmt.Sprintf(p.format, g.orders[p.order].ID)
+		}
+		transactions = append(transactions, t)
+	}
+	g.summary.WalletTransactions = len(transactions)
+	if err := tx.CreateInBatches(&transactions, batchSize).Error; err != nil {
+		return err
+	}
+
+	if err := g.writeFeedback(tx); err != nil {
+		return err
+	}
+
+	// Wallet totals and order counts follow from the transactions and orders
+	for _, cs := range g.customers {
+		for _, c := range cs {
+			if err := tx.Model(&c.wallet).Updates(map[string]interface{}{
+				"balance":        c.wallet.Balance,
+				"totalRecharged": c.wallet.TotalRecharged,
+				"totalUsed":      c.wallet.TotalUsed,
+				"lastRecharged":  c.wallet.LastRecharged,
+				"lastOrder":      c.wallet.LastOrder,
+			}).Error; err != nil {
+				return err
+			}
+			if c.orders > 0 {
+				if err := tx.Model(&c.details).Update("orderCount", c.orders).Error; err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// ensurePending makes the newest order of today pending when today has
+// orders but none is pending
+func (g *generator) ensurePending() {
+	last := len(g.orders) - 1
+	if g.orders[last].CreatedAt.Before(g.today) {
+		return
+	}
+	for i := last; i >= 0 && !g.orders[i].CreatedAt.Before(g.today); i-- {
+		if g.orders[i].Status == string(models.OrderStatusPending) {
+			return
+		}
+	}
+	g.orders[last].Status = string(models.OrderStatusPending)
+	g.orders[last].DeliveredAt = nil
+	for j := range g.items[last] {
+		g.items[last][j].Status = models.OrderItemStatusNotDelivered
+	}
+}
+
+// order generates one order of outlet i on day, with its items and wallet
+// transactions
+func (g *generator) order(i int, day time.Time, forced models.OrderStatus) {
+	slot := slotHours[g.pick(len(slotHours))]
+	createdAt := day.Add(time.Duration(slot.hour)*time.Hour + time.Duration(g.pick(60))*time.Minute)
+	if createdAt.After(g.now) {
+		return
+	}
+
+	// APP orders need a customer who had signed up by then
+	var c *customer
+	if g.chance(0.6) || forced != "" {
+		joined := 0
+		for joined < len(g.customers[i]) && g.customers[i][joined].joined.Before(createdAt) {
+			joined++
+		}
+		if joined > 0 {
+			c = g.customers[i][g.pick(joined)]
+		}
+	}
+
+	// One to three different dishes
+	menu := g.menus[i]
+	var items []models.OrderItem
+	total := 0.0
+	for _, idx := range g.rng.Perm(len(menu))[:1+g.pick(3)] {
+		qty := 1
+		if g.chance(0.25) {
+			qty = 2
+		}
+		items = append(items, models.OrderItem{ProductID: menu[idx].ID, Quantity: qty, UnitPrice: menu[idx].Price})
+		total += float64(qty) * menu[idx].Price
+	}
+
+	order := models.Order{
+		OutletID:    g.outlets[i].ID,
+		TotalAmount: total,
+		CreatedAt:   createdAt,
+		Type:        models.OrderTypeManual,
+	}
+
+	status := forced
+	switch {
+	case status != "":
+	case !day.Before(g.today):
+		status = models.OrderStatusDelivered
+		if g.chance(0.7) {
+			status = models.OrderStatusPending
+		}
+	default:
+		status = g.pastStatus()
+	}
+	// Counter orders are paid on the spot, so they are only ever
+	// delivered or cancelled
+	if c == nil && status != models.OrderStatusPending && status != models.OrderStatusCancelled {
+		status = models.OrderStatusDelivered
+	}
+	order.Status = string(status)
+
+	// Items are handed over in full, in part or not at all
+	delivered := len(items)
+	switch status {
+	case models.OrderStatusPending, models.OrderStatusCancelled:
+		delivered = 0
+	case models.OrderStatusPartiallyDelivered, models.OrderStatusPartialCancel:
+		if len(items) == 1 {
+			items = append(items, models.OrderItem{ProductID: menu[0].ID, Quantity: 1, UnitPrice: menu[0].Price})
+			if items[0].ProductID == menu[0].ID {
+				items[1] = models.OrderItem{ProductID: menu[1].ID, Quantity: 1, UnitPrice: menu[1].Price}
+			}
+			total += items[1].UnitPrice
+			order.TotalAmount = total
+		}
+		delivered = 1 + g.pick(len(items)-1)
+	}
+	for j := range items {
+		items[j].Status = models.OrderItemStatusNotDelivered
+		if j < delivered {
+			items[j].Status = models.OrderItemStatusDelivered
+		}
+	}
+	if delivered > 0 {
+		deliveredAt := createdAt.Add(time.Duration(10+g.pick(30)) * time.Minute)
+		if deliveredAt.After(g.now) {
+			deliveredAt = g.now
+		}
+		order.DeliveredAt = &deliveredAt
+	}
+
+	index := len(g.orders)
+	if c == nil {
+		order.PaymentMethod = []models.PaymentMethod{models.PaymentMethodCash, models.PaymentMethodUPI, models.PaymentMethodCard}[g.pick(3)]
+	} else {
+		deliverySlot := slot.slot
+		deliveryDate := createdAt
+		order.Type = models.OrderTypeApp
+		order.PaymentMethod = models.PaymentMethodWallet
+		order.CustomerID = &c.details.ID
+		order.DeliverySlot = &deliverySlot
+		order.DeliveryDate = &deliveryDate
+		order.IsPreOrder = g.chance(0.1)
+		c.orders++
+		g.pay(c, index, total, createdAt, status, items[delivered:])
+	}
+
+	g.orders = append(g.orders, order)
+	g.items = append(g.items, items)
+}
+
+// pay records the wallet side of an APP order: a top-up when the balance
+// is short, the debit, and the refund of whatever was cancelled
+func (g *generator) pay(c *customer, order int, total float64, at time.Time, status models.OrderStatus, undelivered []models.OrderItem) {
+	if c.wallet.Balance < total {
+		amount := math.Ceil((total-c.wallet.Balance)/100) * 100
+		amount += []float64{0, 100, 200, 400}[g.pick(4)]
+		method := models.PaymentMethodUPI
+		switch r := g.rng.Float64(); {
+		case r < 0.25:
+			method = models.PaymentMethodCash
+		case r < 0.45:
+			method = models.PaymentMethodCard
+		}
+		rechargedAt := at.Add(-time.Duration(5+g.pick(120)) * time.Minute)
+		g.record(c, -1, "", models.WalletTransaction{
+			Amount:    amount,
+			Method:    method,
+			Status:    models.WalletTransTypeRecharge,
+			CreatedAt: rechargedAt,
+		})
+		c.wallet.TotalRecharged += amount
+		c.wallet.LastRecharged = &rechargedAt
+	}
+
+	g.record(c, -1, "", models.WalletTransaction{
+		Amount:    -total,
+		Method:    models.PaymentMethodWallet,
+		Status:    models.WalletTransTypeDeduct,
+		CreatedAt: at,
+	})
+	c.wallet.TotalUsed += total
+	orderedAt := at
+	c.wallet.LastOrder = &orderedAt
+
+	// Refunds follow the handlers: customer cancellations are credits,
+	// staff partial cancellations are recorded as recharges
+	refundAt := at.Add(time.Duration(15+g.pick(60)) * time.Minute)
+	if refundAt.After(g.now) {
+		refundAt = g.now
+	}
+	switch status {
+	case models.OrderStatusCancelled:
+		g.record(c, order, "Refund for order #%d", models.WalletTransaction{
+			Amount:    total,
+			Status:    models.TransactionTypeCredit,
+			CreatedAt: refundAt,
+		})
+	case models.OrderStatusPartialCancel:
+		refund := 0.0
+		for _, item := range undelivered {
+			refund += float64(item.Quantity) * item.UnitPrice
+		}
+		g.record(c, -1, "", models.WalletTransaction{
+			Amount:    refund,
+			Method:    models.PaymentMethodWallet,
+			Status:    models.WalletTransTypeRecharge,
+			CreatedAt: refundAt,
+		})
+	}
+}
+
+// record adds a transaction to the customer's wallet and its balance
+func (g *generator) record(c *customer, order int, format string, t models.WalletTransaction) {
+	t.WalletID = c.wallet.ID
+	c.wallet.Balance += t.Amount
+	g.transactions = append(g.transactions, pendingTransaction{transaction: t, order: order, format: format})
+}
+
+// rating scatters a product's typical rating by up to a point and a half
+func (g *generator) rating(typical float64) float64 {
+	r := math.Round(typical + (g.rng.Float64()-0.5)*3)
+	return math.Max(1, math.Min(5, r))
+}
+
+// writeFeedback rates the delivered items of some APP orders
+func (g *generator) writeFeedback(tx *gorm.DB) error {
+	userOf := map[int]int{}
+	for _, cs := range g.customers {
+		for _, c := range cs {
+			userOf[c.details.ID] = c.user.ID
+		}
+	}
+
+	for i, order := range g.orders {
+		// Customers rate all or nothing of an order
+		if order.Type != models.OrderTypeApp || order.DeliveredAt == nil || !g.chance(0.35) {
+			continue
+		}
+		createdAt := order.DeliveredAt.Add(time.Duration(1+g.pick(180)) * time.Minute)
+		if createdAt.After(g.now) {
+			continue
+		}
+		for _, item := range g.items[i] {
+			if item.Status != models.OrderItemStatusDelivered {
+				continue
+			}
+			typical := g.quality[item.ProductID]
+			f := models.Feedback{
+				UserID:         userOf[*order.CustomerID],
+				ProductID:      item.ProductID,
+				OrderID:        order.ID,
+				RatingOverall:  g.rating(typical),
+				RatingTaste:    g.rating(typical),
+				RatingQuality:  g.rating(typical),
+				RatingQuantity: g.rating(typical),
+				CreatedAt:      createdAt,
+			}
+			if g.chance(0.4) {
+				comments := positiveComments
+				if f.RatingOverall <= 2 {
+					comments = negativeComments
+				}
+				comment := comments[g.pick(len(comments))]
+				f.Comment = &comment
+			}
+			g.feedback = append(g.feedback, f)
+		}
+	}
+	g.summary.Feedback = len(g.feedback)
+	if len(g.feedback) == 0 {
+		return nil
+	}
+	return tx.CreateInBatches(&g.feedback, batchSize).Error
+}
+
+func (g *generator) writeTickets(tx *gorm.DB) error {
+	var tickets []models.Ticket
+	priorities := []models.Priority{models.PriorityLow, models.PriorityMedium, models.PriorityHigh}
+	for _, cs := range g.customers {
+		for _, c := range cs {
+			if !g.chance(0.12) || !c.joined.Before(g.now) {
+				continue
+			}
+			subject := ticketSubjects[g.pick(len(ticketSubjects))]
+			createdAt := g.within(c.joined, g.now.Sub(c.joined))
+			ticket := models.Ticket{
+				CustomerID:  c.details.ID,
+				Title:       subject.title,
+				Description: subject.description,
+				Priority:    priorities[g.pick(len(priorities))],
+				Status:      models.TicketStatusOpen,
+				CreatedAt:   createdAt,
+			}
+			// Older tickets have been dealt with
+			switch age := g.now.Sub(createdAt); {
+			case age > 7*24*time.Hour:
+				resolvedAt := createdAt.Add(time.Duration(2+g.pick(70)) * time.Hour)
+				note := resolutionNotes[g.pick(len(resolutionNotes))]
+				ticket.Status = models.TicketStatusClosed
+				ticket.ResolvedAt = &resolvedAt
+				ticket.ResolutionNote = &note
+			case age > 24*time.Hour && g.chance(0.5):
+				ticket.Status = models.TicketStatusInProgress
+			}
+			tickets = append(tickets, ticket)
+		}
+	}
+	g.summary.Tickets = len(tickets)
+	if len(tickets) == 0 {
+		return nil
+	}
+	return tx.CreateInBatches(&tickets, batchSize).Error
+}
+
+func (g *generator) writeExpenses(tx *gorm.DB) error {
+	var expenses []models.Expense
+	for _, outlet := range g.outlets {
+		for week := g.start; week.Before(g.today); week = week.AddDate(0, 0, 7) {
+			for k := 0; k < 2; k++ {
+				kind := expenseKinds[g.pick(len(expenseKinds))]
+				amount := math.Round(kind.min + g.rng.Float64()*(kind.max-kind.min))
+				date := week.AddDate(0, 0, g.pick(7))
+				if date.After(g.now) {
+					continue
+				}
+				expenses = append(expenses, models.Expense{
+					OutletID:    outlet.ID,
+					Description: fmt.Sprintf("%s for %s", kind.category, outlet.Name),
+					Category:    kind.category,
+					Amount:      amount,
+					Method:      []models.PaymentMethod{models.PaymentMethodCash, models.PaymentMethodUPI}[g.pick(2)],
+					PaidTo:      kind.paidTo,
+					ExpenseDate: date,
+					CreatedAt:   date,
+				})
+			}
+		}
+	}
+	g.summary.Expenses = len(expenses)
+	if len(expenses) == 0 {
+		return nil
+	}
+	return tx.CreateInBatches(&expenses, batchSize).Error
+}
@@ -0,0 +1,225 @@
+// Package seed fills a development database with realistic demo data.
+//
+// Everything a run creates belongs to a namespace: outlets, users and admins
+// carry email addresses under <namespace>.seed.example.com, and every other
+// row hangs off one of them. Running the seeder again with the same namespace
+// first deletes that data, so a run is idempotent and the same seed value
+// produces the same data relative to the day it runs. Rows outside the
+// namespace are never touched.
+package seed
+
+import (
+	"backend_pandhi/pkg/app"
+	"backend_pandhi/pkg/models"
+	"backend_pandhi/pkg/ratings"
+	"backend_pandhi/pkg/utils"
+	"context"
+	"fmt"
+	"regexp"
+
+	"gorm.io/gorm"
+)
+
+// Password is the password of every seeded account
+const Password = "demo1234"
+
+// batchSize is the number of rows written per INSERT
+const batchSize = 500
+
+// Options control what a run generates. Zero values take the defaults.
+type Options struct {
+	// Namespace names the data set; default "demo"
+	Namespace string
+	// Seed drives every random choice; default 1
+	Seed int64
+	// Scale multiplies the number of outlets, customers and orders; default 1
+	Scale int
+	// Months of order history to generate, ending today; default 3
+	Months int
+}
+
+// Summary counts the rows created by a run
+type Summary struct {
+	Outlets            int
+	Products           int
+	Staff              int
+	Admins             int
+	Customers          int
+	Orders             int
+	OrderItems         int
+	WalletTransactions int
+	Feedback           int
+	Tickets            int
+	Expenses           int
+}
+
+var namespacePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,31}$`)
+
+func (o *Options) setDefaults() error {
+	if o.Namespace == "" {
+		o.Namespace = "demo"
+	}
+	if !namespacePattern.MatchString(o.Namespace) {
+		return fmt.Errorf("namespace %q must be up to 32 lowercase letters, digits and dashes", o.Namespace)
+	}
+	if o.Seed == 0 {
+		o.Seed = 1
+	}
+	if o.Scale == 0 {
+		o.Scale = 1
+	}
+	if o.Months == 0 {
+		o.Months = 3
+	}
+	if o.Scale < 0 || o.Months < 0 {
+		return fmt.Errorf("scale and months must be positive")
+	}
+	return nil
+}
+
+// domain is the email domain that marks rows of the namespace
+func domain(namespace string) string {
+	return namespace + ".seed.example.com"
+}
+
+// Run replaces the data of the namespace with freshly generated data, in a
+// single transaction
+func Run(ctx context.Context, a *app.App, opts Options) (Summary, error) {
+	if err := opts.setDefaults(); err != nil {
+		return Summary{}, err
+	}
+
+	hashedPassword, err := utils.HashPassword(Password)
+	if err != nil {
+		return Summary{}, err
+	}
+
+	g := newGenerator(opts, a.Clock.Now(), hashedPassword)
+	err = a.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := reset(tx, opts.Namespace); err != nil {
+			return err
+		}
+		if err := g.write(tx); err != nil {
+			return err
+		}
+
+		// Product ratings are derived from the feedback just written
+		productIDs := make([]int, len(g.products))
+		for i, p := range g.products {
+			productIDs[i] = p.ID
+		}
+		_, err := ratings.Recompute(tx, g.now, productIDs...)
+		return err
+	})
+	if err != nil {
+		return Summary{}, err
+	}
+	return g.summary, nil
+}
+
+// Reset deletes the data of a namespace
+func Reset(ctx context.Context, a *app.App, namespace string) error {
+	opts := Options{Namespace: namespace}
+	if err := opts.setDefaults(); err != nil {
+		return err
+	}
+	return a.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return reset(tx, opts.Namespace)
+	})
+}
+
+// reset deletes the namespace's outlets, users and admins along with every
+// row that refers to them, children first since the foreign keys restrict
+// deletes
+func reset(tx *gorm.DB, namespace string) error {
+	pattern := "%@" + domain(namespace)
+
+	var outletIDs, userIDs, adminIDs []int
+	if err := pluck(tx, &models.Outlet{}, &outletIDs, "email LIKE ?", pattern); err != nil {
+		return err
+	}
+	if err := pluck(tx, &models.User{}, &userIDs, "email LIKE ?", pattern); err != nil {
+		return err
+	}
+	if err := pluck(tx, &models.Admin{}, &adminIDs, "email LIKE ?", pattern); err != nil {
+		return err
+	}
+
+	var customerIDs, staffIDs, productIDs, orderIDs, walletIDs, cartIDs, adminOutletIDs, scheduledIDs []int
+	for _, q := range []struct {
+		model interface{}
+		dest  *[]int
+		where string
+		args  []interface{}
+	}{
+		{&models.CustomerDetails{}, &customerIDs, `"userId" IN ?`, []interface{}{userIDs}},
+		{&models.StaffDetails{}, &staffIDs, `"userId" IN ?`, []interface{}{userIDs}},
+		{&models.Product{}, &productIDs, `"outletId" IN ?`, []interface{}{outletIDs}},
+		{&models.Order{}, &orderIDs, `"outletId" IN ? OR "customerId" IN ?`, []interface{}{outletIDs, &customerIDs}},
+		{&models.Wallet{}, &walletIDs, `"customerId" IN ?`, []interface{}{&customerIDs}},
+		{&models.Cart{}, &cartIDs, `"customerId" IN ?`, []interface{}{&customerIDs}},
+		{&models.AdminOutlet{}, &adminOutletIDs, `"outletId" IN ? OR "adminId" IN ?`, []interface{}{outletIDs, adminIDs}},
+		{&models.ScheduledNotification{}, &scheduledIDs, `"outletId" IN ?`, []interface{}{outletIDs}},
+	} {
+		if err := pluck(tx, q.model, q.dest, q.where, deref(q.args)...); err != nil {
+			return err
+		}
+	}
+
+	for _, d := range []struct {
+		model interface{}
+		where string
+		args  []interface{}
+	}{
+		{&models.Feedback{}, `"orderId" IN ? OR "userId" IN ? OR "productId" IN ?`, []interface{}{orderIDs, userIDs, productIDs}},
+		{&models.OrderItem{}, `"orderId" IN ? OR "productId" IN ?`, []interface{}{orderIDs, productIDs}},
+		{&models.Order{}, `id IN ?`, []interface{}{orderIDs}},
+		{&models.WalletTransaction{}, `"walletId" IN ?`, []interface{}{walletIDs}},
+		{&models.Wallet{}, `id IN ?`, []interface{}{walletIDs}},
+		{&models.CartItem{}, `"cartId" IN ? OR "productId" IN ?`, []interface{}{cartIDs, productIDs}},
+		{&models.Cart{}, `id IN ?`, []interface{}{cartIDs}},
+		{&models.Ticket{}, `"customerId" IN ?`, []interface{}{customerIDs}},
+		{&models.CustomerDetails{}, `id IN ?`, []interface{}{customerIDs}},
+		{&models.StaffPermission{}, `"staffId" IN ?`, []interface{}{staffIDs}},
+		{&models.StaffDetails{}, `id IN ?`, []interface{}{staffIDs}},
+		{&models.AdminPermission{}, `"adminOutletId" IN ? OR "adminId" IN ?`, []interface{}{adminOutletIDs, adminIDs}},
+		{&models.AdminOutlet{}, `id IN ?`, []interface{}{adminOutletIDs}},
+		{&models.Admin{}, `id IN ?`, []interface{}{adminIDs}},
+		{&models.NotificationDelivery{}, `"userId" IN ? OR "scheduledNotificationId" IN ?`, []interface{}{userIDs, scheduledIDs}},
+		{&models.ScheduledNotification{}, `id IN ?`, []interface{}{scheduledIDs}},
+		{&models.Notification{}, `"outletId" IN ? OR "productId" IN ?`, []interface{}{outletIDs, productIDs}},
+		{&models.UserDeviceToken{}, `"userId" IN ?`, []interface{}{userIDs}},
+		{&models.UserFreeQuota{}, `"userId" IN ?`, []interface{}{userIDs}},
+		{&models.StockHistory{}, `"outletId" IN ? OR "productId" IN ?`, []interface{}{outletIDs, productIDs}},
+		{&models.Inventory{}, `"outletId" IN ? OR "productId" IN ?`, []interface{}{outletIDs, productIDs}},
+		{&models.Product{}, `id IN ?`, []interface{}{productIDs}},
+		{&models.Expense{}, `"outletId" IN ?`, []interface{}{outletIDs}},
+		{&models.OutletAvailability{}, `"outletId" IN ?`, []interface{}{outletIDs}},
+		{&models.OutletAppManagement{}, `"outletId" IN ?`, []interface{}{outletIDs}},
+		{&models.User{}, `id IN ?`, []interface{}{userIDs}},
+		{&models.Outlet{}, `id IN ?`, []interface{}{outletIDs}},
+	} {
+		if err := tx.Where(d.where, d.args...).Delete(d.model).Error; err != nil {
+			return fmt.Errorf("failed to delete seeded %T rows: %w", d.model, err)
+		}
+	}
+	return nil
+}
+
+func pluck(tx *gorm.DB, model interface{}, dest *[]int, where string, args ...interface{}) error {
+	return tx.Model(model).Where(where, args...).Pluck("id", dest).Error
+}
+
+// deref resolves arguments given as pointers to ID lists that were filled
+// by earlier queries of the same loop
+func deref(args []interface{}) []interface{} {
+	out := make([]interface{}, len(args))
+	for i, arg := range args {
+		if ids, ok := arg.(*[]int); ok {
+			out[i] = *ids
+		} else {
+			out[i] = arg
+		}
+	}
+	return out
+}
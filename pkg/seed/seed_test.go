@@ -0,0 +1,83 @@
+package seed_test
+
+import (
+	"backend_pandhi/pkg/maintenance"
+	"backend_pandhi/pkg/models"
+	"backend_pandhi/pkg/seed"
+	"backend_pandhi/pkg/testenv"
+	"context"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(testenv.Main(m))
+}
+
+// evening pins the clock after the last delivery slot of today, so today
+// has both pending and delivered orders
+type evening struct{}
+
+func (evening) Now() time.Time {
+	y, m, d := time.Now().Date()
+	return time.Date(y, m, d, 18, 0, 0, 0, time.Local)
+}
+
+func TestRunIsIdempotent(t *testing.T) {
+	env := testenv.New(t)
+	env.App.Clock = evening{}
+	ctx := context.Background()
+	other := env.Customer(t, env.Outlet(t), 0)
+
+	opts := seed.Options{Namespace: "test", Seed: 7, Months: 1}
+	first, err := seed.Run(ctx, env.App, opts)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	second, err := seed.Run(ctx, env.App, opts)
+	if err != nil {
+		t.Fatalf("second Run: %v", err)
+	}
+	if first != second {
+		t.Errorf("second run created %+v, first %+v", second, first)
+	}
+
+	var orders int64
+	env.DB.Model(&models.Order{}).Count(&orders)
+	if int(orders) != second.Orders {
+		t.Errorf("%d orders in the database, want %d", orders, second.Orders)
+	}
+
+	for _, status := range []models.OrderStatus{
+		models.OrderStatusPending,
+		models.OrderStatusDelivered,
+		models.OrderStatusPartiallyDelivered,
+		models.OrderStatusCancelled,
+		models.OrderStatusPartialCancel,
+	} {
+		var n int64
+		env.DB.Model(&models.Order{}).Where("status = ?", status).Count(&n)
+		if n == 0 {
+			t.Errorf("no %s orders", status)
+		}
+	}
+
+	drifts, err := maintenance.ReconcileWallets(ctx, env.App, false)
+	if err != nil {
+		t.Fatalf("ReconcileWallets: %v", err)
+	}
+	if len(drifts) != 0 {
+		t.Errorf("seeded wallets out of balance: %+v", drifts)
+	}
+
+	if err := seed.Reset(ctx, env.App, "test"); err != nil {
+		t.Fatalf("Reset: %v", err)
+	}
+	var outlets int64
+	env.DB.Model(&models.Outlet{}).Count(&outlets)
+	if outlets != 1 {
+		t.Errorf("%d outlets after Reset, want only the fixture", outlets)
+	}
+	env.Reload(t, &other)
+}
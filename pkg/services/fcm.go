@@ -1,45 +1,60 @@
 package services
 
 import (
+	"backend_pandhi/pkg/config"
+	"backend_pandhi/pkg/metrics"
+	"backend_pandhi/pkg/tracing"
 	"context"
 	"fmt"
-	"os"
 
 	firebase "firebase.google.com/go"
 	"firebase.google.com/go/messaging"
+	"go.opentelemetry.io/otel/attribute"
 	"google.golang.org/api/option"
 )
 
-var fcmClient *messaging.Client
+// Push sends push notifications to devices
+type Push interface {
+	// Send notifies a single device and returns the message ID
+	Send(ctx context.Context, deviceToken, title, body string, data map[string]string) (string, error)
+	// SendBulk notifies several devices and returns the tokens that were reached
+	SendBulk(ctx context.Context, deviceTokens []string, title, body string, data map[string]string) ([]string, error)
+	// Ready reports whether notifications can be sent
+	Ready() bool
+}
 
-// InitFCM initializes Firebase Cloud Messaging
-func InitFCM() error {
-	ctx := context.Background()
+// FCM sends push notifications through Firebase Cloud Messaging. The zero
+// value is not initialized and every send fails.
+type FCM struct {
+	client *messaging.Client
+}
 
+// NewFCM initializes Firebase Cloud Messaging
+func NewFCM(ctx context.Context, cfg *config.Config) (*FCM, error) {
 	// Initialize Firebase app
-	opt := option.WithCredentialsFile(os.Getenv("GOOGLE_APPLICATION_CREDENTIALS"))
+	opt := option.WithCredentialsFile(cfg.GoogleApplicationCredentials)
 	app, err := firebase.NewApp(ctx, nil, opt)
 	if err != nil {
-		return fmt.Errorf("failed to initialize Firebase app: %v", err)
+		return nil, fmt.Errorf("failed to initialize Firebase app: %v", err)
 	}
 
 	// Initialize FCM client
 	client, err := app.Messaging(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to initialize FCM client: %v", err)
+		return nil, fmt.Errorf("failed to initialize FCM client: %v", err)
 	}
 
-	fcmClient = client
-	return nil
+	return &FCM{client: client}, nil
 }
 
-// SendPushNotification sends a notification to a single device
-func SendPushNotification(deviceToken, title, body string, data map[string]string) (string, error) {
-	if fcmClient == nil {
+// Send sends a notification to a single device
+func (f *FCM) Send(ctx context.Context, deviceToken, title, body string, data map[string]string) (_ string, err error) {
+	if f.client == nil {
 		return "", fmt.Errorf("FCM client not initialized")
 	}
 
-	ctx := context.Background()
+	ctx, span := tracing.Start(ctx, "fcm.send")
+	defer tracing.End(span, &err)
 
 	message := &messaging.Message{
 		Token: deviceToken,
@@ -50,21 +65,24 @@ func SendPushNotification(deviceToken, title, body string, data map[string]strin
 		Data: data,
 	}
 
-	response, err := fcmClient.Send(ctx, message)
+	response, err := f.client.Send(ctx, message)
 	if err != nil {
+		metrics.PushFailed(1)
 		return "", fmt.Errorf("failed to send notification: %v", err)
 	}
+	metrics.PushSent(1)
 
 	return response, nil
 }
 
-// SendBulkPushNotifications sends notifications to multiple devices
-func SendBulkPushNotifications(deviceTokens []string, title, body string, data map[string]string) ([]string, error) {
-	if fcmClient == nil {
+// SendBulk sends notifications to multiple devices
+func (f *FCM) SendBulk(ctx context.Context, deviceTokens []string, title, body string, data map[string]string) (_ []string, err error) {
+	if f.client == nil {
 		return nil, fmt.Errorf("FCM client not initialized")
 	}
 
-	ctx := context.Background()
+	ctx, span := tracing.Start(ctx, "fcm.send_multicast", attribute.Int("fcm.tokens", len(deviceTokens)))
+	defer tracing.End(span, &err)
 
 	message := &messaging.MulticastMessage{
 		Tokens: deviceTokens,
@@ -75,10 +93,14 @@ func SendBulkPushNotifications(deviceTokens []string, title, body string, data m
 		Data: data,
 	}
 
-	response, err := fcmClient.SendMulticast(ctx, message)
+	response, err := f.client.SendMulticast(ctx, message)
 	if err != nil {
+		metrics.PushFailed(len(deviceTokens))
 		return nil, fmt.Errorf("failed to send bulk notifications: %v", err)
 	}
+	metrics.PushSent(response.SuccessCount)
+	metrics.PushFailed(response.FailureCount)
+	span.SetAttributes(attribute.Int("fcm.success_count", response.SuccessCount), attribute.Int("fcm.failure_count", response.FailureCount))
 
 	// Return successful message IDs
 	results := []string{}
@@ -91,18 +113,7 @@ func SendBulkPushNotifications(deviceTokens []string, title, body string, data m
 	return results, nil
 }
 
-// GetServiceStatus returns FCM service connection status
-func GetServiceStatus() map[string]interface{} {
-	status := map[string]interface{}{
-		"initialized": fcmClient != nil,
-		"service":     "Firebase Cloud Messaging",
-	}
-
-	if fcmClient != nil {
-		status["status"] = "connected"
-	} else {
-		status["status"] = "not initialized"
-	}
-
-	return status
+// Ready reports whether the FCM client was initialized
+func (f *FCM) Ready() bool {
+	return f.client != nil
 }
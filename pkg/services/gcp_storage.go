@@ -1,53 +1,75 @@
 package services
 
 import (
+	"backend_pandhi/pkg/config"
+	"backend_pandhi/pkg/tracing"
 	"context"
 	"crypto/rand"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
-	"os"
 	"strings"
 
 	"cloud.google.com/go/storage"
+	"go.opentelemetry.io/otel/attribute"
 )
 
-var (
-	storageClient *storage.Client
-	bucketName    string
-)
+// Storage keeps uploaded images
+type Storage interface {
+	// UploadImage stores an image and returns its public URL
+	UploadImage(ctx context.Context, fileBuffer []byte, fileName string) (string, error)
+	// UploadImageFromReader stores an image read from reader
+	UploadImageFromReader(ctx context.Context, reader io.Reader, fileName string) (string, error)
+	// DeleteImage removes the image at a URL returned by an upload
+	DeleteImage(ctx context.Context, imageURL string) error
+	// GetSignedURL returns a URL clients can fetch the image from
+	GetSignedURL(fileURL string) (string, error)
+	// Ready reports whether images can be stored
+	Ready() bool
+}
 
-// InitGCPStorage initializes the GCP Storage client
-func InitGCPStorage() error {
-	bucketName = os.Getenv("GCP_BUCKET_NAME")
-	if bucketName == "" {
-		return fmt.Errorf("GCP_BUCKET_NAME not set")
+// GCPStorage keeps images in a Google Cloud Storage bucket. The zero value
+// is not initialized and every upload fails.
+type GCPStorage struct {
+	client *storage.Client
+	bucket string
+}
+
+// NewGCPStorage initializes the GCP Storage client for the configured bucket
+func NewGCPStorage(ctx context.Context, cfg *config.Config) (*GCPStorage, error) {
+	if cfg.GCPBucketName == "" {
+		return nil, fmt.Errorf("GCP_BUCKET_NAME not set")
 	}
 
-	ctx := context.Background()
 	client, err := storage.NewClient(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to create GCP storage client: %v", err)
+		return nil, fmt.Errorf("failed to create GCP storage client: %v", err)
 	}
 
-	storageClient = client
-	return nil
+	return &GCPStorage{client: client, bucket: cfg.GCPBucketName}, nil
+}
+
+// Ready reports whether the GCP Storage client was initialized
+func (g *GCPStorage) Ready() bool {
+	return g.client != nil
 }
 
 // UploadImage uploads an image to GCP Storage and returns the public URL
-func UploadImage(fileBuffer []byte, fileName string) (string, error) {
-	if storageClient == nil {
+func (g *GCPStorage) UploadImage(ctx context.Context, fileBuffer []byte, fileName string) (_ string, err error) {
+	if g.client == nil {
 		return "", fmt.Errorf("GCP storage client not initialized")
 	}
 
-	ctx := context.Background()
+	ctx, span := tracing.Start(ctx, "gcs.upload", attribute.Int("gcs.size", len(fileBuffer)))
+	defer tracing.End(span, &err)
 
 	// Generate unique filename with random prefix
 	randomBytes := make([]byte, 16)
 	rand.Read(randomBytes)
 	uniqueFileName := hex.EncodeToString(randomBytes) + "-" + fileName
 
-	bucket := storageClient.Bucket(bucketName)
+	bucket := g.client.Bucket(g.bucket)
 	obj := bucket.Object(uniqueFileName)
 	writer := obj.NewWriter(ctx)
 
@@ -64,17 +86,17 @@ func UploadImage(fileBuffer []byte, fileName string) (string, error) {
 	}
 
 	// Return public URL
-	publicURL := fmt.Sprintf("https://storage.googleapis.com/%s/%s", bucketName, uniqueFileName)
+	publicURL := fmt.Sprintf("https://storage.googleapis.com/%s/%s", g.bucket, uniqueFileName)
 	return publicURL, nil
 }
 
 // DeleteImage deletes an image from GCP Storage
-func DeleteImage(imageURL string) error {
+func (g *GCPStorage) DeleteImage(ctx context.Context, imageURL string) error {
 	if imageURL == "" {
 		return nil
 	}
 
-	if storageClient == nil {
+	if g.client == nil {
 		return fmt.Errorf("GCP storage client not initialized")
 	}
 
@@ -85,21 +107,23 @@ func DeleteImage(imageURL string) error {
 	}
 	fileName := urlParts[len(urlParts)-1]
 
-	ctx := context.Background()
-	bucket := storageClient.Bucket(bucketName)
+	ctx, span := tracing.Start(ctx, "gcs.delete")
+	defer span.End()
+
+	bucket := g.client.Bucket(g.bucket)
 	obj := bucket.Object(fileName)
 
-	// Delete the file
-	if err := obj.Delete(ctx); err != nil {
-		// Don't fail if file doesn't exist
-		return nil
+	// Delete the file; one that is already gone counts as deleted
+	if err := obj.Delete(ctx); err != nil && !errors.Is(err, storage.ErrObjectNotExist) {
+		span.RecordError(err)
+		return err
 	}
 
 	return nil
 }
 
 // GetSignedURL generates a signed URL for private access (15 minutes)
-func GetSignedURL(fileURL string) (string, error) {
+func (g *GCPStorage) GetSignedURL(fileURL string) (string, error) {
 	if fileURL == "" {
 		return "", nil
 	}
@@ -109,12 +133,12 @@ func GetSignedURL(fileURL string) (string, error) {
 }
 
 // UploadImageFromReader uploads an image from an io.Reader (for multipart uploads)
-func UploadImageFromReader(reader io.Reader, fileName string) (string, error) {
+func (g *GCPStorage) UploadImageFromReader(ctx context.Context, reader io.Reader, fileName string) (string, error) {
 	// Read all data into buffer
 	buffer, err := io.ReadAll(reader)
 	if err != nil {
 		return "", fmt.Errorf("failed to read file: %v", err)
 	}
 
-	return UploadImage(buffer, fileName)
+	return g.UploadImage(ctx, buffer, fileName)
 }
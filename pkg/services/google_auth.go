@@ -0,0 +1,263 @@
+package services
+
+import (
+	"backend_pandhi/pkg/config"
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// GoogleJWKSURL is where Google publishes the keys that sign its ID tokens
+const GoogleJWKSURL = "https://www.googleapis.com/oauth2/v3/certs"
+
+// ErrInvalidGoogleToken is returned for any ID token that fails verification
+var ErrInvalidGoogleToken = errors.New("invalid Google ID token")
+
+// ErrGoogleUnavailable is returned when Google's signing keys cannot be
+// fetched, so a token could not be checked either way
+var ErrGoogleUnavailable = errors.New("Google signing keys unavailable")
+
+// GoogleKeySet resolves the public key for a JWT key ID
+type GoogleKeySet interface {
+	PublicKey(ctx context.Context, kid string) (*rsa.PublicKey, error)
+}
+
+// GoogleIdentity holds the verified claims of a Google ID token
+type GoogleIdentity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	Picture       string
+}
+
+// GoogleTokenVerifier verifies Google ID tokens issued to this app's clients
+type GoogleTokenVerifier struct {
+	// ClientIDs are the accepted audiences (Android, iOS and web clients differ)
+	ClientIDs []string
+	Keys      GoogleKeySet
+	// Now overrides the clock for expiry checks
+	Now func() time.Time
+}
+
+// NewGoogleTokenVerifier verifies ID tokens issued to any of the configured
+// clients against Google's live JWKS
+func NewGoogleTokenVerifier(cfg *config.Config) (*GoogleTokenVerifier, error) {
+	if len(cfg.GoogleClientIDs) == 0 {
+		return nil, fmt.Errorf("GOOGLE_CLIENT_ID not set")
+	}
+
+	return &GoogleTokenVerifier{
+		ClientIDs: cfg.GoogleClientIDs,
+		Keys:      NewRemoteJWKS(GoogleJWKSURL, nil),
+	}, nil
+}
+
+type googleClaims struct {
+	Email         string      `json:"email"`
+	EmailVerified interface{} `json:"email_verified"`
+	Name          string      `json:"name"`
+	Picture       string      `json:"picture"`
+	jwt.RegisteredClaims
+}
+
+// Verify checks the token signature, issuer, audience and expiry. A nil
+// verifier means Google sign-in is not configured.
+func (v *GoogleTokenVerifier) Verify(ctx context.Context, idToken string) (*GoogleIdentity, error) {
+	if v == nil {
+		return nil, fmt.Errorf("Google sign-in not configured")
+	}
+
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{"RS256"}),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(30 * time.Second),
+	}
+	if v.Now != nil {
+		opts = append(opts, jwt.WithTimeFunc(v.Now))
+	}
+
+	var claims googleClaims
+	_, err := jwt.ParseWithClaims(idToken, &claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			return nil, fmt.Errorf("missing kid header")
+		}
+		return v.Keys.PublicKey(ctx, kid)
+	}, opts...)
+	if errors.Is(err, ErrGoogleUnavailable) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidGoogleToken, err)
+	}
+
+	if claims.Issuer != "accounts.google.com" && claims.Issuer != "https://accounts.google.com" {
+		return nil, fmt.Errorf("%w: unexpected issuer %q", ErrInvalidGoogleToken, claims.Issuer)
+	}
+
+	audienceOK := false
+	for _, aud := range claims.Audience {
+		for _, id := range v.ClientIDs {
+			if aud == id {
+				audienceOK = true
+			}
+		}
+	}
+	if !audienceOK {
+		return nil, fmt.Errorf("%w: unexpected audience", ErrInvalidGoogleToken)
+	}
+
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: missing subject", ErrInvalidGoogleToken)
+	}
+
+	// Google has sent email_verified both as a bool and as a string
+	verified := false
+	switch ev := claims.EmailVerified.(type) {
+	case bool:
+		verified = ev
+	case string:
+		verified = ev == "true"
+	}
+
+	return &GoogleIdentity{
+		Subject:       claims.Subject,
+		Email:         strings.ToLower(strings.TrimSpace(claims.Email)),
+		EmailVerified: verified,
+		Name:          claims.Name,
+		Picture:       claims.Picture,
+	}, nil
+}
+
+// StaticKeySet is a fixed key set, for tests and offline use
+type StaticKeySet map[string]*rsa.PublicKey
+
+// PublicKey returns the key registered under kid
+func (s StaticKeySet) PublicKey(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	key, ok := s[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	return key, nil
+}
+
+// RemoteJWKS fetches a JSON Web Key Set over HTTP and caches it for as long as
+// the response's Cache-Control max-age allows. An unknown kid forces a refresh
+// so key rotation is picked up immediately, at most once a minute.
+type RemoteJWKS struct {
+	URL        string
+	HTTPClient *http.Client
+
+	mu        sync.Mutex
+	keys      map[string]*rsa.PublicKey
+	expiresAt time.Time
+	fetchedAt time.Time
+}
+
+// NewRemoteJWKS creates a key set backed by url
+func NewRemoteJWKS(url string, client *http.Client) *RemoteJWKS {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &RemoteJWKS{URL: url, HTTPClient: client}
+}
+
+// PublicKey returns the key for kid, refreshing the cached set when needed
+func (r *RemoteJWKS) PublicKey(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key, ok := r.keys[kid]
+	if ok && time.Now().Before(r.expiresAt) {
+		return key, nil
+	}
+
+	// Unknown kids on a fresh set are not worth a refetch; this stops forged
+	// tokens from turning every request into a JWKS download
+	if ok || time.Since(r.fetchedAt) >= time.Minute || time.Now().After(r.expiresAt) {
+		if err := r.refresh(ctx); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrGoogleUnavailable, err)
+		}
+	}
+
+	key, ok = r.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	return key, nil
+}
+
+var maxAgePattern = regexp.MustCompile(`max-age=(\d+)`)
+
+func (r *RemoteJWKS) refresh(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.URL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to build JWKS request: %v", err)
+	}
+
+	resp, err := r.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch JWKS: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("JWKS endpoint returned status %d", resp.StatusCode)
+	}
+
+	var set struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return fmt.Errorf("failed to decode JWKS: %v", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			continue
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	ttl := time.Hour
+	if m := maxAgePattern.FindStringSubmatch(resp.Header.Get("Cache-Control")); m != nil {
+		if secs, err := strconv.Atoi(m[1]); err == nil {
+			ttl = time.Duration(secs) * time.Second
+		}
+	}
+
+	r.keys = keys
+	r.fetchedAt = time.Now()
+	r.expiresAt = r.fetchedAt.Add(ttl)
+	return nil
+}
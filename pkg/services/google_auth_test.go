@@ -0,0 +1,121 @@
+package services_test
+
+import (
+	"backend_pandhi/pkg/services"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testClientID = "client.apps.googleusercontent.com"
+
+var testNow = time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+func signingKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// googleToken signs claims as Google would, under kid when it is not empty
+func googleToken(t *testing.T, key *rsa.PrivateKey, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func validClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":            "https://accounts.google.com",
+		"aud":            testClientID,
+		"sub":            "1234567890",
+		"email":          " Asha@Example.com ",
+		"email_verified": "true",
+		"name":           "Asha",
+		"iat":            testNow.Add(-time.Minute).Unix(),
+		"exp":            testNow.Add(time.Hour).Unix(),
+	}
+}
+
+func TestGoogleVerify(t *testing.T) {
+	key := signingKey(t)
+	other := signingKey(t)
+	verifier := &services.GoogleTokenVerifier{
+		ClientIDs: []string{"web-client", testClientID},
+		Keys:      services.StaticKeySet{"k1": &key.PublicKey},
+		Now:       func() time.Time { return testNow },
+	}
+
+	with := func(key string, value interface{}) jwt.MapClaims {
+		claims := validClaims()
+		if value == nil {
+			delete(claims, key)
+		} else {
+			claims[key] = value
+		}
+		return claims
+	}
+
+	for _, tc := range []struct {
+		name  string
+		token string
+	}{
+		{"wrong audience", googleToken(t, key, "k1", with("aud", "someone-else"))},
+		{"expired", googleToken(t, key, "k1", with("exp", testNow.Add(-time.Hour).Unix()))},
+		{"no expiry", googleToken(t, key, "k1", with("exp", nil))},
+		{"bad issuer", googleToken(t, key, "k1", with("iss", "https://evil.example.com"))},
+		{"no subject", googleToken(t, key, "k1", with("sub", nil))},
+		{"missing kid", googleToken(t, key, "", validClaims())},
+		{"unknown kid", googleToken(t, key, "k2", validClaims())},
+		{"signed by another key", googleToken(t, other, "k1", validClaims())},
+		{"not a JWT", "not-a-token"},
+	} {
+		identity, err := verifier.Verify(context.Background(), tc.token)
+		if !errors.Is(err, services.ErrInvalidGoogleToken) {
+			t.Errorf("%s: Verify = %+v, %v, want ErrInvalidGoogleToken", tc.name, identity, err)
+		}
+	}
+
+	identity, err := verifier.Verify(context.Background(), googleToken(t, key, "k1", validClaims()))
+	if err != nil {
+		t.Fatalf("Verify of a valid token: %v", err)
+	}
+	want := services.GoogleIdentity{Subject: "1234567890", Email: "asha@example.com", EmailVerified: true, Name: "Asha"}
+	if *identity != want {
+		t.Errorf("identity = %+v, want %+v", *identity, want)
+	}
+}
+
+func TestGoogleVerifyWithoutKeys(t *testing.T) {
+	jwks := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer jwks.Close()
+
+	verifier := &services.GoogleTokenVerifier{
+		ClientIDs: []string{testClientID},
+		Keys:      services.NewRemoteJWKS(jwks.URL, nil),
+		Now:       func() time.Time { return testNow },
+	}
+	_, err := verifier.Verify(context.Background(), googleToken(t, signingKey(t), "k1", validClaims()))
+	if !errors.Is(err, services.ErrGoogleUnavailable) || errors.Is(err, services.ErrInvalidGoogleToken) {
+		t.Errorf("Verify with the JWKS endpoint down = %v, want ErrGoogleUnavailable only", err)
+	}
+}
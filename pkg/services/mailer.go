@@ -0,0 +1,177 @@
+package services
+
+import (
+	"backend_pandhi/pkg/config"
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/smtp"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EmailMessage is a plain-text email
+type EmailMessage struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer sends emails
+type Mailer interface {
+	Send(ctx context.Context, msg EmailMessage) error
+}
+
+// NewMailer selects the mailer implementation from MAIL_DRIVER ("smtp" or "log")
+func NewMailer(cfg *config.Config) (Mailer, error) {
+	switch cfg.MailDriver {
+	case "smtp":
+		if cfg.SMTPHost == "" {
+			return nil, fmt.Errorf("SMTP_HOST not set")
+		}
+		return &SMTPMailer{
+			Host:     cfg.SMTPHost,
+			Port:     strconv.Itoa(cfg.SMTPPort),
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.MailFrom,
+		}, nil
+	case "log", "":
+		return &LogMailer{Path: cfg.MailLogFile}, nil
+	default:
+		return nil, fmt.Errorf("unknown MAIL_DRIVER %q", cfg.MailDriver)
+	}
+}
+
+// SendEmail sends an email through m, which is nil when no mailer is configured
+func SendEmail(ctx context.Context, m Mailer, to, subject, body string) error {
+	if m == nil {
+		return fmt.Errorf("mailer not initialized")
+	}
+	return m.Send(ctx, EmailMessage{To: to, Subject: subject, Body: body})
+}
+
+// SMTPMailer sends mail through an SMTP server using PLAIN auth
+type SMTPMailer struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+// Send delivers msg via SMTP
+func (m *SMTPMailer) Send(ctx context.Context, msg EmailMessage) error {
+	addr := net.JoinHostPort(m.Host, m.Port)
+
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+
+	headers := []string{
+		"From: " + m.From,
+		"To: " + msg.To,
+		"Subject: " + msg.Subject,
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=\"utf-8\"",
+	}
+	body := strings.Join(headers, "\r\n") + "\r\n\r\n" + msg.Body
+
+	// net/smtp has no context support, so honour cancellation around the call
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(addr, auth, m.From, []string{msg.To}, []byte(body))
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("failed to send email: %v", err)
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// LogMailer writes emails to the server log, or appends them to a file when
+// Path is set. Intended for local development. The server log is redacted, so
+// links and codes in the body are masked there; set MAIL_LOG_FILE to see them.
+type LogMailer struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+// Send records msg instead of delivering it
+func (m *LogMailer) Send(ctx context.Context, msg EmailMessage) error {
+	entry := fmt.Sprintf("---\nTo: %s\nSubject: %s\nDate: %s\n\n%s\n", msg.To, msg.Subject, time.Now().Format(time.RFC3339), msg.Body)
+
+	if m.Path == "" {
+		slog.InfoContext(ctx, "Email (log driver)", "to", msg.To, "subject", msg.Subject, "body", msg.Body)
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f, err := os.OpenFile(m.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open mail log file: %v", err)
+	}
+	defer f.Close()
+
+	if _, err := f.WriteString(entry); err != nil {
+		return fmt.Errorf("failed to write mail log file: %v", err)
+	}
+	return nil
+}
+
+// FakeMailer records emails in memory instead of sending them. Used by tests.
+type FakeMailer struct {
+	mu       sync.Mutex
+	messages []EmailMessage
+}
+
+// NewFakeMailer creates an empty fake mailer
+func NewFakeMailer() *FakeMailer {
+	return &FakeMailer{}
+}
+
+// Send records msg
+func (f *FakeMailer) Send(ctx context.Context, msg EmailMessage) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.messages = append(f.messages, msg)
+	return nil
+}
+
+// Messages returns a copy of every recorded email
+func (f *FakeMailer) Messages() []EmailMessage {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	out := make([]EmailMessage, len(f.messages))
+	copy(out, f.messages)
+	return out
+}
+
+// LastMessageTo returns the most recent email sent to an address
+func (f *FakeMailer) LastMessageTo(to string) (EmailMessage, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for i := len(f.messages) - 1; i >= 0; i-- {
+		if f.messages[i].To == to {
+			return f.messages[i], true
+		}
+	}
+	return EmailMessage{}, false
+}
@@ -1,32 +1,54 @@
 package services
 
 import (
+	"backend_pandhi/pkg/config"
+	"backend_pandhi/pkg/metrics"
+	"backend_pandhi/pkg/tracing"
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"log/slog"
 	"math"
-	"os"
 
 	"github.com/razorpay/razorpay-go"
 )
 
-var (
-	razorpayClient *razorpay.Client
-)
+// Payments takes payments through the payment gateway
+type Payments interface {
+	// CreateOrder opens a gateway order for amount rupees
+	CreateOrder(ctx context.Context, amount float64, currency, receiptID string) (map[string]interface{}, error)
+	// FetchPayment returns the gateway's record of a payment
+	FetchPayment(ctx context.Context, paymentID string) (map[string]interface{}, error)
+	// VerifySignature checks the signature the checkout returned for a payment
+	VerifySignature(orderID, paymentID, signature string) bool
+	// Ready reports whether gateway credentials were configured
+	Ready() bool
+}
 
-// InitRazorpay initializes the Razorpay client
-func InitRazorpay() error {
-	keyID := os.Getenv("RAZORPAY_KEY_ID")
-	keySecret := os.Getenv("RAZORPAY_KEY_SECRET")
+// Razorpay is the Razorpay payment gateway. Without credentials orders
+// cannot be created or fetched; signatures are still checked when the key
+// secret alone is set.
+type Razorpay struct {
+	client    *razorpay.Client
+	keySecret string
+}
 
-	if keyID == "" || keySecret == "" {
-		fmt.Println("Warning: RAZORPAY_KEY_ID or RAZORPAY_KEY_SECRET not set")
-		return nil // Don't fail init, just warn
+// NewRazorpay returns the gateway for the configured key
+func NewRazorpay(cfg *config.Config) *Razorpay {
+	r := &Razorpay{keySecret: cfg.RazorpayKeySecret}
+	if cfg.RazorpayKeyID == "" || cfg.RazorpayKeySecret == "" {
+		slog.Warn("RAZORPAY_KEY_ID or RAZORPAY_KEY_SECRET not set")
+		return r // Don't fail startup, just warn
 	}
+	r.client = razorpay.NewClient(cfg.RazorpayKeyID, cfg.RazorpayKeySecret)
+	return r
+}
 
-	razorpayClient = razorpay.NewClient(keyID, keySecret)
-	return nil
+// Ready reports whether Razorpay credentials were configured
+func (r *Razorpay) Ready() bool {
+	return r.client != nil
 }
 
 // CalculateGrossAmount calculates the gross amount customer needs to pay
@@ -37,12 +59,16 @@ func CalculateGrossAmount(amount float64) (float64, float64, float64) {
 	return amount, grossAmount, serviceCharge
 }
 
-// CreateRazorpayOrder creates a Razorpay order
-func CreateRazorpayOrder(amount float64, currency, receiptID string) (map[string]interface{}, error) {
-	if razorpayClient == nil {
+// CreateOrder creates a Razorpay order. The Razorpay SDK cannot be
+// cancelled, so ctx only carries the trace.
+func (r *Razorpay) CreateOrder(ctx context.Context, amount float64, currency, receiptID string) (_ map[string]interface{}, err error) {
+	if r.client == nil {
 		return nil, fmt.Errorf("Razorpay client not initialized")
 	}
 
+	_, span := tracing.Start(ctx, "razorpay.create_order")
+	defer tracing.End(span, &err)
+
 	// Amount in paise
 	amountInPaise := math.Round(amount * 100)
 
@@ -57,7 +83,7 @@ func CreateRazorpayOrder(amount float64, currency, receiptID string) (map[string
 		"receipt": fmt.Sprintf("receipt_%v", receiptID),
 	}
 
-	body, err := razorpayClient.Order.Create(data, nil)
+	body, err := r.client.Order.Create(data, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create razorpay order: %v", err)
 	}
@@ -65,13 +91,16 @@ func CreateRazorpayOrder(amount float64, currency, receiptID string) (map[string
 	return body, nil
 }
 
-// FetchPaymentDetails fetches payment details from Razorpay
-func FetchPaymentDetails(paymentID string) (map[string]interface{}, error) {
-	if razorpayClient == nil {
+// FetchPayment fetches payment details from Razorpay
+func (r *Razorpay) FetchPayment(ctx context.Context, paymentID string) (_ map[string]interface{}, err error) {
+	if r.client == nil {
 		return nil, fmt.Errorf("Razorpay client not initialized")
 	}
 
-	body, err := razorpayClient.Payment.Fetch(paymentID, nil, nil)
+	_, span := tracing.Start(ctx, "razorpay.fetch_payment")
+	defer tracing.End(span, &err)
+
+	body, err := r.client.Payment.Fetch(paymentID, nil, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch payment details: %v", err)
 	}
@@ -79,9 +108,9 @@ func FetchPaymentDetails(paymentID string) (map[string]interface{}, error) {
 	return body, nil
 }
 
-// VerifyPaymentSignature verifies the Razorpay payment signature
-func VerifyPaymentSignature(orderID, paymentID, signature string) bool {
-	keySecret := os.Getenv("RAZORPAY_KEY_SECRET")
+// VerifySignature verifies the Razorpay payment signature
+func (r *Razorpay) VerifySignature(orderID, paymentID, signature string) bool {
+	keySecret := r.keySecret
 	if keySecret == "" {
 		return false
 	}
@@ -91,7 +120,11 @@ func VerifyPaymentSignature(orderID, paymentID, signature string) bool {
 	h.Write([]byte(data))
 	expectedSignature := hex.EncodeToString(h.Sum(nil))
 
-	return expectedSignature == signature
+	if expectedSignature != signature {
+		metrics.RazorpayVerificationFailed()
+		return false
+	}
+	return true
 }
 
 
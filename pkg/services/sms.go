@@ -0,0 +1,154 @@
+package services
+
+import (
+	"backend_pandhi/pkg/config"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SMSSender sends text messages
+type SMSSender interface {
+	SendSMS(ctx context.Context, to, body string) error
+}
+
+// NewSMSSender uses Twilio when credentials are configured and falls back to
+// the fake sender (which only logs) otherwise
+func NewSMSSender(cfg *config.Config) (SMSSender, error) {
+	if cfg.TwilioAccountSID == "" || cfg.TwilioAuthToken == "" || cfg.TwilioPhoneNumber == "" {
+		if cfg.IsProduction() {
+			return nil, fmt.Errorf("TWILIO_ACCOUNT_SID, TWILIO_AUTH_TOKEN or TWILIO_PHONE_NUMBER not set")
+		}
+		return NewFakeSMSSender(), nil
+	}
+
+	return &TwilioSMSSender{
+		AccountSID: cfg.TwilioAccountSID,
+		AuthToken:  cfg.TwilioAuthToken,
+		From:       cfg.TwilioPhoneNumber,
+	}, nil
+}
+
+// SendSMS sends a text message through s, which is nil when no sender is
+// configured
+func SendSMS(ctx context.Context, s SMSSender, to, body string) error {
+	if s == nil {
+		return fmt.Errorf("SMS sender not initialized")
+	}
+	return s.SendSMS(ctx, to, body)
+}
+
+// TwilioSMSSender sends messages with the Twilio Programmable Messaging REST API
+type TwilioSMSSender struct {
+	AccountSID string
+	AuthToken  string
+	From       string
+
+	// BaseURL overrides the Twilio API host (defaults to https://api.twilio.com)
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+// SendSMS creates a Twilio Message resource
+func (t *TwilioSMSSender) SendSMS(ctx context.Context, to, body string) error {
+	baseURL := t.BaseURL
+	if baseURL == "" {
+		baseURL = "https://api.twilio.com"
+	}
+	client := t.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	endpoint := fmt.Sprintf("%s/2010-04-01/Accounts/%s/Messages.json", baseURL, url.PathEscape(t.AccountSID))
+	form := url.Values{}
+	form.Set("To", to)
+	form.Set("From", t.From)
+	form.Set("Body", body)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to build Twilio request: %v", err)
+	}
+	req.SetBasicAuth(t.AccountSID, t.AuthToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send SMS: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var apiErr struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		}
+		payload, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		if json.Unmarshal(payload, &apiErr) == nil && apiErr.Message != "" {
+			return fmt.Errorf("twilio error %d: %s", apiErr.Code, apiErr.Message)
+		}
+		return fmt.Errorf("twilio returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// SentSMS is a message captured by FakeSMSSender
+type SentSMS struct {
+	To     string
+	Body   string
+	SentAt time.Time
+}
+
+// FakeSMSSender records messages in memory and logs them instead of sending.
+// Used for local development and tests.
+type FakeSMSSender struct {
+	mu       sync.Mutex
+	messages []SentSMS
+}
+
+// NewFakeSMSSender creates an empty fake sender
+func NewFakeSMSSender() *FakeSMSSender {
+	return &FakeSMSSender{}
+}
+
+// SendSMS records the message
+func (f *FakeSMSSender) SendSMS(ctx context.Context, to, body string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.messages = append(f.messages, SentSMS{To: to, Body: body, SentAt: time.Now()})
+	slog.InfoContext(ctx, "SMS (fake sender)", "to", to, "body", body)
+	return nil
+}
+
+// Messages returns a copy of every recorded message
+func (f *FakeSMSSender) Messages() []SentSMS {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	out := make([]SentSMS, len(f.messages))
+	copy(out, f.messages)
+	return out
+}
+
+// LastMessageTo returns the most recent message sent to a number
+func (f *FakeSMSSender) LastMessageTo(to string) (SentSMS, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for i := len(f.messages) - 1; i >= 0; i-- {
+		if f.messages[i].To == to {
+			return f.messages[i], true
+		}
+	}
+	return SentSMS{}, false
+}
@@ -0,0 +1,245 @@
+package testenv
+
+import (
+	"backend_pandhi/pkg/models"
+	"backend_pandhi/pkg/utils"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+// Password is the password of every fixture account
+const Password = "password123"
+
+var fixtureSeq atomic.Int64
+
+// unique returns a label that no other fixture of the test binary uses
+func unique(prefix string) string {
+	return fmt.Sprintf("%s-%d", prefix, fixtureSeq.Add(1))
+}
+
+func (e *Env) create(t testing.TB, value interface{}) {
+	t.Helper()
+	if err := e.DB.Create(value).Error; err != nil {
+		t.Fatalf("failed to create %T fixture: %v", value, err)
+	}
+}
+
+func hashedPassword(t testing.TB) string {
+	t.Helper()
+	hash, err := utils.HashPassword(Password)
+	if err != nil {
+		t.Fatalf("failed to hash password: %v", err)
+	}
+	return hash
+}
+
+// Outlet creates an active outlet
+func (e *Env) Outlet(t testing.TB) models.Outlet {
+	t.Helper()
+	outlet := models.Outlet{Name: unique("Outlet"), IsActive: true}
+	e.create(t, &outlet)
+	return outlet
+}
+
+// Customer creates a verified customer of the outlet with a wallet holding
+// balance and an empty cart. CustomerInfo, its Wallet and Cart are set.
+func (e *Env) Customer(t testing.TB, outlet models.Outlet, balance float64) models.User {
+	t.Helper()
+	password := hashedPassword(t)
+	name := unique("customer")
+	user := models.User{
+		Name:       name,
+		Email:      name + "@example.com",
+		Password:   &password,
+		Role:       models.RoleCustomer,
+		OutletID:   &outlet.ID,
+		IsVerified: true,
+	}
+	e.create(t, &user)
+
+	customer := models.CustomerDetails{UserID: user.ID}
+	e.create(t, &customer)
+	wallet := models.Wallet{CustomerID: customer.ID, Balance: balance, TotalRecharged: balance}
+	e.create(t, &wallet)
+	cart := models.Cart{CustomerID: customer.ID}
+	e.create(t, &cart)
+
+	customer.Wallet = &wallet
+	customer.Cart = &cart
+	user.CustomerInfo = &customer
+	return user
+}
+
+// Staff creates a verified staff member of the outlet holding the given
+// permissions. StaffInfo is set.
+func (e *Env) Staff(t testing.TB, outlet models.Outlet, permissions ...models.PermissionType) models.User {
+	t.Helper()
+	password := hashedPassword(t)
+	name := unique("staff")
+	user := models.User{
+		Name:       name,
+		Email:      name + "@example.com",
+		Password:   &password,
+		Role:       models.RoleStaff,
+		OutletID:   &outlet.ID,
+		IsVerified: true,
+	}
+	e.create(t, &user)
+
+	staff := models.StaffDetails{UserID: user.ID, StaffRole: "Staff"}
+	e.create(t, &staff)
+	for _, permission := range permissions {
+		granted := models.StaffPermission{StaffID: staff.ID, Type: permission, IsGranted: true}
+		e.create(t, &granted)
+		staff.Permissions = append(staff.Permissions, granted)
+	}
+
+	user.StaffInfo = &staff
+	return user
+}
+
+// Admin creates a verified admin of the given outlets holding the given
+// permissions on each of them
+func (e *Env) Admin(t testing.TB, outlets []models.Outlet, permissions ...models.AdminPermissionType) models.Admin {
+	t.Helper()
+	name := unique("admin")
+	admin := models.Admin{
+		Name:       name,
+		Email:      name + "@example.com",
+		Password:   hashedPassword(t),
+		IsVerified: true,
+	}
+	e.create(t, &admin)
+
+	for _, outlet := range outlets {
+		link := models.AdminOutlet{AdminID: admin.ID, OutletID: outlet.ID}
+		e.create(t, &link)
+		for _, permission := range permissions {
+			e.create(t, &models.AdminPermission{
+				AdminOutletID: link.ID,
+				AdminID:       &admin.ID,
+				Type:          permission,
+				IsGranted:     true,
+			})
+		}
+		admin.Outlets = append(admin.Outlets, link)
+	}
+	return admin
+}
+
+// SuperAdmin creates a superadmin user
+func (e *Env) SuperAdmin(t testing.TB) models.User {
+	t.Helper()
+	password := hashedPassword(t)
+	name := unique("superadmin")
+	user := models.User{
+		Name:       name,
+		Email:      name + "@example.com",
+		Password:   &password,
+		Role:       models.RoleSuperAdmin,
+		IsVerified: true,
+	}
+	e.create(t, &user)
+	return user
+}
+
+// Product creates a product of the outlet with stock units in its inventory.
+// Inventory is set.
+func (e *Env) Product(t testing.TB, outlet models.Outlet, price float64, stock int) models.Product {
+	t.Helper()
+	product := models.Product{
+		Name:     unique("Product"),
+		Price:    price,
+		OutletID: outlet.ID,
+		Category: models.CategoryMeals,
+		IsVeg:    true,
+	}
+	e.create(t, &product)
+
+	inventory := models.Inventory{ProductID: product.ID, OutletID: outlet.ID, Quantity: stock, Threshold: 5}
+	e.create(t, &inventory)
+	product.Inventory = &inventory
+	return product
+}
+
+// Token returns a JWT for a customer, staff or superadmin user
+func (e *Env) Token(t testing.TB, user models.User) string {
+	t.Helper()
+	token, err := utils.GenerateToken(e.App.Config, user.ID, user.Email, user.Role)
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+	return token
+}
+
+// AdminToken returns a JWT for an admin
+func (e *Env) AdminToken(t testing.TB, admin models.Admin) string {
+	t.Helper()
+	token, err := utils.GenerateToken(e.App.Config, admin.ID, admin.Email, models.RoleAdmin)
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+	return token
+}
+
+// PaymentSignature signs a Razorpay order and payment as the checkout would
+func (e *Env) PaymentSignature(orderID, paymentID string) string {
+	h := hmac.New(sha256.New, []byte(RazorpayKeySecret))
+	h.Write([]byte(orderID + "|" + paymentID))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Do sends a request to the router. body, when not nil, is sent as JSON and
+// token, when not empty, as a bearer token.
+func (e *Env) Do(t testing.TB, method, path, token string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			t.Fatalf("failed to encode request body: %v", err)
+		}
+	}
+
+	req := httptest.NewRequest(method, path, bytes.NewReader(data))
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	w := httptest.NewRecorder()
+	e.Router.ServeHTTP(w, req)
+	return w
+}
+
+// Decode unmarshals a JSON response, failing the test when the status is not
+// the expected one
+func Decode(t testing.TB, w *httptest.ResponseRecorder, status int, v interface{}) {
+	t.Helper()
+	if w.Code != status {
+		t.Fatalf("status = %d, want %d; body: %s", w.Code, status, w.Body.String())
+	}
+	if v == nil {
+		return
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+		t.Fatalf("failed to decode response: %v; body: %s", err, w.Body.String())
+	}
+}
+
+// Reload fetches the current row of a model by its primary key
+func (e *Env) Reload(t testing.TB, value interface{}) {
+	t.Helper()
+	if err := e.DB.First(value).Error; err != nil {
+		t.Fatalf("failed to reload %T: %v", value, err)
+	}
+}
@@ -0,0 +1,230 @@
+package testenv
+
+import (
+	"backend_pandhi/pkg/migrations"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// errNoPostgres means neither TEST_DATABASE_URL nor a postgres binary is
+// available, so integration tests are skipped
+var errNoPostgres = errors.New("set TEST_DATABASE_URL or install postgres to run integration tests")
+
+// server is the Postgres instance shared by every Env of a test binary. It
+// holds a template database with the migrations applied; each Env is a copy
+// of it.
+type server struct {
+	dsn      string // maintenance database
+	template string
+
+	// Set when the server was started from a local binary
+	proc *exec.Cmd
+	dir  string
+}
+
+var (
+	serverOnce   sync.Once
+	sharedServer *server
+	serverErr    error
+)
+
+// getServer starts the shared server on first use
+func getServer() (*server, error) {
+	serverOnce.Do(func() {
+		sharedServer, serverErr = startServer()
+	})
+	return sharedServer, serverErr
+}
+
+func startServer() (*server, error) {
+	s := &server{dsn: os.Getenv("TEST_DATABASE_URL")}
+	if s.dsn == "" {
+		if err := s.startLocal(); err != nil {
+			return nil, err
+		}
+	}
+
+	s.template = fmt.Sprintf("backend_test_%d_%d", os.Getpid(), time.Now().UnixNano()%1e6)
+	if err := s.exec(fmt.Sprintf(`CREATE DATABASE %q`, s.template)); err != nil {
+		s.stop()
+		return nil, fmt.Errorf("failed to create template database: %w", err)
+	}
+	if err := migrate(withDatabase(s.dsn, s.template)); err != nil {
+		s.stop()
+		return nil, fmt.Errorf("failed to migrate template database: %w", err)
+	}
+	return s, nil
+}
+
+// startLocal runs initdb and postgres in a temporary directory, listening on
+// a Unix socket only. Postgres refuses to run as root.
+func (s *server) startLocal() error {
+	initdb, err := findBinary("initdb")
+	if err != nil {
+		return errNoPostgres
+	}
+	postgresBin, err := findBinary("postgres")
+	if err != nil {
+		return errNoPostgres
+	}
+
+	s.dir, err = os.MkdirTemp("", "backend-pg-")
+	if err != nil {
+		return err
+	}
+	data := filepath.Join(s.dir, "data")
+	if out, err := exec.Command(initdb, "-D", data, "-U", "postgres", "-A", "trust", "-E", "UTF8", "--no-sync").CombinedOutput(); err != nil {
+		os.RemoveAll(s.dir)
+		return fmt.Errorf("initdb failed: %v\n%s", err, out)
+	}
+
+	logFile, err := os.Create(filepath.Join(s.dir, "postgres.log"))
+	if err != nil {
+		os.RemoveAll(s.dir)
+		return err
+	}
+	defer logFile.Close()
+
+	// Durability is pointless for a database thrown away after the run
+	s.proc = exec.Command(postgresBin, "-D", data, "-k", s.dir,
+		"-c", "listen_addresses=",
+		"-c", "fsync=off",
+		"-c", "synchronous_commit=off",
+		"-c", "full_page_writes=off")
+	s.proc.Stdout = logFile
+	s.proc.Stderr = logFile
+	if err := s.proc.Start(); err != nil {
+		os.RemoveAll(s.dir)
+		return fmt.Errorf("failed to start postgres: %w", err)
+	}
+
+	s.dsn = fmt.Sprintf("host=%s user=postgres dbname=postgres sslmode=disable", s.dir)
+	deadline := time.Now().Add(30 * time.Second)
+	for {
+		err := s.exec("SELECT 1")
+		if err == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			s.stop()
+			return fmt.Errorf("postgres did not start (log in %s): %w", logFile.Name(), err)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// findBinary looks for a Postgres program on PATH and then in the usual
+// package install locations, which are not on PATH on Debian and Ubuntu
+func findBinary(name string) (string, error) {
+	if path, err := exec.LookPath(name); err == nil {
+		return path, nil
+	}
+	candidates, _ := filepath.Glob(filepath.Join("/usr/lib/postgresql", "*", "bin", name))
+	if out, err := exec.Command("pg_config", "--bindir").Output(); err == nil {
+		candidates = append(candidates, filepath.Join(strings.TrimSpace(string(out)), name))
+	}
+	// Prefer the newest version
+	sort.Sort(sort.Reverse(sort.StringSlice(candidates)))
+	for _, path := range candidates {
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("%s not found", name)
+}
+
+// createDatabase copies the template into a new database and returns its DSN
+func (s *server) createDatabase(name string) (string, error) {
+	if err := s.exec(fmt.Sprintf(`CREATE DATABASE %q TEMPLATE %q`, name, s.template)); err != nil {
+		return "", err
+	}
+	return withDatabase(s.dsn, name), nil
+}
+
+func (s *server) dropDatabase(name string) error {
+	return s.exec(fmt.Sprintf(`DROP DATABASE IF EXISTS %q WITH (FORCE)`, name))
+}
+
+// stop drops the template and shuts down a locally started server
+func (s *server) stop() {
+	if s.template != "" {
+		s.dropDatabase(s.template)
+	}
+	if s.proc != nil {
+		// SIGINT is a fast shutdown: connections are closed, no checkpoint wait
+		s.proc.Process.Signal(os.Interrupt)
+		s.proc.Wait()
+	}
+	if s.dir != "" {
+		os.RemoveAll(s.dir)
+	}
+}
+
+// exec runs one statement on the maintenance database. CREATE and DROP
+// DATABASE cannot run in a transaction, so each call opens its own connection.
+func (s *server) exec(statement string) error {
+	db, err := open(s.dsn)
+	if err != nil {
+		return err
+	}
+	defer closeDB(db)
+	return db.Exec(statement).Error
+}
+
+// migrate applies every migration to the database at dsn
+func migrate(dsn string) error {
+	db, err := open(dsn)
+	if err != nil {
+		return err
+	}
+	defer closeDB(db)
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	m, err := migrations.New(sqlDB)
+	if err != nil {
+		return err
+	}
+	_, err = m.Up(context.Background())
+	return err
+}
+
+func open(dsn string) (*gorm.DB, error) {
+	return gorm.Open(postgres.New(postgres.Config{
+		DSN:                  dsn,
+		PreferSimpleProtocol: true,
+	}), &gorm.Config{Logger: logger.Discard})
+}
+
+func closeDB(db *gorm.DB) {
+	if sqlDB, err := db.DB(); err == nil {
+		sqlDB.Close()
+	}
+}
+
+// withDatabase points a URL or keyword/value DSN at another database
+func withDatabase(dsn, name string) string {
+	if strings.Contains(dsn, "://") {
+		if u, err := url.Parse(dsn); err == nil {
+			u.Path = "/" + name
+			return u.String()
+		}
+	}
+	// In keyword/value form a repeated keyword overrides the earlier one
+	return dsn + " dbname=" + name
+}
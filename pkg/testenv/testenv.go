@@ -0,0 +1,156 @@
+// Package testenv runs the application against a disposable Postgres for
+// integration tests.
+//
+// The server comes from TEST_DATABASE_URL, which needs permission to create
+// databases, or else from a postgres binary started in a temporary directory.
+// When neither is available the tests are skipped. Migrations are applied
+// once to a template database and every Env gets a fresh copy of it.
+//
+// Each Env is a separate app.App with fake mail and SMS senders. Its
+// configuration is read from the environment as in the server, so tests using
+// one must not call t.Parallel. Packages using Env run their tests through
+// Main so the server is shut down afterwards:
+//
+//	func TestMain(m *testing.M) {
+//		os.Exit(testenv.Main(m))
+//	}
+package testenv
+
+import (
+	"backend_pandhi/pkg/app"
+	"backend_pandhi/pkg/config"
+	"backend_pandhi/pkg/database"
+	"backend_pandhi/pkg/middleware"
+	"backend_pandhi/pkg/routes"
+	"backend_pandhi/pkg/services"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-contrib/sessions/cookie"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Secrets the environment is configured with
+const (
+	JWTSecret         = "test-jwt-secret"
+	RazorpayKeySecret = "test-razorpay-secret"
+)
+
+var databaseSeq atomic.Int64
+
+// Env is one application instance with its own database
+type Env struct {
+	// App is what the handlers are served from
+	App *app.App
+
+	// DB is the test database, the same as App.DB
+	DB *gorm.DB
+
+	// Router serves the full API as the server does
+	Router http.Handler
+
+	// Mail and SMS record what the application sends
+	Mail *services.FakeMailer
+	SMS  *services.FakeSMSSender
+}
+
+// Main runs the tests of a package and then shuts down the shared server
+func Main(m *testing.M) int {
+	code := m.Run()
+	if sharedServer != nil {
+		sharedServer.stop()
+	}
+	return code
+}
+
+// New returns an Env on a freshly migrated database, skipping the test when
+// no Postgres is available. Everything is torn down when the test ends.
+func New(t testing.TB) *Env {
+	t.Helper()
+
+	srv, err := getServer()
+	if errors.Is(err, errNoPostgres) {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatalf("test database server: %v", err)
+	}
+
+	name := fmt.Sprintf("%s_%d", srv.template, databaseSeq.Add(1))
+	dsn, err := srv.createDatabase(name)
+	if err != nil {
+		t.Fatalf("failed to create test database: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := srv.dropDatabase(name); err != nil {
+			t.Logf("failed to drop test database %s: %v", name, err)
+		}
+	})
+
+	// Configuration comes from the environment as in the server; unset
+	// values take the usual defaults
+	t.Setenv("DATABASE_URL", dsn)
+	t.Setenv("NODE_ENV", "test")
+	t.Setenv("JWT_SECRET", JWTSecret)
+	t.Setenv("SESSION_SECRET", "test-session-secret")
+	t.Setenv("RAZORPAY_KEY_SECRET", RazorpayKeySecret)
+	t.Setenv("ENABLE_MOBILE_TOKEN_RETURN", "true")
+	t.Setenv("RATE_LIMIT_ENABLED", "false")
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatalf("invalid test configuration: %v", err)
+	}
+
+	db, err := database.Open(cfg)
+	if err != nil {
+		t.Fatalf("failed to connect to test database: %v", err)
+	}
+	t.Cleanup(func() { database.Close(db) })
+
+	// External services. Razorpay has only the key secret, so signatures are
+	// checked against RazorpayKeySecret while orders fail; storage and push
+	// are not initialized and fail softly.
+	env := &Env{
+		DB:   db,
+		Mail: services.NewFakeMailer(),
+		SMS:  services.NewFakeSMSSender(),
+	}
+	env.App = &app.App{
+		Config:   cfg,
+		DB:       db,
+		Clock:    app.SystemClock{},
+		Payments: services.NewRazorpay(cfg),
+		Push:     &services.FCM{},
+		Storage:  &services.GCPStorage{},
+		Mailer:   env.Mail,
+		SMS:      env.SMS,
+	}
+
+	limiter, err := middleware.NewRateLimiter(env.App)
+	if err != nil {
+		t.Fatalf("failed to initialize rate limiter: %v", err)
+	}
+	env.Router = newRouter(env.App, limiter)
+	return env
+}
+
+// newRouter builds the router with the middleware of main that shapes
+// responses; access logs, tracing, metrics and CORS are left out
+func newRouter(a *app.App, limiter *middleware.RateLimiter) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	router.Use(middleware.RequestID())
+	router.Use(middleware.RecoveryMiddleware())
+	router.Use(middleware.ErrorMiddleware())
+	router.Use(sessions.Sessions("session", cookie.NewStore([]byte(a.Config.SessionSecret))))
+	router.Use(limiter.Limit("default"))
+
+	routes.Setup(router, a, limiter)
+	return router
+}
@@ -0,0 +1,95 @@
+// Package tracing sets up OpenTelemetry tracing.
+//
+// Spans are created for every HTTP request (Middleware), every GORM statement
+// (GormPlugin) and every outbound call made by pkg/services. They only leave
+// the process when OTEL_EXPORTER_OTLP_ENDPOINT is set; otherwise the global
+// no-op provider is kept and starting a span costs next to nothing.
+//
+// Work belongs to a request's trace when it is given the request context:
+//
+//	ctx, span := tracing.Start(c.Request.Context(), "razorpay.create_order")
+//	defer span.End()
+package tracing
+
+import (
+	"backend_pandhi/pkg/config"
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "backend_pandhi"
+
+// Init installs the W3C trace-context propagator and, when an OTLP endpoint
+// is configured, an exporting tracer provider. The returned function flushes
+// buffered spans and must be called on shutdown.
+func Init(ctx context.Context, cfg *config.Config) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	if cfg.OTLPEndpoint == nil {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	// Like the OTEL_EXPORTER_OTLP_ENDPOINT convention, a bare collector URL
+	// gets the traces path appended
+	endpoint := *cfg.OTLPEndpoint
+	if endpoint.Path == "" || endpoint.Path == "/" {
+		endpoint.Path = "/v1/traces"
+	}
+
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(endpoint.String()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		attribute.String("service.name", cfg.OTELServiceName),
+		attribute.String("deployment.environment", cfg.Environment),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.TraceSampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// Tracer returns the application's tracer from the global provider
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Start starts a client span named name as a child of any span in ctx
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+}
+
+// End records err on span, if any, and ends it. It is meant for the common
+// "defer End(span, &err)" pattern with a named error result.
+func End(span trace.Span, err *error) {
+	if err != nil && *err != nil {
+		span.RecordError(*err)
+		span.SetStatus(codes.Error, (*err).Error())
+	}
+	span.End()
+}
@@ -2,6 +2,8 @@ package utils
 
 import (
 	"errors"
+	"sync"
+
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -24,6 +26,21 @@ func ComparePassword(hashedPassword, password string) error {
 	return nil
 }
 
+var (
+	dummyHashOnce sync.Once
+	dummyHash     []byte
+)
+
+// DummyComparePassword spends the same time as a real ComparePassword. Call it
+// when the account does not exist or has no password so that response timing
+// does not reveal which emails are registered.
+func DummyComparePassword(password string) {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy-password-for-timing"), 10)
+	})
+	_ = bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+}
+
 // CheckPasswordStrength validates password strength (optional, add if needed)
 func CheckPasswordStrength(password string) error {
 	if len(password) < 6 {
@@ -18,30 +18,14 @@ type TokenClaims struct {
 }
 
 // GenerateToken generates a JWT token for a user
-func GenerateToken(userID int, email string, role models.Role) (string, error) {
-	// Parse expiration duration (default 7d)
-	expiresIn := config.AppConfig.JWTExpiresIn
-	var duration time.Duration
-	
-	// Simple duration parsing (matching Express.js behavior)
-	switch expiresIn {
-	case "7d":
-		duration = 7 * 24 * time.Hour
-	case "1d":
-		duration = 24 * time.Hour
-	case "30m":
-		duration = 30 * time.Minute
-	default:
-		duration = 7 * 24 * time.Hour // Default to 7 days
-	}
-
+func GenerateToken(cfg *config.Config, userID int, email string, role models.Role) (string, error) {
 	// Create claims
 	claims := TokenClaims{
 		ID:    userID,
 		Email: email,
 		Role:  role,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(duration)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(cfg.JWTExpiresIn)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
@@ -50,7 +34,7 @@ func GenerateToken(userID int, email string, role models.Role) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 
 	// Sign token with secret
-	tokenString, err := token.SignedString([]byte(config.AppConfig.JWTSecret))
+	tokenString, err := token.SignedString([]byte(cfg.JWTSecret))
 	if err != nil {
 		return "", err
 	}
@@ -59,14 +43,14 @@ func GenerateToken(userID int, email string, role models.Role) (string, error) {
 }
 
 // VerifyToken verifies and parses a JWT token
-func VerifyToken(tokenString string) (*TokenClaims, error) {
+func VerifyToken(cfg *config.Config, tokenString string) (*TokenClaims, error) {
 	// Parse token
 	token, err := jwt.ParseWithClaims(tokenString, &TokenClaims{}, func(token *jwt.Token) (interface{}, error) {
 		// Validate signing method
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, errors.New("invalid signing method")
 		}
-		return []byte(config.AppConfig.JWTSecret), nil
+		return []byte(cfg.JWTSecret), nil
 	})
 
 	if err != nil {
@@ -82,8 +66,8 @@ func VerifyToken(tokenString string) (*TokenClaims, error) {
 }
 
 // GetTokenExpiration returns the expiration time of a token
-func GetTokenExpiration(tokenString string) (time.Time, error) {
-	claims, err := VerifyToken(tokenString)
+func GetTokenExpiration(cfg *config.Config, tokenString string) (time.Time, error) {
+	claims, err := VerifyToken(cfg, tokenString)
 	if err != nil {
 		return time.Time{}, err
 	}
@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"backend_pandhi/pkg/config"
+	"errors"
+	"strings"
+)
+
+var errInvalidPhone = errors.New("invalid phone number")
+
+// NormalizePhone converts a user-entered phone number to E.164 (+<country><number>),
+// assuming the configured default country code for national numbers
+func NormalizePhone(cfg *config.Config, raw string) (string, error) {
+	countryCode := "91"
+	if cfg.DefaultPhoneCountryCode != "" {
+		countryCode = cfg.DefaultPhoneCountryCode
+	}
+	return NormalizePhoneWithCountry(raw, countryCode)
+}
+
+// NormalizePhoneWithCountry converts a phone number to E.164 using countryCode
+// (digits only, e.g. "91") for numbers written without an international prefix
+func NormalizePhoneWithCountry(raw, countryCode string) (string, error) {
+	s := strings.TrimSpace(raw)
+	if s == "" {
+		return "", errInvalidPhone
+	}
+
+	international := false
+	switch {
+	case strings.HasPrefix(s, "+"):
+		international = true
+		s = s[1:]
+	case strings.HasPrefix(s, "00"):
+		international = true
+		s = s[2:]
+	}
+
+	var digits strings.Builder
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r == ' ' || r == '-' || r == '.' || r == '(' || r == ')':
+			// Formatting characters are dropped
+		default:
+			return "", errInvalidPhone
+		}
+	}
+	number := digits.String()
+
+	if !international {
+		switch {
+		case strings.HasPrefix(number, "0"):
+			// National trunk prefix, e.g. 09876543210
+			number = countryCode + strings.TrimLeft(number, "0")
+		case len(number) > 10 && strings.HasPrefix(number, countryCode):
+			// Country code written without "+"
+		default:
+			number = countryCode + number
+		}
+	}
+
+	// E.164 allows at most 15 digits; anything under 8 is not a real subscriber number
+	if len(number) < 8 || len(number) > 15 || number[0] == '0' {
+		return "", errInvalidPhone
+	}
+
+	return "+" + number, nil
+}
@@ -0,0 +1,45 @@
+package utils_test
+
+import (
+	"backend_pandhi/pkg/config"
+	"backend_pandhi/pkg/utils"
+	"testing"
+)
+
+func TestNormalizePhone(t *testing.T) {
+	for _, tc := range []struct {
+		raw     string
+		country string
+		want    string // "" for invalid
+	}{
+		{"9876543210", "", "+919876543210"},
+		{" 98765 43210 ", "", "+919876543210"},
+		{"098765-43210", "", "+919876543210"},
+		{"00919876543210", "", "+919876543210"},
+		{"+91 (98765) 43210", "", "+919876543210"},
+		{"919876543210", "", "+919876543210"},
+		{"+1 415.555.2671", "", "+14155552671"},
+		{"4155552671", "1", "+14155552671"},
+		{"91234567", "", "+9191234567"},
+		{"", "", ""},
+		{"   ", "", ""},
+		{"+", "", ""},
+		{"12345", "", ""},
+		{"+1234567890123456", "", ""},
+		{"+0123456789", "", ""},
+		{"98765x43210", "", ""},
+		{"+91/9876543210", "", ""},
+		{"٩٨٧٦٥٤٣٢١٠", "", ""},
+	} {
+		got, err := utils.NormalizePhone(&config.Config{DefaultPhoneCountryCode: tc.country}, tc.raw)
+		if tc.want == "" {
+			if err == nil {
+				t.Errorf("NormalizePhone(%q) = %q, want an error", tc.raw, got)
+			}
+			continue
+		}
+		if err != nil || got != tc.want {
+			t.Errorf("NormalizePhone(%q, country %q) = %q, %v, want %q", tc.raw, tc.country, got, err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package utils_test
+
+import (
+	"backend_pandhi/pkg/config"
+	"backend_pandhi/pkg/utils"
+	"regexp"
+	"testing"
+)
+
+func TestHashToken(t *testing.T) {
+	// SHA-256 test vector
+	if got := utils.HashToken("abc"); got != "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad" {
+		t.Errorf("HashToken(abc) = %s", got)
+	}
+	if utils.HashToken("token-a") == utils.HashToken("token-b") {
+		t.Error("different tokens hash alike")
+	}
+}
+
+func TestGenerateSecureToken(t *testing.T) {
+	urlSafe := regexp.MustCompile(`^[A-Za-z0-9_-]{43}$`)
+	seen := map[string]bool{}
+	for i := 0; i < 100; i++ {
+		token, err := utils.GenerateSecureToken(32)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !urlSafe.MatchString(token) {
+			t.Fatalf("token %q is not 43 URL-safe characters", token)
+		}
+		if seen[token] {
+			t.Fatalf("token %q generated twice", token)
+		}
+		seen[token] = true
+	}
+}
+
+func TestHashOTP(t *testing.T) {
+	cfg := &config.Config{JWTSecret: "secret"}
+	hash := utils.HashOTP(cfg, "+919876543210", "123456")
+	for name, other := range map[string]string{
+		"other code":   utils.HashOTP(cfg, "+919876543210", "123457"),
+		"other phone":  utils.HashOTP(cfg, "+919876543211", "123456"),
+		"other secret": utils.HashOTP(&config.Config{JWTSecret: "other"}, "+919876543210", "123456"),
+	} {
+		if other == hash {
+			t.Errorf("%s hashes alike", name)
+		}
+	}
+	if code, err := utils.GenerateNumericCode(6); err != nil || !regexp.MustCompile(`^\d{6}$`).MatchString(code) {
+		t.Errorf("GenerateNumericCode(6) = %q, %v", code, err)
+	}
+}
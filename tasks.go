@@ -0,0 +1,324 @@
+package main
+
+import (
+	"backend_pandhi/pkg/app"
+	"backend_pandhi/pkg/config"
+	"backend_pandhi/pkg/database"
+	"backend_pandhi/pkg/jobs"
+	"backend_pandhi/pkg/maintenance"
+	"backend_pandhi/pkg/models"
+	"backend_pandhi/pkg/notifications"
+	"backend_pandhi/pkg/seed"
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+const tasksUsage = `Maintenance commands:
+  create-superadmin -email E -name N     create a verified superadmin
+  verify-admin -email E -outlets 1,2     verify an admin and link its outlets
+  verify-staff -email E -outlet ID [-role R]
+                                         verify a staff member at an outlet
+  reset-password -email E [-admin]       set the password of a user, or of an
+                                         admin with -admin
+  recompute-ratings [-product ID]        recalculate product rating aggregates
+  reconcile-wallets [-fix]               list wallets whose balance differs from
+                                         their transactions; -fix corrects them
+  dispatch-notifications                 send due scheduled notifications once
+  run-jobs                               run due background jobs until none is left
+  seed [-namespace N] [-seed S] [-scale X] [-months M] [-drop]
+                                         replace the demo data set N (default
+                                         demo); -drop only deletes it. Not
+                                         available in production
+
+Passwords are read from the first line of standard input.
+
+Exit codes: 0 done, 1 failed, 2 bad usage, 3 wallets out of balance,
+4 account or record not found, 5 already done
+`
+
+// taskFunc runs a maintenance command and returns its exit code
+type taskFunc func(ctx context.Context, a *app.App) int
+
+// task defines the flags of a command on fs and returns the command to run
+// once they are parsed. The required flags are checked before the database
+// is opened.
+type task struct {
+	define   func(fs *flag.FlagSet) taskFunc
+	required []string
+}
+
+var tasks = map[string]task{
+	"create-superadmin":      {createSuperAdminTask, []string{"email", "name"}},
+	"verify-admin":           {verifyAdminTask, []string{"email", "outlets"}},
+	"verify-staff":           {verifyStaffTask, []string{"email", "outlet"}},
+	"reset-password":         {resetPasswordTask, []string{"email"}},
+	"recompute-ratings":      {recomputeRatingsTask, nil},
+	"reconcile-wallets":      {reconcileWalletsTask, nil},
+	"dispatch-notifications": {dispatchNotificationsTask, nil},
+	"run-jobs":               {runJobsTask, nil},
+	"seed":                   {seedTask, nil},
+}
+
+// runTask implements the maintenance commands and returns the process exit
+// code
+func runTask(cfg *config.Config, command string, args []string) int {
+	fs := flag.NewFlagSet(command, flag.ContinueOnError)
+	fs.Usage = func() { fmt.Fprint(os.Stderr, tasksUsage) }
+	t := tasks[command]
+	run := t.define(fs)
+	// Parse reports its own errors
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return exitUsage
+	}
+	for _, name := range t.required {
+		if f := fs.Lookup(name); f.Value.String() == f.DefValue {
+			fmt.Fprintf(os.Stderr, "flag is required: -%s\n", name)
+			fs.Usage()
+			return exitUsage
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	db, err := database.Open(cfg)
+	if err != nil {
+		slog.Error("Failed to initialize database", "error", err)
+		return exitFailure
+	}
+	defer database.Close(db)
+
+	return run(ctx, newApp(ctx, cfg, db))
+}
+
+// taskFailed reports err and maps it to an exit code
+func taskFailed(err error) int {
+	fmt.Fprintln(os.Stderr, "Error:", err)
+	switch {
+	case errors.Is(err, maintenance.ErrNotFound):
+		return exitNotFound
+	case errors.Is(err, maintenance.ErrConflict):
+		return exitConflict
+	}
+	return exitFailure
+}
+
+// readPassword reads the first line of standard input
+func readPassword() (string, error) {
+	if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		fmt.Fprint(os.Stderr, "Password: ")
+	}
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if line == "" {
+		return "", errors.New("no password on standard input")
+	}
+	return line, nil
+}
+
+func createSuperAdminTask(fs *flag.FlagSet) taskFunc {
+	email := fs.String("email", "", "email address")
+	name := fs.String("name", "", "display name")
+
+	return func(ctx context.Context, a *app.App) int {
+		password, err := readPassword()
+		if err != nil {
+			return taskFailed(err)
+		}
+		user, err := maintenance.CreateSuperAdmin(ctx, a, *name, *email, password)
+		if err != nil {
+			return taskFailed(err)
+		}
+		fmt.Printf("Created superadmin %d (%s)\n", user.ID, user.Email)
+		return exitOK
+	}
+}
+
+func verifyAdminTask(fs *flag.FlagSet) taskFunc {
+	email := fs.String("email", "", "admin email address")
+	var outletIDs idList
+	fs.Var(&outletIDs, "outlets", "comma-separated outlet IDs")
+
+	return func(ctx context.Context, a *app.App) int {
+		admin, err := maintenance.VerifyAdmin(ctx, a, *email, outletIDs)
+		if err != nil {
+			return taskFailed(err)
+		}
+		fmt.Printf("Verified admin %d (%s) for outlets %s\n", admin.ID, admin.Email, outletIDs.String())
+		return exitOK
+	}
+}
+
+func verifyStaffTask(fs *flag.FlagSet) taskFunc {
+	email := fs.String("email", "", "staff email address")
+	outletID := fs.Int("outlet", 0, "outlet ID")
+	role := fs.String("role", "Staff", "staff role")
+
+	return func(ctx context.Context, a *app.App) int {
+		user, err := maintenance.VerifyStaff(ctx, a, *email, *outletID, *role)
+		if err != nil {
+			return taskFailed(err)
+		}
+		fmt.Printf("Verified staff %d (%s) at outlet %d\n", user.ID, user.Email, *outletID)
+		return exitOK
+	}
+}
+
+func resetPasswordTask(fs *flag.FlagSet) taskFunc {
+	email := fs.String("email", "", "email address")
+	admin := fs.Bool("admin", false, "reset an admin account instead of a user")
+
+	return func(ctx context.Context, a *app.App) int {
+		accountType := models.AuthAccountTypeUser
+		if *admin {
+			accountType = models.AuthAccountTypeAdmin
+		}
+		password, err := readPassword()
+		if err != nil {
+			return taskFailed(err)
+		}
+		if err := maintenance.ResetPassword(ctx, a, accountType, *email, password); err != nil {
+			return taskFailed(err)
+		}
+		fmt.Printf("Password reset for %s\n", *email)
+		return exitOK
+	}
+}
+
+func recomputeRatingsTask(fs *flag.FlagSet) taskFunc {
+	productID := fs.Int("product", 0, "product ID; every product when omitted")
+
+	return func(ctx context.Context, a *app.App) int {
+		var productIDs []int
+		if *productID > 0 {
+			productIDs = append(productIDs, *productID)
+		}
+		n, err := maintenance.RecomputeRatings(ctx, a, productIDs...)
+		if err != nil {
+			return taskFailed(err)
+		}
+		fmt.Printf("Recomputed ratings of %d product(s)\n", n)
+		return exitOK
+	}
+}
+
+func reconcileWalletsTask(fs *flag.FlagSet) taskFunc {
+	fix := fs.Bool("fix", false, "set each balance to its transaction total")
+
+	return func(ctx context.Context, a *app.App) int {
+		drifts, err := maintenance.ReconcileWallets(ctx, a, *fix)
+		if err != nil {
+			return taskFailed(err)
+		}
+		for _, d := range drifts {
+			fmt.Printf("wallet %d: balance %.2f, transactions %.2f\n", d.WalletID, d.Balance, d.Ledger)
+		}
+		switch {
+		case len(drifts) == 0:
+			fmt.Println("All wallets balance")
+		case *fix:
+			fmt.Printf("Corrected %d wallet(s)\n", len(drifts))
+		default:
+			return exitPending
+		}
+		return exitOK
+	}
+}
+
+func dispatchNotificationsTask(fs *flag.FlagSet) taskFunc {
+	return func(ctx context.Context, a *app.App) int {
+		sent, err := notifications.DispatchDue(ctx, a)
+		if err != nil {
+			return taskFailed(err)
+		}
+		fmt.Printf("Sent %d notification(s)\n", sent)
+		return exitOK
+	}
+}
+
+func runJobsTask(fs *flag.FlagSet) taskFunc {
+	return func(ctx context.Context, a *app.App) int {
+		ran, err := jobs.RunDue(ctx, a)
+		if err != nil {
+			return taskFailed(err)
+		}
+		fmt.Printf("Ran %d job(s)\n", ran)
+		return exitOK
+	}
+}
+
+func seedTask(fs *flag.FlagSet) taskFunc {
+	var opts seed.Options
+	fs.StringVar(&opts.Namespace, "namespace", "demo", "name of the data set to replace")
+	fs.Int64Var(&opts.Seed, "seed", 1, "seed of the random choices")
+	fs.IntVar(&opts.Scale, "scale", 1, "multiplier of outlets, customers and orders")
+	fs.IntVar(&opts.Months, "months", 3, "months of order history")
+	drop := fs.Bool("drop", false, "only delete the data set")
+
+	return func(ctx context.Context, a *app.App) int {
+		if a.Config.IsProduction() {
+			fmt.Fprintln(os.Stderr, "Error: refusing to seed demo data in production")
+			return exitFailure
+		}
+		if *drop {
+			if err := seed.Reset(ctx, a, opts.Namespace); err != nil {
+				return taskFailed(err)
+			}
+			fmt.Printf("Deleted the %q data set\n", opts.Namespace)
+			return exitOK
+		}
+		s, err := seed.Run(ctx, a, opts)
+		if err != nil {
+			return taskFailed(err)
+		}
+		fmt.Printf("Seeded the %q data set: %d outlet(s), %d product(s), %d staff, %d admin(s), %d customer(s)\n",
+			opts.Namespace, s.Outlets, s.Products, s.Staff, s.Admins, s.Customers)
+		fmt.Printf("%d order(s) with %d item(s), %d wallet transaction(s), %d feedback, %d ticket(s), %d expense(s)\n",
+			s.Orders, s.OrderItems, s.WalletTransactions, s.Feedback, s.Tickets, s.Expenses)
+		fmt.Printf("Accounts use emails under %s and the password %q\n", opts.Namespace+".seed.example.com", seed.Password)
+		return exitOK
+	}
+}
+
+// idList is a flag holding comma-separated positive IDs
+type idList []int
+
+func (l *idList) String() string {
+	ids := make([]string, len(*l))
+	for i, id := range *l {
+		ids[i] = strconv.Itoa(id)
+	}
+	return strings.Join(ids, ",")
+}
+
+func (l *idList) Set(value string) error {
+	*l = nil
+	for _, s := range strings.Split(value, ",") {
+		if s = strings.TrimSpace(s); s == "" {
+			continue
+		}
+		id, err := strconv.Atoi(s)
+		if err != nil || id < 1 {
+			return fmt.Errorf("invalid ID %q", s)
+		}
+		*l = append(*l, id)
+	}
+	return nil
+}